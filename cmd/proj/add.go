@@ -58,6 +58,9 @@ func runAdd(ctx context.Context, logger *slog.Logger, cfg *config.Config, args [
 	if err != nil {
 		return fmt.Errorf("failed to parse project name: %w", err)
 	}
+	if err := checkWritable(cfg, "link "+p.String()); err != nil {
+		return err
+	}
 
 	// Check if current directory is already inside the project root
 	relPath, err := filepath.Rel(cfg.RootDir, currentDir)
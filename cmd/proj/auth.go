@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/auth"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+)
+
+// resolveToken returns the GitHub token of a command: its --token flag when
+// set, otherwise the token resolved by the auth manager.
+func resolveToken(ctx context.Context, logger *slog.Logger, cfg *config.Config, flagToken string) string {
+	token, source := auth.NewManager(logger, cfg.GitHubToken).Token(ctx, auth.DefaultHost, flagToken)
+	logger.Debug("resolved github token", "source", source)
+	return token
+}
+
+// useGHCLI reports whether the GitHub API requests of a command are run with
+// 'gh api': github.api is gh and its --token flag is not set.
+func useGHCLI(cfg *config.Config, flagToken string) bool {
+	return cfg.GitHubAPI == "gh" && flagToken == ""
+}
+
+// newGitHubClient returns the GitHub client of a command, running its
+// requests with 'gh api' or authenticated with the resolved token.
+func newGitHubClient(ctx context.Context, logger *slog.Logger, cfg *config.Config, flagToken string) *github.Client {
+	if useGHCLI(cfg, flagToken) {
+		logger.Debug("using gh for github api requests")
+		return github.NewClient(logger, "").WithGHCLI()
+	}
+	return github.NewClient(logger, resolveToken(ctx, logger, cfg, flagToken))
+}
+
+// checkGitHubProject returns an error for the projects of other Git servers
+// than GitHub, whose pull requests and issues the GitHub API doesn't know.
+func checkGitHubProject(proj *projects.Project) error {
+	if proj.Host != "" {
+		return fmt.Errorf("%s is not a GitHub project: only GitHub pull requests and issues are supported", proj.String())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type backportConfig struct {
+	Project string
+	Base    string
+}
+
+func newBackportCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	backportCfg := &backportConfig{}
+	fs := ff.NewFlagSet("backport")
+	fs.StringVar(&backportCfg.Project, 'p', "project", "", "project of the change (default: the current project)")
+	fs.StringVar(&backportCfg.Base, 0, "base", "", "branch a pull request was opened against (default: the default branch)")
+
+	return &ff.Command{
+		Name:      "backport",
+		Usage:     "proj backport [flags] <commit|#pr> <branch>...",
+		ShortHelp: "Cherry-pick a change onto release branches, one workspace each",
+		LongHelp: `Backport a commit or a pull request to each target branch: a workspace is
+added on the backport-<change>-<branch> branch, created from the target
+branch, and the change is cherry-picked into it with -x.
+
+The commits of a pull request (#123) are the ones of its head that are not in
+the default branch, or --base. A merge commit is picked against its first
+parent.
+
+Targets are processed in turn, and a failure doesn't stop the others. On
+conflicts, the cherry-pick is left in progress in the workspace of the
+target, to resolve the conflicts there and run 'git cherry-pick --continue'.
+
+Examples:
+  proj backport '#123' release-1.2 release-1.3
+  proj backport -p gfanton/projects 1a2b3c4 release-1.2`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 2 {
+				return errors.New("a change and at least one target branch are required")
+			}
+
+			return runBackport(ctx, os.Stdout, projectsCfg, projectsLogger, args[0], args[1:], *backportCfg)
+		},
+	}
+}
+
+func runBackport(ctx context.Context, w io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, change string, targets []string, backportCfg backportConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, backportCfg.Project)
+	if err != nil {
+		return err
+	}
+
+	base := backportCfg.Base
+	if base == "" {
+		if base, err = projects.NewProjectService(projectsCfg, projectsLogger).DefaultBranch(ctx, proj); err != nil {
+			return err
+		}
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	commits, name, err := svc.BackportCommits(ctx, *proj, change, base)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Backporting %s (%s) of %s\n", change, plural(len(commits), "commit"), proj.String())
+
+	var failed []string
+	for i, target := range targets {
+		if err := ctx.Err(); err != nil {
+			return stoppedError(err, len(targets)-i, len(targets), "branches")
+		}
+
+		branch := "backport-" + name + "-" + target
+		fmt.Fprintf(w, "[%d/%d] %s\n", i+1, len(targets), target)
+
+		err := svc.Backport(ctx, *proj, commits, branch, target)
+		var conflict *projects.BackportConflictError
+		switch {
+		case errors.As(err, &conflict):
+			fmt.Fprintf(w, "  conflicts in %s\n", strings.Join(conflict.Files, ", "))
+			fmt.Fprintf(w, "  resolve them with: cd %s && git cherry-pick --continue\n", conflict.Path)
+			failed = append(failed, target)
+		case err != nil:
+			fmt.Fprintf(w, "  failed: %v\n", err)
+			failed = append(failed, target)
+		default:
+			fmt.Fprintf(w, "  backported on %s in %s\n", branch, svc.WorkspacePath(*proj, branch))
+		}
+	}
+
+	fmt.Fprintf(w, "Backported to %d of %d branches\n", len(targets)-len(failed), len(targets))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to backport to %d of %d branches: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type cleanBranchesConfig struct {
+	All    bool
+	DryRun bool
+	Yes    bool
+}
+
+// mergedBranches are the merged branches of a project.
+type mergedBranches struct {
+	Project  *projects.Project
+	Branches []string
+}
+
+func newCleanBranchesCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	cleanCfg := &cleanBranchesConfig{}
+	fs := ff.NewFlagSet("clean-branches")
+	fs.BoolVar(&cleanCfg.All, 0, "all", "clean the branches of every project of the root directory")
+	fs.BoolVar(&cleanCfg.DryRun, 'n', "dry-run", "only list the merged branches")
+	fs.BoolVar(&cleanCfg.Yes, 'y', "yes", "delete without asking for confirmation")
+
+	return &ff.Command{
+		Name:      "clean-branches",
+		Usage:     "proj clean-branches [flags] [project...]",
+		ShortHelp: "Delete local branches merged into the default branch",
+		LongHelp: `Delete the local branches already merged into the default branch of the
+projects, keeping long-lived clones tidy.
+
+The default branch itself and the branches checked out in a worktree, such as
+the ones backing workspaces, are kept. The default branch is compared locally,
+or on origin when it has no local branch: run 'git fetch' first to catch the
+branches merged upstream.
+
+If no project is given, the current directory must be inside a project; with
+--all, every project of the root directory is cleaned.
+
+Examples:
+  proj clean-branches --dry-run
+  proj clean-branches gfanton/projects
+  proj clean-branches --all --yes`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projs []*projects.Project
+			var err error
+			if cleanCfg.All {
+				if len(args) > 0 {
+					return errors.New("--all takes no project arguments")
+				}
+				projs, err = projects.NewProjectService(projectsCfg, projectsLogger).ListProjects()
+				if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+					return fmt.Errorf("failed to list projects: %w", err)
+				}
+			} else if projs, err = resolveProjects(projectsCfg, projectsLogger, args); err != nil {
+				return err
+			}
+
+			return runCleanBranches(ctx, os.Stdin, os.Stdout, projectsCfg, projectsLogger, projs, *cleanCfg)
+		},
+	}
+}
+
+func runCleanBranches(ctx context.Context, in io.Reader, out io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, projs []*projects.Project, cleanCfg cleanBranchesConfig) error {
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
+	var merged []mergedBranches
+	var stopped error
+	total := 0
+	for i, proj := range projs {
+		if err := ctx.Err(); err != nil {
+			stopped = stoppedError(err, len(projs)-i, len(projs), "projects")
+			break
+		}
+		if !proj.IsGitRepository() && !proj.IsBare() {
+			continue
+		}
+
+		base, err := projectSvc.DefaultBranch(ctx, proj)
+		if err != nil {
+			projectsLogger.Warn("failed to get default branch", "project", proj.String(), "error", err)
+			continue
+		}
+		branches, err := svc.MergedBranches(ctx, *proj, base)
+		if err != nil {
+			projectsLogger.Warn("failed to list merged branches", "project", proj.String(), "error", err)
+			continue
+		}
+		if len(branches) > 0 {
+			merged = append(merged, mergedBranches{Project: proj, Branches: branches})
+			total += len(branches)
+		}
+	}
+
+	if total == 0 {
+		fmt.Fprintln(out, "No merged branches")
+		return stopped
+	}
+
+	for _, m := range merged {
+		fmt.Fprintf(out, "%s:\n", m.Project.String())
+		for _, branch := range m.Branches {
+			fmt.Fprintf(out, "  %s\n", branch)
+		}
+	}
+	if cleanCfg.DryRun || stopped != nil {
+		return stopped
+	}
+	if err := projectsCfg.CheckWritable("delete merged branches"); err != nil {
+		return err
+	}
+
+	if !cleanCfg.Yes {
+		p := &prompter{in: bufio.NewReader(in), out: out}
+		ok, err := p.confirm(fmt.Sprintf("Delete these %d merged branches?", total), false)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	deleted := 0
+	for _, m := range merged {
+		for _, branch := range m.Branches {
+			if err := ctx.Err(); err != nil {
+				fmt.Fprintf(out, "Deleted %d merged branches\n", deleted)
+				return stoppedError(err, total-deleted, total, "merged branches")
+			}
+			if err := svc.DeleteBranch(ctx, *m.Project, branch); err != nil {
+				fmt.Fprintf(out, "  %v\n", err)
+				continue
+			}
+			deleted++
+		}
+	}
+	fmt.Fprintf(out, "Deleted %d merged branches\n", deleted)
+	return nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+)
+
+// colorRole is a kind of text highlighted in terminal output, whose color is
+// set by the color-theme configuration.
+type colorRole string
+
+const (
+	roleOrg     colorRole = "org"
+	roleProject colorRole = "project"
+	roleBranch  colorRole = "branch"
+	roleMatch   colorRole = "match"
+	roleClean   colorRole = "clean"
+	roleDirty   colorRole = "dirty"
+	roleWarn    colorRole = "warn"
+	roleDim     colorRole = "dim"
+)
+
+// defaultTheme holds the SGR parameters of each role.
+var defaultTheme = map[colorRole]string{
+	roleOrg:     "36",   // cyan
+	roleProject: "1",    // bold
+	roleBranch:  "35",   // magenta
+	roleMatch:   "1;33", // bold yellow
+	roleClean:   "32",   // green
+	roleDirty:   "31",   // red
+	roleWarn:    "33",   // yellow
+	roleDim:     "2",
+}
+
+// colorNames maps the names accepted in color-theme to SGR parameters.
+var colorNames = map[string]string{
+	"none":      "",
+	"bold":      "1",
+	"dim":       "2",
+	"italic":    "3",
+	"underline": "4",
+	"black":     "30",
+	"red":       "31",
+	"green":     "32",
+	"yellow":    "33",
+	"blue":      "34",
+	"magenta":   "35",
+	"cyan":      "36",
+	"white":     "37",
+	"gray":      "90",
+}
+
+// colors paints text with the SGR sequences of a theme, or leaves it as is
+// when colors are disabled. A nil *colors paints nothing.
+type colors struct {
+	enabled bool
+	theme   map[colorRole]string
+}
+
+// newColors returns the colors of output written to f, enabled according to
+// the color configuration: always, never, or auto to only color terminals
+// when NO_COLOR is not set.
+func newColors(cfg *config.Config, f *os.File) (*colors, error) {
+	theme, err := parseColorTheme(cfg.ColorTheme)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color-theme: %w", err)
+	}
+	return &colors{enabled: colorEnabled(cfg.Color, f), theme: theme}, nil
+}
+
+func colorEnabled(mode string, f *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && isTerminal(f)
+}
+
+// parseColorTheme returns the default theme overridden by s, a comma
+// separated list of role=color, each color being names joined by "+", such
+// as "org=blue,match=red+underline", or raw SGR parameters like "38;5;208".
+func parseColorTheme(s string) (map[colorRole]string, error) {
+	theme := make(map[colorRole]string, len(defaultTheme))
+	for role, sgr := range defaultTheme {
+		theme[role] = sgr
+	}
+
+	for _, pair := range splitList(s) {
+		name, spec, ok := strings.Cut(pair, "=")
+		role := colorRole(strings.TrimSpace(name))
+		if _, known := defaultTheme[role]; !ok || !known {
+			return nil, fmt.Errorf("invalid color '%s' (expected role=color, roles: org, project, branch, match, clean, dirty, warn, dim)", pair)
+		}
+
+		var params []string
+		for _, token := range strings.Split(strings.TrimSpace(spec), "+") {
+			token = strings.TrimSpace(token)
+			if sgr, ok := colorNames[token]; ok {
+				if sgr != "" {
+					params = append(params, sgr)
+				}
+				continue
+			}
+			if token == "" || strings.Trim(token, "0123456789;") != "" {
+				return nil, fmt.Errorf("unknown color '%s' of %s", token, role)
+			}
+			params = append(params, token)
+		}
+		theme[role] = strings.Join(params, ";")
+	}
+	return theme, nil
+}
+
+// paint returns s in the color of role.
+func (c *colors) paint(role colorRole, s string) string {
+	if c == nil || !c.enabled || s == "" || c.theme[role] == "" {
+		return s
+	}
+	return "\x1b[" + c.theme[role] + "m" + s + "\x1b[0m"
+}
+
+// project returns the name of p with its org apart from its name, like
+// p.String().
+func (c *colors) project(p *projects.Project) string {
+	org := orgPrefix(p)
+	return c.paint(roleOrg, org) + "/" + c.paint(roleProject, strings.TrimPrefix(p.String(), org+"/"))
+}
+
+// gitStatus returns status in the color of its state.
+func (c *colors) gitStatus(status projects.GitStatus) string {
+	switch status {
+	case projects.GitStatusValid:
+		return c.paint(roleClean, string(status))
+	case projects.GitStatusInvalid:
+		return c.paint(roleDirty, string(status))
+	default:
+		return c.paint(roleDim, string(status))
+	}
+}
+
+// orgPrefix returns the part of the name of p before its project name: its
+// org, with its host if any.
+func orgPrefix(p *projects.Project) string {
+	if p.Host != "" {
+		return p.Host + "/" + p.Organisation
+	}
+	return p.Organisation
+}
+
+// result returns label, the formatted name of result, with its org,
+// project and workspace or subpath apart, and the characters matching query
+// highlighted. Absolute paths only get the matches highlighted.
+func (c *colors) result(label string, result *projects.SearchResult, query string, absPath bool) string {
+	if c == nil || !c.enabled {
+		return label
+	}
+
+	roleAt := func(int) colorRole { return "" }
+	if !absPath {
+		orgEnd := 0
+		if org := orgPrefix(result.Project); strings.HasPrefix(label, org+"/") {
+			orgEnd = len(org)
+		}
+		nameEnd := len(label)
+		switch {
+		case result.Workspace != "":
+			if i := strings.Index(label[orgEnd:], ":"); i >= 0 {
+				nameEnd = orgEnd + i
+			}
+		case result.Subpath != "":
+			if i := strings.Index(label[orgEnd:], "//"); i >= 0 {
+				nameEnd = orgEnd + i
+			}
+		}
+		roleAt = func(i int) colorRole {
+			switch {
+			case i < orgEnd:
+				return roleOrg
+			case i == orgEnd && orgEnd > 0:
+				return "" // separator of the org and the project
+			case i < nameEnd:
+				return roleProject
+			}
+			return roleBranch
+		}
+	}
+
+	matched := matchPositions(label, query)
+	var b strings.Builder
+	runStart, runRole := 0, colorRole("")
+	for i := range label {
+		role := roleAt(i)
+		if matched[i] {
+			role = roleMatch
+		}
+		if role != runRole {
+			b.WriteString(c.paint(runRole, label[runStart:i]))
+			runStart, runRole = i, role
+		}
+	}
+	b.WriteString(c.paint(runRole, label[runStart:]))
+	return b.String()
+}
+
+// matchPositions returns the byte offsets of the characters of label
+// matching query, ignoring case: the first occurrence of query, or else its
+// characters in order, or nothing when label doesn't contain them all.
+func matchPositions(label, query string) map[int]bool {
+	lower, query := strings.ToLower(label), strings.ToLower(strings.TrimSpace(query))
+	if query == "" || len(lower) != len(label) {
+		return nil
+	}
+
+	positions := make(map[int]bool)
+	if i := strings.Index(lower, query); i >= 0 {
+		for j := range query {
+			positions[i+j] = true
+		}
+		return positions
+	}
+
+	i := 0
+	for _, r := range query {
+		if r == ' ' {
+			continue
+		}
+		j := strings.IndexRune(lower[i:], r)
+		if j < 0 {
+			return nil
+		}
+		positions[i+j] = true
+		i += j + utf8.RuneLen(r)
+	}
+	return positions
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestParseColorTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   string
+		role    colorRole
+		want    string
+		wantErr bool
+	}{
+		{name: "default", role: roleOrg, want: "36"},
+		{name: "name", theme: "org=blue", role: roleOrg, want: "34"},
+		{name: "names", theme: "match=red+underline", role: roleMatch, want: "31;4"},
+		{name: "sgr", theme: "branch=38;5;208", role: roleBranch, want: "38;5;208"},
+		{name: "none", theme: "dim=none", role: roleDim, want: ""},
+		{name: "others kept", theme: "org=blue", role: roleBranch, want: "35"},
+		{name: "unknown role", theme: "path=blue", wantErr: true},
+		{name: "unknown color", theme: "org=teal", wantErr: true},
+		{name: "missing color", theme: "org", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme, err := parseColorTheme(tt.theme)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseColorTheme(%q) error = %v, wantErr %v", tt.theme, err, tt.wantErr)
+			}
+			if !tt.wantErr && theme[tt.role] != tt.want {
+				t.Errorf("parseColorTheme(%q)[%s] = %q, want %q", tt.theme, tt.role, theme[tt.role], tt.want)
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if colorEnabled("auto", os.Stdout) {
+		t.Error("colorEnabled(auto) with NO_COLOR = true, want false")
+	}
+	if !colorEnabled("always", os.Stdout) {
+		t.Error("colorEnabled(always) = false, want true")
+	}
+	if colorEnabled("never", os.Stdout) {
+		t.Error("colorEnabled(never) = true, want false")
+	}
+}
+
+func TestColorsResult(t *testing.T) {
+	theme, err := parseColorTheme("org=1,project=2,branch=3,match=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &colors{enabled: true, theme: theme}
+	p := &projects.Project{Organisation: "gfanton", Name: "projects"}
+
+	tests := []struct {
+		name   string
+		label  string
+		result *projects.SearchResult
+		query  string
+		want   string
+	}{
+		{
+			name:   "substring",
+			label:  "gfanton/projects",
+			result: &projects.SearchResult{Project: p},
+			query:  "proj",
+			want:   "\x1b[1mgfanton\x1b[0m/\x1b[4mproj\x1b[0m\x1b[2mects\x1b[0m",
+		},
+		{
+			name:   "fuzzy",
+			label:  "gfanton/projects",
+			result: &projects.SearchResult{Project: p},
+			query:  "gfps",
+			want:   "\x1b[4mgf\x1b[0m\x1b[1manton\x1b[0m/\x1b[4mp\x1b[0m\x1b[2mroject\x1b[0m\x1b[4ms\x1b[0m",
+		},
+		{
+			name:   "workspace",
+			label:  "gfanton/projects:main",
+			result: &projects.SearchResult{Project: p, Workspace: "main"},
+			query:  "xyz",
+			want:   "\x1b[1mgfanton\x1b[0m/\x1b[2mprojects\x1b[0m\x1b[3m:main\x1b[0m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.result(tt.label, tt.result, tt.query, false); got != tt.want {
+				t.Errorf("result() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := (&colors{theme: theme}).result("gfanton/projects", &projects.SearchResult{Project: p}, "proj", false); got != "gfanton/projects" {
+		t.Errorf("result() with colors disabled = %q, want the label", got)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// completionShells are the shells supported by 'proj completion'.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func newCompletionCommand(root *ff.Command, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "completion",
+		Usage:     "proj completion <bash|zsh|fish>",
+		ShortHelp: "Generate shell completion script",
+		LongHelp: `Generate the completion script of proj's subcommands and flags for the
+specified shell, without the navigation command and helpers of 'proj init'.
+
+Supported shells:
+  bash      Source the script, or install it in bash-completion's directory
+  zsh       Source the script, or install it as _proj in a directory of $fpath
+  fish      Install it as ~/.config/fish/completions/proj.fish
+
+Commands:
+  cache     Manage the caches of completions
+
+Examples:
+  source <(proj completion bash)
+  proj completion zsh > "${fpath[1]}/_proj"
+  proj completion fish > ~/.config/fish/completions/proj.fish`,
+		Subcommands: []*ff.Command{
+			newCompletionCacheCommand(logger, cfg, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("exactly one shell argument required")
+			}
+			return writeCompletion(os.Stdout, args[0], completionCommands(root))
+		},
+	}
+}
+
+// completionCommand is a command of the completion scripts, identified by
+// the path of subcommand names leading to it ("" for the root).
+type completionCommand struct {
+	path     string
+	commands []completionEntry
+	flags    []completionFlag
+}
+
+// completionEntry is a subcommand and its short help.
+type completionEntry struct {
+	name string
+	help string
+}
+
+// completionFlag is a flag of a command.
+type completionFlag struct {
+	short rune
+	long  string
+	usage string
+	value bool // takes a value
+}
+
+// names returns the names of the flag, as typed on the command line.
+func (f completionFlag) names() []string {
+	var names []string
+	if f.long != "" {
+		names = append(names, "--"+f.long)
+	}
+	if f.short != 0 {
+		names = append(names, "-"+string(f.short))
+	}
+	return names
+}
+
+// completionCommands returns the commands of the tree of root, depth first.
+func completionCommands(root *ff.Command) []completionCommand {
+	var commands []completionCommand
+	var walk func(cmd *ff.Command, path string)
+	walk = func(cmd *ff.Command, path string) {
+		c := completionCommand{path: path}
+		for _, sub := range cmd.Subcommands {
+			c.commands = append(c.commands, completionEntry{name: sub.Name, help: sub.ShortHelp})
+		}
+		if cmd.Flags != nil {
+			cmd.Flags.WalkFlags(func(f ff.Flag) error {
+				c.flags = append(c.flags, newCompletionFlag(f))
+				return nil
+			})
+		}
+		commands = append(commands, c)
+
+		for _, sub := range cmd.Subcommands {
+			walk(sub, strings.TrimSpace(path+" "+sub.Name))
+		}
+	}
+	walk(root, "")
+	return commands
+}
+
+// newCompletionFlag returns the completion of flag f.
+func newCompletionFlag(f ff.Flag) completionFlag {
+	short, _ := f.GetShortName()
+	long, _ := f.GetLongName()
+
+	// Boolean flags have no placeholder
+	value := f.GetPlaceholder() != ""
+	if b, ok := f.(interface{ IsBoolFlag() bool }); ok {
+		value = !b.IsBoolFlag()
+	}
+
+	return completionFlag{short: short, long: long, usage: f.GetUsage(), value: value}
+}
+
+// writeCompletion writes the completion script of commands for shell.
+func writeCompletion(w io.Writer, shell string, commands []completionCommand) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion(commands)
+	case "zsh":
+		script = zshCompletion(commands)
+	case "fish":
+		script = fishCompletion(commands)
+	default:
+		return fmt.Errorf("unsupported shell: %s (expected %s)", shell, strings.Join(completionShells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// valueFlags returns the names of the flags of commands taking a value, so
+// that the scripts skip their values when looking for subcommands.
+func valueFlags(commands []completionCommand) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range commands {
+		for _, f := range c.flags {
+			if !f.value {
+				continue
+			}
+			for _, name := range f.names() {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// commandPaths returns the paths of the subcommands of commands.
+func commandPaths(commands []completionCommand) []string {
+	var paths []string
+	for _, c := range commands {
+		if c.path != "" {
+			paths = append(paths, c.path)
+		}
+	}
+	return paths
+}
+
+// fishQuote returns s single-quoted for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func bashCompletion(commands []completionCommand) string {
+	var sb strings.Builder
+	sb.WriteString(`# bash completion for proj, generated by 'proj completion bash'
+
+_proj() {
+    local cur=${COMP_WORDS[COMP_CWORD]} cmdpath="" next word i skip=0
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        word=${COMP_WORDS[i]}
+        if ((skip)); then
+            skip=0
+            continue
+        fi
+        case $word in
+`)
+	if names := valueFlags(commands); len(names) > 0 {
+		fmt.Fprintf(&sb, "            %s) skip=1 ;;\n", strings.Join(names, "|"))
+	}
+	sb.WriteString(`            -*) ;;
+            *)
+                next=${cmdpath:+$cmdpath }$word
+                case $next in
+`)
+	var quoted []string
+	for _, path := range commandPaths(commands) {
+		quoted = append(quoted, shellQuote(path))
+	}
+	if len(quoted) > 0 {
+		fmt.Fprintf(&sb, "                    %s) cmdpath=$next ;;\n", strings.Join(quoted, "|"))
+	}
+	sb.WriteString(`                esac
+                ;;
+        esac
+    done
+
+    local commands="" flags=""
+    case $cmdpath in
+`)
+	for _, c := range commands {
+		var names, flags []string
+		for _, sub := range c.commands {
+			names = append(names, sub.name)
+		}
+		for _, f := range c.flags {
+			flags = append(flags, f.names()...)
+		}
+		fmt.Fprintf(&sb, "        %s)\n", shellQuote(c.path))
+		fmt.Fprintf(&sb, "            commands=%s\n", shellQuote(strings.Join(names, " ")))
+		fmt.Fprintf(&sb, "            flags=%s\n", shellQuote(strings.Join(flags, " ")))
+		sb.WriteString("            ;;\n")
+	}
+	sb.WriteString(`    esac
+
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+    elif [[ -n $commands ]]; then
+        COMPREPLY=($(compgen -W "$commands" -- "$cur"))
+    fi
+}
+
+complete -o default -F _proj proj
+`)
+	return sb.String()
+}
+
+func zshCompletion(commands []completionCommand) string {
+	// describe escapes the separator of _describe entries
+	describe := func(name, help string) string {
+		return shellQuote(name + ":" + strings.ReplaceAll(help, ":", `\:`))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`#compdef proj
+# zsh completion for proj, generated by 'proj completion zsh'
+
+_proj() {
+    local cmdpath="" next word i skip=0
+    local -a paths=(
+`)
+	for _, path := range commandPaths(commands) {
+		fmt.Fprintf(&sb, "        %s\n", shellQuote(path))
+	}
+	sb.WriteString(`    )
+    for ((i = 2; i < CURRENT; i++)); do
+        word=${words[i]}
+        if ((skip)); then
+            skip=0
+            continue
+        fi
+        case $word in
+`)
+	if names := valueFlags(commands); len(names) > 0 {
+		fmt.Fprintf(&sb, "            %s) skip=1 ;;\n", strings.Join(names, "|"))
+	}
+	sb.WriteString(`            -*) ;;
+            *)
+                next=${cmdpath:+$cmdpath }$word
+                if ((${paths[(Ie)$next]})); then
+                    cmdpath=$next
+                fi
+                ;;
+        esac
+    done
+
+    local -a commands flags
+    case $cmdpath in
+`)
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "        %s)\n", shellQuote(c.path))
+		if len(c.commands) > 0 {
+			sb.WriteString("            commands=(\n")
+			for _, sub := range c.commands {
+				fmt.Fprintf(&sb, "                %s\n", describe(sub.name, sub.help))
+			}
+			sb.WriteString("            )\n")
+		}
+		if len(c.flags) > 0 {
+			sb.WriteString("            flags=(\n")
+			for _, f := range c.flags {
+				for _, name := range f.names() {
+					fmt.Fprintf(&sb, "                %s\n", describe(name, f.usage))
+				}
+			}
+			sb.WriteString("            )\n")
+		}
+		sb.WriteString("            ;;\n")
+	}
+	sb.WriteString(`    esac
+
+    if [[ $PREFIX == -* ]]; then
+        _describe -t flags 'flag' flags
+    elif ((${#commands})); then
+        _describe -t commands 'command' commands
+    else
+        _files
+    fi
+}
+
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+    _proj "$@"
+else
+    compdef _proj proj
+fi
+`)
+	return sb.String()
+}
+
+func fishCompletion(commands []completionCommand) string {
+	var sb strings.Builder
+	sb.WriteString(`# fish completion for proj, generated by 'proj completion fish'
+
+function __proj_path
+    set -l paths`)
+	for _, path := range commandPaths(commands) {
+		sb.WriteString(" " + fishQuote(path))
+	}
+	sb.WriteString(`
+    set -l path ''
+    set -l skip 0
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    for token in $tokens
+        if test $skip = 1
+            set skip 0
+            continue
+        end
+        switch $token
+`)
+	if names := valueFlags(commands); len(names) > 0 {
+		fmt.Fprintf(&sb, "            case %s\n                set skip 1\n", strings.Join(names, " "))
+	}
+	sb.WriteString(`            case '-*'
+            case '*'
+                set -l next (string trim -- "$path $token")
+                if contains -- $next $paths
+                    set path $next
+                end
+        end
+    end
+    echo $path
+end
+
+function __proj_path_is
+    set -l path (__proj_path)
+    test "$path" = "$argv[1]"
+end
+`)
+	for _, c := range commands {
+		cond := fishQuote("__proj_path_is " + fishQuote(c.path))
+		if len(c.commands) > 0 || len(c.flags) > 0 {
+			sb.WriteString("\n")
+		}
+		for _, sub := range c.commands {
+			fmt.Fprintf(&sb, "complete -c proj -f -n %s -a %s -d %s\n", cond, fishQuote(sub.name), fishQuote(sub.help))
+		}
+		for _, f := range c.flags {
+			fmt.Fprintf(&sb, "complete -c proj -n %s", cond)
+			if f.long != "" {
+				fmt.Fprintf(&sb, " -l %s", f.long)
+			}
+			if f.short != 0 {
+				fmt.Fprintf(&sb, " -s %c", f.short)
+			}
+			if f.value {
+				sb.WriteString(" -r")
+			}
+			fmt.Fprintf(&sb, " -d %s\n", fishQuote(f.usage))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newCompletionCacheCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "cache",
+		Usage:     "proj completion cache <subcommand>",
+		ShortHelp: "Manage the caches of completions",
+		LongHelp: `Manage the caches answering completions and queries without reading every
+project.
+
+Commands:
+  warm    Rebuild the caches, such as from a cron job or a login hook`,
+		Subcommands: []*ff.Command{
+			newCompletionCacheWarmCommand(logger, cfg, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+type cacheWarmConfig struct {
+	Background bool
+}
+
+func newCompletionCacheWarmCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	warmCfg := &cacheWarmConfig{}
+	fs := ff.NewFlagSet("completion cache warm")
+	fs.BoolVar(&warmCfg.Background, 0, "background", "warm the caches in a detached process and return right away")
+
+	return &ff.Command{
+		Name:      "warm",
+		Usage:     "proj completion cache warm [flags]",
+		ShortHelp: "Rebuild the caches of completions",
+		LongHelp: `Rebuild the caches read by completions and queries, so that the first
+completion after a cold start answers right away:
+
+  completion cache     the projects and their workspaces
+  directories cache    the top-level directories of projects, for 'project//dir'
+  description index    the README descriptions and remotes of 'proj index'
+
+The description index is built from READMEs only: run 'proj index --github'
+to look up the missing descriptions on GitHub.
+
+Only one warm runs at a time: a warm started while another one runs exits
+right away. Run it from a cron job or a login hook, or start it with each
+shell with 'proj init --warm-cache'.
+
+Examples:
+  proj completion cache warm
+  proj completion cache warm --background`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if warmCfg.Background {
+				return spawnDetached(cfg, nil, "completion", "cache", "warm")
+			}
+			return runCacheWarm(ctx, os.Stdout, logger, projectsCfg, projectsLogger)
+		},
+	}
+}
+
+func runCacheWarm(ctx context.Context, w io.Writer, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) error {
+	cacheSvc := projects.NewCacheService(projectsCfg, projectsLogger)
+	if !cacheSvc.TryLockWarm() {
+		fmt.Fprintln(w, "Caches are already being warmed")
+		return nil
+	}
+	defer cacheSvc.UnlockWarm()
+
+	snapshot, err := cacheSvc.Refresh(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to warm the completion cache: %w", err)
+	}
+	var workspaces int
+	projs := make([]*projects.Project, len(snapshot.Projects))
+	for i, cached := range snapshot.Projects {
+		workspaces += len(cached.Workspaces)
+		projs[i] = &projects.Project{Path: cached.Path, Name: cached.Name, Organisation: cached.Organisation, Host: cached.Host}
+	}
+	fmt.Fprintf(w, "Completion cache: %d projects, %d workspaces\n", len(projs), workspaces)
+
+	projects.NewSubdirService(projectsCfg, projectsLogger).TopLevel(projs)
+	fmt.Fprintf(w, "Directories cache: %d projects\n", len(projs))
+
+	_, total, described, err := updateIndex(ctx, logger, projectsCfg, projectsLogger, nil)
+	if err != nil {
+		return fmt.Errorf("failed to warm the description index: %w", err)
+	}
+	fmt.Fprintf(w, "Description index: %d/%d projects described\n", described, total)
+	return nil
+}
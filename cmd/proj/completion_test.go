@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/peterbourgon/ff/v4"
+)
+
+func TestCompletionCommands(t *testing.T) {
+	var debug bool
+	var root string
+	rootFlags := ff.NewFlagSet("proj")
+	rootFlags.BoolVar(&debug, 0, "debug", "enable debug logging")
+	rootFlags.StringVar(&root, 0, "root", "", "root directory for projects")
+
+	var verbose bool
+	listFlags := ff.NewFlagSet("workspace list")
+	listFlags.BoolVar(&verbose, 'v', "verbose", "show submodules")
+
+	cmd := &ff.Command{
+		Name:  "proj",
+		Flags: rootFlags,
+		Subcommands: []*ff.Command{{
+			Name:      "workspace",
+			ShortHelp: "Manage git worktrees",
+			Subcommands: []*ff.Command{
+				{Name: "list", ShortHelp: "List workspaces", Flags: listFlags},
+			},
+		}},
+	}
+
+	commands := completionCommands(cmd)
+	var paths []string
+	for _, c := range commands {
+		paths = append(paths, c.path)
+	}
+	if got := strings.Join(paths, ","); got != ",workspace,workspace list" {
+		t.Fatalf("completionCommands() paths = %q", got)
+	}
+
+	if got := commands[0].flags; len(got) != 2 || got[0].value || !got[1].value {
+		t.Errorf("root flags = %+v, want --debug without value and --root with value", got)
+	}
+	if got := commands[2].flags; len(got) != 1 || got[0].short != 'v' || got[0].long != "verbose" {
+		t.Errorf("workspace list flags = %+v, want -v/--verbose", got)
+	}
+	if got := valueFlags(commands); len(got) != 1 || got[0] != "--root" {
+		t.Errorf("valueFlags() = %v, want [--root]", got)
+	}
+}
+
+// testCompletionCommands is a command tree for the completion scripts.
+var testCompletionCommands = []completionCommand{
+	{
+		path:     "",
+		commands: []completionEntry{{"workspace", "Manage git worktrees"}, {"list", "List all projects"}},
+		flags:    []completionFlag{{long: "root", usage: "root directory", value: true}},
+	},
+	{
+		path:     "workspace",
+		commands: []completionEntry{{"add", "Add new workspace"}, {"list", "List workspaces"}},
+	},
+	{path: "workspace add", flags: []completionFlag{{long: "base", usage: "ref: the base", value: true}}},
+	{path: "workspace list", flags: []completionFlag{{short: 'v', long: "verbose", usage: "don't hide submodules"}}},
+	{path: "list", flags: []completionFlag{{long: "all", usage: "all directories"}}},
+}
+
+func TestWriteCompletion(t *testing.T) {
+	tests := []struct {
+		shell    string
+		contains []string
+	}{
+		{
+			shell:    "bash",
+			contains: []string{"--root|--base) skip=1 ;;", "'workspace'|'workspace add'|'workspace list'|'list') cmdpath=$next ;;", "complete -o default -F _proj proj"},
+		},
+		{
+			shell:    "zsh",
+			contains: []string{"#compdef proj", "'add:Add new workspace'", `'--base:ref\: the base'`, `'-v:don'\''t hide submodules'`, "compdef _proj proj"},
+		},
+		{
+			shell: "fish",
+			contains: []string{
+				"complete -c proj -f -n '__proj_path_is \\'workspace\\'' -a 'add' -d 'Add new workspace'",
+				"complete -c proj -n '__proj_path_is \\'workspace list\\'' -l verbose -s v -d 'don\\'t hide submodules'",
+				"complete -c proj -n '__proj_path_is \\'\\'' -l root -r -d 'root directory'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeCompletion(&buf, tt.shell, testCompletionCommands); err != nil {
+				t.Fatalf("writeCompletion() error = %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("%s completion missing %q\n%s", tt.shell, want, buf.String())
+				}
+			}
+		})
+	}
+
+	if err := writeCompletion(&bytes.Buffer{}, "tcsh", testCompletionCommands); err == nil {
+		t.Error("writeCompletion() of an unsupported shell should fail")
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	script := filepath.Join(t.TempDir(), "proj.bash")
+	if err := os.WriteFile(script, []byte(bashCompletion(testCompletionCommands)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "proj ", want: "workspace list"},
+		{line: "proj w", want: "workspace"},
+		{line: "proj workspace ", want: "add list"},
+		{line: "proj --root list workspace ", want: "add list"}, // list is the value of --root
+		{line: "proj workspace list -", want: "--verbose -v"},
+		{line: "proj workspace add --base main ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			cmd := exec.Command("bash", "-c", `source "$1"; read -ra COMP_WORDS <<<"$2"; [[ $2 == *" " ]] && COMP_WORDS+=(""); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); _proj; echo "${COMPREPLY[*]}"`, "bash", script, tt.line)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("bash: %v\n%s", err, output)
+			}
+			if got := strings.TrimSpace(string(output)); got != tt.want {
+				t.Errorf("completion of %q = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newConfigCommand(cfg *config.Config) *ff.Command {
+	return &ff.Command{
+		Name:      "config",
+		Usage:     "proj config <subcommand>",
+		ShortHelp: "Inspect the proj configuration",
+		LongHelp: `Inspect the proj configuration.
+
+Commands:
+  validate    Check the configuration file and values`,
+		Subcommands: []*ff.Command{
+			newConfigValidateCommand(cfg),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+func newConfigValidateCommand(cfg *config.Config) *ff.Command {
+	return &ff.Command{
+		Name:      "validate",
+		Usage:     "proj config validate",
+		ShortHelp: "Check the configuration file and values",
+		LongHelp: `Check the configuration file and the resulting configuration values.
+
+Invalid values (a relative root, a malformed user) already prevent proj from
+starting. This command also fails on config file keys that match no option,
+such as typos, and lists the valid keys, and on included files that could
+not be loaded.`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runConfigValidate(cfg)
+		},
+	}
+}
+
+func runConfigValidate(cfg *config.Config) error {
+	fmt.Printf("Config file: %s\n", cfg.ConfigFile)
+	fmt.Printf("Root: %s\n", cfg.RootDir)
+	fmt.Printf("User: %s\n", cfg.RootUser)
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	unknown, err := cfg.UnknownKeys()
+	if err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		for _, key := range unknown {
+			fmt.Printf("Unknown key: %s\n", key)
+		}
+		fmt.Printf("Valid keys: %s\n", strings.Join(config.ValidKeys(), ", "))
+		return fmt.Errorf("%d unknown key(s) in %s", len(unknown), cfg.ConfigFile)
+	}
+
+	if errs := cfg.IncludeErrors(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Printf("Include error: %v\n", err)
+		}
+		return fmt.Errorf("%d include error(s) in %s", len(errs), cfg.ConfigFile)
+	}
+
+	fmt.Println("Configuration is valid")
+	return nil
+}
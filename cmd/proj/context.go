@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type contextConfig struct {
+	JSON bool
+}
+
+// contextInfo is the resolved context of a directory, as printed by
+// 'proj context'.
+type contextInfo struct {
+	Dir         string            `json:"dir"`
+	Root        string            `json:"root"`
+	User        string            `json:"user"`
+	ConfigFile  string            `json:"config_file"`
+	ConfigFound bool              `json:"config_found"`
+	ReadOnly    bool              `json:"read_only"`
+	Project     *contextProject   `json:"project,omitempty"` // nil outside projects
+	Env         map[string]string `json:"env,omitempty"`     // environment overrides
+}
+
+// contextProject is the project of a contextInfo.
+type contextProject struct {
+	Name         string `json:"name"`
+	Organisation string `json:"organisation"`
+	Provider     string `json:"provider"`
+	Path         string `json:"path"`
+	Checkout     string `json:"checkout"`            // main checkout or workspace containing the directory
+	Workspace    string `json:"workspace,omitempty"` // branch of the workspace, empty in the main checkout
+}
+
+// contextEnvVars are the environment variables, other than the PROJECT_*
+// ones overriding options, that change the behavior of proj.
+var contextEnvVars = []string{"GITHUB_TOKEN", "XDG_CACHE_HOME", "XDG_STATE_HOME"}
+
+func newContextCommand(cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	contextCfg := &contextConfig{}
+	fs := ff.NewFlagSet("context")
+	fs.BoolVar(&contextCfg.JSON, 0, "json", "print the context as JSON")
+
+	return &ff.Command{
+		Name:      "context",
+		Usage:     "proj context [flags] [dir]",
+		ShortHelp: "Print the resolved context of the current directory",
+		LongHelp: `Print the context proj resolves for the current directory, or dir: its
+project, organisation, provider and workspace branch, the root directory and
+default user, the config file and the environment variables overriding the
+configuration. Use it to find out why proj behaves differently somewhere.
+
+Tokens are not printed, only whether they are set.
+
+Examples:
+  proj context
+  proj context --json | jq .project`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 1 {
+				return errors.New("proj context takes at most one directory")
+			}
+
+			dir := ""
+			if len(args) > 0 {
+				dir = args[0]
+			} else {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get working directory: %w", err)
+				}
+				dir = wd
+			}
+
+			info := resolveContext(cfg, projectsCfg, projectsLogger, dir, os.Environ())
+			if contextCfg.JSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+			printContext(os.Stdout, info)
+			return nil
+		},
+	}
+}
+
+// resolveContext returns the context of dir, with the overrides of the
+// environment environ.
+func resolveContext(cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, dir string, environ []string) *contextInfo {
+	info := &contextInfo{
+		Dir:        dir,
+		Root:       cfg.RootDir,
+		User:       cfg.RootUser,
+		ConfigFile: cfg.ConfigFile,
+		ReadOnly:   cfg.ReadOnly,
+		Env:        contextEnv(environ),
+	}
+	if _, err := os.Stat(cfg.ConfigFile); err == nil {
+		info.ConfigFound = true
+	}
+
+	proj, err := projects.NewProjectService(projectsCfg, projectsLogger).FindFromPath(dir)
+	if err != nil {
+		return info
+	}
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	info.Project = &contextProject{
+		Name:         proj.String(),
+		Organisation: proj.Organisation,
+		Provider:     proj.Provider(),
+		Path:         proj.Path,
+		Checkout:     contextDir(svc, proj, dir),
+		Workspace:    workspaceBranch(svc, proj, dir),
+	}
+	return info
+}
+
+// contextEnv returns the variables of environ changing the behavior of proj,
+// with the values of tokens replaced by "(set)".
+func contextEnv(environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || value == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, "PROJECT_") && !slices.Contains(contextEnvVars, name) {
+			continue
+		}
+		if strings.Contains(name, "TOKEN") {
+			value = "(set)"
+		}
+		env[name] = value
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
+
+// printContext writes info as text.
+func printContext(w io.Writer, info *contextInfo) {
+	fmt.Fprintf(w, "dir:       %s\n", info.Dir)
+	if p := info.Project; p != nil {
+		fmt.Fprintf(w, "project:   %s\n", p.Name)
+		fmt.Fprintf(w, "  org:       %s\n", p.Organisation)
+		fmt.Fprintf(w, "  provider:  %s\n", p.Provider)
+		fmt.Fprintf(w, "  path:      %s\n", p.Path)
+		fmt.Fprintf(w, "  checkout:  %s\n", p.Checkout)
+		if p.Workspace != "" {
+			fmt.Fprintf(w, "  workspace: %s\n", p.Workspace)
+		}
+	} else {
+		fmt.Fprintln(w, "project:   (none)")
+	}
+
+	fmt.Fprintf(w, "root:      %s\n", info.Root)
+	user := info.User
+	if user == "" {
+		user = "(none)"
+	}
+	fmt.Fprintf(w, "user:      %s\n", user)
+	configFile := info.ConfigFile
+	if !info.ConfigFound {
+		configFile += " (not found)"
+	}
+	fmt.Fprintf(w, "config:    %s\n", configFile)
+	if info.ReadOnly {
+		fmt.Fprintln(w, "read-only: true")
+	}
+
+	if len(info.Env) == 0 {
+		return
+	}
+	names := make([]string, 0, len(info.Env))
+	for name := range info.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "env:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s=%s\n", name, info.Env[name])
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+)
+
+func TestResolveContext(t *testing.T) {
+	root := t.TempDir()
+	cfg := &config.Config{RootDir: root, RootUser: "user", ConfigFile: filepath.Join(root, "missing.toml")}
+	projectsCfg := &projects.Config{RootDir: root}
+
+	projDir := filepath.Join(root, "git.corp.com", "team", "app")
+	gitDir := filepath.Join(projDir, ".git", "worktrees", "feature")
+	workspace := filepath.Join(root, ".workspace", "git.corp.com", "team", "app", "feature")
+	for _, dir := range []string{gitDir, filepath.Join(workspace, "src")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	environ := []string{"PROJECT_RANK=exact", "PROJECT_GITHUB_TOKEN=secret", "GITHUB_TOKEN=secret", "HOME=/home/user", "PROJECT_USER="}
+	info := resolveContext(cfg, projectsCfg, &mockLogger{}, filepath.Join(workspace, "src"), environ)
+
+	want := &contextProject{
+		Name:         "git.corp.com/team/app",
+		Organisation: "team",
+		Provider:     "git.corp.com",
+		Path:         projDir,
+		Checkout:     workspace,
+		Workspace:    "feature",
+	}
+	if !reflect.DeepEqual(info.Project, want) {
+		t.Errorf("Project = %+v, want %+v", info.Project, want)
+	}
+	wantEnv := map[string]string{"PROJECT_RANK": "exact", "PROJECT_GITHUB_TOKEN": "(set)", "GITHUB_TOKEN": "(set)"}
+	if !reflect.DeepEqual(info.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", info.Env, wantEnv)
+	}
+
+	var sb strings.Builder
+	printContext(&sb, info)
+	for _, line := range []string{"project:   git.corp.com/team/app", "  workspace: feature", "missing.toml (not found)", "  PROJECT_GITHUB_TOKEN=(set)"} {
+		if !strings.Contains(sb.String(), line) {
+			t.Errorf("printContext() = %q, missing %q", sb.String(), line)
+		}
+	}
+	if strings.Contains(sb.String(), "secret") {
+		t.Errorf("printContext() = %q, shows a token", sb.String())
+	}
+
+	if info := resolveContext(cfg, projectsCfg, &mockLogger{}, t.TempDir(), nil); info.Project != nil || info.Env != nil {
+		t.Errorf("resolveContext() outside projects = %+v", info)
+	}
+}
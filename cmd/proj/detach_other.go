@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// detach leaves cmd as is on this platform.
+func detach(cmd *exec.Cmd) {}
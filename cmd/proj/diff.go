@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type diffConfig struct {
+	Base       string
+	MaxCommits int
+	Width      int
+}
+
+func newDiffCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	diffCfg := &diffConfig{}
+	fs := ff.NewFlagSet("diff")
+	fs.StringVar(&diffCfg.Base, 0, "base", "", "branch to compare with (default: the default branch)")
+	fs.IntVar(&diffCfg.MaxCommits, 'n', "max-commits", 20, "maximum number of commits listed (0 = all)")
+	fs.IntVar(&diffCfg.Width, 0, "width", 80, "width of the diffstat")
+
+	return &ff.Command{
+		Name:      "diff",
+		Usage:     "proj diff [flags] [project[:branch]]",
+		ShortHelp: "Summarize a workspace branch against the default branch",
+		LongHelp: `Summarize what a workspace, or the main checkout, holds compared to the
+default branch, or --base: its commits, how many commits of the base it
+misses, whether it has uncommitted changes, and the diffstat of its changes
+since the merge base. Use it to assess a stale workspace before removing it.
+
+The argument is a project or one of its workspaces with the project:branch
+syntax, such as gfanton/projects:feature or gfanton/projects:#123. Without
+it, the workspace or project containing the current directory is used.
+
+Examples:
+  proj diff
+  proj diff gfanton/projects:feature
+  proj diff --base release -n 5 api:hotfix`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 1 {
+				return errors.New("proj diff takes at most one project")
+			}
+			var target string
+			if len(args) > 0 {
+				target = args[0]
+			}
+
+			return runDiff(ctx, os.Stdout, projectsCfg, projectsLogger, target, *diffCfg)
+		},
+	}
+}
+
+func runDiff(ctx context.Context, out io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, target string, diffCfg diffConfig) error {
+	projectStr, branch, _ := strings.Cut(target, ":")
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	name, dir := proj.String(), proj.CheckoutPath()
+	switch {
+	case branch != "":
+		ws, err := resolveWorkspace(ctx, svc, proj, branch)
+		if err != nil {
+			return err
+		}
+		name, dir = proj.String()+":"+branch, ws.Path
+	case projectStr == "":
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		dir = contextDir(svc, proj, wd)
+	}
+
+	base := diffCfg.Base
+	if base == "" {
+		if base, err = projects.NewProjectService(projectsCfg, projectsLogger).DefaultBranch(ctx, proj); err != nil {
+			return err
+		}
+	}
+
+	diff, err := svc.Diff(ctx, dir, base, diffCfg.Width)
+	if err != nil {
+		return err
+	}
+	printDiff(out, name, dir, diff, diffCfg.MaxCommits, time.Now())
+	return nil
+}
+
+// printDiff writes the summary of diff of the checkout dir of name.
+func printDiff(out io.Writer, name, dir string, diff *projects.BranchDiff, maxCommits int, now time.Time) {
+	branch := diff.Branch
+	if branch == "" {
+		branch = "detached HEAD"
+	}
+	fmt.Fprintf(out, "%s (%s) against %s\n", name, branch, diff.Base)
+	fmt.Fprintf(out, "  path:   %s\n", dir)
+
+	state := fmt.Sprintf("%d ahead, %d behind", len(diff.Commits), diff.Behind)
+	if diff.Dirty {
+		state += ", uncommitted changes"
+	}
+	fmt.Fprintf(out, "  state:  %s\n", state)
+
+	if len(diff.Commits) == 0 {
+		fmt.Fprintf(out, "\nNo commits not in %s\n", diff.Base)
+		return
+	}
+
+	fmt.Fprintln(out)
+	for i, commit := range diff.Commits {
+		if maxCommits > 0 && i == maxCommits {
+			fmt.Fprintf(out, "  ... %d more\n", len(diff.Commits)-maxCommits)
+			break
+		}
+		fmt.Fprintf(out, "  %s %s (%s, %s)\n", commit.Hash, commit.Subject, commit.Author, formatAge(now, commit.When))
+	}
+
+	if diff.Stat != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, diff.Stat)
+	}
+}
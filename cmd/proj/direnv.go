@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// envrcFile is the direnv file of a directory.
+const envrcFile = ".envrc"
+
+func newDirenvCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "direnv",
+		Usage:     "proj direnv <subcommand>",
+		ShortHelp: "Integrate projects with direnv",
+		LongHelp: `Integrate projects and workspaces with direnv.
+
+Commands:
+  init [project]    Generate an .envrc exporting the PROJ_* variables
+
+Workspaces run 'direnv allow' on their .envrc when created with
+'proj workspace add --direnv' or 'workspace.direnv = true' in the config file.`,
+		Subcommands: []*ff.Command{
+			newDirenvInitCommand(logger, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+type direnvInitConfig struct {
+	Force      bool
+	Allow      bool
+	NoSourceUp bool
+	Print      bool
+}
+
+func newDirenvInitCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	initCfg := &direnvInitConfig{}
+	fs := ff.NewFlagSet("direnv init")
+	fs.BoolVar(&initCfg.Force, 'f', "force", "overwrite an existing .envrc")
+	fs.BoolVar(&initCfg.Allow, 0, "allow", "run 'direnv allow' on the generated .envrc")
+	fs.BoolVar(&initCfg.NoSourceUp, 0, "no-source-up", "don't load the .envrc of parent directories")
+	fs.BoolVar(&initCfg.Print, 0, "print", "print the .envrc instead of writing it")
+
+	return &ff.Command{
+		Name:      "init",
+		Usage:     "proj direnv init [flags] [project]",
+		ShortHelp: "Generate an .envrc for a project or workspace",
+		LongHelp: `Generate an .envrc at the root of a project, or of the workspace containing
+the current directory, exporting:
+
+  PROJ_NAME, PROJ_ORG, PROJ_PATH    the project
+  PROJ_WORKSPACE                    the workspace branch, empty in the project
+
+The .envrc starts with 'source_up_if_exists', so an .envrc in a parent
+directory, such as the projects root, is loaded too (disable with
+--no-source-up). An existing .envrc is kept unless --force is set.
+
+The .envrc is added to .git/info/exclude, shared by the project and its
+workspaces, so that it doesn't show in git status. Commit it with 'git add
+-f' if it is meant to be shared.
+
+If the project parameter is not provided, the current directory must be inside a project.
+
+Examples:
+  proj direnv init --allow
+  proj direnv init --print gfanton/projects`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+
+			return runDirenvInit(ctx, logger, projectsCfg, projectsLogger, projectStr, *initCfg)
+		},
+	}
+}
+
+func runDirenvInit(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectStr string, initCfg direnvInitConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+
+	dir := proj.CheckoutPath()
+	if wd, err := os.Getwd(); err == nil {
+		dir = contextDir(svc, proj, wd)
+	}
+
+	var branch string
+	if dir != proj.CheckoutPath() {
+		ws, err := lookupWorkspace(ctx, svc, proj, func(ws projects.Workspace) bool {
+			return ws.Path == dir
+		})
+		if err != nil {
+			return err
+		}
+		branch = ws.Branch
+	}
+
+	if initCfg.Print {
+		writeEnvrc(os.Stdout, proj, branch, !initCfg.NoSourceUp)
+		return nil
+	}
+
+	path := filepath.Join(dir, envrcFile)
+	if err := projectsCfg.CheckWritable("write " + path); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !initCfg.Force {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(path, flags, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	writeEnvrc(f, proj, branch, !initCfg.NoSourceUp)
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	logger.Debug("envrc generated", "path", path, "workspace", branch)
+	fmt.Printf("Generated %s\n", path)
+
+	// Keep the generated file out of git status
+	if added, err := svc.Exclude(ctx, dir, "/"+envrcFile); err != nil {
+		logger.Warn("failed to exclude .envrc from git status", "path", path, "error", err)
+	} else if len(added) > 0 {
+		fmt.Printf("Excluded /%s in .git/info/exclude\n", envrcFile)
+	}
+
+	if initCfg.Allow {
+		return direnvAllow(ctx, os.Stdout, dir)
+	}
+	return nil
+}
+
+// writeEnvrc writes an .envrc exporting the PROJ_* variables of proj and
+// the workspace branch, loading the parent .envrc first with sourceUp.
+func writeEnvrc(w io.Writer, proj *projects.Project, branch string, sourceUp bool) {
+	fmt.Fprintln(w, "# Generated by proj direnv init")
+	if sourceUp {
+		fmt.Fprintln(w, "source_up_if_exists")
+	}
+	fmt.Fprintf(w, "export PROJ_NAME=%s\n", shellQuote(proj.Name))
+	fmt.Fprintf(w, "export PROJ_ORG=%s\n", shellQuote(proj.Organisation))
+	fmt.Fprintf(w, "export PROJ_PATH=%s\n", shellQuote(proj.Path))
+	fmt.Fprintf(w, "export PROJ_WORKSPACE=%s\n", shellQuote(branch))
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// direnvAllow runs 'direnv allow' on the .envrc of dir.
+func direnvAllow(ctx context.Context, w io.Writer, dir string) error {
+	if _, err := exec.LookPath("direnv"); err != nil {
+		return errors.New("direnv is not installed")
+	}
+
+	cmd := exec.CommandContext(ctx, "direnv", "allow", dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("direnv allow %s: %w\nOutput: %s", dir, err, string(output))
+	}
+
+	fmt.Fprintf(w, "Allowed %s\n", filepath.Join(dir, envrcFile))
+	return nil
+}
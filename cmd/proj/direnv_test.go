@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestWriteEnvrc(t *testing.T) {
+	proj := &projects.Project{Organisation: "user", Name: "proj", Path: "/code/user/proj"}
+
+	tests := []struct {
+		name     string
+		branch   string
+		sourceUp bool
+		want     string
+	}{
+		{
+			name:     "project",
+			sourceUp: true,
+			want: "# Generated by proj direnv init\n" +
+				"source_up_if_exists\n" +
+				"export PROJ_NAME='proj'\n" +
+				"export PROJ_ORG='user'\n" +
+				"export PROJ_PATH='/code/user/proj'\n" +
+				"export PROJ_WORKSPACE=''\n",
+		},
+		{
+			name:   "workspace without source_up",
+			branch: "it's-a-branch",
+			want: "# Generated by proj direnv init\n" +
+				"export PROJ_NAME='proj'\n" +
+				"export PROJ_ORG='user'\n" +
+				"export PROJ_PATH='/code/user/proj'\n" +
+				"export PROJ_WORKSPACE='it'\\''s-a-branch'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeEnvrc(&buf, proj, tt.branch, tt.sourceUp)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("writeEnvrc() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
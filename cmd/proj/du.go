@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type duConfig struct {
+	Top    int
+	Clean  bool
+	DryRun bool
+}
+
+// projectUsage is the disk usage report of a project.
+type projectUsage struct {
+	Project   *projects.Project
+	Size      int64
+	Artifacts []projects.Artifact
+}
+
+// ArtifactSize returns the total size of the artifact directories of u.
+func (u projectUsage) ArtifactSize() int64 {
+	var size int64
+	for _, artifact := range u.Artifacts {
+		size += artifact.Size
+	}
+	return size
+}
+
+func newDUCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	duCfg := &duConfig{}
+	fs := ff.NewFlagSet("du")
+	fs.IntVar(&duCfg.Top, 0, "top", 10, "number of projects and artifact directories to show (0 = all)")
+	fs.BoolVar(&duCfg.Clean, 0, "clean", "run the clean command of each project with artifacts")
+	fs.BoolVar(&duCfg.DryRun, 0, "dry-run", "with --clean, only print the clean commands")
+
+	return &ff.Command{
+		Name:      "du",
+		Usage:     "proj du [flags] [search]",
+		ShortHelp: "Show project disk usage and build artifacts",
+		LongHelp: `Show the disk usage of projects, biggest first, and their largest build
+artifact directories.
+
+Artifact directories are the ones whose name matches a du.artifacts pattern
+(default: node_modules,target,.venv).
+
+With --clean, the du.clean command of the project language is run in each
+project with artifacts, e.g. 'rust=cargo clean' (see 'proj list --group-by
+lang' for languages). The default is:
+  rust=cargo clean,javascript=rm -rf node_modules,python=rm -rf .venv
+
+An optional search narrows the report using the same matching as 'proj query'.
+
+Examples:
+  proj du
+  proj du --top 0 api
+  proj du --clean --dry-run`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runDU(ctx, logger, cfg, projectsCfg, projectsLogger, *duCfg, args)
+		},
+	}
+}
+
+func runDU(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, duCfg duConfig, args []string) error {
+	cleanCommands, err := parseCleanCommands(cfg.DUClean)
+	if err != nil {
+		return fmt.Errorf("invalid du.clean: %w", err)
+	}
+
+	queryService := projects.NewQueryService(projectsCfg, projectsLogger)
+	results, err := queryService.Search(ctx, projects.SearchOptions{
+		Query: strings.Join(args, " "),
+	})
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	patterns := splitList(cfg.DUArtifacts)
+
+	var usages []projectUsage
+	var stopped error
+	for i, result := range results {
+		if err := ctx.Err(); err != nil {
+			stopped = stoppedError(err, len(results)-i, len(results), "projects")
+			break
+		}
+		if result.Workspace != "" {
+			continue
+		}
+
+		size, artifacts, err := projects.ProjectUsage(ctx, result.Project.Path, patterns)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				stopped = stoppedError(ctxErr, len(results)-i, len(results), "projects")
+				break
+			}
+			return err
+		}
+		usages = append(usages, projectUsage{Project: result.Project, Size: size, Artifacts: artifacts})
+	}
+
+	if len(usages) == 0 {
+		if stopped != nil {
+			return stopped
+		}
+		return projects.ErrNoMatch
+	}
+
+	if duCfg.Clean && stopped == nil {
+		if !duCfg.DryRun {
+			if err := projectsCfg.CheckWritable("clean build artifacts"); err != nil {
+				return err
+			}
+		}
+		return cleanProjects(ctx, logger, os.Stdout, usages, cleanCommands, duCfg.DryRun)
+	}
+
+	printProjectUsage(os.Stdout, usages, duCfg.Top)
+	return stopped
+}
+
+// printProjectUsage writes the top project usages, biggest first, the total
+// and the top artifact directories.
+func printProjectUsage(w io.Writer, usages []projectUsage, top int) {
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Size > usages[j].Size
+	})
+
+	var total, artifactTotal int64
+	var artifacts []projects.Artifact
+	for i, usage := range usages {
+		total += usage.Size
+		artifactTotal += usage.ArtifactSize()
+		artifacts = append(artifacts, usage.Artifacts...)
+
+		if top == 0 || i < top {
+			fmt.Fprintf(w, "%10s  %10s  %s\n", formatSize(usage.Size), formatSize(usage.ArtifactSize()), usage.Project.String())
+		}
+	}
+	fmt.Fprintf(w, "\nTotal: %s in %d projects, %s in build artifacts\n", formatSize(total), len(usages), formatSize(artifactTotal))
+
+	if len(artifacts) == 0 {
+		return
+	}
+
+	sort.SliceStable(artifacts, func(i, j int) bool {
+		return artifacts[i].Size > artifacts[j].Size
+	})
+	if top > 0 && len(artifacts) > top {
+		artifacts = artifacts[:top]
+	}
+
+	fmt.Fprintln(w, "\nLargest build artifacts:")
+	for _, artifact := range artifacts {
+		fmt.Fprintf(w, "%10s  %s\n", formatSize(artifact.Size), artifact.Path)
+	}
+}
+
+// cleanProjects runs the clean command of the language of each project with
+// artifacts, or only prints it with dryRun.
+func cleanProjects(ctx context.Context, logger *slog.Logger, w io.Writer, usages []projectUsage, commands map[string]string, dryRun bool) error {
+	var freed int64
+	for _, usage := range usages {
+		if len(usage.Artifacts) == 0 {
+			continue
+		}
+
+		language := usage.Project.Language()
+		command, ok := commands[language]
+		if !ok {
+			logger.Debug("no clean command for project language", "project", usage.Project.String(), "language", language)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s (%s): %s\n", usage.Project.String(), formatSize(usage.ArtifactSize()), command)
+		if dryRun {
+			continue
+		}
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Dir = usage.Project.Path
+		cmd.Stdout, cmd.Stderr = w, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to clean %s: %w", usage.Project.String(), err)
+		}
+		freed += usage.ArtifactSize()
+	}
+
+	if !dryRun {
+		fmt.Fprintf(w, "Cleaned %s of build artifacts\n", formatSize(freed))
+	}
+	return nil
+}
+
+// parseCleanCommands parses comma-separated "language=command" clean
+// commands, e.g. "rust=cargo clean, javascript=rm -rf node_modules".
+func parseCleanCommands(s string) (map[string]string, error) {
+	commands := make(map[string]string)
+	for _, pair := range splitList(s) {
+		language, command, ok := strings.Cut(pair, "=")
+		language, command = strings.TrimSpace(language), strings.TrimSpace(command)
+		if !ok || language == "" || command == "" {
+			return nil, fmt.Errorf("invalid clean command '%s' (expected language=command)", pair)
+		}
+		commands[language] = command
+	}
+	return commands, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestParseCleanCommands(t *testing.T) {
+	got, err := parseCleanCommands("rust=cargo clean, javascript = rm -rf node_modules,")
+	if err != nil {
+		t.Fatalf("parseCleanCommands() error = %v", err)
+	}
+	if len(got) != 2 || got["rust"] != "cargo clean" || got["javascript"] != "rm -rf node_modules" {
+		t.Errorf("parseCleanCommands() = %v", got)
+	}
+
+	for _, invalid := range []string{"cargo clean", "rust=", "=cargo clean"} {
+		if _, err := parseCleanCommands(invalid); err == nil {
+			t.Errorf("parseCleanCommands(%q) expected error", invalid)
+		}
+	}
+}
+
+func TestPrintProjectUsage(t *testing.T) {
+	usages := []projectUsage{
+		{Project: &projects.Project{Organisation: "user", Name: "small"}, Size: 1 << 10},
+		{
+			Project:   &projects.Project{Organisation: "user", Name: "web"},
+			Size:      3 << 30,
+			Artifacts: []projects.Artifact{{Path: "/code/user/web/node_modules", Size: 2 << 30}},
+		},
+	}
+
+	var out strings.Builder
+	printProjectUsage(&out, usages, 1)
+
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[0], "user/web") || !strings.Contains(lines[0], "2.0 GiB") {
+		t.Errorf("first line = %q, want the biggest project with its artifact size", lines[0])
+	}
+	if strings.Contains(out.String(), "user/small") {
+		t.Errorf("output shows more than the top project:\n%s", out.String())
+	}
+	for _, want := range []string{"in 2 projects", "/code/user/web/node_modules"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gfanton/projects"
+)
+
+// ---- Exit Codes
+//
+// proj exits with 0 on success, and with one of these codes on failure so
+// scripts can react without parsing stderr.
+const (
+	exitCodeError     = 1 // generic failure
+	exitCodeNoMatch   = 2 // query matched nothing, or the target does not exist
+	exitCodeAmbiguous = 3 // query matched several equally good candidates
+)
+
+// ---- Error Formats
+const (
+	errorFormatText = "text"
+	errorFormatJSON = "json"
+)
+
+// exitError is an error that terminates proj with a specific exit code.
+type exitError struct {
+	code       int
+	err        error
+	candidates []string // for ambiguous errors, the matching candidates
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// stoppedError reports a bulk command stopped by err, the cancellation or
+// timeout of its context, with left of the total items not processed.
+func stoppedError(err error, left, total int, items string) error {
+	return fmt.Errorf("stopped with %d of %d %s left: %w", left, total, items, err)
+}
+
+// partialWalk returns nil for the *projects.WalkErrors of a walk of the
+// root directory that skipped unreadable directories, so that the commands
+// use the partial results; the skipped directories are logged at debug
+// level. With --strict, or for other errors, it returns err.
+func partialWalk(projectsCfg *projects.Config, logger projects.Logger, err error) error {
+	var walkErrs *projects.WalkErrors
+	if !errors.As(err, &walkErrs) {
+		return err
+	}
+	for _, skipped := range walkErrs.Errors {
+		logger.Debug("skipped unreadable directory", "error", skipped)
+	}
+	if projectsCfg.Strict {
+		return err
+	}
+	return nil
+}
+
+// exitCode returns the process exit code for err.
+func exitCode(err error) int {
+	var exitErr *exitError
+	switch {
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	case errors.Is(err, projects.ErrNoMatch), errors.Is(err, projects.ErrWorkspaceNotFound):
+		return exitCodeNoMatch
+	default:
+		return exitCodeError
+	}
+}
+
+// errorKind returns a stable machine-readable name for an exit code.
+func errorKind(code int) string {
+	switch code {
+	case exitCodeNoMatch:
+		return "no_match"
+	case exitCodeAmbiguous:
+		return "ambiguous"
+	default:
+		return "error"
+	}
+}
+
+// jsonError is the --error-format=json representation of an error.
+type jsonError struct {
+	Error      string   `json:"error"`
+	Kind       string   `json:"kind"`
+	Code       int      `json:"code"`
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// writeError reports err on w in the given format and returns the exit code.
+func writeError(w io.Writer, format string, err error) int {
+	code := exitCode(err)
+
+	var candidates []string
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		candidates = exitErr.candidates
+	}
+
+	if format == errorFormatJSON {
+		data, _ := json.Marshal(jsonError{
+			Error:      err.Error(),
+			Kind:       errorKind(code),
+			Code:       code,
+			Candidates: candidates,
+		})
+		fmt.Fprintln(w, string(data))
+		return code
+	}
+
+	for _, c := range candidates {
+		fmt.Fprintln(w, c)
+	}
+	fmt.Fprintf(w, "error: %v\n", err)
+	return code
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/gfanton/projects"
+)
+
+// formatHelp documents the --format templates, shared by the commands
+// supporting them.
+const formatHelp = `Templates (--format):
+  A Go text/template executed for each result, followed by a newline. \t and
+  \n in the template stand for a tab and a newline. Fields:
+    {{.Org}}        organisation of the project
+    {{.Name}}       name of the project
+    {{.Project}}    org/name
+    {{.Path}}       absolute path of the project, or of the workspace
+    {{.Workspace}}  workspace branch, empty for projects
+    {{.Status}}     Git status of the project (list only)
+    {{.PR}}         pull request of the workspace, e.g. #123 (workspace list only)
+    {{.PRURL}}      web page of the pull request (workspace list only)
+    {{.Distance}}   match distance (query only)
+    {{.Language}}   detected language of the project
+    {{.Tags}}       tags of the project, e.g. {{join .Tags ","}}
+  Functions: join (strings.Join) and json (JSON encoding).`
+
+// formatItem is the data of a result rendered by a --format template.
+type formatItem struct {
+	Org       string
+	Name      string
+	Project   string
+	Path      string
+	Workspace string
+	Status    string
+	Distance  int
+	PR        string // pull request of the workspace, e.g. #123
+	PRURL     string
+
+	project *projects.Project
+}
+
+// newFormatItem returns the template data of project p, or of its workspace
+// at path when branch is set.
+func newFormatItem(p *projects.Project, branch, path string) formatItem {
+	return formatItem{
+		Org:       p.Organisation,
+		Name:      p.Name,
+		Project:   p.String(),
+		Path:      path,
+		Workspace: branch,
+		project:   p,
+	}
+}
+
+// Language returns the detected language of the project, computed on use.
+func (f formatItem) Language() string {
+	return f.project.Language()
+}
+
+// Tags returns the tags of the project, computed on use.
+func (f formatItem) Tags() []string {
+	return f.project.Tags()
+}
+
+// parseFormat parses a --format template.
+func parseFormat(format string) (*template.Template, error) {
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+
+	tmpl, err := template.New("format").Funcs(template.FuncMap{
+		"join": strings.Join,
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// executeFormat writes item rendered by tmpl, followed by a newline, or by a
+// NUL byte with print0.
+func executeFormat(w io.Writer, tmpl *template.Template, item formatItem, print0 bool) error {
+	if err := tmpl.Execute(w, item); err != nil {
+		return fmt.Errorf("failed to render --format template: %w", err)
+	}
+
+	end := "\n"
+	if print0 {
+		end = "\x00"
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestFormat(t *testing.T) {
+	p := &projects.Project{Organisation: "user", Name: "repo", Path: "/code/user/repo"}
+
+	tests := []struct {
+		name     string
+		format   string
+		item     formatItem
+		expected string
+	}{
+		{
+			name:     "escapes",
+			format:   `{{.Org}}\t{{.Name}}\t{{.Path}}`,
+			item:     newFormatItem(p, "", p.Path),
+			expected: "user\trepo\t/code/user/repo\n",
+		},
+		{
+			name:     "workspace",
+			format:   `{{.Project}}:{{.Workspace}} {{.Path}}`,
+			item:     newFormatItem(p, "feature/x", "/code/.workspace/user/repo/feature--x"),
+			expected: "user/repo:feature/x /code/.workspace/user/repo/feature--x\n",
+		},
+		{
+			name:     "json",
+			format:   `{"project":{{json .Project}},"distance":{{.Distance}}}`,
+			item:     formatItem{Project: "user/repo", Distance: 3},
+			expected: "{\"project\":\"user/repo\",\"distance\":3}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseFormat(tt.format)
+			if err != nil {
+				t.Fatalf("parseFormat() failed: %v", err)
+			}
+
+			var sb strings.Builder
+			if err := executeFormat(&sb, tmpl, tt.item, false); err != nil {
+				t.Fatalf("executeFormat() failed: %v", err)
+			}
+			if sb.String() != tt.expected {
+				t.Errorf("output = %q, want %q", sb.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	if _, err := parseFormat("{{.Org"); err == nil {
+		t.Error("parseFormat() of an unterminated action succeeded")
+	}
+
+	tmpl, err := parseFormat("{{.Unknown}}")
+	if err != nil {
+		t.Fatalf("parseFormat() failed: %v", err)
+	}
+	if err := executeFormat(&strings.Builder{}, tmpl, formatItem{}, false); err == nil {
+		t.Error("executeFormat() of an unknown field succeeded")
+	}
+}
@@ -2,26 +2,48 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/gfanton/projects"
 	"github.com/gfanton/projects/internal/config"
 	"github.com/gfanton/projects/internal/git"
+	"github.com/gfanton/projects/internal/index"
 	"github.com/gfanton/projects/internal/project"
 	"github.com/peterbourgon/ff/v4"
 )
 
 type getConfig struct {
-	UseSSH bool
-	Token  string
+	UseSSH    bool
+	Protocol  string
+	Token     string
+	LFS       bool
+	SkipLFS   bool
+	Bare      bool
+	Reference string
+	Update    bool
+	Yes       bool
+	PrintPath bool
 }
 
 func newGetCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
 	getCfg := &getConfig{}
 	fs := ff.NewFlagSet("get")
-	fs.BoolVar(&getCfg.UseSSH, 0, "ssh", "use SSH for cloning instead of HTTPS")
-	fs.StringVar(&getCfg.Token, 0, "token", os.Getenv("GITHUB_TOKEN"), "GitHub token for authentication")
+	fs.BoolVar(&getCfg.UseSSH, 0, "ssh", "use SSH for cloning (same as --protocol=ssh)")
+	fs.StringVar(&getCfg.Protocol, 0, "protocol", "", "clone protocol: auto, ssh or https (default: clone.protocol config)")
+	fs.StringVar(&getCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+	fs.BoolVar(&getCfg.LFS, 0, "lfs", "fetch Git LFS objects, even when cloning several projects")
+	fs.BoolVar(&getCfg.SkipLFS, 0, "skip-lfs", "don't fetch Git LFS objects")
+	fs.BoolVar(&getCfg.Bare, 0, "bare", "clone a bare repository, with every checkout a worktree of the project directory")
+	fs.StringVar(&getCfg.Reference, 0, "reference", "", "local clone to borrow objects from: auto, none or a project (default: clone.reference config)")
+	fs.BoolVar(&getCfg.Update, 'u', "update", "fetch and fast-forward the default branch of projects already cloned (default: clone.update config)")
+	fs.BoolVar(&getCfg.Yes, 'y', "yes", "clone the repositories matching glob patterns without asking for confirmation")
+	fs.BoolVar(&getCfg.PrintPath, 0, "print-path", "only print the absolute path of each project on stdout, messages going to stderr")
 
 	return &ff.Command{
 		Name:      "get",
@@ -32,12 +54,62 @@ func newGetCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
 The project name can be:
   - "project" (uses default user from config)
   - "user/project" (explicit user specification)
+  - "host/user/project" with the host of the Git server: github.com
+    projects are the same as "user/project", the ones of other servers
+    are stored under <root>/<host>; with a [hosts."<host>"] config table,
+    "host/project" uses the default_org_prefix of the host
+  - a glob pattern of GitHub project names, such as "user/service-*": the
+    repositories of the user, or organisation, matching it are listed and
+    cloned after confirmation (skipped with --yes); quote it for the shell
+  - "@set" for the projects of a set of the [sets] config table, such as
+    the repositories of a polyrepo product
+
+The clone protocol is taken from --protocol, then from the clone.hosts
+override of the host, then from clone.protocol (default: auto). With auto,
+SSH is used when an SSH agent or a passphrase-less ~/.ssh identity is
+available, and HTTPS (with the resolved GitHub token) otherwise.
+
+Repositories using Git LFS get their LFS objects fetched when a single
+project is cloned. When cloning several projects, or with --skip-lfs, the
+objects are not fetched and 'git lfs install --local --skip-smudge' keeps
+later checkouts from downloading them; run 'git lfs pull' in the project to
+fetch them. LFS requires the git-lfs extension.
+
+With --bare, the repository is cloned bare into <project>/.bare, and the
+default branch is checked out in the <project>/<branch> worktree. Workspaces
+of the project are created next to it, so that no checkout is duplicated
+and switching branches is a cd.
+
+With --reference, objects present in a local clone of the same repository,
+or of a fork, are borrowed through Git alternates instead of downloaded,
+saving disk and time. With auto, the clone is picked among the projects whose
+origin remote (recorded by 'proj index') is the repository, then among the
+projects of the same name. A project others borrow from must not be removed:
+first run 'git repack -a -d' and delete .git/objects/info/alternates in the
+borrowing clones.
+
+Projects already cloned are skipped with a warning. With --update, or
+clone.update set in the config, their origin is fetched instead and their
+default branch fast-forwarded, making 'proj get' an idempotent "make sure I
+have the latest" operation. A default branch checked out with uncommitted
+changes, or diverged from origin, is left untouched and reported.
+
+With --print-path, the absolute path of each project cloned or already there
+is printed on stdout, the checkout of the default branch with --bare, and
+the other messages go to stderr. The shell integration of 'proj init' uses
+it so that 'p get user/project' clones the project and changes to it.
 
 Examples:
   proj get myrepo
   proj get johndoe/webapp
   proj get --ssh johndoe/webapp
-  proj get repo1 user2/repo2`,
+  proj get git.corp.com/team/app
+  proj get --bare kubernetes/kubernetes
+  proj get --reference auto johndoe/kubernetes
+  proj get repo1 user2/repo2
+  proj get 'acme/service-*'
+  proj get @payments
+  proj get --update johndoe/webapp johndoe/api`,
 		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			return runGet(ctx, logger, cfg, *getCfg, args)
@@ -49,45 +121,293 @@ func runGet(ctx context.Context, logger *slog.Logger, cfg *config.Config, getCfg
 	if len(args) == 0 {
 		return fmt.Errorf("at least one project name required")
 	}
+	if getCfg.LFS && getCfg.SkipLFS {
+		return fmt.Errorf("--lfs and --skip-lfs are mutually exclusive")
+	}
 
-	gitClient := git.NewClient(logger)
+	projectSvc := projects.NewProjectService(cfg.ProjectsConfig(), projects.NewSlogAdapter(logger))
+	args, err := projectSvc.ExpandSets(args)
+	if err != nil {
+		return err
+	}
+	if err := checkWritable(cfg, "clone "+strings.Join(args, " ")); err != nil {
+		return err
+	}
+
+	// With --print-path, stdout is for the paths only
+	var w io.Writer = os.Stdout
+	if getCfg.PrintPath {
+		w = os.Stderr
+	}
+
+	args, ok, err := expandGetPatterns(ctx, logger, cfg, getCfg, args, os.Stdin, w)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Avoid surprise LFS downloads when cloning in bulk
+	fetchLFS := getCfg.LFS || (!getCfg.SkipLFS && len(args) == 1)
+
+	protocol, hosts, err := cloneProtocol(cfg, getCfg)
+	if err != nil {
+		return err
+	}
+
+	reference := getCfg.Reference
+	if reference == "" {
+		reference = cfg.CloneReference
+	}
+
+	update := getCfg.Update || cfg.CloneUpdate
+
+	gitClient := newGitClient(logger, cfg)
+	token := resolveToken(ctx, logger, cfg, getCfg.Token)
 
-	for _, arg := range args {
-		p, err := project.ParseProject(cfg.RootDir, cfg.RootUser, arg)
+	var failed int
+	for i, arg := range args {
+		if err := ctx.Err(); err != nil {
+			if failed > 0 {
+				fmt.Fprintf(w, "Failed to get %d projects\n", failed)
+			}
+			return stoppedError(err, len(args)-i, len(args), "projects")
+		}
+
+		p, err := parseGetProject(cfg, arg)
 		if err != nil {
 			logger.Error("failed to parse project name", "name", arg, "error", err)
-			fmt.Printf("Error: failed to parse project name '%s': %v\n", arg, err)
+			fmt.Fprintf(w, "Error: failed to parse project name '%s': %v\n", arg, err)
+			failed++
 			continue
 		}
 
 		// Check if directory already exists
 		if _, err := os.Stat(p.Path); err == nil {
-			logger.Warn("project directory already exists", "name", p.String(), "path", p.Path)
-			fmt.Printf("Warning: project directory already exists: %s\n", p.Path)
+			if !update {
+				logger.Warn("project directory already exists", "name", p.String(), "path", p.Path)
+				fmt.Fprintf(w, "Warning: project directory already exists: %s\n", p.Path)
+			} else if err := updateProject(ctx, w, projectSvc, p); err != nil {
+				logger.Error("failed to update project", "name", p.String(), "error", err)
+				fmt.Fprintf(w, "Error: failed to update %s: %v\n", p.String(), err)
+				failed++
+				continue
+			}
+			if getCfg.PrintPath {
+				printPath(p.Path)
+			}
 			continue
 		}
 
 		// Determine URL to use
+		useSSH := git.UseSSH(p.Provider(), protocol, hosts)
 		url := p.GitHTTPURL()
-		if getCfg.UseSSH {
+		if useSSH {
 			url = p.GitSSHURL()
 		}
 
 		cloneOpts := git.CloneOptions{
 			URL:         url,
 			Destination: p.Path,
-			UseSSH:      getCfg.UseSSH,
-			Token:       getCfg.Token,
+			UseSSH:      useSSH,
+			Bare:        getCfg.Bare,
+			Progress:    w,
+		}
+		// The GitHub token is not sent to other servers
+		if p.Host == nil {
+			cloneOpts.Token = token
+		}
+
+		ref, err := cloneReference(projectSvc, reference, p)
+		if err != nil {
+			logger.Error("failed to find reference clone", "name", p.String(), "reference", reference, "error", err)
+			fmt.Fprintf(w, "Error: failed to find a clone to borrow objects from for %s: %v\n", p.String(), err)
+			failed++
+			continue
+		}
+		if ref != nil {
+			cloneOpts.Reference = ref.Path
+			fmt.Fprintf(w, "Borrowing objects from %s\n", ref.String())
 		}
 
 		if err := gitClient.Clone(ctx, cloneOpts); err != nil {
 			logger.Error("failed to clone project", "name", p.String(), "url", url, "error", err)
-			fmt.Printf("Error: failed to clone %s: %v\n", p.String(), err)
+			fmt.Fprintf(w, "Error: failed to clone %s: %v\n", p.String(), err)
+			failed++
 			continue
 		}
 
-		fmt.Printf("Cloned: %s\n", p.String())
+		checkout := p.Path
+		if getCfg.Bare {
+			var err error
+			if checkout, err = addBareCheckout(ctx, logger, cfg, p); err != nil {
+				logger.Error("failed to check out project", "name", p.String(), "error", err)
+				fmt.Fprintf(w, "Error: cloned %s, but failed to check out its default branch: %v\n", p.String(), err)
+				failed++
+				continue
+			}
+		}
+
+		fmt.Fprintf(w, "Cloned: %s\n", p.String())
+		setupLFS(ctx, w, logger, gitClient, p.String(), checkout, fetchLFS)
+		if getCfg.PrintPath {
+			printPath(checkout)
+		}
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("failed to get %d of %d projects", failed, len(args))
+	}
+
+	return nil
+}
+
+// updateProject fetches the already cloned project p and fast-forwards its
+// default branch, printing the outcome on w.
+func updateProject(ctx context.Context, w io.Writer, projectSvc *projects.ProjectService, p *project.Project) error {
+	proj := clonedProject(p)
+	if !proj.IsGitRepository() {
+		return fmt.Errorf("%s is not a Git repository", p.Path)
+	}
+
+	update, err := projectSvc.UpdateDefaultBranch(ctx, proj)
+	if err != nil {
+		return err
+	}
+
+	if update.UpToDate() {
+		fmt.Fprintf(w, "Up to date: %s (%s)\n", p.String(), update.Branch)
+		return nil
+	}
+	fmt.Fprintf(w, "Updated: %s %s %s..%s\n", p.String(), update.Branch, shortHash(update.From), shortHash(update.To))
 	return nil
 }
+
+// printPath prints the absolute form of path on stdout, for --print-path.
+func printPath(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	fmt.Println(path)
+}
+
+// parseGetProject parses the name of a project to clone: a name of
+// project.ParseProject, or a "host/user/project" one of the hosts of cfg.
+func parseGetProject(cfg *config.Config, name string) (*project.Project, error) {
+	if hostName, rest, ok := strings.Cut(strings.TrimSpace(name), "/"); ok {
+		if host, ok := cfg.Hosts[hostName]; ok && hostName != project.DefaultProvider {
+			return project.ParseHostProject(cfg.RootDir, project.Host{
+				Name:             hostName,
+				SSHUser:          host.SSHUser,
+				DefaultOrgPrefix: host.DefaultOrgPrefix,
+			}, rest)
+		}
+	}
+	return project.ParseProject(cfg.RootDir, cfg.RootUser, name)
+}
+
+// newGitClient returns a Git client bounded by the clone and network
+// timeouts of cfg.
+func newGitClient(logger *slog.Logger, cfg *config.Config) *git.Client {
+	gitClient := git.NewClient(logger)
+	gitClient.CloneTimeout = cfg.CloneTimeout
+	gitClient.NetworkTimeout = cfg.NetworkTimeout
+	gitClient.Retry = git.RetryPolicy{Attempts: cfg.RetryAttempts, Backoff: cfg.RetryBackoff}
+	return gitClient
+}
+
+// addBareCheckout creates the worktree of the default branch of the bare
+// clone of p, and returns its path.
+func addBareCheckout(ctx context.Context, logger *slog.Logger, cfg *config.Config, p *project.Project) (string, error) {
+	svc := projects.NewWorkspaceService(cfg.ProjectsConfig(), projects.NewSlogAdapter(logger))
+
+	return svc.AddBareCheckout(ctx, *clonedProject(p))
+}
+
+// clonedProject returns the projects.Project of the project p to clone.
+func clonedProject(p *project.Project) *projects.Project {
+	proj := &projects.Project{Path: p.Path, Name: p.Name, Organisation: p.Organisation}
+	if p.Host != nil {
+		proj.Host = p.Host.Name
+	}
+	return proj
+}
+
+// cloneReference returns the local clone a clone of p borrows objects from,
+// or nil for none, following reference: empty or "none" for none, "auto" for
+// the best candidate if any, or the name of a project.
+func cloneReference(projectSvc *projects.ProjectService, reference string, p *project.Project) (*projects.Project, error) {
+	switch reference {
+	case "", "none":
+		return nil, nil
+	case "auto":
+		// Without an index, only names are matched
+		indexPath, err := index.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		idx, err := index.Load(indexPath)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates, err := projectSvc.ReferenceCandidates(clonedProject(p), idx)
+		if err != nil || len(candidates) == 0 {
+			return nil, err
+		}
+		return candidates[0], nil
+	}
+
+	ref, err := projectSvc.ParseProject(reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference '%s': %w", reference, err)
+	}
+	if !ref.IsGitRepository() {
+		return nil, fmt.Errorf("reference %s is not a Git repository: %s", ref.String(), ref.Path)
+	}
+	return ref, nil
+}
+
+// cloneProtocol returns the clone protocol requested by the get flags, or
+// the configured one, and the configured per-host overrides. Overrides are
+// ignored when a protocol flag is set.
+func cloneProtocol(cfg *config.Config, getCfg getConfig) (string, map[string]string, error) {
+	protocol := getCfg.Protocol
+	if getCfg.UseSSH {
+		protocol = git.ProtocolSSH
+	}
+	if protocol != "" {
+		return protocol, nil, git.ValidateProtocol(protocol)
+	}
+
+	if err := git.ValidateProtocol(cfg.CloneProtocol); err != nil {
+		return "", nil, fmt.Errorf("invalid clone.protocol: %w", err)
+	}
+
+	hosts, err := git.ParseHostProtocols(cfg.CloneHosts)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid clone.hosts: %w", err)
+	}
+
+	return cfg.CloneProtocol, hosts, nil
+}
+
+// setupLFS configures Git LFS in the cloned project at path when it uses
+// LFS, fetching the objects or not, printing the outcome on w. Failures are
+// reported as warnings: the clone itself succeeded.
+func setupLFS(ctx context.Context, w io.Writer, logger *slog.Logger, gitClient *git.Client, name, path string, fetch bool) {
+	if !git.UsesLFS(path) {
+		return
+	}
+
+	err := gitClient.SetupLFS(ctx, path, fetch)
+	switch {
+	case errors.Is(err, git.ErrLFSUnavailable):
+		fmt.Fprintf(w, "Warning: %s uses Git LFS but git-lfs is not installed: LFS files are pointers\n", name)
+	case err != nil:
+		logger.Warn("failed to set up git lfs", "name", name, "error", err)
+		fmt.Fprintf(w, "Warning: failed to set up Git LFS for %s: %v\n", name, err)
+	case fetch:
+		fmt.Fprintf(w, "Fetched LFS objects: %s\n", name)
+	default:
+		fmt.Fprintf(w, "LFS objects not fetched: %s (run 'git lfs pull' in %s)\n", name, path)
+	}
+}
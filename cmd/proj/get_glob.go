@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+)
+
+// isGlob reports whether the name of a project to get is a glob pattern.
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// matchRepositories returns the repos whose name matches the glob pattern,
+// in the order of repos.
+func matchRepositories(pattern string, repos []github.Repository) ([]github.Repository, error) {
+	var matched []github.Repository
+	for _, repo := range repos {
+		ok, err := path.Match(pattern, repo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		if ok {
+			matched = append(matched, repo)
+		}
+	}
+	return matched, nil
+}
+
+// expandGetPatterns replaces the glob patterns of args, such as
+// "acme/service-*", with the names of the matching GitHub repositories.
+// The matches are listed on out, and cloning the ones missing locally is
+// confirmed on in unless getCfg.Yes is set. It returns false when declined.
+func expandGetPatterns(ctx context.Context, logger *slog.Logger, cfg *config.Config, getCfg getConfig, args []string, in io.Reader, out io.Writer) ([]string, bool, error) {
+	if !hasGlob(args) {
+		return args, true, nil
+	}
+
+	client := newGitHubClient(ctx, logger, cfg, getCfg.Token)
+	owners := make(map[string][]github.Repository)
+
+	var names []string
+	missing := 0
+	for _, arg := range args {
+		if !isGlob(arg) {
+			names = append(names, arg)
+			continue
+		}
+
+		p, err := parseGetProject(cfg, arg)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse project name '%s': %w", arg, err)
+		}
+		if p.Host != nil {
+			return nil, false, fmt.Errorf("glob patterns are only supported for GitHub projects: %s", arg)
+		}
+		if isGlob(p.Organisation) {
+			return nil, false, fmt.Errorf("glob patterns only match project names, not organisations: %s", arg)
+		}
+		if _, err := path.Match(p.Name, ""); err != nil {
+			return nil, false, fmt.Errorf("invalid pattern '%s': %w", p.Name, err)
+		}
+
+		repos, ok := owners[p.Organisation]
+		if !ok {
+			if repos, err = client.OwnerRepositories(ctx, p.Organisation); err != nil {
+				return nil, false, fmt.Errorf("failed to list repositories of %s: %w", p.Organisation, err)
+			}
+			owners[p.Organisation] = repos
+		}
+
+		matched, err := matchRepositories(p.Name, repos)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(matched) == 0 {
+			return nil, false, &exitError{
+				code: exitCodeNoMatch,
+				err:  fmt.Errorf("no repositories of %s match '%s'", p.Organisation, p.Name),
+			}
+		}
+
+		fmt.Fprintf(out, "Repositories of %s matching '%s':\n", p.Organisation, p.Name)
+		for _, repo := range matched {
+			name := p.Organisation + "/" + repo.Name
+			names = append(names, name)
+
+			var notes []string
+			if _, err := os.Stat(filepath.Join(filepath.Dir(p.Path), repo.Name)); err == nil {
+				notes = append(notes, "cloned")
+			} else {
+				missing++
+			}
+			if repo.Fork {
+				notes = append(notes, "fork")
+			}
+			if repo.Archived {
+				notes = append(notes, "archived")
+			}
+
+			if len(notes) > 0 {
+				name = fmt.Sprintf("%-40s (%s)", name, strings.Join(notes, ", "))
+			}
+			fmt.Fprintf(out, "  %s\n", name)
+		}
+	}
+
+	if missing > 0 && !getCfg.Yes {
+		question := fmt.Sprintf("Clone the %d missing repositories?", missing)
+		if missing == 1 {
+			question = "Clone the missing repository?"
+		}
+		p := &prompter{in: bufio.NewReader(in), out: out}
+		ok, err := p.confirm(question, false)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+	return names, true, nil
+}
+
+// hasGlob reports whether one of the names of projects to get is a glob
+// pattern.
+func hasGlob(names []string) bool {
+	for _, name := range names {
+		if isGlob(name) {
+			return true
+		}
+	}
+	return false
+}
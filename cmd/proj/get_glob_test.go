@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gfanton/projects/internal/github"
+)
+
+func TestMatchRepositories(t *testing.T) {
+	repos := []github.Repository{
+		{Name: "service-auth"},
+		{Name: "api"},
+		{Name: "service-billing"},
+		{Name: "legacy-service"},
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{pattern: "service-*", want: []string{"service-auth", "service-billing"}},
+		{pattern: "*service*", want: []string{"service-auth", "service-billing", "legacy-service"}},
+		{pattern: "ap?", want: []string{"api"}},
+		{pattern: "web-*", want: nil},
+		{pattern: "[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if !isGlob(tt.pattern) && !tt.wantErr {
+				t.Errorf("isGlob(%q) = false, want true", tt.pattern)
+			}
+
+			matched, err := matchRepositories(tt.pattern, repos)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchRepositories() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, repo := range matched {
+				got = append(got, repo.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matchRepositories(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	if isGlob("acme/api") {
+		t.Error("isGlob(acme/api) = true, want false")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// grepMaxLineLength is the length past which matched lines are truncated.
+const grepMaxLineLength = 200
+
+type grepConfig struct {
+	IgnoreCase bool
+	Fixed      bool
+	Open       bool
+}
+
+// projectMatches holds the grep matches of a project.
+type projectMatches struct {
+	Project *projects.Project
+	Matches []projects.GrepMatch
+}
+
+func newGrepCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	grepCfg := &grepConfig{}
+	fs := ff.NewFlagSet("grep")
+	fs.BoolVar(&grepCfg.IgnoreCase, 'i', "ignore-case", "match case insensitively")
+	fs.BoolVar(&grepCfg.Fixed, 'F', "fixed-strings", "match the pattern as a literal string")
+	fs.BoolVar(&grepCfg.Open, 0, "open", "open a hit in $VISUAL or $EDITOR, prompting when there are several")
+
+	return &ff.Command{
+		Name:      "grep",
+		Usage:     "proj grep [flags] <pattern> [search]",
+		ShortHelp: "Search the code of projects",
+		LongHelp: `Search the files of the projects matching a search, using the same matching
+as 'proj query', for lines matching a regular expression. Results are grouped
+by project.
+
+Files are searched with ripgrep (rg) when installed, respecting .gitignore.
+Without it, a built-in matcher searches the files tracked or not ignored by
+Git.
+
+With --open, the hit is opened in $VISUAL or $EDITOR at its line; when there
+are several, you are prompted to pick one.
+
+Examples:
+  proj grep TODO                     # Search all projects
+  proj grep -i 'func main' api       # Search the projects matching api
+  proj grep --open -F 'ParseProject(' api`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return errors.New("pattern is required")
+			}
+			return runGrep(ctx, projectsCfg, projectsLogger, *grepCfg, args[0], strings.Join(args[1:], " "))
+		},
+	}
+}
+
+func runGrep(ctx context.Context, projectsCfg *projects.Config, projectsLogger projects.Logger, grepCfg grepConfig, pattern, query string) error {
+	results, err := projects.NewQueryService(projectsCfg, projectsLogger).Search(ctx, projects.SearchOptions{Query: query})
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	opts := projects.GrepOptions{Pattern: pattern, IgnoreCase: grepCfg.IgnoreCase, Fixed: grepCfg.Fixed}
+
+	var found []projectMatches
+	for _, result := range results {
+		if result.Workspace != "" {
+			continue
+		}
+
+		matches, err := projects.Grep(ctx, result.Project.Path, opts)
+		if err != nil {
+			return fmt.Errorf("failed to search %s: %w", result.Project.String(), err)
+		}
+		if len(matches) > 0 {
+			found = append(found, projectMatches{Project: result.Project, Matches: matches})
+		}
+	}
+
+	if len(found) == 0 {
+		return fmt.Errorf("%w: no line matches '%s'", projects.ErrNoMatch, pattern)
+	}
+
+	if !grepCfg.Open {
+		printGrepMatches(os.Stdout, found)
+		return nil
+	}
+
+	var labels []string
+	var hits []string
+	var lines []int
+	for _, pm := range found {
+		for _, m := range pm.Matches {
+			labels = append(labels, fmt.Sprintf("%s %s:%d: %s", pm.Project.String(), m.Path, m.Line, truncateLine(m.Text)))
+			hits = append(hits, filepath.Join(pm.Project.Path, m.Path))
+			lines = append(lines, m.Line)
+		}
+	}
+
+	i := 0
+	if len(hits) > 1 {
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+			printGrepMatches(os.Stderr, found)
+			return &exitError{code: exitCodeAmbiguous, err: fmt.Errorf("'%s' matches %d lines, narrow the pattern or search to open one", pattern, len(hits))}
+		}
+		if i, err = promptSelect(os.Stdin, os.Stderr, labels); err != nil {
+			return err
+		}
+	}
+
+	return openInEditor(ctx, hits[i], lines[i])
+}
+
+// printGrepMatches writes the matches grouped by project.
+func printGrepMatches(w io.Writer, found []projectMatches) {
+	for i, pm := range found {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, pm.Project.String())
+		for _, m := range pm.Matches {
+			fmt.Fprintf(w, "  %s:%d: %s\n", m.Path, m.Line, truncateLine(m.Text))
+		}
+	}
+}
+
+// truncateLine trims line and shortens it to grepMaxLineLength runes.
+func truncateLine(line string) string {
+	line = strings.TrimSpace(line)
+	if runes := []rune(line); len(runes) > grepMaxLineLength {
+		return string(runes[:grepMaxLineLength]) + "…"
+	}
+	return line
+}
+
+// openInEditor opens path at line in $VISUAL or $EDITOR, defaulting to vi.
+func openInEditor(ctx context.Context, path string, line int) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	args := append(fields[1:], editorArgs(fields[0], path, line)...)
+
+	cmd := exec.CommandContext(ctx, fields[0], args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", editor, err)
+	}
+	return nil
+}
+
+// editorArgs returns the arguments opening path at line in editor: --goto
+// for VS Code and its forks, and the +line of vi, emacs, nano and others.
+func editorArgs(editor, path string, line int) []string {
+	switch filepath.Base(editor) {
+	case "code", "codium", "cursor":
+		return []string{"--goto", path + ":" + strconv.Itoa(line)}
+	default:
+		return []string{"+" + strconv.Itoa(line), path}
+	}
+}
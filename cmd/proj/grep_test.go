@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := []struct {
+		editor string
+		want   []string
+	}{
+		{"vim", []string{"+12", "/code/user/api/main.go"}},
+		{"/usr/bin/nano", []string{"+12", "/code/user/api/main.go"}},
+		{"code", []string{"--goto", "/code/user/api/main.go:12"}},
+	}
+
+	for _, tt := range tests {
+		if got := editorArgs(tt.editor, "/code/user/api/main.go", 12); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("editorArgs(%q) = %v, want %v", tt.editor, got, tt.want)
+		}
+	}
+}
+
+func TestPrintGrepMatches(t *testing.T) {
+	var out strings.Builder
+	printGrepMatches(&out, []projectMatches{
+		{
+			Project: &projects.Project{Organisation: "user", Name: "api"},
+			Matches: []projects.GrepMatch{{Path: "main.go", Line: 3, Text: "\t// TODO: handle errors"}},
+		},
+		{
+			Project: &projects.Project{Organisation: "user", Name: "web"},
+			Matches: []projects.GrepMatch{{Path: "app.js", Line: 1, Text: strings.Repeat("x", 300)}},
+		},
+	})
+
+	want := "user/api\n  main.go:3: // TODO: handle errors\n\nuser/web\n  app.js:1: " + strings.Repeat("x", grepMaxLineLength) + "…\n"
+	if out.String() != want {
+		t.Errorf("printGrepMatches() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+	"github.com/gfanton/projects/internal/index"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type indexConfig struct {
+	GitHub bool
+	Token  string
+}
+
+func newIndexCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	indexCfg := &indexConfig{}
+	fs := ff.NewFlagSet("index")
+	fs.BoolVar(&indexCfg.GitHub, 0, "github", "fetch descriptions from the GitHub API when a project has no README")
+	fs.StringVar(&indexCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+
+	return &ff.Command{
+		Name:      "index",
+		Usage:     "proj index [flags] | proj index <subcommand>",
+		ShortHelp: "Build the project description index",
+		LongHelp: `Build the opt-in description index used by 'proj query --desc'.
+
+Each project's description is taken from the first heading of its README.
+With --github, projects without a README description are looked up on the
+GitHub API instead. The origin remote of each project is recorded too, letting
+'proj get --reference auto' find local clones of the same repository.
+
+The index is stored in the user cache directory and can be refreshed at any
+time by running this command again.
+
+Commands:
+  export    Export the index as a portable JSON snapshot
+  import    Import a snapshot written by 'proj index export'
+
+Examples:
+  proj index
+  proj index --github
+  proj query --desc kubernetes`,
+		Flags: fs,
+		Subcommands: []*ff.Command{
+			newIndexExportCommand(projectsCfg, projectsLogger),
+			newIndexImportCommand(projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return runIndex(ctx, logger, cfg, projectsCfg, projectsLogger, *indexCfg)
+		},
+	}
+}
+
+func runIndex(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, indexCfg indexConfig) error {
+	var ghClient *github.Client
+	if indexCfg.GitHub {
+		ghClient = newGitHubClient(ctx, logger, cfg, indexCfg.Token)
+	}
+
+	indexPath, total, described, err := updateIndex(ctx, logger, projectsCfg, projectsLogger, ghClient)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Indexed %d/%d projects\n", described, total)
+	fmt.Printf("Index: %s\n", indexPath)
+	return nil
+}
+
+// updateIndex updates the description index with the projects of the root
+// directory, looking up the descriptions missing from their README with
+// ghClient when not nil. It returns the path of the index, and the number
+// of projects and of the described ones.
+func updateIndex(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, ghClient *github.Client) (indexPath string, total, described int, err error) {
+	indexPath, err = index.DefaultPath()
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	idx, err := index.Load(indexPath)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
+	err = projectSvc.Walk(func(d fs.DirEntry, p *projects.Project) error {
+		total++
+
+		entry := index.Entry{
+			Description: index.ReadmeDescription(p.Path),
+			Source:      index.SourceReadme,
+			Remote:      p.RemoteURL(),
+			UpdatedAt:   time.Now(),
+		}
+
+		// Only the projects hosted on GitHub have a description there
+		if entry.Description == "" && ghClient != nil && p.Host == "" {
+			repo, err := ghClient.Repository(ctx, p.Organisation, p.Name)
+			if err != nil {
+				logger.Debug("failed to fetch github description", "project", p.String(), "error", err)
+			} else {
+				entry.Description = repo.Description
+				entry.Source = index.SourceGitHub
+			}
+		}
+
+		if entry.Description == "" && entry.Remote == "" {
+			return nil
+		}
+
+		if entry.Description != "" {
+			described++
+		}
+		idx.Set(p.Path, entry)
+		logger.Debug("indexed project", "project", p.String(), "description", entry.Description)
+		return nil
+	})
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return "", 0, 0, fmt.Errorf("failed to walk projects: %w", err)
+	}
+
+	if err := idx.Save(indexPath); err != nil {
+		return "", 0, 0, err
+	}
+	return indexPath, total, described, nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/index"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type indexExportConfig struct {
+	Output string
+	Tags   bool
+}
+
+func newIndexExportCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	exportCfg := &indexExportConfig{}
+	fs := ff.NewFlagSet("index export")
+	fs.StringVar(&exportCfg.Output, 'o', "output", "-", "file to write the snapshot to, - for stdout")
+	fs.BoolVar(&exportCfg.Tags, 0, "tags", "also export the tags of projects")
+
+	return &ff.Command{
+		Name:      "export",
+		Usage:     "proj index export [flags]",
+		ShortHelp: "Export the index as a portable JSON snapshot",
+		LongHelp: `Export the description index as a portable JSON snapshot, to seed the index
+of another machine or a container with 'proj index import'.
+
+Projects are identified by their org/name in the snapshot, so it can be
+imported under another root directory. With --tags, the tags of the projects
+('git config --add proj.tag <tag>') are exported too.
+
+Examples:
+  proj index export --tags -o proj-index.json
+  proj index export | ssh host proj index import -`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runIndexExport(projectsCfg, projectsLogger, *exportCfg)
+		},
+	}
+}
+
+func runIndexExport(projectsCfg *projects.Config, projectsLogger projects.Logger, exportCfg indexExportConfig) error {
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+
+	snapshot := idx.Export(projectsCfg.RootDir, time.Now())
+
+	if exportCfg.Tags {
+		err := projects.NewProjectService(projectsCfg, projectsLogger).Walk(func(d fs.DirEntry, p *projects.Project) error {
+			if tags := p.Tags(); len(tags) > 0 {
+				entry := snapshot.Projects[p.String()]
+				entry.Tags = tags
+				snapshot.Projects[p.String()] = entry
+			}
+			return nil
+		})
+		if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+			return fmt.Errorf("failed to walk projects: %w", err)
+		}
+	}
+
+	if exportCfg.Output == "-" {
+		return index.WriteSnapshot(os.Stdout, snapshot)
+	}
+
+	f, err := os.Create(exportCfg.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	if err := index.WriteSnapshot(f, snapshot); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d projects to %s\n", len(snapshot.Projects), exportCfg.Output)
+	return nil
+}
+
+type indexImportConfig struct {
+	Tags bool
+}
+
+func newIndexImportCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	importCfg := &indexImportConfig{}
+	fs := ff.NewFlagSet("index import")
+	fs.BoolVar(&importCfg.Tags, 0, "tags", "also add the exported tags to the cloned projects")
+
+	return &ff.Command{
+		Name:      "import",
+		Usage:     "proj index import [flags] <file|->",
+		ShortHelp: "Import a snapshot written by 'proj index export'",
+		LongHelp: `Import a snapshot written by 'proj index export' into the description index,
+reading it from stdin with -.
+
+Entries are imported for projects that are not cloned yet, so the index can
+be seeded before cloning. Existing entries updated after the snapshot ones
+are kept.
+
+With --tags, the exported tags are added to the projects already cloned;
+tags of projects not cloned are skipped, import again once they are.
+
+Examples:
+  proj index import proj-index.json
+  proj index import --tags proj-index.json`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("snapshot file is required (- for stdin)")
+			}
+			return runIndexImport(ctx, projectsCfg, *importCfg, args[0])
+		},
+	}
+}
+
+func runIndexImport(ctx context.Context, projectsCfg *projects.Config, importCfg indexImportConfig, file string) error {
+	if importCfg.Tags {
+		if err := projectsCfg.CheckWritable("tag projects"); err != nil {
+			return err
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("failed to open snapshot: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	snapshot, err := index.ReadSnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	idx, err := index.Load(indexPath)
+	if err != nil {
+		return err
+	}
+
+	imported := idx.Import(snapshot, projectsCfg.RootDir)
+	if err := idx.Save(indexPath); err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d entries into %s\n", imported, indexPath)
+
+	if !importCfg.Tags {
+		return nil
+	}
+
+	tagged, notCloned := importTags(ctx, projectsCfg.RootDir, snapshot)
+	fmt.Printf("Tagged %d projects", tagged)
+	if notCloned > 0 {
+		fmt.Printf(", skipped %d not cloned", notCloned)
+	}
+	fmt.Println()
+	return nil
+}
+
+// importTags adds the tags of the snapshot to the projects cloned under
+// rootDir, returning the number of projects tagged and not cloned. Failures
+// are reported as warnings.
+func importTags(ctx context.Context, rootDir string, snapshot *index.Snapshot) (tagged, notCloned int) {
+	names := make([]string, 0, len(snapshot.Projects))
+	for name, entry := range snapshot.Projects {
+		if len(entry.Tags) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		org, projectName, _ := strings.Cut(name, "/")
+		p := &projects.Project{Path: filepath.Join(rootDir, org, projectName), Organisation: org, Name: projectName}
+		if !p.IsGitRepository() {
+			notCloned++
+			continue
+		}
+
+		if err := p.AddTags(ctx, snapshot.Projects[name].Tags...); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to tag %s: %v\n", name, err)
+			continue
+		}
+		tagged++
+	}
+	return tagged, notCloned
+}
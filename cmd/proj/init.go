@@ -5,57 +5,131 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/gfanton/projects/internal/config"
 	"github.com/gfanton/projects/pkg/template"
 	"github.com/peterbourgon/ff/v4"
 )
 
+type initConfig struct {
+	Cmd       string
+	NoAliases bool
+	Prompt    string
+	Idle      bool
+	WarmCache bool
+}
+
 func newInitCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
+	initCfg := &initConfig{}
+	fs := ff.NewFlagSet("init")
+	fs.StringVar(&initCfg.Cmd, 0, "cmd", template.DefaultCmd, "name of the navigation command")
+	fs.BoolVar(&initCfg.NoAliases, 0, "no-aliases", "don't define the navigation command, only helper functions")
+	fs.StringVar(&initCfg.Prompt, 0, "prompt", "", "also wire the project prompt segment into "+strings.Join(template.PromptNames, " or ")+" (zsh only)")
+	fs.BoolVar(&initCfg.Idle, 0, "idle", "record the use of workspaces on directory change and remind of the idle ones on startup")
+	fs.BoolVar(&initCfg.WarmCache, 0, "warm-cache", "warm the caches of completions in the background on startup")
+
 	return &ff.Command{
 		Name:      "init",
-		Usage:     "proj init <shell>",
+		Usage:     "proj init [flags] <shell>",
 		ShortHelp: "Generate shell integration script",
 		LongHelp: `Generate shell integration script for the specified shell.
 
 Supported shells:
-  zsh    Generate zsh integration script
+  zsh       Generate zsh integration script
+  elvish    Generate elvish integration script
+
+Use --cmd to rename the navigation command (default: p), or --no-aliases to
+only define the __project_* helper functions and bind them yourself.
+
+With --prompt, the current project and workspace ('proj prompt') is shown in
+the starship or powerlevel10k (p10k) prompt. The segment is cached in the
+PROJ_PROMPT variable and refreshed on directory change, so prompts stay fast;
+the script ends with the prompt configuration to add.
+
+With --idle, entering a workspace records its use, and the shell prints a
+reminder on startup when workspaces were not used for longer than
+workspace.idle-after (see 'proj workspace idle').
+
+With --warm-cache, the shell warms the caches of completions in the
+background when it starts (see 'proj completion cache warm').
 
 Example:
-  eval "$(proj init zsh)"`,
+  eval "$(proj init zsh)"
+  eval "$(proj init --cmd j zsh)"
+  eval "$(proj init --prompt starship zsh)"
+  eval "$(proj init --idle zsh)"
+  eval "$(proj init --warm-cache zsh)"
+  eval (proj init elvish | slurp)`,
+		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
-			return runInit(ctx, logger, cfg, args)
+			return runInit(ctx, logger, cfg, *initCfg, args)
 		},
 	}
 }
 
-func runInit(_ context.Context, _ *slog.Logger, _ *config.Config, args []string) error {
+func runInit(_ context.Context, _ *slog.Logger, _ *config.Config, initCfg initConfig, args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("exactly one shell argument required")
 	}
 
+	if !isValidCmdName(initCfg.Cmd) {
+		return fmt.Errorf("invalid command name: %q", initCfg.Cmd)
+	}
+
 	shell := args[0]
+	if initCfg.Prompt != "" {
+		if !slices.Contains(template.PromptNames, initCfg.Prompt) {
+			return fmt.Errorf("unsupported prompt: %s (expected %s)", initCfg.Prompt, strings.Join(template.PromptNames, " or "))
+		}
+		if shell != "zsh" {
+			return fmt.Errorf("--prompt is only supported with zsh")
+		}
+	}
+
 	switch shell {
-	case "zsh":
-		return generateZshInit()
+	case "zsh", "elvish":
+		return generateShellInit(shell, initCfg)
 	default:
 		return fmt.Errorf("unsupported shell: %s", shell)
 	}
 }
 
-func generateZshInit() error {
+// isValidCmdName reports whether name can safely be used as a shell
+// function name.
+func isValidCmdName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func generateShellInit(shell string, initCfg initConfig) error {
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
 	data := template.Data{
-		Exec: execPath,
+		Exec:      execPath,
+		Cmd:       initCfg.Cmd,
+		NoAliases: initCfg.NoAliases,
+		Prompt:    initCfg.Prompt,
+		Idle:      initCfg.Idle,
+		WarmCache: initCfg.WarmCache,
 	}
 
-	output, err := template.Render("zsh", data)
+	output, err := template.Render(shell, data)
 	if err != nil {
-		return fmt.Errorf("failed to render zsh template: %w", err)
+		return fmt.Errorf("failed to render %s template: %w", shell, err)
 	}
 
 	fmt.Print(output)
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newIssueCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "issue",
+		Usage:     "proj issue <subcommand>",
+		ShortHelp: "Browse GitHub issues of projects",
+		LongHelp: `Browse GitHub issues of projects.
+
+Commands:
+  list [project]    List open issues
+
+When inside a project directory, the project parameter is optional.
+Use 'proj workspace add --issue N' to start working on an issue.`,
+		Subcommands: []*ff.Command{
+			newIssueListCommand(logger, cfg, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+type issueListConfig struct {
+	Token string
+}
+
+func newIssueListCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	listCfg := &issueListConfig{}
+	fs := ff.NewFlagSet("issue list")
+	fs.StringVar(&listCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj issue list [flags] [project]",
+		ShortHelp: "List open issues",
+		LongHelp: `List the open issues of a project from the GitHub API, with their number,
+title, author and labels. Pull requests are left out, see 'proj pr list'.
+
+The project's organisation and name are used as the GitHub repository.
+
+Examples:
+  proj issue list
+  proj issue list gfanton/projects
+  proj workspace add --issue 42`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+
+			return runIssueList(ctx, logger, cfg, projectsCfg, projectsLogger, projectStr, *listCfg)
+		},
+	}
+}
+
+func runIssueList(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, projectStr string, listCfg issueListConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	if err := checkGitHubProject(proj); err != nil {
+		return err
+	}
+
+	client := newGitHubClient(ctx, logger, cfg, listCfg.Token)
+	issues, err := client.Issues(ctx, proj.Organisation, proj.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list issues: %w", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("No open issues for %s\n", proj.String())
+		return nil
+	}
+
+	printIssues(os.Stdout, issues)
+	return nil
+}
+
+// printIssues writes one issue per line: number, title, author and labels.
+func printIssues(w io.Writer, issues []github.Issue) {
+	for _, issue := range issues {
+		labels := make([]string, 0, len(issue.Labels))
+		for _, label := range issue.Labels {
+			labels = append(labels, label.Name)
+		}
+		line := fmt.Sprintf("#%-6d %-50s @%-15s %s", issue.Number, truncate(issue.Title, 50), issue.User.Login, strings.Join(labels, ","))
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/github"
+)
+
+func TestPrintIssues(t *testing.T) {
+	var issue github.Issue
+	issue.Number = 42
+	issue.Title = "List issues"
+	issue.User.Login = "octocat"
+	issue.Labels = []github.Label{{Name: "enhancement"}, {Name: "good first issue"}}
+
+	var out strings.Builder
+	printIssues(&out, []github.Issue{issue})
+
+	line := out.String()
+	for _, want := range []string{"#42", "List issues", "@octocat", "enhancement,good first issue\n"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("printIssues() = %q, missing %q", line, want)
+		}
+	}
+}
+
+func TestCheckGitHubProject(t *testing.T) {
+	if err := checkGitHubProject(&projects.Project{Organisation: "gfanton", Name: "projects"}); err != nil {
+		t.Errorf("checkGitHubProject(github project) = %v, want nil", err)
+	}
+
+	proj := &projects.Project{Host: "git.corp.com", Organisation: "team", Name: "app"}
+	err := checkGitHubProject(proj)
+	if err == nil || !strings.Contains(err.Error(), "git.corp.com/team/app") {
+		t.Errorf("checkGitHubProject(%s) = %v, want a not a GitHub project error", proj, err)
+	}
+}
@@ -2,24 +2,52 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/gfanton/projects"
 	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/match"
+	"github.com/gfanton/projects/internal/tableprint"
 	"github.com/peterbourgon/ff/v4"
 )
 
+// ---- Grouping Keys
+const (
+	groupByOrg  = "org"
+	groupByLang = "lang"
+	groupByTag  = "tag"
+)
+
 type listConfig struct {
-	All bool
+	All     bool
+	Pinned  bool
+	GroupBy string
+	Tree    bool
+	Wide    bool
+	Format  string
+	Print0  bool
 }
 
 func newListCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
 	listCfg := &listConfig{}
 	fs := ff.NewFlagSet("list")
 	fs.BoolVar(&listCfg.All, 0, "all", "display all projects (including non-Git directories)")
+	fs.BoolVar(&listCfg.Pinned, 0, "pinned", "display only pinned projects (see 'proj pin')")
+	fs.StringVar(&listCfg.GroupBy, 0, "group-by", "", "group projects by org, lang or tag")
+	fs.BoolVar(&listCfg.Tree, 0, "tree", "render groups as a tree (groups by org unless --group-by is set)")
+	fs.BoolVar(&listCfg.Wide, 'w', "wide", "show the branch, upstream state, stashes and last commit of projects")
+	fs.StringVar(&listCfg.Format, 0, "format", "", "print each project with a Go template (see below)")
+	fs.BoolVar(&listCfg.Print0, '0', "print0", "print the path of each project terminated by a NUL byte (for xargs -0)")
 
 	return &ff.Command{
 		Name:      "list",
@@ -29,27 +57,94 @@ func newListCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projec
 
 Optionally provide a prefix to filter projects by name.
 
-By default, only Git repositories are shown. Use --all to show all directories.`,
+By default, only Git repositories are shown. Use --all to show all directories.
+Use --pinned to show only the projects pinned with 'proj pin'.
+
+Grouping:
+  --group-by org     Group projects by organisation
+  --group-by lang    Group projects by detected language (go.mod, package.json, ...)
+  --group-by tag     Group projects by tag (set with 'git config --add proj.tag <tag>')
+  --tree             Render groups as a tree
+
+With --wide, each project is shown with its current branch, remote-tracking
+state (ahead/behind its upstream), stash count and last commit age. They are
+computed concurrently and cached until the Git state of the project changes.
+Long project names are truncated for the table to fit in the terminal.
+
+With -0, the absolute path of each project is printed terminated by a NUL
+byte instead of a newline, safe to pipe to 'xargs -0' whatever the path. It
+also terminates --format output.
+
+` + formatHelp + `
+
+Examples:
+  proj list --format '{{.Org}}\t{{.Name}}\t{{.Path}}'
+  proj list --format '{{.Project}} {{join .Tags ","}}'
+  proj list -0 | xargs -0 du -sh`,
 		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			var prefix string
 			if len(args) > 0 {
 				prefix = args[0]
 			}
-			return runList(ctx, logger, projectsCfg, projectsLogger, *listCfg, prefix)
+			c, err := newColors(cfg, os.Stdout)
+			if err != nil {
+				return err
+			}
+			return runList(ctx, logger, c, projectsCfg, projectsLogger, *listCfg, prefix)
 		},
 	}
 }
 
-func runList(_ context.Context, _ *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, listCfg listConfig, prefix string) error {
+func runList(ctx context.Context, _ *slog.Logger, c *colors, projectsCfg *projects.Config, projectsLogger projects.Logger, listCfg listConfig, prefix string) error {
+	groupBy := listCfg.GroupBy
+	if groupBy == "" && listCfg.Tree {
+		groupBy = groupByOrg
+	}
+
+	switch groupBy {
+	case "", groupByOrg, groupByLang, groupByTag:
+	default:
+		return fmt.Errorf("invalid --group-by value '%s' (expected org, lang or tag)", groupBy)
+	}
+
+	if listCfg.Wide && groupBy != "" {
+		return errors.New("--wide can't be combined with --group-by or --tree")
+	}
+	if listCfg.Print0 && (listCfg.Wide || groupBy != "") {
+		return errors.New("--print0 can't be combined with --wide, --group-by or --tree")
+	}
+
+	var tmpl *template.Template
+	if listCfg.Format != "" {
+		if listCfg.Wide || groupBy != "" {
+			return errors.New("--format can't be combined with --wide, --group-by or --tree")
+		}
+
+		var err error
+		if tmpl, err = parseFormat(listCfg.Format); err != nil {
+			return err
+		}
+	}
+
 	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
 
-	return projectSvc.Walk(func(d fs.DirEntry, p *projects.Project) error {
+	var pinned map[string]bool
+	if listCfg.Pinned {
+		pinned = projects.NewPinService(projectsCfg, projectsLogger).PinnedSet()
+	}
+
+	var entries []listEntry
+	err := projectSvc.Walk(func(d fs.DirEntry, p *projects.Project) error {
 		// Skip if prefix is provided and project doesn't match
 		if prefix != "" && !hasPrefix(p.String(), prefix) {
 			return nil
 		}
 
+		if pinned != nil && !pinned[p.String()] {
+			return nil
+		}
+
 		status := p.GetGitStatus()
 
 		// Skip non-Git directories unless --all is specified
@@ -57,11 +152,172 @@ func runList(_ context.Context, _ *slog.Logger, projectsCfg *projects.Config, pr
 			return nil
 		}
 
-		fmt.Printf("%s - [%s]\n", p.String(), status)
+		if tmpl != nil {
+			item := newFormatItem(p, "", p.Path)
+			item.Status = string(status)
+			return executeFormat(os.Stdout, tmpl, item, listCfg.Print0)
+		}
+
+		if listCfg.Print0 {
+			fmt.Printf("%s\x00", p.Path)
+			return nil
+		}
+
+		if groupBy == "" && !listCfg.Wide {
+			fmt.Printf("%s - [%s]\n", c.project(p), c.gitStatus(status))
+			return nil
+		}
+
+		entries = append(entries, listEntry{
+			project: p,
+			status:  status,
+			groups:  projectGroups(p, groupBy),
+		})
 		return nil
 	})
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return err
+	}
+
+	switch {
+	case listCfg.Wide:
+		projs := make([]*projects.Project, len(entries))
+		for i, e := range entries {
+			projs[i] = e.project
+		}
+		healths := projects.NewHealthService(projectsCfg, projectsLogger).Collect(ctx, projs)
+		renderWide(os.Stdout, c, entries, healths, time.Now(), tableprint.TerminalWidth(os.Stdout))
+	case groupBy != "":
+		renderGroups(os.Stdout, c, groupBy, entries, listCfg.Tree)
+	}
+
+	return nil
+}
+
+// listEntry is a project collected for grouped output.
+type listEntry struct {
+	project *projects.Project
+	status  projects.GitStatus
+	groups  []string
+}
+
+// label returns the project as displayed inside a group. Projects grouped by
+// org only show their name since the org is already the group header.
+func (e listEntry) label(c *colors, groupBy string) string {
+	if groupBy == groupByOrg {
+		return c.paint(roleProject, e.project.Name)
+	}
+	return c.project(e.project)
+}
+
+// projectGroups returns the groups a project belongs to.
+func projectGroups(p *projects.Project, groupBy string) []string {
+	switch groupBy {
+	case groupByLang:
+		if lang := p.Language(); lang != "" {
+			return []string{lang}
+		}
+		return []string{"(unknown)"}
+	case groupByTag:
+		if tags := p.Tags(); len(tags) > 0 {
+			return tags
+		}
+		return []string{"(untagged)"}
+	default:
+		return []string{p.Organisation}
+	}
+}
+
+// renderGroups writes entries grouped by their groups, sorted by group name,
+// with a project count per group.
+func renderGroups(w io.Writer, c *colors, groupBy string, entries []listEntry, tree bool) {
+	groups := make(map[string][]listEntry)
+	for _, e := range entries {
+		for _, g := range e.groups {
+			groups[g] = append(groups[g], e)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		members := groups[name]
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].project.String() < members[j].project.String()
+		})
+
+		header := name
+		if groupBy == groupByOrg {
+			header = c.paint(roleOrg, name)
+		}
+		fmt.Fprintf(w, "%s (%d)\n", header, len(members))
+		for i, e := range members {
+			indent := "  "
+			if tree {
+				indent = "├── "
+				if i == len(members)-1 {
+					indent = "└── "
+				}
+			}
+			fmt.Fprintf(w, "%s%s - [%s]\n", indent, e.label(c, groupBy), c.gitStatus(e.status))
+		}
+	}
+}
+
+// renderWide writes entries as a table with the health of each project,
+// healths being in the order of entries, truncating long project names for
+// lines to fit in maxWidth columns when not zero.
+func renderWide(w io.Writer, c *colors, entries []listEntry, healths []projects.Health, now time.Time, maxWidth int) {
+	t := tableprint.New("PROJECT", "BRANCH", "REMOTE", "STASHES", "LAST COMMIT")
+	t.MaxWidth = maxWidth
+	for i, e := range entries {
+		if e.status != projects.GitStatusValid {
+			t.Append(c.project(e.project), "["+c.gitStatus(e.status)+"]", "-", "-", "-")
+			continue
+		}
+
+		health := healths[i]
+		branch := health.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+
+		stashes := "-"
+		if health.Stashes > 0 {
+			stashes = strconv.Itoa(health.Stashes)
+		}
+
+		t.Append(c.project(e.project), c.paint(roleBranch, branch), formatTracking(health), stashes, formatAge(now, health.LastCommit))
+	}
+	t.Write(w)
+}
+
+// formatTracking returns the remote-tracking state of health, such as
+// "up to date", "↑2 ↓1", "gone" or "no upstream".
+func formatTracking(health projects.Health) string {
+	switch {
+	case health.Upstream == "":
+		return "no upstream"
+	case health.Gone:
+		return "gone"
+	case health.Ahead == 0 && health.Behind == 0:
+		return "up to date"
+	}
+
+	var parts []string
+	if health.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", health.Ahead))
+	}
+	if health.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", health.Behind))
+	}
+	return strings.Join(parts, " ")
 }
 
 func hasPrefix(projectName, prefix string) bool {
-	return strings.HasPrefix(strings.ToLower(projectName), strings.ToLower(prefix))
+	return strings.HasPrefix(match.Normalize(projectName), match.Normalize(prefix))
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gfanton/projects"
+)
+
+func TestRenderGroups(t *testing.T) {
+	entries := []listEntry{
+		{project: &projects.Project{Organisation: "bob", Name: "zeta"}, status: projects.GitStatusValid, groups: []string{"bob"}},
+		{project: &projects.Project{Organisation: "alice", Name: "app"}, status: projects.GitStatusValid, groups: []string{"alice"}},
+		{project: &projects.Project{Organisation: "bob", Name: "alpha"}, status: projects.GitStatusInvalid, groups: []string{"bob"}},
+	}
+
+	tests := []struct {
+		name     string
+		tree     bool
+		expected string
+	}{
+		{
+			name: "indented",
+			expected: `alice (1)
+  app - [valid]
+bob (2)
+  alpha - [invalid]
+  zeta - [valid]
+`,
+		},
+		{
+			name: "tree",
+			tree: true,
+			expected: `alice (1)
+└── app - [valid]
+bob (2)
+├── alpha - [invalid]
+└── zeta - [valid]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			renderGroups(&buf, nil, groupByOrg, entries, tt.tree)
+			if got := buf.String(); got != tt.expected {
+				t.Errorf("renderGroups() =\n%s\nwant:\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderGroupsMultipleTags(t *testing.T) {
+	entries := []listEntry{
+		{project: &projects.Project{Organisation: "org", Name: "api"}, status: projects.GitStatusValid, groups: []string{"work", "go"}},
+	}
+
+	var buf strings.Builder
+	renderGroups(&buf, nil, groupByTag, entries, false)
+
+	expected := "go (1)\n  org/api - [valid]\nwork (1)\n  org/api - [valid]\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("renderGroups() =\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestRenderWide(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	entries := []listEntry{
+		{project: &projects.Project{Organisation: "user", Name: "api"}, status: projects.GitStatusValid},
+		{project: &projects.Project{Organisation: "user", Name: "web"}, status: projects.GitStatusValid},
+		{project: &projects.Project{Organisation: "user", Name: "notes"}, status: projects.GitStatusNotGit},
+	}
+	healths := []projects.Health{
+		{Branch: "main", Upstream: "origin/main", Ahead: 2, Behind: 1, Stashes: 3, LastCommit: now.Add(-2 * time.Hour)},
+		{Upstream: "origin/feature", Gone: true, LastCommit: now.Add(-72 * time.Hour)},
+		{},
+	}
+
+	var buf strings.Builder
+	renderWide(&buf, nil, entries, healths, now, 0)
+
+	expected := `PROJECT     BRANCH       REMOTE  STASHES  LAST COMMIT
+user/api    main         ↑2 ↓1   3        2h ago
+user/web    (detached)   gone    -        3d ago
+user/notes  [not a git]  -       -        -
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("renderWide() =\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestFormatTracking(t *testing.T) {
+	tests := []struct {
+		health projects.Health
+		want   string
+	}{
+		{projects.Health{}, "no upstream"},
+		{projects.Health{Upstream: "origin/main"}, "up to date"},
+		{projects.Health{Upstream: "origin/main", Behind: 4}, "↓4"},
+		{projects.Health{Upstream: "origin/main", Gone: true}, "gone"},
+	}
+
+	for _, tt := range tests {
+		if got := formatTracking(tt.health); got != tt.want {
+			t.Errorf("formatTracking(%+v) = %q, want %q", tt.health, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects/internal/logfile"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// logsPollInterval is how often the log file is checked with --follow.
+const logsPollInterval = 500 * time.Millisecond
+
+type logsConfig struct {
+	Lines  int
+	Follow bool
+	JSON   bool
+}
+
+func newLogsCommand() *ff.Command {
+	logsCfg := &logsConfig{}
+	fs := ff.NewFlagSet("logs")
+	fs.IntVar(&logsCfg.Lines, 'n', "lines", 20, "number of recent entries to show")
+	fs.BoolVar(&logsCfg.Follow, 'f', "follow", "keep printing new entries")
+	fs.BoolVar(&logsCfg.JSON, 0, "json", "print raw JSON entries")
+
+	return &ff.Command{
+		Name:      "logs",
+		Usage:     "proj logs [flags]",
+		ShortHelp: "Show recent log file entries",
+		LongHelp: `Show recent entries of the proj log file.
+
+Logging to the file is enabled with 'log = true' in the config file or
+PROJECT_LOG=true, and records debug entries of every proj process, including
+the ones started by tmux or the shell integration where stderr is invisible.
+
+The log file is $XDG_STATE_HOME/proj/proj.log (~/.local/state by default),
+rotated above 5 MiB.
+
+Examples:
+  proj logs
+  proj logs -n 100
+  proj logs --follow`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runLogs(ctx, os.Stdout, *logsCfg)
+		},
+	}
+}
+
+func runLogs(ctx context.Context, w io.Writer, logsCfg logsConfig) error {
+	path, err := logfile.DefaultPath()
+	if err != nil {
+		return err
+	}
+
+	lines, err := logfile.Tail(path, logsCfg.Lines)
+	if err != nil {
+		return err
+	}
+
+	if len(lines) == 0 && !logsCfg.Follow {
+		fmt.Fprintf(w, "No log entries in %s (enable with 'log = true' in the config file or PROJECT_LOG=true)\n", path)
+		return nil
+	}
+
+	for _, line := range lines {
+		printLogLine(w, line, logsCfg.JSON)
+	}
+
+	if !logsCfg.Follow {
+		return nil
+	}
+
+	return followLogs(ctx, w, path, logsCfg.JSON)
+}
+
+// followLogs prints lines appended to the log file at path until ctx is
+// done, starting over when the file is rotated.
+func followLogs(ctx context.Context, w io.Writer, path string, raw bool) error {
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	var partial string
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			offset, partial = 0, ""
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		data, err := readFrom(path, offset)
+		if err != nil {
+			return err
+		}
+		offset += int64(len(data))
+
+		// Keep an incomplete last line for the next read
+		chunk := partial + string(data)
+		lines := strings.Split(chunk, "\n")
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			if line != "" {
+				printLogLine(w, line, raw)
+			}
+		}
+	}
+}
+
+// readFrom reads the file at path from offset to its end.
+func readFrom(path string, offset int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek log file: %w", err)
+	}
+	return io.ReadAll(f)
+}
+
+// printLogLine prints a JSON log entry as
+// "time level [cmd:pid] message key=value...", or as is when raw or not JSON.
+func printLogLine(w io.Writer, line string, raw bool) {
+	var entry map[string]any
+	if raw || json.Unmarshal([]byte(line), &entry) != nil {
+		fmt.Fprintln(w, line)
+		return
+	}
+
+	timestamp, _ := entry["time"].(string)
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		timestamp = t.Local().Format("2006-01-02 15:04:05.000")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-5v [%v:%v] %v", timestamp, entry["level"], entry["cmd"], entry["pid"], entry["msg"])
+
+	// Remaining attributes, in stable order
+	var keys []string
+	for key := range entry {
+		switch key {
+		case "time", "level", "cmd", "pid", "msg":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, entry[key])
+	}
+
+	fmt.Fprintln(w, b.String())
+}
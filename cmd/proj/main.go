@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gfanton/projects"
 	"github.com/gfanton/projects/internal/config"
@@ -19,7 +22,9 @@ type rootConfig struct {
 }
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
+	// Interrupts cancel the running Git operations instead of leaving them
+	// behind
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	cfg, err := config.NewConfig()
@@ -33,15 +38,29 @@ func main() {
 		os.Exit(1)
 	}
 
+	if cfg.ErrorFormat != errorFormatText && cfg.ErrorFormat != errorFormatJSON {
+		fmt.Fprintf(os.Stderr, "error: invalid --error-format '%s' (expected text or json)\n", cfg.ErrorFormat)
+		os.Exit(1)
+	}
+
 	logger := cfg.Logger()
 
-	// Create projects config and services
-	projectsCfg := &projects.Config{
-		ConfigFile: cfg.ConfigFile,
-		Debug:      cfg.Debug,
-		RootDir:    cfg.RootDir,
-		RootUser:   cfg.RootUser,
+	if cfg.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	if unknown, err := cfg.UnknownKeys(); err != nil {
+		logger.Warn("failed to check config file keys", "error", err)
+	} else if len(unknown) > 0 {
+		logger.Warn("unknown config file keys, run 'proj config validate' for details", "file", cfg.ConfigFile, "keys", strings.Join(unknown, ", "))
+	}
+	for _, err := range cfg.IncludeErrors() {
+		logger.Warn("skipped config file include", "file", cfg.ConfigFile, "error", err)
 	}
+
+	// Create projects config and services
+	projectsCfg := cfg.ProjectsConfig()
 	projectsLogger := projects.NewSlogAdapter(logger)
 
 	rootCfg := &rootConfig{
@@ -57,6 +76,13 @@ func main() {
 	rootFlags.StringVar(&cfg.RootDir, 0, "root", cfg.RootDir, "root directory for projects")
 	rootFlags.StringVar(&cfg.RootUser, 0, "user", cfg.RootUser, "default user for projects")
 	rootFlags.StringVar(&cfg.ConfigFile, 0, "config", cfg.ConfigFile, "configuration file path")
+	rootFlags.StringVar(&cfg.ErrorFormat, 0, "error-format", cfg.ErrorFormat, "error output format (text|json)")
+	rootFlags.StringVar(&cfg.LogFormat, 0, "log-format", cfg.LogFormat, "stderr log format (text|json)")
+	rootFlags.StringVar(&cfg.Color, 0, "color", cfg.Color, "color output (auto|always|never), auto coloring terminals unless NO_COLOR is set")
+	rootFlags.StringVar(&cfg.GitHubToken, 0, "github-token", cfg.GitHubToken, "GitHub token (default: $GITHUB_TOKEN, gh auth token or keychain)")
+	rootFlags.DurationVar(&cfg.Timeout, 0, "timeout", cfg.Timeout, "timeout of the whole command (0 = none)")
+	rootFlags.BoolVar(&cfg.ReadOnly, 0, "read-only", "refuse operations modifying the root directory (clone, mkdir, worktree add, symlink)")
+	rootFlags.BoolVar(&cfg.Strict, 0, "strict", "fail when directories of the root can't be read instead of using partial results")
 
 	root := &ff.Command{
 		Name:      "proj",
@@ -65,30 +91,94 @@ func main() {
 		LongHelp: `proj is a command-line tool for managing Git projects organized in a GitHub-style
 directory structure. It provides fast project navigation, creation, and management.
 
+Exit codes:
+  0  success
+  1  error
+  2  no match (query found nothing, or the project/workspace does not exist)
+  3  ambiguous (query --select matched several equally good candidates)
+
+Use --error-format json to report errors on stderr as a JSON object with
+"error", "kind", "code" and, for ambiguous queries, "candidates" fields.
+
+Other subcommands run the 'proj-<subcommand>' executable found on PATH, such as
+proj-tmux, with the remaining arguments. The resolved configuration is passed
+through the PROJECT_* environment variables.
+
 Use 'proj <subcommand> -h' for more information about a specific command.`,
 		Flags: rootFlags,
 		Exec: func(ctx context.Context, args []string) error {
-			return ff.ErrHelp
+			if len(args) == 0 {
+				return ff.ErrHelp
+			}
+			return runPlugin(ctx, logger, cfg, projectsCfg, args)
 		},
 		Subcommands: []*ff.Command{
 			newInitCommand(logger, cfg),
+			newSetupCommand(cfg, projectsLogger),
 			newListCommand(logger, cfg, projectsCfg, projectsLogger),
 			newNewCommand(logger, cfg),
+			newTemplateCommand(cfg),
 			newAddCommand(logger, cfg),
 			newGetCommand(logger, cfg),
 			newQueryCommand(logger, cfg, projectsCfg, projectsLogger),
+			newIndexCommand(logger, cfg, projectsCfg, projectsLogger),
+			newRecentCommand(logger, cfg, projectsCfg, projectsLogger),
+			newGrepCommand(projectsCfg, projectsLogger),
+			newPromptCommand(projectsCfg, projectsLogger),
+			newStatusCommand(logger, cfg, projectsCfg, projectsLogger),
+			newPreviewCommand(projectsCfg, projectsLogger),
+			newContextCommand(cfg, projectsCfg, projectsLogger),
+			newPinCommand(projectsCfg, projectsLogger),
+			newUnpinCommand(projectsCfg, projectsLogger),
+			newRunCommand(logger, projectsCfg, projectsLogger),
+			newDirenvCommand(logger, projectsCfg, projectsLogger),
+			newCleanBranchesCommand(projectsCfg, projectsLogger),
+			newOwnersCommand(projectsCfg, projectsLogger),
+			newDiffCommand(projectsCfg, projectsLogger),
 			newWorkspaceCommand(logger, cfg, projectsCfg, projectsLogger),
+			newBackportCommand(projectsCfg, projectsLogger),
+			newPRCommand(logger, cfg, projectsCfg, projectsLogger),
+			newIssueCommand(logger, cfg, projectsCfg, projectsLogger),
+			newDUCommand(logger, cfg, projectsCfg, projectsLogger),
+			newOrgCommand(logger, cfg, projectsCfg, projectsLogger),
+			newConfigCommand(cfg),
+			newLogsCommand(),
+			newSelfUpdateCommand(logger, cfg),
+			newMigrateCommand(projectsCfg, projectsLogger),
 			NewVersionCommand(rootCfg),
 		},
 	}
 
-	if err := root.ParseAndRun(ctx, os.Args[1:]); err != nil {
+	root.Subcommands = append(root.Subcommands, newCompletionCommand(root, logger, cfg, projectsCfg, projectsLogger))
+
+	// Accept the global flags after subcommands too, e.g. 'proj workspace list --root ~/src'
+	config.InheritFlags(root)
+
+	err = root.ParseAndRun(ctx, os.Args[1:])
+	// Let the stale remote includes refreshed in the background be cached
+	// for the next runs
+	cfg.WaitIncludes()
+	if err != nil {
+		var cmdErr *commandExitError
+		if errors.As(err, &cmdErr) {
+			os.Exit(cmdErr.code)
+		}
 		if errors.Is(err, ff.ErrHelp) {
 			fmt.Fprint(os.Stdout, ffhelp.Command(root))
+			if cfg.UpdateCheck {
+				printUpdateHint(ctx, os.Stderr, logger, cfg)
+			}
 			os.Exit(0)
 		}
-		logger.Error("command failed", "error", err)
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		if cfg.ErrorFormat != errorFormatJSON {
+			logger.Error("command failed", "error", err)
+		}
+		os.Exit(writeError(os.Stderr, cfg.ErrorFormat, err))
 	}
 }
+
+// checkWritable returns an error naming op in read-only mode, for the
+// commands modifying the root directory outside of the projects services.
+func checkWritable(cfg *config.Config, op string) error {
+	return cfg.ProjectsConfig().CheckWritable(op)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type migrateConfig struct {
+	To     string
+	DryRun bool
+}
+
+func newMigrateCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	migrateCfg := &migrateConfig{}
+	fs := ff.NewFlagSet("migrate")
+	fs.StringVar(&migrateCfg.To, 0, "to", "latest", "layout version to migrate to, or 'latest'")
+	fs.BoolVar(&migrateCfg.DryRun, 'n', "dry-run", "only list the changes of the migration")
+
+	return &ff.Command{
+		Name:      "migrate",
+		Usage:     "proj migrate [flags]",
+		ShortHelp: "Upgrade the layout of the root directory",
+		LongHelp: `Upgrade the layout of the root directory, the directories proj creates in
+it, to the one of this version of proj, one versioned step after the other.
+Older layouts keep working: migrate to adopt the features that need the new
+one.
+
+The layout version of the root directory, and the changes of each applied
+step, are recorded in $XDG_STATE_HOME/proj (default ~/.local/state/proj), to
+revert them by hand if needed. Before each step, the files it changes, the
+layout state and the config file are backed up in
+$XDG_STATE_HOME/proj/backups. A root directory never migrated is at version 0.
+
+Versions:
+  1    escape the dashes of branch names in workspace directories, also
+       run by 'proj workspace migrate'
+
+Examples:
+  proj migrate --dry-run
+  proj migrate --to latest`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return errors.New("proj migrate takes no arguments")
+			}
+			to, err := parseLayoutVersion(migrateCfg.To)
+			if err != nil {
+				return err
+			}
+			svc := projects.NewLayoutService(projectsCfg, projectsLogger)
+			return runMigrate(ctx, os.Stdout, svc, projectsCfg.RootDir, to, migrateCfg.DryRun)
+		},
+	}
+}
+
+// parseLayoutVersion parses the --to flag of proj migrate.
+func parseLayoutVersion(s string) (int, error) {
+	if s == "latest" {
+		return projects.LayoutVersion, nil
+	}
+	version, err := strconv.Atoi(s)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid layout version %q, want a number or 'latest'", s)
+	}
+	return version, nil
+}
+
+func runMigrate(ctx context.Context, w io.Writer, svc *projects.LayoutService, rootDir string, to int, dryRun bool) error {
+	current, err := svc.Version()
+	if err != nil {
+		return err
+	}
+	steps, err := svc.Plan(ctx, to)
+	if err != nil {
+		return err
+	}
+	if len(steps) == 0 {
+		fmt.Fprintf(w, "Layout of %s is at version %d, nothing to migrate\n", rootDir, current)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Layout of %s at version %d, migrating to %d:\n", rootDir, current, to)
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %d: %s\n", step.Version, step.Description)
+		if len(step.Changes) == 0 {
+			fmt.Fprintln(w, "     nothing to change")
+		}
+		for _, change := range step.Changes {
+			fmt.Fprintf(w, "     %s\n", change.Description)
+		}
+	}
+	if dryRun {
+		return nil
+	}
+
+	applied, err := svc.Migrate(ctx, to)
+	for _, step := range applied {
+		if step.Backup != "" {
+			fmt.Fprintf(w, "Backed up the files changed by version %d to %s\n", step.Version, step.Backup)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Layout migrated to version %d, recorded in %s\n", to, svc.Path())
+	return nil
+}
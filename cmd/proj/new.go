@@ -2,19 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 
 	"github.com/gfanton/projects/internal/config"
 	"github.com/gfanton/projects/internal/project"
+	"github.com/gfanton/projects/internal/scaffold"
 	"github.com/peterbourgon/ff/v4"
 )
 
+type newConfig struct {
+	Template  string
+	Vars      []string
+	PrintPath bool
+}
+
 func newNewCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
+	newCfg := &newConfig{}
+	fs := ff.NewFlagSet("new")
+	fs.StringVar(&newCfg.Template, 't', "template", "", "create the project from this template (see 'proj template list')")
+	fs.StringSetVar(&newCfg.Vars, 0, "var", "value of a template variable, name=value (repeatable)")
+	fs.BoolVar(&newCfg.PrintPath, 0, "print-path", "only print the absolute path of the project on stdout, messages going to stderr")
+
 	return &ff.Command{
 		Name:      "new",
-		Usage:     "proj new <name>",
+		Usage:     "proj new [flags] <name>",
 		ShortHelp: "Create a new project directory",
 		LongHelp: `Create a new project directory in the configured root.
 
@@ -22,16 +37,32 @@ The project name can be:
   - "project" (uses default user from config)
   - "user/project" (explicit user specification)
 
+With --template, the files of a template registered with 'proj template add'
+are copied into the project, replacing the {{name}} placeholders of its
+variables. The template and the variables are checked before the project is
+created.
+
+Go projects become the module {{module}}, <provider>/<org>/<name> such as
+github.com/johndoe/tool unless set with --var module=path: 'go mod init' is
+run when the template has no go.mod, and the module path of its go.mod is
+replaced in go.mod and in the imports of its Go files otherwise.
+
+With --print-path, only the absolute path of the new project is printed on
+stdout, the other messages going to stderr. The shell integration of 'proj
+init' uses it so that 'p new project' creates the project and changes to it.
+
 Example:
   proj new myapp
-  proj new johndoe/webapp`,
+  proj new johndoe/webapp
+  proj new --template go-cli --var author="John Doe" tool`,
+		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
-			return runNew(ctx, logger, cfg, args)
+			return runNew(ctx, logger, cfg, *newCfg, args)
 		},
 	}
 }
 
-func runNew(ctx context.Context, logger *slog.Logger, cfg *config.Config, args []string) error {
+func runNew(ctx context.Context, logger *slog.Logger, cfg *config.Config, newCfg newConfig, args []string) error {
 	if len(args) != 1 {
 		return fmt.Errorf("exactly one project name required")
 	}
@@ -40,20 +71,79 @@ func runNew(ctx context.Context, logger *slog.Logger, cfg *config.Config, args [
 	if err != nil {
 		return fmt.Errorf("failed to parse project name: %w", err)
 	}
+	if err := checkWritable(cfg, "create "+p.String()); err != nil {
+		return err
+	}
 
 	// Check if directory already exists
 	if _, err := os.Stat(p.Path); err == nil {
 		return fmt.Errorf("project directory already exists: %s", p.Path)
 	}
 
+	if newCfg.Template == "" && len(newCfg.Vars) > 0 {
+		return errors.New("--var requires --template")
+	}
+
+	// Validate the template and fetch its files before creating anything
+	var templateDir string
+	var values map[string]string
+	var isGo bool
+	if newCfg.Template != "" {
+		t, ok := cfg.Templates[newCfg.Template]
+		if !ok {
+			return &exitError{
+				code: exitCodeNoMatch,
+				err:  fmt.Errorf("no template %s (see 'proj template list')", newCfg.Template),
+			}
+		}
+		module := p.Provider() + "/" + p.Organisation + "/" + p.Name
+		if values, err = templateValues(t, p.Organisation, p.Name, module, newCfg.Vars); err != nil {
+			return fmt.Errorf("template %s: %w", newCfg.Template, err)
+		}
+
+		dir, cleanup, err := scaffold.Fetch(ctx, t.Source)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		templateDir = dir
+	}
+
 	// Create the directory
 	if err := os.MkdirAll(p.Path, 0755); err != nil {
 		return fmt.Errorf("failed to create project directory: %w", err)
 	}
 
+	if templateDir != "" {
+		files, err := scaffold.Copy(templateDir, p.Path, values)
+		if err == nil {
+			isGo, err = scaffold.GoModule(ctx, p.Path, values["module"])
+		}
+		if err != nil {
+			os.RemoveAll(p.Path)
+			return err
+		}
+		logger.Info("applied template", "template", newCfg.Template, "files", files)
+	}
+
 	logger.Info("created new project", "name", p.String(), "path", p.Path)
-	fmt.Printf("Created project: %s\n", p.String())
-	fmt.Printf("Location: %s\n", p.Path)
 
+	// With --print-path, stdout is for the path only
+	var w io.Writer = os.Stdout
+	if newCfg.PrintPath {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "Created project: %s\n", p.String())
+	fmt.Fprintf(w, "Location: %s\n", p.Path)
+	if templateDir != "" {
+		fmt.Fprintf(w, "Template: %s\n", newCfg.Template)
+	}
+	if isGo {
+		fmt.Fprintf(w, "Go module: %s\n", values["module"])
+	}
+
+	if newCfg.PrintPath {
+		printPath(p.Path)
+	}
 	return nil
 }
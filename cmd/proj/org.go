@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+	"github.com/gfanton/projects/internal/tableprint"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newOrgCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "org",
+		Usage:     "proj org <subcommand>",
+		ShortHelp: "Manage organisations of projects",
+		LongHelp: `Manage organisations: the <root>/<org> directories of projects.
+
+Commands:
+  list                 List organisations with project counts and disk usage
+  stats <org>          Show the projects of an organisation, biggest first
+  clone <org>          Clone the repositories of an organisation missing locally
+  archive <org>        Archive an organisation directory to a tar.gz file`,
+		Subcommands: []*ff.Command{
+			newOrgListCommand(projectsCfg, projectsLogger),
+			newOrgStatsCommand(projectsCfg, projectsLogger),
+			newOrgCloneCommand(logger, cfg),
+			newOrgArchiveCommand(projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+// orgUsage is the disk usage report of an organisation.
+type orgUsage struct {
+	Name     string
+	Projects int
+	Size     int64
+}
+
+func newOrgListCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj org list",
+		ShortHelp: "List organisations with project counts and disk usage",
+		Exec: func(ctx context.Context, args []string) error {
+			orgs, err := projects.NewProjectService(projectsCfg, projectsLogger).Organisations()
+			if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+				return err
+			}
+			if len(orgs) == 0 {
+				fmt.Println("No organisations found")
+				return nil
+			}
+
+			usages := make([]orgUsage, 0, len(orgs))
+			for _, org := range orgs {
+				size, err := projects.DiskUsage(ctx, org.Path, false)
+				if err != nil {
+					return err
+				}
+				usages = append(usages, orgUsage{Name: org.Name, Projects: len(org.Projects), Size: size})
+			}
+
+			printOrgUsage(os.Stdout, usages, tableprint.TerminalWidth(os.Stdout))
+			return nil
+		},
+	}
+}
+
+// printOrgUsage writes the organisation usages, followed by the total,
+// truncating long names for lines to fit in maxWidth columns when not zero.
+func printOrgUsage(w io.Writer, usages []orgUsage, maxWidth int) {
+	var total int64
+	var count int
+	t := tableprint.New()
+	t.MaxWidth = maxWidth
+	t.Right = []int{1, 2}
+	for _, usage := range usages {
+		total += usage.Size
+		count += usage.Projects
+		t.Append(usage.Name, fmt.Sprintf("%d projects", usage.Projects), formatSize(usage.Size))
+	}
+	t.Write(w)
+	fmt.Fprintf(w, "\nTotal: %s in %d projects of %d organisations\n", formatSize(total), count, len(usages))
+}
+
+func newOrgStatsCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "stats",
+		Usage:     "proj org stats <org>",
+		ShortHelp: "Show the projects of an organisation, biggest first",
+		LongHelp: `Show the size, language and last activity of the projects of an
+organisation, biggest first, and the project count per language.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return errors.New("organisation name is required")
+			}
+
+			org, err := projects.NewProjectService(projectsCfg, projectsLogger).Organisation(args[0])
+			if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+				return err
+			}
+
+			var usages []projectUsage
+			for _, p := range org.Projects {
+				size, err := projects.DiskUsage(ctx, p.Path, false)
+				if err != nil {
+					return err
+				}
+				usages = append(usages, projectUsage{Project: p, Size: size})
+			}
+
+			printOrgStats(os.Stdout, usages, time.Now(), tableprint.TerminalWidth(os.Stdout))
+			return nil
+		},
+	}
+}
+
+// printOrgStats writes the project usages biggest first, with their
+// language and last activity, followed by the project count per language,
+// truncating long names for lines to fit in maxWidth columns when not zero.
+func printOrgStats(w io.Writer, usages []projectUsage, now time.Time, maxWidth int) {
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Size > usages[j].Size
+	})
+
+	var total int64
+	languages := make(map[string]int)
+	t := tableprint.New()
+	t.MaxWidth = maxWidth
+	t.Shrink = 1
+	t.Right = []int{0}
+	for _, usage := range usages {
+		total += usage.Size
+
+		language := usage.Project.Language()
+		if language == "" {
+			language = "(unknown)"
+		}
+		languages[language]++
+
+		t.Append(formatSize(usage.Size), usage.Project.Name, language, formatAge(now, usage.Project.LastActivity()))
+	}
+	t.Write(w)
+	fmt.Fprintf(w, "\nTotal: %s in %d projects\n", formatSize(total), len(usages))
+
+	names := make([]string, 0, len(languages))
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "\nLanguages:")
+	t = tableprint.New()
+	t.Indent = "  "
+	for _, name := range names {
+		t.Append(name, strconv.Itoa(languages[name]))
+	}
+	t.Write(w)
+}
+
+type orgCloneConfig struct {
+	Forks    bool
+	Archived bool
+	DryRun   bool
+	Token    string
+	Protocol string
+}
+
+func newOrgCloneCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
+	cloneCfg := &orgCloneConfig{}
+	fs := ff.NewFlagSet("org clone")
+	fs.BoolVar(&cloneCfg.Forks, 0, "forks", "also clone forks")
+	fs.BoolVar(&cloneCfg.Archived, 0, "archived", "also clone archived repositories")
+	fs.BoolVar(&cloneCfg.DryRun, 0, "dry-run", "only list the repositories to clone")
+	fs.StringVar(&cloneCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+	fs.StringVar(&cloneCfg.Protocol, 0, "protocol", "", "clone protocol: auto, ssh or https (default: clone.protocol config)")
+
+	return &ff.Command{
+		Name:      "clone",
+		Usage:     "proj org clone [flags] <org>",
+		ShortHelp: "Clone the repositories of an organisation missing locally",
+		LongHelp: `Clone the GitHub repositories of an organisation, or user, that are not
+in <root>/<org> yet, like 'proj get'. Private repositories are listed when
+visible with the resolved GitHub token. Forks and archived repositories are
+skipped unless --forks or --archived is set.
+
+Examples:
+  proj org clone --dry-run acme
+  proj org clone --protocol ssh acme`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return errors.New("organisation name is required")
+			}
+			return runOrgClone(ctx, logger, cfg, args[0], *cloneCfg)
+		},
+	}
+}
+
+func runOrgClone(ctx context.Context, logger *slog.Logger, cfg *config.Config, org string, cloneCfg orgCloneConfig) error {
+	token := resolveToken(ctx, logger, cfg, cloneCfg.Token)
+
+	client := github.NewClient(logger, token)
+	if useGHCLI(cfg, cloneCfg.Token) {
+		client = client.WithGHCLI()
+	}
+	repos, err := client.OwnerRepositories(ctx, org)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories of %s: %w", org, err)
+	}
+
+	missing := missingRepositories(cfg.RootDir, org, repos, cloneCfg)
+	if len(missing) == 0 {
+		fmt.Printf("All %d repositories of %s are cloned\n", len(repos), org)
+		return nil
+	}
+
+	if cloneCfg.DryRun {
+		for _, name := range missing {
+			fmt.Printf("Would clone: %s\n", name)
+		}
+		return nil
+	}
+
+	return runGet(ctx, logger, cfg, getConfig{Token: token, Protocol: cloneCfg.Protocol}, missing)
+}
+
+// missingRepositories returns the "org/name" of the repos without project
+// directory under rootDir, skipping forks and archived repositories unless
+// requested.
+func missingRepositories(rootDir, org string, repos []github.Repository, cloneCfg orgCloneConfig) []string {
+	var missing []string
+	for _, repo := range repos {
+		if (repo.Fork && !cloneCfg.Forks) || (repo.Archived && !cloneCfg.Archived) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(rootDir, org, repo.Name)); err == nil {
+			continue
+		}
+		missing = append(missing, org+"/"+repo.Name)
+	}
+	return missing
+}
+
+type orgArchiveConfig struct {
+	Output string
+	Remove bool
+}
+
+func newOrgArchiveCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	archiveCfg := &orgArchiveConfig{}
+	fs := ff.NewFlagSet("org archive")
+	fs.StringVar(&archiveCfg.Output, 'o', "output", "", "archive file path (default: ./<org>-<date>.tar.gz)")
+	fs.BoolVar(&archiveCfg.Remove, 0, "remove", "remove the organisation directory once archived")
+
+	return &ff.Command{
+		Name:      "archive",
+		Usage:     "proj org archive [flags] <org>",
+		ShortHelp: "Archive an organisation directory to a tar.gz file",
+		LongHelp: `Archive the <root>/<org> directory, including the Git history and the
+uncommitted changes of its projects, to a tar.gz file.
+
+With --remove, the directory is removed once archived. Organisations with
+workspaces can't be removed: remove them first with 'proj workspace remove'.
+
+Examples:
+  proj org archive acme
+  proj org archive --remove -o ~/archives/acme.tar.gz acme`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return errors.New("organisation name is required")
+			}
+			return runOrgArchive(ctx, projectsCfg, projectsLogger, args[0], *archiveCfg, time.Now())
+		},
+	}
+}
+
+func runOrgArchive(ctx context.Context, projectsCfg *projects.Config, projectsLogger projects.Logger, name string, archiveCfg orgArchiveConfig, now time.Time) error {
+	org, err := projects.NewProjectService(projectsCfg, projectsLogger).Organisation(name)
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return err
+	}
+
+	if archiveCfg.Remove {
+		if err := projectsCfg.CheckWritable("remove organisation " + org.Name); err != nil {
+			return err
+		}
+		workspacesDir := filepath.Join(projects.NewWorkspaceService(projectsCfg, projectsLogger).WorkspaceDir(), org.Name)
+		if entries, err := os.ReadDir(workspacesDir); err == nil && len(entries) > 0 {
+			return fmt.Errorf("organisation %s has workspaces in %s, remove them before --remove", org.Name, workspacesDir)
+		}
+	}
+
+	output := archiveCfg.Output
+	if output == "" {
+		output = fmt.Sprintf("%s-%s.tar.gz", org.Name, now.Format("20060102"))
+	}
+	// An archive written inside the organisation would be archived with it,
+	// and removed with it by --remove
+	if output, err = filepath.Abs(output); err != nil {
+		return fmt.Errorf("failed to resolve archive path: %w", err)
+	}
+	if pathWithin(output, org.Path) {
+		return fmt.Errorf("archive %s is inside %s, choose another --output", output, org.Path)
+	}
+	if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("%s already exists", output)
+	}
+
+	// Write to a temporary file so that failures don't leave a partial archive
+	tmp, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := projects.ArchiveDir(ctx, org.Path, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	fmt.Printf("Archived %s (%d projects) to %s\n", org.Name, len(org.Projects), output)
+
+	if !archiveCfg.Remove {
+		return nil
+	}
+
+	if _, err := os.Stat(output); err != nil || pathWithin(output, org.Path) {
+		return fmt.Errorf("archive %s not found outside %s, keeping the organisation", output, org.Path)
+	}
+	if err := os.RemoveAll(org.Path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", org.Path, err)
+	}
+	fmt.Printf("Removed %s\n", org.Path)
+	return nil
+}
+
+// pathWithin reports whether path is dir or inside it, following the
+// symbolic links of their existing parents.
+func pathWithin(path, dir string) bool {
+	resolve := func(p string) string {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			return resolved
+		}
+		if resolved, err := filepath.EvalSymlinks(filepath.Dir(p)); err == nil {
+			return filepath.Join(resolved, filepath.Base(p))
+		}
+		return filepath.Clean(p)
+	}
+	rel, err := filepath.Rel(resolve(dir), resolve(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/github"
+)
+
+func TestMissingRepositories(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootDir, "acme", "cloned"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := []github.Repository{
+		{Name: "cloned"},
+		{Name: "api"},
+		{Name: "fork", Fork: true},
+		{Name: "old", Archived: true},
+	}
+
+	tests := []struct {
+		name     string
+		cloneCfg orgCloneConfig
+		want     []string
+	}{
+		{"default", orgCloneConfig{}, []string{"acme/api"}},
+		{"forks", orgCloneConfig{Forks: true}, []string{"acme/api", "acme/fork"}},
+		{"archived", orgCloneConfig{Archived: true}, []string{"acme/api", "acme/old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := missingRepositories(rootDir, "acme", repos, tt.cloneCfg)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("missingRepositories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintOrgUsage(t *testing.T) {
+	var out strings.Builder
+	printOrgUsage(&out, []orgUsage{
+		{Name: "acme", Projects: 3, Size: 2 << 30},
+		{Name: "user", Projects: 1, Size: 1 << 10},
+	}, 0)
+
+	for _, want := range []string{"acme", "2.0 GiB", "Total: ", "in 4 projects of 2 organisations"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRunOrgArchiveOutsideOrg(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "bob", "api")
+	if err := os.MkdirAll(filepath.Join(project, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	projectsCfg := &projects.Config{RootDir: root, RootUser: "bob"}
+	now := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// The default output is relative to the working directory: from inside
+	// the organisation, the archive would be removed with it
+	t.Chdir(project)
+	if err := runOrgArchive(context.Background(), projectsCfg, &mockLogger{}, "bob", orgArchiveConfig{Remove: true}, now); err == nil {
+		t.Fatal("runOrgArchive() inside the organisation should fail")
+	}
+	if _, err := os.Stat(project); err != nil {
+		t.Fatalf("organisation should be kept: %v", err)
+	}
+	if entries, _ := os.ReadDir(project); len(entries) != 2 {
+		t.Errorf("organisation holds %d entries, want no archive written in it", len(entries))
+	}
+
+	output := filepath.Join(t.TempDir(), "bob.tar.gz")
+	if err := runOrgArchive(context.Background(), projectsCfg, &mockLogger{}, "bob", orgArchiveConfig{Output: output, Remove: true}, now); err != nil {
+		t.Fatalf("runOrgArchive() failed: %v", err)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("archive should exist: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "bob")); !os.IsNotExist(err) {
+		t.Errorf("organisation should be removed, stat error = %v", err)
+	}
+}
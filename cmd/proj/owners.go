@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type ownersConfig struct {
+	Base  string
+	Files bool
+}
+
+func newOwnersCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	ownersCfg := &ownersConfig{}
+	fs := ff.NewFlagSet("owners")
+	fs.StringVar(&ownersCfg.Base, 0, "base", "", "branch to compare with (default: the default branch)")
+	fs.BoolVar(&ownersCfg.Files, 'f', "files", "list the owners of each changed file")
+
+	return &ff.Command{
+		Name:      "owners",
+		Usage:     "proj owners [flags] [project]",
+		ShortHelp: "Show the code owners of the changes against the default branch",
+		LongHelp: `Show the owners, from the CODEOWNERS file, of the files changed against the
+default branch, or --base: the reviewers to expect before opening a pull
+request.
+
+Changed files are the ones modified by the commits since the merge base with
+the base branch, and the uncommitted ones. CODEOWNERS is read from .github/,
+the root or docs/ of the checkout, and the last matching rule of a file
+gives its owners, as on GitHub.
+
+If the project parameter is not provided, the current directory must be
+inside a project or one of its workspaces.
+
+Examples:
+  proj owners
+  proj owners --files --base release
+  proj owners gfanton/projects`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+			if len(args) > 1 {
+				return errors.New("proj owners takes at most one project")
+			}
+
+			proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+			if err != nil {
+				return err
+			}
+
+			dir := proj.CheckoutPath()
+			if projectStr == "" {
+				wd, err := os.Getwd()
+				if err != nil {
+					return fmt.Errorf("failed to get working directory: %w", err)
+				}
+				dir = contextDir(projects.NewWorkspaceService(projectsCfg, projectsLogger), proj, wd)
+			}
+
+			return runOwners(ctx, os.Stdout, projects.NewProjectService(projectsCfg, projectsLogger), proj, dir, *ownersCfg)
+		},
+	}
+}
+
+func runOwners(ctx context.Context, out io.Writer, projectSvc *projects.ProjectService, proj *projects.Project, dir string, ownersCfg ownersConfig) error {
+	owners, err := projects.LoadCodeOwners(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", proj.String(), err)
+	}
+
+	base := ownersCfg.Base
+	if base == "" {
+		if base, err = projectSvc.DefaultBranch(ctx, proj); err != nil {
+			return err
+		}
+	}
+
+	files, err := projectSvc.ChangedFiles(dir, base)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(out, "No changes against %s\n", base)
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var unowned []string
+	for _, file := range files {
+		fileOwners := owners.Owners(file)
+		if ownersCfg.Files {
+			if len(fileOwners) == 0 {
+				fmt.Fprintf(out, "%s\t(no owner)\n", file)
+			} else {
+				fmt.Fprintf(out, "%s\t%s\n", file, strings.Join(fileOwners, " "))
+			}
+		}
+		if len(fileOwners) == 0 {
+			unowned = append(unowned, file)
+		}
+		for _, owner := range fileOwners {
+			counts[owner]++
+		}
+	}
+	if ownersCfg.Files {
+		fmt.Fprintln(out)
+	}
+
+	// Owners of the most files first
+	names := make([]string, 0, len(counts))
+	for owner := range counts {
+		names = append(names, owner)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	fmt.Fprintf(out, "Owners of %s against %s:\n", plural(len(files), "changed file"), base)
+	for _, owner := range names {
+		fmt.Fprintf(out, "  %-30s %s\n", owner, plural(counts[owner], "file"))
+	}
+	if len(unowned) > 0 {
+		fmt.Fprintf(out, "  %-30s %s\n", "(no owner)", plural(len(unowned), "file"))
+	}
+	return nil
+}
+
+// plural returns n followed by noun, with an s unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
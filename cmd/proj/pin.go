@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newPinCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "pin",
+		Usage:     "proj pin [project...]",
+		ShortHelp: "Pin projects above the others in queries",
+		LongHelp: `Pin projects so that they sort above the other matches of 'proj query' and
+the 'p' navigation command. Use 'proj list --pinned' to list them and
+'proj unpin' to unpin them.
+
+Pins are kept per root directory in $XDG_STATE_HOME/proj (default
+~/.local/state/proj).
+
+If no project is provided, the current directory must be inside a project.
+
+Examples:
+  proj pin
+  proj pin gfanton/projects work/api`,
+		Exec: func(ctx context.Context, args []string) error {
+			projs, err := resolveProjects(projectsCfg, projectsLogger, args)
+			if err != nil {
+				return err
+			}
+			for _, proj := range projs {
+				if _, err := os.Stat(proj.Path); err != nil {
+					return &exitError{code: exitCodeNoMatch, err: fmt.Errorf("project %s does not exist", proj.String())}
+				}
+			}
+
+			svc := projects.NewPinService(projectsCfg, projectsLogger)
+			pinned := svc.PinnedSet()
+			if _, err := svc.Pin(projs...); err != nil {
+				return err
+			}
+			for _, proj := range projs {
+				if pinned[proj.String()] {
+					fmt.Printf("%s is already pinned\n", proj.String())
+				} else {
+					fmt.Printf("Pinned %s\n", proj.String())
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newUnpinCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "unpin",
+		Usage:     "proj unpin [project...]",
+		ShortHelp: "Unpin projects",
+		LongHelp: `Unpin projects pinned with 'proj pin'.
+
+If no project is provided, the current directory must be inside a project.
+
+Examples:
+  proj unpin
+  proj unpin gfanton/projects`,
+		Exec: func(ctx context.Context, args []string) error {
+			projs, err := resolveProjects(projectsCfg, projectsLogger, args)
+			if err != nil {
+				return err
+			}
+
+			svc := projects.NewPinService(projectsCfg, projectsLogger)
+			pinned := svc.PinnedSet()
+			if _, err := svc.Unpin(projs...); err != nil {
+				return err
+			}
+			for _, proj := range projs {
+				if pinned[proj.String()] {
+					fmt.Printf("Unpinned %s\n", proj.String())
+				} else {
+					fmt.Printf("%s is not pinned\n", proj.String())
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// resolveProjects resolves the projects of args, or the current project
+// without args.
+func resolveProjects(projectsCfg *projects.Config, projectsLogger projects.Logger, args []string) ([]*projects.Project, error) {
+	if len(args) == 0 {
+		proj, err := resolveProject(projectsCfg, projectsLogger, "")
+		if err != nil {
+			return nil, err
+		}
+		return []*projects.Project{proj}, nil
+	}
+
+	projs := make([]*projects.Project, 0, len(args))
+	for _, arg := range args {
+		proj, err := resolveProject(projectsCfg, projectsLogger, arg)
+		if err != nil {
+			return nil, err
+		}
+		projs = append(projs, proj)
+	}
+	return projs, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/plugin"
+)
+
+// pluginPrefix is the executable name prefix of external proj commands:
+// 'proj foo' runs proj-foo from PATH when foo is not a built-in command.
+const pluginPrefix = "proj-"
+
+// commandExitError reports the non-zero exit code of an external command,
+// such as a plugin or a task, which already reported its own error.
+type commandExitError struct {
+	code int
+}
+
+func (e *commandExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.code)
+}
+
+// findPlugin returns the path of the external command executable of name.
+func findPlugin(name string) (string, bool) {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// runPlugin runs the external command of args[0] with the remaining args,
+// the resolved configuration passed through PROJECT_* environment variables
+// and, except on Windows, the plugin.Context through a pipe.
+func runPlugin(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, args []string) error {
+	path, ok := findPlugin(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command '%s' (no %s%s executable found on PATH), see 'proj --help'", args[0], pluginPrefix, args[0])
+	}
+
+	logger.Debug("running plugin", "path", path, "args", args[1:])
+
+	cmd := exec.CommandContext(ctx, path, args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), pluginEnv(cfg)...)
+
+	var contextPipe *os.File
+	if runtime.GOOS != "windows" {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return fmt.Errorf("failed to create plugin context pipe: %w", err)
+		}
+
+		// ExtraFiles entries are file descriptors 3 and up in the child
+		cmd.ExtraFiles = []*os.File{r}
+		cmd.Env = append(cmd.Env, plugin.ContextFDEnv+"=3")
+		contextPipe = w
+	}
+
+	err := cmd.Start()
+	for _, f := range cmd.ExtraFiles {
+		f.Close()
+	}
+	if err != nil {
+		if contextPipe != nil {
+			contextPipe.Close()
+		}
+		return fmt.Errorf("failed to run %s: %w", path, err)
+	}
+
+	// The read end is only open in the plugin: plugins not reading the
+	// context fail the write on exit instead of blocking it
+	if contextPipe != nil {
+		go func() {
+			defer contextPipe.Close()
+			if err := newPluginContext(cfg, projectsCfg).Write(contextPipe); err != nil {
+				logger.Debug("plugin context not read", "error", err)
+			}
+		}()
+	}
+
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &commandExitError{code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", path, err)
+	}
+	return nil
+}
+
+// newPluginContext returns the plugin context of the resolved configuration
+// and working directory.
+func newPluginContext(cfg *config.Config, projectsCfg *projects.Config) *plugin.Context {
+	pctx := plugin.NewContext(cfg)
+	pctx.Version = version
+	if wd, err := os.Getwd(); err == nil {
+		pctx.Project = plugin.FindProject(projectsCfg, wd)
+	}
+	return pctx
+}
+
+// pluginEnv returns the environment variables passing the resolved
+// configuration to external commands, which load it with plugin.Load or
+// config.Load like built-in ones. Secrets such as the GitHub token are not passed.
+func pluginEnv(cfg *config.Config) []string {
+	return []string{
+		"PROJECT_CONFIG=" + cfg.ConfigFile,
+		"PROJECT_ROOT=" + cfg.RootDir,
+		"PROJECT_USER=" + cfg.RootUser,
+		"PROJECT_DEBUG=" + strconv.FormatBool(cfg.Debug),
+		"PROJECT_RANK=" + cfg.Rank,
+		"PROJECT_ERROR_FORMAT=" + cfg.ErrorFormat,
+		"PROJECT_LOG=" + strconv.FormatBool(cfg.Log),
+		"PROJECT_LOG_FORMAT=" + cfg.LogFormat,
+		"PROJECT_COLOR=" + cfg.Color,
+		"PROJECT_COLOR_THEME=" + cfg.ColorTheme,
+		"PROJECT_CLONE_PROTOCOL=" + cfg.CloneProtocol,
+		"PROJECT_CLONE_HOSTS=" + cfg.CloneHosts,
+		"PROJECT_WORKSPACE_SUBMODULES=" + strconv.FormatBool(cfg.WorkspaceSubmodules),
+		"PROJECT_WORKSPACE_DIRENV=" + strconv.FormatBool(cfg.WorkspaceDirenv),
+		"PROJECT_READ_ONLY=" + strconv.FormatBool(cfg.ReadOnly),
+		"PROJECT_STRICT=" + strconv.FormatBool(cfg.Strict),
+		"PROJECT_WALK_ALLOW_HIDDEN=" + cfg.WalkAllowHidden,
+		"PROJECT_CLONE_TIMEOUT=" + cfg.CloneTimeout.String(),
+		"PROJECT_NETWORK_TIMEOUT=" + cfg.NetworkTimeout.String(),
+		"PROJECT_RETRY_ATTEMPTS=" + strconv.Itoa(cfg.RetryAttempts),
+		"PROJECT_RETRY_BACKOFF=" + cfg.RetryBackoff.String(),
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFindPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable lookup differs on windows")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "proj-hello"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"hello", true},
+		{"missing", false},
+		{"-h", false},
+		{"../hello", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		path, ok := findPlugin(tt.name)
+		if ok != tt.want {
+			t.Errorf("findPlugin(%q) = %q, %v, want %v", tt.name, path, ok, tt.want)
+		}
+	}
+}
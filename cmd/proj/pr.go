@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newPRCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "pr",
+		Usage:     "proj pr <subcommand>",
+		ShortHelp: "Browse GitHub pull requests of projects",
+		LongHelp: `Browse GitHub pull requests of projects.
+
+Commands:
+  list [project]    List open pull requests
+
+When inside a project directory, the project parameter is optional.`,
+		Subcommands: []*ff.Command{
+			newPRListCommand(logger, cfg, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+type prListConfig struct {
+	Token    string
+	Checkout int
+}
+
+func newPRListCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	listCfg := &prListConfig{}
+	fs := ff.NewFlagSet("pr list")
+	fs.StringVar(&listCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+	fs.IntVar(&listCfg.Checkout, 0, "checkout", 0, "create the workspace of the given pull request number")
+
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj pr list [flags] [project]",
+		ShortHelp: "List open pull requests",
+		LongHelp: `List the open pull requests of a project from the GitHub API, with their
+number, title, author and branch.
+
+The project's organisation and name are used as the GitHub repository.
+With --checkout, the '#N' workspace of the pull request is created, as with
+'proj workspace add #N'.
+
+Examples:
+  proj pr list
+  proj pr list gfanton/projects
+  proj pr list --checkout 42`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+
+			return runPRList(ctx, logger, cfg, projectsCfg, projectsLogger, projectStr, *listCfg)
+		},
+	}
+}
+
+func runPRList(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, projectStr string, listCfg prListConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	if err := checkGitHubProject(proj); err != nil {
+		return err
+	}
+
+	client := newGitHubClient(ctx, logger, cfg, listCfg.Token)
+	prs, err := client.PullRequests(ctx, proj.Organisation, proj.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if listCfg.Checkout != 0 {
+		return checkoutPR(ctx, projectsCfg, projectsLogger, proj, prs, listCfg.Checkout)
+	}
+
+	if len(prs) == 0 {
+		fmt.Printf("No open pull requests for %s\n", proj.String())
+		return nil
+	}
+
+	printPullRequests(os.Stdout, prs)
+	return nil
+}
+
+// checkoutPR creates the workspace of the open pull request number.
+func checkoutPR(ctx context.Context, projectsCfg *projects.Config, projectsLogger projects.Logger, proj *projects.Project, prs []github.PullRequest, number int) error {
+	var found bool
+	for _, pr := range prs {
+		if pr.Number == number {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return &exitError{
+			code: exitCodeNoMatch,
+			err:  fmt.Errorf("no open pull request #%d for %s", number, proj.String()),
+		}
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	return svc.Add(ctx, *proj, fmt.Sprintf("#%d", number))
+}
+
+// printPullRequests writes one pull request per line: number, title,
+// author and branch.
+func printPullRequests(w io.Writer, prs []github.PullRequest) {
+	for _, pr := range prs {
+		title := pr.Title
+		if pr.Draft {
+			title = "[draft] " + title
+		}
+		fmt.Fprintf(w, "#%-6d %-50s @%-15s %s\n", pr.Number, truncate(title, 50), pr.User.Login, pr.Head.Ref)
+	}
+}
+
+// truncate shortens s to at most n runes, ending with an ellipsis when cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
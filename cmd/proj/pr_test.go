@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects/internal/github"
+)
+
+func TestPrintPullRequests(t *testing.T) {
+	var pr github.PullRequest
+	pr.Number = 42
+	pr.Title = "Add PR listing"
+	pr.Draft = true
+	pr.User.Login = "octocat"
+	pr.Head.Ref = "feature/pr"
+
+	var out strings.Builder
+	printPullRequests(&out, []github.PullRequest{pr})
+
+	line := out.String()
+	for _, want := range []string{"#42", "[draft] Add PR listing", "@octocat", "feature/pr"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("printPullRequests() = %q, missing %q", line, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate() = %q, want %q", got, "short")
+	}
+	if got := truncate("a longer title", 8); got != "a longe…" {
+		t.Errorf("truncate() = %q, want %q", got, "a longe…")
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type previewConfig struct {
+	Lines int
+	Width int
+	Plain bool
+}
+
+func newPreviewCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	previewCfg := &previewConfig{}
+	fs := ff.NewFlagSet("preview")
+	fs.IntVar(&previewCfg.Lines, 0, "lines", 200, "maximum number of README lines shown (0 = all)")
+	fs.IntVar(&previewCfg.Width, 0, "width", 0, "width the README is rendered at (default: $FZF_PREVIEW_COLUMNS, or 80)")
+	fs.BoolVar(&previewCfg.Plain, 0, "plain", "show the README as is, without rendering Markdown")
+
+	return &ff.Command{
+		Name:      "preview",
+		Usage:     "proj preview [flags] <project[:workspace]>",
+		ShortHelp: "Show a project summary and its README",
+		LongHelp: `Show the path, branch, last commit and language of a project or workspace,
+followed by its README, to tell similarly named repositories apart in a picker.
+
+Markdown READMEs are rendered with glow when it is installed, and shown as
+is otherwise. The argument is a project or a query result such as
+'org/name:branch', so the command fits the --preview of fzf.
+
+Examples:
+  proj preview gfanton/projects
+  proj list | fzf --preview 'proj preview {}'`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("proj preview takes exactly one project")
+			}
+
+			return runPreview(ctx, os.Stdout, projectsCfg, projectsLogger, args[0], *previewCfg)
+		},
+	}
+}
+
+func runPreview(ctx context.Context, out io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, target string, previewCfg previewConfig) error {
+	// Drop the decorations of list and query results, e.g. "org/name - [go]"
+	target, _, _ = strings.Cut(strings.TrimSpace(target), " ")
+	projectStr, branch, _ := strings.Cut(target, ":")
+
+	proj, err := projects.NewProjectService(projectsCfg, projectsLogger).ParseProject(projectStr)
+	if err != nil {
+		return err
+	}
+
+	dir := proj.CheckoutPath()
+	if branch != "" {
+		dir = projects.NewWorkspaceService(projectsCfg, projectsLogger).WorkspacePath(*proj, branch)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return &exitError{code: exitCodeNoMatch, err: fmt.Errorf("%s does not exist", target)}
+	}
+
+	printPreviewSummary(out, proj, branch, dir, time.Now())
+
+	readme := projects.FindReadme(dir)
+	if readme == "" {
+		fmt.Fprintln(out, "\nNo README")
+		return nil
+	}
+	fmt.Fprintln(out)
+	return printReadme(ctx, out, readme, previewCfg)
+}
+
+// printPreviewSummary writes the path, branch, last commit and language of
+// the project or workspace at dir.
+func printPreviewSummary(out io.Writer, proj *projects.Project, branch, dir string, now time.Time) {
+	name := proj.String()
+	if branch != "" {
+		name += ":" + branch
+	}
+	fmt.Fprintln(out, name)
+	fmt.Fprintf(out, "  path:     %s\n", dir)
+
+	if head, err := projects.ReadHead(dir); err == nil {
+		if head.Branch != "" {
+			fmt.Fprintf(out, "  branch:   %s\n", head.Branch)
+		} else {
+			fmt.Fprintf(out, "  branch:   detached at %s\n", shortHash(head.Hash))
+		}
+		fmt.Fprintf(out, "  commit:   %s %s (%s, %s)\n", shortHash(head.Hash), head.Subject, head.Author, formatAge(now, head.When))
+	}
+	if language := proj.Language(); language != "" {
+		fmt.Fprintf(out, "  language: %s\n", language)
+	}
+}
+
+// printReadme writes the README at path, rendered with glow when it is
+// Markdown and glow is installed, limited to previewCfg.Lines lines.
+func printReadme(ctx context.Context, out io.Writer, path string, previewCfg previewConfig) error {
+	var content io.Reader
+	if glow, err := exec.LookPath("glow"); err == nil && !previewCfg.Plain && isMarkdown(path) {
+		width := previewCfg.Width
+		if width <= 0 {
+			width, _ = strconv.Atoi(os.Getenv("FZF_PREVIEW_COLUMNS"))
+		}
+		if width <= 0 {
+			width = 80
+		}
+
+		cmd := exec.CommandContext(ctx, glow, "--style", "dark", "--width", strconv.Itoa(width), path)
+		if rendered, err := cmd.Output(); err == nil {
+			content = strings.NewReader(string(rendered))
+		}
+	}
+	if content == nil {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read README: %w", err)
+		}
+		defer f.Close()
+		content = f
+	}
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 0; scanner.Scan(); n++ {
+		if previewCfg.Lines > 0 && n >= previewCfg.Lines {
+			fmt.Fprintln(out, "...")
+			break
+		}
+		fmt.Fprintln(out, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read README: %w", err)
+	}
+	return nil
+}
+
+// isMarkdown reports whether the README at path is written in Markdown.
+func isMarkdown(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".md" || ext == ".markdown"
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestRunPreview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "gfanton", "projects")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	readme := "# projects\n\nline 1\nline 2\nline 3\n"
+	for name, content := range map[string]string{"README.md": readme, "go.mod": "module example.com/projects\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"add", "."},
+		{"commit", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com", "GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	projectsCfg := &projects.Config{RootDir: root}
+	var out bytes.Buffer
+	if err := runPreview(context.Background(), &out, projectsCfg, &mockLogger{}, "gfanton/projects - [go]", previewConfig{Lines: 3, Plain: true}); err != nil {
+		t.Fatalf("runPreview() error = %v", err)
+	}
+	for _, want := range []string{"gfanton/projects\n", "branch:   main", "Initial commit (alice, just now)", "language: go", "# projects\n\nline 1\n...\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("preview missing %q:\n%s", want, out.String())
+		}
+	}
+
+	var exitErr *exitError
+	if err := runPreview(context.Background(), &out, projectsCfg, &mockLogger{}, "gfanton/missing", previewConfig{}); !errors.As(err, &exitErr) {
+		t.Errorf("runPreview(missing) error = %v, want an exit error", err)
+	}
+}
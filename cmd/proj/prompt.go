@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// starshipModule is the starship custom module showing the current project.
+const starshipModule = `[custom.proj]
+description = "Current proj project and workspace"
+command = "proj prompt"
+when = true
+format = "[$output]($style) "
+style = "bold blue"
+`
+
+type promptConfig struct {
+	Starship bool
+}
+
+func newPromptCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	promptCfg := &promptConfig{}
+	fs := ff.NewFlagSet("prompt")
+	fs.BoolVar(&promptCfg.Starship, 0, "starship", "print the starship custom module showing the current project")
+
+	return &ff.Command{
+		Name:      "prompt",
+		Usage:     "proj prompt [flags] [dir]",
+		ShortHelp: "Print the current project for shell prompts",
+		LongHelp: `Print the project containing the current directory, or dir, as org/name,
+followed by :branch inside a workspace. Nothing is printed outside projects,
+so the output can be embedded as is in a shell prompt. The project is found
+from the path and the workspace branch from its Git HEAD file, without
+running git.
+
+With --starship, the starship custom module running this command is printed
+instead, to add to ~/.config/starship.toml.
+
+Examples:
+  # zsh, in ~/.zshrc
+  setopt PROMPT_SUBST
+  RPROMPT='$(proj prompt)'
+
+  # starship
+  proj prompt --starship >> ~/.config/starship.toml`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if promptCfg.Starship {
+				fmt.Print(starshipModule)
+				return nil
+			}
+
+			dir := ""
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runPrompt(os.Stdout, projectsCfg, projectsLogger, dir)
+		},
+	}
+}
+
+func runPrompt(w io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, dir string) error {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil // deleted directory: nothing to show
+		}
+		dir = wd
+	}
+
+	proj, err := projects.NewProjectService(projectsCfg, projectsLogger).FindFromPath(dir)
+	if err != nil {
+		return nil
+	}
+
+	segment := proj.String()
+	if branch := workspaceBranch(projects.NewWorkspaceService(projectsCfg, projectsLogger), proj, dir); branch != "" {
+		segment += ":" + branch
+	}
+
+	fmt.Fprintln(w, segment)
+	return nil
+}
+
+// workspaceBranch returns the branch of the workspace of proj containing
+// dir, or an empty string when dir is in the main checkout.
+func workspaceBranch(svc *projects.WorkspaceService, proj *projects.Project, dir string) string {
+	workspace := contextDir(svc, proj, dir)
+	if workspace == proj.CheckoutPath() {
+		return ""
+	}
+
+	if branch, ok := projects.WorktreeBranch(workspace); ok {
+		return branch
+	}
+	// Detached or unreadable HEAD: fall back to the workspace directory name
+	return projects.DecodeBranch(filepath.Base(workspace))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestRunPrompt(t *testing.T) {
+	root := t.TempDir()
+	projectsCfg := &projects.Config{RootDir: root}
+
+	projDir := filepath.Join(root, "user", "proj")
+	gitDir := filepath.Join(projDir, ".git", "worktrees", "feature--x")
+	workspace := filepath.Join(root, ".workspace", "user", "proj", "feature--x")
+	detached := filepath.Join(root, ".workspace", "user", "proj", "fix--y")
+	for _, dir := range []string{filepath.Join(projDir, "src"), gitDir, filepath.Join(workspace, "src"), detached} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(gitDir, "HEAD"):    "ref: refs/heads/feature/x\n",
+		filepath.Join(workspace, ".git"): "gitdir: " + gitDir + "\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{filepath.Join(projDir, "src"), "user/proj\n"},
+		{filepath.Join(workspace, "src"), "user/proj:feature/x\n"},
+		{detached, "user/proj:fix/y\n"},
+		{root, ""},
+		{t.TempDir(), ""},
+	}
+
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := runPrompt(&sb, projectsCfg, &mockLogger{}, tt.dir); err != nil {
+			t.Fatalf("runPrompt(%q) failed: %v", tt.dir, err)
+		}
+		if sb.String() != tt.want {
+			t.Errorf("runPrompt(%q) = %q, want %q", tt.dir, sb.String(), tt.want)
+		}
+	}
+}
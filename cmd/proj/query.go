@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/gfanton/projects"
 	"github.com/gfanton/projects/internal/config"
@@ -18,6 +25,16 @@ type queryConfig struct {
 	Separator    string
 	Limit        int
 	ShowDistance bool
+	Rank         string
+	Sort         string
+	Descriptions bool
+	Annotate     bool
+	Complete     bool
+	RefreshCache bool
+	Select       bool
+	First        bool
+	Format       string
+	Print0       bool
 }
 
 func newQueryCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
@@ -26,8 +43,18 @@ func newQueryCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *proje
 	fs.StringSetVar(&queryCfg.Exclude, 0, "exclude", "exclude project path (repeatable)")
 	fs.BoolVar(&queryCfg.AbsPath, 0, "abspath", "return absolute paths instead of project names")
 	fs.StringVar(&queryCfg.Separator, 0, "sep", "\n", "separator between results")
+	fs.BoolVar(&queryCfg.Print0, '0', "print0", "terminate each result with a NUL byte instead of a separator (for xargs -0)")
 	fs.IntVar(&queryCfg.Limit, 0, "limit", 20, "limit number of results (0 = no limit)")
-	fs.BoolVar(&queryCfg.ShowDistance, 'v', "", "show distance with matching projects")
+	fs.BoolVar(&queryCfg.ShowDistance, 'v', "", "show distance with matching projects, highlighting the matches in terminals")
+	fs.BoolVar(&queryCfg.Select, 0, "select", "print the single best match; prompt on a TTY or fail with exit code 3 if ambiguous")
+	fs.BoolVar(&queryCfg.First, 0, "first", "print the single best match, picking the first one if ambiguous")
+	fs.BoolVar(&queryCfg.Complete, 0, "complete", "answer from the completion cache only, refreshing it in the background")
+	fs.BoolVar(&queryCfg.RefreshCache, 0, "refresh-cache", "rebuild the completion cache and exit")
+	fs.BoolVar(&queryCfg.Annotate, 0, "annotate", "append a tab-separated description to each result (used by shell completion)")
+	fs.BoolVar(&queryCfg.Descriptions, 0, "desc", "also match project descriptions (requires 'proj index')")
+	fs.StringVar(&queryCfg.Rank, 0, "rank", cfg.Rank, "ranking algorithm: "+strings.Join(projects.RankNames, "|"))
+	fs.StringVar(&queryCfg.Sort, 0, "sort", projects.SortRelevance, "result order: "+strings.Join(projects.SortNames, "|"))
+	fs.StringVar(&queryCfg.Format, 0, "format", "", "print each result with a Go template (see below)")
 
 	return &ff.Command{
 		Name:      "query",
@@ -44,12 +71,54 @@ Workspace search (requires ':' syntax):
   proj query :feature                 # Search workspaces named "feature" in all projects
   proj query foo:                     # List all workspaces in projects matching "foo"
 
+Directory search (requires '//' syntax):
+  proj query mono//services/auth      # Directory services/auth of project "mono"
+  proj query mono//ser                # Top-level directories of "mono" starting with "ser"
+  proj query //src                    # Directory src of the current project
+
+Negative filters (use '--' before terms starting with '-'):
+  proj query api !archived            # Hide projects whose name contains "archived"
+  proj query -- api -org:work         # Hide projects in the "work" organisation
+  proj query -- api -name:test        # Hide projects whose name contains "test"
+
+Ranking (--rank, or "rank" in the config file):
+  fuzzy        Exact and substring matches first, then fuzzy matches (default)
+  substring    Contiguous substring matches only, prefixes first
+  exact        Exact org, name or org/name matches only
+  frecency     Fuzzy matches ordered by recent Git activity
+
+Sorting (--sort):
+  relevance    Best matches first (default)
+  recent       Most recent Git activity first (HEAD commit date or .git mtime)
+
 Examples:
   proj query myapp
   proj query --exclude $(pwd) myapp
   proj query --abspath --limit 5 app
   proj query gfanton/projects:main
-  proj query :dev`,
+  proj query :dev
+  proj query --rank substring api
+  proj query --desc kubernetes
+  proj query --sort recent --limit 1
+  proj query --select --abspath api
+  proj query -0 --abspath --limit 0 api | xargs -0 du -sh
+  proj query --format '{{.Org}}\t{{.Name}}\t{{.Path}}' api
+
+Single match (--select, --first):
+  Both print exactly one result. When several candidates are equally good,
+  --first picks the first one, while --select prompts for a choice if stdin
+  and stderr are terminals, and otherwise prints the candidates on stderr
+  and exits with code 3.
+
+Completion mode:
+  --complete never walks the filesystem: results come from the completion
+  cache, which is refreshed in the background once it is older than 30s.
+  The first completion after a cold start returns nothing while the cache
+  is being built.
+
+` + formatHelp + `
+  The template replaces the default output, ignoring --abspath, --sep, -v
+  and --annotate. With -0, each result ends with a NUL byte instead.`,
 		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			return runQuery(ctx, logger, cfg, projectsCfg, projectsLogger, *queryCfg, args)
@@ -60,12 +129,44 @@ Examples:
 func runQuery(ctx context.Context, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, queryCfg queryConfig, args []string) error {
 	searchQuery := strings.Join(args, " ")
 
+	var tmpl *template.Template
+	if queryCfg.Format != "" {
+		var err error
+		if tmpl, err = parseFormat(queryCfg.Format); err != nil {
+			return err
+		}
+	}
+
+	cacheSvc := projects.NewCacheService(projectsCfg, projectsLogger)
+	if queryCfg.RefreshCache {
+		// The background refresh adopts the lock taken by the process
+		// spawning it; refreshes run by hand take it themselves
+		if token := os.Getenv(cacheLockEnv); token != "" {
+			cacheSvc.AdoptLock(token)
+		} else if !cacheSvc.TryLock() {
+			return errors.New("a completion cache refresh is already running")
+		}
+		defer cacheSvc.Unlock()
+		_, err := cacheSvc.Refresh(ctx)
+		return err
+	}
+
+	var snapshot *projects.CacheSnapshot
+	if queryCfg.Complete {
+		snapshot = loadCompletionCache(logger, cfg, cacheSvc)
+		if snapshot == nil {
+			// Never walk in completion mode: answer nothing until the cache is warm
+			return nil
+		}
+	}
+
 	queryService := projects.NewQueryService(projectsCfg, projectsLogger)
 	projectService := projects.NewProjectService(projectsCfg, projectsLogger)
 
-	// Detect current project if query starts with ':' (workspace query without project prefix)
+	// Detect current project if query starts with ':' or '//' (workspace or
+	// directory query without project prefix)
 	var currentProject *projects.Project
-	if strings.HasPrefix(searchQuery, ":") {
+	if strings.HasPrefix(searchQuery, ":") || strings.HasPrefix(searchQuery, "//") {
 		wd, err := os.Getwd()
 		if err == nil {
 			if proj, err := projectService.FindFromPath(wd); err == nil {
@@ -80,27 +181,223 @@ func runQuery(ctx context.Context, logger *slog.Logger, cfg *config.Config, proj
 		Exclude:        queryCfg.Exclude,
 		AbsPath:        queryCfg.AbsPath,
 		Separator:      queryCfg.Separator,
+		Print0:         queryCfg.Print0,
 		Limit:          queryCfg.Limit,
 		ShowDistance:   queryCfg.ShowDistance,
+		Rank:           queryCfg.Rank,
+		Sort:           queryCfg.Sort,
+		Descriptions:   queryCfg.Descriptions,
+		Annotate:       queryCfg.Annotate,
+		Snapshot:       snapshot,
 		CurrentProject: currentProject,
 	}
 
+	single := queryCfg.Select || queryCfg.First
+	if single {
+		// Look at every candidate so ties beyond the limit are detected
+		opts.Limit = 0
+	}
+
 	results, err := queryService.Search(ctx, opts)
-	if err != nil {
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
 
 	if len(results) == 0 {
-		return fmt.Errorf("no matching projects found")
+		return projects.ErrNoMatch
 	}
 
-	output := queryService.Format(results, opts)
-	fmt.Print(output)
+	if single {
+		result, err := selectResult(queryService, results, opts, queryCfg.Select)
+		if err != nil {
+			return err
+		}
+		results = []*projects.SearchResult{result}
+	}
 
-	// Add newline if not already present and we have output
-	if output != "" && !strings.HasSuffix(output, "\n") {
+	if tmpl != nil {
+		return formatResults(os.Stdout, tmpl, projects.NewWorkspaceService(projectsCfg, projectsLogger), results, queryCfg.Print0)
+	}
+
+	if opts.ShowDistance && !opts.Print0 {
+		c, err := newColors(cfg, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if c.enabled {
+			return writeColorResults(os.Stdout, c, queryService, results, opts)
+		}
+	}
+
+	if err := queryService.FormatTo(os.Stdout, results, opts); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+
+	// Terminate the last result, NUL terminated already with --print0
+	if !opts.Print0 {
 		fmt.Println()
 	}
 
 	return nil
 }
+
+// writeColorResults writes the results like QueryService.FormatTo followed by
+// a newline, with their org, project, workspace and the characters matching
+// the query highlighted, and their distance dimmed.
+func writeColorResults(w io.Writer, c *colors, queryService *projects.QueryService, results []*projects.SearchResult, opts projects.SearchOptions) error {
+	labelOpts := opts
+	labelOpts.ShowDistance = false
+
+	bw := bufio.NewWriter(w)
+	for i, result := range results {
+		if i > 0 {
+			bw.WriteString(opts.Separator)
+		}
+
+		label, description, described := strings.Cut(queryService.Format([]*projects.SearchResult{result}, labelOpts), "\t")
+		bw.WriteString(c.result(label, result, opts.Query, opts.AbsPath))
+		bw.WriteString(" - " + c.paint(roleDim, strconv.Itoa(result.Distance)))
+		if described {
+			bw.WriteString("\t" + c.paint(roleDim, description))
+		}
+	}
+	bw.WriteByte('\n')
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write results: %w", err)
+	}
+	return nil
+}
+
+// formatResults writes each result rendered by tmpl, NUL terminated with
+// print0.
+func formatResults(w io.Writer, tmpl *template.Template, workspaceSvc *projects.WorkspaceService, results []*projects.SearchResult, print0 bool) error {
+	for _, result := range results {
+		path := result.Project.Path
+		if result.Workspace != "" {
+			path = workspaceSvc.WorkspacePath(*result.Project, result.Workspace)
+		} else if result.Subpath != "" {
+			path = filepath.Join(path, filepath.FromSlash(result.Subpath))
+		}
+
+		item := newFormatItem(result.Project, result.Workspace, path)
+		item.Distance = result.Distance
+		if err := executeFormat(w, tmpl, item, print0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectResult picks a single result. Ambiguous results are resolved by
+// taking the first one, unless interactive is set: then the user is prompted
+// when running in a terminal, or an ambiguity error carrying the candidates
+// is returned.
+func selectResult(queryService *projects.QueryService, results []*projects.SearchResult, opts projects.SearchOptions, interactive bool) (*projects.SearchResult, error) {
+	candidates := projects.BestMatches(results)
+	if len(candidates) == 1 || !interactive {
+		return candidates[0], nil
+	}
+
+	if isTerminal(os.Stdin) && isTerminal(os.Stderr) {
+		labels := make([]string, len(candidates))
+		for i, c := range candidates {
+			labels[i] = queryService.Format([]*projects.SearchResult{c}, projects.SearchOptions{Query: opts.Query, CurrentProject: opts.CurrentProject})
+		}
+
+		i, err := promptSelect(os.Stdin, os.Stderr, labels)
+		if err != nil {
+			return nil, err
+		}
+		return candidates[i], nil
+	}
+
+	listOpts := opts
+	listOpts.Separator = "\n"
+	listOpts.Print0 = false
+	return nil, &exitError{
+		code:       exitCodeAmbiguous,
+		err:        fmt.Errorf("ambiguous query '%s' matches %d projects", opts.Query, len(candidates)),
+		candidates: strings.Split(queryService.Format(candidates, listOpts), "\n"),
+	}
+}
+
+// promptSelect lists labels on out and reads a 1-based choice from in.
+func promptSelect(in io.Reader, out io.Writer, labels []string) (int, error) {
+	for i, label := range labels {
+		fmt.Fprintf(out, "%2d) %s\n", i+1, label)
+	}
+	fmt.Fprintf(out, "Select [1-%d]: ", len(labels))
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		return 0, fmt.Errorf("no selection made")
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(labels) {
+		return 0, fmt.Errorf("invalid selection '%s'", strings.TrimSpace(line))
+	}
+
+	return choice - 1, nil
+}
+
+// isTerminal reports whether f is connected to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// loadCompletionCache returns the completion cache snapshot, spawning a
+// background refresh when it is missing or stale. It returns nil if no
+// snapshot is available yet.
+func loadCompletionCache(logger *slog.Logger, cfg *config.Config, cacheSvc *projects.CacheService) *projects.CacheSnapshot {
+	snapshot, err := cacheSvc.Load()
+	if err != nil && !errors.Is(err, projects.ErrCacheMissing) {
+		logger.Debug("failed to load completion cache", "error", err)
+	}
+
+	if snapshot == nil || snapshot.IsStale(projects.DefaultCacheTTL) {
+		if cacheSvc.TryLock() {
+			if err := spawnCacheRefresh(cfg, cacheSvc); err != nil {
+				logger.Debug("failed to spawn cache refresh", "error", err)
+				cacheSvc.Unlock()
+			}
+		}
+	}
+
+	return snapshot
+}
+
+// cacheLockEnv is the environment variable passing the token of the cache
+// refresh lock to the background refresh.
+const cacheLockEnv = "PROJ_CACHE_LOCK"
+
+// spawnCacheRefresh starts a detached "proj query --refresh-cache" process,
+// handing it the refresh lock held by cacheSvc.
+func spawnCacheRefresh(cfg *config.Config, cacheSvc *projects.CacheService) error {
+	return spawnDetached(cfg, []string{cacheLockEnv + "=" + cacheSvc.LockToken()}, "query", "--refresh-cache")
+}
+
+// spawnDetached starts a detached proj process running args in its own
+// session, with the root directory and config file of cfg and the extra
+// environment variables env.
+func spawnDetached(cfg *config.Config, env []string, args ...string) error {
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	cmd := exec.Command(execPath, append([]string{"--root", cfg.RootDir, "--config", cfg.ConfigFile}, args...)...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start proj %s: %w", strings.Join(args, " "), err)
+	}
+
+	return cmd.Process.Release()
+}
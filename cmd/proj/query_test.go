@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestPromptSelect(t *testing.T) {
+	labels := []string{"a/api", "b/api"}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "first", input: "1\n", want: 0},
+		{name: "second without newline", input: "2", want: 1},
+		{name: "out of range", input: "3\n", wantErr: true},
+		{name: "not a number", input: "api\n", wantErr: true},
+		{name: "no input", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := promptSelect(strings.NewReader(tt.input), &out, labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("promptSelect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("promptSelect() = %d, want %d", got, tt.want)
+			}
+			if !strings.Contains(out.String(), " 2) b/api") {
+				t.Errorf("prompt should list candidates, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	ambiguous := &exitError{code: exitCodeAmbiguous, err: errors.New("ambiguous")}
+
+	if got := exitCode(errors.New("boom")); got != exitCodeError {
+		t.Errorf("exitCode(plain) = %d, want %d", got, exitCodeError)
+	}
+	if got := exitCode(fmt.Errorf("wrapped: %w", ambiguous)); got != exitCodeAmbiguous {
+		t.Errorf("exitCode(wrapped ambiguous) = %d, want %d", got, exitCodeAmbiguous)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		err      error
+		wantCode int
+		wantOut  string
+	}{
+		{
+			name:     "text generic",
+			format:   errorFormatText,
+			err:      errors.New("boom"),
+			wantCode: exitCodeError,
+			wantOut:  "error: boom\n",
+		},
+		{
+			name:     "json no match",
+			format:   errorFormatJSON,
+			err:      fmt.Errorf("search: %w", projects.ErrNoMatch),
+			wantCode: exitCodeNoMatch,
+			wantOut:  `{"error":"search: no matching projects found","kind":"no_match","code":2}` + "\n",
+		},
+		{
+			name:     "json workspace not found",
+			format:   errorFormatJSON,
+			err:      fmt.Errorf("%w: /tmp/ws", projects.ErrWorkspaceNotFound),
+			wantCode: exitCodeNoMatch,
+			wantOut:  `{"error":"workspace does not exist: /tmp/ws","kind":"no_match","code":2}` + "\n",
+		},
+		{
+			name:     "text ambiguous lists candidates",
+			format:   errorFormatText,
+			err:      &exitError{code: exitCodeAmbiguous, err: errors.New("ambiguous"), candidates: []string{"a/api", "b/api"}},
+			wantCode: exitCodeAmbiguous,
+			wantOut:  "a/api\nb/api\nerror: ambiguous\n",
+		},
+		{
+			name:     "json ambiguous",
+			format:   errorFormatJSON,
+			err:      &exitError{code: exitCodeAmbiguous, err: errors.New("ambiguous"), candidates: []string{"a/api", "b/api"}},
+			wantCode: exitCodeAmbiguous,
+			wantOut:  `{"error":"ambiguous","kind":"ambiguous","code":3,"candidates":["a/api","b/api"]}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if code := writeError(&out, tt.format, tt.err); code != tt.wantCode {
+				t.Errorf("writeError() code = %d, want %d", code, tt.wantCode)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("writeError() output = %q, want %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
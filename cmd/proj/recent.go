@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type recentConfig struct {
+	Limit   int
+	AbsPath bool
+}
+
+func newRecentCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	recentCfg := &recentConfig{}
+	fs := ff.NewFlagSet("recent")
+	fs.IntVar(&recentCfg.Limit, 0, "limit", 10, "limit number of results (0 = no limit)")
+	fs.BoolVar(&recentCfg.AbsPath, 0, "abspath", "print absolute paths only")
+
+	return &ff.Command{
+		Name:      "recent",
+		Usage:     "proj recent [flags] [search]",
+		ShortHelp: "List projects by most recent Git activity",
+		LongHelp: `List projects ordered by their most recent Git activity.
+
+Activity is the later of the HEAD commit date and the last time Git touched
+the repository (checkout, commit, fetch).
+
+An optional search narrows the list using the same matching as 'proj query'.
+
+Examples:
+  proj recent
+  proj recent --limit 3 api
+  cd "$(proj recent --abspath --limit 1)"`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runRecent(ctx, logger, projectsCfg, projectsLogger, *recentCfg, args)
+		},
+	}
+}
+
+func runRecent(ctx context.Context, _ *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, recentCfg recentConfig, args []string) error {
+	queryService := projects.NewQueryService(projectsCfg, projectsLogger)
+
+	results, err := queryService.Search(ctx, projects.SearchOptions{
+		Query: strings.Join(args, " "),
+		Limit: recentCfg.Limit,
+		Sort:  projects.SortRecent,
+	})
+	if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(results) == 0 {
+		return projects.ErrNoMatch
+	}
+
+	now := time.Now()
+	for _, result := range results {
+		if recentCfg.AbsPath {
+			fmt.Println(result.Project.Path)
+			continue
+		}
+		fmt.Printf("%s - %s\n", result.Project.String(), formatAge(now, result.Project.LastActivity()))
+	}
+
+	return nil
+}
+
+// formatAge returns a short human-readable age such as "5m ago" or "3d ago".
+func formatAge(now, t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
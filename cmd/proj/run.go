@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type runConfig struct {
+	List bool
+}
+
+func newRunCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	runCfg := &runConfig{}
+	fs := ff.NewFlagSet("run")
+	fs.BoolVar(&runCfg.List, 'l', "list", "list the tasks of the project")
+
+	return &ff.Command{
+		Name:      "run",
+		Usage:     "proj run [flags] <task> [project]",
+		ShortHelp: "Run a named task of a project",
+		LongHelp: `Run a task defined in the .proj.toml file of a project:
+
+  [tasks]
+  dev = "npm run dev"
+  test = "go test ./..."
+
+Tasks run with 'sh -c' from the project root, or from the root of the
+workspace containing the current directory. The .proj.toml of that
+directory is used.
+
+Tasks listed in the [session] tasks setting, e.g. 'tasks = "dev"', are
+started in panes of new proj-tmux sessions.
+
+If the project parameter is not provided, the current directory must be inside a project.
+
+Examples:
+  proj run dev
+  proj run test gfanton/projects
+  proj run --list`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var task, projectStr string
+			switch {
+			case runCfg.List && len(args) > 0:
+				projectStr = args[0]
+			case runCfg.List:
+			case len(args) == 0:
+				return errors.New("task name is required (use --list to show tasks)")
+			default:
+				task = args[0]
+				if len(args) > 1 {
+					projectStr = args[1]
+				}
+			}
+
+			return runTask(ctx, logger, projectsCfg, projectsLogger, projectStr, task, *runCfg)
+		},
+	}
+}
+
+func runTask(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectStr, task string, runCfg runConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	dir := proj.CheckoutPath()
+	if wd, err := os.Getwd(); err == nil {
+		dir = contextDir(projects.NewWorkspaceService(projectsCfg, projectsLogger), proj, wd)
+	}
+
+	settings, err := projects.LoadSettings(dir)
+	if err != nil {
+		return err
+	}
+
+	if runCfg.List {
+		printTasks(os.Stdout, proj, settings)
+		return nil
+	}
+
+	command, ok := settings.Tasks[task]
+	if !ok {
+		return &exitError{
+			code: exitCodeNoMatch,
+			err:  fmt.Errorf("no task '%s' in %s (available: %s)", task, filepath.Join(dir, projects.SettingsFile), strings.Join(settings.TaskNames(), ", ")),
+		}
+	}
+
+	logger.Debug("running task", "project", proj.String(), "task", task, "dir", dir, "command", command)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &commandExitError{code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run task '%s': %w", task, err)
+	}
+	return nil
+}
+
+// contextDir returns the directory of proj to work in: the root of the
+// workspace of proj containing wd, if any, or the main checkout of proj.
+func contextDir(svc *projects.WorkspaceService, proj *projects.Project, wd string) string {
+	workspacesDir := svc.ProjectWorkspaceDir(*proj)
+
+	rel, err := filepath.Rel(workspacesDir, wd)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return proj.CheckoutPath()
+	}
+
+	dir := strings.Split(rel, string(filepath.Separator))[0]
+	if dir == projects.BareDir {
+		return proj.CheckoutPath()
+	}
+	return filepath.Join(workspacesDir, dir)
+}
+
+// printTasks writes the tasks of settings, one per line.
+func printTasks(w io.Writer, proj *projects.Project, settings *projects.Settings) {
+	if len(settings.Tasks) == 0 {
+		fmt.Fprintf(w, "No tasks for %s (define them in the [tasks] section of %s)\n", proj.String(), projects.SettingsFile)
+		return
+	}
+
+	for _, name := range settings.TaskNames() {
+		fmt.Fprintf(w, "%-15s %s\n", name, settings.Tasks[name])
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestContextDir(t *testing.T) {
+	root := t.TempDir()
+	projectsCfg := &projects.Config{RootDir: root}
+	svc := projects.NewWorkspaceService(projectsCfg, &mockLogger{})
+	proj := &projects.Project{Organisation: "user", Name: "proj", Path: filepath.Join(root, "user", "proj")}
+
+	tests := []struct {
+		wd   string
+		want string
+	}{
+		{filepath.Join(root, "user", "proj", "src"), proj.Path},
+		{filepath.Join(root, ".workspace", "user", "proj", "feature", "src"), filepath.Join(root, ".workspace", "user", "proj", "feature")},
+		{filepath.Join(root, ".workspace", "user", "other", "feature"), proj.Path},
+	}
+
+	for _, tt := range tests {
+		if got := contextDir(svc, proj, tt.wd); got != tt.want {
+			t.Errorf("contextDir(%q) = %q, want %q", tt.wd, got, tt.want)
+		}
+	}
+}
+
+func TestContextDirBare(t *testing.T) {
+	root := t.TempDir()
+	svc := projects.NewWorkspaceService(&projects.Config{RootDir: root}, &mockLogger{})
+	proj := &projects.Project{Organisation: "user", Name: "proj", Path: filepath.Join(root, "user", "proj")}
+
+	if err := os.MkdirAll(filepath.Join(proj.Path, projects.BareDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proj.Path, projects.BareDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkout := filepath.Join(proj.Path, "main")
+	tests := []struct {
+		wd   string
+		want string
+	}{
+		{proj.Path, checkout},
+		{filepath.Join(proj.Path, projects.BareDir, "refs"), checkout},
+		{filepath.Join(checkout, "src"), checkout},
+		{filepath.Join(proj.Path, "feature--x", "src"), filepath.Join(proj.Path, "feature--x")},
+	}
+
+	for _, tt := range tests {
+		if got := contextDir(svc, proj, tt.wd); got != tt.want {
+			t.Errorf("contextDir(%q) = %q, want %q", tt.wd, got, tt.want)
+		}
+	}
+}
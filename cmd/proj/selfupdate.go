@@ -0,0 +1,415 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// Repository and checksum file of proj releases, as published by goreleaser.
+const (
+	releaseOwner    = "gfanton"
+	releaseRepo     = "projects"
+	releaseChecksum = "checksums.txt"
+)
+
+const (
+	// updateCheckInterval is how long the latest release seen by the help
+	// staleness hint is cached.
+	updateCheckInterval = 24 * time.Hour
+
+	// updateCheckTimeout bounds the release lookup of the help hint.
+	updateCheckTimeout = 2 * time.Second
+)
+
+type selfUpdateConfig struct {
+	Check bool
+	Force bool
+	Token string
+}
+
+func newSelfUpdateCommand(logger *slog.Logger, cfg *config.Config) *ff.Command {
+	updateCfg := &selfUpdateConfig{}
+	fs := ff.NewFlagSet("self-update")
+	fs.BoolVar(&updateCfg.Check, 0, "check", "only report whether a newer release is available")
+	fs.BoolVar(&updateCfg.Force, 0, "force", "install the latest release even if it is not newer")
+	fs.StringVar(&updateCfg.Token, 0, "token", "", "GitHub token for authentication (overrides the resolved token)")
+
+	return &ff.Command{
+		Name:      "self-update",
+		Usage:     "proj self-update [flags]",
+		ShortHelp: "Update proj to the latest release",
+		LongHelp: `Update proj to the latest GitHub release of gfanton/projects.
+
+The release archive of the current platform is downloaded, verified against
+the SHA-256 sum published in the release's checksums.txt, and its proj
+binary replaces the running executable. Releases are not signed: the
+checksum protects against corrupted downloads, not against a compromised
+release.
+
+Installations managed by a package manager (Homebrew, Nix, ...) should be
+updated with it instead.
+
+Set 'update-check = true' in the config file (or PROJECT_UPDATE_CHECK=true)
+to show a hint in 'proj --help' when a newer release is available.
+
+Examples:
+  proj self-update --check
+  proj self-update`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runSelfUpdate(ctx, logger, cfg, *updateCfg)
+		},
+	}
+}
+
+func runSelfUpdate(ctx context.Context, logger *slog.Logger, cfg *config.Config, updateCfg selfUpdateConfig) error {
+	client := newGitHubClient(ctx, logger, cfg, updateCfg.Token)
+	release, err := client.LatestRelease(ctx, releaseOwner, releaseRepo)
+	if err != nil {
+		return fmt.Errorf("failed to get latest release: %w", err)
+	}
+
+	newer, err := isNewerVersion(release.TagName, version)
+	// --check only reports, --force doesn't make an older release new
+	if updateCfg.Check {
+		switch {
+		case err != nil:
+			return err
+		case !newer:
+			fmt.Printf("proj %s is up to date\n", version)
+		default:
+			fmt.Printf("A new proj release is available: %s -> %s\n%s\n", version, release.TagName, release.HTMLURL)
+		}
+		return nil
+	}
+	switch {
+	case err != nil && !updateCfg.Force:
+		return fmt.Errorf("%w; use --force to install %s", err, release.TagName)
+	case !newer && !updateCfg.Force:
+		fmt.Printf("proj %s is up to date\n", version)
+		return nil
+	}
+
+	exe, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+
+	binary, err := downloadRelease(ctx, client, release, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceExecutable(exe, binary); err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated proj %s -> %s (%s)\n", version, release.TagName, exe)
+	return nil
+}
+
+// downloadRelease downloads the release archive of goos/goarch, verifies
+// its checksum and returns the proj binary it contains.
+func downloadRelease(ctx context.Context, client *github.Client, release *github.Release, goos, goarch string) ([]byte, error) {
+	name := releaseArchiveName(goos, goarch)
+	archive := release.Asset(name)
+	if archive == nil {
+		return nil, fmt.Errorf("release %s has no archive for %s/%s (%s)", release.TagName, goos, goarch, name)
+	}
+	checksums := release.Asset(releaseChecksum)
+	if checksums == nil {
+		return nil, fmt.Errorf("release %s has no %s to verify the download", release.TagName, releaseChecksum)
+	}
+
+	var sums bytes.Buffer
+	if err := client.Download(ctx, checksums.BrowserDownloadURL, &sums); err != nil {
+		return nil, err
+	}
+	want, err := findChecksum(&sums, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	if err := client.Download(ctx, archive.BrowserDownloadURL, &data); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data.Bytes())
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %s, expected %s", name, got, want)
+	}
+
+	return extractBinary(name, data.Bytes(), binaryName(goos))
+}
+
+// releaseArchiveName returns the name of the release archive of goos/goarch,
+// following the archive name template of .goreleaser.yml.
+func releaseArchiveName(goos, goarch string) string {
+	arch := goarch
+	switch goarch {
+	case "amd64":
+		arch = "x86_64"
+	case "386":
+		arch = "i386"
+	}
+
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+
+	return fmt.Sprintf("proj_%s%s_%s%s", strings.ToUpper(goos[:1]), goos[1:], arch, ext)
+}
+
+// binaryName returns the file name of the proj binary on goos.
+func binaryName(goos string) string {
+	if goos == "windows" {
+		return "proj.exe"
+	}
+	return "proj"
+}
+
+// findChecksum returns the SHA-256 sum of name in a checksums file made of
+// "<sum>  <name>" lines.
+func findChecksum(r io.Reader, name string) (string, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read %s: %w", releaseChecksum, err)
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", name, releaseChecksum)
+}
+
+// extractBinary returns the content of the file named binary at the root of
+// the tar.gz or zip archive data.
+func extractBinary(archiveName string, data []byte, binary string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", archiveName, err)
+		}
+		for _, f := range zr.File {
+			if path.Base(f.Name) != binary || f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("extract %s: %w", binary, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(rc)
+		}
+		return nil, fmt.Errorf("%s not found in %s", binary, archiveName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", archiveName, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in %s", binary, archiveName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", archiveName, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == binary {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// currentExecutable returns the resolved path of the running proj binary,
+// refusing paths managed by Nix.
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate proj executable: %w", err)
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve proj executable: %w", err)
+	}
+
+	if strings.HasPrefix(exe, "/nix/store/") {
+		return "", fmt.Errorf("proj is installed in the read-only Nix store (%s), update it with Nix instead", exe)
+	}
+	return exe, nil
+}
+
+// replaceExecutable atomically replaces the executable at exe with binary,
+// through a temporary file in the same directory.
+func replaceExecutable(exe string, binary []byte) error {
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".proj-update-*")
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("cannot write to %s: run with sufficient permissions or update proj with its package manager: %w", dir, err)
+		}
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := tmp.Chmod(0755); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to make new binary executable: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+
+	// A running executable can't be overwritten on Windows, but can be renamed
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("failed to move current binary: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// isNewerVersion reports whether the release tag latest is newer than the
+// current version. Both are "vMAJOR.MINOR.PATCH", with an optional
+// pre-release or build suffix that is ignored.
+func isNewerVersion(latest, current string) (bool, error) {
+	l, err := parseVersion(latest)
+	if err != nil {
+		return false, err
+	}
+	c, err := parseVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("cannot compare the development build '%s' with releases", current)
+	}
+
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i], nil
+		}
+	}
+	return false, nil
+}
+
+// parseVersion parses a "v1.2.3" version into its numeric parts.
+func parseVersion(s string) ([3]int, error) {
+	var parts [3]int
+
+	core := strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	fields := strings.Split(core, ".")
+	if len(fields) != len(parts) {
+		return parts, fmt.Errorf("invalid version '%s'", s)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version '%s'", s)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// updateCheckCache is the latest release seen by the help staleness hint.
+type updateCheckCache struct {
+	Latest    string    `json:"latest"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// printUpdateHint writes a hint to w when a release newer than the running
+// version is available. The latest release is looked up at most once a day,
+// and lookup errors are only logged: the hint must never break help.
+func printUpdateHint(ctx context.Context, w io.Writer, logger *slog.Logger, cfg *config.Config) {
+	latest, err := latestReleaseCached(ctx, logger, cfg)
+	if err != nil {
+		logger.Debug("failed to check for a newer release", "error", err)
+		return
+	}
+
+	if newer, err := isNewerVersion(latest, version); err == nil && newer {
+		fmt.Fprintf(w, "\nA new proj release is available: %s -> %s, run 'proj self-update'\n", version, latest)
+	}
+}
+
+// latestReleaseCached returns the latest release tag, from the cache file
+// when checked less than updateCheckInterval ago.
+func latestReleaseCached(ctx context.Context, logger *slog.Logger, cfg *config.Config) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	cachePath := filepath.Join(cacheDir, "proj", "latest-release.json")
+
+	var cache updateCheckCache
+	if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cache) == nil {
+		if time.Since(cache.CheckedAt) < updateCheckInterval && cache.Latest != "" {
+			return cache.Latest, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+
+	client := newGitHubClient(ctx, logger, cfg, "")
+	release, err := client.LatestRelease(ctx, releaseOwner, releaseRepo)
+	if err != nil {
+		return "", err
+	}
+
+	cache = updateCheckCache{Latest: release.TagName, CheckedAt: time.Now()}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return "", fmt.Errorf("encode update check cache: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return "", fmt.Errorf("create cache directory: %w", err)
+	}
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		return "", fmt.Errorf("write update check cache: %w", err)
+	}
+
+	return release.TagName, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+		wantErr         bool
+	}{
+		{"v1.2.0", "v1.1.9", true, false},
+		{"v1.2.0", "1.2.0", false, false},
+		{"v1.10.0", "v1.9.3", true, false},
+		{"v1.2.0", "v2.0.0", false, false},
+		{"v1.2.1", "v1.2.0-rc1", true, false},
+		{"v1.2.0", "dev", false, true},
+		{"latest", "v1.0.0", false, true},
+	}
+
+	for _, tt := range tests {
+		got, err := isNewerVersion(tt.latest, tt.current)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("isNewerVersion(%q, %q) error = %v, wantErr %v", tt.latest, tt.current, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("isNewerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseArchiveName(t *testing.T) {
+	tests := []struct {
+		goos, goarch, want string
+	}{
+		{"linux", "amd64", "proj_Linux_x86_64.tar.gz"},
+		{"darwin", "arm64", "proj_Darwin_arm64.tar.gz"},
+		{"windows", "amd64", "proj_Windows_x86_64.zip"},
+	}
+
+	for _, tt := range tests {
+		if got := releaseArchiveName(tt.goos, tt.goarch); got != tt.want {
+			t.Errorf("releaseArchiveName(%q, %q) = %q, want %q", tt.goos, tt.goarch, got, tt.want)
+		}
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	sums := "abc123  proj_Linux_x86_64.tar.gz\nDEF456  proj_Darwin_arm64.tar.gz\n"
+
+	got, err := findChecksum(strings.NewReader(sums), "proj_Darwin_arm64.tar.gz")
+	if err != nil {
+		t.Fatalf("findChecksum() error = %v", err)
+	}
+	if got != "def456" {
+		t.Errorf("findChecksum() = %q, want %q", got, "def456")
+	}
+
+	if _, err := findChecksum(strings.NewReader(sums), "proj_Windows_x86_64.zip"); err == nil {
+		t.Error("findChecksum() expected error for missing archive")
+	}
+}
+
+func TestExtractBinaryTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"README.md": "readme", "proj": "binary"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+
+	got, err := extractBinary("proj_Linux_x86_64.tar.gz", buf.Bytes(), "proj")
+	if err != nil {
+		t.Fatalf("extractBinary() error = %v", err)
+	}
+	if string(got) != "binary" {
+		t.Errorf("extractBinary() = %q, want %q", got, "binary")
+	}
+
+	if _, err := extractBinary("proj_Linux_x86_64.tar.gz", buf.Bytes(), "proj.exe"); err == nil {
+		t.Error("extractBinary() expected error for missing binary")
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+)
+
+// setupShells are the shells 'proj setup' prints the integration line of.
+var setupShells = []string{"zsh", "bash", "fish", "elvish"}
+
+func newSetupCommand(cfg *config.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "setup",
+		Usage:     "proj setup",
+		ShortHelp: "Configure proj interactively",
+		LongHelp: `Configure proj interactively, for a first run.
+
+The wizard asks for the root directory, the default user, the clone protocol,
+your shell and whether you use tmux, then writes the config file (--config,
+default ~/.projectrc) and prints the lines to add to your shell configuration.
+
+It can also import an existing source directory: the Git repositories directly
+under it are linked into the root directory like 'proj add', named after their
+origin remote (org/name), or after their directory with the default user.
+
+Press Enter to keep the default value shown in brackets.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return errors.New("proj setup takes no arguments")
+			}
+			return runSetup(os.Stdin, os.Stdout, cfg, projectsLogger)
+		},
+	}
+}
+
+// setupAnswers are the answers of the setup wizard.
+type setupAnswers struct {
+	RootDir  string // as typed, written to the config file
+	User     string
+	Protocol string
+	Shell    string
+	Tmux     bool
+	Import   string
+}
+
+// prompter asks questions on the terminal, such as the ones of the setup
+// wizard.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask asks question until valid accepts the answer, def being used for an
+// empty one.
+func (p *prompter) ask(question, def string, valid func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		line, err := p.in.ReadString('\n')
+		if err != nil && line == "" {
+			return "", errors.New("aborted: no answer")
+		}
+
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if valid == nil {
+			return answer, nil
+		}
+		if err := valid(answer); err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// confirm asks a yes/no question.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	choices := "y/N"
+	if def {
+		choices = "Y/n"
+	}
+
+	answer, err := p.ask(question+" ("+choices+")", "", func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, nil
+}
+
+// oneOf returns a validation accepting the given choices.
+func oneOf(choices ...string) func(string) error {
+	return func(answer string) error {
+		if !slices.Contains(choices, answer) {
+			return fmt.Errorf("expected %s", strings.Join(choices, ", "))
+		}
+		return nil
+	}
+}
+
+func runSetup(in io.Reader, out io.Writer, cfg *config.Config, projectsLogger projects.Logger) error {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+
+	fmt.Fprintf(out, "Welcome to proj! Your answers are written to %s.\n", cfg.ConfigFile)
+	fmt.Fprintln(out, "Press Enter to keep the default value shown in brackets.")
+	fmt.Fprintln(out)
+
+	if _, err := os.Stat(cfg.ConfigFile); err == nil {
+		overwrite, err := p.confirm(cfg.ConfigFile+" already exists, overwrite it?", false)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			fmt.Fprintf(out, "Keeping %s\n", cfg.ConfigFile)
+			return nil
+		}
+	}
+
+	answers, err := askSetup(p, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.ConfigFile), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(cfg.ConfigFile, []byte(answers.configFile()), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	fmt.Fprintf(out, "\nWrote %s\n", cfg.ConfigFile)
+
+	rootDir := config.ExpandPath(answers.RootDir)
+	if err := os.MkdirAll(rootDir, 0755); err != nil {
+		return fmt.Errorf("failed to create root directory: %w", err)
+	}
+
+	if answers.Import != "" {
+		projectsCfg := cfg.ProjectsConfig()
+		projectsCfg.RootDir = rootDir
+		projectsCfg.RootUser = answers.User
+		if err := importSources(p, projects.NewProjectService(projectsCfg, projectsLogger), config.ExpandPath(answers.Import)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(out)
+	printSetupNextSteps(out, answers)
+	return nil
+}
+
+// askSetup asks the questions of the wizard, defaulting to the values of
+// cfg.
+func askSetup(p *prompter, cfg *config.Config) (setupAnswers, error) {
+	var answers setupAnswers
+	var err error
+
+	answers.RootDir, err = p.ask("Root directory of your projects", cfg.RootDir, func(answer string) error {
+		if !filepath.IsAbs(config.ExpandPath(answer)) {
+			return errors.New("must be an absolute path (~ and $VAR are expanded)")
+		}
+		return nil
+	})
+	if err != nil {
+		return answers, err
+	}
+
+	answers.User, err = p.ask("Default user for single-name projects (your GitHub user)", cfg.RootUser, func(answer string) error {
+		if answer == "" {
+			return nil
+		}
+		check := config.Config{RootDir: config.ExpandPath(answers.RootDir), RootUser: answer}
+		return check.Validate()
+	})
+	if err != nil {
+		return answers, err
+	}
+
+	answers.Protocol, err = p.ask("Clone protocol (auto, ssh or https)", cfg.CloneProtocol, oneOf("auto", "ssh", "https"))
+	if err != nil {
+		return answers, err
+	}
+
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if !slices.Contains(setupShells, shell) {
+		shell = "zsh"
+	}
+	answers.Shell, err = p.ask("Shell ("+strings.Join(setupShells, ", ")+")", shell, oneOf(setupShells...))
+	if err != nil {
+		return answers, err
+	}
+
+	_, lookErr := exec.LookPath("tmux")
+	if answers.Tmux, err = p.confirm("Do you use tmux?", lookErr == nil); err != nil {
+		return answers, err
+	}
+
+	answers.Import, err = p.ask("Existing source directory to import (empty to skip)", "", func(answer string) error {
+		if answer == "" {
+			return nil
+		}
+		if info, err := os.Stat(config.ExpandPath(answer)); err != nil || !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", answer)
+		}
+		return nil
+	})
+	return answers, err
+}
+
+// configFile returns the content of the config file of the answers.
+func (a setupAnswers) configFile() string {
+	var sb strings.Builder
+	sb.WriteString("# proj configuration, written by 'proj setup'\n")
+	fmt.Fprintf(&sb, "root = %q\n", a.RootDir)
+	if a.User != "" {
+		fmt.Fprintf(&sb, "user = %q\n", a.User)
+	}
+	fmt.Fprintf(&sb, "\n[clone]\nprotocol = %q\n", a.Protocol)
+	return sb.String()
+}
+
+// importSources links the repositories under dir into the root directory,
+// after confirmation.
+func importSources(p *prompter, svc *projects.ProjectService, dir string) error {
+	candidates, err := svc.ImportCandidates(dir)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintf(p.out, "No new Git repository found in %s\n", dir)
+		return nil
+	}
+
+	fmt.Fprintf(p.out, "\nRepositories found in %s:\n", dir)
+	for _, c := range candidates {
+		fmt.Fprintf(p.out, "  %-30s %s\n", c.Project.String(), c.Source)
+	}
+	ok, err := p.confirm(fmt.Sprintf("Link these %d repositories into the root directory?", len(candidates)), true)
+	if err != nil || !ok {
+		return err
+	}
+
+	imported := 0
+	for _, c := range candidates {
+		if err := svc.Import(c); err != nil {
+			fmt.Fprintf(p.out, "  failed to import %s: %v\n", c.Source, err)
+			continue
+		}
+		imported++
+	}
+	fmt.Fprintf(p.out, "Imported %d project(s)\n", imported)
+	return nil
+}
+
+// printSetupNextSteps writes the shell and tmux configuration to add.
+func printSetupNextSteps(out io.Writer, answers setupAnswers) {
+	switch answers.Shell {
+	case "zsh":
+		fmt.Fprintln(out, "Add this line to ~/.zshrc for the 'p' navigation command and completion:")
+		fmt.Fprintln(out, `  eval "$(proj init zsh)"`)
+	case "elvish":
+		fmt.Fprintln(out, "Add this line to ~/.config/elvish/rc.elv for the 'p' navigation command:")
+		fmt.Fprintln(out, "  eval (proj init elvish | slurp)")
+	case "bash":
+		fmt.Fprintln(out, "Add this line to ~/.bashrc for completion:")
+		fmt.Fprintln(out, "  source <(proj completion bash)")
+	case "fish":
+		fmt.Fprintln(out, "Run this command for completion:")
+		fmt.Fprintln(out, "  proj completion fish > ~/.config/fish/completions/proj.fish")
+	}
+
+	if answers.Tmux {
+		fmt.Fprintln(out, "\nFor tmux sessions per project, install the proj-tmux plugin, then run:")
+		fmt.Fprintln(out, "  proj-tmux install")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects/internal/config"
+)
+
+func TestRunSetup(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "code")
+	cfg := &config.Config{
+		ConfigFile:    filepath.Join(dir, ".projectrc"),
+		RootDir:       filepath.Join(dir, "default"),
+		CloneProtocol: "auto",
+	}
+
+	// Invalid answers are asked again, empty ones keep the default
+	answers := strings.Join([]string{
+		"relative/root", root,
+		"bad user", "johndoe",
+		"",
+		"tcsh", "bash",
+		"n",
+		"",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := runSetup(strings.NewReader(answers), &out, cfg, &mockLogger{}); err != nil {
+		t.Fatalf("runSetup() error = %v\n%s", err, out.String())
+	}
+
+	data, err := os.ReadFile(cfg.ConfigFile)
+	if err != nil {
+		t.Fatalf("config file not written: %v", err)
+	}
+	want := "# proj configuration, written by 'proj setup'\nroot = \"" + root + "\"\nuser = \"johndoe\"\n\n[clone]\nprotocol = \"auto\"\n"
+	if string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("root directory not created: %v", err)
+	}
+	for _, want := range []string{"must be an absolute path", "invalid user", "expected zsh, bash, fish, elvish", "source <(proj completion bash)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "proj-tmux install") {
+		t.Errorf("output should not mention tmux:\n%s", out.String())
+	}
+
+	// An existing config file is kept unless overwriting is confirmed
+	out.Reset()
+	if err := runSetup(strings.NewReader("\n"), &out, cfg, &mockLogger{}); err != nil {
+		t.Fatalf("runSetup() with existing config error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Keeping "+cfg.ConfigFile) {
+		t.Errorf("output = %q, want the config file kept", out.String())
+	}
+}
+
+func TestRunSetupImport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	repo := filepath.Join(src, "tool")
+	if err := os.MkdirAll(filepath.Join(src, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("git", "init", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, output)
+	}
+
+	root := filepath.Join(dir, "code")
+	cfg := &config.Config{ConfigFile: filepath.Join(dir, ".projectrc"), RootDir: root, CloneProtocol: "auto"}
+	answers := strings.Join([]string{"", "johndoe", "", "zsh", "y", src, ""}, "\n") + "\n"
+
+	var out bytes.Buffer
+	if err := runSetup(strings.NewReader(answers), &out, cfg, &mockLogger{}); err != nil {
+		t.Fatalf("runSetup() error = %v\n%s", err, out.String())
+	}
+
+	target, err := os.Readlink(filepath.Join(root, "johndoe", "tool"))
+	if err != nil || target != repo {
+		t.Errorf("imported project link = %q, %v, want %q", target, err, repo)
+	}
+	if _, err := os.Lstat(filepath.Join(root, "johndoe", "notes")); !os.IsNotExist(err) {
+		t.Errorf("non-Git directory should not be imported, stat error = %v", err)
+	}
+	for _, want := range []string{"Imported 1 project(s)", `eval "$(proj init zsh)"`, "proj-tmux install"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
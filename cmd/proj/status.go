@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/git"
+	"github.com/gfanton/projects/internal/tableprint"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newStatusCommand(logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "status",
+		Usage:     "proj status [project|@set]",
+		ShortHelp: "Show the status of a project",
+		LongHelp: `Show the status of a project: path, Git repository state, language and
+Git LFS objects.
+
+LFS objects not fetched, as left by 'proj get' when cloning several projects
+or with --skip-lfs, are reported with the command to fetch them. Workspaces
+not used for longer than workspace.idle-after are reported as idle.
+
+If the project parameter is not provided, the current directory must be inside a project.
+
+With @set, the projects of a set of the [sets] config table are checked as
+a unit: the branch, remote-tracking state, stashes and last commit of each,
+like 'proj list --wide', and the projects not cloned, making the command
+fail.
+
+Examples:
+  proj status
+  proj status gfanton/projects
+  proj status @payments`,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+
+			c, err := newColors(cfg, os.Stdout)
+			if err != nil {
+				return err
+			}
+
+			if set, ok := projects.ParseSetName(projectStr); ok {
+				return printSetStatus(ctx, os.Stdout, c, projectsCfg, projectsLogger, set, time.Now())
+			}
+
+			proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(proj.Path); err != nil {
+				return &exitError{code: exitCodeNoMatch, err: fmt.Errorf("project %s does not exist", proj.String())}
+			}
+
+			svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+			printStatus(ctx, os.Stdout, c, git.NewClient(logger), svc, proj, time.Now())
+			return nil
+		},
+	}
+}
+
+// printStatus writes the status of proj.
+func printStatus(ctx context.Context, w io.Writer, c *colors, gitClient *git.Client, svc *projects.WorkspaceService, proj *projects.Project, now time.Time) {
+	fmt.Fprintln(w, c.project(proj))
+
+	t := tableprint.New()
+	t.Indent = "  "
+	t.Append("path:", proj.Path)
+	t.Append("git:", c.gitStatus(proj.GetGitStatus()))
+	if language := proj.Language(); language != "" {
+		t.Append("language:", language)
+	}
+	lfs, missing := lfsStatusLine(ctx, gitClient, proj.Path)
+	if missing {
+		lfs = c.paint(roleWarn, lfs)
+	}
+	t.Append("lfs:", lfs)
+	if line := workspacesStatusLine(c, svc, proj, now); line != "" {
+		t.Append("workspaces:", line)
+	}
+	t.Write(w)
+}
+
+// printSetStatus writes the health of the projects of the set name, and
+// returns an error when some of them are not cloned.
+func printSetStatus(ctx context.Context, w io.Writer, c *colors, projectsCfg *projects.Config, projectsLogger projects.Logger, name string, now time.Time) error {
+	projs, err := projects.NewProjectService(projectsCfg, projectsLogger).ParseSet(name)
+	if err != nil {
+		return err
+	}
+
+	var entries []listEntry
+	var cloned []*projects.Project
+	var missing []string
+	for _, p := range projs {
+		if _, err := os.Stat(p.Path); err != nil {
+			missing = append(missing, p.String())
+			continue
+		}
+		entries = append(entries, listEntry{project: p, status: p.GetGitStatus()})
+		cloned = append(cloned, p)
+	}
+
+	fmt.Fprintf(w, "%s (%s)\n", c.paint(roleProject, projects.SetPrefix+name), plural(len(projs), "project"))
+	if len(entries) > 0 {
+		healths := projects.NewHealthService(projectsCfg, projectsLogger).Collect(ctx, cloned)
+		renderWide(w, c, entries, healths, now, tableprint.TerminalWidth(os.Stdout))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s %s\n", c.paint(roleWarn, "Not cloned:"), strings.Join(missing, ", "))
+	return &exitError{
+		code: exitCodeNoMatch,
+		err:  fmt.Errorf("%d of %s of %s%s not cloned, run 'proj get %s%s'", len(missing), plural(len(projs), "project"), projects.SetPrefix, name, projects.SetPrefix, name),
+	}
+}
+
+// workspacesStatusLine describes the workspaces of proj and lists the idle
+// ones, or returns an empty string when it has none.
+func workspacesStatusLine(c *colors, svc *projects.WorkspaceService, proj *projects.Project, now time.Time) string {
+	workspaces, err := svc.ReadList(*proj)
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	if len(workspaces) == 0 {
+		return ""
+	}
+
+	var idle []string
+	for _, ws := range workspaces {
+		if lastAccess := svc.LastAccess(ws.Path); svc.IsIdle(lastAccess, now) {
+			idle = append(idle, fmt.Sprintf("%s (last used %s)", c.paint(roleBranch, ws.Branch), formatAge(now, lastAccess)))
+		}
+	}
+
+	line := fmt.Sprintf("%d", len(workspaces))
+	if len(idle) > 0 {
+		line += c.paint(roleWarn, fmt.Sprintf(", %d idle", len(idle))) + ": " + strings.Join(idle, ", ")
+	}
+	return line
+}
+
+// lfsStatusLine describes the Git LFS objects of the repository at path,
+// reporting whether objects are missing or can't be fetched.
+func lfsStatusLine(ctx context.Context, gitClient *git.Client, path string) (string, bool) {
+	if !git.UsesLFS(path) {
+		return "not used", false
+	}
+
+	status, err := gitClient.LFSStatus(ctx, path)
+	switch {
+	case errors.Is(err, git.ErrLFSUnavailable):
+		return "used, but git-lfs is not installed", true
+	case err != nil:
+		return fmt.Sprintf("unknown (%v)", err), false
+	case status.Missing > 0:
+		return fmt.Sprintf("%d of %d objects not fetched (run 'git lfs pull')", status.Missing, status.Missing+status.Fetched), true
+	default:
+		return fmt.Sprintf("%d objects fetched", status.Fetched), false
+	}
+}
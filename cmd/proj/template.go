@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/scaffold"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newTemplateCommand(cfg *config.Config) *ff.Command {
+	return &ff.Command{
+		Name:      "template",
+		Usage:     "proj template <subcommand>",
+		ShortHelp: "Manage the templates of new projects",
+		LongHelp: `Manage the registry of scaffold templates used by 'proj new --template'.
+
+Templates are stored in the [templates.<name>] tables of the config file,
+with their source, description and variables.
+
+Commands:
+  list                         List templates
+  add <name> <source>          Register a template
+  remove <name>                Unregister a template`,
+		Subcommands: []*ff.Command{
+			newTemplateListCommand(cfg),
+			newTemplateAddCommand(cfg),
+			newTemplateRemoveCommand(cfg),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+func newTemplateListCommand(cfg *config.Config) *ff.Command {
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj template list",
+		ShortHelp: "List templates",
+		LongHelp: `List the registered templates with their description, source and variables.
+Local sources that don't exist anymore are flagged as missing.`,
+		Exec: func(ctx context.Context, args []string) error {
+			printTemplates(os.Stdout, cfg.Templates)
+			return nil
+		},
+	}
+}
+
+// printTemplates writes the templates sorted by name.
+func printTemplates(w io.Writer, templates map[string]config.Template) {
+	if len(templates) == 0 {
+		fmt.Fprintln(w, "No templates (add one with 'proj template add <name> <source>')")
+		return
+	}
+
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := templates[name]
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%-15s %s", name, t.Description), " "))
+
+		source := t.Source
+		if !scaffold.IsRemote(source) {
+			if info, err := os.Stat(source); err != nil || !info.IsDir() {
+				source += " (missing)"
+			}
+		}
+		fmt.Fprintf(w, "  source:    %s\n", source)
+		if len(t.Variables) > 0 {
+			fmt.Fprintf(w, "  variables: %s\n", strings.Join(t.Variables, ", "))
+		}
+	}
+}
+
+type templateAddConfig struct {
+	Description string
+	Vars        []string
+}
+
+func newTemplateAddCommand(cfg *config.Config) *ff.Command {
+	addCfg := &templateAddConfig{}
+	fs := ff.NewFlagSet("template add")
+	fs.StringVar(&addCfg.Description, 'd', "description", "", "description shown by 'proj template list'")
+	fs.StringSetVar(&addCfg.Vars, 0, "var", "variable of the template, name or name=default (repeatable)")
+
+	return &ff.Command{
+		Name:      "add",
+		Usage:     "proj template add [flags] <name> <source>",
+		ShortHelp: "Register a template",
+		LongHelp: `Register a template in the config file. The source is a local directory, or
+a Git repository: a URL, or "user/repo" for GitHub.
+
+The files of the template are copied into new projects, replacing the
+{{name}} placeholders of the variables declared with --var in the file
+names and contents, as well as {{project}}, {{org}} and {{module}} with the
+name, organisation and Go module path (github.com/org/name) of the new
+project. Variables without default must be given to 'proj new --template'
+with --var.
+
+Examples:
+  proj template add -d "Go command line tool" --var author --var license=MIT go-cli ~/templates/go-cli
+  proj template add rust gfanton/rust-template`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return errors.New("template name and source required")
+			}
+			return runTemplateAdd(cfg, args[0], args[1], *addCfg)
+		},
+	}
+}
+
+func runTemplateAdd(cfg *config.Config, name, source string, addCfg templateAddConfig) error {
+	if !scaffold.IsRemote(source) {
+		abs, err := filepath.Abs(config.ExpandPath(source))
+		if err != nil {
+			return fmt.Errorf("failed to resolve template source: %w", err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return fmt.Errorf("template source: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("template source %s is not a directory", abs)
+		}
+		source = abs
+	}
+
+	t := config.Template{Source: source, Description: addCfg.Description, Variables: addCfg.Vars}
+	if err := cfg.AddTemplate(name, t); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added template %s (%s) to %s\n", name, source, cfg.ConfigFile)
+	return nil
+}
+
+func newTemplateRemoveCommand(cfg *config.Config) *ff.Command {
+	return &ff.Command{
+		Name:      "remove",
+		Usage:     "proj template remove <name>",
+		ShortHelp: "Unregister a template",
+		LongHelp: `Remove a template from the config file. The source of the template is left
+untouched.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("exactly one template name required")
+			}
+			if err := cfg.RemoveTemplate(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed template %s from %s\n", args[0], cfg.ConfigFile)
+			return nil
+		},
+	}
+}
+
+// templateValues returns the values of the variables of t: the given
+// name=value pairs, the defaults of the others, and project, org and module,
+// the Go module path of the project, which can be given too. It fails on
+// unknown variables and on required variables not given.
+func templateValues(t config.Template, org, project, module string, given []string) (map[string]string, error) {
+	vars, err := t.ParsedVariables()
+	if err != nil {
+		return nil, err
+	}
+
+	declared := map[string]bool{"module": true}
+	for _, v := range vars {
+		declared[v.Name] = true
+	}
+
+	values := map[string]string{"project": project, "org": org, "module": module}
+	for _, pair := range given {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --var '%s': expected name=value", pair)
+		}
+		if !declared[name] {
+			return nil, fmt.Errorf("unknown variable '%s'", name)
+		}
+		values[name] = value
+	}
+
+	var missing []string
+	for _, v := range vars {
+		if _, ok := values[v.Name]; ok {
+			continue
+		}
+		if v.Required {
+			missing = append(missing, v.Name)
+			continue
+		}
+		values[v.Name] = v.Default
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing template variables: %s (set them with --var name=value)", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
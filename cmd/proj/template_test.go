@@ -0,0 +1,62 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/gfanton/projects/internal/config"
+)
+
+func TestTemplateValues(t *testing.T) {
+	tmpl := config.Template{Source: "/tmp", Variables: []string{"author", "license=MIT"}}
+
+	tests := []struct {
+		name    string
+		given   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "defaults",
+			given: []string{"author=gfanton"},
+			want:  map[string]string{"project": "tool", "org": "gfanton", "module": "github.com/gfanton/tool", "author": "gfanton", "license": "MIT"},
+		},
+		{
+			name:  "override default",
+			given: []string{"author=gfanton", "license=Apache-2.0"},
+			want:  map[string]string{"project": "tool", "org": "gfanton", "module": "github.com/gfanton/tool", "author": "gfanton", "license": "Apache-2.0"},
+		},
+		{
+			name:  "module path",
+			given: []string{"author=gfanton", "module=example.com/tool"},
+			want:  map[string]string{"project": "tool", "org": "gfanton", "module": "example.com/tool", "author": "gfanton", "license": "MIT"},
+		},
+		{
+			name:    "missing required",
+			given:   []string{"license=MIT"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown variable",
+			given:   []string{"author=gfanton", "email=me@example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "no value",
+			given:   []string{"author"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templateValues(tmpl, "gfanton", "tool", "github.com/gfanton/tool", tt.given)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("templateValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("templateValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
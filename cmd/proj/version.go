@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"runtime/debug"
 
 	"github.com/peterbourgon/ff/v4"
 )
@@ -15,6 +16,31 @@ var (
 	builtBy = "unknown"
 )
 
+// Builds without ldflags, such as 'go install', fall back to the module
+// version and VCS information embedded by the Go toolchain.
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	if version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+
+	for _, setting := range info.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && commit == "none":
+			commit = setting.Value
+			if len(commit) > 7 {
+				commit = commit[:7]
+			}
+		case setting.Key == "vcs.time" && date == "unknown":
+			date = setting.Value
+		}
+	}
+}
+
 type versionConfig struct {
 	Verbose bool
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+)
+
+// readBranchesFile reads the branches listed in the file at path, or in
+// stdin when path is "-".
+func readBranchesFile(path string, stdin io.Reader) ([]string, error) {
+	if path == "-" {
+		return readBranches(stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open branches file: %w", err)
+	}
+	defer f.Close()
+
+	branches, err := readBranches(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return branches, nil
+}
+
+// readBranches returns the branches of r, one per line, skipping empty
+// lines, comments and duplicates. Comments start with '#', which is not
+// followed by a digit unlike pull requests (#123).
+func readBranches(r io.Reader) ([]string, error) {
+	var branches []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || isBranchComment(line) {
+			continue
+		}
+		if strings.ContainsFunc(line, unicode.IsSpace) {
+			return nil, fmt.Errorf("line %d: expected one branch, got '%s'", n, line)
+		}
+		if !seen[line] {
+			seen[line] = true
+			branches = append(branches, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(branches) == 0 {
+		return nil, fmt.Errorf("no branches listed")
+	}
+	return branches, nil
+}
+
+func isBranchComment(line string) bool {
+	rest, ok := strings.CutPrefix(line, "#")
+	return ok && (rest == "" || !unicode.IsDigit(rune(rest[0])))
+}
+
+// runWorkspaceAddBatch adds a workspace to proj for each of branches,
+// writing the progress and a summary to w. Existing workspaces are kept and
+// failures don't stop the batch.
+func runWorkspaceAddBatch(ctx context.Context, w io.Writer, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, proj *projects.Project, branches []string, base string, addCfg workspaceAddConfig) error {
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+
+	var added, existing int
+	var failed []string
+	for i, branch := range branches {
+		if err := ctx.Err(); err != nil {
+			return stoppedError(err, len(branches)-i, len(branches), "branches")
+		}
+
+		fmt.Fprintf(w, "[%d/%d] %s:%s\n", i+1, len(branches), proj.String(), branch)
+		if _, err := os.Stat(svc.WorkspacePath(*proj, branch)); err == nil {
+			fmt.Fprintln(w, "  exists, kept")
+			existing++
+			continue
+		}
+
+		if _, err := addWorkspace(ctx, w, cfg, projectsCfg, projectsLogger, proj, branch, base, addCfg); err != nil {
+			fmt.Fprintf(w, "  failed: %v\n", err)
+			failed = append(failed, branch)
+			continue
+		}
+		fmt.Fprintln(w, "  added")
+		added++
+	}
+
+	summary := fmt.Sprintf("Added %d of %d workspaces", added, len(branches))
+	if existing > 0 {
+		summary += fmt.Sprintf(", %d already existing", existing)
+	}
+	fmt.Fprintln(w, summary)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to add %d of %d workspaces: %s", len(failed), len(branches), strings.Join(failed, ", "))
+	}
+	return nil
+}
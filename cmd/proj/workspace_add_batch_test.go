@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+)
+
+func TestReadBranches(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "branches, comments and pull requests",
+			input: "# backports\nrelease-1.2\n\n  release-1.3  \n#123\n#\nrelease-1.2\n",
+			want:  []string{"release-1.2", "release-1.3", "#123"},
+		},
+		{
+			name:    "several branches on a line",
+			input:   "release-1.2 release-1.3\n",
+			wantErr: true,
+		},
+		{
+			name:    "no branches",
+			input:   "# nothing yet\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBranches(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readBranches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWorkspaceAddBatch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "user", "api")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"commit", "--allow-empty", "-m", "Initial commit"},
+		{"branch", "release-1.2"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com", "GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	cfg := &config.Config{RootDir: root}
+	projectsCfg := &projects.Config{RootDir: root, SkipSubmodules: true}
+	proj := &projects.Project{Path: dir, Name: "api", Organisation: "user"}
+
+	var out bytes.Buffer
+	err := runWorkspaceAddBatch(context.Background(), &out, cfg, projectsCfg, &mockLogger{}, proj, []string{"release-1.2", "backport-1.3", "#1"}, "", workspaceAddConfig{})
+	if err == nil || !strings.Contains(err.Error(), "failed to add 1 of 3 workspaces: #1") {
+		t.Fatalf("runWorkspaceAddBatch() error = %v, want the #1 failure", err)
+	}
+	for _, want := range []string{"[1/3] user/api:release-1.2\n  added\n", "[2/3] user/api:backport-1.3\n  added\n", "[3/3] user/api:#1\n  failed: ", "Added 2 of 3 workspaces\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, missing %q", out.String(), want)
+		}
+	}
+
+	// Running the batch again keeps the existing workspaces
+	out.Reset()
+	if err := runWorkspaceAddBatch(context.Background(), &out, cfg, projectsCfg, &mockLogger{}, proj, []string{"release-1.2", "backport-1.3"}, "", workspaceAddConfig{}); err != nil {
+		t.Fatalf("runWorkspaceAddBatch() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Added 0 of 2 workspaces, 2 already existing\n") {
+		t.Errorf("output = %q, want the existing workspaces kept", out.String())
+	}
+}
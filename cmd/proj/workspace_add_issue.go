@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/gfanton/projects/internal/github"
+)
+
+// runWorkspaceAddIssue adds the workspace of the GitHub issue addCfg.Issue
+// of proj, on a branch named from the issue, and records the issue in the
+// description of the branch. It returns the branch.
+func runWorkspaceAddIssue(ctx context.Context, w io.Writer, logger *slog.Logger, cfg *config.Config, projectsCfg *projects.Config, projectsLogger projects.Logger, proj *projects.Project, base string, addCfg workspaceAddConfig) (string, error) {
+	if err := checkGitHubProject(proj); err != nil {
+		return "", err
+	}
+	issue, err := newGitHubClient(ctx, logger, cfg, "").Issue(ctx, proj.Organisation, proj.Name, addCfg.Issue)
+	if errors.Is(err, github.ErrNotFound) {
+		return "", &exitError{
+			code: exitCodeNoMatch,
+			err:  fmt.Errorf("no issue #%d for %s", addCfg.Issue, proj.String()),
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get issue #%d: %w", addCfg.Issue, err)
+	}
+	if issue.PullRequest != nil {
+		return "", fmt.Errorf("#%d of %s is a pull request, use 'proj workspace add #%d'", issue.Number, proj.String(), issue.Number)
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	branch, err := svc.IssueBranch(issue.Number, issue.Title)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(w, "Branch for issue #%d: %s\n", issue.Number, branch)
+
+	if _, err := addWorkspace(ctx, w, cfg, projectsCfg, projectsLogger, proj, branch, base, addCfg); err != nil {
+		return "", err
+	}
+	return branch, svc.LinkIssue(ctx, *proj, branch, issue.Number, issue.Title, issue.HTMLURL)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type workspaceDUConfig struct {
+	All       bool
+	Exclusive bool
+	Top       int
+	StaleDays int
+}
+
+// workspaceUsage is the disk usage report of a workspace.
+type workspaceUsage struct {
+	Workspace  projects.Workspace
+	Size       int64
+	Dirty      bool
+	Merged     bool // the branch is merged into the default branch
+	LastCommit time.Time
+}
+
+func newWorkspaceDUCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	duCfg := &workspaceDUConfig{}
+	fs := ff.NewFlagSet("workspace du")
+	fs.BoolVar(&duCfg.All, 0, "all", "report workspaces of all projects")
+	fs.BoolVar(&duCfg.Exclusive, 0, "exclusive", "only count files not hard-linked elsewhere")
+	fs.IntVar(&duCfg.Top, 0, "top", 3, "number of biggest workspaces to highlight")
+	fs.IntVar(&duCfg.StaleDays, 0, "stale-days", 30, "days without commit after which a clean workspace is a prune candidate")
+
+	return &ff.Command{
+		Name:      "du",
+		Usage:     "workspace du [flags] [project]",
+		ShortHelp: "Show workspace disk usage",
+		LongHelp: `Show the disk usage of workspaces, biggest first.
+
+Workspaces share the Git objects of their project, so the reported size is
+the one of their checkout, including build outputs and dependencies such as
+node_modules. Hard-linked files are counted once; with --exclusive, they
+are not counted at all, which leaves only the space removing the workspace
+would free.
+
+Clean workspaces whose branch is merged into the default branch, or without
+commit for --stale-days, are suggested for removal.
+
+If the project parameter is not provided, the current directory must be
+inside a project, unless --all is set.
+
+Examples:
+  proj workspace du
+  proj workspace du --all
+  proj workspace du --all --exclusive --stale-days 14`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectStr string
+			if len(args) > 0 {
+				projectStr = args[0]
+			}
+
+			return runWorkspaceDU(ctx, projectsCfg, projectsLogger, projectStr, *duCfg)
+		},
+	}
+}
+
+func runWorkspaceDU(ctx context.Context, projectsCfg *projects.Config, projectsLogger projects.Logger, projectStr string, duCfg workspaceDUConfig) error {
+	var projs []*projects.Project
+	if duCfg.All {
+		var err error
+		projs, err = projects.NewProjectService(projectsCfg, projectsLogger).ListProjects()
+		if err := partialWalk(projectsCfg, projectsLogger, err); err != nil {
+			return fmt.Errorf("failed to list projects: %w", err)
+		}
+	} else {
+		proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+		if err != nil {
+			return err
+		}
+		projs = []*projects.Project{proj}
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
+	var usages []workspaceUsage
+	var stopped error
+scan:
+	for i, proj := range projs {
+		if err := ctx.Err(); err != nil {
+			stopped = stoppedError(err, len(projs)-i, len(projs), "projects")
+			break
+		}
+
+		workspaces, err := svc.List(ctx, *proj)
+		if err != nil {
+			projectsLogger.Warn("failed to list workspaces", "project", proj.String(), "error", err)
+			continue
+		}
+
+		var defaultBranch string
+		if len(workspaces) > 0 {
+			if defaultBranch, err = projectSvc.DefaultBranch(ctx, proj); err != nil {
+				projectsLogger.Debug("failed to get default branch", "project", proj.String(), "error", err)
+			}
+		}
+
+		for _, ws := range workspaces {
+			size, err := projects.DiskUsage(ctx, ws.Path, duCfg.Exclusive)
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					stopped = stoppedError(ctxErr, len(projs)-i, len(projs), "projects")
+					break scan
+				}
+				return err
+			}
+
+			usage := workspaceUsage{Workspace: ws, Size: size}
+			if usage.Dirty, err = svc.IsDirty(ctx, ws.Path); err != nil {
+				projectsLogger.Debug("failed to get workspace status", "path", ws.Path, "error", err)
+			}
+			if usage.LastCommit, err = svc.LastCommit(ctx, ws.Path); err != nil {
+				projectsLogger.Debug("failed to get workspace last commit", "path", ws.Path, "error", err)
+			}
+			if defaultBranch != "" && ws.Branch != defaultBranch {
+				if usage.Merged, err = svc.IsMerged(ctx, *proj, ws.Branch, defaultBranch); err != nil {
+					projectsLogger.Debug("failed to check if workspace is merged", "path", ws.Path, "error", err)
+				}
+			}
+			usages = append(usages, usage)
+		}
+	}
+
+	if len(usages) == 0 {
+		if stopped != nil {
+			return stopped
+		}
+		fmt.Println("No workspaces found")
+		return nil
+	}
+
+	printWorkspaceUsage(os.Stdout, usages, duCfg, time.Now())
+	return stopped
+}
+
+// printWorkspaceUsage writes the usages biggest first, marking the top
+// ones, followed by the total and the prune candidates.
+func printWorkspaceUsage(w io.Writer, usages []workspaceUsage, duCfg workspaceDUConfig, now time.Time) {
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Size > usages[j].Size
+	})
+
+	var total int64
+	for i, usage := range usages {
+		total += usage.Size
+
+		mark := " "
+		if i < duCfg.Top {
+			mark = "*"
+		}
+
+		state := ""
+		if usage.Dirty {
+			state = "  (dirty)"
+		}
+
+		name := usage.Workspace.Project.String() + ":" + usage.Workspace.Branch
+		fmt.Fprintf(w, "%s %10s  %-45s %s%s\n", mark, formatSize(usage.Size), name, formatAge(now, usage.LastCommit), state)
+	}
+	fmt.Fprintf(w, "\nTotal: %s in %d workspaces\n", formatSize(total), len(usages))
+
+	stale := now.Add(-time.Duration(duCfg.StaleDays) * 24 * time.Hour)
+	var candidates []workspaceUsage
+	for _, usage := range usages {
+		if usage.Dirty {
+			continue
+		}
+		if usage.Merged || (!usage.LastCommit.IsZero() && usage.LastCommit.Before(stale)) {
+			candidates = append(candidates, usage)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	var freed int64
+	fmt.Fprintf(w, "\nPrune candidates (clean, merged or no commit for %d days):\n", duCfg.StaleDays)
+	for _, usage := range candidates {
+		freed += usage.Size
+
+		reason := "stale"
+		if usage.Merged {
+			reason = "merged"
+		}
+		fmt.Fprintf(w, "  proj workspace remove %s %s   # %s, %s\n", usage.Workspace.Branch, usage.Workspace.Project.String(), formatSize(usage.Size), reason)
+	}
+	fmt.Fprintf(w, "Removing them would free up to %s\n", formatSize(freed))
+}
+
+// formatSize returns a human-readable size in binary units, such as "1.5 GiB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
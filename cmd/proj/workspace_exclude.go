@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newWorkspaceExcludeCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "exclude",
+		Usage:     "workspace exclude [pattern...]",
+		ShortHelp: "Keep files out of git status with .git/info/exclude",
+		LongHelp: `Add patterns to the .git/info/exclude file of the project containing the
+current directory, or list its patterns without arguments.
+
+Patterns follow the .gitignore syntax, relative to the root of the checkout,
+and apply to the project and all its workspaces, which share the file. Use it
+in the [setup] commands of the .proj.toml to keep the files they generate in
+new workspaces out of git status:
+
+  [setup]
+  go = 'cp "$(git rev-parse --git-common-dir)/../.env" . && proj workspace exclude /.env'
+
+'proj direnv init' excludes the .envrc it generates.
+
+Examples:
+  proj workspace exclude /.env /.tool-versions
+  proj workspace exclude`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runWorkspaceExclude(ctx, os.Stdout, projectsCfg, projectsLogger, args)
+		},
+	}
+}
+
+func runWorkspaceExclude(ctx context.Context, w io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, patterns []string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+	proj, err := resolveProject(projectsCfg, projectsLogger, "")
+	if err != nil {
+		return err
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	dir := contextDir(svc, proj, wd)
+
+	if len(patterns) == 0 {
+		excludes, err := svc.Excludes(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, pattern := range excludes {
+			fmt.Fprintln(w, pattern)
+		}
+		return nil
+	}
+
+	added, err := svc.Exclude(ctx, dir, patterns...)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range added {
+		fmt.Fprintf(w, "Excluded: %s\n", pattern)
+	}
+	if len(added) < len(patterns) {
+		fmt.Fprintf(w, "Already excluded: %d of %d patterns\n", len(patterns)-len(added), len(patterns))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type workspaceGCConfig struct {
+	DryRun bool
+	Yes    bool
+}
+
+func newWorkspaceGCCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	gcCfg := &workspaceGCConfig{}
+	fs := ff.NewFlagSet("workspace gc")
+	fs.BoolVar(&gcCfg.DryRun, 'n', "dry-run", "only list the orphaned directories")
+	fs.BoolVar(&gcCfg.Yes, 'y', "yes", "delete without asking for confirmation")
+
+	return &ff.Command{
+		Name:      "gc",
+		Usage:     "workspace gc [flags]",
+		ShortHelp: "Delete orphaned workspace directories",
+		LongHelp: `Find the directories of the workspace directory that are not registered
+as worktrees of their project anymore, such as the workspaces left behind by
+a manual 'rm -rf' of their project, and offer to delete them.
+
+'git worktree prune' is then run in the remaining projects of the deleted
+directories, to drop the worktree metadata left behind.
+
+Examples:
+  proj workspace gc --dry-run
+  proj workspace gc --yes`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return errors.New("proj workspace gc takes no arguments")
+			}
+			svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+			return runWorkspaceGC(ctx, os.Stdin, os.Stdout, svc, *gcCfg)
+		},
+	}
+}
+
+func runWorkspaceGC(ctx context.Context, in io.Reader, out io.Writer, svc *projects.WorkspaceService, gcCfg workspaceGCConfig) error {
+	orphans, err := svc.Orphans()
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Fprintln(out, "No orphaned workspace directories")
+		return nil
+	}
+
+	fmt.Fprintf(out, "Orphaned workspace directories in %s:\n", svc.WorkspaceDir())
+	for _, o := range orphans {
+		fmt.Fprintf(out, "  %-50s %s\n", o.Path, o.Reason)
+	}
+	if gcCfg.DryRun {
+		return nil
+	}
+
+	if !gcCfg.Yes {
+		p := &prompter{in: bufio.NewReader(in), out: out}
+		ok, err := p.confirm(fmt.Sprintf("Delete these %d directories?", len(orphans)), false)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	removed := 0
+	pruned := make(map[string]bool)
+	for _, o := range orphans {
+		if err := svc.RemoveOrphan(o); err != nil {
+			fmt.Fprintf(out, "  %v\n", err)
+			continue
+		}
+		removed++
+
+		if pruned[o.Project.String()] {
+			continue
+		}
+		pruned[o.Project.String()] = true
+		if err := svc.Prune(ctx, o.Project); err != nil {
+			fmt.Fprintf(out, "  %v\n", err)
+		}
+	}
+	fmt.Fprintf(out, "Deleted %d orphaned workspace directories\n", removed)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type workspaceIdleConfig struct {
+	Remind bool
+}
+
+func newWorkspaceIdleCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	idleCfg := &workspaceIdleConfig{}
+	fs := ff.NewFlagSet("workspace idle")
+	fs.BoolVar(&idleCfg.Remind, 0, "remind", "only print a one-line reminder, and nothing when no workspace is idle")
+
+	return &ff.Command{
+		Name:      "idle",
+		Usage:     "workspace idle [flags]",
+		ShortHelp: "List workspaces not used for a while",
+		LongHelp: `List the workspaces of all projects not used for longer than
+workspace.idle-after (default 30 days, 0 to disable), the least recently used
+first, as candidates for 'proj workspace remove'.
+
+A workspace is used when files are added to or removed from its directory,
+or when Git checks out, commits or stages in it. With 'proj init --idle',
+entering a workspace from the shell also counts, and this command reminds of
+the idle workspaces when the shell starts.
+
+Examples:
+  proj workspace idle
+  PROJECT_WORKSPACE_IDLE_AFTER=336h proj workspace idle`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return errors.New("proj workspace idle takes no arguments")
+			}
+			svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+			return runWorkspaceIdle(os.Stdout, svc, projectsCfg.IdleAfter, time.Now(), *idleCfg)
+		},
+	}
+}
+
+func runWorkspaceIdle(w io.Writer, svc *projects.WorkspaceService, idleAfter time.Duration, now time.Time, idleCfg workspaceIdleConfig) error {
+	if idleAfter <= 0 {
+		if !idleCfg.Remind {
+			fmt.Fprintln(w, "Idle workspaces are disabled (workspace.idle-after = 0)")
+		}
+		return nil
+	}
+
+	idle, err := svc.IdleWorkspaces(now)
+	if err != nil {
+		return err
+	}
+
+	if idleCfg.Remind {
+		if len(idle) > 0 {
+			fmt.Fprintf(w, "proj: %s not used for more than %s, see 'proj workspace idle'\n", plural(len(idle), "workspace"), formatIdleAfter(idleAfter))
+		}
+		return nil
+	}
+
+	if len(idle) == 0 {
+		fmt.Fprintf(w, "No workspaces idle for more than %s\n", formatIdleAfter(idleAfter))
+		return nil
+	}
+
+	fmt.Fprintf(w, "Workspaces idle for more than %s:\n", formatIdleAfter(idleAfter))
+	for _, ws := range idle {
+		fmt.Fprintf(w, "  %-40s %-50s last used %s\n", ws.Project.String()+":"+ws.Branch, ws.Path, formatAge(now, ws.LastAccess))
+	}
+	return nil
+}
+
+// formatIdleAfter returns d in days when it is a whole number of days, such
+// as the default "30 days".
+func formatIdleAfter(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return plural(int(d/(24*time.Hour)), "day")
+	}
+	return d.String()
+}
+
+func newWorkspaceTouchCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "touch",
+		Usage:     "workspace touch [dir]",
+		ShortHelp: "Record the use of the current workspace",
+		LongHelp: `Record the use of the workspace containing the current directory, or dir,
+so that it is not flagged as idle. Nothing is done outside workspaces.
+
+This is run by the shell hook of 'proj init --idle' on directory change.`,
+		Exec: func(ctx context.Context, args []string) error {
+			dir := ""
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			return runWorkspaceTouch(projectsCfg, projectsLogger, dir)
+		},
+	}
+}
+
+func runWorkspaceTouch(projectsCfg *projects.Config, projectsLogger projects.Logger, dir string) error {
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		dir = wd
+	}
+
+	proj, err := projects.NewProjectService(projectsCfg, projectsLogger).FindFromPath(dir)
+	if err != nil {
+		return nil // not in a project: nothing to record
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	workspace := contextDir(svc, proj, dir)
+	if workspace == proj.CheckoutPath() {
+		return nil
+	}
+	return svc.Touch(workspace)
+}
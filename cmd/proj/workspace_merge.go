@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type workspaceMergeBackConfig struct {
+	Base   string
+	Rebase bool
+	Remove bool
+}
+
+func newWorkspaceMergeBackCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	mergeCfg := &workspaceMergeBackConfig{}
+	fs := ff.NewFlagSet("workspace merge-back")
+	fs.StringVar(&mergeCfg.Base, 0, "base", "", "branch to merge into (default: the default branch)")
+	fs.BoolVar(&mergeCfg.Rebase, 0, "rebase", "rebase the workspace branch and fast-forward instead of merging")
+	fs.BoolVar(&mergeCfg.Remove, 0, "remove", "remove the workspace and delete its branch once merged")
+
+	return &ff.Command{
+		Name:      "merge-back",
+		Usage:     "workspace merge-back [flags] [branch] [project]",
+		ShortHelp: "Merge a workspace branch into the main checkout",
+		LongHelp: `Merge the branch of a workspace into the default branch, or --base, in the
+main checkout of the project, which must have it checked out.
+
+With --rebase, the branch is rebased onto the base in the workspace first,
+and the base is fast-forwarded, keeping the history linear. With --remove,
+the workspace and its branch are removed once merged.
+
+Both checkouts must be clean. On conflicts, the merge or rebase is aborted,
+leaving both checkouts untouched, and the conflicting files are listed.
+
+If the branch parameter is not provided, the current directory must be
+inside a workspace.
+
+Examples:
+  proj workspace merge-back                       # Merge the current workspace
+  proj workspace merge-back --rebase --remove     # Rebase, fast-forward and clean up
+  proj workspace merge-back feature user/project  # Merge the feature workspace`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var branch, projectStr string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			if len(args) > 1 {
+				projectStr = args[1]
+			}
+
+			return runWorkspaceMergeBack(ctx, projectsCfg, projectsLogger, branch, projectStr, *mergeCfg)
+		},
+	}
+}
+
+func runWorkspaceMergeBack(ctx context.Context, projectsCfg *projects.Config, projectsLogger projects.Logger, branch, projectStr string, mergeCfg workspaceMergeBackConfig) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	ws, err := resolveWorkspace(ctx, svc, proj, branch)
+	if err != nil {
+		return err
+	}
+
+	base := mergeCfg.Base
+	if base == "" {
+		if base, err = projects.NewProjectService(projectsCfg, projectsLogger).DefaultBranch(ctx, proj); err != nil {
+			return err
+		}
+	}
+
+	err = svc.MergeBack(ctx, ws, projects.MergeBackOptions{
+		Base:   base,
+		Rebase: mergeCfg.Rebase,
+		Remove: mergeCfg.Remove,
+	})
+	var conflict *projects.MergeConflictError
+	if errors.As(err, &conflict) {
+		printMergeConflict(os.Stderr, conflict, ws)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Merged %s into %s in %s\n", ws.Branch, base, proj.CheckoutPath())
+	if mergeCfg.Remove {
+		fmt.Printf("Removed workspace %s\n", ws.Path)
+	}
+	return nil
+}
+
+// resolveWorkspace returns the workspace of proj for branch, a branch or the
+// argument it was added with such as "#123", or the one containing the
+// current directory when branch is empty.
+func resolveWorkspace(ctx context.Context, svc *projects.WorkspaceService, proj *projects.Project, branch string) (projects.Workspace, error) {
+	if branch != "" {
+		return lookupWorkspace(ctx, svc, proj, func(ws projects.Workspace) bool {
+			return ws.Branch == branch || ws.Path == svc.WorkspacePath(*proj, branch)
+		})
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return projects.Workspace{}, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	dir := contextDir(svc, proj, wd)
+	if dir == proj.CheckoutPath() {
+		return projects.Workspace{}, errors.New("not inside a workspace and no branch specified")
+	}
+	return lookupWorkspace(ctx, svc, proj, func(ws projects.Workspace) bool {
+		return ws.Path == dir
+	})
+}
+
+// lookupWorkspace returns the first workspace of proj matching match.
+func lookupWorkspace(ctx context.Context, svc *projects.WorkspaceService, proj *projects.Project, match func(projects.Workspace) bool) (projects.Workspace, error) {
+	workspaces, err := svc.List(ctx, *proj)
+	if err != nil {
+		return projects.Workspace{}, err
+	}
+
+	for _, ws := range workspaces {
+		if match(ws) {
+			return ws, nil
+		}
+	}
+	return projects.Workspace{}, fmt.Errorf("%w for %s", projects.ErrWorkspaceNotFound, proj.String())
+}
+
+// printMergeConflict writes the conflicting files of conflict and how to
+// resolve them by hand.
+func printMergeConflict(w io.Writer, conflict *projects.MergeConflictError, ws projects.Workspace) {
+	if conflict.Rebase {
+		fmt.Fprintf(w, "Rebasing %s onto %s conflicts in:\n", conflict.Branch, conflict.Base)
+	} else {
+		fmt.Fprintf(w, "Merging %s into %s conflicts in:\n", conflict.Branch, conflict.Base)
+	}
+	for _, file := range conflict.Files {
+		fmt.Fprintf(w, "  %s\n", file)
+	}
+
+	fmt.Fprintln(w, "\nThe operation was aborted and both checkouts are unchanged. To resolve the conflicts, run:")
+	if conflict.Rebase {
+		fmt.Fprintf(w, "  cd %s && git rebase %s\n", ws.Path, conflict.Base)
+	} else {
+		fmt.Fprintf(w, "  cd %s && git merge %s\n", ws.Project.CheckoutPath(), conflict.Branch)
+	}
+}
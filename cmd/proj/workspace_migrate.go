@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type workspaceMigrateConfig struct {
+	DryRun bool
+}
+
+func newWorkspaceMigrateCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	migrateCfg := &workspaceMigrateConfig{}
+	fs := ff.NewFlagSet("workspace migrate")
+	fs.BoolVar(&migrateCfg.DryRun, 'n', "dry-run", "only list the workspaces to move")
+
+	return &ff.Command{
+		Name:      "migrate",
+		Usage:     "workspace migrate [flags]",
+		ShortHelp: "Move workspaces to the current naming of branches",
+		LongHelp: `Move the workspaces named after the legacy encoding of their branch to
+their current directory: the layout version 1 of 'proj migrate', which this
+command runs.
+
+Branch names were encoded by replacing "/" with "--" only, so that the
+branches "feat/x" and "feat--x" shared the directory "feat--x". The dashes
+next to a dash or a slash are now escaped as "%2D", and "%" as "%25":
+"feat--x" is created in "feat%2D%2Dx". Workspaces left at their legacy
+directory keep working until they are moved.
+
+Workspaces are moved with 'git worktree move', which refuses to move the
+workspaces holding submodules: move them by hand, or remove and add them
+again.
+
+Examples:
+  proj workspace migrate --dry-run
+  proj workspace migrate`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return errors.New("proj workspace migrate takes no arguments")
+			}
+			svc := projects.NewLayoutService(projectsCfg, projectsLogger)
+			current, err := svc.Version()
+			if err != nil {
+				return err
+			}
+			return runMigrate(ctx, os.Stdout, svc, projectsCfg.RootDir, max(current, projects.LayoutWorkspaceNames), migrateCfg.DryRun)
+		},
+	}
+}
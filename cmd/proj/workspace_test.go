@@ -2,7 +2,9 @@ package main
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gfanton/projects"
 )
@@ -153,3 +155,90 @@ func TestFindProjectFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPrintWorkspaceUsage(t *testing.T) {
+	now := time.Now()
+	proj := projects.Project{Organisation: "user", Name: "proj"}
+	usages := []workspaceUsage{
+		{Workspace: projects.Workspace{Project: proj, Branch: "small"}, Size: 1 << 10, LastCommit: now},
+		{Workspace: projects.Workspace{Project: proj, Branch: "old"}, Size: 2 << 30, LastCommit: now.AddDate(0, -2, 0)},
+		{Workspace: projects.Workspace{Project: proj, Branch: "dirty"}, Size: 1 << 20, Dirty: true, LastCommit: now.AddDate(0, -2, 0)},
+	}
+
+	var out strings.Builder
+	printWorkspaceUsage(&out, usages, workspaceDUConfig{Top: 1, StaleDays: 30}, now)
+
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasPrefix(lines[0], "*") || !strings.Contains(lines[0], "user/proj:old") {
+		t.Errorf("first line = %q, want the biggest workspace highlighted", lines[0])
+	}
+	if !strings.Contains(out.String(), "proj workspace remove old user/proj") {
+		t.Errorf("output missing prune candidate:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "remove dirty") || strings.Contains(out.String(), "remove small") {
+		t.Errorf("output suggests removing dirty or recent workspaces:\n%s", out.String())
+	}
+}
+
+func TestPrintMergeConflict(t *testing.T) {
+	ws := projects.Workspace{
+		Project: projects.Project{Path: "/code/user/repo"},
+		Branch:  "feature",
+		Path:    "/code/.workspace/user/repo/feature",
+	}
+	conflict := &projects.MergeConflictError{Branch: "feature", Base: "main", Rebase: true, Files: []string{"a.go", "b.go"}}
+
+	var buf strings.Builder
+	printMergeConflict(&buf, conflict, ws)
+
+	expected := `Rebasing feature onto main conflicts in:
+  a.go
+  b.go
+
+The operation was aborted and both checkouts are unchanged. To resolve the conflicts, run:
+  cd /code/.workspace/user/repo/feature && git rebase main
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("printMergeConflict() =\n%s\nwant:\n%s", got, expected)
+	}
+}
+
+func TestPrintDirtyWorkspace(t *testing.T) {
+	dirtyErr := &projects.DirtyWorkspaceError{
+		Path:    "/code/.workspace/user/repo/feature",
+		Summary: " main.go | 2 +-\n 1 file changed, 1 insertion(+), 1 deletion(-)\n 2 untracked files",
+	}
+
+	var buf strings.Builder
+	printDirtyWorkspace(&buf, dirtyErr)
+
+	expected := `Workspace /code/.workspace/user/repo/feature has uncommitted changes:
+ main.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+ 2 untracked files
+
+Commit or stash them, or use --force to discard them.
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("printDirtyWorkspace() =\n%s\nwant:\n%s", got, expected)
+	}
+}
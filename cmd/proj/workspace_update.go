@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newWorkspaceUpdateCommand(projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	fs := ff.NewFlagSet("workspace update")
+
+	return &ff.Command{
+		Name:      "update",
+		Usage:     "workspace update [branch|#pr] [project]",
+		ShortHelp: "Refresh a workspace from its PR or upstream",
+		LongHelp: `Refresh the checkout of a workspace.
+
+Pull request workspaces, added with #123, are reset to the head of the pull
+request fetched again, following force pushes. Other workspaces are
+fast-forwarded to the upstream of their branch (git pull --ff-only).
+
+The workspace must be clean. If the branch parameter is not provided, the
+current directory must be inside a workspace.
+
+Examples:
+  proj workspace update                  # Refresh the current workspace
+  proj workspace update '#123'           # Fetch the latest head of PR 123
+  proj workspace update feature user/project`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var branch, projectStr string
+			if len(args) > 0 {
+				branch = args[0]
+			}
+			if len(args) > 1 {
+				projectStr = args[1]
+			}
+
+			return runWorkspaceUpdate(ctx, os.Stdout, projectsCfg, projectsLogger, branch, projectStr)
+		},
+	}
+}
+
+func runWorkspaceUpdate(ctx context.Context, out io.Writer, projectsCfg *projects.Config, projectsLogger projects.Logger, branch, projectStr string) error {
+	proj, err := resolveProject(projectsCfg, projectsLogger, projectStr)
+	if err != nil {
+		return err
+	}
+
+	svc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	ws, err := resolveWorkspace(ctx, svc, proj, branch)
+	if err != nil {
+		return err
+	}
+
+	from, to, err := svc.Update(ctx, ws)
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		fmt.Fprintf(out, "%s is already up to date (%s)\n", ws.Branch, shortHash(to))
+		return nil
+	}
+	fmt.Fprintf(out, "Updated %s: %s..%s\n", ws.Branch, shortHash(from), shortHash(to))
+	return nil
+}
+
+// shortHash abbreviates a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
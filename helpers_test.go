@@ -0,0 +1,20 @@
+package projects
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// runGit runs git with args in dir, committing as a test user, and returns
+// its trimmed output. It fails t when git fails.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
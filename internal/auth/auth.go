@@ -0,0 +1,121 @@
+// Package auth resolves provider tokens from the configuration, the
+// environment, the GitHub CLI and the OS keychain.
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Source identifies where a token was found.
+type Source string
+
+const (
+	SourceNone     Source = ""
+	SourceFlag     Source = "flag"
+	SourceConfig   Source = "config"
+	SourceEnv      Source = "env"
+	SourceGHCLI    Source = "gh"
+	SourceKeychain Source = "keychain"
+)
+
+const (
+	// DefaultHost is the provider host tokens are looked up for.
+	DefaultHost = "github.com"
+
+	// KeychainService is the service name of tokens stored in the OS keychain.
+	KeychainService = "proj"
+)
+
+// envVars are the environment variables holding a GitHub token, by priority.
+var envVars = []string{"GITHUB_TOKEN", "GH_TOKEN"}
+
+// Manager resolves the token used to authenticate against a provider.
+type Manager struct {
+	logger      *slog.Logger
+	configToken string
+
+	// Overridable for tests
+	goos   string
+	getenv func(string) string
+	run    func(ctx context.Context, name string, args ...string) (string, error)
+}
+
+// NewManager creates a token manager. configToken is the token set in the
+// configuration file (or PROJECT_GITHUB_TOKEN), and may be empty.
+func NewManager(logger *slog.Logger, configToken string) *Manager {
+	return &Manager{
+		logger:      logger,
+		configToken: configToken,
+		goos:        runtime.GOOS,
+		getenv:      os.Getenv,
+		run:         runCommand,
+	}
+}
+
+// Token returns the token for host and where it was found. The first
+// non-empty token wins, in order: flagToken, configuration, GITHUB_TOKEN and
+// GH_TOKEN environment variables, 'gh auth token', then the OS keychain.
+// An empty token with SourceNone is returned when none is available:
+// requests are then unauthenticated.
+func (m *Manager) Token(ctx context.Context, host, flagToken string) (string, Source) {
+	if flagToken != "" {
+		return flagToken, SourceFlag
+	}
+
+	if m.configToken != "" {
+		return m.configToken, SourceConfig
+	}
+
+	for _, name := range envVars {
+		if token := m.getenv(name); token != "" {
+			return token, SourceEnv
+		}
+	}
+
+	token, err := m.run(ctx, "gh", "auth", "token", "--hostname", host)
+	if err != nil {
+		m.logger.Debug("no token from gh cli", "host", host, "error", err)
+	} else if token != "" {
+		return token, SourceGHCLI
+	}
+
+	token, err = m.keychainToken(ctx, host)
+	if err != nil {
+		m.logger.Debug("no token from keychain", "host", host, "error", err)
+	} else if token != "" {
+		return token, SourceKeychain
+	}
+
+	return "", SourceNone
+}
+
+// keychainToken reads the token of host from the OS keychain: the macOS
+// keychain, or the Secret Service on Linux. Tokens are stored with:
+//
+//	security add-generic-password -s proj -a github.com -w <token>
+//	secret-tool store --label=proj service proj host github.com
+func (m *Manager) keychainToken(ctx context.Context, host string) (string, error) {
+	switch m.goos {
+	case "darwin":
+		return m.run(ctx, "security", "find-generic-password", "-s", KeychainService, "-a", host, "-w")
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return m.run(ctx, "secret-tool", "lookup", "service", KeychainService, "host", host)
+	default:
+		return "", fmt.Errorf("keychain not supported on %s", m.goos)
+	}
+}
+
+// runCommand runs name and returns its trimmed standard output.
+func runCommand(ctx context.Context, name string, args ...string) (string, error) {
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
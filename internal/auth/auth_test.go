@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newTestManager(configToken string, env map[string]string, commands map[string]string) *Manager {
+	m := NewManager(slog.New(slog.NewTextHandler(io.Discard, nil)), configToken)
+	m.goos = "linux"
+	m.getenv = func(name string) string { return env[name] }
+	m.run = func(ctx context.Context, name string, args ...string) (string, error) {
+		if output, ok := commands[name]; ok {
+			return output, nil
+		}
+		return "", errors.New(name + ": not found")
+	}
+	return m
+}
+
+func TestToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		config     string
+		env        map[string]string
+		commands   map[string]string
+		wantToken  string
+		wantSource Source
+	}{
+		{
+			name:       "flag first",
+			flag:       "flag-token",
+			config:     "config-token",
+			env:        map[string]string{"GITHUB_TOKEN": "env-token"},
+			wantToken:  "flag-token",
+			wantSource: SourceFlag,
+		},
+		{
+			name:       "config before env",
+			config:     "config-token",
+			env:        map[string]string{"GITHUB_TOKEN": "env-token"},
+			wantToken:  "config-token",
+			wantSource: SourceConfig,
+		},
+		{
+			name:       "GH_TOKEN",
+			env:        map[string]string{"GH_TOKEN": "gh-env-token"},
+			commands:   map[string]string{"gh": "gh-token"},
+			wantToken:  "gh-env-token",
+			wantSource: SourceEnv,
+		},
+		{
+			name:       "gh cli before keychain",
+			commands:   map[string]string{"gh": "gh-token", "secret-tool": "keychain-token"},
+			wantToken:  "gh-token",
+			wantSource: SourceGHCLI,
+		},
+		{
+			name:       "keychain",
+			commands:   map[string]string{"secret-tool": "keychain-token"},
+			wantToken:  "keychain-token",
+			wantSource: SourceKeychain,
+		},
+		{
+			name:       "empty gh output",
+			commands:   map[string]string{"gh": ""},
+			wantSource: SourceNone,
+		},
+		{
+			name:       "none",
+			wantSource: SourceNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(tt.config, tt.env, tt.commands)
+			token, source := m.Token(context.Background(), DefaultHost, tt.flag)
+			if token != tt.wantToken || source != tt.wantSource {
+				t.Errorf("Token() = (%q, %q), want (%q, %q)", token, source, tt.wantToken, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestKeychainToken(t *testing.T) {
+	var got string
+	m := newTestManager("", nil, nil)
+	m.run = func(ctx context.Context, name string, args ...string) (string, error) {
+		got = name + " " + strings.Join(args, " ")
+		return "token", nil
+	}
+
+	m.goos = "darwin"
+	if _, err := m.keychainToken(context.Background(), "github.com"); err != nil {
+		t.Fatalf("keychainToken() failed: %v", err)
+	}
+	if want := "security find-generic-password -s proj -a github.com -w"; got != want {
+		t.Errorf("darwin command = %q, want %q", got, want)
+	}
+
+	m.goos = "windows"
+	if _, err := m.keychainToken(context.Background(), "github.com"); err == nil {
+		t.Error("keychainToken() should fail on unsupported platforms")
+	}
+}
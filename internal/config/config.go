@@ -10,8 +10,14 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gfanton/projects/internal/logfile"
 	"github.com/peterbourgon/ff/v4"
 	"github.com/peterbourgon/ff/v4/fftoml"
 )
@@ -20,10 +26,72 @@ const defaultDirPerms = 0755
 
 // Config holds the global configuration for the project tool.
 type Config struct {
-	ConfigFile string `ff:"long=config,  usage='configuration file path'"`
-	Debug      bool   `ff:"long=debug,   usage='enable debug logging'"`
-	RootDir    string `ff:"long=root,    usage='root directory for projects'"`
-	RootUser   string `ff:"long=user,    usage='default user for projects'"`
+	ConfigFile  string `ff:"long=config,  usage='configuration file path'"`
+	Debug       bool   `ff:"long=debug,   usage='enable debug logging'"`
+	RootDir     string `ff:"long=root,    usage='root directory for projects'"`
+	RootUser    string `ff:"long=user,    usage='default user for projects'"`
+	Rank        string `ff:"long=rank,    usage='default ranking algorithm for queries (fuzzy|substring|exact|frecency)'"`
+	ErrorFormat string `ff:"long=error-format, usage='error output format (text|json)'"`
+	GitHubToken string `ff:"long=github-token, usage='GitHub token (default: $GITHUB_TOKEN, gh auth token or keychain)'"`
+	GitHubAPI   string `ff:"long=github.api, usage='how GitHub API requests are made: http, or gh to run them with gh api and its authentication'"`
+	Log         bool   `ff:"long=log,     usage='also write debug JSON logs to $XDG_STATE_HOME/proj/proj.log'"`
+	LogFormat   string `ff:"long=log-format, usage='stderr log format (text|json)'"`
+	Color       string `ff:"long=color,   usage='color output (auto|always|never), auto coloring terminals unless NO_COLOR is set'"`
+	ColorTheme  string `ff:"long=color-theme, usage='colors of the output per role, e.g. org=blue,match=red+bold (comma separated)'"`
+	UpdateCheck bool   `ff:"long=update-check, usage='show a hint in help output when a newer proj release is available'"`
+	ReadOnly    bool   `ff:"long=read-only, usage='refuse operations modifying the root directory (clone, mkdir, worktree add, symlink)'"`
+	Strict      bool   `ff:"long=strict,   usage='fail when directories of the root can't be read instead of using partial results'"`
+
+	Timeout        time.Duration `ff:"long=timeout,         usage='timeout of the whole command (0 = none)'"`
+	CloneTimeout   time.Duration `ff:"long=clone.timeout,   usage='timeout of each clone (0 = none)'"`
+	NetworkTimeout time.Duration `ff:"long=network.timeout, usage='timeout of each other Git network operation: fetch, ls-remote, submodule update, LFS pull (0 = none)'"`
+
+	RetryAttempts int           `ff:"long=retry.attempts, usage='attempts of Git network operations failing with transient errors (1 = no retry)'"`
+	RetryBackoff  time.Duration `ff:"long=retry.backoff,  usage='delay before the first retry, doubled before each next one'"`
+
+	CloneProtocol  string `ff:"long=clone.protocol, usage='clone protocol (auto|ssh|https)'"`
+	CloneHosts     string `ff:"long=clone.hosts,    usage='per-host clone protocols (host=protocol, comma separated)'"`
+	CloneReference string `ff:"long=clone.reference, usage='local clone to borrow objects from when cloning (auto|none|user/project)'"`
+	CloneUpdate    bool   `ff:"long=clone.update,    usage='fetch and fast-forward the default branch of projects proj get finds already cloned'"`
+
+	WorkspaceSubmodules bool `ff:"long=workspace.submodules, usage='initialize submodules in new workspaces'"`
+	WorkspaceDirenv     bool `ff:"long=workspace.direnv,     usage='run direnv allow on the .envrc of new workspaces'"`
+
+	WorkspaceBranchTemplate string `ff:"long=workspace.branch-template, usage='branch of workspaces added for a ticket, e.g. {user}/{ticket}-{slug}'"`
+	WorkspaceTicketURL      string `ff:"long=workspace.ticket-url,      usage='URL template ({ticket}) the title of tickets is fetched from'"`
+
+	WorkspaceIssueBranchTemplate string `ff:"long=workspace.issue-branch-template, usage='branch of workspaces added for a GitHub issue with --issue, e.g. {user}/{issue}-{slug}'"`
+
+	WorkspaceIdleAfter time.Duration `ff:"long=workspace.idle-after, usage='flag workspaces not used for this long as idle (0 = never)'"`
+
+	DUArtifacts string `ff:"long=du.artifacts, usage='build artifact directory patterns reported by proj du (comma separated)'"`
+	DUClean     string `ff:"long=du.clean,     usage='clean commands of proj du --clean per language (language=command, comma separated)'"`
+
+	WalkAllowHidden string `ff:"long=walk.allow-hidden, usage='hidden directories walked for projects like others, e.g. .dotfiles (comma separated)'"`
+
+	// Aliases maps short names to "user/project", from the [aliases] table
+	// of the config file.
+	Aliases map[string]string
+
+	// Sets maps the names of project sets, such as the repositories of a
+	// polyrepo product, to their projects, from the [sets] table of the
+	// config file.
+	Sets map[string][]string
+
+	// Hosts maps the names of Git servers other than GitHub to their
+	// settings, from the [hosts."<host>"] tables of the config file.
+	Hosts map[string]Host
+
+	// Templates maps the names of the scaffold templates of 'proj new
+	// --template' to their settings, from the [templates.<name>] tables of
+	// the config file.
+	Templates map[string]Template
+
+	// includeErrors holds the errors of the skipped includes of the config
+	// file.
+	includeErrors []error
+	// includeRefreshes tracks the refreshes of stale remote includes.
+	includeRefreshes sync.WaitGroup
 }
 
 // NewConfig creates a new configuration with default values.
@@ -34,9 +102,24 @@ func NewConfig() (*Config, error) {
 	}
 
 	return &Config{
-		ConfigFile: filepath.Join(u.HomeDir, ".projectrc"),
-		RootDir:    filepath.Join(u.HomeDir, "code"),
-		Debug:      false,
+		ConfigFile:                   filepath.Join(u.HomeDir, ".projectrc"),
+		RootDir:                      filepath.Join(u.HomeDir, "code"),
+		Rank:                         "fuzzy",
+		ErrorFormat:                  "text",
+		LogFormat:                    "text",
+		Color:                        "auto",
+		GitHubAPI:                    "http",
+		CloneProtocol:                "auto",
+		CloneTimeout:                 time.Hour,
+		NetworkTimeout:               5 * time.Minute,
+		RetryAttempts:                3,
+		RetryBackoff:                 2 * time.Second,
+		WorkspaceSubmodules:          true,
+		WorkspaceIssueBranchTemplate: "{issue}-{slug}",
+		WorkspaceIdleAfter:           30 * 24 * time.Hour,
+		DUArtifacts:                  "node_modules,target,.venv",
+		DUClean:                      "rust=cargo clean,javascript=rm -rf node_modules,python=rm -rf .venv",
+		Debug:                        false,
 	}, nil
 }
 
@@ -57,7 +140,7 @@ func (c *Config) Load(args []string) error {
 		ff.WithEnvVarPrefix("PROJECT"),
 		ff.WithConfigFileFlag("config"),
 		ff.WithConfigAllowMissingFile(),
-		ff.WithConfigFileParser(fftoml.Parse),
+		ff.WithConfigFileParser(c.parseConfigFile),
 	)
 	if err != nil {
 		// Ignore help requests - those are handled by the main command parser
@@ -68,8 +151,13 @@ func (c *Config) Load(args []string) error {
 	}
 
 	// Expand paths
-	c.RootDir = expandPath(c.RootDir)
-	c.ConfigFile = expandPath(c.ConfigFile)
+	c.RootDir = ExpandPath(c.RootDir)
+	c.ConfigFile = ExpandPath(c.ConfigFile)
+
+	// Validate before creating anything from the configuration
+	if err := c.Validate(); err != nil {
+		return err
+	}
 
 	// Ensure root directory exists
 	if err := c.ensureRootDir(); err != nil {
@@ -79,19 +167,233 @@ func (c *Config) Load(args []string) error {
 	return nil
 }
 
-// filterGlobalFlags extracts only global config flags from args.
+// Host holds the settings of a Git server, such as a GitHub Enterprise
+// instance, whose projects are named "<host>/user/project".
+type Host struct {
+	// SSHUser is the user of SSH clone URLs (default: git).
+	SSHUser string
+	// DefaultOrgPrefix is the organisation, e.g. "team/", of the project
+	// names of the host without one.
+	DefaultOrgPrefix string
+}
+
+const (
+	// aliasesTable is the config file table of the project aliases.
+	aliasesTable = "aliases"
+	// setsTable is the config file table of the project sets.
+	setsTable = "sets"
+	// hostsTable is the config file table of the Git server settings.
+	hostsTable = "hosts"
+)
+
+// hostKey splits the config file key name of a [hosts."<host>"] setting
+// into the host and the setting. Since host names contain dots, the setting
+// is the last segment of the key.
+func hostKey(name string) (host, setting string, ok bool) {
+	rest, ok := strings.CutPrefix(name, hostsTable+".")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	host, setting = strings.Trim(rest[:i], `"`), rest[i+1:]
+	switch setting {
+	case "ssh_user", "default_org_prefix":
+		return host, setting, true
+	}
+	return "", "", false
+}
+
+// userPattern matches valid default users: GitHub-style user and
+// organisation names, also allowing dots and underscores of other providers.
+var userPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// setNamePattern matches valid project set names, also used in the names
+// of their multiplexer sessions.
+var setNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// AllowHidden returns the hidden directories of walk.allow-hidden.
+func (c *Config) AllowHidden() []string {
+	var names []string
+	for _, name := range strings.Split(c.WalkAllowHidden, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate checks the loaded configuration values.
+func (c *Config) Validate() error {
+	if !filepath.IsAbs(c.RootDir) {
+		return fmt.Errorf("invalid root '%s': must be an absolute path (after ~ and $VAR expansion)", c.RootDir)
+	}
+
+	if c.LogFormat != "" && c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("invalid log-format '%s': expected text or json", c.LogFormat)
+	}
+
+	if c.Color != "" && c.Color != "auto" && c.Color != "always" && c.Color != "never" {
+		return fmt.Errorf("invalid color '%s': expected auto, always or never", c.Color)
+	}
+
+	if c.GitHubAPI != "" && c.GitHubAPI != "http" && c.GitHubAPI != "gh" {
+		return fmt.Errorf("invalid github.api '%s': expected http or gh", c.GitHubAPI)
+	}
+
+	if c.RootUser != "" && !userPattern.MatchString(c.RootUser) {
+		return fmt.Errorf("invalid user '%s': must start with a letter or digit and contain only letters, digits, '.', '_' and '-'", c.RootUser)
+	}
+
+	for name, timeout := range map[string]time.Duration{"timeout": c.Timeout, "clone.timeout": c.CloneTimeout, "network.timeout": c.NetworkTimeout, "retry.backoff": c.RetryBackoff, "workspace.idle-after": c.WorkspaceIdleAfter} {
+		if timeout < 0 {
+			return fmt.Errorf("invalid %s '%s': must not be negative", name, timeout)
+		}
+	}
+
+	if c.RetryAttempts < 0 {
+		return fmt.Errorf("invalid retry.attempts '%d': must not be negative", c.RetryAttempts)
+	}
+
+	if c.WorkspaceBranchTemplate != "" && !strings.Contains(c.WorkspaceBranchTemplate, "{ticket}") {
+		return fmt.Errorf("invalid workspace.branch-template '%s': must contain {ticket}", c.WorkspaceBranchTemplate)
+	}
+
+	if c.WorkspaceIssueBranchTemplate != "" && !strings.Contains(c.WorkspaceIssueBranchTemplate, "{issue}") {
+		return fmt.Errorf("invalid workspace.issue-branch-template '%s': must contain {issue}", c.WorkspaceIssueBranchTemplate)
+	}
+
+	for _, name := range c.AllowHidden() {
+		if !strings.HasPrefix(name, ".") || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return fmt.Errorf("invalid walk.allow-hidden '%s': expected the name of a hidden directory, such as .dotfiles", name)
+		}
+	}
+
+	for alias, target := range c.Aliases {
+		if alias == "" || strings.ContainsAny(alias, "/:") {
+			return fmt.Errorf("invalid alias '%s': must not be empty or contain '/' or ':'", alias)
+		}
+		if org, name, ok := strings.Cut(target, "/"); !ok || org == "" || name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("invalid alias %s = '%s': expected user/project", alias, target)
+		}
+	}
+
+	for set, names := range c.Sets {
+		if !setNamePattern.MatchString(set) {
+			return fmt.Errorf("invalid set '%s': must start with a letter or digit and contain only letters, digits and '-'", set)
+		}
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" || strings.HasPrefix(name, "@") {
+				return fmt.Errorf("invalid set %s project '%s': expected a project name", set, name)
+			}
+		}
+	}
+
+	for name, host := range c.Hosts {
+		if name == "" || strings.ContainsAny(name, "/:@") {
+			return fmt.Errorf("invalid host '%s': must not be empty or contain '/', ':' or '@'", name)
+		}
+		if strings.ContainsAny(host.SSHUser, "/:@") {
+			return fmt.Errorf("invalid hosts.%s.ssh_user '%s': must not contain '/', ':' or '@'", name, host.SSHUser)
+		}
+		if org := strings.TrimSuffix(host.DefaultOrgPrefix, "/"); host.DefaultOrgPrefix != "" && !userPattern.MatchString(org) {
+			return fmt.Errorf("invalid hosts.%s.default_org_prefix '%s': expected an organisation such as 'team/'", name, host.DefaultOrgPrefix)
+		}
+	}
+
+	for name, t := range c.Templates {
+		if err := ValidateTemplate(name, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnknownKeys returns the keys of the config file that don't match any
+// configuration option, in file order. A missing config file has none.
+func (c *Config) UnknownKeys() ([]string, error) {
+	f, err := os.Open(c.ConfigFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	valid := make(map[string]bool)
+	for _, key := range ValidKeys() {
+		valid[key] = true
+	}
+
+	var unknown []string
+	seen := make(map[string]bool)
+	err = fftoml.Parse(f, func(name, value string) error {
+		if name == includeKey || strings.HasPrefix(name, aliasesTable+".") || strings.HasPrefix(name, setsTable+".") {
+			return nil
+		}
+		if _, _, ok := hostKey(name); ok {
+			return nil
+		}
+		if _, _, ok := templateKey(name); ok {
+			return nil
+		}
+		if !valid[name] && !seen[name] {
+			seen[name] = true
+			unknown = append(unknown, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", c.ConfigFile, err)
+	}
+
+	return unknown, nil
+}
+
+// ValidKeys returns the sorted config file keys, from the long flag names
+// of the Config struct tags.
+func ValidKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		for _, opt := range strings.Split(t.Field(i).Tag.Get("ff"), ",") {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(opt), "long="); ok {
+				keys = append(keys, name)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// filterGlobalFlags extracts only global config flags from args, at any
+// position before a "--" terminator, e.g. after a subcommand.
 // Global flags are: --debug, --root, --user, --config (and their values)
 func filterGlobalFlags(args []string) []string {
 	var filtered []string
 	globalFlags := map[string]bool{
-		"--debug":  false, // bool flag, no value
-		"--root":   true,  // string flag, has value
-		"--user":   true,  // string flag, has value
-		"--config": true,  // string flag, has value
+		"--debug":        false, // bool flag, no value
+		"--root":         true,  // string flag, has value
+		"--user":         true,  // string flag, has value
+		"--config":       true,  // string flag, has value
+		"--error-format": true,  // string flag, has value
+		"--github-token": true,  // string flag, has value
+		"--log-format":   true,  // string flag, has value
+		"--color":        true,  // string flag, has value
+		"--read-only":    false, // bool flag, no value
+		"--strict":       false, // bool flag, no value
+		"--timeout":      true,  // duration flag, has value
 	}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
+		if arg == "--" {
+			break
+		}
 
 		// Check for --flag=value format
 		if strings.HasPrefix(arg, "--") {
@@ -113,20 +415,101 @@ func filterGlobalFlags(args []string) []string {
 }
 
 // Logger creates a structured logger based on the debug configuration.
+// With Log set, debug records are also written as JSON to the log file.
 func (c *Config) Logger() *slog.Logger {
 	level := slog.LevelInfo
 	if c.Debug {
 		level = slog.LevelDebug
 	}
 
-	handler := NewToolHandler(os.Stderr, level)
+	var handler slog.Handler = NewToolHandler(os.Stderr, level)
+	if c.LogFormat == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	}
+
+	if c.Log {
+		fileHandler, err := newLogFileHandler()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "!W: failed to open log file (error=%v)\n", err)
+		} else {
+			handler = &teeHandler{handlers: []slog.Handler{handler, fileHandler}}
+		}
+	}
+
 	return slog.New(handler)
 }
 
+// newLogFileHandler returns a JSON handler writing debug records to the
+// default log file, tagged with the command and process id since several
+// proj processes share the file.
+func newLogFileHandler() (slog.Handler, error) {
+	path, err := logfile.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := logfile.Open(path, logfile.DefaultMaxSize, logfile.DefaultBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := slog.NewJSONHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return handler.WithAttrs([]slog.Attr{
+		slog.String("cmd", filepath.Base(os.Args[0])),
+		slog.Int("pid", os.Getpid()),
+	}), nil
+}
+
+// teeHandler sends records to several handlers.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+// Enabled returns true if any handler handles the given level
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes the record to the handlers enabled for its level
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs returns a tee of the handlers with the given attributes
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+// WithGroup returns a tee of the handlers with the given group
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
 // ToolHandler is a custom slog handler optimized for CLI tools
 type ToolHandler struct {
 	writer io.Writer
 	level  slog.Level
+	attrs  []string // preformatted key=value pairs from WithAttrs
+	group  string   // key prefix from WithGroup, e.g. "req."
 }
 
 // NewToolHandler creates a new tool-friendly handler
@@ -161,38 +544,76 @@ func (h *ToolHandler) Handle(_ context.Context, r slog.Record) error {
 	// Build the message
 	msg := prefix + r.Message
 
-	// Add attributes if any
-	if r.NumAttrs() > 0 {
-		var attrs []string
-		r.Attrs(func(a slog.Attr) bool {
-			attrs = append(attrs, fmt.Sprintf("%s=%v", a.Key, a.Value))
-			return true
-		})
-		if len(attrs) > 0 {
-			msg += " (" + strings.Join(attrs, " ") + ")"
-		}
+	// Add attributes if any, handler attributes first
+	attrs := h.attrs[:len(h.attrs):len(h.attrs)] // append must not share h.attrs
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, h.group, a)
+		return true
+	})
+	if len(attrs) > 0 {
+		msg += " (" + strings.Join(attrs, " ") + ")"
 	}
 
 	_, err := fmt.Fprintln(h.writer, msg)
 	return err
 }
 
-// WithAttrs returns a new handler with the given attributes.
-// Note: This CLI tool handler does not support persistent attributes.
-// Attributes passed here are ignored; use per-record attributes instead.
+// WithAttrs returns a new handler with the given attributes added to every
+// record, under the current group.
 func (h *ToolHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return h
+	if len(attrs) == 0 {
+		return h
+	}
+
+	clone := *h
+	clone.attrs = append([]string(nil), h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.group, a)
+	}
+	return &clone
 }
 
-// WithGroup returns a new handler with the given group name.
-// Note: This CLI tool handler does not support attribute grouping.
-// Group names are ignored in the output.
+// WithGroup returns a new handler prefixing the keys of later attributes
+// with the group name, e.g. "group.key=value".
 func (h *ToolHandler) WithGroup(name string) slog.Handler {
-	return h
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.group = h.group + name + "."
+	return &clone
+}
+
+// appendAttr appends the key=value pairs of a, with keys prefixed by group,
+// flattening group attributes.
+func appendAttr(attrs []string, group string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+
+	if a.Value.Kind() == slog.KindGroup {
+		// Attributes of groups without key are inlined
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendAttr(attrs, group, ga)
+		}
+		return attrs
+	}
+
+	if a.Key == "" {
+		return attrs
+	}
+
+	return append(attrs, fmt.Sprintf("%s%s=%v", group, a.Key, a.Value))
 }
 
-// ensureRootDir creates the root directory if it doesn't exist.
+// ensureRootDir creates the root directory if it doesn't exist, unless in
+// read-only mode.
 func (c *Config) ensureRootDir() error {
+	if c.ReadOnly {
+		return nil
+	}
 	if _, err := os.Stat(c.RootDir); os.IsNotExist(err) {
 		slog.Info("creating root directory", "path", c.RootDir)
 		if err := os.MkdirAll(c.RootDir, defaultDirPerms); err != nil {
@@ -202,8 +623,8 @@ func (c *Config) ensureRootDir() error {
 	return nil
 }
 
-// expandPath expands environment variables and ~ in paths.
-func expandPath(path string) string {
+// ExpandPath expands environment variables and ~ in paths.
+func ExpandPath(path string) string {
 	path = os.ExpandEnv(path)
 	if strings.HasPrefix(path, "~") {
 		if u, err := user.Current(); err == nil {
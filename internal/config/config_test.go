@@ -1,10 +1,16 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -79,6 +85,38 @@ func TestConfigLoad(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "flags after subcommand",
+			args: []string{"workspace", "list", "--user=testuser", "--debug"},
+			want: func(c *Config) bool {
+				return c.Debug == true && c.RootUser == "testuser"
+			},
+			wantErr: false,
+		},
+		{
+			name: "read-only flag after subcommand",
+			args: []string{"get", "--read-only", "user/repo"},
+			want: func(c *Config) bool {
+				return c.ReadOnly == true
+			},
+			wantErr: false,
+		},
+		{
+			name: "timeout flag",
+			args: []string{"get", "--timeout", "90s", "user/repo"},
+			want: func(c *Config) bool {
+				return c.Timeout == 90*time.Second && c.CloneTimeout == time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name: "flags after terminator",
+			args: []string{"run", "--", "--user", "testuser"},
+			want: func(c *Config) bool {
+				return c.RootUser == ""
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -173,26 +211,26 @@ func TestExpandPath(t *testing.T) {
 				tt.expected = strings.Replace(tt.path, "$HOME", testHome, 1)
 			}
 
-			result := expandPath(tt.path)
+			result := ExpandPath(tt.path)
 			if result != tt.expected {
-				t.Errorf("expandPath(%s) = %s, want %s", tt.path, result, tt.expected)
+				t.Errorf("ExpandPath(%s) = %s, want %s", tt.path, result, tt.expected)
 			}
 		})
 	}
 
 	// Test tilde expansion separately with real home directory
 	t.Run("tilde expansion", func(t *testing.T) {
-		result := expandPath("~/Documents")
+		result := ExpandPath("~/Documents")
 		// The function uses user.Current() which gets the actual home directory
 		// So we just verify that it starts with / and contains Documents
 		if !strings.HasPrefix(result, "/") {
-			t.Errorf("expandPath(~/Documents) should return absolute path, got %s", result)
+			t.Errorf("ExpandPath(~/Documents) should return absolute path, got %s", result)
 		}
 		if !strings.HasSuffix(result, "/Documents") {
-			t.Errorf("expandPath(~/Documents) should end with /Documents, got %s", result)
+			t.Errorf("ExpandPath(~/Documents) should end with /Documents, got %s", result)
 		}
 		if strings.Contains(result, "~") {
-			t.Errorf("expandPath(~/Documents) should not contain ~, got %s", result)
+			t.Errorf("ExpandPath(~/Documents) should not contain ~, got %s", result)
 		}
 	})
 }
@@ -230,6 +268,15 @@ func TestConfigEnsureRootDir(t *testing.T) {
 	if err != nil {
 		t.Fatalf("ensureRootDir() failed on existing directory: %v", err)
 	}
+
+	// Nothing is created in read-only mode
+	cfg = &Config{RootDir: filepath.Join(tempDir, "read-only"), ReadOnly: true}
+	if err := cfg.ensureRootDir(); err != nil {
+		t.Fatalf("ensureRootDir() failed in read-only mode: %v", err)
+	}
+	if _, err := os.Stat(cfg.RootDir); !os.IsNotExist(err) {
+		t.Fatal("ensureRootDir() created the directory in read-only mode")
+	}
 }
 
 func TestConfigWithEnvironmentVariables(t *testing.T) {
@@ -289,3 +336,607 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 		t.Errorf("Expected RootDir=%s from env var, got %s", tempDir, cfg.RootDir)
 	}
 }
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		root          string
+		user          string
+		template      string
+		issueTemplate string
+		timeout       time.Duration
+		idleAfter     time.Duration
+		attempts      int
+		api           string
+		allowHidden   string
+		color         string
+		wantErr       bool
+	}{
+		{name: "valid", root: "/home/user/code", user: "gfanton"},
+		{name: "no user", root: "/home/user/code"},
+		{name: "dotted user", root: "/home/user/code", user: "my.org_name-2"},
+		{name: "relative root", root: "code", wantErr: true},
+		{name: "user with slash", root: "/home/user/code", user: "gfanton/projects", wantErr: true},
+		{name: "user with space", root: "/home/user/code", user: "gf anton", wantErr: true},
+		{name: "user starting with dash", root: "/home/user/code", user: "-gfanton", wantErr: true},
+		{name: "branch template", root: "/home/user/code", template: "{user}/{ticket}-{slug}"},
+		{name: "branch template without ticket", root: "/home/user/code", template: "{user}/{slug}", wantErr: true},
+		{name: "issue branch template", root: "/home/user/code", issueTemplate: "{user}/{issue}-{slug}"},
+		{name: "issue branch template without issue", root: "/home/user/code", issueTemplate: "{user}/{ticket}", wantErr: true},
+		{name: "clone timeout", root: "/home/user/code", timeout: time.Minute},
+		{name: "negative clone timeout", root: "/home/user/code", timeout: -time.Minute, wantErr: true},
+		{name: "idle after", root: "/home/user/code", idleAfter: 14 * 24 * time.Hour},
+		{name: "negative idle after", root: "/home/user/code", idleAfter: -time.Hour, wantErr: true},
+		{name: "no retry", root: "/home/user/code", attempts: 1},
+		{name: "negative retry attempts", root: "/home/user/code", attempts: -1, wantErr: true},
+		{name: "gh api", root: "/home/user/code", api: "gh"},
+		{name: "unknown api", root: "/home/user/code", api: "graphql", wantErr: true},
+		{name: "allow hidden", root: "/home/user/code", allowHidden: ".dotfiles, .config"},
+		{name: "allow hidden not hidden", root: "/home/user/code", allowHidden: "dotfiles", wantErr: true},
+		{name: "allow hidden path", root: "/home/user/code", allowHidden: ".config/nvim", wantErr: true},
+		{name: "color always", root: "/home/user/code", color: "always"},
+		{name: "unknown color", root: "/home/user/code", color: "yes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{RootDir: tt.root, RootUser: tt.user, WorkspaceBranchTemplate: tt.template, WorkspaceIssueBranchTemplate: tt.issueTemplate, CloneTimeout: tt.timeout, WorkspaceIdleAfter: tt.idleAfter, RetryAttempts: tt.attempts, GitHubAPI: tt.api, WalkAllowHidden: tt.allowHidden, Color: tt.color}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigLoadRelativeRoot(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+
+	if err := cfg.Load([]string{"--root", "code"}); err == nil {
+		t.Fatal("Load() should fail with a relative root")
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "code")); !os.IsNotExist(err) {
+		t.Error("Load() should not create a relative root directory")
+	}
+}
+
+func TestConfigUnknownKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &Config{ConfigFile: filepath.Join(tempDir, ".projectrc")}
+
+	// A missing config file has no unknown keys
+	if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+		t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+	}
+
+	content := `root = "~/code"
+usr = "gfanton"
+
+[clone]
+protocol = "ssh"
+protcol = "https"
+`
+	if err := os.WriteFile(cfg.ConfigFile, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	unknown, err := cfg.UnknownKeys()
+	if err != nil {
+		t.Fatalf("UnknownKeys() failed: %v", err)
+	}
+
+	got := strings.Join(unknown, ",")
+	if got != "usr,clone.protcol" && got != "clone.protcol,usr" {
+		t.Errorf("UnknownKeys() = %v, want [usr clone.protcol]", unknown)
+	}
+}
+
+func TestConfigAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "aliases table",
+			content: "[aliases]\nk8s = \"kubernetes/kubernetes\"\nproj = \"gfanton/projects\"\n",
+			want:    map[string]string{"k8s": "kubernetes/kubernetes", "proj": "gfanton/projects"},
+		},
+		{
+			name:    "no aliases",
+			content: "rank = \"exact\"\n",
+		},
+		{
+			name:    "target without user",
+			content: "[aliases]\nk8s = \"kubernetes\"\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg, err := NewConfig()
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+			if err := os.WriteFile(cfg.ConfigFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			err = cfg.Load([]string{"--root", tempDir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.Aliases, tt.want) {
+				t.Errorf("Aliases = %v, want %v", cfg.Aliases, tt.want)
+			}
+			if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+				t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+			}
+		})
+	}
+}
+
+func TestConfigSets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:    "sets table",
+			content: "[sets]\npayments = [\"acme/api\", \"acme/worker\", \"acme/ui\"]\ndocs = [\"acme/site\"]\n",
+			want:    map[string][]string{"payments": {"acme/api", "acme/worker", "acme/ui"}, "docs": {"acme/site"}},
+		},
+		{
+			name:    "no sets",
+			content: "rank = \"exact\"\n",
+		},
+		{
+			name:    "invalid name",
+			content: "[sets]\n\"pay.ments\" = [\"acme/api\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "nested set",
+			content: "[sets]\nall = [\"@payments\"]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg, err := NewConfig()
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+			if err := os.WriteFile(cfg.ConfigFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			err = cfg.Load([]string{"--root", tempDir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.Sets, tt.want) {
+				t.Errorf("Sets = %v, want %v", cfg.Sets, tt.want)
+			}
+			if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+				t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+			}
+		})
+	}
+}
+
+func TestConfigWalkAllowHidden(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "array", content: "[walk]\nallow-hidden = [\".dotfiles\", \".config\"]\n", want: ".dotfiles,.config"},
+		{name: "comma separated", content: "[walk]\nallow-hidden = \".dotfiles,.config\"\n", want: ".dotfiles,.config"},
+		{name: "unset", content: "rank = \"exact\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg, err := NewConfig()
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+			if err := os.WriteFile(cfg.ConfigFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			if err := cfg.Load([]string{"--root", tempDir}); err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if cfg.WalkAllowHidden != tt.want {
+				t.Errorf("WalkAllowHidden = %q, want %q", cfg.WalkAllowHidden, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]Host
+		wantErr bool
+	}{
+		{
+			name:    "hosts tables",
+			content: "[hosts.\"git.corp.com\"]\nssh_user = \"git\"\ndefault_org_prefix = \"team/\"\n\n[hosts.\"gitlab.example.org\"]\nssh_user = \"gitlab\"\n",
+			want: map[string]Host{
+				"git.corp.com":       {SSHUser: "git", DefaultOrgPrefix: "team/"},
+				"gitlab.example.org": {SSHUser: "gitlab"},
+			},
+		},
+		{
+			name:    "invalid ssh user",
+			content: "[hosts.\"git.corp.com\"]\nssh_user = \"git@corp\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "nested organisation prefix",
+			content: "[hosts.\"git.corp.com\"]\ndefault_org_prefix = \"team/sub/\"\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg, err := NewConfig()
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+			if err := os.WriteFile(cfg.ConfigFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			err = cfg.Load([]string{"--root", tempDir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.Hosts, tt.want) {
+				t.Errorf("Hosts = %v, want %v", cfg.Hosts, tt.want)
+			}
+			if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+				t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+			}
+		})
+	}
+}
+
+func TestConfigTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]Template
+		wantErr bool
+	}{
+		{
+			name:    "templates tables",
+			content: "[templates.go-cli]\nsource = \"/home/user/templates/go\"\ndescription = \"Go tool\"\nvariables = [\"author\", \"license=MIT\"]\n\n[templates.rust]\nsource = \"gfanton/rust-template\"\n",
+			want: map[string]Template{
+				"go-cli": {Source: "/home/user/templates/go", Description: "Go tool", Variables: []string{"author", "license=MIT"}},
+				"rust":   {Source: "gfanton/rust-template"},
+			},
+		},
+		{
+			name:    "no source",
+			content: "[templates.go]\ndescription = \"Go tool\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid variable",
+			content: "[templates.go]\nsource = \"/tmp\"\nvariables = [\"go module\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "reserved variable",
+			content: "[templates.go]\nsource = \"/tmp\"\nvariables = [\"project\"]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			cfg, err := NewConfig()
+			if err != nil {
+				t.Fatalf("NewConfig() failed: %v", err)
+			}
+			cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+			if err := os.WriteFile(cfg.ConfigFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			err = cfg.Load([]string{"--root", tempDir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(cfg.Templates, tt.want) {
+				t.Errorf("Templates = %v, want %v", cfg.Templates, tt.want)
+			}
+			if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+				t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+			}
+		})
+	}
+}
+
+func TestConfigAddRemoveTemplate(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	cfg.ConfigFile = filepath.Join(tempDir, ".projectrc")
+	content := "root = \"~/code\"\n\n[aliases]\nk8s = \"kubernetes/kubernetes\"\n"
+	if err := os.WriteFile(cfg.ConfigFile, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	tmpl := Template{Source: "/home/user/templates/go", Description: "Go tool", Variables: []string{"author", "license=MIT"}}
+	if err := cfg.AddTemplate("go-cli", tmpl); err != nil {
+		t.Fatalf("AddTemplate() failed: %v", err)
+	}
+	if err := cfg.AddTemplate("go-cli", tmpl); err == nil {
+		t.Error("AddTemplate() of an existing template should fail")
+	}
+	if err := cfg.AddTemplate("bad name", tmpl); err == nil {
+		t.Error("AddTemplate() with an invalid name should fail")
+	}
+
+	// The template is read back from the config file
+	loaded, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	loaded.ConfigFile = cfg.ConfigFile
+	if err := loaded.Load([]string{"--root", tempDir}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Templates["go-cli"], tmpl) {
+		t.Errorf("Templates[go-cli] = %v, want %v", loaded.Templates["go-cli"], tmpl)
+	}
+
+	if err := cfg.RemoveTemplate("go-cli"); err != nil {
+		t.Fatalf("RemoveTemplate() failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.ConfigFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("config file after RemoveTemplate() = %q, want %q", data, content)
+	}
+	if err := cfg.RemoveTemplate("go-cli"); err == nil {
+		t.Error("RemoveTemplate() of a missing template should fail")
+	}
+}
+
+func TestValidKeys(t *testing.T) {
+	keys := strings.Join(ValidKeys(), ",")
+	for _, want := range []string{"root", "user", "rank", "clone.protocol"} {
+		if !strings.Contains(","+keys+",", ","+want+",") {
+			t.Errorf("ValidKeys() = %s, missing %s", keys, want)
+		}
+	}
+}
+
+func TestToolHandlerAttrs(t *testing.T) {
+	var buf strings.Builder
+	logger := slog.New(NewToolHandler(&buf, slog.LevelDebug))
+
+	logger.With("project", "gfanton/projects").
+		WithGroup("git").
+		With("branch", "main").
+		Info("status", "dirty", true, slog.Group("remote", "name", "origin"))
+
+	want := "status (project=gfanton/projects git.branch=main git.dirty=true git.remote.name=origin)\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	// Handlers derived from the same parent don't share attributes
+	buf.Reset()
+	parent := logger.With("a", 1)
+	parent.With("b", 2).Info("first")
+	parent.With("c", 3).Info("second")
+
+	want = "first (a=1 b=2)\nsecond (a=1 c=3)\n"
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestProjectsConfig(t *testing.T) {
+	cfg := &Config{
+		RootDir:                 "/src",
+		Sets:                    map[string][]string{"work": {"acme/*"}},
+		Hosts:                   map[string]Host{"git.corp.com": {SSHUser: "git", DefaultOrgPrefix: "team/"}},
+		WalkAllowHidden:         ".dotfiles",
+		Strict:                  true,
+		WorkspaceBranchTemplate: "{user}/{name}",
+	}
+
+	// The plugins share the projects config of proj, with every setting
+	got := cfg.ProjectsConfig()
+	if !reflect.DeepEqual(got.Sets, cfg.Sets) || !reflect.DeepEqual(got.AllowHidden, []string{".dotfiles"}) {
+		t.Errorf("Sets, AllowHidden = %v, %v, want %v, [.dotfiles]", got.Sets, got.AllowHidden, cfg.Sets)
+	}
+	if host := got.Hosts["git.corp.com"]; host.SSHUser != "git" || host.DefaultOrgPrefix != "team/" {
+		t.Errorf("Hosts = %v, want git.corp.com with its settings", got.Hosts)
+	}
+	if !got.Strict || !got.SkipSubmodules || got.BranchTemplate != "{user}/{name}" {
+		t.Errorf("Strict, SkipSubmodules, BranchTemplate = %v, %v, %q, want true, true, {user}/{name}", got.Strict, got.SkipSubmodules, got.BranchTemplate)
+	}
+}
+
+func TestConfigInclude(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	protocol := "ssh"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[clone]\nprotocol = %q\n\n[du]\nartifacts = \"target\"\n", protocol)
+	}))
+	defer server.Close()
+	includeTransport = server.Client().Transport
+	t.Cleanup(func() { includeTransport = http.DefaultTransport })
+
+	tempDir := t.TempDir()
+	team := "rank = \"exact\"\n\n[du]\nartifacts = \"node_modules\"\n\n[aliases]\nk8s = \"kubernetes/kubernetes\"\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "team.toml"), []byte(team), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	content := fmt.Sprintf("include = [\"team.toml\", \"%s/proj.toml\", \"missing.toml\"]\nrank = \"substring\"\n\n[aliases]\nproj = \"gfanton/projects\"\n", server.URL)
+	configFile := filepath.Join(tempDir, ".projectrc")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	load := func() *Config {
+		t.Helper()
+		cfg, err := NewConfig()
+		if err != nil {
+			t.Fatalf("NewConfig() failed: %v", err)
+		}
+		cfg.ConfigFile = configFile
+		if err := cfg.Load([]string{"--root", tempDir}); err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		return cfg
+	}
+
+	check := func(cfg *Config) {
+		t.Helper()
+		// The config file overrides includes, later includes override earlier ones
+		if cfg.Rank != "substring" || cfg.DUArtifacts != "target" || cfg.CloneProtocol != "ssh" {
+			t.Errorf("rank, du.artifacts, clone.protocol = %q, %q, %q, want substring, target, ssh", cfg.Rank, cfg.DUArtifacts, cfg.CloneProtocol)
+		}
+		want := map[string]string{"k8s": "kubernetes/kubernetes", "proj": "gfanton/projects"}
+		if !reflect.DeepEqual(cfg.Aliases, want) {
+			t.Errorf("Aliases = %v, want %v", cfg.Aliases, want)
+		}
+		if errs := cfg.IncludeErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "missing.toml") {
+			t.Errorf("IncludeErrors() = %v, want the missing.toml error", errs)
+		}
+	}
+
+	cfg := load()
+	check(cfg)
+	if unknown, err := cfg.UnknownKeys(); err != nil || len(unknown) != 0 {
+		t.Errorf("UnknownKeys() = %v, %v, want none", unknown, err)
+	}
+
+	// A stale remote include is used while refreshed in the background
+	protocol = "https"
+	cacheFiles, err := filepath.Glob(filepath.Join(os.Getenv("XDG_CACHE_HOME"), "proj", "includes", "*.toml"))
+	if err != nil || len(cacheFiles) != 1 {
+		t.Fatalf("include cache = %v, %v, want one file", cacheFiles, err)
+	}
+	stale := time.Now().Add(-2 * includeCacheTTL)
+	if err := os.Chtimes(cacheFiles[0], stale, stale); err != nil {
+		t.Fatalf("Chtimes() failed: %v", err)
+	}
+	cfg = load()
+	check(cfg)
+	cfg.WaitIncludes()
+	if cfg := load(); cfg.CloneProtocol != "https" {
+		t.Errorf("clone.protocol after refresh = %q, want https", cfg.CloneProtocol)
+	}
+
+	// The remote include is now served from the cache
+	server.Close()
+	if cfg := load(); cfg.CloneProtocol != "https" || cfg.DUArtifacts != "target" {
+		t.Errorf("clone.protocol, du.artifacts from the cache = %q, %q, want https, target", cfg.CloneProtocol, cfg.DUArtifacts)
+	}
+}
+
+func TestConfigIncludeHTTP(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// Includes can set commands, so they are never fetched in clear
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, ".projectrc")
+	if err := os.WriteFile(configFile, []byte("include = [\"http://example.com/proj.toml\"]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	cfg, err := NewConfig()
+	if err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	cfg.ConfigFile = configFile
+	if err := cfg.Load([]string{"--root", tempDir}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if errs := cfg.IncludeErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "https") {
+		t.Errorf("IncludeErrors() = %v, want the https error", errs)
+	}
+
+	// Nor through a redirect from https
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[clone]\nprotocol = \"ssh\"\n")
+	}))
+	defer plain.Close()
+	redirect := httptest.NewTLSServer(http.RedirectHandler(plain.URL+"/proj.toml", http.StatusFound))
+	defer redirect.Close()
+	includeTransport = redirect.Client().Transport
+	t.Cleanup(func() { includeTransport = http.DefaultTransport })
+
+	content := fmt.Sprintf("include = [\"%s/proj.toml\"]\n", redirect.URL)
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if cfg, err = NewConfig(); err != nil {
+		t.Fatalf("NewConfig() failed: %v", err)
+	}
+	cfg.ConfigFile = configFile
+	if err := cfg.Load([]string{"--root", tempDir}); err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if errs := cfg.IncludeErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "redirect") {
+		t.Errorf("IncludeErrors() = %v, want the redirect error", errs)
+	}
+	if cfg.CloneProtocol == "ssh" {
+		t.Error("clone.protocol set by an include redirected to http")
+	}
+}
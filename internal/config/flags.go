@@ -0,0 +1,24 @@
+package config
+
+import "github.com/peterbourgon/ff/v4"
+
+// InheritFlags makes the flags of cmd, such as the global --root and
+// --config flags, accepted after any of its subcommands, at any depth, by
+// setting each subcommand flag set parent. Subcommands without flags get a
+// flag set holding only the inherited ones.
+func InheritFlags(cmd *ff.Command) {
+	parent, ok := cmd.Flags.(*ff.FlagSet)
+	if !ok {
+		return
+	}
+
+	for _, sub := range cmd.Subcommands {
+		switch fs := sub.Flags.(type) {
+		case nil:
+			sub.Flags = ff.NewFlagSet(sub.Name).SetParent(parent)
+		case *ff.FlagSet:
+			fs.SetParent(parent)
+		}
+		InheritFlags(sub)
+	}
+}
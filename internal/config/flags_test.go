@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/peterbourgon/ff/v4"
+)
+
+func TestInheritFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"root", []string{"--root", "/a", "workspace", "list"}, "/a"},
+		{"subcommand", []string{"workspace", "--root", "/b", "list"}, "/b"},
+		{"nested subcommand", []string{"workspace", "list", "--verbose", "--root=/c"}, "/c"},
+		{"subcommand without flags", []string{"version", "--root", "/d"}, "/d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var root string
+			var verbose bool
+
+			rootFlags := ff.NewFlagSet("proj")
+			rootFlags.StringVar(&root, 0, "root", "", "root directory")
+
+			listFlags := ff.NewFlagSet("list")
+			listFlags.BoolVar(&verbose, 'v', "verbose", "verbose output")
+
+			noop := func(context.Context, []string) error { return nil }
+			cmd := &ff.Command{
+				Name:  "proj",
+				Flags: rootFlags,
+				Subcommands: []*ff.Command{
+					{
+						Name:  "workspace",
+						Flags: ff.NewFlagSet("workspace"),
+						Subcommands: []*ff.Command{
+							{Name: "list", Flags: listFlags, Exec: noop},
+						},
+					},
+					{Name: "version", Exec: noop},
+				},
+			}
+			InheritFlags(cmd)
+
+			if err := cmd.ParseAndRun(context.Background(), tt.args); err != nil {
+				t.Fatalf("ParseAndRun(%v) failed: %v", tt.args, err)
+			}
+			if root != tt.want {
+				t.Errorf("ParseAndRun(%v) root = %q, want %q", tt.args, root, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/peterbourgon/ff/v4/fftoml"
+)
+
+const (
+	// includeKey is the config file key listing the files it includes.
+	includeKey = "include"
+
+	// includeCacheTTL is how long a remote include is used from the cache
+	// before being fetched again.
+	includeCacheTTL = time.Hour
+	// includeTimeout bounds the request fetching a remote include.
+	includeTimeout = 5 * time.Second
+)
+
+// configValue is a key of a config file and its value.
+type configValue struct {
+	name  string
+	value string
+}
+
+// listKeys are the comma separated keys, which can also be set with TOML
+// arrays, e.g. allow-hidden = [".dotfiles", ".config"].
+var listKeys = map[string]bool{
+	"walk.allow-hidden": true,
+}
+
+// joinLists joins the values of the list keys set several times, once per
+// element of a TOML array, into a comma separated value.
+func joinLists(values []configValue) []configValue {
+	var joined []configValue
+	index := make(map[string]int)
+	for _, v := range values {
+		if i, ok := index[v.name]; ok && listKeys[v.name] {
+			joined[i].value += "," + v.value
+			continue
+		}
+		index[v.name] = len(joined)
+		joined = append(joined, v)
+	}
+	return joined
+}
+
+// parseConfigFile parses the TOML config file like fftoml.Parse, collecting
+// the keys of the [aliases] table into Aliases, the ones of the [sets] table
+// into Sets, the ones of the [hosts."<host>"] tables into Hosts and the ones
+// of the [templates.<name>] tables into Templates since they have no flags.
+//
+// The files listed by its include key, local paths (relative to the config
+// file) or https URLs, are parsed first: the values of the config file
+// override theirs, and the values of an include override those of the
+// previous ones. Includes failing to load are skipped, recorded in
+// IncludeErrors.
+func (c *Config) parseConfigFile(r io.Reader, set func(name, value string) error) error {
+	var includes []string
+	var local []configValue
+	err := fftoml.Parse(r, func(name, value string) error {
+		if name == includeKey {
+			includes = append(includes, value)
+		} else {
+			local = append(local, configValue{name, value})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var sources [][]configValue
+	for _, include := range includes {
+		values, err := c.parseInclude(include)
+		if err != nil {
+			c.includeErrors = append(c.includeErrors, fmt.Errorf("include %s: %w", include, err))
+			continue
+		}
+		sources = append(sources, values)
+	}
+	sources = append(sources, local)
+
+	for i, values := range sources {
+		for _, v := range joinLists(values) {
+			if overridden(v.name, sources[i+1:]) {
+				continue
+			}
+			if err := c.setConfigValue(v.name, v.value, set); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// overridden reports whether name is set by one of sources.
+func overridden(name string, sources [][]configValue) bool {
+	for _, values := range sources {
+		for _, v := range values {
+			if v.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setConfigValue sets the config file key name to value, through set for
+// options, into Aliases for the keys of the [aliases] table, into Sets for
+// the ones of the [sets] table, into Hosts for the ones of the
+// [hosts."<host>"] tables and into Templates for the ones of the
+// [templates.<name>] tables.
+func (c *Config) setConfigValue(name, value string, set func(name, value string) error) error {
+	if alias, ok := strings.CutPrefix(name, aliasesTable+"."); ok {
+		if c.Aliases == nil {
+			c.Aliases = make(map[string]string)
+		}
+		c.Aliases[alias] = value
+		return nil
+	}
+	if setName, ok := strings.CutPrefix(name, setsTable+"."); ok {
+		if c.Sets == nil {
+			c.Sets = make(map[string][]string)
+		}
+		c.Sets[setName] = append(c.Sets[setName], value)
+		return nil
+	}
+	if name, setting, ok := hostKey(name); ok {
+		if c.Hosts == nil {
+			c.Hosts = make(map[string]Host)
+		}
+		host := c.Hosts[name]
+		switch setting {
+		case "ssh_user":
+			host.SSHUser = value
+		case "default_org_prefix":
+			host.DefaultOrgPrefix = value
+		}
+		c.Hosts[name] = host
+		return nil
+	}
+	if name, setting, ok := templateKey(name); ok {
+		c.setTemplateValue(name, setting, value)
+		return nil
+	}
+	return set(name, value)
+}
+
+// IncludeErrors returns the errors of the includes of the config file that
+// were skipped while loading it.
+func (c *Config) IncludeErrors() []error {
+	return c.includeErrors
+}
+
+// parseInclude reads and parses an included config file. Included files
+// can't include other files.
+func (c *Config) parseInclude(include string) ([]configValue, error) {
+	data, err := c.readInclude(include)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []configValue
+	err = fftoml.Parse(bytes.NewReader(data), func(name, value string) error {
+		if name == includeKey {
+			return errors.New("included files can't include other files")
+		}
+		values = append(values, configValue{name, value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// readInclude returns the content of an include: a remote https URL, or a
+// local path relative to the config file. Plain http URLs are refused since
+// includes can set commands, such as hooks.
+func (c *Config) readInclude(include string) ([]byte, error) {
+	if strings.HasPrefix(include, "http://") {
+		return nil, errors.New("remote includes must use https")
+	}
+	if strings.HasPrefix(include, "https://") {
+		return c.fetchInclude(include)
+	}
+
+	path := ExpandPath(include)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(ExpandPath(c.ConfigFile)), path)
+	}
+	return os.ReadFile(path)
+}
+
+// fetchInclude returns the content of a remote include, from the cache when
+// cached. A copy cached more than includeCacheTTL ago is still used, while
+// it is refreshed in the background, so that loading the config never waits
+// on the network but for the first fetch.
+func (c *Config) fetchInclude(rawURL string) ([]byte, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	cachePath := filepath.Join(cacheDir, "proj", "includes", hex.EncodeToString(sum[:8])+".toml")
+
+	if info, err := os.Stat(cachePath); err == nil {
+		cached, err := os.ReadFile(cachePath)
+		if err != nil {
+			return nil, err
+		}
+		if time.Since(info.ModTime()) >= includeCacheTTL {
+			c.includeRefreshes.Add(1)
+			go func() {
+				defer c.includeRefreshes.Done()
+				// A failed refresh keeps the stale copy, refreshed again
+				// on the next load
+				_, _ = cacheInclude(rawURL, cachePath)
+			}()
+		}
+		return cached, nil
+	}
+
+	return cacheInclude(rawURL, cachePath)
+}
+
+// WaitIncludes waits for the background refreshes of the stale remote
+// includes of the config file, each bounded by includeTimeout.
+func (c *Config) WaitIncludes() {
+	c.includeRefreshes.Wait()
+}
+
+// includeCacheMu serializes the writes of the include cache.
+var includeCacheMu sync.Mutex
+
+// cacheInclude fetches the remote include rawURL into cachePath and returns
+// its content.
+func cacheInclude(rawURL, cachePath string) ([]byte, error) {
+	data, err := fetchURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the content before caching it
+	if err := fftoml.Parse(bytes.NewReader(data), func(string, string) error { return nil }); err != nil {
+		return nil, err
+	}
+
+	includeCacheMu.Lock()
+	defer includeCacheMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(cachePath), defaultDirPerms); err != nil {
+		return nil, fmt.Errorf("failed to create include cache directory: %w", err)
+	}
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to cache include: %w", err)
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		return nil, fmt.Errorf("failed to cache include: %w", err)
+	}
+	return data, nil
+}
+
+// includeTransport is the transport of the requests fetching remote
+// includes.
+var includeTransport = http.DefaultTransport
+
+// checkIncludeRedirect refuses the redirects of remote includes to anything
+// but https, which would defeat readInclude refusing http.
+func checkIncludeRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf("refusing redirect to %s: remote includes must use https", req.URL.Redacted())
+	}
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	return nil
+}
+
+// fetchURL returns the body of a GET request to rawURL.
+func fetchURL(rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), includeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Transport: includeTransport, CheckRedirect: checkIncludeRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"github.com/gfanton/projects"
+)
+
+// ProjectsConfig returns the configuration of the projects services, shared
+// by proj and the plugins.
+func (c *Config) ProjectsConfig() *projects.Config {
+	return &projects.Config{
+		ConfigFile: c.ConfigFile,
+		Debug:      c.Debug,
+		RootDir:    c.RootDir,
+		RootUser:   c.RootUser,
+		Aliases:    c.Aliases,
+		Sets:       c.Sets,
+		Hosts:      projectsHosts(c.Hosts),
+
+		SkipSubmodules:      !c.WorkspaceSubmodules,
+		BranchTemplate:      c.WorkspaceBranchTemplate,
+		TicketURL:           c.WorkspaceTicketURL,
+		IssueBranchTemplate: c.WorkspaceIssueBranchTemplate,
+		IdleAfter:           c.WorkspaceIdleAfter,
+		ReadOnly:            c.ReadOnly,
+		Strict:              c.Strict,
+		AllowHidden:         c.AllowHidden(),
+		NetworkTimeout:      c.NetworkTimeout,
+		RetryAttempts:       c.RetryAttempts,
+		RetryBackoff:        c.RetryBackoff,
+	}
+}
+
+// projectsHosts returns the Git server settings of the projects services.
+func projectsHosts(hosts map[string]Host) map[string]projects.Host {
+	if len(hosts) == 0 {
+		return nil
+	}
+	result := make(map[string]projects.Host, len(hosts))
+	for name, host := range hosts {
+		result[name] = projects.Host{SSHUser: host.SSHUser, DefaultOrgPrefix: host.DefaultOrgPrefix}
+	}
+	return result
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templatesTable is the config file table of the project templates.
+const templatesTable = "templates"
+
+// Template is a scaffold template of 'proj new --template', from a
+// [templates.<name>] table of the config file.
+type Template struct {
+	// Source is the directory or the Git repository (URL or GitHub
+	// "user/repo") the files of new projects are copied from.
+	Source string
+	// Description is shown by 'proj template list'.
+	Description string
+	// Variables lists the variables replaced in the template files, as
+	// "name" for the required ones or "name=default".
+	Variables []string
+}
+
+// TemplateVariable is a variable of a Template.
+type TemplateVariable struct {
+	Name     string
+	Default  string
+	Required bool // no default, the value must be given
+}
+
+var (
+	// templateNamePattern matches valid template names.
+	templateNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	// variableNamePattern matches valid template variable names.
+	variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ParseTemplateVariable parses a variable of a Template, "name" or
+// "name=default".
+func ParseTemplateVariable(spec string) (TemplateVariable, error) {
+	name, def, hasDefault := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !variableNamePattern.MatchString(name) {
+		return TemplateVariable{}, fmt.Errorf("invalid variable '%s': expected name or name=default, the name made of letters, digits and '_'", spec)
+	}
+	return TemplateVariable{Name: name, Default: def, Required: !hasDefault}, nil
+}
+
+// ParsedVariables returns the parsed variables of t. The project, org and
+// module variables are reserved for the name, organisation and Go module
+// path of the new project.
+func (t Template) ParsedVariables() ([]TemplateVariable, error) {
+	vars := make([]TemplateVariable, 0, len(t.Variables))
+	seen := make(map[string]bool)
+	for _, spec := range t.Variables {
+		v, err := ParseTemplateVariable(spec)
+		if err != nil {
+			return nil, err
+		}
+		if v.Name == "project" || v.Name == "org" || v.Name == "module" {
+			return nil, fmt.Errorf("variable '%s' is reserved", v.Name)
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate variable '%s'", v.Name)
+		}
+		seen[v.Name] = true
+		vars = append(vars, v)
+	}
+	return vars, nil
+}
+
+// ValidateTemplate checks the name and the settings of a template.
+func ValidateTemplate(name string, t Template) error {
+	if !templateNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid template '%s': must start with a letter or digit and contain only letters, digits, '_' and '-'", name)
+	}
+	if strings.TrimSpace(t.Source) == "" {
+		return fmt.Errorf("invalid template %s: no source", name)
+	}
+	if _, err := t.ParsedVariables(); err != nil {
+		return fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return nil
+}
+
+// templateKey splits the config file key name of a [templates.<name>]
+// setting into the template and the setting.
+func templateKey(name string) (tmpl, setting string, ok bool) {
+	rest, ok := strings.CutPrefix(name, templatesTable+".")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i < 0 {
+		return "", "", false
+	}
+	tmpl, setting = strings.Trim(rest[:i], `"`), rest[i+1:]
+	switch setting {
+	case "source", "description", "variables":
+		return tmpl, setting, true
+	}
+	return "", "", false
+}
+
+// setTemplateValue sets the setting of the template name to value, with ~
+// and environment variables of the source expanded. Each element of the
+// variables array is set in turn.
+func (c *Config) setTemplateValue(name, setting, value string) {
+	if c.Templates == nil {
+		c.Templates = make(map[string]Template)
+	}
+	t := c.Templates[name]
+	switch setting {
+	case "source":
+		t.Source = ExpandPath(value)
+	case "description":
+		t.Description = value
+	case "variables":
+		t.Variables = append(t.Variables, value)
+	}
+	c.Templates[name] = t
+}
+
+// AddTemplate appends the [templates.<name>] table of t to the config file
+// and to Templates. It fails if the template already exists.
+func (c *Config) AddTemplate(name string, t Template) error {
+	if err := ValidateTemplate(name, t); err != nil {
+		return err
+	}
+	if _, ok := c.Templates[name]; ok {
+		return fmt.Errorf("template %s already exists", name)
+	}
+
+	data, err := os.ReadFile(c.ConfigFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 {
+		if !strings.HasSuffix(string(data), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "[%s.%s]\n", templatesTable, name)
+	fmt.Fprintf(&b, "source = %s\n", strconv.Quote(t.Source))
+	if t.Description != "" {
+		fmt.Fprintf(&b, "description = %s\n", strconv.Quote(t.Description))
+	}
+	if len(t.Variables) > 0 {
+		quoted := make([]string, len(t.Variables))
+		for i, v := range t.Variables {
+			quoted[i] = strconv.Quote(v)
+		}
+		fmt.Fprintf(&b, "variables = [%s]\n", strings.Join(quoted, ", "))
+	}
+
+	if err := os.WriteFile(c.ConfigFile, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	if c.Templates == nil {
+		c.Templates = make(map[string]Template)
+	}
+	c.Templates[name] = t
+	return nil
+}
+
+// RemoveTemplate removes the [templates.<name>] table from the config file
+// and from Templates. Templates of included files can't be removed.
+func (c *Config) RemoveTemplate(name string) error {
+	data, err := os.ReadFile(c.ConfigFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	headers := []string{
+		fmt.Sprintf("[%s.%s]", templatesTable, name),
+		fmt.Sprintf("[%s.%q]", templatesTable, name),
+	}
+
+	var kept []string
+	found, inTable := false, false
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inTable = trimmed == headers[0] || trimmed == headers[1]
+			if inTable {
+				found = true
+				// Drop the blank line separating the table from the previous one
+				if n := len(kept); n > 0 && strings.TrimSpace(kept[n-1]) == "" {
+					kept = kept[:n-1]
+				}
+			}
+		}
+		if !inTable {
+			kept = append(kept, line)
+		}
+	}
+
+	if !found {
+		if _, ok := c.Templates[name]; ok {
+			return fmt.Errorf("template %s is not defined in %s, but in one of its includes", name, c.ConfigFile)
+		}
+		return fmt.Errorf("no template %s", name)
+	}
+
+	if err := os.WriteFile(c.ConfigFile, []byte(strings.Join(kept, "")), 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	delete(c.Templates, name)
+	return nil
+}
@@ -1,14 +1,21 @@
 package git
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/gfanton/projects/internal/project"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
 )
 
 const defaultDirPerms = 0755
@@ -16,6 +23,15 @@ const defaultDirPerms = 0755
 // Client provides Git operations.
 type Client struct {
 	logger *slog.Logger
+
+	// CloneTimeout bounds each clone, and NetworkTimeout the other network
+	// operations such as LFS pulls. Zero means no timeout.
+	CloneTimeout   time.Duration
+	NetworkTimeout time.Duration
+
+	// Retry retries the network operations failing with transient errors,
+	// within their timeout.
+	Retry RetryPolicy
 }
 
 // NewClient creates a new Git client.
@@ -31,29 +47,106 @@ type CloneOptions struct {
 	Destination string
 	UseSSH      bool
 	Token       string
+	// Bare clones a bare repository into the project.BareDir of
+	// Destination, referenced by a .git file, without checkout.
+	Bare bool
+	// Reference is a local repository of the same project, or of a fork,
+	// whose objects are borrowed through Git alternates instead of fetched
+	// (git clone --reference-if-able). The clone depends on it afterwards.
+	Reference string
+	// Progress receives the progress of the clone, os.Stdout when nil.
+	Progress io.Writer
+}
+
+// progress returns the writer of the clone progress.
+func (opts CloneOptions) progress() io.Writer {
+	if opts.Progress == nil {
+		return os.Stdout
+	}
+	return opts.Progress
 }
 
-// Clone clones a repository to the specified destination.
-func (c *Client) Clone(ctx context.Context, opts CloneOptions) error {
+// Clone clones a repository to the specified destination, within
+// CloneTimeout, retrying on transient errors. A destination created by a
+// failed clone is removed.
+func (c *Client) Clone(ctx context.Context, opts CloneOptions) (err error) {
 	c.logger.Debug("cloning repository",
 		"url", opts.URL,
 		"destination", opts.Destination,
 		"use_ssh", opts.UseSSH,
+		"bare", opts.Bare,
+		"reference", opts.Reference,
 	)
 
-	// Ensure destination directory exists
+	ctx, cancel := withTimeout(ctx, c.CloneTimeout)
+	defer cancel()
+
+	// Ensure destination directory exists, removing it again when the
+	// clone fails so that it can be retried
+	_, statErr := os.Stat(opts.Destination)
+	created := os.IsNotExist(statErr)
+	if created {
+		defer func() {
+			if err != nil {
+				os.RemoveAll(opts.Destination)
+			}
+		}()
+	}
 	if err := os.MkdirAll(opts.Destination, defaultDirPerms); err != nil {
 		return fmt.Errorf("create destination directory: %w", err)
 	}
 
+	dir := opts.Destination
+	if opts.Bare {
+		dir = filepath.Join(opts.Destination, project.BareDir)
+	}
+
+	err = c.Retry.Do(ctx, c.logger.Warn, func() error {
+		err := c.clone(ctx, dir, opts)
+		if err != nil && created {
+			// Start the next attempt from an empty destination
+			os.RemoveAll(dir)
+			os.MkdirAll(opts.Destination, defaultDirPerms)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clone repository: %w", ContextError(ctx, err))
+	}
+
+	if opts.Bare {
+		// The .git file lets git and go-git open the bare repository from
+		// the project directory
+		gitFile := filepath.Join(opts.Destination, ".git")
+		if err := os.WriteFile(gitFile, []byte("gitdir: ./"+project.BareDir+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", gitFile, err)
+		}
+	}
+
+	c.logger.Info("repository cloned successfully",
+		"url", opts.URL,
+		"destination", opts.Destination,
+	)
+
+	return nil
+}
+
+// clone clones opts.URL into dir, with go-git or, to borrow the objects of
+// opts.Reference, with the git command.
+func (c *Client) clone(ctx context.Context, dir string, opts CloneOptions) error {
+	if opts.Reference != "" {
+		// go-git doesn't support alternates, clone with git itself
+		return c.cloneWithReference(ctx, dir, opts)
+	}
+
 	cloneOpts := &git.CloneOptions{
 		URL:      opts.URL,
-		Progress: os.Stdout,
+		Progress: opts.progress(),
 	}
 
 	// Set up authentication if needed
 	if opts.UseSSH {
-		auth, err := ssh.NewSSHAgentAuth("git")
+		auth, err := sshAuth()
 		if err != nil {
 			return fmt.Errorf("failed to create SSH auth: %w", err)
 		}
@@ -65,15 +158,62 @@ func (c *Client) Clone(ctx context.Context, opts CloneOptions) error {
 		}
 	}
 
-	_, err := git.PlainCloneContext(ctx, opts.Destination, false, cloneOpts)
-	if err != nil {
-		return fmt.Errorf("failed to clone repository: %w", err)
+	_, err := git.PlainCloneContext(ctx, dir, opts.Bare, cloneOpts)
+	return err
+}
+
+// cloneWithReference clones opts.URL into dir with the git command,
+// borrowing the objects of opts.Reference. The token is passed as an HTTP
+// header through the environment, keeping it out of the process arguments.
+func (c *Client) cloneWithReference(ctx context.Context, dir string, opts CloneOptions) error {
+	args := []string{"clone", "--reference-if-able", opts.Reference}
+	if opts.Bare {
+		args = append(args, "--bare")
 	}
+	args = append(args, "--", opts.URL, dir)
 
-	c.logger.Info("repository cloned successfully",
-		"url", opts.URL,
-		"destination", opts.Destination,
-	)
+	// Keep stderr to tell transient failures apart
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = opts.progress()
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	cmd.Env = os.Environ()
+	if !opts.UseSSH && opts.Token != "" {
+		cmd.Env = append(cmd.Env, tokenEnv(opts.Token)...)
+	}
 
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
 	return nil
 }
+
+// withTimeout returns ctx bounded by timeout, or only cancelable when
+// timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// ContextError returns the error of ctx when it is done, so that a git
+// command killed on timeout or cancellation reports why instead of "signal:
+// killed", or else err.
+func ContextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// tokenEnv returns the environment variables configuring git to send token
+// as HTTP basic authentication, like the go-git clones.
+func tokenEnv(token string) []string {
+	credentials := base64.StdEncoding.EncodeToString([]byte("git:" + token))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + credentials,
+	}
+}
@@ -1,9 +1,14 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -19,6 +24,40 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestCloneTimeout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+	for _, args := range [][]string{{"init", "-q", "-b", "main", source}, {"-C", source, "commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	client := NewClient(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	client.CloneTimeout = time.Nanosecond
+
+	for _, reference := range []string{"", source} {
+		destination := filepath.Join(dir, "clone")
+		err := client.Clone(context.Background(), CloneOptions{URL: source, Destination: destination, Reference: reference})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Clone(reference=%q) error = %v, want context.DeadlineExceeded", reference, err)
+		}
+		if _, err := os.Stat(destination); !os.IsNotExist(err) {
+			t.Errorf("Clone(reference=%q) should remove the destination it created, stat error = %v", reference, err)
+		}
+	}
+
+	client.CloneTimeout = time.Minute
+	if err := client.Clone(context.Background(), CloneOptions{URL: source, Destination: filepath.Join(dir, "clone")}); err != nil {
+		t.Errorf("Clone() failed: %v", err)
+	}
+}
+
 // func TestCloneOptions(t *testing.T) {
 // 	tests := []struct {
 // 		name        string
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrLFSUnavailable is returned when the git-lfs extension is not installed.
+var ErrLFSUnavailable = errors.New("git-lfs is not installed")
+
+// LFSStatus is the state of the Git LFS objects of a repository.
+type LFSStatus struct {
+	Fetched int // objects whose content is checked out
+	Missing int // objects checked out as pointer files
+}
+
+// UsesLFS reports whether the repository at dir tracks files with Git LFS,
+// according to its root .gitattributes.
+func UsesLFS(dir string) bool {
+	f, err := os.Open(filepath.Join(dir, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") && strings.Contains(line, "filter=lfs") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupLFS configures Git LFS in the repository at dir. With fetch, the LFS
+// objects are downloaded and checked out; otherwise smudging is disabled
+// so later checkouts keep pointer files instead of downloading objects.
+func (c *Client) SetupLFS(ctx context.Context, dir string, fetch bool) error {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return ErrLFSUnavailable
+	}
+
+	install := []string{"lfs", "install", "--local"}
+	if !fetch {
+		install = append(install, "--skip-smudge")
+	}
+	if err := c.runGit(ctx, dir, install...); err != nil {
+		return err
+	}
+
+	if fetch {
+		ctx, cancel := withTimeout(ctx, c.NetworkTimeout)
+		defer cancel()
+		return c.Retry.Do(ctx, c.logger.Warn, func() error {
+			return c.runGit(ctx, dir, "lfs", "pull")
+		})
+	}
+	return nil
+}
+
+// LFSStatus returns the state of the LFS objects of the repository at dir.
+func (c *Client) LFSStatus(ctx context.Context, dir string) (LFSStatus, error) {
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return LFSStatus{}, ErrLFSUnavailable
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "lfs", "ls-files")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return LFSStatus{}, fmt.Errorf("failed to list LFS files: %w", err)
+	}
+
+	return parseLFSFiles(string(output)), nil
+}
+
+// parseLFSFiles counts the fetched and missing objects of 'git lfs ls-files'
+// output, made of "<oid> <*|-> <path>" lines where '-' marks pointer files.
+func parseLFSFiles(output string) LFSStatus {
+	var status LFSStatus
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[1] {
+		case "*":
+			status.Fetched++
+		case "-":
+			status.Missing++
+		}
+	}
+	return status
+}
+
+// runGit runs git with args in dir.
+func (c *Client) runGit(ctx context.Context, dir string, args ...string) error {
+	c.logger.Debug("running git", "dir", dir, "args", args)
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %s: %w\nOutput: %s", strings.Join(args, " "), ContextError(ctx, err), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
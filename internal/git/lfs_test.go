@@ -0,0 +1,44 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsesLFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       bool
+	}{
+		{"lfs", "*.psd filter=lfs diff=lfs merge=lfs -text\n", true},
+		{"commented", "# *.psd filter=lfs diff=lfs merge=lfs -text\n", false},
+		{"no lfs", "*.go text eol=lf\n", false},
+		{"no attributes", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.attributes != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(tt.attributes), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := UsesLFS(dir); got != tt.want {
+				t.Errorf("UsesLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLFSFiles(t *testing.T) {
+	output := "4d7a214614 * assets/logo.psd\n9f86d08188 - assets/video.mp4\ne3b0c44298 - data/model.bin\n"
+
+	got := parseLFSFiles(output)
+	if got.Fetched != 1 || got.Missing != 2 {
+		t.Errorf("parseLFSFiles() = %+v, want 1 fetched and 2 missing", got)
+	}
+}
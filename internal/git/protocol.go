@@ -0,0 +1,110 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// Clone protocols.
+const (
+	ProtocolAuto  = "auto"  // SSH when an SSH identity is available, HTTPS otherwise
+	ProtocolSSH   = "ssh"   // always SSH
+	ProtocolHTTPS = "https" // always HTTPS, authenticated with a token if any
+)
+
+// sshUser is the user of git SSH URLs.
+const sshUser = "git"
+
+// defaultIdentities are the identity files tried, in order, without SSH agent.
+var defaultIdentities = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// ParseHostProtocols parses comma-separated "host=protocol" overrides, e.g.
+// "github.com=ssh, git.example.com=https".
+func ParseHostProtocols(s string) (map[string]string, error) {
+	hosts := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		host, protocol, ok := strings.Cut(pair, "=")
+		host, protocol = strings.TrimSpace(host), strings.TrimSpace(protocol)
+		if !ok || host == "" {
+			return nil, fmt.Errorf("invalid clone host override '%s' (expected host=protocol)", pair)
+		}
+		if err := ValidateProtocol(protocol); err != nil {
+			return nil, fmt.Errorf("invalid clone host override '%s': %w", pair, err)
+		}
+
+		hosts[host] = protocol
+	}
+	return hosts, nil
+}
+
+// ValidateProtocol returns an error if protocol is not a clone protocol.
+func ValidateProtocol(protocol string) error {
+	switch protocol {
+	case ProtocolAuto, ProtocolSSH, ProtocolHTTPS:
+		return nil
+	default:
+		return fmt.Errorf("unknown clone protocol '%s' (expected %s, %s or %s)", protocol, ProtocolAuto, ProtocolSSH, ProtocolHTTPS)
+	}
+}
+
+// UseSSH reports whether to clone from host over SSH. The host override
+// wins over protocol; with ProtocolAuto, SSH is used when an SSH agent or a
+// passphrase-less default identity is available.
+func UseSSH(host, protocol string, hosts map[string]string) bool {
+	if override, ok := hosts[host]; ok {
+		protocol = override
+	}
+
+	switch protocol {
+	case ProtocolSSH:
+		return true
+	case ProtocolHTTPS:
+		return false
+	default:
+		_, err := sshAuth()
+		return err == nil
+	}
+}
+
+// sshAuth returns the SSH authentication to clone with: the SSH agent when
+// reachable, otherwise the first default identity file usable without
+// passphrase.
+func sshAuth() (transport.AuthMethod, error) {
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		conn, err := net.DialTimeout("unix", socket, time.Second)
+		if err == nil {
+			conn.Close()
+			return ssh.NewSSHAgentAuth(sshUser)
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	for _, name := range defaultIdentities {
+		path := filepath.Join(home, ".ssh", name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if auth, err := ssh.NewPublicKeysFromFile(sshUser, path, ""); err == nil {
+			return auth, nil
+		}
+	}
+
+	return nil, errors.New("no SSH agent or passphrase-less identity available")
+}
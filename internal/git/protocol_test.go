@@ -0,0 +1,43 @@
+package git
+
+import "testing"
+
+func TestParseHostProtocols(t *testing.T) {
+	hosts, err := ParseHostProtocols("github.com=ssh, git.example.com = https,")
+	if err != nil {
+		t.Fatalf("ParseHostProtocols() failed: %v", err)
+	}
+	if len(hosts) != 2 || hosts["github.com"] != ProtocolSSH || hosts["git.example.com"] != ProtocolHTTPS {
+		t.Errorf("ParseHostProtocols() = %v", hosts)
+	}
+
+	for _, s := range []string{"github.com", "=ssh", "github.com=ftp"} {
+		if _, err := ParseHostProtocols(s); err == nil {
+			t.Errorf("ParseHostProtocols(%q) should fail", s)
+		}
+	}
+}
+
+func TestUseSSH(t *testing.T) {
+	hosts := map[string]string{"github.com": ProtocolHTTPS}
+
+	if UseSSH("gitlab.com", ProtocolHTTPS, hosts) {
+		t.Error("UseSSH() should follow the https protocol")
+	}
+	if !UseSSH("gitlab.com", ProtocolSSH, hosts) {
+		t.Error("UseSSH() should follow the ssh protocol")
+	}
+	if UseSSH("github.com", ProtocolSSH, hosts) {
+		t.Error("UseSSH() should prefer the host override")
+	}
+}
+
+func TestUseSSHAuto(t *testing.T) {
+	// No agent and no identity: fall back to HTTPS
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", t.TempDir())
+
+	if UseSSH("github.com", ProtocolAuto, nil) {
+		t.Error("UseSSH() should fall back to https without SSH identity")
+	}
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxRetryBackoff caps the delay between two attempts.
+const maxRetryBackoff = time.Minute
+
+// transientErrors are the lowercase messages of git and go-git errors that
+// flaky networks and proxies cause, worth retrying.
+var transientErrors = []string{
+	"could not resolve host",
+	"could not resolve proxy",
+	"connection timed out",
+	"operation timed out",
+	"i/o timeout",
+	"tls handshake timeout",
+	"connection reset",
+	"connection refused",
+	"failed to connect",
+	"early eof",
+	"unexpected disconnect",
+	"the remote end hung up unexpectedly",
+	"rpc failed",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway",
+	"returned error: 502",
+	"returned error: 503",
+	"returned error: 504",
+}
+
+// RetryPolicy retries the network operations failing with transient errors,
+// such as the ones of flaky corporate proxies.
+type RetryPolicy struct {
+	Attempts int           // attempts in total, 1 or less to never retry
+	Backoff  time.Duration // delay before the first retry, doubled before each next one
+}
+
+// Do runs fn until it succeeds, fails with an error that is not Retryable,
+// or the attempts are exhausted, waiting between attempts. Retries are
+// reported with warn, and waiting stops when ctx is done.
+func (p RetryPolicy) Do(ctx context.Context, warn func(msg string, args ...any), fn func() error) error {
+	delay := p.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.Attempts || !Retryable(err) {
+			return err
+		}
+
+		warn("transient network failure, retrying", "attempt", attempt, "of", p.Attempts, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (after %d attempts, last error: %v)", ctx.Err(), attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRetryBackoff)
+	}
+}
+
+// Retryable reports whether err is a transient network failure, as opposed
+// to authentication failures, missing repositories or the cancellation of
+// the operation.
+func Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientErrors {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
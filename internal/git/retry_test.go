@@ -0,0 +1,97 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "dns", err: errors.New("fatal: unable to access 'https://github.com/a/b/': Could not resolve host: github.com"), want: true},
+		{name: "bad gateway", err: errors.New("error: RPC failed; HTTP 502 curl 22 The requested URL returned error: 502"), want: true},
+		{name: "early eof", err: errors.New("fatal: early EOF"), want: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "unexpected eof", err: fmt.Errorf("fetch: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "authentication", err: errors.New("fatal: Authentication failed for 'https://github.com/a/b/'"), want: false},
+		{name: "not found", err: errors.New("repository not found"), want: false},
+		{name: "canceled", err: fmt.Errorf("connection reset: %w", context.Canceled), want: false},
+		{name: "deadline", err: context.DeadlineExceeded, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	transient := errors.New("fatal: the remote end hung up unexpectedly")
+	permanent := errors.New("repository not found")
+
+	tests := []struct {
+		name      string
+		attempts  int
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", attempts: 3, errs: []error{nil}, wantCalls: 1},
+		{name: "transient then success", attempts: 3, errs: []error{transient, transient, nil}, wantCalls: 3},
+		{name: "exhausted", attempts: 3, errs: []error{transient, transient, transient}, wantCalls: 3, wantErr: transient},
+		{name: "permanent", attempts: 3, errs: []error{permanent}, wantCalls: 1, wantErr: permanent},
+		{name: "no retry", attempts: 0, errs: []error{transient}, wantCalls: 1, wantErr: transient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{Attempts: tt.attempts, Backoff: time.Millisecond}
+			calls, warnings := 0, 0
+			warn := func(string, ...any) { warnings++ }
+
+			err := policy.Do(context.Background(), warn, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+			if warnings != calls-1 {
+				t.Errorf("Do() warned %d times, want %d", warnings, calls-1)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{Attempts: 5, Backoff: time.Hour}
+
+	calls := 0
+	err := policy.Do(ctx, func(string, ...any) { cancel() }, func() error {
+		calls++
+		return errors.New("fatal: early EOF")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Do() error = %v, want context.Canceled", err)
+	}
+	if calls != 1 {
+		t.Errorf("Do() called fn %d times, want 1", calls)
+	}
+}
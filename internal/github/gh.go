@@ -0,0 +1,103 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ghRunner runs 'gh api' with args and returns its standard output.
+type ghRunner func(ctx context.Context, args ...string) ([]byte, error)
+
+// WithGHCLI returns a copy of the client making its API requests with
+// 'gh api', which authenticates with the account of the GitHub CLI: no
+// token needs to be configured for proj. Retries and rate limits are left
+// to gh.
+func (c *Client) WithGHCLI() *Client {
+	clone := *c
+	clone.gh = runGH
+	return &clone
+}
+
+// ghGet performs a GET request on the API path with gh and decodes the JSON
+// response into v.
+func (c *Client) ghGet(ctx context.Context, path string, v any) error {
+	output, err := c.ghRequest(ctx, path, false)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(output, v); err != nil {
+		return fmt.Errorf("decode github response %s: %w", path, err)
+	}
+	return nil
+}
+
+// ghGetAll performs GET requests on the API path with gh, following
+// pagination, and returns the items of all pages.
+func ghGetAll[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	output, err := c.ghRequest(ctx, path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// gh writes the pages one after the other
+	var items []T
+	dec := json.NewDecoder(bytes.NewReader(output))
+	for dec.More() {
+		var page []T
+		if err := dec.Decode(&page); err != nil {
+			return nil, fmt.Errorf("decode github response %s: %w", path, err)
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
+
+// ghRequest runs 'gh api' on path, following all pages when paginate is set.
+func (c *Client) ghRequest(ctx context.Context, path string, paginate bool) ([]byte, error) {
+	args := []string{strings.TrimPrefix(path, "/")}
+	if paginate {
+		args = append([]string{"--paginate"}, args...)
+	}
+
+	c.logger.Debug("github api request", "path", path, "via", "gh")
+
+	output, err := c.gh(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("github request %s: %w", path, err)
+	}
+	return output, nil
+}
+
+// runGH runs 'gh api' with args. Not found responses match ErrNotFound.
+func runGH(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", append([]string{"api"}, args...)...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, errors.New("gh not found: install the GitHub CLI or set github.api to http")
+	}
+	if err != nil {
+		return nil, ghError(stderr.String(), err)
+	}
+	return output, nil
+}
+
+// ghError returns the error of a failed 'gh api' run from its standard
+// error, such as "gh: Not Found (HTTP 404)".
+func ghError(stderr string, err error) error {
+	msg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(stderr), "gh:"))
+	if msg == "" {
+		return fmt.Errorf("gh api: %w", err)
+	}
+	if strings.Contains(msg, "(HTTP 404)") {
+		return fmt.Errorf("%w: %s", ErrNotFound, msg)
+	}
+	return fmt.Errorf("gh api: %s", msg)
+}
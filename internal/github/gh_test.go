@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestGHCLI(t *testing.T) {
+	var calls []string
+	responses := map[string]string{
+		"repos/gfanton/projects": `{"full_name":"gfanton/projects","default_branch":"master"}`,
+		"--paginate repos/gfanton/projects/pulls?state=open&per_page=100": `[{"number":2,"title":"second"}]` + "\n" + `[{"number":1,"title":"first"}]`,
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "").WithGHCLI()
+	client.gh = func(ctx context.Context, args ...string) ([]byte, error) {
+		call := strings.Join(args, " ")
+		calls = append(calls, call)
+		if output, ok := responses[call]; ok {
+			return []byte(output), nil
+		}
+		return nil, ghError("gh: Not Found (HTTP 404)\n", errors.New("exit status 1"))
+	}
+
+	repo, err := client.Repository(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("Repository() failed: %v", err)
+	}
+	if repo.DefaultBranch != "master" {
+		t.Errorf("DefaultBranch = %q, want %q", repo.DefaultBranch, "master")
+	}
+
+	// Pages are written one after the other
+	prs, err := client.PullRequests(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("PullRequests() failed: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Number != 2 || prs[1].Number != 1 {
+		t.Errorf("PullRequests() = %+v, want #2 and #1", prs)
+	}
+
+	if _, err := client.Repository(context.Background(), "gfanton", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Repository() on 404 error = %v, want ErrNotFound", err)
+	}
+
+	// Not found organisations fall back to users, as over HTTP
+	if _, err := client.OwnerRepositories(context.Background(), "octocat"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OwnerRepositories(octocat) error = %v, want ErrNotFound", err)
+	}
+	if got := calls[len(calls)-1]; got != "--paginate users/octocat/repos?per_page=100" {
+		t.Errorf("last gh call = %q, want the users repositories", got)
+	}
+}
+
+func TestGHError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		want     string
+		notFound bool
+	}{
+		{
+			name:     "not found",
+			stderr:   "gh: Not Found (HTTP 404)\n",
+			want:     "404 Not Found: Not Found (HTTP 404)",
+			notFound: true,
+		},
+		{
+			name:   "not logged in",
+			stderr: "To get started with GitHub CLI, please run:  gh auth login\n",
+			want:   "gh api: To get started with GitHub CLI, please run:  gh auth login",
+		},
+		{
+			name:   "no output",
+			stderr: "",
+			want:   "gh api: exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ghError(tt.stderr, errors.New("exit status 1"))
+			if err.Error() != tt.want {
+				t.Errorf("ghError() = %q, want %q", err, tt.want)
+			}
+			if errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("ghError() is ErrNotFound = %v, want %v", !tt.notFound, tt.notFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package github provides a client for the GitHub REST API, with
+// pagination, retries with backoff and rate-limit awareness.
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the GitHub REST API endpoint.
+	DefaultBaseURL = "https://api.github.com"
+
+	defaultTimeout    = 15 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = time.Second
+	defaultMaxWait    = time.Minute
+)
+
+// ErrNotFound is returned when the requested resource doesn't exist, or is
+// not visible with the client token.
+var ErrNotFound = errors.New("404 Not Found")
+
+// Client is a GitHub REST API client. Failed requests are retried with
+// exponential backoff, and requests hitting the rate limit wait for its
+// reset when it is less than a minute away.
+type Client struct {
+	logger  *slog.Logger
+	http    *http.Client
+	baseURL string
+	token   string
+
+	maxRetries int
+	backoff    time.Duration
+	maxWait    time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+	rate       *rateState
+
+	// gh runs the requests with 'gh api' when set, see WithGHCLI
+	gh ghRunner
+}
+
+// NewClient creates a new GitHub client. The token is optional; without it
+// requests are unauthenticated and subject to lower rate limits.
+func NewClient(logger *slog.Logger, token string) *Client {
+	return &Client{
+		logger:  logger,
+		http:    &http.Client{Timeout: defaultTimeout},
+		baseURL: DefaultBaseURL,
+		token:   token,
+
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+		maxWait:    defaultMaxWait,
+		sleep:      sleep,
+		rate:       &rateState{},
+	}
+}
+
+// WithBaseURL returns a copy of the client targeting a different API endpoint
+// (GitHub Enterprise or tests).
+func (c *Client) WithBaseURL(baseURL string) *Client {
+	clone := *c
+	clone.baseURL = strings.TrimSuffix(baseURL, "/")
+	clone.rate = &rateState{}
+	return &clone
+}
+
+// Repository holds the subset of repository metadata used by proj.
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+	Fork          bool   `json:"fork"`
+}
+
+// Repository fetches metadata of the repository owner/name.
+func (c *Client) Repository(ctx context.Context, owner, name string) (*Repository, error) {
+	var repo Repository
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/%s", owner, name), &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// OwnerRepositories lists the repositories of the organisation or user
+// owner. Private repositories are included when visible with the token.
+func (c *Client) OwnerRepositories(ctx context.Context, owner string) ([]Repository, error) {
+	repos, err := getAll[Repository](ctx, c, fmt.Sprintf("/orgs/%s/repos?per_page=100", owner))
+	if !errors.Is(err, ErrNotFound) {
+		return repos, err
+	}
+
+	// Not an organisation, list the repositories of the user
+	return getAll[Repository](ctx, c, fmt.Sprintf("/users/%s/repos?per_page=100", owner))
+}
+
+// PullRequest holds the subset of pull request metadata used by proj.
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Draft  bool   `json:"draft"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Head struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+}
+
+// PullRequests lists the open pull requests of the repository owner/name,
+// most recently created first.
+func (c *Client) PullRequests(ctx context.Context, owner, name string) ([]PullRequest, error) {
+	return getAll[PullRequest](ctx, c, fmt.Sprintf("/repos/%s/%s/pulls?state=open&per_page=100", owner, name))
+}
+
+// Issue holds the subset of issue metadata used by proj.
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []Label `json:"labels"`
+	// PullRequest is set on pull requests, which the API lists as issues
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// Label is a label of an issue.
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Issues lists the open issues of the repository owner/name, most recently
+// created first. Pull requests are left out.
+func (c *Client) Issues(ctx context.Context, owner, name string) ([]Issue, error) {
+	all, err := getAll[Issue](ctx, c, fmt.Sprintf("/repos/%s/%s/issues?state=open&per_page=100", owner, name))
+	if err != nil {
+		return nil, err
+	}
+
+	issues := all[:0]
+	for _, issue := range all {
+		if issue.PullRequest == nil {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// Issue fetches the issue number of the repository owner/name.
+func (c *Client) Issue(ctx context.Context, owner, name string, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/%s/issues/%d", owner, name, number), &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// Release holds the subset of release metadata used by proj.
+type Release struct {
+	TagName    string         `json:"tag_name"`
+	HTMLURL    string         `json:"html_url"`
+	Prerelease bool           `json:"prerelease"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// Asset returns the asset of the release named name, or nil.
+func (r *Release) Asset(name string) *ReleaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// LatestRelease fetches the latest published, non-prerelease release of the
+// repository owner/name.
+func (c *Client) LatestRelease(ctx context.Context, owner, name string) (*Release, error) {
+	var release Release
+	if err := c.get(ctx, fmt.Sprintf("/repos/%s/%s/releases/latest", owner, name), &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
@@ -0,0 +1,156 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/gfanton/projects" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization header = %q, want %q", got, "Bearer secret")
+		}
+		w.Write([]byte(`{"full_name":"gfanton/projects","description":"Project manager","default_branch":"master"}`))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "secret").WithBaseURL(server.URL)
+
+	repo, err := client.Repository(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("Repository() failed: %v", err)
+	}
+
+	if repo.Description != "Project manager" {
+		t.Errorf("Description = %q, want %q", repo.Description, "Project manager")
+	}
+	if repo.DefaultBranch != "master" {
+		t.Errorf("DefaultBranch = %q, want %q", repo.DefaultBranch, "master")
+	}
+
+	if _, err := client.Repository(context.Background(), "gfanton", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Repository() on 404 error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOwnerRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/acme/repos":
+			w.Write([]byte(`[{"name":"api","full_name":"acme/api"},{"name":"old","full_name":"acme/old","archived":true}]`))
+		case "/users/octocat/repos":
+			w.Write([]byte(`[{"name":"fork","full_name":"octocat/fork","fork":true}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "").WithBaseURL(server.URL)
+
+	repos, err := client.OwnerRepositories(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("OwnerRepositories(acme) failed: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "api" || !repos[1].Archived {
+		t.Errorf("OwnerRepositories(acme) = %+v, want api and archived old", repos)
+	}
+
+	// Users are listed when the owner is not an organisation
+	repos, err = client.OwnerRepositories(context.Background(), "octocat")
+	if err != nil {
+		t.Fatalf("OwnerRepositories(octocat) failed: %v", err)
+	}
+	if len(repos) != 1 || !repos[0].Fork {
+		t.Errorf("OwnerRepositories(octocat) = %+v, want fork", repos)
+	}
+
+	if _, err := client.OwnerRepositories(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("OwnerRepositories(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPullRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/gfanton/projects/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.URL.Query().Get("state"); got != "open" {
+			t.Errorf("state = %q, want %q", got, "open")
+		}
+		w.Write([]byte(`[{"number":42,"title":"Add PR listing","draft":true,"user":{"login":"octocat"},"head":{"ref":"feature/pr"}}]`))
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "").WithBaseURL(server.URL)
+
+	prs, err := client.PullRequests(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("PullRequests() failed: %v", err)
+	}
+
+	if len(prs) != 1 {
+		t.Fatalf("PullRequests() returned %d pull requests, want 1", len(prs))
+	}
+	pr := prs[0]
+	if pr.Number != 42 || pr.Title != "Add PR listing" || !pr.Draft {
+		t.Errorf("PullRequest = %+v, want #42 draft 'Add PR listing'", pr)
+	}
+	if pr.User.Login != "octocat" {
+		t.Errorf("User.Login = %q, want %q", pr.User.Login, "octocat")
+	}
+	if pr.Head.Ref != "feature/pr" {
+		t.Errorf("Head.Ref = %q, want %q", pr.Head.Ref, "feature/pr")
+	}
+}
+
+func TestIssues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/gfanton/projects/issues":
+			w.Write([]byte(`[{"number":43,"title":"Add issues","pull_request":{"url":"x"}},{"number":42,"title":"List issues","user":{"login":"octocat"},"labels":[{"name":"enhancement"}]}]`))
+		case "/repos/gfanton/projects/issues/42":
+			w.Write([]byte(`{"number":42,"title":"List issues","html_url":"https://github.com/gfanton/projects/issues/42"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "").WithBaseURL(server.URL)
+
+	// Pull requests are left out
+	issues, err := client.Issues(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("Issues() failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Number != 42 || issues[0].User.Login != "octocat" || len(issues[0].Labels) != 1 {
+		t.Errorf("Issues() = %+v, want #42 by octocat labeled enhancement", issues)
+	}
+
+	issue, err := client.Issue(context.Background(), "gfanton", "projects", 42)
+	if err != nil {
+		t.Fatalf("Issue() failed: %v", err)
+	}
+	if issue.Title != "List issues" || issue.HTMLURL != "https://github.com/gfanton/projects/issues/42" {
+		t.Errorf("Issue() = %+v, want 'List issues' with its URL", issue)
+	}
+
+	if _, err := client.Issue(context.Background(), "gfanton", "projects", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Issue() on 404 error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,280 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when the API rate limit is exhausted and its
+// reset is too far away to wait for.
+var ErrRateLimited = errors.New("github rate limit exceeded")
+
+// Rate is the API rate limit reported by the last response.
+type Rate struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// rateState holds the last known rate limit, shared by requests of a client.
+type rateState struct {
+	mu   sync.Mutex
+	rate Rate
+}
+
+// Rate returns the rate limit reported by the last response, or a zero Rate
+// before any request.
+func (c *Client) Rate() Rate {
+	c.rate.mu.Lock()
+	defer c.rate.mu.Unlock()
+	return c.rate.rate
+}
+
+// updateRate records the rate limit headers of resp, if any.
+func (c *Client) updateRate(resp *http.Response) {
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	c.rate.mu.Lock()
+	defer c.rate.mu.Unlock()
+	c.rate.rate = Rate{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// get performs a GET request on the API path and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, v any) error {
+	if c.gh != nil {
+		return c.ghGet(ctx, path, v)
+	}
+	_, err := c.getPage(ctx, c.baseURL+path, v)
+	return err
+}
+
+// getAll performs GET requests on the API path, following pagination, and
+// returns the items of all pages.
+func getAll[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	if c.gh != nil {
+		return ghGetAll[T](ctx, c, path)
+	}
+
+	var items []T
+	for url := c.baseURL + path; url != ""; {
+		var page []T
+		next, err := c.getPage(ctx, url, &page)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		url = next
+	}
+	return items, nil
+}
+
+// getPage performs a GET request on url, decodes the JSON response into v
+// and returns the URL of the next page, or an empty string on the last page.
+func (c *Client) getPage(ctx context.Context, url string, v any) (string, error) {
+	path := strings.TrimPrefix(url, c.baseURL)
+
+	resp, err := c.do(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("github request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("decode github response %s: %w", path, err)
+	}
+
+	// Only follow links to the API endpoint, which receives the token
+	next := nextPageURL(resp.Header.Get("Link"))
+	if !strings.HasPrefix(next, c.baseURL+"/") {
+		return "", nil
+	}
+	return next, nil
+}
+
+// do performs a GET request on url, retrying network errors, server errors
+// and rate-limited requests. The returned response has a 200 status.
+func (c *Client) do(ctx context.Context, url string) (*http.Response, error) {
+	if err := c.waitRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, url)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		wait, retry := c.backoff<<attempt, true
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+		} else {
+			wait, retry = c.retryDelay(resp, wait)
+			err = responseError(resp)
+		}
+
+		switch {
+		case !retry || attempt >= c.maxRetries:
+			return nil, err
+		case wait > c.maxWait:
+			return nil, fmt.Errorf("%w: retry in %s: %w", ErrRateLimited, wait.Round(time.Second), err)
+		}
+
+		c.logger.Debug("retrying github request", "url", url, "attempt", attempt+1, "wait", wait, "error", err)
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Download writes the content at url, such as a release asset download URL,
+// to w. The token is only sent to the API endpoint.
+func (c *Client) Download(ctx context.Context, url string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	c.logger.Debug("github download", "url", url)
+
+	// Downloads can be larger than API responses: don't apply the client
+	// timeout, ctx bounds the transfer
+	resp, err := (&http.Client{Transport: c.http.Transport}).Do(req)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %w", url, responseError(resp))
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("download %s: %w", url, err)
+	}
+	return nil
+}
+
+// send performs a single GET request on url.
+func (c *Client) send(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	c.logger.Debug("github api request", "url", url)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.updateRate(resp)
+	return resp, nil
+}
+
+// waitRateLimit waits for the rate limit reset when the last response
+// reported no remaining requests.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	rate := c.Rate()
+	if rate.Limit == 0 || rate.Remaining > 0 {
+		return nil
+	}
+
+	wait := time.Until(rate.Reset)
+	switch {
+	case wait <= 0:
+		return nil
+	case wait > c.maxWait:
+		return fmt.Errorf("%w: resets at %s", ErrRateLimited, rate.Reset.Format(time.Kitchen))
+	}
+
+	c.logger.Debug("waiting for github rate limit reset", "wait", wait)
+	return c.sleep(ctx, wait)
+}
+
+// retryDelay returns how long to wait before retrying the failed request
+// of resp, given the backoff delay, and whether it should be retried.
+func (c *Client) retryDelay(resp *http.Response, backoff time.Duration) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// Secondary rate limits tell how long to wait
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+
+		// Primary rate limit: wait for the reset
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if wait := time.Until(c.Rate().Reset) + time.Second; wait > 0 {
+				return wait, true
+			}
+			return backoff, true
+		}
+
+		// Other forbidden requests are permission errors
+		return 0, resp.StatusCode == http.StatusTooManyRequests
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// responseError returns the error of a failed response and closes its body.
+// Not found responses match ErrNotFound.
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(body)))
+	}
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// nextPageURL returns the rel="next" URL of a Link header, or an empty string.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		url, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(url), "<>")
+	}
+	return ""
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
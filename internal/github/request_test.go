@@ -0,0 +1,176 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for server whose sleeps are recorded
+// instead of waited.
+func newTestClient(server *httptest.Server, waits *[]time.Duration) *Client {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client := NewClient(logger, "").WithBaseURL(server.URL)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		return nil
+	}
+	return client
+}
+
+func TestPullRequestsPagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?state=open&page=%d>; rel="next", <%s%s?page=3>; rel="last"`, server.URL, r.URL.Path, page+1, server.URL, r.URL.Path))
+		}
+		fmt.Fprintf(w, `[{"number":%d}]`, page)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	prs, err := newTestClient(server, &waits).PullRequests(context.Background(), "gfanton", "projects")
+	if err != nil {
+		t.Fatalf("PullRequests() failed: %v", err)
+	}
+
+	if len(prs) != 3 {
+		t.Fatalf("PullRequests() returned %d pull requests, want 3", len(prs))
+	}
+	for i, pr := range prs {
+		if pr.Number != i+1 {
+			t.Errorf("prs[%d].Number = %d, want %d", i, pr.Number, i+1)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []func(w http.ResponseWriter)
+		wantErr   bool
+		wantWaits []time.Duration
+	}{
+		{
+			name: "server error",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+			},
+			wantWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name: "secondary rate limit",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) {
+					w.Header().Set("Retry-After", "5")
+					w.WriteHeader(http.StatusForbidden)
+				},
+			},
+			wantWaits: []time.Duration{5 * time.Second},
+		},
+		{
+			name: "forbidden",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusForbidden) },
+			},
+			wantErr: true,
+		},
+		{
+			name: "retries exhausted",
+			responses: []func(w http.ResponseWriter){
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+				func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+			},
+			wantErr:   true,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer func() { calls++ }()
+				if calls < len(tt.responses) {
+					tt.responses[calls](w)
+					return
+				}
+				w.Write([]byte(`{"full_name":"gfanton/projects"}`))
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			_, err := newTestClient(server, &waits).Repository(context.Background(), "gfanton", "projects")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(waits) != fmt.Sprint(tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := newTestClient(server, &waits)
+
+	_, err := client.Repository(context.Background(), "gfanton", "projects")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Repository() error = %v, want ErrRateLimited", err)
+	}
+	if len(waits) != 0 {
+		t.Errorf("waits = %v, want none for a reset an hour away", waits)
+	}
+
+	rate := client.Rate()
+	if rate.Limit != 60 || rate.Remaining != 0 || rate.Reset.Unix() != reset {
+		t.Errorf("Rate() = %+v, want limit 60, remaining 0, reset %d", rate, reset)
+	}
+
+	// Later requests fail before reaching the API
+	if _, err := client.Repository(context.Background(), "gfanton", "projects"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Repository() error = %v, want ErrRateLimited", err)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{`<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, "https://api.github.com/x?page=2"},
+		{`<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=1>; rel="first"`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := nextPageURL(tt.link); got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
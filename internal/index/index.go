@@ -0,0 +1,165 @@
+// Package index stores per-project metadata, such as descriptions, used by
+// the opt-in content search of the query command.
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Version is the current on-disk format version of the index.
+	Version = 1
+
+	defaultDirPerms  = 0755
+	defaultFilePerms = 0644
+)
+
+// Description sources.
+const (
+	SourceReadme = "readme"
+	SourceGitHub = "github"
+)
+
+// readmeNames lists README file names checked, in order of preference.
+var readmeNames = []string{"README.md", "readme.md", "README.markdown", "README.rst", "README.txt", "README"}
+
+// Entry holds the indexed metadata of a single project.
+type Entry struct {
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	// Remote is the URL of the origin remote of the project, used to find
+	// local clones of the same repository.
+	Remote    string    `json:"remote,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Index maps project paths to their indexed metadata.
+type Index struct {
+	Version int              `json:"version"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{
+		Version: Version,
+		Entries: make(map[string]Entry),
+	}
+}
+
+// DefaultPath returns the default location of the index file inside the
+// user cache directory.
+func DefaultPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "proj", "index.json"), nil
+}
+
+// Load reads the index stored at path. A missing file yields an empty index.
+func Load(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read index %s: %w", path, err)
+	}
+
+	idx := New()
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("parse index %s: %w", path, err)
+	}
+
+	if idx.Version != Version {
+		return nil, fmt.Errorf("unsupported index version %d in %s (expected %d)", idx.Version, path, Version)
+	}
+
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]Entry)
+	}
+
+	return idx, nil
+}
+
+// Save atomically writes the index to path, creating parent directories.
+func (i *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), defaultDirPerms); err != nil {
+		return fmt.Errorf("create index directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, defaultFilePerms); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace index: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the entry for the project at path.
+func (i *Index) Get(path string) (Entry, bool) {
+	e, ok := i.Entries[path]
+	return e, ok
+}
+
+// Set stores the entry for the project at path.
+func (i *Index) Set(path string, e Entry) {
+	i.Entries[path] = e
+}
+
+// ReadmeDescription extracts a one-line description from the README found in
+// dir: the first heading, or the first non-empty line when the README has no
+// heading. It returns an empty string if no README is found.
+func ReadmeDescription(dir string) string {
+	for _, name := range readmeNames {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		desc := firstHeading(f)
+		f.Close()
+		return desc
+	}
+	return ""
+}
+
+func firstHeading(f *os.File) string {
+	var firstLine string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+
+		// Skip badges, HTML and reStructuredText underlines
+		if firstLine == "" && !strings.HasPrefix(line, "[!") && !strings.HasPrefix(line, "<") && strings.Trim(line, "=-~") != "" {
+			firstLine = line
+		}
+	}
+
+	return firstLine
+}
@@ -0,0 +1,150 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadmeDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "markdown heading",
+			file:     "README.md",
+			content:  "[![CI](badge.svg)](ci)\n\n# Kubernetes operator for foo\n\nMore text",
+			expected: "Kubernetes operator for foo",
+		},
+		{
+			name:     "no heading falls back to first line",
+			file:     "README",
+			content:  "\nA tiny tool to do things\nsecond line\n",
+			expected: "A tiny tool to do things",
+		},
+		{
+			name:     "rst title",
+			file:     "README.rst",
+			content:  "My Library\n==========\n\nDetails",
+			expected: "My Library",
+		},
+		{
+			name:     "no readme",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.file != "" {
+				if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644); err != nil {
+					t.Fatalf("failed to write readme: %v", err)
+				}
+			}
+
+			if got := ReadmeDescription(dir); got != tt.expected {
+				t.Errorf("ReadmeDescription() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIndexSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "index.json")
+
+	// Missing file yields an empty index
+	idx, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() on missing file failed: %v", err)
+	}
+	if len(idx.Entries) != 0 {
+		t.Fatalf("expected empty index, got %d entries", len(idx.Entries))
+	}
+
+	entry := Entry{Description: "a project", Source: SourceReadme, UpdatedAt: time.Now().UTC().Truncate(time.Second)}
+	idx.Set("/code/org/app", entry)
+
+	if err := idx.Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	got, ok := loaded.Get("/code/org/app")
+	if !ok {
+		t.Fatal("entry missing after reload")
+	}
+	if got.Description != entry.Description || got.Source != entry.Source || !got.UpdatedAt.Equal(entry.UpdatedAt) {
+		t.Errorf("reloaded entry = %+v, want %+v", got, entry)
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": {}}`), 0644); err != nil {
+		t.Fatalf("failed to write index: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load() should fail on unknown version")
+	}
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := old.Add(24 * time.Hour)
+
+	src := New()
+	src.Set("/home/a/code/org/app", Entry{Description: "An app", Source: SourceReadme, UpdatedAt: recent})
+	src.Set("/home/a/code/org/lib", Entry{Description: "A lib", Source: SourceGitHub, UpdatedAt: old})
+	src.Set("/elsewhere/org/other", Entry{Description: "Outside the root", UpdatedAt: old})
+
+	snapshot := src.Export("/home/a/code", recent)
+	snapshot.Projects["org/tagged"] = SnapshotEntry{Tags: []string{"work"}}
+
+	var sb strings.Builder
+	if err := WriteSnapshot(&sb, snapshot); err != nil {
+		t.Fatalf("WriteSnapshot() failed: %v", err)
+	}
+	read, err := ReadSnapshot(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ReadSnapshot() failed: %v", err)
+	}
+	if len(read.Projects) != 3 {
+		t.Fatalf("snapshot projects = %v, want org/app, org/lib and org/tagged", read.Projects)
+	}
+
+	dst := New()
+	dst.Set("/root/code/org/lib", Entry{Description: "Newer lib", UpdatedAt: recent})
+
+	if imported := dst.Import(read, "/root/code"); imported != 1 {
+		t.Errorf("Import() = %d, want 1", imported)
+	}
+	if e, _ := dst.Get("/root/code/org/app"); e.Description != "An app" || !e.UpdatedAt.Equal(recent) {
+		t.Errorf("org/app entry = %+v, want the exported one", e)
+	}
+	if e, _ := dst.Get("/root/code/org/lib"); e.Description != "Newer lib" {
+		t.Errorf("org/lib entry = %+v, want the existing newer one", e)
+	}
+	if _, ok := dst.Get("/root/code/org/tagged"); ok {
+		t.Error("tags-only project imported as an index entry")
+	}
+}
+
+func TestReadSnapshotRejectsInvalidNames(t *testing.T) {
+	for _, name := range []string{"../etc", "org/../../x", "org", "org/name/sub", ".workspace/x"} {
+		data := `{"version": 1, "projects": {"` + name + `": {"description": "x"}}}`
+		if _, err := ReadSnapshot(strings.NewReader(data)); err == nil {
+			t.Errorf("ReadSnapshot() accepted project name %q", name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the current format version of index snapshots.
+const SnapshotVersion = 1
+
+// Snapshot is a portable copy of the index. Projects are keyed by their
+// "org/name" instead of their path, so that a snapshot can be imported on a
+// machine with another root directory.
+type Snapshot struct {
+	Version    int                      `json:"version"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Projects   map[string]SnapshotEntry `json:"projects"`
+}
+
+// SnapshotEntry holds the exported metadata of a project: its index entry,
+// if any, and optionally its tags.
+type SnapshotEntry struct {
+	Entry
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Export returns a snapshot of the entries of the projects under rootDir.
+func (i *Index) Export(rootDir string, now time.Time) *Snapshot {
+	s := &Snapshot{
+		Version:    SnapshotVersion,
+		ExportedAt: now,
+		Projects:   make(map[string]SnapshotEntry),
+	}
+
+	for p, e := range i.Entries {
+		if name, ok := projectName(rootDir, p); ok {
+			s.Projects[name] = SnapshotEntry{Entry: e}
+		}
+	}
+	return s
+}
+
+// Import merges the index entries of s under rootDir, keeping the existing
+// entries updated after the imported ones. It returns the number of entries
+// imported.
+func (i *Index) Import(s *Snapshot, rootDir string) int {
+	var imported int
+	for name, se := range s.Projects {
+		if se.Description == "" && se.Remote == "" {
+			continue // tags only
+		}
+
+		p := filepath.Join(rootDir, filepath.FromSlash(name))
+		if existing, ok := i.Get(p); ok && existing.UpdatedAt.After(se.UpdatedAt) {
+			continue
+		}
+		i.Set(p, se.Entry)
+		imported++
+	}
+	return imported
+}
+
+// WriteSnapshot writes s as indented JSON.
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot, rejecting invalid
+// project names.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("parse snapshot: %w", err)
+	}
+
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d (expected %d)", s.Version, SnapshotVersion)
+	}
+
+	for name := range s.Projects {
+		if !validProjectName(name) {
+			return nil, fmt.Errorf("invalid project name '%s' in snapshot", name)
+		}
+	}
+	return &s, nil
+}
+
+// projectName returns the "org/name" of the project at p under rootDir.
+func projectName(rootDir, p string) (string, bool) {
+	rel, err := filepath.Rel(rootDir, p)
+	if err != nil {
+		return "", false
+	}
+
+	name := filepath.ToSlash(rel)
+	return name, validProjectName(name)
+}
+
+// validProjectName reports whether name is a clean "org/name".
+func validProjectName(name string) bool {
+	org, project, ok := strings.Cut(name, "/")
+	return ok && org != "" && project != "" && !strings.Contains(project, "/") &&
+		path.Clean(name) == name && !strings.HasPrefix(org, ".") && !strings.HasPrefix(project, ".")
+}
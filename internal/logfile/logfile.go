@@ -0,0 +1,175 @@
+// Package logfile provides the size-rotated JSON log file of proj.
+package logfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxSize is the size above which the log file is rotated.
+	DefaultMaxSize = 5 << 20 // 5 MiB
+
+	// DefaultBackups is the number of rotated log files kept.
+	DefaultBackups = 2
+)
+
+// DefaultPath returns the log file path: $XDG_STATE_HOME/proj/proj.log,
+// defaulting to ~/.local/state/proj/proj.log.
+func DefaultPath() (string, error) {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		stateDir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateDir, "proj", "proj.log"), nil
+}
+
+// File is a log file rotated when it grows above a maximum size: path is
+// renamed to path.1, path.1 to path.2, and so on up to the kept backups.
+// Writes are appended, so several processes can share the file.
+type File struct {
+	path    string
+	maxSize int64
+	backups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// Open opens the log file at path, creating it and its directory if needed.
+func Open(path string, maxSize int64, backups int) (*File, error) {
+	f := &File{path: path, maxSize: maxSize, backups: backups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of the log file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Write appends p to the log file, rotating it first if p would make it
+// exceed the maximum size.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(int64(len(p))); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the backups and reopens the log file before writing next
+// bytes.
+func (f *File) rotate(next int64) error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+
+	// Another process may have rotated the file already: only shift
+	// backups if it is still too large
+	if info, err := os.Stat(f.path); err == nil && info.Size()+next > f.maxSize {
+		for i := f.backups; i > 0; i-- {
+			src := f.path
+			if i > 1 {
+				src = fmt.Sprintf("%s.%d", f.path, i-1)
+			}
+			err := os.Rename(src, fmt.Sprintf("%s.%d", f.path, i))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("rotate log file: %w", err)
+			}
+		}
+		if f.backups == 0 {
+			if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("rotate log file: %w", err)
+			}
+		}
+	}
+
+	return f.open()
+}
+
+// Tail returns the last n lines of the log file at path, completed with
+// lines of its most recent backup when the file has fewer.
+func Tail(path string, n int) ([]string, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) < n {
+		backup, err := readLines(path + ".1")
+		if err != nil {
+			return nil, err
+		}
+		lines = append(backup, lines...)
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// readLines returns the non-empty lines of the file at path, or none if it
+// doesn't exist.
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read log file: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
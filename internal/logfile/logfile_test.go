@@ -0,0 +1,75 @@
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("DefaultPath() failed: %v", err)
+	}
+	if path != filepath.Join("/state", "proj", "proj.log") {
+		t.Errorf("DefaultPath() = %q", path)
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proj", "proj.log")
+
+	f, err := Open(path, 20, 2)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer f.Close()
+
+	// Each line is 10 bytes: two lines fit in a file
+	for i := 0; i < 7; i++ {
+		if _, err := fmt.Fprintf(f, "line %04d\n", i); err != nil {
+			t.Fatalf("Write() failed: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "line 0006\n",
+		path + ".1": "line 0004\nline 0005\n",
+		path + ".2": "line 0002\nline 0003\n",
+	}
+	for file, want := range expected {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) failed: %v", file, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), data, want)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("only 2 backups should be kept")
+	}
+
+	lines, err := Tail(path, 2)
+	if err != nil {
+		t.Fatalf("Tail() failed: %v", err)
+	}
+	if got := strings.Join(lines, ","); got != "line 0005,line 0006" {
+		t.Errorf("Tail() = %s, want line 0005,line 0006", got)
+	}
+}
+
+func TestTailMissing(t *testing.T) {
+	lines, err := Tail(filepath.Join(t.TempDir(), "proj.log"), 10)
+	if err != nil {
+		t.Fatalf("Tail() failed: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("Tail() = %v, want no lines", lines)
+	}
+}
@@ -0,0 +1,38 @@
+// Package match provides the text normalization used when comparing project
+// names, branch names and paths during queries.
+package match
+
+import (
+	"runtime"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalize returns s in Unicode NFC form with full case folding applied.
+// Filesystems such as APFS/HFS+ may hand back names in decomposed (NFD) form,
+// so both sides of a comparison must go through Normalize to match reliably.
+func Normalize(s string) string {
+	return cases.Fold().String(norm.NFC.String(s))
+}
+
+// Path normalizes a filesystem path for comparison. Paths are always converted
+// to NFC, and are additionally case-folded on case-insensitive platforms
+// (macOS/Windows).
+func Path(p string) string {
+	p = norm.NFC.String(p)
+	if caseInsensitiveFS() {
+		return cases.Fold().String(p)
+	}
+	return p
+}
+
+// PathsEqual reports whether two paths refer to the same location once
+// normalized with Path.
+func PathsEqual(a, b string) bool {
+	return Path(a) == Path(b)
+}
+
+func caseInsensitiveFS() bool {
+	return runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+}
@@ -0,0 +1,33 @@
+package match
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"ascii case", "MyOrg", "myorg"},
+		{"nfd vs nfc", "élan", "élan"},
+		{"nfd upper vs nfc lower", "ÉLAN", "élan"},
+		{"sharp s folding", "STRASSE", "straße"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := Normalize(tt.a), Normalize(tt.b); got != want {
+				t.Errorf("Normalize(%q) = %q, Normalize(%q) = %q, want equal", tt.a, got, tt.b, want)
+			}
+		})
+	}
+}
+
+func TestPathsEqual(t *testing.T) {
+	if !PathsEqual("/code/élan/app", "/code/élan/app") {
+		t.Error("PathsEqual() should treat NFD and NFC paths as equal")
+	}
+
+	if PathsEqual("/code/a", "/code/b") {
+		t.Error("PathsEqual() should not match different paths")
+	}
+}
@@ -0,0 +1,58 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// languageMarkers maps well-known manifest files to the language they imply,
+// in order of precedence.
+var languageMarkers = []struct {
+	file     string
+	language string
+}{
+	{"go.mod", "go"},
+	{"Cargo.toml", "rust"},
+	{"package.json", "javascript"},
+	{"pyproject.toml", "python"},
+	{"setup.py", "python"},
+	{"requirements.txt", "python"},
+	{"Gemfile", "ruby"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+	{"build.gradle.kts", "kotlin"},
+	{"mix.exs", "elixir"},
+	{"composer.json", "php"},
+	{"Package.swift", "swift"},
+	{"CMakeLists.txt", "c"},
+	{"flake.nix", "nix"},
+	{"default.nix", "nix"},
+}
+
+// DetectLanguage returns the primary language of the project at dir, based on
+// the presence of well-known manifest files. It returns an empty string when
+// no marker is found.
+func DetectLanguage(dir string) string {
+	for _, m := range languageMarkers {
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil {
+			return m.language
+		}
+	}
+	return ""
+}
+
+// DetectLanguages returns all the languages of the project at dir, in order
+// of precedence, for projects mixing several stacks.
+func DetectLanguages(dir string) []string {
+	var languages []string
+	for _, m := range languageMarkers {
+		if slices.Contains(languages, m.language) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, m.file)); err == nil {
+			languages = append(languages, m.language)
+		}
+	}
+	return languages
+}
@@ -6,6 +6,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
@@ -20,6 +21,9 @@ const (
 	WalkDepth = 1
 	// WorkspaceDir is the directory name used for workspace (worktree) storage.
 	WorkspaceDir = ".workspace"
+	// BareDir is the directory name of the bare repository of projects using
+	// the bare layout, whose checkouts are worktrees of the project directory.
+	BareDir = ".bare"
 )
 
 // Project represents a project with its organization and name.
@@ -27,10 +31,28 @@ type Project struct {
 	Path         string
 	Name         string
 	Organisation string
+
+	// Host is the Git server of the project when it is not DefaultProvider,
+	// the project being stored under a directory of its name.
+	Host *Host
+}
+
+// Host is a Git server other than DefaultProvider, such as a GitHub
+// Enterprise instance.
+type Host struct {
+	Name string
+	// SSHUser is the user of SSH clone URLs, "git" when empty.
+	SSHUser string
+	// DefaultOrgPrefix is the organisation, e.g. "team/", of the project
+	// names without one.
+	DefaultOrgPrefix string
 }
 
 // ParseProject parses a project name into a Project struct.
-// Supports formats: "project" (uses default user), "user/project".
+// Supports formats: "project" (uses default user), "user/project", and
+// "host/user/project" with the host name of the Git server: the projects
+// of DefaultProvider are the same as "user/project", the ones of other
+// servers are stored under <rootDir>/<host>.
 func ParseProject(rootDir, defaultUser, name string) (*Project, error) {
 	name = strings.TrimSpace(name)
 	split := strings.Split(name, string(os.PathSeparator))
@@ -66,24 +88,115 @@ func ParseProject(rootDir, defaultUser, name string) (*Project, error) {
 			Organisation: user,
 		}, nil
 
+	case 3:
+		host := split[0]
+		if !IsHostName(host) {
+			return nil, fmt.Errorf("malformed project name '%s' (expected 'project', 'user/project' or 'host/user/project')", name)
+		}
+		if host == DefaultProvider {
+			return ParseProject(rootDir, defaultUser, split[1]+"/"+split[2])
+		}
+		return ParseHostProject(rootDir, Host{Name: host}, split[1]+"/"+split[2])
+
 	default:
-		return nil, fmt.Errorf("malformed project name '%s' (expected 'project' or 'user/project')", name)
+		return nil, fmt.Errorf("malformed project name '%s' (expected 'project', 'user/project' or 'host/user/project')", name)
+	}
+}
+
+// IsHostName reports whether the first segment of a project name is the
+// host name of a Git server rather than a user: unlike GitHub users, host
+// names contain dots.
+func IsHostName(name string) bool {
+	return strings.Contains(name, ".") && !strings.HasPrefix(name, ".")
+}
+
+// IsHostDir reports whether the directory dir of the root directory holds
+// the projects of a Git server, checking its subdirectory sub: host
+// directories are named after the host and hold organisations, not Git
+// repositories, which tells them apart from users with dots in their name.
+// Unless dir is one of the configured hosts, it must also hold at least one
+// <org>/<name> repository, so that plain directories of such users are not
+// taken for organisations.
+func IsHostDir(rootDir, dir, sub string, hosts []string) bool {
+	if !IsHostName(dir) {
+		return false
+	}
+	path := filepath.Join(rootDir, dir, sub)
+	for _, name := range []string{".git", BareDir} {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			return false
+		}
+	}
+	if slices.Contains(hosts, dir) {
+		return true
+	}
+	for _, name := range []string{".git", BareDir} {
+		if matches, _ := filepath.Glob(filepath.Join(rootDir, dir, "*", "*", name)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHostProject parses the name of a project of host, without the
+// leading "<host>/". Supports formats: "user/project", and "project" when
+// the host has a default organisation prefix. The project is stored under
+// <rootDir>/<host>.
+func ParseHostProject(rootDir string, host Host, name string) (*Project, error) {
+	name = strings.TrimSpace(name)
+	if !strings.Contains(name, "/") && host.DefaultOrgPrefix != "" {
+		name = strings.TrimSuffix(host.DefaultOrgPrefix, "/") + "/" + name
 	}
+
+	split := strings.Split(name, "/")
+	if len(split) != 2 {
+		return nil, fmt.Errorf("malformed project name '%s/%s' (expected '%s/user/project')", host.Name, name, host.Name)
+	}
+	user, projectName := split[0], split[1]
+	if user == "" {
+		return nil, fmt.Errorf("user/org name is required in '%s/%s'", host.Name, name)
+	}
+	if projectName == "" {
+		return nil, fmt.Errorf("project name is required in '%s/%s'", host.Name, name)
+	}
+
+	return &Project{
+		Path:         filepath.Join(rootDir, host.Name, user, projectName),
+		Name:         projectName,
+		Organisation: user,
+		Host:         &host,
+	}, nil
 }
 
-// String returns the string representation of the project (user/project).
+// String returns the string representation of the project (user/project),
+// prefixed by the host of projects not on DefaultProvider.
 func (p *Project) String() string {
+	if p.Host != nil {
+		return fmt.Sprintf("%s/%s/%s", p.Host.Name, p.Organisation, p.Name)
+	}
 	return fmt.Sprintf("%s/%s", p.Organisation, p.Name)
 }
 
+// Provider returns the host name of the Git server of the project.
+func (p *Project) Provider() string {
+	if p.Host != nil {
+		return p.Host.Name
+	}
+	return DefaultProvider
+}
+
 // GitHTTPURL returns the HTTP URL for cloning the project.
 func (p *Project) GitHTTPURL() string {
-	return fmt.Sprintf("https://%s/%s/%s.git", GitHubProvider, p.Organisation, p.Name)
+	return fmt.Sprintf("https://%s/%s/%s.git", p.Provider(), p.Organisation, p.Name)
 }
 
 // GitSSHURL returns the SSH URL for cloning the project.
 func (p *Project) GitSSHURL() string {
-	return fmt.Sprintf("git@%s:%s/%s.git", GitHubProvider, p.Organisation, p.Name)
+	user := "git"
+	if p.Host != nil && p.Host.SSHUser != "" {
+		user = p.Host.SSHUser
+	}
+	return fmt.Sprintf("%s@%s:%s/%s.git", user, p.Provider(), p.Organisation, p.Name)
 }
 
 // GitDir returns the path to the .git directory.
@@ -97,6 +210,14 @@ func (p *Project) IsGitRepository() bool {
 	return err == nil
 }
 
+// IsBare reports whether the project at path uses the bare layout: a bare
+// repository in BareDir, referenced by a .git file, and worktrees as
+// checkouts.
+func IsBare(path string) bool {
+	info, err := os.Stat(filepath.Join(path, BareDir))
+	return err == nil && info.IsDir()
+}
+
 // OpenRepository opens the Git repository.
 func (p *Project) OpenRepository() (*git.Repository, error) {
 	return git.PlainOpen(p.Path)
@@ -130,12 +251,60 @@ func (p *Project) GetGitStatus() GitStatus {
 // WalkFunc is the function called for each project during traversal.
 type WalkFunc func(d fs.DirEntry, project *Project) error
 
+// WalkErrors is returned by Walk when directories of the root directory
+// could not be read, such as for lack of permissions: the projects of the
+// other directories have been walked, so the results of the walk are
+// partial rather than wrong.
+type WalkErrors struct {
+	Errors []error // errors of the unreadable directories, *fs.PathError
+}
+
+func (e *WalkErrors) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("skipped an unreadable directory: %v", e.Errors[0])
+	}
+	return fmt.Sprintf("skipped %d unreadable directories: %v (and %d more)", len(e.Errors), e.Errors[0], len(e.Errors)-1)
+}
+
+func (e *WalkErrors) Unwrap() []error {
+	return e.Errors
+}
+
 // Walk traverses the root directory and calls fn for each project found.
 // It follows symlinks to directories to support projects added via symlinks.
+// Directories that can't be read are skipped, and reported by a *WalkErrors
+// error once the others have been walked; an unreadable root directory, or
+// an error of fn, stops the walk.
 func Walk(rootDir string, fn WalkFunc) error {
-	return filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+	return WalkWith(rootDir, WalkOptions{}, fn)
+}
+
+// WalkOptions changes the directories walked by WalkWith.
+type WalkOptions struct {
+	// Orgs reports whether to walk the top-level directory name, an
+	// organisation or a Git server host. A nil Orgs walks them all.
+	Orgs func(name string) bool
+	// AllowHidden lists the hidden directories, such as ".dotfiles",
+	// walked like others. The other hidden directories are skipped.
+	AllowHidden []string
+	// Hosts lists the configured Git server hosts, whose directories hold
+	// projects even before one is cloned (see IsHostDir).
+	Hosts []string
+}
+
+// WalkWith is like Walk, with the directories walked changed by opts.
+func WalkWith(rootDir string, opts WalkOptions, fn WalkFunc) error {
+	var skipped []error
+	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			if path == rootDir {
+				return err
+			}
+			skipped = append(skipped, err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
 		}
 
 		// Handle both regular directories and symlinks to directories
@@ -160,41 +329,76 @@ func Walk(rootDir string, fn WalkFunc) error {
 			return err
 		}
 
-		sepCount := strings.Count(relPath, string(os.PathSeparator))
-		if sepCount < WalkDepth {
+		if relPath == "." {
 			return nil
 		}
-
-		if sepCount > WalkDepth {
-			return fs.SkipDir
-		}
+		split := strings.Split(relPath, string(os.PathSeparator))
 
 		// Skip any directory that starts with a dot (like .workspace, .git, .vscode, etc.)
-		for _, part := range strings.Split(relPath, string(os.PathSeparator)) {
-			if strings.HasPrefix(part, ".") {
+		// unless allowed
+		for _, part := range split {
+			if strings.HasPrefix(part, ".") && !slices.Contains(opts.AllowHidden, part) {
 				return fs.SkipDir
 			}
 		}
 
-		split := strings.Split(relPath, string(os.PathSeparator))
-		if len(split) != 2 {
+		if len(split) == 1 && opts.Orgs != nil && !opts.Orgs(split[0]) && d.IsDir() {
+			return fs.SkipDir
+		}
+
+		// Projects of other Git servers are one level deeper, under a
+		// directory named after their host
+		var host *Host
+		if len(split) > 1 && IsHostDir(rootDir, split[0], split[1], opts.Hosts) {
+			host = &Host{Name: split[0]}
+			split = split[1:]
+		}
+
+		sepCount := len(split) - 1
+		if sepCount < WalkDepth {
 			return nil
 		}
 
+		if sepCount > WalkDepth {
+			return fs.SkipDir
+		}
+
 		project := &Project{
 			Path:         path,
 			Name:         split[1],
 			Organisation: split[0],
+			Host:         host,
 		}
 
-		return fn(d, project)
+		if err := fn(d, project); err != nil {
+			return err
+		}
+		// Nothing to find inside projects: don't read them
+		if d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		return &WalkErrors{Errors: skipped}
+	}
+	return nil
 }
 
 // FindFromPath finds a project from a given path by checking if it's within the root directory
 // and follows the organization/project structure.
-// Also handles paths inside .workspace directory.
+// Also handles paths inside .workspace directory, and inside the worktrees
+// and bare repository of projects using the bare layout.
 func FindFromPath(rootDir, path string) (*Project, error) {
+	return FindFromPathWith(rootDir, path, nil)
+}
+
+// FindFromPathWith is like FindFromPath, with the configured Git server
+// hosts (see IsHostDir).
+func FindFromPathWith(rootDir, path string, hosts []string) (*Project, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
@@ -221,8 +425,9 @@ func FindFromPath(rootDir, path string) (*Project, error) {
 	parts := strings.Split(relPath, string(os.PathSeparator))
 
 	// Path structures:
-	// - Regular:   <org>/<name>[/...]
-	// - Workspace: .workspace/<org>/<name>/<branch>[/...]
+	// - Regular:   [<host>/]<org>/<name>[/...]
+	// - Workspace: .workspace/[<host>/]<org>/<name>/<branch>[/...]
+	// - Bare:      [<host>/]<org>/<name>/<branch>[/...] or [<host>/]<org>/<name>/.bare[/...]
 	orgIdx := 0
 	nameIdx := 1
 	if len(parts) > 0 && parts[0] == WorkspaceDir {
@@ -230,6 +435,13 @@ func FindFromPath(rootDir, path string) (*Project, error) {
 		nameIdx = 2
 	}
 
+	var host *Host
+	if len(parts) > orgIdx+1 && IsHostDir(absRootDir, parts[orgIdx], parts[orgIdx+1], hosts) {
+		host = &Host{Name: parts[orgIdx]}
+		orgIdx++
+		nameIdx++
+	}
+
 	if len(parts) < nameIdx+1 {
 		return nil, errors.New("path does not contain organization/project structure")
 	}
@@ -237,9 +449,14 @@ func FindFromPath(rootDir, path string) (*Project, error) {
 	org := parts[orgIdx]
 	name := parts[nameIdx]
 
+	projectPath := filepath.Join(absRootDir, org, name)
+	if host != nil {
+		projectPath = filepath.Join(absRootDir, host.Name, org, name)
+	}
 	return &Project{
-		Path:         filepath.Join(absRootDir, org, name),
+		Path:         projectPath,
 		Name:         name,
 		Organisation: org,
+		Host:         host,
 	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -52,6 +53,28 @@ func TestParseProject(t *testing.T) {
 			expected:    nil,
 			wantErr:     true,
 		},
+		{
+			name:        "project of the default provider",
+			rootDir:     "/root",
+			defaultUser: "defaultuser",
+			projectName: "github.com/user/project",
+			expected: &Project{
+				Path:         "/root/user/project",
+				Name:         "project",
+				Organisation: "user",
+			},
+		},
+		{
+			name:        "project of another host",
+			rootDir:     "/root",
+			defaultUser: "defaultuser",
+			projectName: "gitlab.com/user/project",
+			expected: &Project{
+				Path:         "/root/gitlab.com/user/project",
+				Name:         "project",
+				Organisation: "user",
+			},
+		},
 		{
 			name:        "malformed project name",
 			rootDir:     "/root",
@@ -151,6 +174,85 @@ func TestProjectGitURLs(t *testing.T) {
 	}
 }
 
+func TestParseHostProject(t *testing.T) {
+	host := Host{Name: "git.corp.com", SSHUser: "gitlab", DefaultOrgPrefix: "team/"}
+
+	tests := []struct {
+		name     string
+		host     Host
+		input    string
+		wantPath string
+		wantStr  string
+		wantSSH  string
+		wantHTTP string
+		wantErr  bool
+	}{
+		{
+			name:     "user/project",
+			host:     host,
+			input:    "infra/app",
+			wantPath: "/root/git.corp.com/infra/app",
+			wantStr:  "git.corp.com/infra/app",
+			wantSSH:  "gitlab@git.corp.com:infra/app.git",
+			wantHTTP: "https://git.corp.com/infra/app.git",
+		},
+		{
+			name:     "default organisation prefix",
+			host:     host,
+			input:    "app",
+			wantPath: "/root/git.corp.com/team/app",
+			wantStr:  "git.corp.com/team/app",
+			wantSSH:  "gitlab@git.corp.com:team/app.git",
+			wantHTTP: "https://git.corp.com/team/app.git",
+		},
+		{
+			name:     "default SSH user",
+			host:     Host{Name: "git.corp.com"},
+			input:    "team/app",
+			wantPath: "/root/git.corp.com/team/app",
+			wantStr:  "git.corp.com/team/app",
+			wantSSH:  "git@git.corp.com:team/app.git",
+			wantHTTP: "https://git.corp.com/team/app.git",
+		},
+		{
+			name:    "no organisation",
+			host:    Host{Name: "git.corp.com"},
+			input:   "app",
+			wantErr: true,
+		},
+		{
+			name:    "too many segments",
+			host:    host,
+			input:   "team/app/sub",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseHostProject("/root", tt.host, tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHostProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if p.Path != tt.wantPath {
+				t.Errorf("Path = %v, want %v", p.Path, tt.wantPath)
+			}
+			if got := p.String(); got != tt.wantStr {
+				t.Errorf("String() = %v, want %v", got, tt.wantStr)
+			}
+			if got := p.GitSSHURL(); got != tt.wantSSH {
+				t.Errorf("GitSSHURL() = %v, want %v", got, tt.wantSSH)
+			}
+			if got := p.GitHTTPURL(); got != tt.wantHTTP {
+				t.Errorf("GitHTTPURL() = %v, want %v", got, tt.wantHTTP)
+			}
+		})
+	}
+}
+
 func TestProjectGitDir(t *testing.T) {
 	p := &Project{
 		Path:         "/root/user/project",
@@ -253,6 +355,9 @@ func TestWalk(t *testing.T) {
 		"user2/project3/.git",
 		"not-a-project", // Should be ignored (wrong depth)
 		"user3",         // Should be ignored (wrong depth)
+		"gitlab.com/team/app/.git",
+		"gitlab.com/team/app/src",
+		"john.doe/dotted/.git", // User with a dot, not a host
 	}
 
 	for _, dir := range testStructure {
@@ -276,9 +381,11 @@ func TestWalk(t *testing.T) {
 
 	// Verify expected projects were found
 	expectedProjects := map[string]bool{
-		"user1/project1": false,
-		"user1/project2": false,
-		"user2/project3": false,
+		"user1/project1":      false,
+		"user1/project2":      false,
+		"user2/project3":      false,
+		"gitlab.com/team/app": false,
+		"john.doe/dotted":     false,
 	}
 
 	for _, p := range foundProjects {
@@ -383,6 +490,34 @@ func TestWalkExcludesDotDirectories(t *testing.T) {
 	}
 }
 
+func TestWalkAllowHidden(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{
+		"user1/project1",
+		"user1/.dotfiles",                   // Allowed
+		"user1/.hidden-project",             // Still excluded
+		".team/tools",                       // Allowed organisation
+		".workspace/user1/project1.feature", // Still excluded
+	} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create test directory %s: %v", dir, err)
+		}
+	}
+
+	var found []string
+	err := WalkWith(tempDir, WalkOptions{AllowHidden: []string{".dotfiles", ".team"}}, func(d fs.DirEntry, p *Project) error {
+		found = append(found, p.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkWith() failed: %v", err)
+	}
+
+	if want := []string{".team/tools", "user1/.dotfiles", "user1/project1"}; !slices.Equal(found, want) {
+		t.Errorf("WalkWith() found %v, want %v", found, want)
+	}
+}
+
 func TestWalkWithError(t *testing.T) {
 	// Test walking a non-existent directory
 	err := Walk("/non-existent-directory", func(d fs.DirEntry, p *Project) error {
@@ -394,6 +529,42 @@ func TestWalkWithError(t *testing.T) {
 	}
 }
 
+func TestWalkUnreadableDirectory(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permissions are not enforced for root")
+	}
+
+	tempDir := t.TempDir()
+	for _, dir := range []string{"user1/project1", "locked/project2", "user3/project3"} {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("Failed to create test directory %s: %v", dir, err)
+		}
+	}
+	locked := filepath.Join(tempDir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	var found []string
+	err := Walk(tempDir, func(d fs.DirEntry, p *Project) error {
+		found = append(found, p.String())
+		return nil
+	})
+
+	// The other projects are walked, and the unreadable directory reported
+	if want := []string{"user1/project1", "user3/project3"}; !slices.Equal(found, want) {
+		t.Errorf("Walk() found %v, want %v", found, want)
+	}
+	var walkErrs *WalkErrors
+	if !errors.As(err, &walkErrs) {
+		t.Fatalf("Walk() error = %v, want *WalkErrors", err)
+	}
+	if len(walkErrs.Errors) != 1 || !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("Walk() errors = %v, want one permission error", walkErrs.Errors)
+	}
+}
+
 func TestWalkWithCallbackError(t *testing.T) {
 	// Create temporary directory
 	tempDir, err := os.MkdirTemp("", "project-test-*")
@@ -442,6 +613,13 @@ func TestFindFromPath(t *testing.T) {
 		".workspace/user1/project1/feature-branch/src/pkg", // deeply nested
 		".workspace/user2/project2",
 		".workspace/user1",
+		"user1/bare-project/.bare",
+		"user1/bare-project/main/src",
+		"gitlab.com/team/app/.git",
+		"gitlab.com/team/app/src",
+		".workspace/gitlab.com/team/app/feature",
+		"john.doe/dotted/.git",
+		"john.doe/scratch/notes/drafts", // Plain directory of a user with a dot
 	}
 
 	for _, project := range testProjects {
@@ -478,6 +656,33 @@ func TestFindFromPath(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "find host project from subdirectory",
+			path: filepath.Join(tempDir, "gitlab.com/team/app/src"),
+			expected: &Project{
+				Path:         filepath.Join(tempDir, "gitlab.com/team/app"),
+				Name:         "app",
+				Organisation: "team",
+			},
+		},
+		{
+			name: "find host project from workspace directory",
+			path: filepath.Join(tempDir, ".workspace/gitlab.com/team/app/feature"),
+			expected: &Project{
+				Path:         filepath.Join(tempDir, "gitlab.com/team/app"),
+				Name:         "app",
+				Organisation: "team",
+			},
+		},
+		{
+			name: "find project of a user with a dot",
+			path: filepath.Join(tempDir, "john.doe/scratch/notes/drafts"),
+			expected: &Project{
+				Path:         filepath.Join(tempDir, "john.doe/scratch"),
+				Name:         "scratch",
+				Organisation: "john.doe",
+			},
+		},
 		{
 			name:        "path outside root directory",
 			path:        "/tmp/outside",
@@ -558,6 +763,16 @@ func TestFindFromPath(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "bare layout worktree",
+			path: filepath.Join(tempDir, "user1/bare-project/main/src"),
+			expected: &Project{
+				Path:         filepath.Join(tempDir, "user1/bare-project"),
+				Name:         "bare-project",
+				Organisation: "user1",
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -595,3 +810,81 @@ func TestFindFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected string
+	}{
+		{name: "go module", files: []string{"go.mod"}, expected: "go"},
+		{name: "node package", files: []string{"package.json"}, expected: "javascript"},
+		{name: "precedence", files: []string{"package.json", "go.mod"}, expected: "go"},
+		{name: "no marker", files: []string{"main.c.txt"}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+					t.Fatalf("failed to create %s: %v", f, err)
+				}
+			}
+
+			if got := DetectLanguage(dir); got != tt.expected {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDetectLanguages(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"package.json", "go.mod", "setup.py", "requirements.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", f, err)
+		}
+	}
+
+	got := DetectLanguages(dir)
+	if want := []string{"go", "javascript", "python"}; !slices.Equal(got, want) {
+		t.Errorf("DetectLanguages() = %v, want %v", got, want)
+	}
+	if got := DetectLanguages(t.TempDir()); len(got) != 0 {
+		t.Errorf("DetectLanguages() without marker = %v, want none", got)
+	}
+}
+
+func TestIsHostDir(t *testing.T) {
+	rootDir := t.TempDir()
+	for _, dir := range []string{
+		"gitlab.com/team/app/.git",
+		"git.corp.com/team",
+		"john.doe/dotted/.git",
+		"john.doe/scratch/notes",
+	} {
+		if err := os.MkdirAll(filepath.Join(rootDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		dir, sub string
+		hosts    []string
+		want     bool
+	}{
+		{"gitlab.com", "team", nil, true},
+		{"git.corp.com", "team", nil, false},
+		{"git.corp.com", "team", []string{"git.corp.com"}, true},
+		{"john.doe", "dotted", nil, false},
+		{"john.doe", "scratch", nil, false},
+		{"user1", "project", []string{"user1"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHostDir(rootDir, tt.dir, tt.sub, tt.hosts); got != tt.want {
+			t.Errorf("IsHostDir(%q, %q, %v) = %v, want %v", tt.dir, tt.sub, tt.hosts, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,15 @@ package query
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"path/filepath"
-	"runtime"
 	"sort"
 	"strings"
 
+	"github.com/gfanton/projects/internal/match"
 	"github.com/gfanton/projects/internal/project"
 	"github.com/gfanton/projects/internal/workspace"
 	"github.com/lithammer/fuzzysearch/fuzzy"
@@ -26,14 +27,6 @@ const (
 	distanceBranchFuzzy   = 20
 )
 
-// pathsEqual compares paths with case-insensitivity on macOS/Windows.
-func pathsEqual(a, b string) bool {
-	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
-		return strings.EqualFold(a, b)
-	}
-	return a == b
-}
-
 // Options holds configuration for project queries.
 type Options struct {
 	Query          string
@@ -69,6 +62,8 @@ func NewService(logger *slog.Logger, rootDir string) *Service {
 }
 
 // Search searches for projects and workspaces matching the given options.
+// When directories of the root directory can't be read, the results found
+// in the others are returned with a *project.WalkErrors error.
 func (s *Service) Search(ctx context.Context, opts Options) ([]*Result, error) {
 	s.logger.Debug("searching projects and workspaces",
 		"query", opts.Query,
@@ -88,7 +83,7 @@ func (s *Service) Search(ctx context.Context, opts Options) ([]*Result, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid exclude path '%s': %w", exclude, err)
 		}
-		excludeMap[abs] = true
+		excludeMap[match.Path(abs)] = true
 	}
 
 	// Check if query contains workspace syntax (contains ':')
@@ -104,12 +99,12 @@ func (s *Service) Search(ctx context.Context, opts Options) ([]*Result, error) {
 func (s *Service) searchProjects(ctx context.Context, opts Options, excludeMap map[string]bool) ([]*Result, error) {
 	var results []*Result
 
-	qLower := strings.ToLower(opts.Query)
+	qLower := match.Normalize(opts.Query)
 	qOrg, qName, qHasOrg := strings.Cut(qLower, "/")
 
 	err := project.Walk(s.rootDir, func(d fs.DirEntry, p *project.Project) error {
 		// Check if project should be excluded
-		if excludeMap[p.Path] {
+		if excludeMap[match.Path(p.Path)] {
 			s.logger.Debug("excluding project", "path", p.Path)
 			return filepath.SkipDir
 		}
@@ -125,13 +120,12 @@ func (s *Service) searchProjects(ctx context.Context, opts Options, excludeMap m
 
 		// Calculate match distance
 		projectName := p.String()
-		distance := fuzzy.RankMatchFold(opts.Query, projectName)
+		projectLower := match.Normalize(projectName)
+		distance := fuzzy.RankMatch(qLower, projectLower)
 		if distance < 0 {
 			return nil
 		}
 
-		projectLower := strings.ToLower(projectName)
-
 		// Split project name into parts (org/name)
 		pOrg, pName, _ := strings.Cut(projectLower, "/")
 
@@ -143,7 +137,7 @@ func (s *Service) searchProjects(ctx context.Context, opts Options, excludeMap m
 			if qName == pName {
 				distance = 0
 			} else {
-				distance = fuzzy.RankMatchFold(qName, pName)
+				distance = fuzzy.RankMatch(qName, pName)
 			}
 		} else {
 			switch {
@@ -152,11 +146,11 @@ func (s *Service) searchProjects(ctx context.Context, opts Options, excludeMap m
 			case qLower == pOrg:
 				distance = distanceExactOrg
 			case strings.Contains(pName, qLower):
-				distance = distanceNameContains + fuzzy.RankMatchFold(qLower, pName)
+				distance = distanceNameContains + fuzzy.RankMatch(qLower, pName)
 			case strings.Contains(pOrg, qLower):
-				distance = distanceOrgContains + fuzzy.RankMatchFold(qLower, pOrg)
+				distance = distanceOrgContains + fuzzy.RankMatch(qLower, pOrg)
 			default:
-				distance = distanceFuzzyFallback + fuzzy.RankMatchFold(qLower, projectLower)
+				distance = distanceFuzzyFallback + fuzzy.RankMatch(qLower, projectLower)
 			}
 		}
 
@@ -174,11 +168,12 @@ func (s *Service) searchProjects(ctx context.Context, opts Options, excludeMap m
 		return nil
 	})
 
-	if err != nil {
+	var walkErrs *project.WalkErrors
+	if err != nil && !errors.As(err, &walkErrs) {
 		return nil, fmt.Errorf("failed to walk projects: %w", err)
 	}
 
-	return s.sortAndLimitResults(results, opts), nil
+	return s.sortAndLimitResults(results, opts), err
 }
 
 func (s *Service) searchWorkspaces(ctx context.Context, opts Options, excludeMap map[string]bool) ([]*Result, error) {
@@ -193,19 +188,19 @@ func (s *Service) searchWorkspaces(ctx context.Context, opts Options, excludeMap
 
 	err := project.Walk(s.rootDir, func(d fs.DirEntry, p *project.Project) error {
 		// Check if project should be excluded
-		if excludeMap[p.Path] {
+		if excludeMap[match.Path(p.Path)] {
 			s.logger.Debug("excluding project", "path", p.Path)
 			return filepath.SkipDir
 		}
 
 		// If project part is specified, check if this project matches
 		if projectPart != "" {
-			projectName := strings.ToLower(p.String())
+			projectName := match.Normalize(p.String())
 			if !s.matchesProject(projectPart, projectName) {
 				return nil
 			}
 		} else if opts.CurrentProject != nil {
-			if !pathsEqual(p.Path, opts.CurrentProject.Path) {
+			if !match.PathsEqual(p.Path, opts.CurrentProject.Path) {
 				return nil
 			}
 		}
@@ -238,15 +233,16 @@ func (s *Service) searchWorkspaces(ctx context.Context, opts Options, excludeMap
 		return nil
 	})
 
-	if err != nil {
+	var walkErrs *project.WalkErrors
+	if err != nil && !errors.As(err, &walkErrs) {
 		return nil, fmt.Errorf("failed to walk projects: %w", err)
 	}
 
-	return s.sortAndLimitResults(results, opts), nil
+	return s.sortAndLimitResults(results, opts), err
 }
 
 func (s *Service) matchesProject(query, projectName string) bool {
-	queryLower := strings.ToLower(query)
+	queryLower := match.Normalize(query)
 
 	// Exact match
 	if projectName == queryLower {
@@ -254,12 +250,12 @@ func (s *Service) matchesProject(query, projectName string) bool {
 	}
 
 	// Fuzzy match
-	return fuzzy.MatchFold(queryLower, projectName)
+	return fuzzy.Match(queryLower, projectName)
 }
 
 func (s *Service) matchesBranch(query, branchName string) bool {
-	queryLower := strings.ToLower(query)
-	branchLower := strings.ToLower(branchName)
+	queryLower := match.Normalize(query)
+	branchLower := match.Normalize(branchName)
 
 	// Exact match
 	if branchLower == queryLower {
@@ -272,7 +268,7 @@ func (s *Service) matchesBranch(query, branchName string) bool {
 	}
 
 	// Fuzzy match
-	return fuzzy.MatchFold(queryLower, branchName)
+	return fuzzy.Match(queryLower, branchLower)
 }
 
 func (s *Service) calculateWorkspaceDistance(projectQuery, branchQuery, projectName, branchName string) int {
@@ -280,8 +276,8 @@ func (s *Service) calculateWorkspaceDistance(projectQuery, branchQuery, projectN
 
 	// Project matching distance
 	if projectQuery != "" {
-		projectLower := strings.ToLower(projectName)
-		queryLower := strings.ToLower(projectQuery)
+		projectLower := match.Normalize(projectName)
+		queryLower := match.Normalize(projectQuery)
 
 		switch {
 		case projectLower == queryLower:
@@ -289,14 +285,14 @@ func (s *Service) calculateWorkspaceDistance(projectQuery, branchQuery, projectN
 		case strings.Contains(projectLower, queryLower):
 			distance += distanceNameContains
 		default:
-			distance += distanceFuzzyFallback + fuzzy.RankMatchFold(projectQuery, projectName)
+			distance += distanceFuzzyFallback + fuzzy.RankMatch(queryLower, projectLower)
 		}
 	}
 
 	// Branch matching distance
 	if branchQuery != "" {
-		branchLower := strings.ToLower(branchName)
-		queryLower := strings.ToLower(branchQuery)
+		branchLower := match.Normalize(branchName)
+		queryLower := match.Normalize(branchQuery)
 
 		switch {
 		case branchLower == queryLower:
@@ -304,7 +300,7 @@ func (s *Service) calculateWorkspaceDistance(projectQuery, branchQuery, projectN
 		case strings.Contains(branchLower, queryLower):
 			distance += distanceBranchSubstr
 		default:
-			distance += distanceBranchFuzzy + fuzzy.RankMatchFold(branchQuery, branchName)
+			distance += distanceBranchFuzzy + fuzzy.RankMatch(queryLower, branchLower)
 		}
 	}
 
@@ -355,7 +351,7 @@ func (s *Service) Format(results []*Result, opts Options) string {
 			if result.Workspace != "" {
 				// For bare workspace queries from current project, return :branch format
 				// This allows shell completion to work when user types "p :"
-				if isBareWorkspaceQuery && pathsEqual(result.Project.Path, opts.CurrentProject.Path) {
+				if isBareWorkspaceQuery && match.PathsEqual(result.Project.Path, opts.CurrentProject.Path) {
 					path = ":" + result.Workspace
 				} else {
 					// For workspace results, return project:branch format
@@ -0,0 +1,122 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GoModule makes the Go project at dir the module named module, such as
+// github.com/org/name: go.mod is created with 'go mod init' when missing,
+// and the module path of an existing one, such as the one of the template
+// repository, is replaced in go.mod and in the imports of the Go files. It
+// returns false when dir is not a Go project, having neither go.mod nor Go
+// files.
+func GoModule(ctx context.Context, dir, module string) (bool, error) {
+	gomod := filepath.Join(dir, "go.mod")
+	data, err := os.ReadFile(gomod)
+	if errors.Is(err, os.ErrNotExist) {
+		if !hasGoFiles(dir) {
+			return false, nil
+		}
+		return true, goModInit(ctx, dir, module)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+
+	old := modulePath(string(data))
+	if old == "" || old == module {
+		return true, nil
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	for i, line := range lines {
+		if modulePath(line) == old {
+			lines[i] = strings.Replace(line, old, module, 1)
+			break
+		}
+	}
+	if err := os.WriteFile(gomod, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return true, fmt.Errorf("failed to write go.mod: %w", err)
+	}
+
+	// Imports of the packages of the module, quoted or with a subpackage
+	replacer := strings.NewReplacer(`"`+old+`"`, `"`+module+`"`, `"`+old+`/`, `"`+module+`/`)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == "vendor") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".go") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if updated := replacer.Replace(string(data)); updated != string(data) {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, []byte(updated), info.Mode().Perm())
+		}
+		return nil
+	})
+	if err != nil {
+		return true, fmt.Errorf("failed to update imports of %s: %w", old, err)
+	}
+	return true, nil
+}
+
+// modulePath returns the module path declared by the module directive of
+// the go.mod content, or an empty string.
+func modulePath(gomod string) string {
+	for _, line := range strings.Split(gomod, "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
+}
+
+// goModInit runs 'go mod init module' in dir.
+func goModInit(ctx context.Context, dir, module string) error {
+	cmd := exec.CommandContext(ctx, "go", "mod", "init", module)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("go not found: install Go to initialize the module %s", module)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run go mod init %s: %w\n%s", module, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// hasGoFiles reports whether dir holds Go files, outside of .git.
+func hasGoFiles(dir string) bool {
+	found := false
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || found {
+			return filepath.SkipAll
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		found = !d.IsDir() && strings.HasSuffix(d.Name(), ".go")
+		return nil
+	})
+	return found
+}
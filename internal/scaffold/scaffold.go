@@ -0,0 +1,140 @@
+// Package scaffold creates the files of new projects from templates: a local
+// directory or a Git repository whose files are copied, replacing the
+// {{name}} placeholders of the template variables.
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// scpPattern matches scp-like Git URLs, such as git@github.com:org/name.
+	scpPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:`)
+	// shorthandPattern matches GitHub "user/repo" sources.
+	shorthandPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*/[A-Za-z0-9._-]+$`)
+)
+
+// IsRemote reports whether source is a Git repository: a URL, an scp-like
+// address or a GitHub "user/repo", rather than a local directory.
+func IsRemote(source string) bool {
+	return strings.Contains(source, "://") || scpPattern.MatchString(source) || shorthandPattern.MatchString(source)
+}
+
+// RepositoryURL returns the clone URL of the remote source, expanding
+// GitHub "user/repo" sources.
+func RepositoryURL(source string) string {
+	if shorthandPattern.MatchString(source) {
+		return "https://github.com/" + strings.TrimSuffix(source, ".git") + ".git"
+	}
+	return source
+}
+
+// Fetch returns the directory holding the files of source: source itself
+// for a local directory, or a shallow clone of the repository in a
+// temporary directory, deleted by cleanup.
+func Fetch(ctx context.Context, source string) (dir string, cleanup func(), err error) {
+	if !IsRemote(source) {
+		info, err := os.Stat(source)
+		if err != nil {
+			return "", nil, fmt.Errorf("template source: %w", err)
+		}
+		if !info.IsDir() {
+			return "", nil, fmt.Errorf("template source %s is not a directory", source)
+		}
+		return source, func() {}, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "proj-template-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(tmp) }
+
+	dir = filepath.Join(tmp, "template")
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth", "1", RepositoryURL(source), dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to clone template %s: %w\n%s", source, err, strings.TrimSpace(string(output)))
+	}
+	return dir, cleanup, nil
+}
+
+// Copy copies the files of src into dst, leaving out the .git directory, and
+// replaces the {{name}} placeholders of vars in the file names and in the
+// contents of text files. Other {{...}} sequences are kept as is. It returns
+// the number of files created.
+func Copy(src, dst string, vars map[string]string) (int, error) {
+	pairs := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		pairs = append(pairs, "{{"+name+"}}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	files := 0
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if d.Name() == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, replacer.Replace(rel))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files++
+			return os.Symlink(link, target)
+		case !d.Type().IsRegular():
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !isBinary(data) {
+			data = []byte(replacer.Replace(string(data)))
+		}
+		files++
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+	if err != nil {
+		return files, fmt.Errorf("failed to copy template: %w", err)
+	}
+	return files, nil
+}
+
+// isBinary reports whether data looks like the content of a binary file, a
+// NUL byte appearing in its first 8000 bytes as for git.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
@@ -0,0 +1,152 @@
+package scaffold
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsRemote(t *testing.T) {
+	tests := []struct {
+		source string
+		remote bool
+		url    string
+	}{
+		{source: "https://github.com/gfanton/go-template.git", remote: true, url: "https://github.com/gfanton/go-template.git"},
+		{source: "git@github.com:gfanton/go-template.git", remote: true, url: "git@github.com:gfanton/go-template.git"},
+		{source: "gfanton/go-template", remote: true, url: "https://github.com/gfanton/go-template.git"},
+		{source: "/home/user/templates/go", remote: false},
+		{source: "./templates/go", remote: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			if got := IsRemote(tt.source); got != tt.remote {
+				t.Errorf("IsRemote(%q) = %v, want %v", tt.source, got, tt.remote)
+			}
+			if tt.remote {
+				if got := RepositoryURL(tt.source); got != tt.url {
+					t.Errorf("RepositoryURL(%q) = %q, want %q", tt.source, got, tt.url)
+				}
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"README.md":               "# {{project}}\n\nBy {{author}}, {{unknown}} kept.\n",
+		"cmd/{{project}}/main.go": "package main // {{project}}\n",
+		".git/HEAD":               "ref: refs/heads/main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	binary := []byte("{{project}}\x00\x01")
+	if err := os.WriteFile(filepath.Join(src, "logo.png"), binary, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	n, err := Copy(src, dst, map[string]string{"project": "myapp", "author": "gfanton"})
+	if err != nil {
+		t.Fatalf("Copy() failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Copy() = %d files, want 3", n)
+	}
+
+	want := map[string]string{
+		"README.md":         "# myapp\n\nBy gfanton, {{unknown}} kept.\n",
+		"cmd/myapp/main.go": "package main // myapp\n",
+		"logo.png":          string(binary),
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Errorf("ReadFile(%s) failed: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".git")); !os.IsNotExist(err) {
+		t.Errorf(".git copied: %v", err)
+	}
+}
+
+func TestGoModule(t *testing.T) {
+	write := func(t *testing.T, dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(t *testing.T, dir, name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	t.Run("template module", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "go.mod", "module github.com/gfanton/go-template // template\n\ngo 1.25\n")
+		write(t, dir, "main.go", "package main\n\nimport (\n\t\"github.com/gfanton/go-template/internal/app\"\n\t\"github.com/gfanton/go-template-extra\"\n)\n")
+
+		isGo, err := GoModule(context.Background(), dir, "github.com/me/tool")
+		if err != nil || !isGo {
+			t.Fatalf("GoModule() = %v, %v, want true", isGo, err)
+		}
+		if got, want := read(t, dir, "go.mod"), "module github.com/me/tool // template\n\ngo 1.25\n"; got != want {
+			t.Errorf("go.mod = %q, want %q", got, want)
+		}
+		// Other modules sharing the prefix are left alone
+		if got, want := read(t, dir, "main.go"), "package main\n\nimport (\n\t\"github.com/me/tool/internal/app\"\n\t\"github.com/gfanton/go-template-extra\"\n)\n"; got != want {
+			t.Errorf("main.go = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("not a Go project", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "README.md", "# tool\n")
+
+		if isGo, err := GoModule(context.Background(), dir, "github.com/me/tool"); err != nil || isGo {
+			t.Errorf("GoModule() = %v, %v, want false", isGo, err)
+		}
+	})
+
+	t.Run("go mod init", func(t *testing.T) {
+		if _, err := exec.LookPath("go"); err != nil {
+			t.Skip("go not available")
+		}
+		// Flags of the test run, such as -modfile, don't apply to the new module
+		t.Setenv("GOFLAGS", "")
+		dir := t.TempDir()
+		write(t, dir, "cmd/tool/main.go", "package main\n\nfunc main() {}\n")
+
+		isGo, err := GoModule(context.Background(), dir, "github.com/me/tool")
+		if err != nil || !isGo {
+			t.Fatalf("GoModule() = %v, %v, want true", isGo, err)
+		}
+		if got := modulePath(read(t, dir, "go.mod")); got != "github.com/me/tool" {
+			t.Errorf("module = %q, want github.com/me/tool", got)
+		}
+	})
+}
@@ -41,6 +41,11 @@ func TestShellIntegration(t *testing.T) {
 			shell:    "zsh",
 			testFunc: testZshInitScript,
 		},
+		{
+			name:     "elvish_init_script",
+			shell:    "elvish",
+			testFunc: testElvishInitScript,
+		},
 	}
 
 	for _, tt := range tests {
@@ -121,6 +126,38 @@ func testZshInitScript(t *testing.T, projectBin string) {
 	}
 }
 
+func testElvishInitScript(t *testing.T, projectBin string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Generate elvish init script
+	cmd := exec.CommandContext(ctx, projectBin, "init", "elvish")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to generate elvish init script: %v", err)
+	}
+
+	script := string(output)
+
+	expectedComponents := []string{
+		"fn __project_p",
+		"fn __project_complete",
+		"edit:add-var p~",
+		"edit:completion:arg-completer[p]",
+	}
+
+	for _, component := range expectedComponents {
+		if !strings.Contains(script, component) {
+			t.Errorf("elvish init script missing component: %s", component)
+		}
+	}
+
+	// Verify template substitution worked (should contain actual project binary path)
+	if !strings.Contains(script, projectBin) {
+		t.Errorf("elvish init script should contain project binary path %s", projectBin)
+	}
+}
+
 func testZshCompletion(t *testing.T, projectBin string) {
 	// Create temporary test environment
 	testDir := createTestEnvironment(t, projectBin)
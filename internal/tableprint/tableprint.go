@@ -0,0 +1,250 @@
+// Package tableprint writes column-aligned tables like text/tabwriter, but
+// measures cells by their visible width, ignoring ANSI color sequences and
+// counting wide characters twice, and fits lines in the terminal width by
+// truncating the cells of one column.
+package tableprint
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// gap is the number of spaces between columns.
+const gap = 2
+
+// minShrinkWidth is the width the Shrink column is never truncated below.
+const minShrinkWidth = 8
+
+// Table is a table whose columns are as wide as their widest cell. The last
+// column is not padded.
+type Table struct {
+	// Indent prefixes each line.
+	Indent string
+
+	// MaxWidth is the width lines are fitted in by truncating the cells of
+	// the Shrink column, such as the result of TerminalWidth. Zero
+	// disables truncation.
+	MaxWidth int
+
+	// Shrink is the index of the column truncated when lines are wider
+	// than MaxWidth.
+	Shrink int
+
+	// Right lists the indexes of the columns aligned to the right, such as
+	// sizes and counts.
+	Right []int
+
+	rows []row
+}
+
+// row is a line of the table: cells, or a note written as is.
+type row struct {
+	cells []string
+	note  string
+}
+
+// New returns a table whose first row is header, or without header when
+// none is given.
+func New(header ...string) *Table {
+	t := &Table{}
+	if len(header) > 0 {
+		t.Append(header...)
+	}
+	return t
+}
+
+// Append adds a row of cells.
+func (t *Table) Append(cells ...string) {
+	t.rows = append(t.rows, row{cells: cells})
+}
+
+// Note adds a line written as is after Indent, below the last row and
+// outside of the columns, such as the details of a row.
+func (t *Table) Note(line string) {
+	t.rows = append(t.rows, row{note: line})
+}
+
+// Len returns the number of rows of the table, notes excluded.
+func (t *Table) Len() int {
+	n := 0
+	for _, r := range t.rows {
+		if r.note == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Write writes the table to w.
+func (t *Table) Write(w io.Writer) error {
+	widths := t.widths()
+
+	var b, line strings.Builder
+	for _, r := range t.rows {
+		b.WriteString(t.Indent)
+		if r.note != "" || r.cells == nil {
+			b.WriteString(r.note)
+			b.WriteByte('\n')
+			continue
+		}
+
+		// Rows ending with empty cells are not padded up to them.
+		line.Reset()
+		for i, cell := range r.cells {
+			cellWidth := Width(cell)
+			if cellWidth > widths[i] {
+				cell = Truncate(cell, widths[i])
+				cellWidth = Width(cell)
+			}
+
+			padding := widths[i] - cellWidth
+			last := i == len(r.cells)-1
+			switch {
+			case t.right(i):
+				line.WriteString(strings.Repeat(" ", padding))
+				line.WriteString(cell)
+			case last:
+				line.WriteString(cell)
+			default:
+				line.WriteString(cell)
+				line.WriteString(strings.Repeat(" ", padding))
+			}
+			if !last {
+				line.WriteString(strings.Repeat(" ", gap))
+			}
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// widths returns the width of each column, the Shrink one reduced for the
+// lines to fit in MaxWidth.
+func (t *Table) widths() []int {
+	var widths []int
+	for _, r := range t.rows {
+		for i, cell := range r.cells {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], Width(cell))
+		}
+	}
+
+	if t.MaxWidth <= 0 || t.Shrink < 0 || t.Shrink >= len(widths) {
+		return widths
+	}
+
+	total := Width(t.Indent) + gap*(len(widths)-1)
+	for _, w := range widths {
+		total += w
+	}
+	if excess := total - t.MaxWidth; excess > 0 {
+		widths[t.Shrink] = max(widths[t.Shrink]-excess, min(widths[t.Shrink], minShrinkWidth))
+	}
+	return widths
+}
+
+func (t *Table) right(column int) bool {
+	for _, i := range t.Right {
+		if i == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Width returns the number of terminal columns s takes: its characters,
+// wide ones counting twice, without its ANSI escape sequences.
+func Width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if end := escapeEnd(s, i); end > i {
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n += runeWidth(r)
+		i += size
+	}
+	return n
+}
+
+// Truncate returns s shortened to n terminal columns, the last one being an
+// ellipsis, keeping its ANSI escape sequences and resetting the colors
+// when it has any.
+func Truncate(s string, n int) string {
+	if Width(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	escaped := false
+	visible := 0
+	for i := 0; i < len(s); {
+		if end := escapeEnd(s, i); end > i {
+			b.WriteString(s[i:end])
+			escaped = true
+			i = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if visible+runeWidth(r) > n-1 {
+			break
+		}
+		b.WriteRune(r)
+		visible += runeWidth(r)
+		i += size
+	}
+	b.WriteString("…")
+	if escaped {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// escapeEnd returns the end of the ANSI CSI sequence, such as a color,
+// starting at s[i], or i when there is none.
+func escapeEnd(s string, i int) int {
+	if !strings.HasPrefix(s[i:], "\x1b[") {
+		return i
+	}
+	for j := i + 2; j < len(s); j++ {
+		if c := s[j]; c >= 0x40 && c <= 0x7e {
+			return j + 1
+		}
+	}
+	return i
+}
+
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// TerminalWidth returns the number of columns of the terminal f is
+// connected to, from $COLUMNS when set, or 0 when f is not a terminal.
+func TerminalWidth(f *os.File) int {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return terminalWidth(f)
+}
@@ -0,0 +1,165 @@
+package tableprint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "projects", 8},
+		{"colored", "\x1b[1;33mproj\x1b[0mects", 8},
+		{"accented", "café", 4},
+		{"wide", "日本", 4},
+		{"unterminated escape", "\x1b[", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Width(tt.s); got != tt.want {
+				t.Errorf("Width(%q) = %d, want %d", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{"fits", "projects", 8, "projects"},
+		{"truncated", "projects", 5, "proj…"},
+		{"zero", "projects", 0, ""},
+		{"colored", "\x1b[36mprojects\x1b[0m", 5, "\x1b[36mproj…\x1b[0m"},
+		{"wide", "日本語", 4, "日…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.n)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+			}
+			if Width(got) > tt.n {
+				t.Errorf("Width(Truncate(%q, %d)) = %d", tt.s, tt.n, Width(got))
+			}
+		})
+	}
+}
+
+func TestTableWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		table func() *Table
+		want  string
+	}{
+		{
+			name: "aligned",
+			table: func() *Table {
+				t := New("NAME", "BRANCH", "STATUS")
+				t.Append("gfanton/projects", "main", "clean")
+				t.Append("acme/api", "feature/login", "dirty")
+				return t
+			},
+			want: "" +
+				"NAME              BRANCH         STATUS\n" +
+				"gfanton/projects  main           clean\n" +
+				"acme/api          feature/login  dirty\n",
+		},
+		{
+			name: "colors ignored",
+			table: func() *Table {
+				t := New()
+				t.Append("\x1b[36macme\x1b[0m", "x")
+				t.Append("gfanton", "y")
+				return t
+			},
+			want: "" +
+				"\x1b[36macme\x1b[0m     x\n" +
+				"gfanton  y\n",
+		},
+		{
+			name: "right aligned",
+			table: func() *Table {
+				t := New()
+				t.Right = []int{1}
+				t.Append("acme", "3 projects", "2.0 GiB")
+				t.Append("user", "12 projects", "1.0 KiB")
+				return t
+			},
+			want: "" +
+				"acme   3 projects  2.0 GiB\n" +
+				"user  12 projects  1.0 KiB\n",
+		},
+		{
+			name: "indent and notes",
+			table: func() *Table {
+				t := New()
+				t.Indent = "  "
+				t.Append("main", "/code/a")
+				t.Note("  submodule lib")
+				t.Append("feature", "/code/b")
+				return t
+			},
+			want: "" +
+				"  main     /code/a\n" +
+				"    submodule lib\n" +
+				"  feature  /code/b\n",
+		},
+		{
+			name: "empty last cells",
+			table: func() *Table {
+				t := New()
+				t.Append("dev", "/code/a", "")
+				t.Append("feature", "/code/b", "#12")
+				return t
+			},
+			want: "" +
+				"dev      /code/a\n" +
+				"feature  /code/b  #12\n",
+		},
+		{
+			name: "shrunk",
+			table: func() *Table {
+				t := New()
+				t.MaxWidth = 20
+				t.Append("a-very-long-project-name", "main")
+				t.Append("short", "dev")
+				return t
+			},
+			want: "" +
+				"a-very-long-p…  main\n" +
+				"short           dev\n",
+		},
+		{
+			name: "shrunk to the minimum",
+			table: func() *Table {
+				t := New()
+				t.MaxWidth = 10
+				t.Append("a-very-long-project-name", "main")
+				return t
+			},
+			want: "a-very-…  main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := tt.table().Write(&out); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Write() =\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly)
+
+package tableprint
+
+import "os"
+
+// terminalWidth reports no terminal width on this platform: lines are only
+// fitted with $COLUMNS.
+func terminalWidth(f *os.File) int {
+	return 0
+}
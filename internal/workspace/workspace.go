@@ -47,7 +47,11 @@ func (s *Service) WorkspaceDir() string {
 
 func (s *Service) WorkspacePath(proj project.Project, branch string) string {
 	encoded := encodeBranch(branch)
-	return filepath.Join(s.WorkspaceDir(), proj.Organisation, proj.Name, encoded)
+	var host string
+	if proj.Host != nil {
+		host = proj.Host.Name
+	}
+	return filepath.Join(s.WorkspaceDir(), host, proj.Organisation, proj.Name, encoded)
 }
 
 // isPullRequest checks if the branch string is a PR number (#123 format)
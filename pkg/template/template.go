@@ -10,11 +10,28 @@ import (
 //go:embed *.init
 var templates embed.FS
 
+// DefaultCmd is the default name of the user-facing navigation command.
+const DefaultCmd = "p"
+
 // Data holds template data for shell initialization.
 type Data struct {
-	Exec string // Path to the project executable
+	Exec      string // Path to the project executable
+	Cmd       string // Name of the user-facing navigation command (default: DefaultCmd)
+	NoAliases bool   // Skip defining the user-facing command and its completion
+	Prompt    string // Prompt integration to wire the prompt segment into (PromptNames), empty for none
+	Idle      bool   // Record the use of workspaces on directory change and remind of the idle ones on startup
+	WarmCache bool   // Warm the caches of completions in the background on startup
 }
 
+// Prompt integrations.
+const (
+	PromptStarship = "starship"
+	PromptP10k     = "p10k"
+)
+
+// PromptNames lists the supported prompt integrations.
+var PromptNames = []string{PromptStarship, PromptP10k}
+
 // Render renders the specified template with the given data.
 func Render(name string, data Data) (string, error) {
 	tmplData, err := templates.ReadFile(name + ".init")
@@ -27,6 +44,10 @@ func Render(name string, data Data) (string, error) {
 		return "", fmt.Errorf("parse template %s: %w", name, err)
 	}
 
+	if data.Cmd == "" {
+		data.Cmd = DefaultCmd
+	}
+
 	var buf strings.Builder
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("execute template %s: %w", name, err)
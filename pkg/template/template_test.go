@@ -82,6 +82,8 @@ func TestRenderBasicStructure(t *testing.T) {
 		"function __project_p()",
 		"function p()",
 		"function _p()",
+		"function __project_complete_workspaces()",
+		`"$1" --print-path "${@:2}"`,
 	}
 
 	for _, element := range basicElements {
@@ -155,3 +157,122 @@ func TestRenderSpecialCharacters(t *testing.T) {
 		t.Error("Template should handle paths with special characters")
 	}
 }
+
+func TestRenderCustomCmd(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        Data
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "default command",
+			data:     Data{Exec: "/bin/proj"},
+			contains: []string{"function p()", "function _p()", "compdef _p p"},
+		},
+		{
+			name:        "custom command",
+			data:        Data{Exec: "/bin/proj", Cmd: "j"},
+			contains:    []string{"function j()", "function _j()", "compdef _j j"},
+			notContains: []string{"function p()"},
+		},
+		{
+			name:        "no aliases",
+			data:        Data{Exec: "/bin/proj", NoAliases: true},
+			contains:    []string{"function __project_p()", "function _p()"},
+			notContains: []string{"function p()", "compdef _p p"},
+		},
+		{
+			name:        "no prompt",
+			data:        Data{Exec: "/bin/proj"},
+			notContains: []string{"PROJ_PROMPT"},
+		},
+		{
+			name:        "starship prompt",
+			data:        Data{Exec: "/bin/proj", Prompt: PromptStarship},
+			contains:    []string{`"/bin/proj" prompt`, "add-zsh-hook chpwd __project_prompt_refresh", "export PROJ_PROMPT", "[env_var.PROJ_PROMPT]"},
+			notContains: []string{"function prompt_proj()"},
+		},
+		{
+			name:        "p10k prompt",
+			data:        Data{Exec: "/bin/proj", Prompt: PromptP10k},
+			contains:    []string{"add-zsh-hook chpwd __project_prompt_refresh", "function prompt_proj()", "p10k segment"},
+			notContains: []string{"export PROJ_PROMPT", "[env_var.PROJ_PROMPT]"},
+		},
+		{
+			name:     "idle",
+			data:     Data{Exec: "/bin/proj", Idle: true},
+			contains: []string{"add-zsh-hook chpwd __project_touch", `"/bin/proj" workspace touch`, `"/bin/proj" workspace idle --remind`},
+		},
+		{
+			name:        "no idle",
+			data:        Data{Exec: "/bin/proj"},
+			notContains: []string{"workspace touch", "workspace idle"},
+		},
+		{
+			name:     "warm cache",
+			data:     Data{Exec: "/bin/proj", WarmCache: true},
+			contains: []string{`"/bin/proj" completion cache warm --background`},
+		},
+		{
+			name:        "no warm cache",
+			data:        Data{Exec: "/bin/proj"},
+			notContains: []string{"cache warm"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Render("zsh", tt.data)
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+
+			for _, s := range tt.contains {
+				if !strings.Contains(result, s) {
+					t.Errorf("Template should contain: %s", s)
+				}
+			}
+			for _, s := range tt.notContains {
+				if strings.Contains(result, s) {
+					t.Errorf("Template should not contain: %s", s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderElvish(t *testing.T) {
+	result, err := Render("elvish", Data{Exec: "/test/bin/project"})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+
+	elements := []string{
+		"var __project_exec = (external '/test/bin/project')",
+		"fn __project_cd {|dir|",
+		"fn __project_p {|@args|",
+		"fn __project_complete {|@words|",
+		"edit:add-var p~",
+		"set edit:completion:arg-completer[p] = $__project_complete~",
+		"set edit:completion:matcher[argument]",
+		"(eq $words[0] 'p')",
+		"$__project_matcher $seed",
+		"$__project_exec $args[0] --print-path $@args[1..]",
+	}
+
+	for _, element := range elements {
+		if !strings.Contains(result, element) {
+			t.Errorf("Template should contain: %s", element)
+		}
+	}
+
+	noAliases, err := Render("elvish", Data{Exec: "/test/bin/project", NoAliases: true})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+
+	if strings.Contains(noAliases, "edit:add-var p~") || strings.Contains(noAliases, "arg-completer[p]") {
+		t.Error("Template with NoAliases should not define the navigation command")
+	}
+}
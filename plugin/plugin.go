@@ -0,0 +1,213 @@
+// Package plugin helps external proj commands, proj-<name> executables run
+// by 'proj <name>', get the configuration and context resolved by proj.
+//
+// proj passes the resolved configuration through the PROJECT_* environment
+// variables, and the JSON encoded Context through a pipe whose file
+// descriptor is in PROJECT_PLUGIN_FD. Load reads whichever is available, so
+// plugins also work when run directly:
+//
+//	pctx, err := plugin.Load()
+//	if err != nil {
+//		return err
+//	}
+//	results, err := pctx.Query(ctx, logger, projects.SearchOptions{Query: "proj"})
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+)
+
+const (
+	// ProtocolVersion is the version of the Context handshake, incremented
+	// on incompatible changes.
+	ProtocolVersion = 1
+
+	// ContextFDEnv is the environment variable holding the file descriptor
+	// of the pipe the JSON Context is read from.
+	ContextFDEnv = "PROJECT_PLUGIN_FD"
+)
+
+// Context is the configuration and context resolved by proj for a plugin.
+type Context struct {
+	Protocol    int               `json:"protocol"`
+	Version     string            `json:"version,omitempty"` // version of proj, empty when run directly
+	ConfigFile  string            `json:"config_file"`
+	RootDir     string            `json:"root"`
+	RootUser    string            `json:"user"`
+	Aliases     map[string]string `json:"aliases,omitempty"`
+	Debug       bool              `json:"debug"`
+	ReadOnly    bool              `json:"read_only,omitempty"`    // operations modifying the root directory are refused
+	Strict      bool              `json:"strict,omitempty"`       // partial walks of the root directory are failures
+	AllowHidden []string          `json:"allow_hidden,omitempty"` // hidden directories walked for projects
+	Project     *Project          `json:"project,omitempty"`      // project of the working directory, if any
+
+	Sets  map[string][]string      `json:"sets,omitempty"`  // project names of the project sets
+	Hosts map[string]projects.Host `json:"hosts,omitempty"` // settings of the Git servers other than GitHub
+
+	SkipSubmodules      bool          `json:"skip_submodules,omitempty"`       // submodules aren't initialized in new workspaces
+	BranchTemplate      string        `json:"branch_template,omitempty"`       // branch of workspaces added for a ticket
+	TicketURL           string        `json:"ticket_url,omitempty"`            // URL template of the title of tickets
+	IssueBranchTemplate string        `json:"issue_branch_template,omitempty"` // branch of workspaces added for an issue
+	IdleAfter           time.Duration `json:"idle_after,omitempty"`            // unused time after which workspaces are idle
+
+	NetworkTimeout time.Duration `json:"network_timeout,omitempty"` // timeout of each Git network operation
+	RetryAttempts  int           `json:"retry_attempts,omitempty"`  // attempts of failing Git network operations
+	RetryBackoff   time.Duration `json:"retry_backoff,omitempty"`   // delay before the first retry
+}
+
+// Project is a project of the Context.
+type Project struct {
+	Organisation string `json:"organisation"`
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	Host         string `json:"host,omitempty"` // Git server of projects not on GitHub
+}
+
+// Load returns the Context passed by proj through the ContextFDEnv pipe or,
+// without pipe, the one resolved from the PROJECT_* environment variables
+// and the config file.
+func Load() (*Context, error) {
+	if fd := os.Getenv(ContextFDEnv); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': %w", ContextFDEnv, fd, err)
+		}
+
+		f := os.NewFile(uintptr(n), "proj-context")
+		if f == nil {
+			return nil, fmt.Errorf("invalid %s '%s'", ContextFDEnv, fd)
+		}
+		defer f.Close()
+		return Read(f)
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Load(nil); err != nil {
+		return nil, err
+	}
+
+	pctx := NewContext(cfg)
+	if wd, err := os.Getwd(); err == nil {
+		pctx.Project = FindProject(cfg.ProjectsConfig(), wd)
+	}
+	return pctx, nil
+}
+
+// NewContext returns the Context of the resolved configuration cfg, without
+// project nor proj version.
+func NewContext(cfg *config.Config) *Context {
+	projectsCfg := cfg.ProjectsConfig()
+	return &Context{
+		Protocol:    ProtocolVersion,
+		ConfigFile:  projectsCfg.ConfigFile,
+		RootDir:     projectsCfg.RootDir,
+		RootUser:    projectsCfg.RootUser,
+		Aliases:     projectsCfg.Aliases,
+		Debug:       projectsCfg.Debug,
+		ReadOnly:    projectsCfg.ReadOnly,
+		Strict:      projectsCfg.Strict,
+		AllowHidden: projectsCfg.AllowHidden,
+
+		Sets:  projectsCfg.Sets,
+		Hosts: projectsCfg.Hosts,
+
+		SkipSubmodules:      projectsCfg.SkipSubmodules,
+		BranchTemplate:      projectsCfg.BranchTemplate,
+		TicketURL:           projectsCfg.TicketURL,
+		IssueBranchTemplate: projectsCfg.IssueBranchTemplate,
+		IdleAfter:           projectsCfg.IdleAfter,
+
+		NetworkTimeout: projectsCfg.NetworkTimeout,
+		RetryAttempts:  projectsCfg.RetryAttempts,
+		RetryBackoff:   projectsCfg.RetryBackoff,
+	}
+}
+
+// Read decodes a JSON Context from r.
+func Read(r io.Reader) (*Context, error) {
+	var pctx Context
+	if err := json.NewDecoder(r).Decode(&pctx); err != nil {
+		return nil, fmt.Errorf("decode plugin context: %w", err)
+	}
+	if pctx.Protocol != ProtocolVersion {
+		return nil, fmt.Errorf("unsupported plugin context protocol %d (expected %d)", pctx.Protocol, ProtocolVersion)
+	}
+	return &pctx, nil
+}
+
+// Write encodes c as JSON to w.
+func (c *Context) Write(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		return fmt.Errorf("encode plugin context: %w", err)
+	}
+	return nil
+}
+
+// Config returns the projects configuration of c, the one NewContext was
+// given resolves to.
+func (c *Context) Config() *projects.Config {
+	return &projects.Config{
+		ConfigFile:  c.ConfigFile,
+		Debug:       c.Debug,
+		RootDir:     c.RootDir,
+		RootUser:    c.RootUser,
+		Aliases:     c.Aliases,
+		Sets:        c.Sets,
+		Hosts:       c.Hosts,
+		ReadOnly:    c.ReadOnly,
+		Strict:      c.Strict,
+		AllowHidden: c.AllowHidden,
+
+		SkipSubmodules:      c.SkipSubmodules,
+		BranchTemplate:      c.BranchTemplate,
+		TicketURL:           c.TicketURL,
+		IssueBranchTemplate: c.IssueBranchTemplate,
+		IdleAfter:           c.IdleAfter,
+
+		NetworkTimeout: c.NetworkTimeout,
+		RetryAttempts:  c.RetryAttempts,
+		RetryBackoff:   c.RetryBackoff,
+	}
+}
+
+// Query searches projects and workspaces like 'proj query'. Workspace
+// queries are limited to the project of c when opts has no current project.
+// Like Search, it returns partial results with a *projects.WalkErrors error
+// when unreadable directories were skipped, unless c is strict.
+func (c *Context) Query(ctx context.Context, logger projects.Logger, opts projects.SearchOptions) ([]*projects.SearchResult, error) {
+	if opts.CurrentProject == nil && c.Project != nil {
+		opts.CurrentProject = &projects.Project{
+			Organisation: c.Project.Organisation,
+			Name:         c.Project.Name,
+			Path:         c.Project.Path,
+			Host:         c.Project.Host,
+		}
+	}
+	results, err := projects.NewQueryService(c.Config(), logger).Search(ctx, opts)
+	if projects.IsPartialWalk(err) && c.Strict {
+		return nil, err
+	}
+	return results, err
+}
+
+// FindProject returns the project containing path, or nil.
+func FindProject(cfg *projects.Config, path string) *Project {
+	p, err := projects.NewProjectService(cfg, projects.NewSlogAdapter(slog.New(slog.DiscardHandler))).FindFromPath(path)
+	if err != nil {
+		return nil
+	}
+	return &Project{Organisation: p.Organisation, Name: p.Name, Path: p.Path, Host: p.Host}
+}
@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gfanton/projects/internal/config"
+)
+
+func TestContextWriteRead(t *testing.T) {
+	want := &Context{
+		Protocol:   ProtocolVersion,
+		Version:    "v1.2.3",
+		ConfigFile: "/home/user/.projectrc",
+		RootDir:    "/home/user/code",
+		RootUser:   "user",
+		Project:    &Project{Organisation: "user", Name: "proj", Path: "/home/user/code/user/proj"},
+	}
+
+	var b strings.Builder
+	if err := want.Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got, err := Read(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if got.RootDir != want.RootDir || got.Version != want.Version || got.Project == nil || *got.Project != *want.Project {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewContextConfig(t *testing.T) {
+	cfg := &config.Config{
+		RootDir:                 "/home/user/code",
+		RootUser:                "user",
+		Sets:                    map[string][]string{"payments": {"user/api", "user/web"}},
+		Hosts:                   map[string]config.Host{"git.corp.com": {SSHUser: "gitlab", DefaultOrgPrefix: "team/"}},
+		WorkspaceBranchTemplate: "{user}/{ticket}-{slug}",
+		NetworkTimeout:          time.Minute,
+		RetryAttempts:           3,
+		RetryBackoff:            time.Second,
+	}
+
+	var b strings.Builder
+	if err := NewContext(cfg).Write(&b); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	pctx, err := Read(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	got, want := pctx.Config(), cfg.ProjectsConfig()
+	if got.Hosts["git.corp.com"] != want.Hosts["git.corp.com"] || len(got.Sets["payments"]) != 2 {
+		t.Errorf("Config() Hosts = %v, Sets = %v, want %v, %v", got.Hosts, got.Sets, want.Hosts, want.Sets)
+	}
+	if got.BranchTemplate != want.BranchTemplate || got.SkipSubmodules != want.SkipSubmodules {
+		t.Errorf("Config() BranchTemplate = %q, SkipSubmodules = %v, want %q, %v", got.BranchTemplate, got.SkipSubmodules, want.BranchTemplate, want.SkipSubmodules)
+	}
+	if got.NetworkTimeout != want.NetworkTimeout || got.RetryAttempts != want.RetryAttempts || got.RetryBackoff != want.RetryBackoff {
+		t.Errorf("Config() network = %v, %d, %v, want %v, %d, %v", got.NetworkTimeout, got.RetryAttempts, got.RetryBackoff, want.NetworkTimeout, want.RetryAttempts, want.RetryBackoff)
+	}
+}
+
+func TestReadProtocolMismatch(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"protocol": 99}`)); err == nil {
+		t.Error("Read() expected error for unsupported protocol")
+	}
+}
+
+func TestLoadFromPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := (&Context{Protocol: ProtocolVersion, RootDir: "/code"}).Write(w); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ContextFDEnv, strconv.Itoa(int(r.Fd())))
+
+	got, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.RootDir != "/code" {
+		t.Errorf("Load() RootDir = %q, want %q", got.RootDir, "/code")
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "user", "proj")
+	if err := os.MkdirAll(filepath.Join(projectDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := (&Context{RootDir: root}).Config()
+	p := FindProject(cfg, filepath.Join(projectDir, "sub"))
+	if p == nil || p.Organisation != "user" || p.Name != "proj" {
+		t.Errorf("FindProject() = %+v, want user/proj", p)
+	}
+
+	if p := FindProject(cfg, os.TempDir()); p != nil {
+		t.Errorf("FindProject() outside root = %+v, want nil", p)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"regexp"
+)
+
+// KittyService drives kitty through its remote control protocol
+// ("kitty @"), which requires allow_remote_control in kitty.conf
+type KittyService struct {
+	logger *slog.Logger
+	bin    string
+}
+
+// NewKittyService creates a new kitty service
+func NewKittyService(logger *slog.Logger) *KittyService {
+	bin := os.Getenv("KITTY_BIN")
+	if bin == "" {
+		bin = "kitty"
+	}
+	return &KittyService{
+		logger: logger,
+		bin:    bin,
+	}
+}
+
+// kittyOSWindow is an OS window of "kitty @ ls" output
+type kittyOSWindow struct {
+	IsFocused bool `json:"is_focused"`
+	Tabs      []struct {
+		ID    int    `json:"id"`
+		Title string `json:"title"`
+	} `json:"tabs"`
+}
+
+// Name returns the terminal name
+func (s *KittyService) Name() string {
+	return terminalKitty
+}
+
+// buildCommand builds a kitty remote control command
+func (s *KittyService) buildCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, s.bin, append([]string{"@"}, args...)...)
+}
+
+// ListTabs lists the tab titles of the focused kitty OS window
+func (s *KittyService) ListTabs(ctx context.Context) ([]string, error) {
+	output, err := s.buildCommand(ctx, "ls").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kitty tabs (is remote control enabled?): %w", err)
+	}
+
+	var windows []kittyOSWindow
+	if err := json.Unmarshal(output, &windows); err != nil {
+		return nil, fmt.Errorf("failed to parse kitty tabs: %w", err)
+	}
+
+	var tabs []string
+	for _, window := range windows {
+		if !window.IsFocused && len(windows) > 1 {
+			continue
+		}
+		for _, tab := range window.Tabs {
+			tabs = append(tabs, tab.Title)
+		}
+	}
+
+	return tabs, nil
+}
+
+// FocusTab focuses the tab titled title
+func (s *KittyService) FocusTab(ctx context.Context, title string) (bool, error) {
+	tabs, err := s.ListTabs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !contains(tabs, title) {
+		return false, nil
+	}
+
+	match := "title:^" + regexp.QuoteMeta(title) + "$"
+	if output, err := s.buildCommand(ctx, "focus-tab", "--match", match).CombinedOutput(); err != nil {
+		return false, fmt.Errorf("failed to focus tab %s: %w\nOutput: %s", title, err, string(output))
+	}
+
+	s.logger.Info("focused kitty tab", "tab", title)
+	return true, nil
+}
+
+// NewTab opens a tab titled title in workingDir
+func (s *KittyService) NewTab(ctx context.Context, title, workingDir string) error {
+	s.logger.Debug("creating kitty tab", "tab", title, "dir", workingDir)
+
+	cmd := s.buildCommand(ctx, "launch", "--type=tab", "--tab-title", title, "--cwd", workingDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create tab %s: %w\nOutput: %s", title, err, string(output))
+	}
+
+	s.logger.Info("created kitty tab", "tab", title)
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type openConfig struct {
+	Terminal string
+	New      bool
+}
+
+func newOpenCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	openCfg := &openConfig{}
+	fs := ff.NewFlagSet("open")
+	fs.StringVar(&openCfg.Terminal, 0, "terminal", terminalAuto, "terminal to use: auto, kitty or wezterm")
+	fs.BoolVar(&openCfg.New, 0, "new", "always open a new tab, even if one already exists")
+
+	return &ff.Command{
+		Name:      "open",
+		Usage:     "proj-term open [flags] [target]",
+		ShortHelp: "Open project or workspace in a terminal tab",
+		LongHelp: `Open a project or workspace in a terminal tab.
+
+Targets can be:
+  project               Open project tab (e.g., 'gfanton/projects')
+  project:workspace     Open workspace tab (e.g., 'gfanton/projects:feature')
+
+Without target, the project of the current directory is opened. Tabs are
+titled after the target: when a tab with that title already exists in the
+current window it is focused instead. Workspaces are created if they don't
+exist.
+
+kitty requires remote control: set 'allow_remote_control yes' (or
+'socket-only' with listen_on) in kitty.conf.
+
+FLAGS:
+  --terminal    Terminal to use: auto, kitty or wezterm (default: auto)
+  --new         Always open a new tab`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var target string
+			if len(args) > 0 {
+				target = args[0]
+			}
+
+			return runOpen(ctx, logger, projectsCfg, projectsLogger, target, *openCfg)
+		},
+	}
+}
+
+func newListCommand(logger *slog.Logger) *ff.Command {
+	var terminal string
+	fs := ff.NewFlagSet("list")
+	fs.StringVar(&terminal, 0, "terminal", terminalAuto, "terminal to use: auto, kitty or wezterm")
+
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj-term list [flags]",
+		ShortHelp: "List project tabs",
+		LongHelp:  `List the tabs of the current terminal window that were opened by proj-term.`,
+		Flags:     fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runList(ctx, logger, terminal)
+		},
+	}
+}
+
+func runOpen(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, target string, openCfg openConfig) error {
+	term, err := newTerminal(logger, openCfg.Terminal)
+	if err != nil {
+		return err
+	}
+
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+
+	// Parse target: project or project:workspace
+	projectName, workspace, _ := strings.Cut(target, ":")
+
+	project, err := projectSvc.Resolve(projectName)
+	if err != nil {
+		return err
+	}
+
+	title, workingDir := tabTitle(project, workspace), project.Path
+	if workspace != "" {
+		if err := workspaceSvc.Ensure(ctx, *project, workspace); err != nil {
+			return err
+		}
+		workingDir = workspaceSvc.WorkspacePath(*project, workspace)
+	}
+
+	logger.Debug("opening tab", "terminal", term.Name(), "tab", title, "dir", workingDir)
+
+	if !openCfg.New {
+		focused, err := term.FocusTab(ctx, title)
+		if err != nil {
+			return err
+		}
+		if focused {
+			return nil
+		}
+	}
+
+	return term.NewTab(ctx, title, workingDir)
+}
+
+func runList(ctx context.Context, logger *slog.Logger, terminal string) error {
+	term, err := newTerminal(logger, terminal)
+	if err != nil {
+		return err
+	}
+
+	tabs, err := term.ListTabs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for _, tab := range tabs {
+		projectName, _, _ := strings.Cut(tab, ":")
+		if !isProjectName(projectName) {
+			continue
+		}
+		if !found {
+			fmt.Println("Project tabs:")
+			found = true
+		}
+		fmt.Printf("  %s\n", tab)
+	}
+
+	if !found {
+		fmt.Println("No project tabs found")
+	}
+
+	return nil
+}
+
+// tabTitle returns the title of the tab of project, or of its workspace
+// when not empty: "org/name" or "org/name:workspace".
+func tabTitle(project *projects.Project, workspace string) string {
+	if workspace != "" {
+		return project.String() + ":" + workspace
+	}
+	return project.String()
+}
+
+// isProjectName reports whether s has the "org/name" form of tab titles.
+func isProjectName(s string) bool {
+	org, name, ok := strings.Cut(s, "/")
+	return ok && org != "" && name != "" && !strings.Contains(name, "/") && !strings.Contains(s, " ")
+}
+
+// contains reports whether values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestTabTitle(t *testing.T) {
+	project := &projects.Project{Organisation: "gfanton", Name: "projects"}
+
+	if got := tabTitle(project, ""); got != "gfanton/projects" {
+		t.Errorf("tabTitle() = %q, want %q", got, "gfanton/projects")
+	}
+	if got := tabTitle(project, "feature"); got != "gfanton/projects:feature" {
+		t.Errorf("tabTitle() = %q, want %q", got, "gfanton/projects:feature")
+	}
+}
+
+func TestIsProjectName(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"gfanton/projects", true},
+		{"zsh", false},
+		{"/home/user", false},
+		{"vim src/main.go", false},
+		{"a/b/c", false},
+	}
+
+	for _, tt := range tests {
+		if got := isProjectName(tt.s); got != tt.want {
+			t.Errorf("isProjectName(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Terminal opens and focuses tabs of a terminal emulator
+type Terminal interface {
+	// Name returns the terminal name, as accepted by --terminal
+	Name() string
+	// ListTabs lists the tab titles of the current terminal window
+	ListTabs(ctx context.Context) ([]string, error)
+	// FocusTab focuses the tab titled title, reporting whether it exists
+	FocusTab(ctx context.Context, title string) (bool, error)
+	// NewTab opens a tab titled title in workingDir
+	NewTab(ctx context.Context, title, workingDir string) error
+}
+
+// Supported terminal names
+const (
+	terminalAuto    = "auto"
+	terminalKitty   = "kitty"
+	terminalWezTerm = "wezterm"
+)
+
+// newTerminal returns the terminal named name, detecting it from the
+// environment when name is "auto" or empty
+func newTerminal(logger *slog.Logger, name string) (Terminal, error) {
+	if name == "" || name == terminalAuto {
+		name = detectTerminal()
+		if name == "" {
+			return nil, fmt.Errorf("no supported terminal detected: run inside kitty or WezTerm, or use --terminal")
+		}
+		logger.Debug("detected terminal", "terminal", name)
+	}
+
+	switch name {
+	case terminalKitty:
+		return NewKittyService(logger), nil
+	case terminalWezTerm:
+		return NewWezTermService(logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported terminal %q (supported: %s, %s)", name, terminalKitty, terminalWezTerm)
+	}
+}
+
+// detectTerminal returns the name of the terminal we run in, or an empty
+// string if it isn't supported
+func detectTerminal() string {
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("KITTY_LISTEN_ON") != "":
+		return terminalKitty
+	case os.Getenv("WEZTERM_PANE") != "" || os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return terminalWezTerm
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// WezTermService drives WezTerm through "wezterm cli"
+type WezTermService struct {
+	logger *slog.Logger
+	bin    string
+}
+
+// NewWezTermService creates a new WezTerm service
+func NewWezTermService(logger *slog.Logger) *WezTermService {
+	bin := os.Getenv("WEZTERM_BIN")
+	if bin == "" {
+		bin = "wezterm"
+	}
+	return &WezTermService{
+		logger: logger,
+		bin:    bin,
+	}
+}
+
+// wezTermPane is a pane of "wezterm cli list" output
+type wezTermPane struct {
+	WindowID int    `json:"window_id"`
+	TabID    int    `json:"tab_id"`
+	PaneID   int    `json:"pane_id"`
+	TabTitle string `json:"tab_title"`
+}
+
+// Name returns the terminal name
+func (s *WezTermService) Name() string {
+	return terminalWezTerm
+}
+
+// buildCommand builds a wezterm cli command
+func (s *WezTermService) buildCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, s.bin, append([]string{"cli"}, args...)...)
+}
+
+// listPanes lists the panes of the current WezTerm window, or of all
+// windows outside WezTerm
+func (s *WezTermService) listPanes(ctx context.Context) ([]wezTermPane, error) {
+	output, err := s.buildCommand(ctx, "list", "--format", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wezterm panes: %w", err)
+	}
+
+	var panes []wezTermPane
+	if err := json.Unmarshal(output, &panes); err != nil {
+		return nil, fmt.Errorf("failed to parse wezterm panes: %w", err)
+	}
+
+	current, err := strconv.Atoi(os.Getenv("WEZTERM_PANE"))
+	if err != nil {
+		return panes, nil
+	}
+
+	windowID := -1
+	for _, pane := range panes {
+		if pane.PaneID == current {
+			windowID = pane.WindowID
+			break
+		}
+	}
+
+	var windowPanes []wezTermPane
+	for _, pane := range panes {
+		if windowID < 0 || pane.WindowID == windowID {
+			windowPanes = append(windowPanes, pane)
+		}
+	}
+
+	return windowPanes, nil
+}
+
+// ListTabs lists the tab titles of the current WezTerm window
+func (s *WezTermService) ListTabs(ctx context.Context) ([]string, error) {
+	panes, err := s.listPanes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Panes of a tab share its title
+	var tabs []string
+	seen := make(map[int]bool)
+	for _, pane := range panes {
+		if !seen[pane.TabID] {
+			seen[pane.TabID] = true
+			tabs = append(tabs, pane.TabTitle)
+		}
+	}
+
+	return tabs, nil
+}
+
+// FocusTab focuses the tab titled title
+func (s *WezTermService) FocusTab(ctx context.Context, title string) (bool, error) {
+	panes, err := s.listPanes(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, pane := range panes {
+		if pane.TabTitle != title {
+			continue
+		}
+
+		cmd := s.buildCommand(ctx, "activate-tab", "--tab-id", strconv.Itoa(pane.TabID))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return false, fmt.Errorf("failed to focus tab %s: %w\nOutput: %s", title, err, string(output))
+		}
+
+		s.logger.Info("focused wezterm tab", "tab", title)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NewTab opens a tab titled title in workingDir
+func (s *WezTermService) NewTab(ctx context.Context, title, workingDir string) error {
+	s.logger.Debug("creating wezterm tab", "tab", title, "dir", workingDir)
+
+	output, err := s.buildCommand(ctx, "spawn", "--cwd", workingDir).Output()
+	if err != nil {
+		return fmt.Errorf("failed to create tab %s: %w", title, err)
+	}
+
+	// spawn prints the id of the new pane, used to title its tab
+	paneID := strings.TrimSpace(string(output))
+	cmd := s.buildCommand(ctx, "set-tab-title", "--pane-id", paneID, title)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set title of tab %s: %w\nOutput: %s", title, err, string(output))
+	}
+
+	s.logger.Info("created wezterm tab", "tab", title)
+	return nil
+}
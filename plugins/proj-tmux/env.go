@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects"
+)
+
+const (
+	// projectEnvFile is the per-project file of variables exported to
+	// project sessions when the @proj_load_env tmux option is on.
+	projectEnvFile = ".proj.env"
+
+	// loadEnvOption is the tmux option enabling projectEnvFile.
+	loadEnvOption = "@proj_load_env"
+)
+
+// envVar is a variable exported to a tmux session.
+type envVar struct {
+	Name  string
+	Value string
+}
+
+// projectEnvironment returns the variables describing project context.
+func projectEnvironment(project *projects.Project, workspace string) []envVar {
+	return []envVar{
+		{Name: "PROJ_NAME", Value: project.Name},
+		{Name: "PROJ_ORG", Value: project.Organisation},
+		{Name: "PROJ_PATH", Value: project.Path},
+		{Name: "PROJ_WORKSPACE", Value: workspace},
+	}
+}
+
+// applySessionEnvironment exports the project context to the session, and
+// the variables of the project's .proj.env when @proj_load_env is on.
+// Panes created after this call inherit the variables.
+func applySessionEnvironment(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, sessionName string, project *projects.Project) error {
+	vars := projectEnvironment(project, "")
+
+	if tmuxSvc.GlobalOption(ctx, loadEnvOption) == "on" {
+		fileVars, err := parseEnvFile(filepath.Join(project.Path, projectEnvFile))
+		if err != nil {
+			logger.Warn("failed to load project environment", "project", project.String(), "error", err)
+		}
+		vars = append(vars, fileVars...)
+	}
+
+	for _, v := range vars {
+		if err := tmuxSvc.SetEnvironment(ctx, sessionName, v.Name, v.Value); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("session environment set", "session", sessionName, "vars", len(vars))
+	return nil
+}
+
+// parseEnvFile reads NAME=value lines from path. Blank lines, comments and
+// an optional "export " prefix are ignored, and matching surrounding quotes
+// are stripped from values. The file is parsed, never executed. A missing
+// file yields no variables.
+func parseEnvFile(path string) ([]envVar, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var vars []envVar
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !isEnvName(name) {
+			return vars, fmt.Errorf("%s:%d: invalid line %q", path, lineNum, line)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		vars = append(vars, envVar{Name: name, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return vars, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return vars, nil
+}
+
+// isEnvName reports whether name is a valid environment variable name.
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
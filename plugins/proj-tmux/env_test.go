@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []envVar
+		wantErr bool
+	}{
+		{
+			name:    "comments and export",
+			content: "# comment\n\nexport GOFLAGS=-mod=mod\nAPI_URL = http://localhost\n",
+			want:    []envVar{{"GOFLAGS", "-mod=mod"}, {"API_URL", "http://localhost"}},
+		},
+		{
+			name:    "quoted values",
+			content: "A=\"hello world\"\nB='single'\nC=\"unbalanced\n",
+			want:    []envVar{{"A", "hello world"}, {"B", "single"}, {"C", "\"unbalanced"}},
+		},
+		{
+			name:    "invalid name",
+			content: "OK=1\n1BAD=2\n",
+			want:    []envVar{{"OK", "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing equal sign",
+			content: "NOVALUE\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write env file: %v", err)
+			}
+
+			got, err := parseEnvFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseEnvFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEnvFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if vars, err := parseEnvFile(filepath.Join(dir, "missing")); err != nil || vars != nil {
+		t.Errorf("parseEnvFile(missing) = %v, %v; want nil, nil", vars, err)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// maxAheadBehindWalk bounds the number of commits walked when counting
+// ahead/behind, to keep the status bar responsive on diverged branches.
+const maxAheadBehindWalk = 1000
+
+// gitStatus is the repository state exposed to status format placeholders.
+type gitStatus struct {
+	Branch string
+	Dirty  bool
+	Ahead  int
+	Behind int
+}
+
+// readGitStatus computes the git status of the repository at path with
+// go-git, without spawning git.
+func readGitStatus(path string) (*gitStatus, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	status := &gitStatus{}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	if head.Name().IsBranch() {
+		status.Branch = head.Name().Short()
+	} else {
+		status.Branch = head.Hash().String()[:7]
+	}
+
+	if wt, err := repo.Worktree(); err == nil {
+		if st, err := wt.Status(); err == nil {
+			status.Dirty = !st.IsClean()
+		}
+	}
+
+	if head.Name().IsBranch() {
+		if upstream, err := upstreamHash(repo, status.Branch); err == nil {
+			status.Ahead, status.Behind = aheadBehind(repo, head.Hash(), upstream)
+		}
+	}
+
+	return status, nil
+}
+
+// upstreamHash resolves the commit of the upstream tracking branch of branch.
+func upstreamHash(repo *git.Repository, branch string) (plumbing.Hash, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	b, ok := cfg.Branches[branch]
+	if !ok || b.Remote == "" || b.Merge == "" {
+		return plumbing.ZeroHash, errors.New("no upstream configured")
+	}
+
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName(b.Remote, b.Merge.Short()), true)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return ref.Hash(), nil
+}
+
+// aheadBehind counts the commits reachable from local but not upstream
+// (ahead), and from upstream but not local (behind).
+func aheadBehind(repo *git.Repository, local, upstream plumbing.Hash) (ahead, behind int) {
+	if local == upstream {
+		return 0, 0
+	}
+
+	localSet := ancestors(repo, local)
+	upstreamSet := ancestors(repo, upstream)
+
+	for h := range localSet {
+		if !upstreamSet[h] {
+			ahead++
+		}
+	}
+	for h := range upstreamSet {
+		if !localSet[h] {
+			behind++
+		}
+	}
+
+	return ahead, behind
+}
+
+// ancestors returns up to maxAheadBehindWalk commits reachable from from.
+func ancestors(repo *git.Repository, from plumbing.Hash) map[plumbing.Hash]bool {
+	seen := make(map[plumbing.Hash]bool)
+
+	commit, err := repo.CommitObject(from)
+	if err != nil {
+		return seen
+	}
+
+	iter := object.NewCommitPreorderIter(commit, nil, nil)
+	defer iter.Close()
+
+	_ = iter.ForEach(func(c *object.Commit) error {
+		if len(seen) >= maxAheadBehindWalk {
+			return storer.ErrStop
+		}
+		seen[c.Hash] = true
+		return nil
+	})
+
+	return seen
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/peterbourgon/ff/v4"
+)
+
+//go:embed install.conf
+var installTemplate string
+
+// defaultStatusFormat is the status format used by the installed config.
+// Placeholders are escaped ("##") so tmux passes them to proj-tmux as is.
+const defaultStatusFormat = "##{project} ##{branch}##{dirty}"
+
+type installConfig struct {
+	File        string
+	TmuxConf    string
+	PopupKey    string
+	StatusRight bool
+	Restore     bool
+	DryRun      bool
+	NoSource    bool
+}
+
+// installData holds the values rendered into install.conf.
+type installData struct {
+	ProjBin      string
+	ProjTmuxBin  string
+	PopupKey     string
+	StatusFormat string
+	StatusRight  bool
+	Restore      bool
+}
+
+func newInstallCommand(logger *slog.Logger) *ff.Command {
+	installCfg := &installConfig{}
+	fs := ff.NewFlagSet("install")
+	fs.StringVar(&installCfg.File, 0, "file", defaultInstallFile(), "generated configuration file")
+	fs.StringVar(&installCfg.TmuxConf, 0, "tmux-conf", defaultTmuxConf(), "tmux configuration that sources the generated file")
+	fs.StringVar(&installCfg.PopupKey, 0, "popup-key", "C-p", "key bound to the project switcher popup")
+	fs.BoolVarDefault(&installCfg.StatusRight, 0, "status-right", true, "set status-right to show the project status")
+	fs.BoolVar(&installCfg.Restore, 0, "restore-on-start", "restore the saved project sessions when the tmux server starts")
+	fs.BoolVar(&installCfg.DryRun, 0, "dry-run", "print the generated configuration instead of writing it")
+	fs.BoolVar(&installCfg.NoSource, 0, "no-source", "don't add a source-file line to the tmux configuration")
+
+	return &ff.Command{
+		Name:      "install",
+		Usage:     "proj-tmux install [flags]",
+		ShortHelp: "Install the recommended tmux configuration",
+		LongHelp: `Write the recommended tmux configuration to a dedicated file and make
+tmux source it.
+
+The generated file sets up:
+  - a popup project/workspace switcher (Prefix + --popup-key, requires fzf)
+  - the @proj_status option, and status-right unless --status-right=false
+  - a session hook refreshing the status line on session switch
+  - with --restore-on-start, the restore of the project sessions saved with
+    'proj-tmux session save' when the tmux server starts
+
+A 'source-file' line is appended to --tmux-conf if missing, and the file is
+sourced right away when running inside tmux. Re-run the command to update the
+generated file after upgrading proj-tmux.
+
+If you maintain your own status-right, use --status-right=false and add
+#{E:@proj_status} where you want the project status.
+
+FLAGS:
+  --file              Generated file (default: ~/.config/tmux/proj.conf)
+  --tmux-conf         tmux configuration to update (default: ~/.tmux.conf)
+  --popup-key         Popup key binding (default: C-p)
+  --status-right      Set status-right (default: true)
+  --restore-on-start  Restore the saved sessions on server start
+  --dry-run           Print the configuration and exit
+  --no-source         Don't modify the tmux configuration`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			return runInstall(ctx, logger, *installCfg)
+		},
+	}
+}
+
+func runInstall(ctx context.Context, logger *slog.Logger, installCfg installConfig) error {
+	projTmuxBin, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	projBin, err := exec.LookPath("proj")
+	if err != nil {
+		logger.Warn("proj binary not found in PATH", "error", err)
+		projBin = "proj"
+	}
+
+	conf, err := renderInstallConfig(installData{
+		ProjBin:      projBin,
+		ProjTmuxBin:  projTmuxBin,
+		PopupKey:     installCfg.PopupKey,
+		StatusFormat: defaultStatusFormat,
+		StatusRight:  installCfg.StatusRight,
+		Restore:      installCfg.Restore,
+	})
+	if err != nil {
+		return err
+	}
+
+	if installCfg.DryRun {
+		fmt.Print(conf)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(installCfg.File), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := os.WriteFile(installCfg.File, []byte(conf), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", installCfg.File, err)
+	}
+	fmt.Printf("Wrote %s\n", installCfg.File)
+
+	if !installCfg.NoSource {
+		added, err := ensureSourced(installCfg.TmuxConf, installCfg.File)
+		if err != nil {
+			return err
+		}
+		if added {
+			fmt.Printf("Added source-file line to %s\n", installCfg.TmuxConf)
+		}
+	}
+
+	// Apply right away when running inside tmux
+	if os.Getenv("TMUX") != "" {
+		tmuxSvc := newTmuxServiceFromEnv(logger)
+		cmd := tmuxSvc.buildTmuxCommand(ctx, "source-file", installCfg.File)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to source %s: %w\nOutput: %s", installCfg.File, err, string(output))
+		}
+		fmt.Println("Configuration loaded in the running tmux server")
+	}
+
+	return nil
+}
+
+// renderInstallConfig renders the generated tmux configuration.
+func renderInstallConfig(data installData) (string, error) {
+	tmpl, err := template.New("install").Parse(installTemplate)
+	if err != nil {
+		return "", fmt.Errorf("parse install template: %w", err)
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("execute install template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// ensureSourced appends a source-file line for file to tmuxConf unless it
+// already references file. It reports whether the line was added.
+func ensureSourced(tmuxConf, file string) (bool, error) {
+	data, err := os.ReadFile(tmuxConf)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", tmuxConf, err)
+	}
+
+	if strings.Contains(string(data), file) {
+		return false, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tmuxConf), 0755); err != nil {
+		return false, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	f, err := os.OpenFile(tmuxConf, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", tmuxConf, err)
+	}
+	defer f.Close()
+
+	var line string
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		line = "\n"
+	}
+	line += fmt.Sprintf("\n# proj-tmux (managed by 'proj-tmux install')\nsource-file -q %q\n", file)
+
+	if _, err := f.WriteString(line); err != nil {
+		return false, fmt.Errorf("failed to update %s: %w", tmuxConf, err)
+	}
+
+	return true, nil
+}
+
+// defaultInstallFile returns the default location of the generated file.
+func defaultInstallFile() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		home, _ := os.UserHomeDir()
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "tmux", "proj.conf")
+}
+
+// defaultTmuxConf returns the tmux configuration in use: the XDG location if
+// it exists, ~/.tmux.conf otherwise.
+func defaultTmuxConf() string {
+	home, _ := os.UserHomeDir()
+
+	if configDir, err := os.UserConfigDir(); err == nil {
+		xdg := filepath.Join(configDir, "tmux", "tmux.conf")
+		if _, err := os.Stat(xdg); err == nil {
+			return xdg
+		}
+	}
+
+	return filepath.Join(home, ".tmux.conf")
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+	"github.com/peterbourgon/ff/v4/fftoml"
+)
+
+// layoutTemplate is the content of new layout files.
+const layoutTemplate = `# proj-tmux layout: the windows of a project session, applied with
+# 'proj-tmux layout apply', or to new sessions of the projects whose
+# .proj.toml sets [session] layout.
+
+# windows, in order, each configured in its table
+windows = ["editor", "shell"]
+
+[editor]
+panes = ["${EDITOR:-vi} ."]
+
+[shell]
+# dir = "web"                  # relative to the project, default its root
+# layout = "even-horizontal"   # tmux select-layout, default tiled
+panes = ["", ""]
+`
+
+// tmuxLayout is a layout file: the windows created in a project session.
+type tmuxLayout struct {
+	Name    string
+	Windows []layoutWindow
+}
+
+// layoutWindow is a window of a layout.
+type layoutWindow struct {
+	Name   string
+	Dir    string   // relative to the project
+	Layout string   // select-layout argument, such as main-vertical
+	Panes  []string // command typed in each pane, empty for a shell
+}
+
+func newLayoutCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "layout",
+		Usage:     "proj-tmux layout <subcommand>",
+		ShortHelp: "Manage the window layouts of project sessions",
+		LongHelp: `Manage layouts: named TOML files describing the windows of a project
+session, their directory, the commands of their panes and how the panes are
+arranged. Layouts are stored in $XDG_CONFIG_HOME/proj/tmux-layouts (default
+~/.config), one <name>.toml file each, to share them like dotfiles.
+
+A project uses a layout for its new sessions from its .proj.toml:
+
+  [session]
+  layout = "web"
+
+Commands:
+  list                        List the layouts
+  edit <name>                 Create or edit a layout in $VISUAL or $EDITOR
+  apply <name> [project]      Add the windows of a layout to a project session`,
+		Subcommands: []*ff.Command{
+			newLayoutListCommand(),
+			newLayoutEditCommand(),
+			newLayoutApplyCommand(logger, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+func newLayoutListCommand() *ff.Command {
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj-tmux layout list",
+		ShortHelp: "List the layouts",
+		LongHelp:  `List the layouts with their windows, and the errors of invalid ones.`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runLayoutList(os.Stdout)
+		},
+	}
+}
+
+func newLayoutEditCommand() *ff.Command {
+	return &ff.Command{
+		Name:      "edit",
+		Usage:     "proj-tmux layout edit <name>",
+		ShortHelp: "Create or edit a layout",
+		LongHelp: `Open the layout file name in $VISUAL or $EDITOR, defaulting to vi, created
+from a commented example when missing. The layout is checked once the editor
+exits.
+
+Examples:
+  proj-tmux layout edit web
+  proj tmux layout edit web`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("a layout name is required")
+			}
+			return runLayoutEdit(ctx, os.Stdout, args[0])
+		},
+	}
+}
+
+func newLayoutApplyCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "apply",
+		Usage:     "proj-tmux layout apply <name> [project]",
+		ShortHelp: "Add the windows of a layout to a project session",
+		LongHelp: `Add the windows of the layout name to the session of a project, the one of
+the current session or directory without project. The session is created
+when missing, its first window becoming the first window of the layout.
+Windows that already exist in the session are kept as they are.
+
+Examples:
+  proj-tmux layout apply web
+  proj-tmux layout apply web acme/shop`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 || len(args) > 2 {
+				return fmt.Errorf("a layout name and an optional project are expected")
+			}
+			var projectName string
+			if len(args) > 1 {
+				projectName = args[1]
+			}
+			return runLayoutApply(ctx, os.Stdout, logger, projectsCfg, projectsLogger, args[0], projectName)
+		},
+	}
+}
+
+func runLayoutList(w io.Writer) error {
+	names := savedLayouts()
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No layouts, create one with 'proj-tmux layout edit <name>'")
+		return nil
+	}
+
+	for _, name := range names {
+		layout, err := loadLayout(name)
+		if err != nil {
+			fmt.Fprintf(w, "%-20s invalid: %v\n", name, err)
+			continue
+		}
+		windows := make([]string, len(layout.Windows))
+		for i, window := range layout.Windows {
+			windows[i] = window.Name
+		}
+		fmt.Fprintf(w, "%-20s %s\n", name, strings.Join(windows, ", "))
+	}
+	return nil
+}
+
+func runLayoutEdit(ctx context.Context, w io.Writer, name string) error {
+	path, err := layoutPath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create layouts directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(layoutTemplate), 0644); err != nil {
+			return fmt.Errorf("failed to create layout %s: %w", name, err)
+		}
+	}
+
+	if err := runEditor(ctx, path); err != nil {
+		return err
+	}
+	if _, err := loadLayout(name); err != nil {
+		return fmt.Errorf("layout %s saved but invalid, edit it again: %w", name, err)
+	}
+	fmt.Fprintf(w, "Layout %s saved in %s\n", name, path)
+	return nil
+}
+
+func runLayoutApply(ctx context.Context, w io.Writer, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, name, projectName string) error {
+	layout, err := loadLayout(name)
+	if err != nil {
+		return err
+	}
+
+	project, err := resolveProjectForWindow(projectsCfg, projectsLogger, projectName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve project: %w", err)
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	sessionName := generateSessionName(project)
+	exists, err := tmuxSvc.SessionExists(ctx, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	if !exists {
+		if err := tmuxSvc.NewSession(ctx, sessionName, layout.Windows[0].path(project)); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		if err := applySessionEnvironment(ctx, logger, tmuxSvc, sessionName, project); err != nil {
+			logger.Warn("failed to set session environment", "session", sessionName, "error", err)
+		}
+	}
+
+	created, err := applyLayout(ctx, logger, tmuxSvc, sessionName, project, layout, !exists)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Applied layout %s to %s: %d of %d windows created\n", name, sessionName, created, len(layout.Windows))
+	return nil
+}
+
+// projectLayout returns the layout set in the project's .proj.toml, or nil
+// when it sets none.
+func projectLayout(project *projects.Project) (*tmuxLayout, error) {
+	settings, err := projects.LoadSettings(project.Path)
+	if err != nil {
+		return nil, err
+	}
+	if settings.SessionLayout == "" {
+		return nil, nil
+	}
+	return loadLayout(settings.SessionLayout)
+}
+
+// applyLayout creates the windows of layout missing in the session, and
+// their panes running their commands. With reuseFirst, the current window
+// of the new session is renamed as the first window of the layout. It
+// returns the number of windows created.
+func applyLayout(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, sessionName string, project *projects.Project, layout *tmuxLayout, reuseFirst bool) (int, error) {
+	created := 0
+	for i, window := range layout.Windows {
+		dir := window.path(project)
+
+		var id string
+		if i == 0 && reuseFirst {
+			var err error
+			if id, err = tmuxSvc.WindowID(ctx, sessionName+":"); err != nil {
+				return created, err
+			}
+			if err := tmuxSvc.RenameWindow(ctx, id, window.Name); err != nil {
+				return created, err
+			}
+		} else {
+			exists, err := tmuxSvc.WindowExists(ctx, sessionName, window.Name)
+			if err != nil {
+				return created, err
+			}
+			if exists {
+				logger.Info("window already exists", "session", sessionName, "window", window.Name)
+				continue
+			}
+			if id, err = tmuxSvc.CreateWindow(ctx, sessionName, window.Name, dir); err != nil {
+				return created, err
+			}
+		}
+		created++
+
+		panes := window.Panes
+		if len(panes) == 0 {
+			panes = []string{""}
+		}
+		if panes[0] != "" {
+			if err := tmuxSvc.SendCommand(ctx, id, panes[0]); err != nil {
+				return created, err
+			}
+		}
+		for _, command := range panes[1:] {
+			pane, err := tmuxSvc.SplitWindow(ctx, id, dir)
+			if err != nil {
+				return created, err
+			}
+			if command != "" {
+				if err := tmuxSvc.SendCommand(ctx, pane, command); err != nil {
+					return created, err
+				}
+			}
+		}
+
+		if arrange := window.Layout; arrange != "" || len(panes) > 1 {
+			if arrange == "" {
+				arrange = "tiled"
+			}
+			if err := tmuxSvc.SelectLayout(ctx, id, arrange); err != nil {
+				logger.Warn("failed to arrange panes", "session", sessionName, "window", window.Name, "error", err)
+			}
+		}
+		logger.Debug("layout window created", "session", sessionName, "window", window.Name, "panes", len(panes))
+	}
+	return created, nil
+}
+
+// path returns the directory of the window in project, or the project
+// root when it doesn't exist, such as in a workspace without it.
+func (w layoutWindow) path(project *projects.Project) string {
+	return existingDir(filepath.Join(project.Path, w.Dir), project.Path)
+}
+
+// layoutPath returns the file of the layout name:
+// $XDG_CONFIG_HOME/proj/tmux-layouts/<name>.toml, defaulting to ~/.config.
+func layoutPath(name string) (string, error) {
+	if !fileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid layout name '%s': must start with a letter or digit and contain only letters, digits, '.', '_' and '-'", name)
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "proj", "tmux-layouts", name+".toml"), nil
+}
+
+// savedLayouts returns the names of the layout files, sorted.
+func savedLayouts() []string {
+	path, err := layoutPath("default")
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.toml"))
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".toml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadLayout reads the layout file name.
+func loadLayout(name string) (*tmuxLayout, error) {
+	path, err := layoutPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("layout '%s' not found, create it with 'proj-tmux layout edit %s'", name, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open layout %s: %w", name, err)
+	}
+	defer f.Close()
+
+	layout, err := parseLayout(f, name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %s: %w", path, err)
+	}
+	return layout, nil
+}
+
+// parseLayout parses a layout file: the windows key lists the windows in
+// order, and the table of each window sets its dir, layout and panes.
+func parseLayout(r io.Reader, name string) (*tmuxLayout, error) {
+	var order []string
+	tables := make(map[string]*layoutWindow)
+	table := func(name string) *layoutWindow {
+		if tables[name] == nil {
+			tables[name] = &layoutWindow{Name: name}
+		}
+		return tables[name]
+	}
+
+	err := fftoml.Parse(r, func(key, value string) error {
+		if key == "windows" {
+			order = append(order, value)
+			return nil
+		}
+
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return fmt.Errorf("unknown key '%s'", key)
+		}
+		window := table(key[:i])
+		switch field := key[i+1:]; field {
+		case "dir":
+			window.Dir = value
+		case "layout":
+			window.Layout = value
+		case "panes":
+			window.Panes = append(window.Panes, value)
+		default:
+			return fmt.Errorf("unknown key '%s' of window %s", field, window.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(order) == 0 {
+		return nil, errors.New("no windows listed")
+	}
+
+	layout := &tmuxLayout{Name: name}
+	listed := make(map[string]bool, len(order))
+	for _, name := range order {
+		if name == "" || strings.ContainsAny(name, ".:") {
+			return nil, fmt.Errorf("invalid window name '%s': must not be empty or contain '.' or ':'", name)
+		}
+		if listed[name] {
+			return nil, fmt.Errorf("window %s listed twice", name)
+		}
+		listed[name] = true
+
+		window := table(name)
+		if dir := filepath.Clean(window.Dir); filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("window %s directory '%s' must be inside the project", name, window.Dir)
+		}
+		layout.Windows = append(layout.Windows, *window)
+	}
+
+	for name := range tables {
+		if !listed[name] {
+			return nil, fmt.Errorf("window %s is not listed in windows", name)
+		}
+	}
+	return layout, nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, defaulting to vi.
+func runEditor(ctx context.Context, path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	fields := strings.Fields(editor)
+	cmd := exec.CommandContext(ctx, fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", editor, err)
+	}
+	return nil
+}
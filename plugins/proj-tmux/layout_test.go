@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []layoutWindow
+		wantErr bool
+	}{
+		{
+			name:    "template",
+			content: layoutTemplate,
+			want: []layoutWindow{
+				{Name: "editor", Panes: []string{"${EDITOR:-vi} ."}},
+				{Name: "shell", Panes: []string{"", ""}},
+			},
+		},
+		{
+			name: "window settings",
+			content: `windows = ["server", "logs"]
+
+[server]
+dir = "web"
+layout = "even-horizontal"
+panes = ["npm run dev", "go run ./cmd/api"]
+`,
+			want: []layoutWindow{
+				{Name: "server", Dir: "web", Layout: "even-horizontal", Panes: []string{"npm run dev", "go run ./cmd/api"}},
+				{Name: "logs"},
+			},
+		},
+		{
+			name:    "no windows",
+			content: "[editor]\npanes = [\"vi\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "window not listed",
+			content: "windows = [\"editor\"]\n\n[shell]\npanes = [\"\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "window listed twice",
+			content: "windows = [\"editor\", \"editor\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid window name",
+			content: "windows = [\"web:1\"]\n",
+			wantErr: true,
+		},
+		{
+			name:    "directory outside the project",
+			content: "windows = [\"editor\"]\n\n[editor]\ndir = \"../other\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			content: "windows = [\"editor\"]\n\n[editor]\ncommand = \"vi\"\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := parseLayout(strings.NewReader(tt.content), "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(layout.Windows, tt.want) {
+				t.Errorf("parseLayout() windows = %+v, want %+v", layout.Windows, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, name := range []string{"", ".hidden", "a/b"} {
+		if _, err := layoutPath(name); err == nil {
+			t.Errorf("layoutPath(%q) succeeded, want error", name)
+		}
+	}
+
+	if _, err := loadLayout("web"); err == nil {
+		t.Error("loadLayout() of a missing layout succeeded, want error")
+	}
+
+	path, err := layoutPath("web")
+	if err != nil {
+		t.Fatalf("layoutPath() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(layoutTemplate), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := savedLayouts(); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("savedLayouts() = %v, want [web]", got)
+	}
+	layout, err := loadLayout("web")
+	if err != nil {
+		t.Fatalf("loadLayout() error = %v", err)
+	}
+	if layout.Name != "web" || len(layout.Windows) != 2 {
+		t.Errorf("loadLayout() = %+v, want the 2 windows of the template", layout)
+	}
+}
@@ -40,12 +40,7 @@ func main() {
 	logger := cfg.Logger()
 
 	// Create projects config and services
-	projectsCfg := &projects.Config{
-		ConfigFile: cfg.ConfigFile,
-		Debug:      cfg.Debug,
-		RootDir:    cfg.RootDir,
-		RootUser:   cfg.RootUser,
-	}
+	projectsCfg := cfg.ProjectsConfig()
 	projectsLogger := projects.NewSlogAdapter(logger)
 
 	// Create root flag set with global flags
@@ -54,6 +49,7 @@ func main() {
 	rootFlags.StringVar(&cfg.RootDir, 0, "root", cfg.RootDir, "root directory for projects")
 	rootFlags.StringVar(&cfg.RootUser, 0, "user", cfg.RootUser, "default user for projects")
 	rootFlags.StringVar(&cfg.ConfigFile, 0, "config", cfg.ConfigFile, "configuration file path")
+	rootFlags.BoolVar(&cfg.ReadOnly, 0, "read-only", "refuse operations modifying the root directory")
 
 	root := &ff.Command{
 		Name:      "proj-tmux",
@@ -74,11 +70,16 @@ Use 'proj-tmux <subcommand> -h' for more information about a specific command.`,
 			newSessionCommand(logger, projectsCfg, projectsLogger),
 			newWindowCommand(logger, projectsCfg, projectsLogger),
 			newSwitchCommand(logger, projectsCfg, projectsLogger),
+			newLayoutCommand(logger, projectsCfg, projectsLogger),
 			newStatusCommand(logger, projectsCfg, projectsLogger),
+			newInstallCommand(logger),
 			newVersionCommand(),
 		},
 	}
 
+	// Accept the global flags after subcommands too, e.g. 'proj-tmux session create --root ~/src'
+	config.InheritFlags(root)
+
 	if err := root.ParseAndRun(ctx, os.Args[1:]); err != nil {
 		if errors.Is(err, ff.ErrHelp) {
 			fmt.Fprint(os.Stdout, ffhelp.Command(root))
@@ -82,8 +82,8 @@ project_picker() {
         --height=80% \
         --border=rounded \
         --header="Navigate: ↑↓ | Select: Enter | Cancel: Esc | Search: type to filter" \
-        --preview="echo 'Project: {}' && echo '' && echo 'Path: ~/code/{}'" \
-        --preview-window=down:4:wrap \
+        --preview="${PROJ_BIN} preview -- {} 2>/dev/null" \
+        --preview-window=right:50%:wrap \
         --cycle \
         --reverse \
         --bind='ctrl-u:preview-page-up,ctrl-d:preview-page-down' \
@@ -27,8 +27,11 @@ fzf_output="$("${PROJ_BIN}" list | sed 's/ - \[.*\]$//' | fzf \
     --reverse \
     --cycle \
     --print-query \
+    --preview="${PROJ_BIN} preview -- {} 2>/dev/null" \
+    --preview-window=right:50%:wrap \
     --header='Navigate: ↑↓ | Tab: Complete | Enter: Create Session | Esc: Cancel | Use : for workspaces' \
     --bind='tab:replace-query' \
+    --bind='ctrl-u:preview-page-up,ctrl-d:preview-page-down' \
     --bind='enter:accept' \
     --bind='esc:cancel' \
     --bind="change:reload:${PROJ_BIN} query --limit 50 -- {q} 2>/dev/null || ${PROJ_BIN} list | sed 's/ - \[.*\]$//'" \
@@ -21,15 +21,19 @@ func newSessionCommand(logger *slog.Logger, projectsCfg *projects.Config, projec
 		LongHelp: `Manage tmux sessions for projects.
 
 Commands:
-  create <project>    Create or switch to project session
+  create <project>    Create or switch to project session, or @set
   list                List project sessions
   current             Show current project context
-  switch <project>    Switch to project session`,
+  switch <project>    Switch to project session
+  save [name]         Save the project sessions
+  restore [name]      Recreate the saved project sessions`,
 		Subcommands: []*ff.Command{
 			newSessionCreateCommand(logger, projectsCfg, projectsLogger),
 			newSessionListCommand(logger, projectsCfg, projectsLogger),
 			newSessionCurrentCommand(logger, projectsCfg, projectsLogger),
 			newSessionSwitchCommand(logger, projectsCfg, projectsLogger),
+			newSessionSaveCommand(logger, projectsCfg, projectsLogger),
+			newSessionRestoreCommand(logger, projectsCfg, projectsLogger),
 		},
 		Exec: func(ctx context.Context, args []string) error {
 			return ff.ErrHelp
@@ -57,6 +61,9 @@ func newSessionCreateCommand(logger *slog.Logger, projectsCfg *projects.Config,
 The session will be named using the format: proj-<org>-<name>
 If the session already exists, this command will switch to it.
 
+With @set, a set of the [sets] config table, the session is named
+proj-@<set> and has a window per cloned project of the set.
+
 FLAGS:
   --switch    Automatically switch to the created session (default: true)`,
 		Flags: fs,
@@ -68,6 +75,9 @@ FLAGS:
 			projectName := args[0]
 			// NoSwitch overrides AutoSwitch
 			autoSwitch := createCfg.AutoSwitch && !createCfg.NoSwitch
+			if set, ok := projects.ParseSetName(projectName); ok {
+				return runSetSessionCreate(ctx, logger, projectsCfg, projectsLogger, set, autoSwitch, createCfg.NoSwitch)
+			}
 			return runSessionCreate(ctx, logger, projectsCfg, projectsLogger, projectName, autoSwitch, createCfg.NoSwitch)
 		},
 	}
@@ -102,18 +112,108 @@ func newSessionSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config,
 		Name:      "switch",
 		Usage:     "proj-tmux session switch <project>",
 		ShortHelp: "Switch to project session",
-		LongHelp:  `Switch to the tmux session for the specified project. Creates the session if it doesn't exist.`,
+		LongHelp:  `Switch to the tmux session for the specified project, or @set. Creates the session if it doesn't exist.`,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) < 1 {
 				return fmt.Errorf("project name is required")
 			}
 
 			projectName := args[0]
+			if set, ok := projects.ParseSetName(projectName); ok {
+				return runSetSessionCreate(ctx, logger, projectsCfg, projectsLogger, set, true, false)
+			}
 			return runSessionSwitch(ctx, logger, projectsCfg, projectsLogger, projectName)
 		},
 	}
 }
 
+func newSessionSaveCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "save",
+		Usage:     "proj-tmux session save [name]",
+		ShortHelp: "Save the project sessions",
+		LongHelp: `Save the project sessions to a snapshot, named "default" without name:
+their project, and the name, layout and pane directories of their windows.
+Workspace windows are recorded with their workspace. Restore them, after a
+reboot for instance, with 'proj-tmux session restore'.
+
+Snapshots are stored in $XDG_STATE_HOME/proj/tmux-sessions (default
+~/.local/state), and saving again under a name replaces its snapshot.
+
+Examples:
+  proj-tmux session save
+  proj tmux session save release-work`,
+		Exec: func(ctx context.Context, args []string) error {
+			name, err := snapshotArg(args)
+			if err != nil {
+				return err
+			}
+			return runSessionSave(ctx, os.Stdout, logger, projectsCfg, projectsLogger, name)
+		},
+	}
+}
+
+type sessionRestoreConfig struct {
+	OnStart bool
+}
+
+func newSessionRestoreCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	restoreCfg := &sessionRestoreConfig{}
+	fs := ff.NewFlagSet("session restore")
+	fs.BoolVar(&restoreCfg.OnStart, 0, "on-start", "restore once per tmux server, silently, for run-shell in tmux.conf")
+
+	return &ff.Command{
+		Name:      "restore",
+		Usage:     "proj-tmux session restore [flags] [name]",
+		ShortHelp: "Recreate the saved project sessions",
+		LongHelp: `Recreate the project sessions of a snapshot saved with 'proj-tmux session
+save', named "default" without name: their windows, panes and layouts, in
+the saved directories. Sessions that already exist are kept as they are.
+
+Workspaces of workspace windows that were removed are added again, and panes
+whose directory is gone are opened in the project. Commands running in the
+panes are not restored.
+
+With --on-start, the sessions are restored when the tmux server starts, from
+the tmux configuration:
+
+  run-shell -b 'proj-tmux session restore --on-start'
+
+Nothing is printed, a missing snapshot is not an error, and the snapshot is
+only restored once per server: reloading the configuration doesn't bring
+back the sessions closed since. 'proj-tmux install --restore-on-start' adds
+this line to the generated configuration.
+
+Examples:
+  proj-tmux session restore
+  proj tmux session restore release-work`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			name, err := snapshotArg(args)
+			if err != nil {
+				return err
+			}
+			if restoreCfg.OnStart {
+				return runSessionRestoreOnStart(ctx, logger, projectsCfg, projectsLogger, name)
+			}
+			return runSessionRestore(ctx, os.Stdout, logger, projectsCfg, projectsLogger, name)
+		},
+	}
+}
+
+// snapshotArg returns the snapshot name of the arguments of session save
+// and restore.
+func snapshotArg(args []string) (string, error) {
+	switch len(args) {
+	case 0:
+		return defaultSnapshot, nil
+	case 1:
+		return args[0], nil
+	default:
+		return "", fmt.Errorf("at most one snapshot name is expected")
+	}
+}
+
 func runSessionCreate(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectName string, autoSwitch bool, printSessionName bool) error {
 	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
 
@@ -146,11 +246,35 @@ func runSessionCreate(ctx context.Context, logger *slog.Logger, projectsCfg *pro
 		return nil
 	}
 
+	// The layout of the project, if any, sets the directory of the first window
+	layout, err := projectLayout(project)
+	if err != nil {
+		logger.Warn("failed to load session layout", "project", project.String(), "error", err)
+	}
+	firstDir := project.Path
+	if layout != nil {
+		firstDir = layout.Windows[0].path(project)
+	}
+
 	// Create new session
-	if err := tmuxSvc.NewSession(ctx, sessionName, project.Path); err != nil {
+	if err := tmuxSvc.NewSession(ctx, sessionName, firstDir); err != nil {
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 
+	if err := applySessionEnvironment(ctx, logger, tmuxSvc, sessionName, project); err != nil {
+		logger.Warn("failed to set session environment", "session", sessionName, "error", err)
+	}
+
+	if layout != nil {
+		if _, err := applyLayout(ctx, logger, tmuxSvc, sessionName, project, layout, true); err != nil {
+			logger.Warn("failed to apply session layout", "session", sessionName, "layout", layout.Name, "error", err)
+		}
+	}
+
+	if err := startSessionTasks(ctx, logger, tmuxSvc, sessionName, project); err != nil {
+		logger.Warn("failed to start session tasks", "session", sessionName, "error", err)
+	}
+
 	logger.Info("session created", "session", sessionName, "project", project.String())
 
 	if printSessionName {
@@ -190,6 +314,9 @@ func runSessionList(ctx context.Context, logger *slog.Logger, projectsCfg *proje
 	for _, session := range projSessions {
 		// Extract project name from session name (proj-org-name -> org/name)
 		if projectName := extractProjectFromSession(session); projectName != "" {
+			if workspace := projects.WorkspaceFromSessionName(sessionPrefix, session); workspace != "" {
+				projectName += ":" + workspace
+			}
 			fmt.Printf("  %s -> %s\n", session, projectName)
 		} else {
 			fmt.Printf("  %s\n", session)
@@ -255,40 +382,35 @@ func newTmuxServiceFromEnv(logger *slog.Logger) *TmuxService {
 }
 
 // generateSessionName creates a tmux session name from a project.
-// Format: proj-<org>_<name> (underscore separates org from name).
+// Format: proj-<org>_<name> (underscore separates org from name), prefixed
+// with the host for the projects of other Git servers than GitHub.
 func generateSessionName(project *projects.Project) string {
-	org := strings.ReplaceAll(project.Organisation, ".", "-")
-	name := strings.ReplaceAll(project.Name, ".", "-")
-	return fmt.Sprintf("%s%s_%s", sessionPrefix, org, name)
+	return projects.SessionName(sessionPrefix, project)
 }
 
 // extractProjectFromSession extracts project name from session name.
 // Handles both new format (proj-org_name) and legacy format (proj-org-name).
 func extractProjectFromSession(sessionName string) string {
-	if !strings.HasPrefix(sessionName, sessionPrefix) {
-		return ""
-	}
-
-	// Remove session prefix
-	remainder := strings.TrimPrefix(sessionName, sessionPrefix)
+	return projects.ProjectFromSessionName(sessionPrefix, sessionName)
+}
 
-	// New format: split by underscore (unambiguous separator)
-	if strings.Contains(remainder, "_") {
-		parts := strings.SplitN(remainder, "_", 2)
-		if len(parts) == 2 {
-			return fmt.Sprintf("%s/%s", parts[0], parts[1])
-		}
+// startSessionTasks starts the session tasks of the project's .proj.toml,
+// each in a new pane of the session's first window.
+func startSessionTasks(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, sessionName string, project *projects.Project) error {
+	settings, err := projects.LoadSettings(project.Path)
+	if err != nil {
+		return err
 	}
 
-	// Legacy format fallback: split by hyphen (ambiguous for hyphenated orgs)
-	parts := strings.Split(remainder, "-")
-	if len(parts) < 2 {
-		return ""
+	for _, task := range settings.SessionTasks {
+		pane, err := tmuxSvc.SplitWindow(ctx, sessionName, project.Path)
+		if err != nil {
+			return err
+		}
+		if err := tmuxSvc.SendCommand(ctx, pane, settings.Tasks[task]); err != nil {
+			return err
+		}
+		logger.Debug("session task started", "session", sessionName, "task", task, "pane", pane)
 	}
-
-	// Simple heuristic: assume last part is name, everything before is org
-	name := parts[len(parts)-1]
-	org := strings.Join(parts[:len(parts)-1], "-")
-
-	return fmt.Sprintf("%s/%s", org, name)
+	return nil
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gfanton/projects"
+)
+
+// runSetSessionCreate creates the session of the project set name, with a
+// window per cloned project of the set, then switches to it or prints its
+// name like runSessionCreate.
+func runSetSessionCreate(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, name string, autoSwitch bool, printSessionName bool) error {
+	projs, err := projects.NewProjectService(projectsCfg, projectsLogger).ParseSet(name)
+	if err != nil {
+		return err
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	sessionName := projects.SetSessionName(sessionPrefix, name)
+	exists, err := tmuxSvc.SessionExists(ctx, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	if exists {
+		logger.Info("session already exists", "session", sessionName)
+	} else if err := createSetSession(ctx, logger, tmuxSvc, sessionName, name, projs); err != nil {
+		return err
+	}
+
+	if printSessionName {
+		fmt.Println(sessionName)
+		return nil
+	}
+	if autoSwitch {
+		return tmuxSvc.SwitchSession(ctx, sessionName)
+	}
+	return nil
+}
+
+// createSetSession creates the session of a project set, with a window per
+// cloned project named after it, whose first pane gets the variables of
+// projectEnvironment. Projects not cloned are skipped with a warning.
+func createSetSession(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, sessionName, name string, projs []*projects.Project) error {
+	var cloned []*projects.Project
+	for _, p := range projs {
+		if _, err := os.Stat(p.Path); err != nil {
+			logger.Warn("project of set not cloned", "set", name, "project", p.String())
+			continue
+		}
+		cloned = append(cloned, p)
+	}
+	if len(cloned) == 0 {
+		return fmt.Errorf("no project of set %s%s is cloned, run 'proj get %s%s'", projects.SetPrefix, name, projects.SetPrefix, name)
+	}
+
+	if err := tmuxSvc.NewSession(ctx, sessionName, cloned[0].Path); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	// The first window is replaced by the project ones, created with their
+	// environment
+	initial, err := tmuxSvc.WindowID(ctx, sessionName+":")
+	if err != nil {
+		return err
+	}
+
+	names := setWindowNames(cloned)
+	var first string
+	for i, p := range cloned {
+		var env []string
+		for _, v := range projectEnvironment(p, "") {
+			env = append(env, v.Name+"="+v.Value)
+		}
+		id, err := tmuxSvc.CreateWindow(ctx, sessionName, names[i], p.Path, env...)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			first = id
+		}
+	}
+	if err := tmuxSvc.KillWindow(ctx, sessionName, initial); err != nil {
+		return err
+	}
+	if err := tmuxSvc.SelectWindow(ctx, first); err != nil {
+		logger.Warn("failed to select the first window", "session", sessionName, "error", err)
+	}
+
+	logger.Info("session created", "session", sessionName, "set", name, "projects", len(cloned))
+	return nil
+}
+
+// setWindowNames returns the window names of the projects of a set: their
+// name, or "<org>_<name>" when several projects share it, dots replaced with
+// dashes like SessionName.
+func setWindowNames(projs []*projects.Project) []string {
+	count := make(map[string]int)
+	for _, p := range projs {
+		count[p.Name]++
+	}
+
+	names := make([]string, len(projs))
+	for i, p := range projs {
+		name := p.Name
+		if count[p.Name] > 1 {
+			name = p.Organisation + "_" + p.Name
+		}
+		names[i] = strings.ReplaceAll(name, ".", "-")
+	}
+	return names
+}
@@ -0,0 +1,428 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects"
+)
+
+const (
+	// snapshotVersion is the current on-disk format version of snapshots.
+	snapshotVersion = 1
+
+	// defaultSnapshot is the snapshot saved and restored without name.
+	defaultSnapshot = "default"
+
+	// restoredOption is the server option set once the snapshot restored on
+	// start, so that reloading the tmux configuration doesn't restore it
+	// again.
+	restoredOption = "@proj_restored"
+
+	// paneFormat is the list-panes format read by parsePanes.
+	paneFormat = "#{window_index}\t#{window_name}\t#{window_layout}\t#{window_active}\t#{@proj_workspace}\t#{@proj_window_name}\t#{pane_current_path}"
+)
+
+// fileNamePattern matches valid snapshot and layout names, used as file names.
+var fileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// sessionSnapshot is the saved set of project sessions.
+type sessionSnapshot struct {
+	Version  int               `json:"version"`
+	Saved    time.Time         `json:"saved"`
+	Sessions []snapshotSession `json:"sessions"`
+}
+
+// snapshotSession is a project session of a snapshot.
+type snapshotSession struct {
+	Name    string           `json:"name"`
+	Project string           `json:"project"`
+	Windows []snapshotWindow `json:"windows"`
+}
+
+// snapshotWindow is a window of a snapshotSession.
+type snapshotWindow struct {
+	Name      string   `json:"name"`
+	Workspace string   `json:"workspace,omitempty"` // branch of the workspace window
+	Renamed   bool     `json:"renamed,omitempty"`   // workspace window renamed by hand
+	Layout    string   `json:"layout"`
+	Active    bool     `json:"active,omitempty"`
+	Panes     []string `json:"panes"` // working directory of each pane
+}
+
+// snapshotPath returns the file of the snapshot name:
+// $XDG_STATE_HOME/proj/tmux-sessions/<name>.json, defaulting to
+// ~/.local/state.
+func snapshotPath(name string) (string, error) {
+	if !fileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name '%s': must start with a letter or digit and contain only letters, digits, '.', '_' and '-'", name)
+	}
+
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		stateDir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateDir, "proj", "tmux-sessions", name+".json"), nil
+}
+
+// savedSnapshots returns the names of the saved snapshots, sorted.
+func savedSnapshots() []string {
+	path, err := snapshotPath(defaultSnapshot)
+	if err != nil {
+		return nil
+	}
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.json"))
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readSnapshot reads the snapshot at path.
+func readSnapshot(path string) (*sessionSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot sessionSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d in %s", snapshot.Version, path)
+	}
+	for _, session := range snapshot.Sessions {
+		for _, window := range session.Windows {
+			if len(window.Panes) == 0 {
+				return nil, fmt.Errorf("invalid snapshot %s: window %s of %s has no pane", path, window.Name, session.Name)
+			}
+		}
+	}
+	return &snapshot, nil
+}
+
+// writeSnapshot writes snapshot to path, replacing it atomically.
+func writeSnapshot(path string, snapshot *sessionSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// parsePanes returns the windows of the panes listed with paneFormat, in
+// window order.
+func parsePanes(lines []string) []snapshotWindow {
+	var windows []snapshotWindow
+	index := make(map[string]int)
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\t", 7)
+		if len(fields) != 7 {
+			continue
+		}
+
+		i, ok := index[fields[0]]
+		if !ok {
+			i = len(windows)
+			index[fields[0]] = i
+			window := tmuxWindow{Name: fields[1], Workspace: fields[4], Given: fields[5]}
+			windows = append(windows, snapshotWindow{
+				Name:      fields[1],
+				Workspace: fields[4],
+				Renamed:   window.Workspace != "" && window.namedByHand(),
+				Layout:    fields[2],
+				Active:    fields[3] == "1",
+			})
+		}
+		windows[i].Panes = append(windows[i].Panes, fields[6])
+	}
+	return windows
+}
+
+// runSessionSave saves the project sessions to the snapshot name.
+func runSessionSave(ctx context.Context, w io.Writer, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, name string) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	sessions, err := tmuxSvc.ListSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	snapshot := &sessionSnapshot{Version: snapshotVersion, Saved: time.Now()}
+	for _, session := range sessions {
+		if !strings.HasPrefix(session, sessionPrefix) {
+			continue
+		}
+
+		saved, err := saveSession(ctx, tmuxSvc, projectsCfg, projectsLogger, session)
+		if err != nil {
+			logger.Warn("skipping session", "session", session, "error", err)
+			continue
+		}
+		snapshot.Sessions = append(snapshot.Sessions, *saved)
+	}
+
+	if len(snapshot.Sessions) == 0 {
+		return errors.New("no project sessions to save")
+	}
+	if err := writeSnapshot(path, snapshot); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Saved %d project sessions to %s\n", len(snapshot.Sessions), path)
+	return nil
+}
+
+// saveSession returns the snapshot of the project session.
+func saveSession(ctx context.Context, tmuxSvc *TmuxService, projectsCfg *projects.Config, projectsLogger projects.Logger, session string) (*snapshotSession, error) {
+	project, err := sessionProject(ctx, tmuxSvc, projects.NewProjectService(projectsCfg, projectsLogger), session)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := tmuxSvc.ListPanes(ctx, session, paneFormat)
+	if err != nil {
+		return nil, err
+	}
+	windows := parsePanes(lines)
+
+	// Windows named after a workspace and opened in it are workspace windows
+	// too, when created before proj-tmux marked them
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	for i, window := range windows {
+		if window.Workspace != "" {
+			continue
+		}
+		wsPath := workspaceSvc.WorkspacePath(*project, window.Name)
+		if dir := window.Panes[0]; dir == wsPath || strings.HasPrefix(dir, wsPath+string(filepath.Separator)) {
+			windows[i].Workspace = window.Name
+		}
+	}
+
+	return &snapshotSession{Name: session, Project: project.String(), Windows: windows}, nil
+}
+
+// sessionProject returns the project of the project session.
+func sessionProject(ctx context.Context, tmuxSvc *TmuxService, projectSvc *projects.ProjectService, session string) (*projects.Project, error) {
+	// Session names replace dots: prefer the project path of the session
+	if path := tmuxSvc.Environment(ctx, session, "PROJ_PATH"); path != "" {
+		if project, err := projectSvc.FindFromPath(path); err == nil {
+			return project, nil
+		}
+	}
+
+	project, err := projectSvc.ParseProject(extractProjectFromSession(session))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the project of session %s: %w", session, err)
+	}
+	return project, nil
+}
+
+// runSessionRestore recreates the sessions of the snapshot name that don't
+// exist.
+func runSessionRestore(ctx context.Context, w io.Writer, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, name string) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := readSnapshot(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if saved := savedSnapshots(); len(saved) > 0 {
+			return fmt.Errorf("no saved sessions named %s (saved: %s)", name, strings.Join(saved, ", "))
+		}
+		return fmt.Errorf("no saved sessions named %s", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	var restored, existing int
+	var failed []string
+	for _, saved := range snapshot.Sessions {
+		exists, err := tmuxSvc.SessionExists(ctx, saved.Name)
+		if err != nil {
+			return fmt.Errorf("failed to check if session exists: %w", err)
+		}
+		if exists {
+			fmt.Fprintf(w, "%s: exists, kept\n", saved.Name)
+			existing++
+			continue
+		}
+
+		if err := restoreSession(ctx, logger, tmuxSvc, projectsCfg, projectsLogger, saved); err != nil {
+			fmt.Fprintf(w, "%s: failed: %v\n", saved.Name, err)
+			failed = append(failed, saved.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s: restored %d windows\n", saved.Name, len(saved.Windows))
+		restored++
+	}
+
+	summary := fmt.Sprintf("Restored %d of %d project sessions", restored, len(snapshot.Sessions))
+	if existing > 0 {
+		summary += fmt.Sprintf(", %d already existing", existing)
+	}
+	fmt.Fprintln(w, summary)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restore %d sessions: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runSessionRestoreOnStart restores the snapshot name once per tmux server,
+// silently, for 'run-shell' in the tmux configuration. A missing snapshot is
+// not an error.
+func runSessionRestoreOnStart(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, name string) error {
+	path, err := snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	if tmuxSvc.GlobalOption(ctx, restoredOption) == "on" {
+		logger.Debug("sessions already restored by this server", "snapshot", name)
+		return nil
+	}
+	if err := tmuxSvc.SetGlobalOption(ctx, restoredOption, "on"); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		logger.Debug("no sessions to restore", "snapshot", name)
+		return nil
+	}
+	return runSessionRestore(ctx, io.Discard, logger, projectsCfg, projectsLogger, name)
+}
+
+// restoreSession recreates the session saved, with its windows, panes and
+// layouts. Missing workspaces are added again, and panes whose directory
+// is gone are opened in the project.
+func restoreSession(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, projectsCfg *projects.Config, projectsLogger projects.Logger, saved snapshotSession) error {
+	project, err := projects.NewProjectService(projectsCfg, projectsLogger).ParseProject(saved.Project)
+	if err != nil {
+		return fmt.Errorf("invalid project %s: %w", saved.Project, err)
+	}
+	if _, err := os.Stat(project.Path); err != nil {
+		return fmt.Errorf("project %s not found at %s", saved.Project, project.Path)
+	}
+
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	for _, window := range saved.Windows {
+		if window.Workspace == "" {
+			continue
+		}
+		if _, err := os.Stat(workspaceSvc.WorkspacePath(*project, window.Workspace)); err == nil {
+			continue
+		}
+		if err := workspaceSvc.Add(ctx, *project, window.Workspace); err != nil {
+			logger.Warn("failed to add workspace", "project", saved.Project, "workspace", window.Workspace, "error", err)
+		}
+	}
+
+	firstDir := project.Path
+	if len(saved.Windows) > 0 {
+		firstDir = existingDir(saved.Windows[0].Panes[0], project.Path)
+	}
+	if err := tmuxSvc.NewSession(ctx, saved.Name, firstDir); err != nil {
+		return err
+	}
+	if err := applySessionEnvironment(ctx, logger, tmuxSvc, saved.Name, project); err != nil {
+		logger.Warn("failed to set session environment", "session", saved.Name, "error", err)
+	}
+
+	var active string
+	for i, window := range saved.Windows {
+		var id string
+		if i == 0 {
+			if id, err = tmuxSvc.WindowID(ctx, saved.Name+":"); err != nil {
+				return err
+			}
+			if err := tmuxSvc.RenameWindow(ctx, id, window.Name); err != nil {
+				return err
+			}
+		} else {
+			var env []string
+			if window.Workspace != "" {
+				env = append(env, "PROJ_WORKSPACE="+window.Workspace)
+			}
+			if id, err = tmuxSvc.CreateWindow(ctx, saved.Name, window.Name, existingDir(window.Panes[0], project.Path), env...); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case window.Renamed:
+			if err := tmuxSvc.SetWindowOption(ctx, id, workspaceWindowOption, window.Workspace); err != nil {
+				return err
+			}
+		case window.Workspace != "":
+			if err := markWorkspaceWindow(ctx, tmuxSvc, id, window.Workspace, window.Name); err != nil {
+				return err
+			}
+		}
+
+		for _, dir := range window.Panes[1:] {
+			if _, err := tmuxSvc.SplitWindow(ctx, id, existingDir(dir, project.Path)); err != nil {
+				return err
+			}
+		}
+		if len(window.Panes) > 1 && window.Layout != "" {
+			if err := tmuxSvc.SelectLayout(ctx, id, window.Layout); err != nil {
+				logger.Warn("failed to restore layout", "session", saved.Name, "window", window.Name, "error", err)
+			}
+		}
+		if window.Active {
+			active = id
+		}
+	}
+
+	if active != "" {
+		return tmuxSvc.SelectWindow(ctx, active)
+	}
+	return nil
+}
+
+// existingDir returns dir if it exists, fallback otherwise.
+func existingDir(dir, fallback string) string {
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir
+	}
+	return fallback
+}
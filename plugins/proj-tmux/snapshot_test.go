@@ -0,0 +1,85 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePanes(t *testing.T) {
+	lines := []string{
+		"1\tzsh\tb25f,80x24,0,0,1\t0\t\t\t/code/gfanton/projects",
+		"2\tprojects:feature\tc3a1,80x24,0,0{40x24,0,0,2,39x24,41,0,3}\t1\tfeature\tprojects:feature\t/code/.workspace/gfanton/projects/feature",
+		"2\tprojects:feature\tc3a1,80x24,0,0{40x24,0,0,2,39x24,41,0,3}\t1\tfeature\tprojects:feature\t/code/.workspace/gfanton/projects/feature/cmd",
+		"3\ttests\tb25f,80x24,0,0,4\t0\tfix\tfix\t/code/.workspace/gfanton/projects/fix",
+		"invalid",
+	}
+
+	want := []snapshotWindow{
+		{Name: "zsh", Layout: "b25f,80x24,0,0,1", Panes: []string{"/code/gfanton/projects"}},
+		{
+			Name:      "projects:feature",
+			Workspace: "feature",
+			Layout:    "c3a1,80x24,0,0{40x24,0,0,2,39x24,41,0,3}",
+			Active:    true,
+			Panes:     []string{"/code/.workspace/gfanton/projects/feature", "/code/.workspace/gfanton/projects/feature/cmd"},
+		},
+		{Name: "tests", Workspace: "fix", Renamed: true, Layout: "b25f,80x24,0,0,4", Panes: []string{"/code/.workspace/gfanton/projects/fix"}},
+	}
+	if got := parsePanes(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePanes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	for _, name := range []string{"", ".hidden", "a/b"} {
+		if _, err := snapshotPath(name); err == nil {
+			t.Errorf("snapshotPath(%q) succeeded, want error", name)
+		}
+	}
+
+	path, err := snapshotPath("release-1.2")
+	if err != nil {
+		t.Fatalf("snapshotPath() failed: %v", err)
+	}
+	if want := filepath.Join("proj", "tmux-sessions", "release-1.2.json"); !strings.HasSuffix(path, want) {
+		t.Errorf("snapshotPath() = %q, want suffix %q", path, want)
+	}
+
+	snapshot := &sessionSnapshot{
+		Version: snapshotVersion,
+		Saved:   time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Sessions: []snapshotSession{{
+			Name:    "proj-gfanton_projects",
+			Project: "gfanton/projects",
+			Windows: []snapshotWindow{{Name: "feature", Workspace: "feature", Panes: []string{"/code"}}},
+		}},
+	}
+	if err := writeSnapshot(path, snapshot); err != nil {
+		t.Fatalf("writeSnapshot() failed: %v", err)
+	}
+
+	got, err := readSnapshot(path)
+	if err != nil {
+		t.Fatalf("readSnapshot() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("readSnapshot() = %+v, want %+v", got, snapshot)
+	}
+	if saved := savedSnapshots(); !reflect.DeepEqual(saved, []string{"release-1.2"}) {
+		t.Errorf("savedSnapshots() = %v, want [release-1.2]", saved)
+	}
+
+	// Windows without pane can't be restored
+	snapshot.Sessions[0].Windows[0].Panes = nil
+	if err := writeSnapshot(path, snapshot); err != nil {
+		t.Fatalf("writeSnapshot() failed: %v", err)
+	}
+	if _, err := readSnapshot(path); err == nil {
+		t.Error("readSnapshot() of a window without pane succeeded, want error")
+	}
+}
@@ -6,15 +6,19 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gfanton/projects"
 	"github.com/peterbourgon/ff/v4"
 )
 
 type statusConfig struct {
-	Format string
-	Short  bool
+	Format   string
+	Short    bool
+	CacheTTL time.Duration
+	Pane     string
 }
 
 func newStatusCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
@@ -22,6 +26,8 @@ func newStatusCommand(logger *slog.Logger, projectsCfg *projects.Config, project
 	fs := ff.NewFlagSet("status")
 	fs.StringVar(&statusCfg.Format, 0, "format", "#{project}", "status format string")
 	fs.BoolVar(&statusCfg.Short, 0, "short", "show short status")
+	fs.DurationVar(&statusCfg.CacheTTL, 0, "cache-ttl", defaultStatusCacheTTL, "reuse a status computed less than this long ago (0 disables the cache)")
+	fs.StringVar(&statusCfg.Pane, 0, "pane", os.Getenv("TMUX_PANE"), "tmux pane id used as cache key (pass '#{pane_id}' from status-right)")
 
 	return &ff.Command{
 		Name:      "status",
@@ -37,17 +43,55 @@ Format variables:
   #{session}      Tmux session name
   #{window}       Tmux window name
 
+Pull request variables, for workspaces added with #123:
+  #{pr}           Pull request of the workspace, e.g. #123
+  #{pr_url}       Web page of the pull request
+
+Git variables (computed with go-git, without spawning git):
+  #{branch}       Current branch (or short commit hash when detached)
+  #{dirty}        "*" if the worktree has uncommitted changes, empty otherwise
+  #{ahead}        Commits ahead of the upstream branch
+  #{behind}       Commits behind the upstream branch
+
 FLAGS:
   --format        Custom format string (default: "#{project}")
-  --short         Show abbreviated status`,
+  --short         Show abbreviated status
+  --cache-ttl     Status cache duration (default: 2s, 0 disables)
+  --pane          Pane id used to key the cache (default: $TMUX_PANE)
+
+CACHE:
+  The rendered status is cached per tmux session, pane, working directory
+  and format, so repeated invocations within --cache-ttl are answered
+  without running tmux or reading the repository.`,
 		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
-			return runStatus(ctx, logger, projectsCfg, projectsLogger, statusCfg.Format, statusCfg.Short)
+			return runStatus(ctx, logger, projectsCfg, projectsLogger, *statusCfg)
 		},
 	}
 }
 
-func runStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, format string, short bool) error {
+func runStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, statusCfg statusConfig) error {
+	key := statusCacheKey(statusCfg)
+	if status, ok := readStatusCache(key, statusCfg.CacheTTL); ok {
+		fmt.Print(status)
+		return nil
+	}
+
+	status := renderStatus(ctx, logger, projectsCfg, projectsLogger, statusCfg)
+	if statusCfg.CacheTTL > 0 {
+		if err := writeStatusCache(key, status); err != nil {
+			logger.Debug("failed to write status cache", "error", err)
+		}
+	}
+
+	fmt.Print(status)
+	return nil
+}
+
+// renderStatus computes the status line, querying tmux and the repository.
+func renderStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, statusCfg statusConfig) string {
+	format, short := statusCfg.Format, statusCfg.Short
+
 	tmuxSvc := NewTmuxService(logger)
 	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
 	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
@@ -59,17 +103,20 @@ func runStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.C
 		currentSession = ""
 	}
 
-	var currentWindow string
+	var window tmuxWindow
 	if currentSession != "" {
-		cmd := []string{"tmux", "display-message", "-p", "#{window_name}"}
+		cmd := []string{"tmux", "display-message", "-p", windowListFormat}
 		if output, err := runCommand(ctx, cmd); err == nil {
-			currentWindow = strings.TrimSpace(output)
+			if windows := parseWindows([]string{strings.TrimRight(output, "\n")}); len(windows) == 1 {
+				window = windows[0]
+			}
 		}
 	}
+	currentWindow := window.Name
 
 	// Determine current project
 	var currentProject *projects.Project
-	var currentWorkspace string
+	var currentWorkspace, workspacePath string
 
 	// Try to extract from tmux session name first
 	if currentSession != "" && strings.HasPrefix(currentSession, sessionPrefix) {
@@ -77,13 +124,19 @@ func runStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.C
 			if proj, err := projectSvc.ParseProject(projectStr); err == nil {
 				currentProject = proj
 
-				// Check if current window corresponds to a workspace
-				if currentWindow != "" && currentWindow != "0" {
+				// Check if current window corresponds to a workspace, by the
+				// workspace proj-tmux marked it with or by its name
+				key := window.Workspace
+				if key == "" {
+					key = currentWindow
+				}
+				if key != "" && key != "0" {
 					workspaces, err := workspaceSvc.List(ctx, *currentProject)
 					if err == nil {
 						for _, ws := range workspaces {
-							if ws.Branch == currentWindow {
-								currentWorkspace = ws.Branch
+							// PR workspaces are also opened in a window named after their #123 argument
+							if ws.Branch == key || ws.Path == workspaceSvc.WorkspacePath(*currentProject, key) {
+								currentWorkspace, workspacePath = ws.Branch, ws.Path
 								break
 							}
 						}
@@ -106,21 +159,54 @@ func runStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.C
 	// If no project found, output empty or minimal status
 	if currentProject == nil {
 		if short {
-			fmt.Print("")
-		} else {
-			fmt.Print("no project")
+			return ""
 		}
-		return nil
+		return "no project"
 	}
 
-	// Build status output
-	status := buildStatus(currentProject, currentWorkspace, currentSession, currentWindow, format, short)
-	fmt.Print(status)
+	// Only look at the repository when the format asks for it
+	var git *gitStatus
+	if !short && usesGitPlaceholders(format) {
+		path := currentProject.Path
+		if workspacePath != "" {
+			path = workspacePath
+		}
 
-	return nil
+		git, err = readGitStatus(path)
+		if err != nil {
+			logger.Debug("failed to read git status", "path", path, "error", err)
+		}
+	}
+
+	var pr *projects.PullRequestInfo
+	if !short && workspacePath != "" && usesPRPlaceholders(format) {
+		if pr, err = workspaceSvc.ReadPullRequest(workspacePath); err != nil {
+			logger.Debug("failed to read pull request", "path", workspacePath, "error", err)
+		}
+	}
+
+	return buildStatus(currentProject, currentWorkspace, currentSession, currentWindow, format, short, git, pr)
 }
 
-func buildStatus(project *projects.Project, workspace, session, window, format string, short bool) string {
+// usesPRPlaceholders reports whether format shows the pull request of the
+// workspace.
+func usesPRPlaceholders(format string) bool {
+	return strings.Contains(format, "#{pr}") || strings.Contains(format, "#{pr_url}")
+}
+
+// gitPlaceholders are the format variables that require reading the repository.
+var gitPlaceholders = []string{"#{branch}", "#{dirty}", "#{ahead}", "#{behind}"}
+
+func usesGitPlaceholders(format string) bool {
+	for _, p := range gitPlaceholders {
+		if strings.Contains(format, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildStatus(project *projects.Project, workspace, session, window, format string, short bool, git *gitStatus, pr *projects.PullRequestInfo) string {
 	if short {
 		if workspace != "" {
 			return fmt.Sprintf("%s:%s", project.Name, workspace)
@@ -145,6 +231,29 @@ func buildStatus(project *projects.Project, workspace, session, window, format s
 	result = strings.ReplaceAll(result, "#{session}", session)
 	result = strings.ReplaceAll(result, "#{window}", window)
 
+	// Git placeholders render empty when the repository can't be read
+	var branch, dirty, ahead, behind string
+	if git != nil {
+		branch = git.Branch
+		if git.Dirty {
+			dirty = "*"
+		}
+		ahead = strconv.Itoa(git.Ahead)
+		behind = strconv.Itoa(git.Behind)
+	}
+	result = strings.ReplaceAll(result, "#{branch}", branch)
+	result = strings.ReplaceAll(result, "#{dirty}", dirty)
+	result = strings.ReplaceAll(result, "#{ahead}", ahead)
+	result = strings.ReplaceAll(result, "#{behind}", behind)
+
+	// Pull request placeholders render empty outside PR workspaces
+	var prNum, prURL string
+	if pr != nil {
+		prNum, prURL = fmt.Sprintf("#%d", pr.Number), pr.URL
+	}
+	result = strings.ReplaceAll(result, "#{pr_url}", prURL)
+	result = strings.ReplaceAll(result, "#{pr}", prNum)
+
 	return result
 }
 
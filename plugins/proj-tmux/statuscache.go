@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultStatusCacheTTL is how long a rendered status is reused. tmux
+// refreshes the status line every status-interval, and once per client, so a
+// couple of seconds removes most of the redundant work.
+const defaultStatusCacheTTL = 2 * time.Second
+
+// statusCacheKey identifies a rendered status: the tmux server and session
+// (from $TMUX), the pane, the working directory and the output options.
+func statusCacheKey(statusCfg statusConfig) string {
+	wd, _ := os.Getwd()
+	parts := []string{
+		os.Getenv("TMUX"),
+		statusCfg.Pane,
+		wd,
+		statusCfg.Format,
+		strconv.FormatBool(statusCfg.Short),
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// statusCacheDir returns where rendered statuses are stored: a per-user
+// directory in shared memory when available, the user cache directory
+// otherwise.
+func statusCacheDir() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return filepath.Join("/dev/shm", fmt.Sprintf("proj-tmux-%d", os.Getuid()))
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	return filepath.Join(cacheDir, "proj", "tmux-status")
+}
+
+// readStatusCache returns the cached status for key if it is younger than ttl.
+func readStatusCache(key string, ttl time.Duration) (string, bool) {
+	if ttl <= 0 {
+		return "", false
+	}
+
+	path := filepath.Join(statusCacheDir(), key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return "", false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	return string(data), true
+}
+
+// writeStatusCache atomically stores status under key.
+func writeStatusCache(key, status string) error {
+	dir := statusCacheDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create status cache directory: %w", err)
+	}
+
+	path := filepath.Join(dir, key)
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, []byte(status), 0600); err != nil {
+		return fmt.Errorf("failed to write status cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace status cache: %w", err)
+	}
+
+	return nil
+}
@@ -11,8 +11,10 @@ import (
 )
 
 type switchConfig struct {
-	CreateSession bool
-	CreateWindow  bool
+	CreateSession    bool
+	CreateWindow     bool
+	WorkspaceSession bool
+	SessionPath      string
 }
 
 func newSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
@@ -20,6 +22,8 @@ func newSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config, project
 	fs := ff.NewFlagSet("switch")
 	fs.BoolVar(&switchCfg.CreateSession, 0, "create-session", "create session if it doesn't exist")
 	fs.BoolVar(&switchCfg.CreateWindow, 0, "create-window", "create window if it doesn't exist (for workspace targets)")
+	fs.BoolVar(&switchCfg.WorkspaceSession, 0, "workspace-session", "open workspace targets in a session of their own instead of a window")
+	fs.StringVar(&switchCfg.SessionPath, 0, "session-path", "", "working directory of the session: project or target (default: @proj_session_path, or project)")
 
 	return &ff.Command{
 		Name:      "switch",
@@ -31,9 +35,20 @@ Targets can be:
   project               Switch to project session (e.g., 'gfanton/projects')
   project:workspace     Switch to workspace window (e.g., 'gfanton/projects:feature')
 
+Workspace targets open in a window of the project session, or in a session
+of their own, named proj-<org>_<name>~<workspace>, with --workspace-session
+or the @proj_workspace_session tmux option set to on.
+
+Windows and panes created without a directory, such as with the new-window
+key binding, open in the project, or in the directory of the project or
+workspace last switched to with --session-path target or the
+@proj_session_path tmux option set to target.
+
 FLAGS:
-  --create-session    Create session if it doesn't exist (default: true)
-  --create-window     Create window if it doesn't exist for workspace targets (default: true)`,
+  --create-session       Create session if it doesn't exist (default: true)
+  --create-window        Create window if it doesn't exist for workspace targets (default: true)
+  --workspace-session    Open workspace targets in a session of their own
+  --session-path         Session working directory: project or target`,
 		Flags: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) < 1 {
@@ -41,12 +56,26 @@ FLAGS:
 			}
 
 			target := args[0]
-			return runSwitch(ctx, logger, projectsCfg, projectsLogger, target, switchCfg.CreateSession, switchCfg.CreateWindow)
+			return runSwitch(ctx, logger, projectsCfg, projectsLogger, target, *switchCfg)
 		},
 	}
 }
 
-func runSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, target string, createSession, createWindow bool) error {
+func runSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, target string, switchCfg switchConfig) error {
+	tmuxSvc := NewTmuxService(logger)
+
+	sessionPath := switchCfg.SessionPath
+	if sessionPath == "" {
+		sessionPath = tmuxSvc.GlobalOption(ctx, sessionPathOption)
+	}
+	switch sessionPath {
+	case "", sessionPathProject, sessionPathTarget:
+	default:
+		return fmt.Errorf("invalid session path '%s' (expected %s or %s)", sessionPath, sessionPathProject, sessionPathTarget)
+	}
+
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
 	// Parse target: project or project:workspace
 	if strings.Contains(target, ":") {
 		// Workspace target
@@ -56,40 +85,67 @@ func runSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.C
 
 		logger.Debug("switching to workspace", "project", projectName, "workspace", workspace)
 
-		if createWindow {
-			return runWindowSwitch(ctx, logger, projectsCfg, projectsLogger, workspace, projectName)
+		// Workspace sessions are created in the workspace
+		if switchCfg.WorkspaceSession || tmuxSvc.GlobalOption(ctx, workspaceSessionOption) == "on" {
+			return runWorkspaceSessionSwitch(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, switchCfg.CreateSession)
+		}
+
+		project, err := projectSvc.ParseProject(projectName)
+		if err != nil {
+			return fmt.Errorf("invalid project name: %w", err)
+		}
+		sessionName := generateSessionName(project)
+
+		if switchCfg.CreateWindow {
+			if err := runWindowSwitch(ctx, logger, projectsCfg, projectsLogger, workspace, projectName); err != nil {
+				return err
+			}
 		} else {
 			// Just switch to existing window
-			projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
-			project, err := projectSvc.ParseProject(projectName)
+			lines, err := tmuxSvc.ListWindowsFormat(ctx, sessionName, windowListFormat)
 			if err != nil {
-				return fmt.Errorf("invalid project name: %w", err)
+				return err
 			}
 
-			sessionName := generateSessionName(project)
-			windowName := workspace
+			window := findWorkspaceWindow(parseWindows(lines), workspace)
+			if window == nil {
+				return fmt.Errorf("no window for workspace %s in session %s", workspace, sessionName)
+			}
+			if err := tmuxSvc.SwitchWindow(ctx, sessionName, window.ID); err != nil {
+				return err
+			}
+		}
 
-			tmuxSvc := NewTmuxService(logger)
-			return tmuxSvc.SwitchWindow(ctx, sessionName, windowName)
+		if sessionPath == sessionPathTarget {
+			workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+			return tmuxSvc.SetSessionPath(ctx, sessionName, workspaceSvc.WorkspacePath(*project, workspace))
 		}
-	} else {
-		// Project session target
-		projectName := target
-		logger.Debug("switching to project session", "project", projectName)
+		return nil
+	}
 
-		if createSession {
-			return runSessionSwitch(ctx, logger, projectsCfg, projectsLogger, projectName)
-		} else {
-			// Just switch to existing session
-			projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
-			project, err := projectSvc.ParseProject(projectName)
-			if err != nil {
-				return fmt.Errorf("invalid project name: %w", err)
-			}
+	// Project session target
+	projectName := target
+	logger.Debug("switching to project session", "project", projectName)
 
-			sessionName := generateSessionName(project)
-			tmuxSvc := NewTmuxService(logger)
-			return tmuxSvc.SwitchSession(ctx, sessionName)
+	project, err := projectSvc.ParseProject(projectName)
+	if err != nil {
+		return fmt.Errorf("invalid project name: %w", err)
+	}
+	sessionName := generateSessionName(project)
+
+	if switchCfg.CreateSession {
+		if err := runSessionSwitch(ctx, logger, projectsCfg, projectsLogger, projectName); err != nil {
+			return err
+		}
+	} else {
+		// Just switch to existing session
+		if err := tmuxSvc.SwitchSession(ctx, sessionName); err != nil {
+			return err
 		}
 	}
+
+	if sessionPath == sessionPathTarget {
+		return tmuxSvc.SetSessionPath(ctx, sessionName, project.Path)
+	}
+	return nil
 }
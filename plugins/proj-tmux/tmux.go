@@ -135,11 +135,17 @@ func (s *TmuxService) WindowExists(ctx context.Context, sessionName, windowName
 	return false, nil
 }
 
-// NewWindow creates a new window in a session
-func (s *TmuxService) NewWindow(ctx context.Context, sessionName, windowName, workingDir string) error {
+// NewWindow creates a new window in a session. env entries (NAME=value) are
+// set in the environment of the window's first pane.
+func (s *TmuxService) NewWindow(ctx context.Context, sessionName, windowName, workingDir string, env ...string) error {
 	s.logger.Debug("creating tmux window", "session", sessionName, "window", windowName, "dir", workingDir)
 
-	cmd := s.buildTmuxCommand(ctx, "new-window", "-t", sessionName, "-n", windowName, "-c", workingDir)
+	args := []string{"new-window", "-t", sessionName, "-n", windowName, "-c", workingDir}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+
+	cmd := s.buildTmuxCommand(ctx, args...)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to create window %s in session %s: %w", windowName, sessionName, err)
 	}
@@ -148,6 +154,33 @@ func (s *TmuxService) NewWindow(ctx context.Context, sessionName, windowName, wo
 	return nil
 }
 
+// SplitWindow splits the current pane of target without selecting the new
+// pane, and returns the new pane id.
+func (s *TmuxService) SplitWindow(ctx context.Context, target, workingDir string) (string, error) {
+	s.logger.Debug("splitting tmux window", "target", target, "dir", workingDir)
+
+	cmd := s.buildTmuxCommand(ctx, "split-window", "-d", "-t", target, "-c", workingDir, "-P", "-F", "#{pane_id}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to split window %s: %w", target, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// SendCommand types command in the pane and runs it, leaving the pane's
+// shell usable once the command exits.
+func (s *TmuxService) SendCommand(ctx context.Context, pane, command string) error {
+	s.logger.Debug("sending tmux command", "pane", pane, "command", command)
+
+	if err := s.buildTmuxCommand(ctx, "send-keys", "-t", pane, "-l", command).Run(); err != nil {
+		return fmt.Errorf("failed to send command to pane %s: %w", pane, err)
+	}
+	if err := s.buildTmuxCommand(ctx, "send-keys", "-t", pane, "Enter").Run(); err != nil {
+		return fmt.Errorf("failed to send command to pane %s: %w", pane, err)
+	}
+	return nil
+}
+
 // SwitchWindow switches to a window in a session
 // This first switches to the session (if needed) then selects the window
 func (s *TmuxService) SwitchWindow(ctx context.Context, sessionName, windowName string) error {
@@ -185,6 +218,27 @@ func (s *TmuxService) ListWindows(ctx context.Context, sessionName string) ([]st
 	return windows, nil
 }
 
+// SetEnvironment sets a variable in the session environment, inherited by
+// panes created afterwards.
+func (s *TmuxService) SetEnvironment(ctx context.Context, sessionName, name, value string) error {
+	cmd := s.buildTmuxCommand(ctx, "set-environment", "-t", sessionName, name, value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to set %s in session %s: %w", name, sessionName, err)
+	}
+	return nil
+}
+
+// GlobalOption returns the value of a global tmux option, or an empty string
+// if it is not set.
+func (s *TmuxService) GlobalOption(ctx context.Context, name string) string {
+	cmd := s.buildTmuxCommand(ctx, "show-option", "-gqv", name)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
 // KillSession kills a tmux session
 func (s *TmuxService) KillSession(ctx context.Context, sessionName string) error {
 	s.logger.Debug("killing tmux session", "session", sessionName)
@@ -211,3 +265,126 @@ func (s *TmuxService) KillWindow(ctx context.Context, sessionName, windowName st
 	s.logger.Info("killed tmux window", "session", sessionName, "window", windowName)
 	return nil
 }
+
+// Environment returns the value of a variable of the session environment,
+// or an empty string if it is not set.
+func (s *TmuxService) Environment(ctx context.Context, sessionName, name string) string {
+	cmd := s.buildTmuxCommand(ctx, "show-environment", "-t", sessionName, name)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	_, value, _ := strings.Cut(strings.TrimSpace(string(output)), "=")
+	return value
+}
+
+// ListPanes lists the panes of all windows of a session, one line per pane
+// formatted with format.
+func (s *TmuxService) ListPanes(ctx context.Context, sessionName, format string) ([]string, error) {
+	cmd := s.buildTmuxCommand(ctx, "list-panes", "-s", "-t", sessionName, "-F", format)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes of session %s: %w", sessionName, err)
+	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n"), nil
+}
+
+// WindowID returns the id of the window target, such as "session:" for the
+// current window of a session.
+func (s *TmuxService) WindowID(ctx context.Context, target string) (string, error) {
+	cmd := s.buildTmuxCommand(ctx, "display-message", "-p", "-t", target, "#{window_id}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get window %s: %w", target, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CreateWindow creates a window in a session like NewWindow, without
+// selecting it, and returns its id.
+func (s *TmuxService) CreateWindow(ctx context.Context, sessionName, windowName, workingDir string, env ...string) (string, error) {
+	s.logger.Debug("creating tmux window", "session", sessionName, "window", windowName, "dir", workingDir)
+
+	args := []string{"new-window", "-d", "-t", sessionName, "-n", windowName, "-c", workingDir, "-P", "-F", "#{window_id}"}
+	for _, e := range env {
+		args = append(args, "-e", e)
+	}
+
+	output, err := s.buildTmuxCommand(ctx, args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to create window %s in session %s: %w", windowName, sessionName, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RenameWindow renames the window target.
+func (s *TmuxService) RenameWindow(ctx context.Context, target, windowName string) error {
+	if err := s.buildTmuxCommand(ctx, "rename-window", "-t", target, windowName).Run(); err != nil {
+		return fmt.Errorf("failed to rename window %s: %w", target, err)
+	}
+	return nil
+}
+
+// SelectLayout arranges the panes of the window target following layout, as
+// reported by #{window_layout}.
+func (s *TmuxService) SelectLayout(ctx context.Context, target, layout string) error {
+	if err := s.buildTmuxCommand(ctx, "select-layout", "-t", target, layout).Run(); err != nil {
+		return fmt.Errorf("failed to set the layout of window %s: %w", target, err)
+	}
+	return nil
+}
+
+// SelectWindow makes the window target the current window of its session.
+func (s *TmuxService) SelectWindow(ctx context.Context, target string) error {
+	if err := s.buildTmuxCommand(ctx, "select-window", "-t", target).Run(); err != nil {
+		return fmt.Errorf("failed to select window %s: %w", target, err)
+	}
+	return nil
+}
+
+// SetGlobalOption sets a global tmux option, such as a @user option.
+func (s *TmuxService) SetGlobalOption(ctx context.Context, name, value string) error {
+	if err := s.buildTmuxCommand(ctx, "set-option", "-gq", name, value).Run(); err != nil {
+		return fmt.Errorf("failed to set option %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListWindowsFormat lists the windows of a session, one line per window
+// formatted with format.
+func (s *TmuxService) ListWindowsFormat(ctx context.Context, sessionName, format string) ([]string, error) {
+	cmd := s.buildTmuxCommand(ctx, "list-windows", "-t", sessionName, "-F", format)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows of session %s: %w", sessionName, err)
+	}
+	return strings.Split(strings.TrimRight(string(output), "\n"), "\n"), nil
+}
+
+// SetWindowOption sets an option of the window target, such as a @user
+// option.
+func (s *TmuxService) SetWindowOption(ctx context.Context, target, name, value string) error {
+	if err := s.buildTmuxCommand(ctx, "set-option", "-wq", "-t", target, name, value).Run(); err != nil {
+		return fmt.Errorf("failed to set option %s of window %s: %w", name, target, err)
+	}
+	return nil
+}
+
+// SetSessionPath sets the working directory of the windows and panes created
+// in the session without one, such as by the new-window key binding. tmux
+// only changes it with attach-session -c, which sets it before failing to
+// attach without a terminal: the directory is read back to check it.
+func (s *TmuxService) SetSessionPath(ctx context.Context, sessionName, dir string) error {
+	s.logger.Debug("setting tmux session path", "session", sessionName, "dir", dir)
+
+	_ = s.buildTmuxCommand(ctx, "attach-session", "-t", sessionName, "-c", dir).Run()
+
+	output, err := s.buildTmuxCommand(ctx, "display-message", "-p", "-t", sessionName, "#{session_path}").Output()
+	if err != nil {
+		return fmt.Errorf("failed to get the path of session %s: %w", sessionName, err)
+	}
+	if strings.TrimSpace(string(output)) != dir {
+		return fmt.Errorf("failed to set the path of session %s to %s", sessionName, dir)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -21,10 +22,12 @@ func newWindowCommand(logger *slog.Logger, projectsCfg *projects.Config, project
 Commands:
   create <workspace> [project]    Create window for workspace
   list [project]                  List workspace windows
+  rename [project]                Rename workspace windows after their branch
   switch <workspace> [project]    Switch to workspace window`,
 		Subcommands: []*ff.Command{
 			newWindowCreateCommand(logger, projectsCfg, projectsLogger),
 			newWindowListCommand(logger, projectsCfg, projectsLogger),
+			newWindowRenameCommand(logger, projectsCfg, projectsLogger),
 			newWindowSwitchCommand(logger, projectsCfg, projectsLogger),
 		},
 		Exec: func(ctx context.Context, args []string) error {
@@ -51,8 +54,10 @@ func newWindowCreateCommand(logger *slog.Logger, projectsCfg *projects.Config, p
 		LongHelp: `Create a tmux window for the specified workspace.
 
 The window will be created in the specified session (or the project session
-if not specified) and will be named after the workspace branch. The working
-directory will be set to the workspace path.
+if not specified) and will be named after the workspace branch, or the
+[session] window-name format of the project's .proj.toml, or the
+@proj_window_format tmux option. The working directory will be set to the
+workspace path.
 
 FLAGS:
   --switch     Automatically switch to the created window (default: true)
@@ -91,6 +96,43 @@ func newWindowListCommand(logger *slog.Logger, projectsCfg *projects.Config, pro
 	}
 }
 
+func newWindowRenameCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	var all bool
+	fs := ff.NewFlagSet("window rename")
+	fs.BoolVar(&all, 0, "all", "rename the workspace windows of all project sessions")
+
+	return &ff.Command{
+		Name:      "rename",
+		Usage:     "proj-tmux window rename [--all] [project]",
+		ShortHelp: "Rename workspace windows after their branch",
+		LongHelp: `Rename the workspace windows of the current or specified project session
+after their window name format, to follow branch and dirty state changes.
+Windows renamed by hand keep their name.
+
+The proj-tmux.tmux plugin runs it on window and session switches, when
+@proj_rename_windows is on.
+
+FORMAT:
+  Window names use the placeholders of 'proj-tmux status --format', such as
+  #{name}, #{workspace}, #{branch} and #{dirty}, from the [session]
+  window-name of the project's .proj.toml, or the @proj_window_format tmux
+  option (default: #{branch}). #{dirty} renders the @proj_window_dirty
+  tmux option (default: *) when the worktree has changes.
+
+FLAGS:
+  --all    Rename the workspace windows of all project sessions`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			var projectName string
+			if len(args) > 0 {
+				projectName = args[0]
+			}
+
+			return runWindowRename(ctx, os.Stdout, logger, projectsCfg, projectsLogger, projectName, all)
+		},
+	}
+}
+
 func newWindowSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
 	return &ff.Command{
 		Name:      "switch",
@@ -122,44 +164,9 @@ func runWindowCreate(ctx context.Context, logger *slog.Logger, projectsCfg *proj
 	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
 	tmuxSvc := NewTmuxService(logger)
 
-	// Get workspace details
-	workspaces, err := workspaceSvc.List(ctx, *project)
+	targetWorkspace, err := resolveWorkspace(ctx, logger, workspaceSvc, project, workspace)
 	if err != nil {
-		return fmt.Errorf("failed to list workspaces: %w", err)
-	}
-
-	var targetWorkspace *projects.Workspace
-	for _, ws := range workspaces {
-		if ws.Branch == workspace {
-			targetWorkspace = &ws
-			break
-		}
-	}
-
-	if targetWorkspace == nil {
-		// Auto-create workspace if it doesn't exist
-		logger.Info("workspace not found, creating", "workspace", workspace, "project", project.String())
-		if err := workspaceSvc.Add(ctx, *project, workspace); err != nil {
-			return fmt.Errorf("workspace '%s' not found and auto-create failed: %w", workspace, err)
-		}
-
-		// Re-list workspaces to get the new one
-		workspaces, err = workspaceSvc.List(ctx, *project)
-		if err != nil {
-			return fmt.Errorf("failed to re-list workspaces: %w", err)
-		}
-
-		// Find the newly created workspace
-		for _, ws := range workspaces {
-			if ws.Branch == workspace {
-				targetWorkspace = &ws
-				break
-			}
-		}
-
-		if targetWorkspace == nil {
-			return fmt.Errorf("workspace '%s' created but not found in list", workspace)
-		}
+		return err
 	}
 
 	// Use provided session name or derive from project
@@ -167,7 +174,7 @@ func runWindowCreate(ctx context.Context, logger *slog.Logger, projectsCfg *proj
 	if sessionName == "" {
 		sessionName = generateSessionName(project)
 	}
-	windowName := workspace
+	windowName := newWindowNamer(ctx, logger, tmuxSvc, workspaceSvc, project).name(project, workspace, targetWorkspace.Path)
 
 	logger.Debug("creating window", "project", project.String(), "workspace", workspace, "session", sessionName, "window", windowName)
 
@@ -182,34 +189,47 @@ func runWindowCreate(ctx context.Context, logger *slog.Logger, projectsCfg *proj
 		if err := tmuxSvc.NewSession(ctx, sessionName, project.Path); err != nil {
 			return fmt.Errorf("failed to create session: %w", err)
 		}
+
+		if err := applySessionEnvironment(ctx, logger, tmuxSvc, sessionName, project); err != nil {
+			logger.Warn("failed to set session environment", "session", sessionName, "error", err)
+		}
 	}
 
-	// Check if window already exists
-	windowExists, err := tmuxSvc.WindowExists(ctx, sessionName, windowName)
+	// Check if window already exists, renaming it if its branch changed
+	lines, err := tmuxSvc.ListWindowsFormat(ctx, sessionName, windowListFormat)
 	if err != nil {
 		return fmt.Errorf("failed to check window existence: %w", err)
 	}
 
-	if windowExists {
-		logger.Info("window already exists", "window", windowName, "session", sessionName)
+	if window := findWorkspaceWindow(parseWindows(lines), workspace); window != nil {
+		logger.Info("window already exists", "window", window.Name, "session", sessionName)
+		if _, err := nameWindow(ctx, tmuxSvc, *window, workspace, windowName); err != nil {
+			logger.Warn("failed to rename window", "window", window.Name, "error", err)
+		}
 		if autoSwitch {
-			return tmuxSvc.SwitchWindow(ctx, sessionName, windowName)
+			return tmuxSvc.SwitchWindow(ctx, sessionName, window.ID)
 		}
 		return nil
 	}
 
 	// Create new window
-	if err := tmuxSvc.NewWindow(ctx, sessionName, windowName, targetWorkspace.Path); err != nil {
+	// The session environment is shared by all windows: the workspace is
+	// passed to the window's pane directly
+	id, err := tmuxSvc.CreateWindow(ctx, sessionName, windowName, targetWorkspace.Path, "PROJ_WORKSPACE="+workspace)
+	if err != nil {
 		return fmt.Errorf("failed to create window: %w", err)
 	}
+	if err := markWorkspaceWindow(ctx, tmuxSvc, id, workspace, windowName); err != nil {
+		return err
+	}
 
 	logger.Info("window created", "window", windowName, "session", sessionName, "workspace", targetWorkspace.Path)
 
 	if autoSwitch {
-		return tmuxSvc.SwitchWindow(ctx, sessionName, windowName)
+		return tmuxSvc.SwitchWindow(ctx, sessionName, id)
 	}
-
-	return nil
+	// Like new-window, make it the current window of its session
+	return tmuxSvc.SelectWindow(ctx, id)
 }
 
 func runWindowList(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectName string) error {
@@ -253,20 +273,131 @@ func runWindowList(ctx context.Context, logger *slog.Logger, projectsCfg *projec
 
 func runWindowSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, workspace, projectName string) error {
 	// Create window if it doesn't exist, then switch (use project-derived session)
-	if err := runWindowCreate(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, "", false); err != nil {
-		return err
+	return runWindowCreate(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, "", true)
+}
+
+// runWindowRename renames the workspace windows of the project session, or
+// of all project sessions.
+func runWindowRename(ctx context.Context, w io.Writer, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectName string, all bool) error {
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	tmuxSvc := NewTmuxService(logger)
+
+	var sessions []string
+	if all {
+		list, err := tmuxSvc.ListSessions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+		for _, session := range list {
+			// Set sessions have a window per project, not per workspace
+			if strings.HasPrefix(session, sessionPrefix) && extractProjectFromSession(session) != "" {
+				sessions = append(sessions, session)
+			}
+		}
+	} else {
+		project, err := resolveProjectForWindow(projectsCfg, projectsLogger, projectName)
+		if err != nil {
+			return err
+		}
+		sessionName := generateSessionName(project)
+		exists, err := tmuxSvc.SessionExists(ctx, sessionName)
+		if err != nil {
+			return fmt.Errorf("failed to check session existence: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("no session found for project %s", project.String())
+		}
+		sessions = []string{sessionName}
 	}
 
-	project, err := resolveProjectForWindow(projectsCfg, projectsLogger, projectName)
+	renamed := 0
+	for _, session := range sessions {
+		project, err := sessionProject(ctx, tmuxSvc, projectSvc, session)
+		if err != nil {
+			logger.Warn("skipping session", "session", session, "error", err)
+			continue
+		}
+
+		lines, err := tmuxSvc.ListWindowsFormat(ctx, session, windowListFormat)
+		if err != nil {
+			return err
+		}
+
+		namer := newWindowNamer(ctx, logger, tmuxSvc, workspaceSvc, project)
+		for _, window := range parseWindows(lines) {
+			// Windows named after an existing workspace are workspace
+			// windows created before proj-tmux marked them
+			workspace := window.Workspace
+			if workspace == "" {
+				if _, err := os.Stat(workspaceSvc.WorkspacePath(*project, window.Name)); err != nil {
+					continue
+				}
+				workspace = window.Name
+				window.Workspace = workspace
+			}
+
+			name := namer.name(project, workspace, workspaceSvc.WorkspacePath(*project, workspace))
+			ok, err := nameWindow(ctx, tmuxSvc, window, workspace, name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				fmt.Fprintf(w, "%s: %s renamed %s\n", session, window.Name, name)
+				renamed++
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "Renamed %d workspace windows\n", renamed)
+	return nil
+}
+
+// resolveWorkspace returns the workspace of project, adding it when it
+// doesn't exist.
+func resolveWorkspace(ctx context.Context, logger *slog.Logger, workspaceSvc *projects.WorkspaceService, project *projects.Project, workspace string) (*projects.Workspace, error) {
+	workspaces, err := workspaceSvc.List(ctx, *project)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
 	}
 
-	sessionName := generateSessionName(project)
-	windowName := workspace
+	// The branch checked out in a workspace may have changed since it was
+	// added: match its directory too
+	var targetWorkspace *projects.Workspace
+	for _, ws := range workspaces {
+		if ws.Branch == workspace || ws.Path == workspaceSvc.WorkspacePath(*project, workspace) {
+			targetWorkspace = &ws
+			break
+		}
+	}
 
-	tmuxSvc := NewTmuxService(logger)
-	return tmuxSvc.SwitchWindow(ctx, sessionName, windowName)
+	if targetWorkspace == nil {
+		// Auto-create workspace if it doesn't exist
+		logger.Info("workspace not found, creating", "workspace", workspace, "project", project.String())
+		if err := workspaceSvc.Add(ctx, *project, workspace); err != nil {
+			return nil, fmt.Errorf("workspace '%s' not found and auto-create failed: %w", workspace, err)
+		}
+
+		// Re-list workspaces to get the new one
+		workspaces, err = workspaceSvc.List(ctx, *project)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-list workspaces: %w", err)
+		}
+
+		// Find the newly created workspace
+		for _, ws := range workspaces {
+			if ws.Branch == workspace {
+				targetWorkspace = &ws
+				break
+			}
+		}
+
+		if targetWorkspace == nil {
+			return nil, fmt.Errorf("workspace '%s' created but not found in list", workspace)
+		}
+	}
+
+	return targetWorkspace, nil
 }
 
 // resolveProjectForWindow resolves project for window operations
@@ -285,15 +416,5 @@ func resolveProjectForWindow(projectsCfg *projects.Config, projectsLogger projec
 	}
 
 	// Fall back to working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
-	}
-
-	project, err := projectSvc.FindFromPath(wd)
-	if err != nil {
-		return nil, fmt.Errorf("not inside a project directory and no project specified: %w", err)
-	}
-
-	return project, nil
+	return projectSvc.Resolve("")
 }
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gfanton/projects"
+)
+
+const (
+	// windowFormatOption is the tmux option of the format of workspace
+	// window names, with the placeholders of status --format. The .proj.toml
+	// [session] window-name of a project overrides it.
+	windowFormatOption = "@proj_window_format"
+
+	// windowDirtyOption is the tmux option of the marker #{dirty} renders in
+	// window names, such as an icon.
+	windowDirtyOption = "@proj_window_dirty"
+
+	// workspaceWindowOption is the window option naming the workspace of a
+	// workspace window, to find it whatever its name.
+	workspaceWindowOption = "@proj_workspace"
+
+	// windowNameOption is the window option recording the name last given
+	// to a workspace window, to tell the names changed by hand, which are
+	// kept.
+	windowNameOption = "@proj_window_name"
+
+	defaultWindowFormat = "#{branch}"
+	defaultDirtyMarker  = "*"
+
+	// windowListFormat is the list-windows format read by parseWindows.
+	windowListFormat = "#{window_id}\t#{window_name}\t#{@proj_workspace}\t#{@proj_window_name}"
+)
+
+// tmuxWindow is a window of a session.
+type tmuxWindow struct {
+	ID        string
+	Name      string
+	Workspace string // workspace of workspace windows
+	Given     string // name last given by proj-tmux
+}
+
+// parseWindows returns the windows listed with windowListFormat.
+func parseWindows(lines []string) []tmuxWindow {
+	var windows []tmuxWindow
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		windows = append(windows, tmuxWindow{ID: fields[0], Name: fields[1], Workspace: fields[2], Given: fields[3]})
+	}
+	return windows
+}
+
+// findWorkspaceWindow returns the window of workspace, or nil. Windows
+// created before their name was formatted are found by name.
+func findWorkspaceWindow(windows []tmuxWindow, workspace string) *tmuxWindow {
+	for _, w := range windows {
+		if w.Workspace == workspace {
+			return &w
+		}
+	}
+	for _, w := range windows {
+		if w.Workspace == "" && w.Name == workspace {
+			w.Workspace = workspace
+			return &w
+		}
+	}
+	return nil
+}
+
+// namedByHand reports whether the window was renamed since proj-tmux named
+// it.
+func (w tmuxWindow) namedByHand() bool {
+	if w.Given != "" {
+		return w.Name != w.Given
+	}
+	return w.Name != w.Workspace
+}
+
+// windowNamer renders the names of the workspace windows of a project.
+type windowNamer struct {
+	logger       *slog.Logger
+	workspaceSvc *projects.WorkspaceService
+	format       string
+	dirty        string
+}
+
+// newWindowNamer returns the namer of the windows of project, using the
+// [session] window-name of its .proj.toml, or the @proj_window_format tmux
+// option, defaulting to the branch.
+func newWindowNamer(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, workspaceSvc *projects.WorkspaceService, project *projects.Project) *windowNamer {
+	n := &windowNamer{logger: logger, workspaceSvc: workspaceSvc}
+
+	settings, err := projects.LoadSettings(project.Path)
+	if err != nil {
+		logger.Warn("failed to load project settings", "project", project.String(), "error", err)
+	} else {
+		n.format = settings.WindowName
+	}
+	if n.format == "" {
+		n.format = tmuxSvc.GlobalOption(ctx, windowFormatOption)
+	}
+	if n.format == "" {
+		n.format = defaultWindowFormat
+	}
+
+	if n.dirty = tmuxSvc.GlobalOption(ctx, windowDirtyOption); n.dirty == "" {
+		n.dirty = defaultDirtyMarker
+	}
+	return n
+}
+
+// name returns the name of the window of workspace, checked out at path.
+func (n *windowNamer) name(project *projects.Project, workspace, path string) string {
+	var git *gitStatus
+	if usesGitPlaceholders(n.format) {
+		var err error
+		if git, err = readGitStatus(path); err != nil {
+			n.logger.Debug("failed to read git status", "path", path, "error", err)
+		}
+	}
+
+	var pr *projects.PullRequestInfo
+	if usesPRPlaceholders(n.format) {
+		var err error
+		if pr, err = n.workspaceSvc.ReadPullRequest(path); err != nil {
+			n.logger.Debug("failed to read pull request", "path", path, "error", err)
+		}
+	}
+
+	return formatWindowName(n.format, n.dirty, project, workspace, git, pr)
+}
+
+// formatWindowName renders format like the status line, #{dirty} rendering
+// dirty when the worktree has changes. It falls back to workspace when the
+// name renders empty.
+func formatWindowName(format, dirty string, project *projects.Project, workspace string, git *gitStatus, pr *projects.PullRequestInfo) string {
+	marker := ""
+	if git != nil && git.Dirty {
+		marker = dirty
+	}
+	format = strings.ReplaceAll(format, "#{dirty}", marker)
+
+	name := strings.TrimSpace(buildStatus(project, workspace, "", "", format, false, git, pr))
+	name = strings.ReplaceAll(name, "\n", " ")
+	if name == "" {
+		return workspace
+	}
+	return name
+}
+
+// markWorkspaceWindow records that the window id is the window of
+// workspace, named name by proj-tmux.
+func markWorkspaceWindow(ctx context.Context, tmuxSvc *TmuxService, id, workspace, name string) error {
+	if err := tmuxSvc.SetWindowOption(ctx, id, workspaceWindowOption, workspace); err != nil {
+		return err
+	}
+	return tmuxSvc.SetWindowOption(ctx, id, windowNameOption, name)
+}
+
+// nameWindow renames the window of workspace w to name, unless it was
+// renamed by hand, and reports whether it was renamed.
+func nameWindow(ctx context.Context, tmuxSvc *TmuxService, w tmuxWindow, workspace, name string) (bool, error) {
+	if w.namedByHand() || (w.Name == name && w.Workspace == workspace && w.Given == name) {
+		return false, nil
+	}
+
+	if w.Name != name {
+		if err := tmuxSvc.RenameWindow(ctx, w.ID, name); err != nil {
+			return false, err
+		}
+	}
+	return w.Name != name, markWorkspaceWindow(ctx, tmuxSvc, w.ID, workspace, name)
+}
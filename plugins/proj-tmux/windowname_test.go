@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestFormatWindowName(t *testing.T) {
+	project := &projects.Project{Organisation: "gfanton", Name: "projects"}
+	clean := &gitStatus{Branch: "main"}
+	dirty := &gitStatus{Branch: "fix/login", Dirty: true}
+
+	tests := []struct {
+		name   string
+		format string
+		marker string
+		git    *gitStatus
+		pr     *projects.PullRequestInfo
+		want   string
+	}{
+		{"branch", "#{branch}", "*", clean, nil, "main"},
+		{"name and branch", "#{name}:#{branch}", "*", clean, nil, "projects:main"},
+		{"dirty", "#{branch}#{dirty}", "*", dirty, nil, "fix/login*"},
+		{"dirty icon", "#{dirty}#{branch}", "● ", dirty, nil, "● fix/login"},
+		{"clean icon", "#{dirty}#{branch}", "● ", clean, nil, "main"},
+		{"pull request", "#{pr} #{workspace}", "*", nil, &projects.PullRequestInfo{Number: 12}, "#12 feature"},
+		{"unreadable repository", "#{branch}", "*", nil, nil, "feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatWindowName(tt.format, tt.marker, project, "feature", tt.git, tt.pr); got != tt.want {
+				t.Errorf("formatWindowName(%q) = %q, want %q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspaceWindows(t *testing.T) {
+	windows := parseWindows([]string{
+		"@1\tzsh\t\t",
+		"@2\tmain\t\t",
+		"@3\tprojects:feature*\tfeature\tprojects:feature*",
+		"@4\tmy tests\tfix\tfix",
+		"invalid",
+	})
+	if len(windows) != 4 {
+		t.Fatalf("parseWindows() = %+v, want 4 windows", windows)
+	}
+
+	tests := []struct {
+		workspace string
+		want      *tmuxWindow
+		byHand    bool
+	}{
+		{"feature", &windows[2], false},
+		{"main", &tmuxWindow{ID: "@2", Name: "main", Workspace: "main"}, false},
+		{"fix", &windows[3], true},
+		{"dev", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.workspace, func(t *testing.T) {
+			got := findWorkspaceWindow(windows, tt.workspace)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("findWorkspaceWindow(%q) = %+v, want %+v", tt.workspace, got, tt.want)
+			}
+			if got != nil && got.namedByHand() != tt.byHand {
+				t.Errorf("namedByHand() = %v, want %v", got.namedByHand(), tt.byHand)
+			}
+		})
+	}
+}
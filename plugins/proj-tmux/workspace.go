@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gfanton/projects"
+)
+
+const (
+	// workspaceSessionOption is the tmux option opening workspace targets
+	// in a session per workspace, named by projects.WorkspaceSessionName,
+	// instead of a window of the project session.
+	workspaceSessionOption = "@proj_workspace_session"
+
+	// sessionPathOption is the tmux option of the working directory of
+	// project sessions, for windows and panes created without one:
+	// sessionPathProject, or sessionPathTarget for the directory of the
+	// project or workspace last switched to.
+	sessionPathOption = "@proj_session_path"
+
+	sessionPathProject = "project"
+	sessionPathTarget  = "target"
+)
+
+// runWorkspaceSessionSwitch switches to the session of the workspace of
+// project, creating it in the workspace (and the workspace) unless
+// createSession is false.
+func runWorkspaceSessionSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, workspace, projectName string, createSession bool) error {
+	project, err := resolveProjectForWindow(projectsCfg, projectsLogger, projectName)
+	if err != nil {
+		return err
+	}
+
+	tmuxSvc := newTmuxServiceFromEnv(logger)
+	sessionName := projects.WorkspaceSessionName(sessionPrefix, project, workspace)
+	exists, err := tmuxSvc.SessionExists(ctx, sessionName)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	switch {
+	case exists:
+		logger.Info("session already exists", "session", sessionName)
+	case !createSession:
+		return fmt.Errorf("no session for workspace %s of %s", workspace, project.String())
+	default:
+		if err := createWorkspaceSession(ctx, logger, tmuxSvc, projects.NewWorkspaceService(projectsCfg, projectsLogger), sessionName, project, workspace); err != nil {
+			return err
+		}
+	}
+	return tmuxSvc.SwitchSession(ctx, sessionName)
+}
+
+// createWorkspaceSession creates the session of the workspace of project,
+// whose first window is its workspace window.
+func createWorkspaceSession(ctx context.Context, logger *slog.Logger, tmuxSvc *TmuxService, workspaceSvc *projects.WorkspaceService, sessionName string, project *projects.Project, workspace string) error {
+	ws, err := resolveWorkspace(ctx, logger, workspaceSvc, project, workspace)
+	if err != nil {
+		return err
+	}
+
+	if err := tmuxSvc.NewSession(ctx, sessionName, ws.Path); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	if err := applySessionEnvironment(ctx, logger, tmuxSvc, sessionName, project); err != nil {
+		logger.Warn("failed to set session environment", "session", sessionName, "error", err)
+	}
+	if err := tmuxSvc.SetEnvironment(ctx, sessionName, "PROJ_WORKSPACE", workspace); err != nil {
+		logger.Warn("failed to set session environment", "session", sessionName, "error", err)
+	}
+
+	id, err := tmuxSvc.WindowID(ctx, sessionName+":")
+	if err != nil {
+		return err
+	}
+	name := newWindowNamer(ctx, logger, tmuxSvc, workspaceSvc, project).name(project, workspace, ws.Path)
+	if err := tmuxSvc.RenameWindow(ctx, id, name); err != nil {
+		return err
+	}
+	if err := markWorkspaceWindow(ctx, tmuxSvc, id, workspace, name); err != nil {
+		return err
+	}
+
+	logger.Info("session created", "session", sessionName, "project", project.String(), "workspace", workspace)
+	return nil
+}
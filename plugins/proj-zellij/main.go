@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/gfanton/projects"
+	"github.com/gfanton/projects/internal/config"
+	"github.com/peterbourgon/ff/v4"
+	"github.com/peterbourgon/ff/v4/ffhelp"
+)
+
+// ---- Version Variables (injected at build time)
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+	builtBy = "unknown"
+)
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Load configuration using existing config system
+	cfg, err := config.NewConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to create config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := cfg.Load(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger := cfg.Logger()
+
+	// Create projects config and services
+	projectsCfg := cfg.ProjectsConfig()
+	projectsLogger := projects.NewSlogAdapter(logger)
+
+	// Create root flag set with global flags
+	rootFlags := ff.NewFlagSet("proj-zellij")
+	rootFlags.BoolVar(&cfg.Debug, 0, "debug", "enable debug logging")
+	rootFlags.StringVar(&cfg.RootDir, 0, "root", cfg.RootDir, "root directory for projects")
+	rootFlags.StringVar(&cfg.RootUser, 0, "user", cfg.RootUser, "default user for projects")
+	rootFlags.StringVar(&cfg.ConfigFile, 0, "config", cfg.ConfigFile, "configuration file path")
+	rootFlags.BoolVar(&cfg.ReadOnly, 0, "read-only", "refuse operations modifying the root directory")
+
+	root := &ff.Command{
+		Name:      "proj-zellij",
+		Usage:     "proj-zellij [flags] <subcommand>",
+		ShortHelp: "Zellij integration for proj - session and workspace management",
+		LongHelp: `proj-zellij provides Zellij session and tab management for proj.
+
+It mirrors proj-tmux: each project gets a Zellij session, and each workspace
+a tab in that session. Sessions are created from generated layout files so
+they open in the project directory.
+
+Use 'proj-zellij <subcommand> -h' for more information about a specific command.`,
+		Flags: rootFlags,
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+		Subcommands: []*ff.Command{
+			newSessionCommand(logger, projectsCfg, projectsLogger),
+			newTabCommand(logger, projectsCfg, projectsLogger),
+			newSwitchCommand(logger, projectsCfg, projectsLogger),
+			newStatusCommand(logger, projectsCfg, projectsLogger),
+			newVersionCommand(),
+		},
+	}
+
+	// Accept the global flags after subcommands too, e.g. 'proj-zellij tab create --root ~/src'
+	config.InheritFlags(root)
+
+	if err := root.ParseAndRun(ctx, os.Args[1:]); err != nil {
+		if errors.Is(err, ff.ErrHelp) {
+			fmt.Fprint(os.Stdout, ffhelp.Command(root))
+			os.Exit(0)
+		}
+		logger.Error("command failed", "error", err)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newVersionCommand() *ff.Command {
+	var verbose bool
+	fs := ff.NewFlagSet("proj-zellij version")
+	fs.BoolVar(&verbose, 'v', "verbose", "show verbose version information")
+
+	return &ff.Command{
+		Name:      "version",
+		Usage:     "proj-zellij version [-v]",
+		ShortHelp: "Show version information",
+		Flags:     fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if verbose {
+				fmt.Printf("proj-zellij version %s\n", version)
+				fmt.Printf("  commit: %s\n", commit)
+				fmt.Printf("  built at: %s\n", date)
+				fmt.Printf("  built by: %s\n", builtBy)
+				fmt.Printf("  go version: %s\n", runtime.Version())
+				fmt.Printf("  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+			} else {
+				fmt.Println(version)
+			}
+			return nil
+		},
+	}
+}
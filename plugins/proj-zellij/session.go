@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+const sessionPrefix = "proj-"
+
+func newSessionCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "session",
+		Usage:     "proj-zellij session <subcommand>",
+		ShortHelp: "Manage zellij sessions for projects",
+		LongHelp: `Manage zellij sessions for projects.
+
+Commands:
+  create <project>    Create or attach to project session
+  list                List project sessions
+  current             Show current project context`,
+		Subcommands: []*ff.Command{
+			newSessionCreateCommand(logger, projectsCfg, projectsLogger),
+			newSessionListCommand(logger),
+			newSessionCurrentCommand(logger, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+func newSessionCreateCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "create",
+		Usage:     "proj-zellij session create <project>",
+		ShortHelp: "Create or attach to zellij session for project",
+		LongHelp: `Create a zellij session for the specified project and attach to it.
+
+The session will be named using the format: proj-<org>_<name>
+If the session already exists, this command attaches to it.
+
+Zellij cannot switch a running client to another session from the command
+line: run this command outside zellij, or detach first.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("project name is required")
+			}
+			return runSessionCreate(ctx, logger, projectsCfg, projectsLogger, args[0], "")
+		},
+	}
+}
+
+func newSessionListCommand(logger *slog.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj-zellij session list",
+		ShortHelp: "List project zellij sessions",
+		LongHelp:  `List all zellij sessions that are managed by proj-zellij.`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runSessionList(ctx, logger)
+		},
+	}
+}
+
+func newSessionCurrentCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "current",
+		Usage:     "proj-zellij session current",
+		ShortHelp: "Show current project context",
+		LongHelp:  `Show the current project context based on zellij session or working directory.`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runSessionCurrent(logger, projectsCfg, projectsLogger)
+		},
+	}
+}
+
+// runSessionCreate attaches to the project session, creating it with a
+// layout opening workspace (or the project itself when empty).
+func runSessionCreate(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, projectName, workspace string) error {
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+	zellijSvc := NewZellijService(logger)
+
+	project, err := projectSvc.ParseProject(projectName)
+	if err != nil {
+		return fmt.Errorf("invalid project name: %w", err)
+	}
+
+	sessionName := projects.SessionName(sessionPrefix, project)
+	logger.Debug("creating session", "project", project.String(), "session", sessionName)
+
+	if zellijSvc.InsideZellij() {
+		if zellijSvc.CurrentSession() == sessionName {
+			logger.Info("already in project session", "session", sessionName)
+			return nil
+		}
+		return fmt.Errorf("cannot switch to session %s from inside zellij: detach first, or run 'zellij attach %s'", sessionName, sessionName)
+	}
+
+	tabName, workingDir := project.Name, project.Path
+	if workspace != "" {
+		tabName, workingDir = workspace, workspaceSvc.WorkspacePath(*project, workspace)
+	}
+
+	layoutPath, err := zellijSvc.WriteLayout(sessionName, tabName, workingDir)
+	if err != nil {
+		return err
+	}
+
+	return zellijSvc.AttachSession(ctx, sessionName, layoutPath)
+}
+
+func runSessionList(ctx context.Context, logger *slog.Logger) error {
+	zellijSvc := NewZellijService(logger)
+
+	sessions, err := zellijSvc.ListSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	var found bool
+	for _, session := range sessions {
+		projectName := projects.ProjectFromSessionName(sessionPrefix, session)
+		if projectName == "" {
+			continue
+		}
+		if !found {
+			fmt.Println("Project sessions:")
+			found = true
+		}
+		fmt.Printf("  %s -> %s\n", session, projectName)
+	}
+
+	if !found {
+		fmt.Println("No project sessions found")
+	}
+
+	return nil
+}
+
+func runSessionCurrent(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) error {
+	zellijSvc := NewZellijService(logger)
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
+	currentSession := zellijSvc.CurrentSession()
+	if projectName := projects.ProjectFromSessionName(sessionPrefix, currentSession); projectName != "" {
+		fmt.Printf("Current project session: %s (%s)\n", projectName, currentSession)
+		return nil
+	}
+
+	project, err := projectSvc.Resolve("")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Current project (from directory): %s\n", project.String())
+	return nil
+}
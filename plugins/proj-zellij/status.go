@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+type statusConfig struct {
+	Format string
+	Short  bool
+}
+
+func newStatusCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	statusCfg := &statusConfig{}
+	fs := ff.NewFlagSet("status")
+	fs.StringVar(&statusCfg.Format, 0, "format", "{project}", "status format string")
+	fs.BoolVar(&statusCfg.Short, 0, "short", "show short status")
+
+	return &ff.Command{
+		Name:      "status",
+		Usage:     "proj-zellij status [flags]",
+		ShortHelp: "Show project status for zellij bars",
+		LongHelp: `Show project and workspace status information, for use in zellij
+status bar plugins such as zjstatus (command widget).
+
+Format variables:
+  {project}      Project name (org/name)
+  {org}          Organization name
+  {name}         Project name only
+  {workspace}    Current workspace (if any)
+  {session}      Zellij session name
+
+The workspace is detected from the working directory, since zellij doesn't
+expose the focused tab to commands.
+
+FLAGS:
+  --format        Custom format string (default: "{project}")
+  --short         Show abbreviated status`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			fmt.Print(renderStatus(ctx, logger, projectsCfg, projectsLogger, *statusCfg))
+			return nil
+		},
+	}
+}
+
+// renderStatus computes the status line from the zellij session and the
+// working directory.
+func renderStatus(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, statusCfg statusConfig) string {
+	zellijSvc := NewZellijService(logger)
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+
+	currentSession := zellijSvc.CurrentSession()
+	wd, _ := os.Getwd()
+
+	// Try to extract from zellij session name first
+	var currentProject *projects.Project
+	if projectStr := projects.ProjectFromSessionName(sessionPrefix, currentSession); projectStr != "" {
+		if proj, err := projectSvc.ParseProject(projectStr); err == nil {
+			currentProject = proj
+		}
+	}
+
+	// Fall back to working directory if no project found from session
+	if currentProject == nil && wd != "" {
+		if proj, err := projectSvc.FindFromPath(wd); err == nil {
+			currentProject = proj
+		}
+	}
+
+	if currentProject == nil {
+		if statusCfg.Short {
+			return ""
+		}
+		return "no project"
+	}
+
+	var currentWorkspace string
+	if wd != "" {
+		workspaces, err := workspaceSvc.List(ctx, *currentProject)
+		if err != nil {
+			logger.Debug("failed to list workspaces", "project", currentProject.String(), "error", err)
+		}
+		for _, ws := range workspaces {
+			if isWithin(wd, ws.Path) {
+				currentWorkspace = ws.Branch
+				break
+			}
+		}
+	}
+
+	return buildStatus(currentProject, currentWorkspace, currentSession, statusCfg.Format, statusCfg.Short)
+}
+
+// isWithin reports whether path is dir or one of its descendants.
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func buildStatus(project *projects.Project, workspace, session, format string, short bool) string {
+	if short {
+		if workspace != "" {
+			return fmt.Sprintf("%s:%s", project.Name, workspace)
+		}
+		return project.Name
+	}
+
+	// Default format
+	if format == "" || format == "{project}" {
+		if workspace != "" {
+			return fmt.Sprintf("%s:%s", project.String(), workspace)
+		}
+		return project.String()
+	}
+
+	// Custom format substitution
+	result := format
+	result = strings.ReplaceAll(result, "{project}", project.String())
+	result = strings.ReplaceAll(result, "{org}", project.Organisation)
+	result = strings.ReplaceAll(result, "{name}", project.Name)
+	result = strings.ReplaceAll(result, "{workspace}", workspace)
+	result = strings.ReplaceAll(result, "{session}", session)
+
+	return result
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gfanton/projects"
+)
+
+func TestBuildStatus(t *testing.T) {
+	project := &projects.Project{Organisation: "gfanton", Name: "projects"}
+
+	tests := []struct {
+		name      string
+		workspace string
+		format    string
+		short     bool
+		want      string
+	}{
+		{"default", "", "{project}", false, "gfanton/projects"},
+		{"default workspace", "feature", "{project}", false, "gfanton/projects:feature"},
+		{"short workspace", "feature", "", true, "projects:feature"},
+		{"custom", "feature", "{org}|{name}|{workspace}|{session}", false, "gfanton|projects|feature|proj-gfanton_projects"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildStatus(project, tt.workspace, "proj-gfanton_projects", tt.format, tt.short)
+			if got != tt.want {
+				t.Errorf("buildStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		want      bool
+	}{
+		{"/ws/gfanton/projects.feature", "/ws/gfanton/projects.feature", true},
+		{"/ws/gfanton/projects.feature/cmd", "/ws/gfanton/projects.feature", true},
+		{"/ws/gfanton/projects.feature-2", "/ws/gfanton/projects.feature", false},
+		{"/ws/gfanton", "/ws/gfanton/projects.feature", false},
+	}
+
+	for _, tt := range tests {
+		if got := isWithin(tt.path, tt.dir); got != tt.want {
+			t.Errorf("isWithin(%q, %q) = %v, want %v", tt.path, tt.dir, got, tt.want)
+		}
+	}
+}
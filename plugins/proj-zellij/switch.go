@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "switch",
+		Usage:     "proj-zellij switch <target>",
+		ShortHelp: "Quick switch to project or workspace",
+		LongHelp: `Quick switch to a project session or workspace tab.
+
+Targets can be:
+  project               Attach to project session (e.g., 'gfanton/projects')
+  project:workspace     Switch to workspace tab (e.g., 'gfanton/projects:feature')
+
+Sessions and tabs are created if they don't exist.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("target is required")
+			}
+
+			return runSwitch(ctx, logger, projectsCfg, projectsLogger, args[0])
+		},
+	}
+}
+
+func runSwitch(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, target string) error {
+	// Parse target: project or project:workspace
+	if projectName, workspace, ok := strings.Cut(target, ":"); ok {
+		logger.Debug("switching to workspace", "project", projectName, "workspace", workspace)
+		return runTabCreate(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, true)
+	}
+
+	logger.Debug("switching to project session", "project", target)
+	return runSessionCreate(ctx, logger, projectsCfg, projectsLogger, target, "")
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/gfanton/projects"
+	"github.com/peterbourgon/ff/v4"
+)
+
+func newTabCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "tab",
+		Usage:     "proj-zellij tab <subcommand>",
+		ShortHelp: "Manage zellij tabs for project workspaces",
+		LongHelp: `Manage zellij tabs for project workspaces.
+
+Commands:
+  create <workspace> [project]    Create tab for workspace
+  list                            List tabs of the current session
+  switch <workspace> [project]    Switch to workspace tab`,
+		Subcommands: []*ff.Command{
+			newTabCreateCommand(logger, projectsCfg, projectsLogger),
+			newTabListCommand(logger),
+			newTabSwitchCommand(logger, projectsCfg, projectsLogger),
+		},
+		Exec: func(ctx context.Context, args []string) error {
+			return ff.ErrHelp
+		},
+	}
+}
+
+type tabCreateConfig struct {
+	AutoSwitch bool
+}
+
+func newTabCreateCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	createCfg := &tabCreateConfig{AutoSwitch: true}
+	fs := ff.NewFlagSet("tab create")
+	fs.BoolVar(&createCfg.AutoSwitch, 0, "switch", "automatically switch to created tab")
+
+	return &ff.Command{
+		Name:      "create",
+		Usage:     "proj-zellij tab create [flags] <workspace> [project]",
+		ShortHelp: "Create zellij tab for workspace",
+		LongHelp: `Create a zellij tab for the specified workspace.
+
+Inside the project session, the tab is named after the workspace branch and
+opened in the workspace path. Outside zellij, the project session is created
+(or attached) with the workspace tab.
+
+The workspace is created if it doesn't exist.
+
+FLAGS:
+  --switch     Automatically switch to the created tab (default: true)`,
+		Flags: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("workspace name is required")
+			}
+
+			workspace := args[0]
+			var projectName string
+			if len(args) > 1 {
+				projectName = args[1]
+			}
+
+			return runTabCreate(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, createCfg.AutoSwitch)
+		},
+	}
+}
+
+func newTabListCommand(logger *slog.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "list",
+		Usage:     "proj-zellij tab list",
+		ShortHelp: "List tabs of the current session",
+		LongHelp:  `List all tabs of the current zellij session.`,
+		Exec: func(ctx context.Context, args []string) error {
+			return runTabList(ctx, logger)
+		},
+	}
+}
+
+func newTabSwitchCommand(logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger) *ff.Command {
+	return &ff.Command{
+		Name:      "switch",
+		Usage:     "proj-zellij tab switch <workspace> [project]",
+		ShortHelp: "Switch to workspace tab",
+		LongHelp:  `Switch to the zellij tab for the specified workspace. Creates the tab if it doesn't exist.`,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("workspace name is required")
+			}
+
+			workspace := args[0]
+			var projectName string
+			if len(args) > 1 {
+				projectName = args[1]
+			}
+
+			return runTabCreate(ctx, logger, projectsCfg, projectsLogger, workspace, projectName, true)
+		},
+	}
+}
+
+func runTabCreate(ctx context.Context, logger *slog.Logger, projectsCfg *projects.Config, projectsLogger projects.Logger, workspace, projectName string, autoSwitch bool) error {
+	zellijSvc := NewZellijService(logger)
+	workspaceSvc := projects.NewWorkspaceService(projectsCfg, projectsLogger)
+
+	project, err := resolveProjectForTab(zellijSvc, projectsCfg, projectsLogger, projectName)
+	if err != nil {
+		return err
+	}
+
+	if err := workspaceSvc.Ensure(ctx, *project, workspace); err != nil {
+		return err
+	}
+
+	// Outside zellij, open the project session on the workspace tab
+	if !zellijSvc.InsideZellij() {
+		return runSessionCreate(ctx, logger, projectsCfg, projectsLogger, project.String(), workspace)
+	}
+
+	// Tabs can only be created in the attached session
+	if sessionName := projects.SessionName(sessionPrefix, project); zellijSvc.CurrentSession() != sessionName {
+		return fmt.Errorf("workspace tab belongs to session %s: detach first, or run 'zellij attach %s'", sessionName, sessionName)
+	}
+
+	tabs, err := zellijSvc.ListTabs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tab := range tabs {
+		if tab == workspace {
+			logger.Info("tab already exists", "tab", workspace)
+			if autoSwitch {
+				return zellijSvc.SwitchTab(ctx, workspace)
+			}
+			return nil
+		}
+	}
+
+	// zellij focuses new tabs: go back to the current one when not switching
+	if err := zellijSvc.NewTab(ctx, workspace, workspaceSvc.WorkspacePath(*project, workspace)); err != nil {
+		return err
+	}
+
+	if !autoSwitch && len(tabs) > 0 {
+		return zellijSvc.FocusPreviousTab(ctx)
+	}
+
+	return nil
+}
+
+func runTabList(ctx context.Context, logger *slog.Logger) error {
+	zellijSvc := NewZellijService(logger)
+
+	if !zellijSvc.InsideZellij() {
+		return fmt.Errorf("not inside a zellij session")
+	}
+
+	tabs, err := zellijSvc.ListTabs(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(tabs) == 0 {
+		fmt.Println("No tabs found in current session")
+		return nil
+	}
+
+	fmt.Printf("Tabs in session %s:\n", zellijSvc.CurrentSession())
+	for _, tab := range tabs {
+		fmt.Printf("  %s\n", tab)
+	}
+
+	return nil
+}
+
+// resolveProjectForTab resolves project for tab operations
+func resolveProjectForTab(zellijSvc *ZellijService, projectsCfg *projects.Config, projectsLogger projects.Logger, projectName string) (*projects.Project, error) {
+	projectSvc := projects.NewProjectService(projectsCfg, projectsLogger)
+
+	if projectName != "" {
+		return projectSvc.ParseProject(projectName)
+	}
+
+	// Try to detect from current zellij session
+	if projectStr := projects.ProjectFromSessionName(sessionPrefix, zellijSvc.CurrentSession()); projectStr != "" {
+		return projectSvc.ParseProject(projectStr)
+	}
+
+	// Fall back to working directory
+	return projectSvc.Resolve("")
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ZellijService provides zellij command execution
+type ZellijService struct {
+	logger *slog.Logger
+	bin    string
+}
+
+// NewZellijService creates a new zellij service
+func NewZellijService(logger *slog.Logger) *ZellijService {
+	bin := os.Getenv("ZELLIJ_BIN")
+	if bin == "" {
+		bin = "zellij"
+	}
+	return &ZellijService{
+		logger: logger,
+		bin:    bin,
+	}
+}
+
+// buildCommand builds a zellij command
+func (s *ZellijService) buildCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, s.bin, args...)
+}
+
+// InsideZellij reports whether we run inside a zellij session
+func (s *ZellijService) InsideZellij() bool {
+	return os.Getenv("ZELLIJ") != ""
+}
+
+// CurrentSession returns the current zellij session name, or an empty
+// string outside zellij
+func (s *ZellijService) CurrentSession() string {
+	return os.Getenv("ZELLIJ_SESSION_NAME")
+}
+
+// ListSessions lists all zellij sessions
+func (s *ZellijService) ListSessions(ctx context.Context) ([]string, error) {
+	cmd := s.buildCommand(ctx, "list-sessions", "--short", "--no-formatting")
+	output, err := cmd.Output()
+	if err != nil {
+		// zellij exits non-zero when there are no sessions
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && exitError.ExitCode() == 1 {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+
+	var sessions []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sessions = append(sessions, line)
+		}
+	}
+
+	return sessions, nil
+}
+
+// SessionExists checks if a zellij session exists
+func (s *ZellijService) SessionExists(ctx context.Context, sessionName string) (bool, error) {
+	sessions, err := s.ListSessions(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	for _, session := range sessions {
+		if session == sessionName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// WriteLayout writes a layout file opening a single tab in workingDir and
+// returns its path
+func (s *ZellijService) WriteLayout(sessionName, tabName, workingDir string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	dir := filepath.Join(cacheDir, "proj", "zellij")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create layout directory: %w", err)
+	}
+
+	layout := fmt.Sprintf(`layout {
+    cwd %s
+    tab name=%s focus=true {
+        pane
+        pane size=1 borderless=true {
+            plugin location="zellij:compact-bar"
+        }
+    }
+}
+`, strconv.Quote(workingDir), strconv.Quote(tabName))
+
+	path := filepath.Join(dir, sessionName+".kdl")
+	if err := os.WriteFile(path, []byte(layout), 0644); err != nil {
+		return "", fmt.Errorf("failed to write layout: %w", err)
+	}
+
+	return path, nil
+}
+
+// AttachSession replaces the current process with a zellij client attached
+// to sessionName, creating it from layoutPath if needed
+func (s *ZellijService) AttachSession(ctx context.Context, sessionName, layoutPath string) error {
+	s.logger.Debug("attaching zellij session", "session", sessionName, "layout", layoutPath)
+
+	bin, err := exec.LookPath(s.bin)
+	if err != nil {
+		return fmt.Errorf("zellij not found: %w", err)
+	}
+
+	args := []string{s.bin, "--session", sessionName}
+	if layoutPath != "" {
+		args = append(args, "--layout", layoutPath)
+	}
+
+	exists, err := s.SessionExists(ctx, sessionName)
+	if err == nil && exists {
+		args = []string{s.bin, "attach", sessionName}
+	}
+
+	return syscall.Exec(bin, args, os.Environ())
+}
+
+// NewTab creates a new tab in the current session
+func (s *ZellijService) NewTab(ctx context.Context, tabName, workingDir string) error {
+	s.logger.Debug("creating zellij tab", "tab", tabName, "dir", workingDir)
+
+	cmd := s.buildCommand(ctx, "action", "new-tab", "--name", tabName, "--cwd", workingDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create tab %s: %w\nOutput: %s", tabName, err, string(output))
+	}
+
+	s.logger.Info("created zellij tab", "tab", tabName)
+	return nil
+}
+
+// ListTabs lists the tab names of the current session
+func (s *ZellijService) ListTabs(ctx context.Context) ([]string, error) {
+	cmd := s.buildCommand(ctx, "action", "query-tab-names")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+
+	var tabs []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			tabs = append(tabs, line)
+		}
+	}
+
+	return tabs, nil
+}
+
+// SwitchTab focuses a tab of the current session by name
+func (s *ZellijService) SwitchTab(ctx context.Context, tabName string) error {
+	cmd := s.buildCommand(ctx, "action", "go-to-tab-name", tabName)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to switch to tab %s: %w\nOutput: %s", tabName, err, string(output))
+	}
+
+	s.logger.Info("switched to zellij tab", "tab", tabName)
+	return nil
+}
+
+// FocusPreviousTab focuses the previously focused tab of the current session
+func (s *ZellijService) FocusPreviousTab(ctx context.Context) error {
+	cmd := s.buildCommand(ctx, "action", "go-to-previous-tab")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to focus previous tab: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
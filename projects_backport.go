@@ -0,0 +1,111 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// BackportConflictError is returned when cherry-picking the commits of a
+// backport stops on conflicts. The cherry-pick is left in progress in the
+// workspace, for the conflicts to be resolved there.
+type BackportConflictError struct {
+	Target string   // branch the commits are backported to
+	Path   string   // workspace of the backport
+	Files  []string // conflicting files
+}
+
+func (e *BackportConflictError) Error() string {
+	return fmt.Sprintf("conflicts backporting to %s: %s", e.Target, strings.Join(e.Files, ", "))
+}
+
+// BackportCommits returns the commits of change to backport in proj, oldest
+// first, and a short name of change for branch names. change is a commit, or
+// a pull request "#N" whose commits are the ones of its head not in base.
+// Pull requests are fetched from the default remote.
+func (s *WorkspaceService) BackportCommits(ctx context.Context, proj Project, change, base string) ([]string, string, error) {
+	prNum, isPR := s.isPullRequest(change)
+	if !isPR {
+		commit, err := runGitCombined(ctx, proj.Path, "rev-parse", "--verify", "--quiet", change+"^{commit}")
+		if err != nil {
+			return nil, "", fmt.Errorf("commit %s not found in %s", change, proj.String())
+		}
+		short, err := runGitCombined(ctx, proj.Path, "rev-parse", "--short", commit)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to resolve %s: %w", change, err)
+		}
+		return []string{commit}, short, nil
+	}
+
+	remote, err := s.getDefaultRemote(ctx, proj)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get remote: %w", err)
+	}
+
+	fetchCtx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
+
+	err = s.config.retry().Do(fetchCtx, s.logger.Warn, func() error {
+		cmd := exec.CommandContext(fetchCtx, "git", "fetch", remote, fmt.Sprintf("refs/pull/%d/head", prNum))
+		cmd.Dir = proj.Path
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w\nOutput: %s", gitpkg.ContextError(fetchCtx, err), string(output))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch PR #%d: %w", prNum, err)
+	}
+
+	head, err := runGitCombined(ctx, proj.Path, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve PR #%d: %w", prNum, err)
+	}
+	output, err := runGitCombined(ctx, proj.Path, "rev-list", "--reverse", "--no-merges", resolveBase(ctx, proj.Path, base)+".."+head)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list commits of PR #%d: %w", prNum, err)
+	}
+	if output == "" {
+		return nil, "", fmt.Errorf("PR #%d has no commits outside of %s; backport its merge commit instead", prNum, base)
+	}
+	return strings.Split(output, "\n"), fmt.Sprint(prNum), nil
+}
+
+// Backport adds a workspace of proj on branch, created from target, and
+// cherry-picks commits into it with -x, recording their origin. A single
+// merge commit is picked against its first parent. On conflicts, a
+// *BackportConflictError is returned.
+func (s *WorkspaceService) Backport(ctx context.Context, proj Project, commits []string, branch, target string) error {
+	s.logger.Debug("backporting", "project", proj.String(), "commits", len(commits), "branch", branch, "target", target)
+
+	base := resolveBase(ctx, proj.Path, target)
+	if _, err := runGitCombined(ctx, proj.Path, "rev-parse", "--verify", "--quiet", base); err != nil {
+		return fmt.Errorf("branch %s not found in %s", target, proj.String())
+	}
+
+	if err := s.AddFrom(ctx, proj, branch, base); err != nil {
+		return err
+	}
+	path := s.WorkspacePath(proj, branch)
+
+	args := []string{"cherry-pick", "-x"}
+	if len(commits) == 1 {
+		if parents, err := runGitCombined(ctx, path, "rev-list", "--parents", "-n", "1", commits[0]); err == nil && len(strings.Fields(parents)) > 2 {
+			args = append(args, "-m", "1")
+		}
+	}
+	if _, err := runGitCombined(ctx, path, append(args, commits...)...); err != nil {
+		output, diffErr := runGitCombined(ctx, path, "diff", "--name-only", "--diff-filter=U")
+		if diffErr != nil || output == "" {
+			return fmt.Errorf("failed to cherry-pick onto %s: %w", target, err)
+		}
+		return &BackportConflictError{Target: target, Path: path, Files: strings.Split(output, "\n")}
+	}
+
+	s.logger.Info("backported", "project", proj.String(), "branch", branch, "target", target)
+	return nil
+}
@@ -0,0 +1,75 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(p.Path, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, p.Path, "add", name)
+		runGit(t, p.Path, "commit", "-m", name+": "+strings.TrimSpace(content))
+	}
+
+	runGit(t, p.Path, "init", "-b", "main")
+	commit("a", "1\n")
+	runGit(t, p.Path, "branch", "release-1.2")
+	runGit(t, p.Path, "checkout", "-b", "release-1.3")
+	commit("a", "r13\n")
+	runGit(t, p.Path, "checkout", "main")
+	commit("a", "fix\n")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	commits, name, err := svc.BackportCommits(ctx, p, "HEAD", "main")
+	if err != nil {
+		t.Fatalf("BackportCommits() failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0] != runGit(t, p.Path, "rev-parse", "HEAD") || !strings.HasPrefix(commits[0], name) {
+		t.Errorf("BackportCommits() = %v, %s, want HEAD", commits, name)
+	}
+
+	if err := svc.Backport(ctx, p, commits, "backport-"+name+"-release-1.2", "release-1.2"); err != nil {
+		t.Fatalf("Backport(release-1.2) failed: %v", err)
+	}
+	path := svc.WorkspacePath(p, "backport-"+name+"-release-1.2")
+	if got := runGit(t, path, "log", "-1", "--format=%s%n%b"); !strings.HasPrefix(got, "a: fix\n(cherry picked from commit "+commits[0]) {
+		t.Errorf("backport commit = %q, want the cherry-picked fix", got)
+	}
+
+	err = svc.Backport(ctx, p, commits, "backport-"+name+"-release-1.3", "release-1.3")
+	var conflict *BackportConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Backport(release-1.3) error = %v, want *BackportConflictError", err)
+	}
+	if !reflect.DeepEqual(conflict.Files, []string{"a"}) || conflict.Path != svc.WorkspacePath(p, "backport-"+name+"-release-1.3") {
+		t.Errorf("Backport(release-1.3) conflict = %+v, want files [a]", conflict)
+	}
+
+	if err := svc.Backport(ctx, p, commits, "backport-missing", "release-0.1"); err == nil {
+		t.Error("Backport() onto a missing branch should fail")
+	}
+	if _, _, err := svc.BackportCommits(ctx, p, "deadbeef", "main"); err == nil {
+		t.Error("BackportCommits() of a missing commit should fail")
+	}
+}
@@ -0,0 +1,67 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects/internal/project"
+)
+
+// BareDir is the directory of the bare repository of projects using the
+// bare layout ('proj get --bare'): the project directory only holds the
+// bare repository and its worktrees, one per branch, including the default
+// branch.
+const BareDir = project.BareDir
+
+// IsBare reports whether the project uses the bare layout.
+func (p *Project) IsBare() bool {
+	return project.IsBare(p.Path)
+}
+
+// CheckoutPath returns the main checkout of the project: its directory or,
+// with the bare layout, the worktree of the HEAD branch of the bare
+// repository.
+func (p *Project) CheckoutPath() string {
+	if !p.IsBare() {
+		return p.Path
+	}
+
+	branch, err := bareHead(p.Path)
+	if err != nil {
+		return p.Path
+	}
+	return branchPath(p.Path, branch)
+}
+
+// bareHead returns the branch HEAD of the bare repository of the project
+// at path points to.
+func bareHead(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(path, BareDir, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD of bare repository: %w", err)
+	}
+
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "ref: refs/heads/")
+	if !ok || branch == "" {
+		return "", fmt.Errorf("HEAD of bare repository of %s is not a branch", path)
+	}
+	return branch, nil
+}
+
+// AddBareCheckout creates the main checkout of a project using the bare
+// layout, the worktree of the HEAD branch of its bare repository, and
+// returns its path.
+func (s *WorkspaceService) AddBareCheckout(ctx context.Context, proj Project) (string, error) {
+	branch, err := bareHead(proj.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.Add(ctx, proj, branch); err != nil {
+		return "", err
+	}
+	return s.WorkspacePath(proj, branch), nil
+}
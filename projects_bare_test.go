@@ -0,0 +1,70 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestBareLayout(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lay out the project like 'proj get --bare'
+	runGit(t, src, "init", "-b", "main")
+	runGit(t, src, "commit", "--allow-empty", "-m", "init")
+	runGit(t, root, "clone", "--bare", src, filepath.Join(p.Path, BareDir))
+	if err := os.WriteFile(filepath.Join(p.Path, ".git"), []byte("gitdir: ./"+BareDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if !p.IsBare() {
+		t.Fatal("IsBare() = false, want true")
+	}
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	checkout, err := svc.AddBareCheckout(ctx, p)
+	if err != nil {
+		t.Fatalf("AddBareCheckout() failed: %v", err)
+	}
+	if want := filepath.Join(p.Path, "main"); checkout != want || p.CheckoutPath() != want {
+		t.Errorf("checkout = %q, CheckoutPath() = %q, want %q", checkout, p.CheckoutPath(), want)
+	}
+
+	if err := svc.Add(ctx, p, "feature/x"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	if got, want := svc.WorkspacePath(p, "feature/x"), filepath.Join(p.Path, "feature--x"); got != want {
+		t.Errorf("WorkspacePath() = %q, want %q", got, want)
+	}
+
+	workspaces, err := svc.List(ctx, p)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	var branches []string
+	for _, ws := range workspaces {
+		branches = append(branches, ws.Branch)
+	}
+	if len(branches) != 2 || branches[0] != "feature/x" || branches[1] != "main" {
+		t.Errorf("List() branches = %v, want [feature/x main]", branches)
+	}
+
+	if err := svc.Remove(ctx, p, "feature/x", true); err != nil {
+		t.Fatalf("Remove() failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(p.Path, "feature--x")); !os.IsNotExist(err) {
+		t.Errorf("workspace still exists after Remove(): %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoDefaultBranch is returned when the default branch of a project can't
+// be determined.
+var ErrNoDefaultBranch = errors.New("default branch not found")
+
+// fallbackDefaultBranches are the branches tried, in order, when the remote
+// HEAD of a project is unknown.
+var fallbackDefaultBranches = []string{"main", "master"}
+
+// DefaultBranch returns the default branch of the project: the branch of
+// origin/HEAD, or else the first of main and master that exists locally or
+// on origin. Results are cached for the lifetime of the service.
+func (s *ProjectService) DefaultBranch(ctx context.Context, p *Project) (string, error) {
+	s.mu.Lock()
+	branch, ok := s.defaultBranches[p.Path]
+	s.mu.Unlock()
+	if ok {
+		return branch, nil
+	}
+
+	branch, err := s.resolveDefaultBranch(ctx, p)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	if s.defaultBranches == nil {
+		s.defaultBranches = make(map[string]string)
+	}
+	s.defaultBranches[p.Path] = branch
+	s.mu.Unlock()
+
+	return branch, nil
+}
+
+func (s *ProjectService) resolveDefaultBranch(ctx context.Context, p *Project) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	cmd.Dir = p.Path
+	if output, err := cmd.Output(); err == nil {
+		if branch, ok := strings.CutPrefix(strings.TrimSpace(string(output)), "origin/"); ok && branch != "" {
+			return branch, nil
+		}
+	}
+
+	s.logger.Debug("origin/HEAD not set, trying fallback branches", "project", p.String())
+
+	for _, branch := range fallbackDefaultBranches {
+		for _, ref := range []string{"refs/heads/" + branch, "refs/remotes/origin/" + branch} {
+			cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", ref)
+			cmd.Dir = p.Path
+			if cmd.Run() == nil {
+				return branch, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("%w for %s", ErrNoDefaultBranch, p.String())
+}
@@ -0,0 +1,47 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestDefaultBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	p := &Project{Path: dir, Organisation: "user", Name: "repo"}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, dir, args...)
+	}
+
+	git("init", "-b", "trunk")
+	if _, err := NewProjectService(&Config{}, &testLogger{}).DefaultBranch(ctx, p); !errors.Is(err, ErrNoDefaultBranch) {
+		t.Errorf("DefaultBranch() without candidates error = %v, want ErrNoDefaultBranch", err)
+	}
+
+	git("-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init")
+	git("branch", "master")
+
+	svc := NewProjectService(&Config{}, &testLogger{})
+	if got, err := svc.DefaultBranch(ctx, p); err != nil || got != "master" {
+		t.Errorf("DefaultBranch() fallback = %q, %v, want master", got, err)
+	}
+
+	// origin/HEAD wins over the fallback branches
+	git("update-ref", "refs/remotes/origin/trunk", "HEAD")
+	git("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/trunk")
+
+	if got, _ := svc.DefaultBranch(ctx, p); got != "master" {
+		t.Errorf("DefaultBranch() cached = %q, want master", got)
+	}
+	if got, err := NewProjectService(&Config{}, &testLogger{}).DefaultBranch(ctx, p); err != nil || got != "trunk" {
+		t.Errorf("DefaultBranch() from origin/HEAD = %q, %v, want trunk", got, err)
+	}
+}
@@ -0,0 +1,322 @@
+package projects
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// CacheVersion is the current on-disk format version of the completion cache.
+	CacheVersion = 2
+	// DefaultCacheTTL is the age after which a cache snapshot is refreshed.
+	DefaultCacheTTL = 30 * time.Second
+
+	// cacheLockTimeout is the age after which a refresh lock is considered stale.
+	cacheLockTimeout = time.Minute
+	// warmLockTimeout is the age after which a warm lock is considered
+	// stale. Warms read every project, taking longer than refreshes.
+	warmLockTimeout = 30 * time.Minute
+)
+
+// ErrCacheMissing is returned when no cache snapshot exists yet.
+var ErrCacheMissing = errors.New("completion cache is missing")
+
+// CachedProject is a project stored in the completion cache.
+type CachedProject struct {
+	Path         string   `json:"path"`
+	Name         string   `json:"name"`
+	Organisation string   `json:"organisation"`
+	Host         string   `json:"host,omitempty"`
+	Workspaces   []string `json:"workspaces,omitempty"`
+}
+
+// CacheSnapshot is the content of the completion cache for a root directory.
+type CacheSnapshot struct {
+	Version   int             `json:"version"`
+	RootDir   string          `json:"root_dir"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Projects  []CachedProject `json:"projects"`
+}
+
+// IsStale reports whether the snapshot is older than ttl.
+func (c *CacheSnapshot) IsStale(ttl time.Duration) bool {
+	return time.Since(c.UpdatedAt) > ttl
+}
+
+// CacheService maintains a snapshot of projects and workspaces so shell
+// completion can answer without walking the filesystem or spawning git.
+type CacheService struct {
+	logger           Logger
+	config           *Config
+	projectService   *ProjectService
+	workspaceService *WorkspaceService
+	path             string
+	lockToken        string // content of the refresh lock while held
+	warmLockToken    string // content of the warm lock while held
+}
+
+// NewCacheService creates a new cache service. The cache file lives in the
+// user cache directory and is keyed by the projects root directory.
+func NewCacheService(config *Config, logger Logger) *CacheService {
+	return &CacheService{
+		logger:           logger,
+		config:           config,
+		projectService:   NewProjectService(config, logger),
+		workspaceService: NewWorkspaceService(config, logger),
+		path:             cacheFilePath(config.RootDir, "completion"),
+	}
+}
+
+// cacheFilePath returns the path of the kind cache file for rootDir.
+func cacheFilePath(rootDir, kind string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	sum := sha1.Sum([]byte(rootDir))
+	name := fmt.Sprintf("%s-%s.json", kind, hex.EncodeToString(sum[:])[:12])
+	return filepath.Join(cacheDir, "proj", name)
+}
+
+// Path returns the location of the cache file.
+func (s *CacheService) Path() string {
+	return s.path
+}
+
+// Load reads the cache snapshot. It returns ErrCacheMissing if the cache has
+// not been built yet.
+func (s *CacheService) Load() (*CacheSnapshot, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMissing
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache %s: %w", s.path, err)
+	}
+
+	var snapshot CacheSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse cache %s: %w", s.path, err)
+	}
+
+	if snapshot.Version != CacheVersion || snapshot.RootDir != s.config.RootDir {
+		return nil, ErrCacheMissing
+	}
+
+	return &snapshot, nil
+}
+
+// Refresh walks the projects root, lists workspaces and atomically replaces
+// the cache snapshot.
+func (s *CacheService) Refresh(ctx context.Context) (*CacheSnapshot, error) {
+	snapshot := &CacheSnapshot{
+		Version: CacheVersion,
+		RootDir: s.config.RootDir,
+	}
+
+	err := s.projectService.Walk(func(d fs.DirEntry, p *Project) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		cached := CachedProject{
+			Path:         p.Path,
+			Name:         p.Name,
+			Organisation: p.Organisation,
+			Host:         p.Host,
+		}
+
+		workspaces, err := s.workspaceService.ReadList(*p)
+		if err != nil {
+			s.logger.Debug("failed to read worktrees for cache, asking git", "project", p.String(), "error", err)
+			if workspaces, err = s.workspaceService.List(ctx, *p); err != nil {
+				s.logger.Debug("failed to list workspaces for cache", "project", p.String(), "error", err)
+			}
+		}
+		for _, ws := range workspaces {
+			cached.Workspaces = append(cached.Workspaces, ws.Branch)
+		}
+
+		snapshot.Projects = append(snapshot.Projects, cached)
+		return nil
+	})
+	if IsPartialWalk(err) {
+		// Completion is still useful without the unreadable directories
+		s.logger.Debug("completion cache is partial", "error", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to walk projects: %w", err)
+	}
+
+	snapshot.UpdatedAt = time.Now()
+	if err := s.save(snapshot); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("completion cache refreshed", "path", s.path, "projects", len(snapshot.Projects))
+	return snapshot, nil
+}
+
+func (s *CacheService) save(snapshot *CacheSnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace cache: %w", err)
+	}
+
+	return nil
+}
+
+// TryLock acquires the refresh lock, so that only one background refresh
+// runs at a time. Stale locks left by crashed refreshes are reclaimed.
+func (s *CacheService) TryLock() bool {
+	token, ok := s.tryLock(s.path+".lock", cacheLockTimeout)
+	if ok {
+		s.lockToken = token
+	}
+	return ok
+}
+
+// LockToken returns the token of the refresh lock held by s, for AdoptLock,
+// or "" when s doesn't hold it.
+func (s *CacheService) LockToken() string {
+	return s.lockToken
+}
+
+// AdoptLock takes over the refresh lock acquired with token by another
+// process, such as the one spawning the background refresh, for Unlock to
+// release it.
+func (s *CacheService) AdoptLock(token string) {
+	s.lockToken = token
+}
+
+// Unlock releases the refresh lock held by s. A lock reclaimed as stale and
+// acquired since by another refresh is left alone.
+func (s *CacheService) Unlock() {
+	unlock(s.path+".lock", s.lockToken)
+	s.lockToken = ""
+}
+
+// TryLockWarm acquires the warm lock, so that the warms of the caches
+// started at once, such as by several shells starting, don't all read the
+// projects. Stale locks left by crashed warms are reclaimed.
+func (s *CacheService) TryLockWarm() bool {
+	token, ok := s.tryLock(s.path+".warm.lock", warmLockTimeout)
+	if ok {
+		s.warmLockToken = token
+	}
+	return ok
+}
+
+// UnlockWarm releases the warm lock held by s. Like Unlock, a lock
+// reclaimed as stale and acquired since by another warm is left alone.
+func (s *CacheService) UnlockWarm() {
+	unlock(s.path+".warm.lock", s.warmLockToken)
+	s.warmLockToken = ""
+}
+
+// unlock removes the lock file lockPath if it still holds token.
+func unlock(lockPath, token string) {
+	if token == "" {
+		return
+	}
+	if data, err := os.ReadFile(lockPath); err == nil && string(data) == token {
+		os.Remove(lockPath)
+	}
+}
+
+// tryLock creates the lock file lockPath, removing it first when it is
+// older than timeout, and returns the token written into it.
+func (s *CacheService) tryLock(lockPath string, timeout time.Duration) (string, bool) {
+	if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > timeout {
+		s.logger.Debug("removing stale cache lock", "path", lockPath)
+		os.Remove(lockPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return "", false
+	}
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	token := fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	if _, err := f.WriteString(token); err != nil {
+		s.logger.Debug("failed to write cache lock", "path", lockPath, "error", err)
+	}
+	return token, true
+}
+
+// cacheSource enumerates projects and workspaces from a cache snapshot.
+type cacheSource struct {
+	snapshot         *CacheSnapshot
+	workspaceService *WorkspaceService
+}
+
+func (c cacheSource) WalkOrgs(orgs func(name string) bool, fn WalkFunc) error {
+	for _, cached := range c.snapshot.Projects {
+		p := &Project{
+			Path:         cached.Path,
+			Name:         cached.Name,
+			Organisation: cached.Organisation,
+			Host:         cached.Host,
+		}
+		if orgs != nil {
+			top := p.Organisation
+			if p.Host != "" {
+				top = p.Host
+			}
+			if !orgs(top) {
+				continue
+			}
+		}
+
+		if err := fn(nil, p); err != nil && !errors.Is(err, filepath.SkipDir) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c cacheSource) Workspaces(_ context.Context, p *Project) ([]Workspace, error) {
+	for _, cached := range c.snapshot.Projects {
+		if cached.Path != p.Path {
+			continue
+		}
+
+		workspaces := make([]Workspace, 0, len(cached.Workspaces))
+		for _, branch := range cached.Workspaces {
+			workspaces = append(workspaces, Workspace{
+				Project: *p,
+				Branch:  branch,
+				Path:    c.workspaceService.WorkspacePath(*p, branch),
+			})
+		}
+		return workspaces, nil
+	}
+	return nil, nil
+}
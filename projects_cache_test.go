@@ -0,0 +1,173 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheServiceRefreshAndLoad(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"user1/webapp", "user2/backend"} {
+		if err := os.MkdirAll(filepath.Join(root, p), 0755); err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+	}
+
+	cfg := &Config{RootDir: root}
+	svc := NewCacheService(cfg, &testLogger{})
+	svc.path = filepath.Join(t.TempDir(), "cache.json")
+
+	if _, err := svc.Load(); err != ErrCacheMissing {
+		t.Fatalf("Load() on empty cache = %v, want ErrCacheMissing", err)
+	}
+
+	if _, err := svc.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() failed: %v", err)
+	}
+
+	snapshot, err := svc.Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if len(snapshot.Projects) != 2 {
+		t.Fatalf("expected 2 cached projects, got %d", len(snapshot.Projects))
+	}
+
+	if snapshot.IsStale(time.Minute) {
+		t.Error("fresh snapshot should not be stale")
+	}
+
+	// A snapshot for another root is ignored
+	other := NewCacheService(&Config{RootDir: t.TempDir()}, &testLogger{})
+	other.path = svc.path
+	if _, err := other.Load(); err != ErrCacheMissing {
+		t.Errorf("Load() for another root = %v, want ErrCacheMissing", err)
+	}
+}
+
+func TestCacheServiceLock(t *testing.T) {
+	svc := NewCacheService(&Config{RootDir: t.TempDir()}, &testLogger{})
+	svc.path = filepath.Join(t.TempDir(), "cache.json")
+
+	if !svc.TryLock() {
+		t.Fatal("first TryLock() should succeed")
+	}
+	if svc.TryLock() {
+		t.Fatal("second TryLock() should fail while locked")
+	}
+
+	// Stale locks are reclaimed
+	old := time.Now().Add(-2 * cacheLockTimeout)
+	if err := os.Chtimes(svc.path+".lock", old, old); err != nil {
+		t.Fatalf("failed to age lock: %v", err)
+	}
+	if !svc.TryLock() {
+		t.Fatal("TryLock() should reclaim a stale lock")
+	}
+
+	svc.Unlock()
+	if !svc.TryLock() {
+		t.Fatal("TryLock() should succeed after Unlock()")
+	}
+
+	// The lock is released by the process adopting it, and only while it
+	// still holds it
+	child := NewCacheService(&Config{RootDir: t.TempDir()}, &testLogger{})
+	child.path = svc.path
+	child.Unlock()
+	if _, err := os.Stat(svc.path + ".lock"); err != nil {
+		t.Fatalf("Unlock() without the lock released it: %v", err)
+	}
+	child.AdoptLock(svc.LockToken())
+	if err := os.Chtimes(svc.path+".lock", old, old); err != nil {
+		t.Fatalf("failed to age lock: %v", err)
+	}
+	other := NewCacheService(&Config{RootDir: t.TempDir()}, &testLogger{})
+	other.path = svc.path
+	if !other.TryLock() {
+		t.Fatal("TryLock() should reclaim a stale lock")
+	}
+	child.Unlock()
+	if other.TryLock() {
+		t.Fatal("Unlock() of a reclaimed lock released the new one")
+	}
+	other.Unlock()
+	if !svc.TryLock() {
+		t.Fatal("TryLock() should succeed after Unlock() of its holder")
+	}
+
+	// The warm lock is independent, and stale after a longer time
+	if !svc.TryLockWarm() {
+		t.Fatal("TryLockWarm() should succeed while the refresh lock is held")
+	}
+	if err := os.Chtimes(svc.path+".warm.lock", old, old); err != nil {
+		t.Fatalf("failed to age warm lock: %v", err)
+	}
+	if svc.TryLockWarm() {
+		t.Fatal("second TryLockWarm() should fail while a warm runs")
+	}
+	svc.UnlockWarm()
+	if !svc.TryLockWarm() {
+		t.Fatal("TryLockWarm() should succeed after UnlockWarm()")
+	}
+
+	// A warm lock reclaimed as stale isn't released by its former holder
+	oldWarm := time.Now().Add(-2 * warmLockTimeout)
+	if err := os.Chtimes(svc.path+".warm.lock", oldWarm, oldWarm); err != nil {
+		t.Fatalf("failed to age warm lock: %v", err)
+	}
+	if !other.TryLockWarm() {
+		t.Fatal("TryLockWarm() should reclaim a stale warm lock")
+	}
+	svc.UnlockWarm()
+	if svc.TryLockWarm() {
+		t.Fatal("UnlockWarm() of a reclaimed lock released the new one")
+	}
+	other.UnlockWarm()
+	if !svc.TryLockWarm() {
+		t.Fatal("TryLockWarm() should succeed after UnlockWarm() of its holder")
+	}
+}
+
+func TestSearchFromSnapshot(t *testing.T) {
+	// Projects in the snapshot don't exist on disk: the search must not walk
+	cfg := &Config{RootDir: filepath.Join(t.TempDir(), "missing")}
+	svc := NewQueryService(cfg, &testLogger{})
+
+	snapshot := &CacheSnapshot{
+		Version: CacheVersion,
+		RootDir: cfg.RootDir,
+		Projects: []CachedProject{
+			{Path: filepath.Join(cfg.RootDir, "user1", "webapp"), Organisation: "user1", Name: "webapp", Workspaces: []string{"feature", "main"}},
+			{Path: filepath.Join(cfg.RootDir, "user2", "backend"), Organisation: "user2", Name: "backend"},
+		},
+	}
+
+	results, err := svc.Search(context.Background(), SearchOptions{Query: "webapp", Snapshot: snapshot})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Project.String() != "user1/webapp" {
+		t.Fatalf("Search() = %v, want [user1/webapp]", results)
+	}
+
+	results, err = svc.Search(context.Background(), SearchOptions{Query: "webapp:feat", Snapshot: snapshot})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Workspace != "feature" {
+		t.Fatalf("Search() workspace results = %v, want [user1/webapp:feature]", results)
+	}
+}
+
+// testLogger implements Logger for testing
+type testLogger struct{}
+
+func (l *testLogger) Debug(msg string, args ...any) {}
+func (l *testLogger) Info(msg string, args ...any)  {}
+func (l *testLogger) Warn(msg string, args ...any)  {}
+func (l *testLogger) Error(msg string, args ...any) {}
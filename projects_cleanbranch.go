@@ -0,0 +1,49 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergedBranches returns the local branches of proj merged into base, i.e.
+// whose head commit is an ancestor of base, sorted. base itself and the
+// branches checked out in a worktree, such as the ones backing workspaces,
+// are excluded. base is looked up as a local branch, or else on origin.
+func (s *WorkspaceService) MergedBranches(ctx context.Context, proj Project, base string) ([]string, error) {
+	ref := resolveBase(ctx, proj.Path, base)
+	output, err := runGitCombined(ctx, proj.Path, "for-each-ref", "--merged="+ref, "--format=%(refname:short)%00%(worktreepath)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches merged into %s: %w", base, err)
+	}
+
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		branch, worktree, _ := strings.Cut(line, "\x00")
+		if branch == "" || branch == base {
+			continue
+		}
+		if worktree != "" {
+			s.logger.Debug("keeping branch checked out in a worktree", "branch", branch, "worktree", worktree)
+			continue
+		}
+		branches = append(branches, branch)
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// DeleteBranch deletes the local branch of proj, merged or not.
+func (s *WorkspaceService) DeleteBranch(ctx context.Context, proj Project, branch string) error {
+	if err := s.config.CheckWritable("delete branch " + branch + " of " + proj.String()); err != nil {
+		return err
+	}
+
+	if _, err := runGitCombined(ctx, proj.Path, "branch", "-D", branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s of %s: %w", branch, proj.String(), err)
+	}
+
+	s.logger.Info("branch deleted", "project", proj.String(), "branch", branch)
+	return nil
+}
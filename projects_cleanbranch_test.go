@@ -0,0 +1,61 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMergedBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := NewWorkspaceService(&Config{RootDir: root}, &testLogger{})
+
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+
+	git("init", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "init")
+	git("branch", "merged")
+	git("branch", "other-merged")
+	git("checkout", "-q", "-b", "unmerged")
+	git("commit", "-q", "--allow-empty", "-m", "wip")
+	git("checkout", "-q", "main")
+
+	// A merged branch backing a workspace is kept
+	if err := svc.Add(ctx, p, "workspace"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	branches, err := svc.MergedBranches(ctx, p, "main")
+	if err != nil {
+		t.Fatalf("MergedBranches() failed: %v", err)
+	}
+	if want := []string{"merged", "other-merged"}; !slices.Equal(branches, want) {
+		t.Fatalf("MergedBranches() = %v, want %v", branches, want)
+	}
+
+	if err := svc.DeleteBranch(ctx, p, "merged"); err != nil {
+		t.Fatalf("DeleteBranch() failed: %v", err)
+	}
+	branches, err = svc.MergedBranches(ctx, p, "main")
+	if err != nil {
+		t.Fatalf("MergedBranches() failed: %v", err)
+	}
+	if want := []string{"other-merged"}; !slices.Equal(branches, want) {
+		t.Errorf("MergedBranches() after delete = %v, want %v", branches, want)
+	}
+}
@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
@@ -22,9 +23,25 @@ func NewConfig() (*Config, error) {
 	}, nil
 }
 
+// ErrReadOnly is returned by the operations modifying the root directory in
+// read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// CheckWritable returns an ErrReadOnly error naming op, such as "clone
+// user/project", when the root directory is read-only.
+func (c *Config) CheckWritable(op string) error {
+	if c.ReadOnly {
+		return fmt.Errorf("%w: refusing to %s in %s", ErrReadOnly, op, c.RootDir)
+	}
+	return nil
+}
+
 // EnsureRootDir creates the root directory if it doesn't exist.
 func (c *Config) EnsureRootDir() error {
 	if _, err := os.Stat(c.RootDir); os.IsNotExist(err) {
+		if err := c.CheckWritable("create the root directory"); err != nil {
+			return err
+		}
 		if err := os.MkdirAll(c.RootDir, 0755); err != nil {
 			return fmt.Errorf("failed to create root directory %s: %w", c.RootDir, err)
 		}
@@ -0,0 +1,95 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// BranchDiff summarizes what the branch checked out in a directory holds
+// compared to a base branch.
+type BranchDiff struct {
+	Base    string
+	Branch  string         // empty when HEAD is detached
+	Commits []BranchCommit // commits not in Base, newest first
+	Behind  int            // commits of Base not in the branch
+	Stat    string         // diffstat of the changes since the merge base
+	Dirty   bool           // the checkout has uncommitted changes
+}
+
+// BranchCommit is a commit of a BranchDiff.
+type BranchCommit struct {
+	Hash    string
+	Subject string
+	Author  string
+	When    time.Time
+}
+
+// resolveBase returns the ref of the branch base in the repository at dir:
+// the local branch, or else the one of origin.
+func resolveBase(ctx context.Context, dir, base string) string {
+	ref := "refs/heads/" + base
+	if _, err := runGitCombined(ctx, dir, "show-ref", "--verify", "--quiet", ref); err != nil {
+		ref = "refs/remotes/origin/" + base
+	}
+	return ref
+}
+
+// Diff returns what the checkout at dir, a workspace or a main checkout,
+// holds compared to base: its commits, how far behind it is, the diffstat
+// of its changes since the merge base and whether it has uncommitted ones.
+// The diffstat is laid out for width columns.
+func (s *WorkspaceService) Diff(ctx context.Context, dir, base string, width int) (*BranchDiff, error) {
+	ref := resolveBase(ctx, dir, base)
+	if _, err := runGitCombined(ctx, dir, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return nil, fmt.Errorf("base branch %s not found in %s", base, dir)
+	}
+
+	diff := &BranchDiff{Base: base}
+	if branch, err := runGitCombined(ctx, dir, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		diff.Branch = branch
+	}
+
+	output, err := runGitCombined(ctx, dir, "log", "--format=%h%x00%s%x00%an%x00%at", ref+"..HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits not in %s: %w", base, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		commit := BranchCommit{Hash: fields[0], Subject: fields[1], Author: fields[2]}
+		if at, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			commit.When = time.Unix(at, 0)
+		}
+		diff.Commits = append(diff.Commits, commit)
+	}
+
+	behind, err := runGitCombined(ctx, dir, "rev-list", "--count", "HEAD.."+ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count commits of %s: %w", base, err)
+	}
+	if diff.Behind, err = strconv.Atoi(behind); err != nil {
+		return nil, fmt.Errorf("failed to count commits of %s: %w", base, err)
+	}
+
+	// Keep the leading space of the diffstat, which runGitCombined trims
+	cmd := exec.CommandContext(ctx, "git", "diff", fmt.Sprintf("--stat=%d", width), ref+"...HEAD")
+	cmd.Dir = dir
+	stat, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", base, gitpkg.ContextError(ctx, err))
+	}
+	diff.Stat = strings.TrimRight(string(stat), "\n")
+
+	if diff.Dirty, err = s.IsDirty(ctx, dir); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
@@ -0,0 +1,70 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", name)
+		runGit(t, dir, "commit", "-m", "update "+name)
+	}
+
+	runGit(t, p.Path, "init", "-b", "main")
+	commit(p.Path, "a", "a\n")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	if err := svc.Add(ctx, p, "feature"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	wsPath := svc.WorkspacePath(p, "feature")
+	commit(wsPath, "b", "b\n")
+	commit(wsPath, "c", "c\n")
+	commit(p.Path, "a", "main\n")
+
+	diff, err := svc.Diff(ctx, wsPath, "main", 80)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if diff.Branch != "feature" || len(diff.Commits) != 2 || diff.Behind != 1 || diff.Dirty {
+		t.Errorf("Diff() = %+v, want feature 2 ahead, 1 behind, clean", diff)
+	}
+	if len(diff.Commits) == 2 && (diff.Commits[0].Subject != "update c" || diff.Commits[0].When.IsZero()) {
+		t.Errorf("Diff() first commit = %+v, want the newest one", diff.Commits[0])
+	}
+	// The change of main since the fork is not part of the diffstat
+	if !strings.HasPrefix(diff.Stat, " b |") || strings.Contains(diff.Stat, " a |") || !strings.Contains(diff.Stat, "2 files changed") {
+		t.Errorf("Diff() stat = %q, want b and c only", diff.Stat)
+	}
+
+	if err := os.WriteFile(filepath.Join(wsPath, "d"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if diff, err := svc.Diff(ctx, wsPath, "main", 80); err != nil || !diff.Dirty {
+		t.Errorf("Diff() with untracked file = %+v, %v, want dirty", diff, err)
+	}
+
+	if _, err := svc.Diff(ctx, wsPath, "missing", 80); err == nil {
+		t.Error("Diff() with a missing base should fail")
+	}
+}
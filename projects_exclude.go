@@ -0,0 +1,126 @@
+package projects
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// excludeFile returns the path of the exclude file of the repository of the
+// checkout at path. Git reads info/exclude from the common Git directory, so
+// the file is shared by the main checkout and the workspaces.
+func excludeFile(ctx context.Context, path string) (string, error) {
+	file, err := runGitCombined(ctx, path, "rev-parse", "--git-path", "info/exclude")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the exclude file of %s: %w", path, err)
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(path, file)
+	}
+	return file, nil
+}
+
+// Excludes returns the patterns of the exclude file of the repository of
+// the checkout at path, without comments and empty lines.
+func (s *WorkspaceService) Excludes(ctx context.Context, path string) ([]string, error) {
+	file, err := excludeFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return readExcludes(file)
+}
+
+// Exclude adds the patterns missing from the exclude file of the repository
+// of the checkout at path, so that the files proj, or the setup commands of
+// workspaces, write in checkouts don't show in git status. Patterns follow
+// the .gitignore syntax, relative to the root of the checkout, such as
+// "/.envrc", and apply to every checkout of the repository. It returns the
+// added patterns.
+func (s *WorkspaceService) Exclude(ctx context.Context, path string, patterns ...string) ([]string, error) {
+	file, err := excludeFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.config.CheckWritable("write " + file); err != nil {
+		return nil, err
+	}
+
+	existing, err := readExcludes(file)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, pattern := range existing {
+		seen[pattern] = true
+	}
+
+	var added []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || seen[pattern] {
+			continue
+		}
+		if strings.HasPrefix(pattern, "#") {
+			return nil, fmt.Errorf("invalid exclude pattern '%s': starts a comment", pattern)
+		}
+		seen[pattern] = true
+		added = append(added, pattern)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		b.WriteString("\n")
+	}
+	for _, pattern := range added {
+		b.WriteString(pattern + "\n")
+	}
+	if err := os.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+
+	s.logger.Debug("exclude patterns added", "file", file, "patterns", strings.Join(added, " "))
+	return added, nil
+}
+
+// readExcludes returns the patterns of the exclude file, none when it
+// doesn't exist.
+func readExcludes(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return patterns, nil
+}
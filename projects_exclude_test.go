@@ -0,0 +1,64 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceExclude(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, p.Path, "init", "-b", "main")
+	runGit(t, p.Path, "commit", "--allow-empty", "-m", "init")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	if err := svc.Add(ctx, p, "feature"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+	wsPath := svc.WorkspacePath(p, "feature")
+
+	for _, dir := range []string{p.Path, wsPath} {
+		if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("TOKEN=x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	added, err := svc.Exclude(ctx, wsPath, "/.env", "/.env", " ")
+	if err != nil {
+		t.Fatalf("Exclude() failed: %v", err)
+	}
+	if !slices.Equal(added, []string{"/.env"}) {
+		t.Errorf("Exclude() = %v, want [/.env]", added)
+	}
+
+	// The exclude file is shared by the checkouts of the repository
+	for _, dir := range []string{p.Path, wsPath} {
+		if status := runGit(t, dir, "status", "--porcelain"); status != "" {
+			t.Errorf("git status in %s = %q, want clean", dir, status)
+		}
+	}
+
+	if added, err := svc.Exclude(ctx, p.Path, "/.env", "/.envrc"); err != nil || !slices.Equal(added, []string{"/.envrc"}) {
+		t.Errorf("Exclude() again = %v, %v, want [/.envrc]", added, err)
+	}
+	if excludes, err := svc.Excludes(ctx, wsPath); err != nil || len(excludes) < 2 || !slices.Equal(excludes[len(excludes)-2:], []string{"/.env", "/.envrc"}) {
+		t.Errorf("Excludes() = %v, %v, want ending with /.env /.envrc", excludes, err)
+	}
+
+	if _, err := svc.Exclude(ctx, wsPath, "#comment"); err == nil {
+		t.Error("Exclude(#comment) succeeded, want error")
+	}
+}
@@ -0,0 +1,88 @@
+package projects
+
+import (
+	"strings"
+
+	"github.com/gfanton/projects/internal/match"
+)
+
+// ---- Query Filter Kinds
+const (
+	filterAny  = "any"  // !term or -term: org/name contains term
+	filterOrg  = "org"  // -org:term: organisation equals term
+	filterName = "name" // -name:term: project name contains term
+)
+
+// queryFilter is a negative term parsed from a query string.
+type queryFilter struct {
+	kind  string
+	value string
+}
+
+// queryFilters is a list of negative terms; a project matching any of them
+// is excluded from the results.
+type queryFilters []queryFilter
+
+// parseQueryFilters extracts exclusion terms from a query and returns the
+// remaining search text. Supported terms:
+//
+//	!term, -term    exclude projects whose org/name contains term
+//	-org:term       exclude projects in organisation term
+//	-name:term      exclude projects whose name contains term
+func parseQueryFilters(query string) (string, queryFilters) {
+	var filters queryFilters
+	var rest []string
+
+	for _, field := range strings.Fields(query) {
+		if len(field) < 2 || (field[0] != '!' && field[0] != '-') {
+			rest = append(rest, field)
+			continue
+		}
+
+		term := match.Normalize(field[1:])
+		switch {
+		case strings.HasPrefix(term, filterOrg+":"):
+			if value := strings.TrimPrefix(term, filterOrg+":"); value != "" {
+				filters = append(filters, queryFilter{kind: filterOrg, value: value})
+			}
+		case strings.HasPrefix(term, filterName+":"):
+			if value := strings.TrimPrefix(term, filterName+":"); value != "" {
+				filters = append(filters, queryFilter{kind: filterName, value: value})
+			}
+		default:
+			filters = append(filters, queryFilter{kind: filterAny, value: term})
+		}
+	}
+
+	return strings.Join(rest, " "), filters
+}
+
+// excludes reports whether the project matches any of the filters.
+func (fs queryFilters) excludes(p *Project) bool {
+	if len(fs) == 0 {
+		return false
+	}
+
+	org := match.Normalize(p.Organisation)
+	name := match.Normalize(p.Name)
+	full := org + "/" + name
+
+	for _, f := range fs {
+		switch f.kind {
+		case filterOrg:
+			if org == f.value {
+				return true
+			}
+		case filterName:
+			if strings.Contains(name, f.value) {
+				return true
+			}
+		default:
+			if strings.Contains(full, f.value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
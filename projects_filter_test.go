@@ -0,0 +1,83 @@
+package projects
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQueryFilters(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantQuery   string
+		wantFilters queryFilters
+	}{
+		{
+			name:      "no filters",
+			query:     "webapp",
+			wantQuery: "webapp",
+		},
+		{
+			name:        "bang term",
+			query:       "api !archived",
+			wantQuery:   "api",
+			wantFilters: queryFilters{{kind: filterAny, value: "archived"}},
+		},
+		{
+			name:        "org filter",
+			query:       "-org:Work api",
+			wantQuery:   "api",
+			wantFilters: queryFilters{{kind: filterOrg, value: "work"}},
+		},
+		{
+			name:        "name filter with workspace query",
+			query:       "-name:test foo:main",
+			wantQuery:   "foo:main",
+			wantFilters: queryFilters{{kind: filterName, value: "test"}},
+		},
+		{
+			name:      "lone dash is kept",
+			query:     "- api",
+			wantQuery: "- api",
+		},
+		{
+			name:      "empty org filter is ignored",
+			query:     "-org: api",
+			wantQuery: "api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, filters := parseQueryFilters(tt.query)
+			if query != tt.wantQuery {
+				t.Errorf("parseQueryFilters(%q) query = %q, want %q", tt.query, query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(filters, tt.wantFilters) {
+				t.Errorf("parseQueryFilters(%q) filters = %v, want %v", tt.query, filters, tt.wantFilters)
+			}
+		})
+	}
+}
+
+func TestQueryFiltersExcludes(t *testing.T) {
+	_, filters := parseQueryFilters("!archived -org:work -name:test")
+
+	tests := []struct {
+		project *Project
+		want    bool
+	}{
+		{&Project{Organisation: "me", Name: "old-archived"}, true},
+		{&Project{Organisation: "Work", Name: "api"}, true},
+		{&Project{Organisation: "workshop", Name: "api"}, false},
+		{&Project{Organisation: "me", Name: "api-tests"}, true},
+		{&Project{Organisation: "test", Name: "api"}, false},
+		{&Project{Organisation: "me", Name: "api"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := filters.excludes(tt.project); got != tt.want {
+			t.Errorf("excludes(%s) = %v, want %v", tt.project, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects/internal/project"
+)
+
+// OrphanWorkspace is a directory of the workspace directory that is not a
+// registered worktree of its project, such as the workspaces left behind by
+// a manual 'rm -rf' of their project.
+type OrphanWorkspace struct {
+	Path    string
+	Project Project // the project owning the directory, by its location
+	Reason  string
+}
+
+// Orphans returns the orphaned directories of the workspace directory, each
+// [<host>/]<org>/<name>/<branch> directory that is not a worktree
+// registered in the project [<host>/]<org>/<name>.
+func (s *WorkspaceService) Orphans() ([]OrphanWorkspace, error) {
+	workspaceDir := s.WorkspaceDir()
+	paths, err := filepath.Glob(filepath.Join(workspaceDir, "*", "*", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan workspaces: %w", err)
+	}
+
+	hosts := s.config.hostNames()
+	var orphans []OrphanWorkspace
+	check := func(proj Project, path string) {
+		if info, err := os.Lstat(path); err != nil || !info.IsDir() {
+			return
+		}
+		if reason := orphanReason(proj, path); reason != "" {
+			orphans = append(orphans, OrphanWorkspace{Path: path, Project: proj, Reason: reason})
+		}
+	}
+
+	for _, path := range paths {
+		rel, err := filepath.Rel(workspaceDir, path)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+
+		// The workspaces of the projects of other Git servers are one level
+		// deeper, under a directory named after their host
+		if project.IsHostDir(s.config.RootDir, parts[0], parts[1], hosts) {
+			proj := Project{Path: filepath.Join(s.config.RootDir, rel), Host: parts[0], Organisation: parts[1], Name: parts[2]}
+			branches, err := filepath.Glob(filepath.Join(path, "*"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to scan workspaces: %w", err)
+			}
+			for _, branch := range branches {
+				check(proj, branch)
+			}
+			continue
+		}
+
+		check(Project{Path: filepath.Join(s.config.RootDir, parts[0], parts[1]), Organisation: parts[0], Name: parts[1]}, path)
+	}
+	return orphans, nil
+}
+
+// orphanReason returns why the directory at path is not a worktree of proj,
+// or an empty string when it is one.
+func orphanReason(proj Project, path string) string {
+	if _, err := os.Stat(proj.Path); err != nil {
+		return "project removed"
+	}
+
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return "not a worktree"
+	}
+	if _, err := os.Stat(gitDir); err != nil {
+		return "worktree not registered"
+	}
+
+	// The worktree metadata must point back to the directory
+	data, err := os.ReadFile(filepath.Join(gitDir, "gitdir"))
+	if err != nil || !samePath(filepath.Dir(strings.TrimSpace(string(data))), path) {
+		return "worktree registered at another path"
+	}
+
+	projGitDir, err := resolveGitDir(proj.Path)
+	if err != nil || !samePath(filepath.Dir(gitDir), filepath.Join(projGitDir, "worktrees")) {
+		return "worktree of another repository"
+	}
+	return ""
+}
+
+// RemoveOrphan deletes the directory of o, and its org and name directories
+// when left empty.
+func (s *WorkspaceService) RemoveOrphan(o OrphanWorkspace) error {
+	if err := s.config.CheckWritable("remove " + o.Path); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(o.Path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", o.Path, err)
+	}
+
+	workspaceDir := s.WorkspaceDir()
+	for dir := filepath.Dir(o.Path); dir != workspaceDir && strings.HasPrefix(dir, workspaceDir); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			break // not empty
+		}
+	}
+
+	s.logger.Info("orphaned workspace removed", "path", o.Path, "reason", o.Reason)
+	return nil
+}
+
+// Prune runs 'git worktree prune' in proj, dropping the metadata of its
+// worktrees whose directory is gone.
+func (s *WorkspaceService) Prune(ctx context.Context, proj Project) error {
+	if err := s.config.CheckWritable("prune worktrees of " + proj.String()); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(proj.Path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if _, err := runGitCombined(ctx, proj.Path, "worktree", "prune"); err != nil {
+		return fmt.Errorf("failed to prune worktrees of %s: %w", proj.String(), err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package projects
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrphans(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	svc := NewWorkspaceService(&Config{RootDir: root}, &testLogger{})
+
+	newProject := func(host, org, name string) Project {
+		t.Helper()
+		p := Project{Path: filepath.Join(root, host, org, name), Host: host, Organisation: org, Name: name}
+		if err := os.MkdirAll(p.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, p.Path, "init", "-q", "-b", "main")
+		runGit(t, p.Path, "commit", "-q", "--allow-empty", "-m", "init")
+		return p
+	}
+
+	kept := newProject("", "user", "kept")
+	removed := newProject("", "user", "removed")
+	hosted := newProject("gitlab.com", "acme", "app")
+	for _, p := range []Project{kept, removed, hosted} {
+		if err := svc.Add(ctx, p, "feature"); err != nil {
+			t.Fatalf("Add() failed: %v", err)
+		}
+	}
+
+	// A project removed by hand, its workspace and stray directories are
+	// orphans; the workspaces of kept and of the host project, with
+	// uncommitted work, are not
+	if err := os.RemoveAll(removed.Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(svc.WorkspacePath(hosted, "feature"), "wip.txt"), []byte("wip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stray := filepath.Join(svc.ProjectWorkspaceDir(kept), "stray")
+	hostStray := filepath.Join(svc.ProjectWorkspaceDir(hosted), "stray")
+	for _, dir := range []string{stray, hostStray} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orphans, err := svc.Orphans()
+	if err != nil {
+		t.Fatalf("Orphans() failed: %v", err)
+	}
+	got := make(map[string]string)
+	for _, o := range orphans {
+		got[o.Path] = o.Reason
+	}
+	want := map[string]string{
+		svc.WorkspacePath(removed, "feature"): "project removed",
+		stray:                                 "not a worktree",
+		hostStray:                             "not a worktree",
+	}
+	if !maps.Equal(got, want) {
+		t.Fatalf("Orphans() = %v, want %v", got, want)
+	}
+
+	for _, o := range orphans {
+		if err := svc.RemoveOrphan(o); err != nil {
+			t.Fatalf("RemoveOrphan() failed: %v", err)
+		}
+		if err := svc.Prune(ctx, o.Project); err != nil {
+			t.Fatalf("Prune() failed: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(svc.ProjectWorkspaceDir(removed)); !os.IsNotExist(err) {
+		t.Errorf("workspace directory of removed project still exists: %v", err)
+	}
+	for _, p := range []Project{kept, hosted} {
+		if _, err := os.Stat(svc.WorkspacePath(p, "feature")); err != nil {
+			t.Errorf("workspace of %s removed: %v", p.String(), err)
+		}
+	}
+	if orphans, err := svc.Orphans(); err != nil || len(orphans) != 0 {
+		t.Errorf("Orphans() after removal = %v, %v, want none", orphans, err)
+	}
+}
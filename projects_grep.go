@@ -0,0 +1,183 @@
+package projects
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GrepOptions holds the options of Grep.
+type GrepOptions struct {
+	Pattern    string // regular expression, or literal string with Fixed
+	IgnoreCase bool
+	Fixed      bool
+}
+
+// GrepMatch is a line matching a Grep pattern.
+type GrepMatch struct {
+	Path string // path relative to the searched directory
+	Line int    // 1-based line number
+	Text string
+}
+
+// binaryProbeSize is how much of a file is checked for NUL bytes to skip
+// binary files in the fallback matcher.
+const binaryProbeSize = 8000
+
+// Grep returns the lines of the files of dir matching opts.Pattern. It runs
+// ripgrep when installed, and otherwise matches the files tracked or not
+// ignored by Git, or all the non-hidden files outside Git repositories.
+func Grep(ctx context.Context, dir string, opts GrepOptions) ([]GrepMatch, error) {
+	if _, err := exec.LookPath("rg"); err == nil {
+		return ripgrep(ctx, dir, opts)
+	}
+
+	re, err := grepRegexp(opts)
+	if err != nil {
+		return nil, err
+	}
+	return grepFiles(ctx, dir, re)
+}
+
+// ripgrep runs rg in dir and parses its "path\0line:text" output.
+func ripgrep(ctx context.Context, dir string, opts GrepOptions) ([]GrepMatch, error) {
+	args := []string{"--line-number", "--no-heading", "--with-filename", "--null", "--color", "never"}
+	if opts.IgnoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if opts.Fixed {
+		args = append(args, "--fixed-strings")
+	}
+	args = append(args, "--regexp", opts.Pattern, ".")
+
+	cmd := exec.CommandContext(ctx, "rg", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+
+	// rg exits with 1 without match, and with 2 on errors such as
+	// unreadable files, still reporting the matches of the other files
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("rg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var matches []GrepMatch
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		path, rest, ok := strings.Cut(scanner.Text(), "\x00")
+		if !ok {
+			continue
+		}
+		lineStr, text, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		line, err := strconv.Atoi(lineStr)
+		if err != nil {
+			continue
+		}
+		matches = append(matches, GrepMatch{Path: filepath.Clean(path), Line: line, Text: text})
+	}
+	return matches, scanner.Err()
+}
+
+// grepRegexp compiles the pattern of opts.
+func grepRegexp(opts GrepOptions) (*regexp.Regexp, error) {
+	pattern := opts.Pattern
+	if opts.Fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", opts.Pattern, err)
+	}
+	return re, nil
+}
+
+// grepFiles matches the lines of the files of dir against re.
+func grepFiles(ctx context.Context, dir string, re *regexp.Regexp) ([]GrepMatch, error) {
+	files, err := grepFileList(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []GrepMatch
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || bytes.IndexByte(data[:min(len(data), binaryProbeSize)], 0) >= 0 {
+			continue // unreadable, directory (submodule) or binary file
+		}
+
+		for i, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if re.MatchString(line) {
+				matches = append(matches, GrepMatch{Path: file, Line: i + 1, Text: strings.TrimSuffix(line, "\r")})
+			}
+		}
+	}
+	return matches, nil
+}
+
+// grepFileList returns the files of dir to search: the ones tracked or not
+// ignored by Git, or the non-hidden ones when dir isn't a Git checkout.
+func grepFileList(ctx context.Context, dir string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	cmd.Dir = dir
+	if output, err := cmd.Output(); err == nil {
+		var files []string
+		for _, file := range strings.Split(string(output), "\x00") {
+			if file != "" {
+				files = append(files, filepath.FromSlash(file))
+			}
+		}
+		return files, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", dir, err)
+	}
+	return files, nil
+}
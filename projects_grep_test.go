@@ -0,0 +1,108 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGrepFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":          "package main\n\n// TODO: handle errors\nfunc main() {}\n",
+		"docs/README.md":   "# todo list\n",
+		"build/out.bin":    "TODO\x00binary",
+		".hidden/notes.md": "TODO hidden\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts GrepOptions
+		want []GrepMatch
+	}{
+		{
+			name: "regexp",
+			opts: GrepOptions{Pattern: `TODO:\s`},
+			want: []GrepMatch{{Path: "main.go", Line: 3, Text: "// TODO: handle errors"}},
+		},
+		{
+			name: "ignore case",
+			opts: GrepOptions{Pattern: "todo", IgnoreCase: true},
+			want: []GrepMatch{
+				{Path: filepath.Join("docs", "README.md"), Line: 1, Text: "# todo list"},
+				{Path: "main.go", Line: 3, Text: "// TODO: handle errors"},
+			},
+		},
+		{
+			name: "fixed strings",
+			opts: GrepOptions{Pattern: "main()", Fixed: true},
+			want: []GrepMatch{{Path: "main.go", Line: 4, Text: "func main() {}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := grepRegexp(tt.opts)
+			if err != nil {
+				t.Fatalf("grepRegexp() error = %v", err)
+			}
+			got, err := grepFiles(context.Background(), dir, re)
+			if err != nil {
+				t.Fatalf("grepFiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("grepFiles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := grepRegexp(GrepOptions{Pattern: "("}); err == nil {
+		t.Error("grepRegexp() expected error for invalid pattern")
+	}
+}
+
+func TestGrepFilesGitIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	for name, content := range map[string]string{
+		".gitignore":    "vendor/\n",
+		"api.go":        "// needle\n",
+		"vendor/lib.go": "// needle\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	re, _ := grepRegexp(GrepOptions{Pattern: "needle"})
+	got, err := grepFiles(context.Background(), dir, re)
+	if err != nil {
+		t.Fatalf("grepFiles() error = %v", err)
+	}
+	want := []GrepMatch{{Path: "api.go", Line: 1, Text: "// needle"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("grepFiles() = %+v, want %+v", got, want)
+	}
+}
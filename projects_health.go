@@ -0,0 +1,284 @@
+package projects
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HealthCacheVersion is the current on-disk format version of the health cache.
+	HealthCacheVersion = 1
+	// DefaultHealthCacheTTL is the age after which a cached health is
+	// recomputed, even if the Git state of the project looks unchanged.
+	DefaultHealthCacheTTL = 10 * time.Minute
+	// DefaultHealthWorkers is the default number of projects inspected concurrently.
+	DefaultHealthWorkers = 8
+)
+
+// Health is a summary of the Git state of a project.
+type Health struct {
+	Branch     string    `json:"branch,omitempty"`   // empty when HEAD is detached
+	LastCommit time.Time `json:"last_commit"`        // zero without commit
+	Stashes    int       `json:"stashes,omitempty"`  // number of stash entries
+	Upstream   string    `json:"upstream,omitempty"` // remote-tracking branch, e.g. origin/main
+	Ahead      int       `json:"ahead,omitempty"`    // commits not pushed to Upstream
+	Behind     int       `json:"behind,omitempty"`   // commits of Upstream not merged
+	Gone       bool      `json:"gone,omitempty"`     // Upstream was deleted from the remote
+}
+
+// healthEntry is a cached project health with the state it was computed from.
+type healthEntry struct {
+	Health    Health    `json:"health"`
+	Stamp     time.Time `json:"stamp"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// healthCache is the content of the health cache, keyed by project path.
+type healthCache struct {
+	Version  int                    `json:"version"`
+	Projects map[string]healthEntry `json:"projects"`
+}
+
+// HealthService computes the health of projects concurrently, caching the
+// results until the Git state of a project changes.
+type HealthService struct {
+	logger  Logger
+	path    string
+	ttl     time.Duration
+	workers int
+}
+
+// NewHealthService creates a new health service. The cache file lives in
+// the user cache directory and is keyed by the projects root directory.
+func NewHealthService(config *Config, logger Logger) *HealthService {
+	return &HealthService{
+		logger:  logger,
+		path:    cacheFilePath(config.RootDir, "health"),
+		ttl:     DefaultHealthCacheTTL,
+		workers: DefaultHealthWorkers,
+	}
+}
+
+// Collect returns the health of projs, in the same order, inspecting up to
+// the service workers projects at a time. Projects whose health can't be
+// computed, such as non-Git directories, get a zero Health.
+func (s *HealthService) Collect(ctx context.Context, projs []*Project) []Health {
+	cache := s.load()
+	now := time.Now()
+
+	healths := make([]Health, len(projs))
+	entries := make([]healthEntry, len(projs))
+	fresh := make([]bool, len(projs))
+
+	var wg sync.WaitGroup
+	indexes := make(chan int)
+	for range max(1, min(s.workers, len(projs))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				p := projs[i]
+				stamp := healthStamp(p)
+				if entry, ok := cache.Projects[p.Path]; ok && entry.Stamp.Equal(stamp) && now.Sub(entry.CheckedAt) < s.ttl {
+					healths[i] = entry.Health
+					continue
+				}
+
+				health, err := ProjectHealth(ctx, p)
+				if err != nil {
+					s.logger.Debug("failed to get project health", "project", p.String(), "error", err)
+					continue
+				}
+				healths[i] = health
+				entries[i] = healthEntry{Health: health, Stamp: stamp, CheckedAt: now}
+				fresh[i] = true
+			}
+		}()
+	}
+
+	for i := range projs {
+		if ctx.Err() != nil {
+			break
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	updated := false
+	for i, ok := range fresh {
+		if ok {
+			cache.Projects[projs[i].Path] = entries[i]
+			updated = true
+		}
+	}
+	if updated {
+		if err := s.save(cache); err != nil {
+			s.logger.Debug("failed to save health cache", "path", s.path, "error", err)
+		}
+	}
+
+	return healths
+}
+
+// load reads the health cache, returning an empty one if it is missing or
+// invalid.
+func (s *HealthService) load() *healthCache {
+	cache := &healthCache{Version: HealthCacheVersion, Projects: make(map[string]healthEntry)}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return cache
+	}
+
+	var loaded healthCache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Version != HealthCacheVersion || loaded.Projects == nil {
+		s.logger.Debug("ignoring invalid health cache", "path", s.path, "error", err)
+		return cache
+	}
+	return &loaded
+}
+
+func (s *HealthService) save(cache *healthCache) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to encode health cache: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write health cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace health cache: %w", err)
+	}
+	return nil
+}
+
+// healthStamp returns the latest modification time of the files Git
+// updates when the health of p changes: on checkout, commit, fetch, pull,
+// push and stash.
+func healthStamp(p *Project) time.Time {
+	var latest time.Time
+	for _, name := range []string{"HEAD", "FETCH_HEAD", "ORIG_HEAD", "packed-refs", "logs/HEAD", "logs/refs/stash", "refs/remotes"} {
+		info, err := os.Stat(filepath.Join(p.GitDir(), name))
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// ProjectHealth computes the health of p.
+func ProjectHealth(ctx context.Context, p *Project) (Health, error) {
+	var health Health
+	if !p.IsGitRepository() {
+		return health, fmt.Errorf("not a git repository: %s", p.Path)
+	}
+
+	// Detached HEADs have no branch, hence no upstream
+	if output, err := gitOutput(ctx, p.Path, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		health.Branch = output
+	}
+
+	// Repositories without commit have no last commit
+	if output, err := gitOutput(ctx, p.Path, "log", "-1", "--format=%ct"); err == nil && output != "" {
+		sec, err := strconv.ParseInt(output, 10, 64)
+		if err != nil {
+			return health, fmt.Errorf("invalid commit time '%s': %w", output, err)
+		}
+		// In UTC, so that it compares equal once loaded from the cache
+		health.LastCommit = time.Unix(sec, 0).UTC()
+	}
+
+	if health.Branch != "" {
+		output, err := gitOutput(ctx, p.Path, "for-each-ref", "--format=%(upstream:short)%00%(upstream:track,nobracket)", "refs/heads/"+health.Branch)
+		if err != nil {
+			return health, err
+		}
+		health.Upstream, health.Ahead, health.Behind, health.Gone = parseUpstreamTrack(output)
+	}
+
+	stashes, err := countStashes(p)
+	if err != nil {
+		return health, err
+	}
+	health.Stashes = stashes
+
+	return health, nil
+}
+
+// parseUpstreamTrack parses the "%(upstream:short)%00%(upstream:track,nobracket)"
+// for-each-ref output of a branch, e.g. "origin/main\x00ahead 1, behind 2".
+func parseUpstreamTrack(output string) (upstream string, ahead, behind int, gone bool) {
+	upstream, track, _ := strings.Cut(output, "\x00")
+	if upstream == "" {
+		return "", 0, 0, false
+	}
+
+	for _, part := range strings.Split(track, ",") {
+		field, value, _ := strings.Cut(strings.TrimSpace(part), " ")
+		switch field {
+		case "ahead":
+			ahead, _ = strconv.Atoi(value)
+		case "behind":
+			behind, _ = strconv.Atoi(value)
+		case "gone":
+			gone = true
+		}
+	}
+	return upstream, ahead, behind, gone
+}
+
+// countStashes returns the number of stash entries of p, from the stash
+// reflog.
+func countStashes(p *Project) (int, error) {
+	data, err := os.ReadFile(filepath.Join(p.GitDir(), "logs", "refs", "stash"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stash log: %w", err)
+	}
+
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) != "" {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// gitOutput runs git with args in dir and returns its trimmed output.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
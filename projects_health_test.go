@@ -0,0 +1,71 @@
+package projects
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseUpstreamTrack(t *testing.T) {
+	tests := []struct {
+		output   string
+		upstream string
+		ahead    int
+		behind   int
+		gone     bool
+	}{
+		{"", "", 0, 0, false},
+		{"origin/main\x00", "origin/main", 0, 0, false},
+		{"origin/main\x00ahead 2", "origin/main", 2, 0, false},
+		{"origin/main\x00ahead 1, behind 3", "origin/main", 1, 3, false},
+		{"origin/feature\x00gone", "origin/feature", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		upstream, ahead, behind, gone := parseUpstreamTrack(tt.output)
+		if upstream != tt.upstream || ahead != tt.ahead || behind != tt.behind || gone != tt.gone {
+			t.Errorf("parseUpstreamTrack(%q) = %q, %d, %d, %v, want %q, %d, %d, %v",
+				tt.output, upstream, ahead, behind, gone, tt.upstream, tt.ahead, tt.behind, tt.gone)
+		}
+	}
+}
+
+func TestHealthServiceCollect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	p := &Project{Path: dir, Organisation: "user", Name: "repo"}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, dir, args...)
+	}
+
+	git("init", "-b", "main")
+	git("commit", "--allow-empty", "-m", "init")
+	git("remote", "add", "origin", dir)
+	git("update-ref", "refs/remotes/origin/main", "HEAD")
+	git("branch", "--set-upstream-to=origin/main")
+	git("commit", "--allow-empty", "-m", "local")
+
+	svc := NewHealthService(&Config{RootDir: dir}, &testLogger{})
+	svc.path = filepath.Join(t.TempDir(), "health.json")
+
+	notGit := &Project{Path: t.TempDir(), Organisation: "user", Name: "plain"}
+	healths := svc.Collect(context.Background(), []*Project{p, notGit})
+
+	got := healths[0]
+	if got.Branch != "main" || got.Upstream != "origin/main" || got.Ahead != 1 || got.Behind != 0 || got.LastCommit.IsZero() {
+		t.Errorf("Collect() health = %+v, want main, origin/main, ahead 1", got)
+	}
+	if healths[1] != (Health{}) {
+		t.Errorf("Collect() health of non-Git directory = %+v, want zero", healths[1])
+	}
+
+	if entry, ok := svc.load().Projects[dir]; !ok || entry.Health != got {
+		t.Errorf("cached health = %+v, %v, want %+v", entry.Health, ok, got)
+	}
+}
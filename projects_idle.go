@@ -0,0 +1,106 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// accessFile is the file whose modification time records the last access
+// to a workspace by the shell hook of 'proj init --idle', kept in its
+// worktree metadata directory (<gitdir>/worktrees/<id>) like pullRequestFile.
+const accessFile = "proj-access"
+
+// IdleWorkspace is a workspace not used for longer than Config.IdleAfter.
+type IdleWorkspace struct {
+	Workspace
+	LastAccess time.Time
+}
+
+// LastAccess returns the last time the workspace at path was used: the
+// latest modification time of the workspace directory, of the files Git
+// updates on checkout, commit and staging, and of the access recorded by
+// Touch. It returns the zero time if nothing could be read.
+func (s *WorkspaceService) LastAccess(path string) time.Time {
+	var latest time.Time
+	stamp := func(name string) {
+		if info, err := os.Stat(name); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	stamp(path)
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return latest
+	}
+	for _, name := range []string{"HEAD", "index", "logs/HEAD", accessFile} {
+		stamp(filepath.Join(gitDir, name))
+	}
+	return latest
+}
+
+// Touch records an access to the workspace at path, for shell hooks to keep
+// the workspaces they enter from being flagged as idle.
+func (s *WorkspaceService) Touch(path string) error {
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve Git directory of %s: %w", path, err)
+	}
+	if filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+		return fmt.Errorf("not a workspace: %s", path)
+	}
+
+	name := filepath.Join(gitDir, accessFile)
+	now := time.Now()
+	if err := os.Chtimes(name, now, now); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(name, nil, 0644); err != nil {
+		return fmt.Errorf("failed to record access to %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsIdle reports whether a workspace last used at lastAccess is idle at now.
+// Nothing is idle when Config.IdleAfter is zero.
+func (s *WorkspaceService) IsIdle(lastAccess, now time.Time) bool {
+	if s.config.IdleAfter <= 0 || lastAccess.IsZero() {
+		return false
+	}
+	return now.Sub(lastAccess) > s.config.IdleAfter
+}
+
+// IdleWorkspaces returns the workspaces of the workspace directory idle at
+// now, the least recently used first. Like ScanWorkspaces, it neither runs
+// git nor reads the projects, so it is cheap enough to run on shell startup.
+func (s *WorkspaceService) IdleWorkspaces(now time.Time) ([]IdleWorkspace, error) {
+	if s.config.IdleAfter <= 0 {
+		return nil, nil
+	}
+
+	scanned, err := s.ScanWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var idle []IdleWorkspace
+	for _, workspaces := range scanned {
+		for _, sw := range workspaces {
+			lastAccess := s.LastAccess(sw.Path)
+			if s.IsIdle(lastAccess, now) {
+				idle = append(idle, IdleWorkspace{
+					Workspace:  Workspace{Project: sw.Project, Branch: sw.Branch, Path: sw.Path},
+					LastAccess: lastAccess,
+				})
+			}
+		}
+	}
+
+	sort.Slice(idle, func(i, j int) bool {
+		return idle[i].LastAccess.Before(idle[j].LastAccess)
+	})
+	return idle, nil
+}
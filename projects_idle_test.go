@@ -0,0 +1,82 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdleWorkspaces(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	cfg := &Config{RootDir: root, IdleAfter: 30 * 24 * time.Hour}
+	svc := NewWorkspaceService(cfg, &testLogger{})
+
+	proj := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(proj.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = proj.Path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	for _, branch := range []string{"old", "recent"} {
+		if err := svc.Add(ctx, proj, branch); err != nil {
+			t.Fatalf("Add(%s) failed: %v", branch, err)
+		}
+	}
+
+	// Age the workspace old and its worktree metadata by two months
+	now := time.Now()
+	old := svc.WorkspacePath(proj, "old")
+	gitDir, err := resolveGitDir(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := now.Add(-60 * 24 * time.Hour)
+	for _, path := range []string{old, filepath.Join(gitDir, "HEAD"), filepath.Join(gitDir, "index"), filepath.Join(gitDir, "logs", "HEAD")} {
+		if err := os.Chtimes(path, past, past); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+
+	if got := svc.LastAccess(old); !got.Equal(past) {
+		t.Errorf("LastAccess() = %v, want %v", got, past)
+	}
+
+	idle, err := svc.IdleWorkspaces(now)
+	if err != nil {
+		t.Fatalf("IdleWorkspaces() failed: %v", err)
+	}
+	if len(idle) != 1 || idle[0].Branch != "old" || idle[0].Project.String() != "user/repo" {
+		t.Fatalf("IdleWorkspaces() = %+v, want the old workspace", idle)
+	}
+
+	// Recording an access makes it active again
+	if err := svc.Touch(old); err != nil {
+		t.Fatalf("Touch() failed: %v", err)
+	}
+	if idle, err := svc.IdleWorkspaces(now.Add(time.Minute)); err != nil || len(idle) != 0 {
+		t.Errorf("IdleWorkspaces() after Touch() = %+v, %v, want none", idle, err)
+	}
+
+	if err := svc.Touch(proj.Path); err == nil {
+		t.Error("Touch() of the project checkout should fail")
+	}
+
+	// Nothing is idle when disabled
+	cfg.IdleAfter = 0
+	if idle, err := svc.IdleWorkspaces(now.Add(365 * 24 * time.Hour)); err != nil || len(idle) != 0 {
+		t.Errorf("IdleWorkspaces() when disabled = %+v, %v, want none", idle, err)
+	}
+}
@@ -0,0 +1,85 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects/internal/project"
+)
+
+// ImportCandidate is a repository outside the root directory and the
+// project it is linked as by Import.
+type ImportCandidate struct {
+	Source  string
+	Project *Project
+}
+
+// ImportCandidates returns the Git repositories directly under dir that are
+// not projects yet, named after their origin remote (org/name), or after
+// their directory with the default user without remote. Repositories whose
+// project already exists are skipped.
+func (s *ProjectService) ImportCandidates(dir string) ([]ImportCandidate, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var candidates []ImportCandidate
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		source := filepath.Join(dir, entry.Name())
+		if _, err := os.Stat(filepath.Join(source, ".git")); err != nil {
+			continue
+		}
+
+		name := entry.Name()
+		if org, repo := remoteRepository((&Project{Path: source}).RemoteURL()); org != "" {
+			name = org + "/" + repo
+		}
+
+		p, err := project.ParseProject(s.config.RootDir, s.config.RootUser, name)
+		if err != nil {
+			s.logger.Debug("skipping repository", "path", source, "name", name, "error", err)
+			continue
+		}
+		if _, err := os.Lstat(p.Path); err == nil {
+			s.logger.Debug("project already exists", "path", source, "project", p.String())
+			continue
+		}
+
+		candidates = append(candidates, ImportCandidate{
+			Source:  source,
+			Project: newProject(p),
+		})
+	}
+	return candidates, nil
+}
+
+// Import links the repository of c into the root directory, like 'proj add'.
+func (s *ProjectService) Import(c ImportCandidate) error {
+	if err := s.config.CheckWritable("import " + c.Source); err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(c.Project.Path); err == nil {
+		return fmt.Errorf("project already exists: %s", c.Project.Path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Project.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := os.Symlink(c.Source, c.Project.Path); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	s.logger.Info("imported project", "project", c.Project.String(), "source", c.Source)
+	return nil
+}
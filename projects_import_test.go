@@ -0,0 +1,58 @@
+package projects
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportCandidates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	src := t.TempDir()
+
+	for _, name := range []string{"fork", "local", "existing"} {
+		if err := os.MkdirAll(filepath.Join(src, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, filepath.Join(src, name), "init")
+	}
+	runGit(t, filepath.Join(src, "fork"), "remote", "add", "origin", "git@github.com:acme/tool.git")
+	if err := os.MkdirAll(filepath.Join(src, "not-git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "johndoe", "existing"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewProjectService(&Config{RootDir: root, RootUser: "johndoe"}, &testLogger{})
+	candidates, err := svc.ImportCandidates(src)
+	if err != nil {
+		t.Fatalf("ImportCandidates() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, c := range candidates {
+		got[filepath.Base(c.Source)] = c.Project.String()
+	}
+	want := map[string]string{"fork": "acme/tool", "local": "johndoe/local"}
+	if len(got) != len(want) || got["fork"] != want["fork"] || got["local"] != want["local"] {
+		t.Fatalf("ImportCandidates() = %v, want %v", got, want)
+	}
+
+	for _, c := range candidates {
+		if err := svc.Import(c); err != nil {
+			t.Fatalf("Import(%s) error = %v", c.Source, err)
+		}
+		if target, err := os.Readlink(c.Project.Path); err != nil || target != c.Source {
+			t.Errorf("Import(%s) link = %q, %v", c.Source, target, err)
+		}
+		if err := svc.Import(c); err == nil {
+			t.Errorf("Import(%s) of an existing project should fail", c.Source)
+		}
+	}
+}
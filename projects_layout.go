@@ -0,0 +1,339 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LayoutVersion is the version of the layout of the projects root, the
+// directories proj creates in it, written by this version of proj.
+const LayoutVersion = 1
+
+// LayoutWorkspaceNames is the layout version escaping the dashes of branch
+// names in workspace directories, the one 'proj workspace migrate' migrates
+// to.
+const LayoutWorkspaceNames = 1
+
+// layoutSteps are the steps migrating the layout of a projects root from
+// one version to the next, Version being the one a step migrates to.
+var layoutSteps = []layoutStep{
+	{
+		Version:     LayoutWorkspaceNames,
+		Description: "escape the dashes of branch names in workspace directories",
+		plan:        planWorkspaceNames,
+	},
+}
+
+type layoutStep struct {
+	Version     int
+	Description string
+	plan        func(ctx context.Context, s *LayoutService) ([]LayoutChange, error)
+}
+
+// LayoutStep is a step of a layout migration, with the changes it makes to
+// the projects root.
+type LayoutStep struct {
+	Version     int
+	Description string
+	Changes     []LayoutChange
+	Backup      string // directory of the backup made before applying it
+}
+
+// LayoutChange is a change of a layout step, such as a moved directory.
+type LayoutChange struct {
+	Description string
+	apply       func(ctx context.Context) error
+	backup      []string // files and directories changed by apply
+}
+
+// layoutFile is the content of the layout state file.
+type layoutFile struct {
+	Version int            `json:"version"`
+	RootDir string         `json:"root_dir"`
+	History []layoutRecord `json:"history,omitempty"`
+}
+
+// layoutRecord records an applied step and its changes, to revert them by
+// hand if needed.
+type layoutRecord struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Changes []string  `json:"changes,omitempty"`
+	Backup  string    `json:"backup,omitempty"`
+}
+
+// LayoutService migrates the layout of the projects root to LayoutVersion.
+// The version of a root is kept in the user state directory, like pins: a
+// root without one is at version 0, the layout before versioning.
+type LayoutService struct {
+	logger           Logger
+	config           *Config
+	path             string
+	workspaceService *WorkspaceService
+}
+
+// NewLayoutService creates a new layout service.
+func NewLayoutService(config *Config, logger Logger) *LayoutService {
+	return &LayoutService{
+		logger:           logger,
+		config:           config,
+		path:             stateFilePath(config.RootDir, "layout"),
+		workspaceService: NewWorkspaceService(config, logger),
+	}
+}
+
+// Path returns the path of the layout state file of the projects root.
+func (s *LayoutService) Path() string {
+	return s.path
+}
+
+// Version returns the layout version of the projects root.
+func (s *LayoutService) Version() (int, error) {
+	state, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	return state.Version, nil
+}
+
+// Plan returns the steps migrating the projects root to version to, each
+// with the changes it would make, without applying them.
+func (s *LayoutService) Plan(ctx context.Context, to int) ([]LayoutStep, error) {
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLayoutVersion(state.Version, to); err != nil {
+		return nil, err
+	}
+
+	var steps []LayoutStep
+	for _, step := range layoutSteps {
+		if step.Version <= state.Version || step.Version > to {
+			continue
+		}
+		changes, err := step.plan(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan layout version %d: %w", step.Version, err)
+		}
+		steps = append(steps, LayoutStep{Version: step.Version, Description: step.Description, Changes: changes})
+	}
+	return steps, nil
+}
+
+// Migrate migrates the projects root to version to, one step after the
+// other, recording the version reached after each step. Before applying a
+// step, the files it changes, the layout state file and the config file are
+// backed up in a directory next to the state file. It stops at the first
+// step whose backup or changes fail, leaving the root at the previous
+// version to retry once fixed. It returns the applied steps.
+func (s *LayoutService) Migrate(ctx context.Context, to int) ([]LayoutStep, error) {
+	if err := s.config.CheckWritable("migrate layout of " + s.config.RootDir); err != nil {
+		return nil, err
+	}
+
+	steps, err := s.Plan(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		record := layoutRecord{Version: step.Version, Time: time.Now()}
+		if len(step.Changes) > 0 {
+			backup, err := s.backup(step, record.Time)
+			if err != nil {
+				return steps[:i], fmt.Errorf("failed to back up before layout version %d: %w", step.Version, err)
+			}
+			record.Backup, steps[i].Backup = backup, backup
+		}
+
+		var errs []error
+		for _, change := range step.Changes {
+			if err := change.apply(ctx); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			record.Changes = append(record.Changes, change.Description)
+		}
+
+		if len(record.Changes) > 0 {
+			state.History = append(state.History, record)
+		}
+		if len(errs) == 0 {
+			state.Version = step.Version
+		}
+		if err := s.save(state); err != nil {
+			return steps[:i], err
+		}
+		if len(errs) > 0 {
+			return steps[:i], fmt.Errorf("failed to migrate layout to version %d, backup in %s: %w", step.Version, record.Backup, errors.Join(errs...))
+		}
+		s.logger.Info("layout migrated", "version", step.Version, "changes", len(record.Changes))
+	}
+	return steps, nil
+}
+
+// checkLayoutVersion returns an error when the projects root can't be
+// migrated from version current to version to.
+func checkLayoutVersion(current, to int) error {
+	switch {
+	case to > LayoutVersion:
+		return fmt.Errorf("unknown layout version %d, the latest is %d", to, LayoutVersion)
+	case current > LayoutVersion:
+		return fmt.Errorf("layout version %d is newer than the latest known, %d: upgrade proj", current, LayoutVersion)
+	case to < current:
+		return fmt.Errorf("layout is at version %d, migrating back to %d is not supported", current, to)
+	}
+	return nil
+}
+
+// planWorkspaceNames moves the workspaces named after the legacy encoding
+// of their branch.
+func planWorkspaceNames(ctx context.Context, s *LayoutService) ([]LayoutChange, error) {
+	legacy, err := s.workspaceService.LegacyWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]LayoutChange, len(legacy))
+	for i, lw := range legacy {
+		// 'git worktree move' rewrites the .git file of the workspace and
+		// the gitdir file of its worktree metadata
+		backup := []string{filepath.Join(lw.Path, ".git")}
+		if gitDir, err := resolveGitDir(lw.Path); err == nil {
+			backup = append(backup, gitDir)
+		}
+		changes[i] = LayoutChange{
+			Description: fmt.Sprintf("move %s to %s", lw.Path, lw.NewPath),
+			apply: func(ctx context.Context) error {
+				return s.workspaceService.MigrateWorkspace(ctx, lw)
+			},
+			backup: backup,
+		}
+	}
+	return changes, nil
+}
+
+// backup copies the files changed by step, the layout state file and the
+// config file to a new backup directory, under their absolute path, and
+// returns it.
+func (s *LayoutService) backup(step LayoutStep, now time.Time) (string, error) {
+	name := fmt.Sprintf("%s-v%d-%s", strings.TrimSuffix(filepath.Base(s.path), ".json"), step.Version, now.Format("20060102T150405"))
+	dir := filepath.Join(filepath.Dir(s.path), "backups", name)
+
+	paths := []string{s.path}
+	if s.config.ConfigFile != "" {
+		paths = append(paths, s.config.ConfigFile)
+	}
+	for _, change := range step.Changes {
+		paths = append(paths, change.backup...)
+	}
+
+	for _, path := range paths {
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		dst := filepath.Join(dir, strings.TrimPrefix(abs, filepath.VolumeName(abs)))
+		if err := copyTree(abs, dst); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+	return dir, nil
+}
+
+// copyTree copies the file or directory src to dst, keeping the modes of
+// regular files and copying symbolic links as links.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func (s *LayoutService) load() (layoutFile, error) {
+	state := layoutFile{RootDir: s.config.RootDir}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read layout %s: %w", s.path, err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse layout %s: %w", s.path, err)
+	}
+	return state, nil
+}
+
+// save atomically replaces the layout state file.
+func (s *LayoutService) save(state layoutFile) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode layout: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write layout: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace layout: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLayoutMigrate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"commit", "--allow-empty", "-m", "init"},
+		{"worktree", "add", "-b", "feat--x", filepath.Join(root, ".workspace", "user", "repo", "feat--x")},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = p.Path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	svc := NewLayoutService(&Config{RootDir: root}, &testLogger{})
+	if version, err := svc.Version(); err != nil || version != 0 {
+		t.Fatalf("Version() = %d, %v, want 0", version, err)
+	}
+
+	if _, err := svc.Plan(ctx, LayoutVersion+1); err == nil {
+		t.Error("Plan() to an unknown version should fail")
+	}
+
+	steps, err := svc.Plan(ctx, LayoutVersion)
+	if err != nil {
+		t.Fatalf("Plan() failed: %v", err)
+	}
+	if len(steps) != 1 || len(steps[0].Changes) != 1 || !strings.HasSuffix(steps[0].Changes[0].Description, "feat%2D%2Dx") {
+		t.Fatalf("Plan() = %+v, want the move of feat--x", steps)
+	}
+	if version, _ := svc.Version(); version != 0 {
+		t.Errorf("Version() after Plan() = %d, want 0", version)
+	}
+
+	legacyGit := filepath.Join(root, ".workspace", "user", "repo", "feat--x", ".git")
+	legacyGitData, err := os.ReadFile(legacyGit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := svc.Migrate(ctx, LayoutVersion)
+	if err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	// The .git file of the moved workspace is backed up before the move
+	if len(applied) != 1 || applied[0].Backup == "" {
+		t.Fatalf("Migrate() = %+v, want one step with a backup", applied)
+	}
+	if data, err := os.ReadFile(filepath.Join(applied[0].Backup, legacyGit)); err != nil || string(data) != string(legacyGitData) {
+		t.Errorf("backup of %s = %q, %v, want %q", legacyGit, data, err, legacyGitData)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".workspace", "user", "repo", "feat%2D%2Dx")); err != nil {
+		t.Errorf("workspace not moved: %v", err)
+	}
+	if version, err := svc.Version(); err != nil || version != LayoutVersion {
+		t.Errorf("Version() after Migrate() = %d, %v, want %d", version, err, LayoutVersion)
+	}
+	data, err := os.ReadFile(svc.Path())
+	if err != nil || !strings.Contains(string(data), "feat%2D%2Dx") {
+		t.Errorf("layout state should record the changes, got %s (%v)", data, err)
+	}
+
+	if steps, err := svc.Plan(ctx, LayoutVersion); err != nil || len(steps) != 0 {
+		t.Errorf("Plan() after Migrate() = %+v, %v, want no steps", steps, err)
+	}
+	if _, err := svc.Plan(ctx, 0); err == nil {
+		t.Error("Plan() back to version 0 should fail")
+	}
+}
@@ -0,0 +1,125 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDirtyCheckout is returned when an operation requires a checkout
+// without uncommitted changes.
+var ErrDirtyCheckout = errors.New("checkout has uncommitted changes")
+
+// MergeConflictError is returned when merging or rebasing a workspace
+// branch stops on conflicts. The operation is aborted, leaving both
+// checkouts as they were.
+type MergeConflictError struct {
+	Branch string   // workspace branch
+	Base   string   // branch merged into
+	Rebase bool     // the conflicts happened while rebasing Branch onto Base
+	Files  []string // conflicting files
+}
+
+func (e *MergeConflictError) Error() string {
+	op := "merging " + e.Branch + " into " + e.Base
+	if e.Rebase {
+		op = "rebasing " + e.Branch + " onto " + e.Base
+	}
+	return fmt.Sprintf("conflicts %s (aborted): %s", op, strings.Join(e.Files, ", "))
+}
+
+// MergeBackOptions holds the options of WorkspaceService.MergeBack.
+type MergeBackOptions struct {
+	Base   string // branch to merge into, checked out in the main checkout
+	Rebase bool   // rebase the branch onto Base and fast-forward Base instead of merging
+	Remove bool   // remove the workspace and delete its branch once merged
+}
+
+// MergeBack merges the branch of ws into opts.Base in the main checkout of
+// its project (see Project.CheckoutPath). With opts.Rebase, the branch is first rebased onto the base
+// in the workspace, and the base is fast-forwarded. Both checkouts must be
+// clean; on conflicts the operation is aborted and a *MergeConflictError is
+// returned.
+func (s *WorkspaceService) MergeBack(ctx context.Context, ws Workspace, opts MergeBackOptions) error {
+	proj, branch, workspacePath, base := ws.Project, ws.Branch, ws.Path, opts.Base
+	checkout := proj.CheckoutPath()
+	s.logger.Debug("merging back workspace", "project", proj.String(), "branch", branch, "base", base, "rebase", opts.Rebase)
+
+	if err := s.config.CheckWritable("merge back workspace " + proj.String() + ":" + branch); err != nil {
+		return err
+	}
+	if branch == base {
+		return fmt.Errorf("workspace branch %s is the branch to merge into", branch)
+	}
+
+	for _, dir := range []string{workspacePath, checkout} {
+		dirty, err := s.IsDirty(ctx, dir)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w: %s (commit or stash them first)", ErrDirtyCheckout, dir)
+		}
+	}
+
+	current, err := runGitCombined(ctx, checkout, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		current = "a detached HEAD"
+	}
+	if current != base {
+		return fmt.Errorf("main checkout %s must be on %s, not %s", checkout, base, current)
+	}
+
+	if opts.Rebase {
+		if _, err := runGitCombined(ctx, workspacePath, "rebase", base); err != nil {
+			return s.abortConflicts(ctx, workspacePath, "rebase", &MergeConflictError{Branch: branch, Base: base, Rebase: true}, err)
+		}
+
+		if _, err := runGitCombined(ctx, checkout, "merge", "--ff-only", branch); err != nil {
+			return fmt.Errorf("failed to fast-forward %s to %s: %w", base, branch, err)
+		}
+	} else {
+		if _, err := runGitCombined(ctx, checkout, "merge", "--no-edit", branch); err != nil {
+			return s.abortConflicts(ctx, checkout, "merge", &MergeConflictError{Branch: branch, Base: base}, err)
+		}
+	}
+
+	s.logger.Info("workspace merged back", "branch", branch, "base", base, "rebase", opts.Rebase)
+
+	if !opts.Remove {
+		return nil
+	}
+
+	if _, err := runGitCombined(ctx, proj.Path, "worktree", "remove", workspacePath); err != nil {
+		return fmt.Errorf("merged, but failed to remove workspace: %w", err)
+	}
+	if _, err := runGitCombined(ctx, proj.Path, "branch", "--delete", branch); err != nil {
+		return fmt.Errorf("merged, but failed to delete branch: %w", err)
+	}
+
+	s.logger.Info("workspace removed", "path", workspacePath, "branch", branch)
+	return nil
+}
+
+// abortConflicts reports the conflicting files of the op ("merge" or
+// "rebase") that failed with cause in dir into conflict, and aborts it.
+// Failures that aren't conflicts return cause.
+func (s *WorkspaceService) abortConflicts(ctx context.Context, dir, op string, conflict *MergeConflictError, cause error) error {
+	output, err := runGitCombined(ctx, dir, "diff", "--name-only", "--diff-filter=U")
+	if err == nil && output != "" {
+		conflict.Files = strings.Split(output, "\n")
+	}
+
+	if _, err := runGitCombined(ctx, dir, op, "--abort"); err != nil {
+		s.logger.Debug("failed to abort", "op", op, "dir", dir, "error", err)
+		if len(conflict.Files) > 0 {
+			return errors.Join(conflict, fmt.Errorf("failed to abort %s, resolve it in %s: %w", op, dir, err))
+		}
+	}
+
+	if len(conflict.Files) == 0 {
+		return fmt.Errorf("failed to %s %s: %w", op, conflict.Branch, cause)
+	}
+	return conflict
+}
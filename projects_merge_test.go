@@ -0,0 +1,78 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeBack(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", name)
+		runGit(t, dir, "commit", "-m", name)
+	}
+
+	runGit(t, p.Path, "init", "-b", "main")
+	commit(p.Path, "a", "a\n")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	for _, branch := range []string{"conflict", "feature"} {
+		if err := svc.Add(ctx, p, branch); err != nil {
+			t.Fatalf("Add(%s) failed: %v", branch, err)
+		}
+	}
+	conflictWS := Workspace{Project: p, Branch: "conflict", Path: svc.WorkspacePath(p, "conflict")}
+	featureWS := Workspace{Project: p, Branch: "feature", Path: svc.WorkspacePath(p, "feature")}
+
+	commit(conflictWS.Path, "a", "conflict\n")
+	commit(featureWS.Path, "b", "b\n")
+	commit(p.Path, "a", "main\n")
+
+	for _, rebase := range []bool{false, true} {
+		err := svc.MergeBack(ctx, conflictWS, MergeBackOptions{Base: "main", Rebase: rebase})
+		var conflict *MergeConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("MergeBack(rebase=%v) error = %v, want *MergeConflictError", rebase, err)
+		}
+		if !reflect.DeepEqual(conflict.Files, []string{"a"}) || conflict.Rebase != rebase {
+			t.Errorf("MergeBack(rebase=%v) conflict = %+v, want files [a]", rebase, conflict)
+		}
+		for _, dir := range []string{p.Path, conflictWS.Path} {
+			if got := runGit(t, dir, "status", "--porcelain"); got != "" {
+				t.Errorf("status of %s after aborted MergeBack(rebase=%v) = %q, want clean", dir, rebase, got)
+			}
+		}
+	}
+
+	if err := svc.MergeBack(ctx, featureWS, MergeBackOptions{Base: "main", Rebase: true, Remove: true}); err != nil {
+		t.Fatalf("MergeBack() failed: %v", err)
+	}
+	if got := runGit(t, p.Path, "log", "--format=%s", "main"); got != "b\na\na" {
+		t.Errorf("main history = %q, want b rebased onto main", got)
+	}
+	if _, err := os.Stat(featureWS.Path); !os.IsNotExist(err) {
+		t.Errorf("workspace should be removed, stat error = %v", err)
+	}
+	if got := runGit(t, p.Path, "branch", "--list", "feature"); got != "" {
+		t.Errorf("branch should be deleted, got %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// LegacyWorkspace is a workspace whose directory is named after the legacy
+// encoding of its branch, to be moved to NewPath by MigrateWorkspace.
+type LegacyWorkspace struct {
+	Workspace
+	NewPath string
+}
+
+// LegacyWorkspaces returns the workspaces of the workspace directory whose
+// directory is named after the legacy encoding of their branch, such as
+// "feat--x" for the branch "feat--x", now "feat%2D%2Dx". Like
+// IdleWorkspaces, it neither runs git nor reads the projects.
+func (s *WorkspaceService) LegacyWorkspaces() ([]LegacyWorkspace, error) {
+	scanned, err := s.ScanWorkspaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var legacy []LegacyWorkspace
+	for _, workspaces := range scanned {
+		for _, sw := range workspaces {
+			base, encoded := filepath.Base(sw.Path), encodeBranch(sw.Branch)
+			if base == encoded || base != legacyEncodeBranch(sw.Branch) {
+				continue
+			}
+			legacy = append(legacy, LegacyWorkspace{
+				Workspace: Workspace{Project: sw.Project, Branch: sw.Branch, Path: sw.Path},
+				NewPath:   filepath.Join(filepath.Dir(sw.Path), encoded),
+			})
+		}
+	}
+
+	sort.Slice(legacy, func(i, j int) bool {
+		return legacy[i].Path < legacy[j].Path
+	})
+	return legacy, nil
+}
+
+// MigrateWorkspace moves the legacy workspace lw to its new path with
+// 'git worktree move', which updates the worktree metadata of its project.
+func (s *WorkspaceService) MigrateWorkspace(ctx context.Context, lw LegacyWorkspace) error {
+	if err := s.config.CheckWritable("move workspace " + lw.Path); err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(lw.NewPath); err == nil {
+		return fmt.Errorf("failed to migrate %s: %s already exists", lw.Path, lw.NewPath)
+	}
+	if _, err := runGitCombined(ctx, lw.Project.Path, "worktree", "move", lw.Path, lw.NewPath); err != nil {
+		return fmt.Errorf("failed to migrate %s: %w", lw.Path, err)
+	}
+
+	s.logger.Info("workspace migrated", "branch", lw.Branch, "from", lw.Path, "to", lw.NewPath)
+	return nil
+}
@@ -0,0 +1,74 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+
+	git("init", "-b", "main")
+	git("commit", "--allow-empty", "-m", "init")
+
+	// A workspace of "feat--x" created under the legacy encoding
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	legacyPath := filepath.Join(svc.ProjectWorkspaceDir(p), "feat--x")
+	git("worktree", "add", "-b", "feat--x", legacyPath)
+
+	if got := svc.WorkspacePath(p, "feat--x"); got != legacyPath {
+		t.Errorf("WorkspacePath(feat--x) = %q, want the legacy path %q", got, legacyPath)
+	}
+	if got, want := svc.WorkspacePath(p, "feat/x"), legacyPath; got != want {
+		t.Errorf("WorkspacePath(feat/x) = %q, want %q", got, want)
+	}
+
+	legacy, err := svc.LegacyWorkspaces()
+	if err != nil {
+		t.Fatalf("LegacyWorkspaces() failed: %v", err)
+	}
+	newPath := filepath.Join(svc.ProjectWorkspaceDir(p), "feat%2D%2Dx")
+	if len(legacy) != 1 || legacy[0].Path != legacyPath || legacy[0].NewPath != newPath {
+		t.Fatalf("LegacyWorkspaces() = %+v, want %s -> %s", legacy, legacyPath, newPath)
+	}
+
+	if err := svc.MigrateWorkspace(ctx, legacy[0]); err != nil {
+		t.Fatalf("MigrateWorkspace() failed: %v", err)
+	}
+	if got := svc.WorkspacePath(p, "feat--x"); got != newPath {
+		t.Errorf("WorkspacePath(feat--x) after migration = %q, want %q", got, newPath)
+	}
+
+	// The legacy directory is free for the branch feat/x
+	if err := svc.Add(ctx, p, "feat/x"); err != nil {
+		t.Fatalf("Add(feat/x) failed: %v", err)
+	}
+	if branch, ok := WorktreeBranch(legacyPath); !ok || branch != "feat/x" {
+		t.Errorf("branch of %s = %q, want feat/x", legacyPath, branch)
+	}
+
+	legacy, err = svc.LegacyWorkspaces()
+	if err != nil {
+		t.Fatalf("LegacyWorkspaces() failed: %v", err)
+	}
+	if len(legacy) != 0 {
+		t.Errorf("LegacyWorkspaces() after migration = %+v, want none", legacy)
+	}
+}
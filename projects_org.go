@@ -0,0 +1,135 @@
+package projects
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Organisation is a directory of projects under the root directory.
+type Organisation struct {
+	Name     string
+	Path     string
+	Projects []*Project
+}
+
+// Organisations returns the organisations of the root directory with their
+// projects, sorted by name, with a *WalkErrors error when unreadable
+// directories were skipped.
+func (s *ProjectService) Organisations() ([]Organisation, error) {
+	projs, err := s.ListProjects()
+	if err != nil && !IsPartialWalk(err) {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	byName := make(map[string]*Organisation)
+	var names []string
+	for _, p := range projs {
+		org, ok := byName[p.Organisation]
+		if !ok {
+			org = &Organisation{Name: p.Organisation, Path: filepath.Join(s.config.RootDir, p.Organisation)}
+			byName[p.Organisation] = org
+			names = append(names, p.Organisation)
+		}
+		org.Projects = append(org.Projects, p)
+	}
+	sort.Strings(names)
+
+	orgs := make([]Organisation, 0, len(names))
+	for _, name := range names {
+		orgs = append(orgs, *byName[name])
+	}
+	return orgs, err
+}
+
+// Organisation returns the organisation name of the root directory, with a
+// *WalkErrors error when unreadable directories were skipped.
+func (s *ProjectService) Organisation(name string) (*Organisation, error) {
+	orgs, err := s.Organisations()
+	if err != nil && !IsPartialWalk(err) {
+		return nil, err
+	}
+
+	for _, org := range orgs {
+		if org.Name == name {
+			return &org, err
+		}
+	}
+	return nil, fmt.Errorf("%w: no organisation '%s' in %s", ErrNoMatch, name, s.config.RootDir)
+}
+
+// ArchiveDir writes a gzipped tar archive of dir to w. Entries are named
+// relative to the parent of dir, so that the archive extracts to a
+// directory named like dir. Symlinks are archived as links.
+func ArchiveDir(ctx context.Context, dir string, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	parent := filepath.Dir(dir)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if d.Type()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(parent, path)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package projects
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// ErrNoCodeOwners is returned when a project has no CODEOWNERS file.
+var ErrNoCodeOwners = errors.New("no CODEOWNERS file")
+
+// codeOwnersPaths are the locations of the CODEOWNERS file, looked up in
+// this order as GitHub does.
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwners is a parsed CODEOWNERS file.
+type CodeOwners struct {
+	Path  string // path of the file
+	rules []ownerRule
+}
+
+// ownerRule is a line of a CODEOWNERS file.
+type ownerRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// LoadCodeOwners reads the CODEOWNERS file of the checkout at dir, returning
+// ErrNoCodeOwners when it has none.
+func LoadCodeOwners(dir string) (*CodeOwners, error) {
+	for _, name := range codeOwnersPaths {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer f.Close()
+
+		owners, err := parseCodeOwners(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		owners.Path = path
+		return owners, nil
+	}
+	return nil, ErrNoCodeOwners
+}
+
+func parseCodeOwners(r io.Reader) (*CodeOwners, error) {
+	owners := &CodeOwners{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		pattern, err := compileOwnersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		owners.rules = append(owners.rules, ownerRule{pattern: pattern, owners: fields[1:]})
+	}
+	return owners, scanner.Err()
+}
+
+// compileOwnersPattern compiles a CODEOWNERS pattern, which follows the
+// gitignore rules: a pattern with a slash other than a trailing one is
+// relative to the root, others match at any depth; a trailing slash only
+// matches directories; '*' doesn't cross directories while '**' does. A
+// pattern matching a directory matches the files under it, except for the
+// ones ending with "/*" which only match the files directly in it.
+func compileOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			expr.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*$")
+	case strings.HasSuffix(pattern, "/*"):
+		expr.WriteString("$")
+	default:
+		expr.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(expr.String())
+}
+
+// Owners returns the owners of the slash-separated file relative to the
+// root of the checkout: the ones of the last matching rule, or none.
+func (c *CodeOwners) Owners(file string) []string {
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(file) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// ChangedFiles returns the sorted slash-separated files of the checkout at
+// dir that differ from base: the ones changed by the commits since its
+// merge base with base, and the uncommitted ones. base is looked up as a
+// local branch, or else on origin. The diff is computed with go-git.
+func (s *ProjectService) ChangedFiles(dir, base string) ([]string, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(base), true)
+	if err != nil {
+		if ref, err = repo.Reference(plumbing.NewRemoteReferenceName("origin", base), true); err != nil {
+			return nil, fmt.Errorf("failed to resolve base branch %s: %w", base, err)
+		}
+	}
+	baseCommit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit of %s: %w", base, err)
+	}
+
+	// Compare with the merge base, so that the commits of base since the
+	// branch was forked don't count as changes
+	if bases, err := headCommit.MergeBase(baseCommit); err == nil && len(bases) > 0 {
+		baseCommit = bases[0]
+	}
+
+	baseTree, err := baseCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of %s: %w", base, err)
+	}
+	headTree, err := headCommit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tree of HEAD: %w", err)
+	}
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff against %s: %w", base, err)
+	}
+
+	files := make(map[string]struct{})
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" {
+				files[name] = struct{}{}
+			}
+		}
+	}
+
+	if wt, err := repo.Worktree(); err == nil {
+		status, err := wt.Status()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status: %w", err)
+		}
+		for name, st := range status {
+			if st.Staging != git.Unmodified || st.Worktree != git.Unmodified {
+				files[name] = struct{}{}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(files))
+	for name := range files {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, nil
+}
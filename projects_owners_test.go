@@ -0,0 +1,138 @@
+package projects
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeOwners(t *testing.T) {
+	codeowners := `# Default owners
+*                @org/core
+
+*.js             @org/web # front-end
+/docs/           @org/docs
+apps/            @org/apps
+/build/logs/     @org/ops
+docs/api/*       @org/api
+**/testdata/**   @org/qa
+/vendor/
+`
+	owners, err := parseCodeOwners(strings.NewReader(codeowners))
+	if err != nil {
+		t.Fatalf("parseCodeOwners() error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"web/app.js", []string{"@org/web"}},
+		{"docs/index.md", []string{"@org/docs"}},
+		{"pkg/docs/index.md", []string{"@org/core"}},
+		{"apps/a/main.go", []string{"@org/apps"}},
+		{"src/apps/b/main.go", []string{"@org/apps"}},
+		{"apps", []string{"@org/core"}},
+		{"build/logs/today.log", []string{"@org/ops"}},
+		{"docs/api/spec.yaml", []string{"@org/api"}},
+		{"docs/api/v1/spec.yaml", []string{"@org/docs"}},
+		{"pkg/testdata/golden.txt", []string{"@org/qa"}},
+		{"vendor/lib/lib.go", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := owners.Owners(tt.file); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCodeOwners(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadCodeOwners(dir); err != ErrNoCodeOwners {
+		t.Errorf("LoadCodeOwners() without file error = %v, want ErrNoCodeOwners", err)
+	}
+
+	// .github/CODEOWNERS takes precedence over the root one
+	for name, content := range map[string]string{".github/CODEOWNERS": "* @github\n", "CODEOWNERS": "* @root\n"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owners, err := LoadCodeOwners(dir)
+	if err != nil {
+		t.Fatalf("LoadCodeOwners() error = %v", err)
+	}
+	if got := owners.Owners("main.go"); !reflect.DeepEqual(got, []string{"@github"}) {
+		t.Errorf("Owners(main.go) = %v, want [@github]", got)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, dir, args...)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-b", "main")
+	write("a", "a\n")
+	write("old", "old\n")
+	git("add", ".")
+	git("commit", "-m", "initial")
+
+	git("checkout", "-b", "feature")
+	write("pkg/b", "b\n")
+	git("add", ".")
+	git("rm", "-q", "old")
+	git("commit", "-m", "feature")
+	write("a", "changed\n")
+	write("untracked", "x\n")
+
+	// Commits of main since the fork are not changes of the branch
+	git("checkout", "-q", "main", "--")
+	git("stash", "-q", "--include-untracked")
+	write("main-only", "m\n")
+	git("add", ".")
+	git("commit", "-m", "main")
+	git("checkout", "-q", "feature")
+	git("stash", "pop", "-q")
+
+	svc := NewProjectService(&Config{RootDir: dir}, &testLogger{})
+	got, err := svc.ChangedFiles(dir, "main")
+	if err != nil {
+		t.Fatalf("ChangedFiles() error = %v", err)
+	}
+	want := []string{"a", "old", "pkg/b", "untracked"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChangedFiles() = %v, want %v", got, want)
+	}
+
+	if _, err := svc.ChangedFiles(dir, "missing"); err == nil {
+		t.Error("ChangedFiles() with a missing base should fail")
+	}
+}
@@ -0,0 +1,165 @@
+package projects
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// PinsVersion is the current on-disk format version of the pins file.
+const PinsVersion = 1
+
+// pinsFile is the content of the pins file.
+type pinsFile struct {
+	Version  int      `json:"version"`
+	RootDir  string   `json:"root_dir"`
+	Projects []string `json:"projects"` // user/project, sorted
+}
+
+// PinService manages the pinned projects, which sort above the others in
+// query results. Pins are stored per projects root in the user state
+// directory.
+type PinService struct {
+	logger  Logger
+	rootDir string
+	path    string
+}
+
+// NewPinService creates a new pin service.
+func NewPinService(config *Config, logger Logger) *PinService {
+	return &PinService{
+		logger:  logger,
+		rootDir: config.RootDir,
+		path:    stateFilePath(config.RootDir, "pins"),
+	}
+}
+
+// stateFilePath returns the path of a state file of the given kind for the
+// projects root: $XDG_STATE_HOME/proj/<kind>-<hash>.json, defaulting to
+// ~/.local/state.
+func stateFilePath(rootDir, kind string) string {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			stateDir = filepath.Join(home, ".local", "state")
+		} else {
+			stateDir = os.TempDir()
+		}
+	}
+
+	sum := sha1.Sum([]byte(rootDir))
+	name := fmt.Sprintf("%s-%s.json", kind, hex.EncodeToString(sum[:])[:12])
+	return filepath.Join(stateDir, "proj", name)
+}
+
+// Pinned returns the pinned projects (user/project), sorted.
+func (s *PinService) Pinned() ([]string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pins %s: %w", s.path, err)
+	}
+
+	var pins pinsFile
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("failed to parse pins %s: %w", s.path, err)
+	}
+	if pins.Version != PinsVersion {
+		return nil, fmt.Errorf("unsupported pins version %d in %s", pins.Version, s.path)
+	}
+	return pins.Projects, nil
+}
+
+// PinnedSet returns the pinned projects as a set, empty when the pins can't
+// be read.
+func (s *PinService) PinnedSet() map[string]bool {
+	pinned, err := s.Pinned()
+	if err != nil {
+		s.logger.Debug("failed to load pins", "error", err)
+	}
+
+	set := make(map[string]bool, len(pinned))
+	for _, name := range pinned {
+		set[name] = true
+	}
+	return set
+}
+
+// Pin pins projs. It reports whether the pins changed.
+func (s *PinService) Pin(projs ...*Project) (bool, error) {
+	return s.update(func(pinned []string) []string {
+		for _, p := range projs {
+			if !slices.Contains(pinned, p.String()) {
+				pinned = append(pinned, p.String())
+			}
+		}
+		return pinned
+	})
+}
+
+// Unpin unpins projs. It reports whether the pins changed.
+func (s *PinService) Unpin(projs ...*Project) (bool, error) {
+	return s.update(func(pinned []string) []string {
+		return slices.DeleteFunc(pinned, func(name string) bool {
+			return slices.ContainsFunc(projs, func(p *Project) bool { return p.String() == name })
+		})
+	})
+}
+
+// update replaces the pins by the result of fn, saving them when they
+// changed.
+func (s *PinService) update(fn func(pinned []string) []string) (bool, error) {
+	pinned, err := s.Pinned()
+	if err != nil {
+		return false, err
+	}
+
+	updated := fn(slices.Clone(pinned))
+	sort.Strings(updated)
+	if slices.Equal(updated, pinned) {
+		return false, nil
+	}
+
+	return true, s.save(pinsFile{Version: PinsVersion, RootDir: s.rootDir, Projects: updated})
+}
+
+// save atomically replaces the pins file.
+func (s *PinService) save(pins pinsFile) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pins: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pins: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace pins: %w", err)
+	}
+	return nil
+}
+
+// sortPinned moves the results of pinned projects first, keeping the order
+// of the results otherwise.
+func sortPinned(results []*SearchResult, pinned map[string]bool) {
+	if len(pinned) == 0 {
+		return
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return pinned[results[i].Project.String()] && !pinned[results[j].Project.String()]
+	})
+}
@@ -0,0 +1,71 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPinService(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	svc := NewPinService(&Config{RootDir: "/code"}, &testLogger{})
+
+	a := &Project{Organisation: "user", Name: "a"}
+	b := &Project{Organisation: "org", Name: "b"}
+
+	if pinned, err := svc.Pinned(); err != nil || len(pinned) != 0 {
+		t.Fatalf("Pinned() without pins = %v, %v", pinned, err)
+	}
+
+	if changed, err := svc.Pin(a, b); err != nil || !changed {
+		t.Fatalf("Pin() = %v, %v, want changed", changed, err)
+	}
+	if changed, err := svc.Pin(a); err != nil || changed {
+		t.Errorf("Pin() of a pinned project = %v, %v, want unchanged", changed, err)
+	}
+	if pinned, _ := svc.Pinned(); !reflect.DeepEqual(pinned, []string{"org/b", "user/a"}) {
+		t.Errorf("Pinned() = %v, want [org/b user/a]", pinned)
+	}
+
+	if changed, err := svc.Unpin(b); err != nil || !changed {
+		t.Fatalf("Unpin() = %v, %v, want changed", changed, err)
+	}
+	if set := svc.PinnedSet(); len(set) != 1 || !set["user/a"] {
+		t.Errorf("PinnedSet() = %v, want user/a", set)
+	}
+
+	// Pins are kept per projects root
+	other := NewPinService(&Config{RootDir: "/other"}, &testLogger{})
+	if pinned, _ := other.Pinned(); len(pinned) != 0 {
+		t.Errorf("Pinned() of another root = %v, want none", pinned)
+	}
+}
+
+func TestSearchPinned(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	for _, name := range []string{"user/api", "user/api-client", "work/legacy-api"} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+	}
+
+	cfg := &Config{RootDir: root}
+	if _, err := NewPinService(cfg, &testLogger{}).Pin(&Project{Organisation: "work", Name: "legacy-api"}); err != nil {
+		t.Fatalf("Pin() failed: %v", err)
+	}
+
+	results, err := NewQueryService(cfg, &testLogger{}).Search(context.Background(), SearchOptions{Query: "api"})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 3 || results[0].Project.String() != "work/legacy-api" || results[1].Project.String() != "user/api" {
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Project.String())
+		}
+		t.Errorf("Search(api) = %v, want work/legacy-api pinned first, then user/api", got)
+	}
+}
@@ -0,0 +1,71 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// readmeNames are the README files looked up by FindReadme, preferred in
+// this order and matched case-insensitively.
+var readmeNames = []string{"readme.md", "readme.markdown", "readme.rst", "readme.txt", "readme"}
+
+// HeadCommit is the commit checked out in a repository.
+type HeadCommit struct {
+	Branch  string // empty when HEAD is detached
+	Hash    string
+	Subject string
+	Author  string
+	When    time.Time
+}
+
+// ReadHead returns the commit checked out in the repository or workspace at
+// path, read with go-git.
+func ReadHead(path string) (*HeadCommit, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	info := &HeadCommit{
+		Hash:    head.Hash().String(),
+		Subject: strings.TrimSpace(strings.SplitN(commit.Message, "\n", 2)[0]),
+		Author:  commit.Author.Name,
+		When:    commit.Author.When,
+	}
+	if head.Name().IsBranch() {
+		info.Branch = head.Name().Short()
+	}
+	return info, nil
+}
+
+// FindReadme returns the path of the README of dir, or an empty string if
+// it has none.
+func FindReadme(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	for _, name := range readmeNames {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(entry.Name(), name) {
+				return filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return ""
+}
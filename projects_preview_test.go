@@ -0,0 +1,39 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindReadme(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{name: "markdown", files: []string{"README.md", "main.go"}, want: "README.md"},
+		{name: "markdown preferred", files: []string{"README", "README.md"}, want: "README.md"},
+		{name: "lowercase", files: []string{"readme.rst"}, want: "readme.rst"},
+		{name: "none", files: []string{"main.go", "READMEs.md"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want := ""
+			if tt.want != "" {
+				want = filepath.Join(dir, tt.want)
+			}
+			if got := FindReadme(dir); got != want {
+				t.Errorf("FindReadme() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package projects
 
 import (
+	"context"
 	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gfanton/projects/internal/project"
 	"github.com/go-git/go-git/v5"
@@ -35,6 +40,9 @@ const (
 type ProjectService struct {
 	logger Logger
 	config *Config
+
+	mu              sync.Mutex
+	defaultBranches map[string]string // default branch by project path
 }
 
 // NewProjectService creates a new project service.
@@ -46,17 +54,82 @@ func NewProjectService(config *Config, logger Logger) *ProjectService {
 }
 
 // ParseProject parses a project name into a Project struct.
-// Supports formats: "project" (uses default user), "user/project".
+// Supports formats: "project" (uses default user), "user/project",
+// "host/user/project" for the projects of other Git servers than GitHub,
+// "[host/]user/project/subproject" for the subprojects of a monorepo, and
+// the aliases of the configuration. The first segment is a host when it
+// contains a dot, or has a [hosts] setting; "host/project" uses the default
+// organisation of the host.
 func (s *ProjectService) ParseProject(name string) (*Project, error) {
+	name = strings.TrimSpace(name)
+	if target, ok := s.config.Aliases[name]; ok {
+		s.logger.Debug("resolved project alias", "alias", name, "project", target)
+		name = target
+	}
+
+	if hostName, rest, ok := strings.Cut(name, "/"); ok {
+		host, configured := s.config.Hosts[hostName]
+		if configured || (project.IsHostName(hostName) && strings.Contains(rest, "/")) {
+			return s.parseHostProject(hostName, host, rest)
+		}
+	}
+
+	// user/project/subproject names a subproject of a monorepo
+	if parts := strings.SplitN(name, "/", 3); len(parts) == 3 {
+		parent, err := s.ParseProject(parts[0] + "/" + parts[1])
+		if err != nil {
+			return nil, err
+		}
+		return s.Subproject(parent, parts[2])
+	}
+
 	p, err := project.ParseProject(s.config.RootDir, s.config.RootUser, name)
 	if err != nil {
 		return nil, err
 	}
-	return &Project{
+	return newProject(p), nil
+}
+
+// parseHostProject parses the name of a project, or of a subproject, of
+// the Git server hostName without its leading "<host>/".
+func (s *ProjectService) parseHostProject(hostName string, host Host, name string) (*Project, error) {
+	var subproject string
+	if parts := strings.SplitN(name, "/", 3); len(parts) == 3 {
+		name, subproject = parts[0]+"/"+parts[1], parts[2]
+	}
+
+	var p *project.Project
+	var err error
+	if hostName == project.DefaultProvider {
+		p, err = project.ParseProject(s.config.RootDir, s.config.RootUser, name)
+	} else {
+		p, err = project.ParseHostProject(s.config.RootDir, project.Host{
+			Name:             hostName,
+			SSHUser:          host.SSHUser,
+			DefaultOrgPrefix: host.DefaultOrgPrefix,
+		}, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subproject != "" {
+		return s.Subproject(newProject(p), subproject)
+	}
+	return newProject(p), nil
+}
+
+// newProject returns the Project of p.
+func newProject(p *project.Project) *Project {
+	proj := &Project{
 		Path:         p.Path,
 		Name:         p.Name,
 		Organisation: p.Organisation,
-	}, nil
+	}
+	if p.Host != nil {
+		proj.Host = p.Host.Name
+	}
+	return proj
 }
 
 // GitDir returns the path to the .git directory.
@@ -88,10 +161,104 @@ func (p *Project) GetGitStatus() GitStatus {
 	}
 }
 
+// Language returns the primary language of the project detected from its
+// manifest files, or an empty string if unknown.
+func (p *Project) Language() string {
+	return project.DetectLanguage(p.Path)
+}
+
+// Tags returns the tags attached to the project through its Git config
+// (e.g. "git config --add proj.tag work").
+func (p *Project) Tags() []string {
+	repo, err := p.OpenRepository()
+	if err != nil {
+		return nil
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil
+	}
+
+	return cfg.Raw.Section("proj").Options.GetAll("tag")
+}
+
+// AddTags attaches tags to the project through its Git config, skipping the
+// ones already attached.
+func (p *Project) AddTags(ctx context.Context, tags ...string) error {
+	existing := p.Tags()
+	for _, tag := range tags {
+		if slices.Contains(existing, tag) {
+			continue
+		}
+		if _, err := runGitCombined(ctx, p.Path, "config", "--add", "proj.tag", tag); err != nil {
+			return err
+		}
+		existing = append(existing, tag)
+	}
+	return nil
+}
+
+// RemoteURL returns the URL of the origin remote of the project, or an
+// empty string if it has none.
+func (p *Project) RemoteURL() string {
+	repo, err := p.OpenRepository()
+	if err != nil {
+		return ""
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return ""
+	}
+	return remote.Config().URLs[0]
+}
+
+// LastActivity returns the time of the most recent Git activity in the
+// project: the later of the HEAD commit date and the modification time of
+// the files Git touches on checkout, commit and fetch. It returns the zero
+// time if nothing could be read.
+func (p *Project) LastActivity() time.Time {
+	latest := lastActivity(p)
+
+	repo, err := p.OpenRepository()
+	if err != nil {
+		return latest
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return latest
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return latest
+	}
+
+	if when := commit.Committer.When; when.After(latest) {
+		latest = when
+	}
+	return latest
+}
+
 // WalkFunc is the function called for each project during traversal.
 type WalkFunc func(d fs.DirEntry, project *Project) error
 
-// ListProjects walks the root directory and returns all projects found.
+// WalkErrors is returned by Walk, and with the partial results of the
+// searches walking the projects, when directories of the root directory
+// could not be read: the projects of the other directories were walked.
+type WalkErrors = project.WalkErrors
+
+// IsPartialWalk reports whether err is the *WalkErrors of a walk that
+// skipped unreadable directories, rather than a failure.
+func IsPartialWalk(err error) bool {
+	var walkErrs *WalkErrors
+	return errors.As(err, &walkErrs)
+}
+
+// ListProjects walks the root directory and returns all projects found,
+// with a *WalkErrors error when unreadable directories were skipped.
 func (s *ProjectService) ListProjects() ([]*Project, error) {
 	var projects []*Project
 
@@ -105,13 +272,18 @@ func (s *ProjectService) ListProjects() ([]*Project, error) {
 
 // Walk traverses the root directory and calls fn for each project found.
 // It follows symlinks to directories to support projects added via symlinks.
+// Unreadable directories are skipped and reported by a *WalkErrors error.
 func (s *ProjectService) Walk(fn WalkFunc) error {
-	return project.Walk(s.config.RootDir, func(d fs.DirEntry, p *project.Project) error {
-		return fn(d, &Project{
-			Path:         p.Path,
-			Name:         p.Name,
-			Organisation: p.Organisation,
-		})
+	return s.WalkOrgs(nil, fn)
+}
+
+// WalkOrgs is like Walk, but only walks the top-level directories,
+// organisations or Git server hosts, whose name matches orgs. A nil orgs
+// walks them all.
+func (s *ProjectService) WalkOrgs(orgs func(name string) bool, fn WalkFunc) error {
+	opts := project.WalkOptions{Orgs: orgs, AllowHidden: s.config.AllowHidden, Hosts: s.config.hostNames()}
+	return project.WalkWith(s.config.RootDir, opts, func(d fs.DirEntry, p *project.Project) error {
+		return fn(d, newProject(p))
 	})
 }
 
@@ -119,13 +291,9 @@ func (s *ProjectService) Walk(fn WalkFunc) error {
 // and follows the organization/project structure.
 // Also handles paths inside .workspace directory.
 func (s *ProjectService) FindFromPath(path string) (*Project, error) {
-	p, err := project.FindFromPath(s.config.RootDir, path)
+	p, err := project.FindFromPathWith(s.config.RootDir, path, s.config.hostNames())
 	if err != nil {
 		return nil, err
 	}
-	return &Project{
-		Path:         p.Path,
-		Name:         p.Name,
-		Organisation: p.Organisation,
-	}, nil
+	return newProject(p), nil
 }
@@ -0,0 +1,112 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BranchUpdate is the result of UpdateDefaultBranch: the default branch of
+// a project and its head commit before and after, the same when it was up to
+// date.
+type BranchUpdate struct {
+	Branch string
+	From   string
+	To     string
+}
+
+// UpToDate reports whether the branch did not move.
+func (u BranchUpdate) UpToDate() bool {
+	return u.From == u.To
+}
+
+// UpdateDefaultBranch fetches origin in the project p and fast-forwards its
+// default branch to origin. When the branch is checked out, in the main
+// checkout or in a workspace, the checkout is fast-forwarded and must have
+// no uncommitted changes; otherwise only the branch moves. A branch that
+// diverged from origin is left untouched with an error.
+func (s *ProjectService) UpdateDefaultBranch(ctx context.Context, p *Project) (BranchUpdate, error) {
+	if err := s.config.CheckWritable("update " + p.String()); err != nil {
+		return BranchUpdate{}, err
+	}
+
+	fetchCtx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
+
+	err := s.config.retry().Do(fetchCtx, s.logger.Warn, func() error {
+		_, err := runGitCombined(fetchCtx, p.Path, "fetch", "--quiet", "origin")
+		return err
+	})
+	if err != nil {
+		return BranchUpdate{}, fmt.Errorf("failed to fetch origin: %w", err)
+	}
+
+	branch, err := s.DefaultBranch(ctx, p)
+	if err != nil {
+		return BranchUpdate{}, err
+	}
+	update := BranchUpdate{Branch: branch}
+
+	target, err := runGitCombined(ctx, p.Path, "rev-parse", "--verify", "refs/remotes/origin/"+branch)
+	if err != nil {
+		return update, fmt.Errorf("no origin/%s to update %s from", branch, branch)
+	}
+	if update.From, err = runGitCombined(ctx, p.Path, "rev-parse", "--verify", "refs/heads/"+branch); err != nil {
+		// No local branch: nothing to move, origin is fetched
+		update.From, update.To = target, target
+		return update, nil
+	}
+	if update.From == target {
+		update.To = target
+		return update, nil
+	}
+
+	if _, err := runGitCombined(ctx, p.Path, "merge-base", "--is-ancestor", update.From, target); err != nil {
+		return update, fmt.Errorf("branch %s has diverged from origin/%s", branch, branch)
+	}
+
+	checkout, err := branchCheckout(ctx, p.Path, branch)
+	if err != nil {
+		return update, err
+	}
+
+	if checkout == "" {
+		if _, err := runGitCombined(ctx, p.Path, "update-ref", "refs/heads/"+branch, target, update.From); err != nil {
+			return update, fmt.Errorf("failed to update %s: %w", branch, err)
+		}
+	} else {
+		status, err := runGitCombined(ctx, checkout, "status", "--porcelain", "--untracked-files=no")
+		if err != nil {
+			return update, fmt.Errorf("failed to get status of %s: %w", checkout, err)
+		}
+		if status != "" {
+			return update, fmt.Errorf("%w: %s (commit or stash them first)", ErrDirtyCheckout, checkout)
+		}
+		if _, err := runGitCombined(ctx, checkout, "merge", "--ff-only", "--quiet", target); err != nil {
+			return update, fmt.Errorf("failed to fast-forward %s: %w", branch, err)
+		}
+	}
+
+	update.To = target
+	s.logger.Info("default branch updated", "project", p.String(), "branch", branch, "from", update.From, "to", update.To)
+	return update, nil
+}
+
+// branchCheckout returns the path of the worktree of the repository at dir
+// having branch checked out, or an empty string when none has.
+func branchCheckout(ctx context.Context, dir, branch string) (string, error) {
+	output, err := runGitCombined(ctx, dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return "", fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var path string
+	for _, line := range strings.Split(output, "\n") {
+		if p, ok := strings.CutPrefix(line, "worktree "); ok {
+			path = p
+		} else if line == "branch refs/heads/"+branch {
+			return path, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,92 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateDefaultBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(t.TempDir(), "origin")
+	p := &Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+
+	commit := func(dir, name, content string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", name)
+		runGit(t, dir, "commit", "-m", name)
+		return runGit(t, dir, "rev-parse", "HEAD")
+	}
+
+	if err := os.MkdirAll(origin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, origin, "init", "-b", "main")
+	commit(origin, "a", "a\n")
+	runGit(t, root, "clone", origin, p.Path)
+
+	svc := NewProjectService(&Config{RootDir: root}, &testLogger{})
+
+	update, err := svc.UpdateDefaultBranch(ctx, p)
+	if err != nil || !update.UpToDate() || update.Branch != "main" {
+		t.Fatalf("UpdateDefaultBranch() = %+v, %v, want main up to date", update, err)
+	}
+
+	// Checked out: the checkout is fast-forwarded, untracked files don't matter
+	head := commit(origin, "b", "b\n")
+	if err := os.WriteFile(filepath.Join(p.Path, "notes"), []byte("notes\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if update, err := svc.UpdateDefaultBranch(ctx, p); err != nil || update.To != head || update.UpToDate() {
+		t.Fatalf("UpdateDefaultBranch() checked out = %+v, %v, want ..%s", update, err, head)
+	}
+	if _, err := os.Stat(filepath.Join(p.Path, "b")); err != nil {
+		t.Errorf("checkout not fast-forwarded: %v", err)
+	}
+
+	// Checked out with uncommitted changes: left untouched
+	commit(origin, "c", "c\n")
+	if err := os.WriteFile(filepath.Join(p.Path, "a"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UpdateDefaultBranch(ctx, p); !errors.Is(err, ErrDirtyCheckout) {
+		t.Errorf("UpdateDefaultBranch() dirty error = %v, want ErrDirtyCheckout", err)
+	}
+	if got := runGit(t, p.Path, "rev-parse", "main"); got != head {
+		t.Errorf("main = %s after dirty update, want %s", got, head)
+	}
+	runGit(t, p.Path, "checkout", "--", "a")
+
+	// Not checked out: only the branch moves
+	runGit(t, p.Path, "checkout", "-q", "-b", "feature")
+	head = commit(origin, "d", "d\n")
+	if update, err := svc.UpdateDefaultBranch(ctx, p); err != nil || update.To != head {
+		t.Fatalf("UpdateDefaultBranch() not checked out = %+v, %v, want ..%s", update, err, head)
+	}
+	if got := runGit(t, p.Path, "rev-parse", "main"); got != head {
+		t.Errorf("main = %s, want %s", got, head)
+	}
+
+	// Diverged: left untouched
+	runGit(t, p.Path, "checkout", "-q", "main")
+	local := commit(p.Path, "local", "local\n")
+	commit(origin, "e", "e\n")
+	if _, err := svc.UpdateDefaultBranch(ctx, p); err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Errorf("UpdateDefaultBranch() diverged error = %v, want diverged", err)
+	}
+	if got := runGit(t, p.Path, "rev-parse", "main"); got != local {
+		t.Errorf("main = %s after diverged update, want %s", got, local)
+	}
+}
@@ -0,0 +1,89 @@
+package projects
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pullRequestFile is the file recording the pull request of a workspace,
+// kept in its worktree metadata directory (<gitdir>/worktrees/<id>) so that
+// it leaves the checkout clean and goes away with the worktree.
+const pullRequestFile = "proj-pull-request.json"
+
+// PullRequestInfo is the pull request a workspace was added for with "#123".
+type PullRequestInfo struct {
+	Number int    `json:"number"`
+	URL    string `json:"url,omitempty"` // web page of the pull request, empty when the remote is not a hosted one
+}
+
+// ReadPullRequest returns the pull request recorded for the workspace at
+// path, or nil if it was not added for one.
+func (s *WorkspaceService) ReadPullRequest(path string) (*PullRequestInfo, error) {
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Git directory of %s: %w", path, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(gitDir, pullRequestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pull request of %s: %w", path, err)
+	}
+
+	var info PullRequestInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to decode pull request of %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// writePullRequest records info as the pull request of the workspace at
+// path.
+func writePullRequest(path string, info PullRequestInfo) error {
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve Git directory of %s: %w", path, err)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to encode pull request: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, pullRequestFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write pull request of %s: %w", path, err)
+	}
+	return nil
+}
+
+// pullRequestURL returns the web page of the pull request num of the
+// repository at the Git remote URL, in the https, ssh:// or scp-like
+// (git@host:org/name) forms, or an empty string for local remotes.
+func pullRequestURL(remoteURL string, num int) string {
+	url := strings.TrimSpace(remoteURL)
+	if scheme, rest, ok := strings.Cut(url, "://"); ok {
+		if scheme == "file" {
+			return ""
+		}
+		url = rest
+	} else if filepath.IsAbs(url) || !strings.Contains(url, ":") {
+		return ""
+	}
+	if _, rest, ok := strings.Cut(url, "@"); ok {
+		url = rest
+	}
+
+	// Drop the path, then the port or the path of scp-like URLs
+	host, _, _ := strings.Cut(url, "/")
+	host, _, _ = strings.Cut(host, ":")
+	org, name := remoteRepository(remoteURL)
+	if host == "" || org == "" || name == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://%s/%s/%s/pull/%d", host, org, name, num)
+}
@@ -0,0 +1,27 @@
+package projects
+
+import "testing"
+
+func TestPullRequestURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"https://github.com/gfanton/projects.git", "https://github.com/gfanton/projects/pull/42"},
+		{"https://token@github.com/gfanton/projects", "https://github.com/gfanton/projects/pull/42"},
+		{"git@github.com:gfanton/projects.git", "https://github.com/gfanton/projects/pull/42"},
+		{"ssh://git@git.example.com:2222/gfanton/projects.git", "https://git.example.com/gfanton/projects/pull/42"},
+		{"/srv/git/projects.git", ""},
+		{"file:///srv/git/gfanton/projects.git", ""},
+		{"../projects", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			if got := pullRequestURL(tt.remote, 42); got != tt.want {
+				t.Errorf("pullRequestURL(%q) = %q, want %q", tt.remote, got, tt.want)
+			}
+		})
+	}
+}
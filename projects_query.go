@@ -1,15 +1,20 @@
 package projects
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"path/filepath"
-	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/gfanton/projects/internal/index"
+	"github.com/gfanton/projects/internal/match"
 )
 
 // ---- Distance Constants
@@ -21,21 +26,31 @@ const (
 	distanceFuzzyFallback = 50
 	distanceBranchSubstr  = 5
 	distanceBranchFuzzy   = 20
+	distanceDescription   = 40
 )
 
-// pathsEqual compares paths with case-insensitivity on macOS/Windows.
-func pathsEqual(a, b string) bool {
-	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
-		return strings.EqualFold(a, b)
-	}
-	return a == b
-}
+// ---- Sort Orders
+const (
+	// SortRelevance orders results by match distance (default).
+	SortRelevance = "relevance"
+	// SortRecent orders results by most recent Git activity first.
+	SortRecent = "recent"
+)
+
+// SortNames lists the supported sort orders.
+var SortNames = []string{SortRelevance, SortRecent}
+
+// ErrNoMatch is returned when a query matches no project or workspace.
+var ErrNoMatch = errors.New("no matching projects found")
 
 // QueryService provides project querying functionality.
 type QueryService struct {
 	logger           Logger
 	projectService   *ProjectService
 	workspaceService *WorkspaceService
+	pinService       *PinService
+	subdirService    *SubdirService
+	indexPath        string
 }
 
 // NewQueryService creates a new query service.
@@ -43,14 +58,81 @@ func NewQueryService(config *Config, logger Logger) *QueryService {
 	projectSvc := NewProjectService(config, logger)
 	workspaceSvc := NewWorkspaceService(config, logger)
 
+	indexPath, err := index.DefaultPath()
+	if err != nil {
+		logger.Debug("description index unavailable", "error", err)
+	}
+
 	return &QueryService{
 		logger:           logger,
 		projectService:   projectSvc,
 		workspaceService: workspaceSvc,
+		pinService:       NewPinService(config, logger),
+		subdirService:    NewSubdirService(config, logger),
+		indexPath:        indexPath,
 	}
 }
 
+// projectSource enumerates the projects and workspaces considered by a search.
+type projectSource interface {
+	// WalkOrgs calls fn for the projects of the top-level directories
+	// matching orgs, or all of them when orgs is nil.
+	WalkOrgs(orgs func(name string) bool, fn WalkFunc) error
+	Workspaces(ctx context.Context, p *Project) ([]Workspace, error)
+}
+
+// liveSource walks the filesystem and reads workspaces from the workspace
+// directory, scanned once, falling back to the worktree metadata of projects
+// and then to git when the scan doesn't match them.
+type liveSource struct {
+	projectService   *ProjectService
+	workspaceService *WorkspaceService
+
+	scanned map[string][]ScannedWorkspace // nil until scanned
+	scanErr error
+}
+
+func (l *liveSource) WalkOrgs(orgs func(name string) bool, fn WalkFunc) error {
+	return l.projectService.WalkOrgs(orgs, fn)
+}
+
+func (l *liveSource) Workspaces(ctx context.Context, p *Project) ([]Workspace, error) {
+	logger := l.workspaceService.logger
+
+	if !p.IsBare() {
+		if l.scanned == nil && l.scanErr == nil {
+			l.scanned, l.scanErr = l.workspaceService.ScanWorkspaces()
+			if l.scanErr != nil {
+				logger.Debug("failed to scan workspaces", "error", l.scanErr)
+			}
+		}
+		if l.scanErr == nil {
+			if workspaces, ok := validScan(*p, l.scanned[p.String()]); ok {
+				return workspaces, nil
+			}
+			logger.Debug("scanned workspaces don't match project, reading worktrees", "project", p.String())
+		}
+	}
+
+	workspaces, err := l.workspaceService.ReadList(*p)
+	if err != nil {
+		logger.Debug("failed to read worktrees, asking git", "project", p.String(), "error", err)
+		return l.workspaceService.List(ctx, *p)
+	}
+	return workspaces, nil
+}
+
+// source returns where projects are read from for the given options.
+func (s *QueryService) source(opts SearchOptions) projectSource {
+	if opts.Snapshot != nil {
+		return cacheSource{snapshot: opts.Snapshot, workspaceService: s.workspaceService}
+	}
+	return &liveSource{projectService: s.projectService, workspaceService: s.workspaceService}
+}
+
 // Search searches for projects and workspaces matching the given options.
+// When directories of the root directory can't be read, the results found
+// in the others are returned with a *WalkErrors error (see IsPartialWalk).
 func (s *QueryService) Search(ctx context.Context, opts SearchOptions) ([]*SearchResult, error) {
 	s.logger.Debug("searching projects and workspaces",
 		"query", opts.Query,
@@ -70,130 +152,234 @@ func (s *QueryService) Search(ctx context.Context, opts SearchOptions) ([]*Searc
 		if err != nil {
 			return nil, fmt.Errorf("invalid exclude path '%s': %w", exclude, err)
 		}
-		excludeMap[abs] = true
+		excludeMap[match.Path(abs)] = true
+	}
+
+	scorer, err := NewScorer(opts.Rank)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Sort {
+	case "", SortRelevance, SortRecent:
+	default:
+		return nil, fmt.Errorf("unknown sort order '%s' (expected one of: %s)", opts.Sort, strings.Join(SortNames, ", "))
 	}
 
+	// Extract negative filters (!term, -org:x, -name:x) from the query
+	query, filters := parseQueryFilters(opts.Query)
+	opts.Query = resolveQueryAlias(s.projectService.config.Aliases, query)
+
 	// Check if query contains workspace syntax (contains ':')
 	isWorkspaceQuery := strings.Contains(opts.Query, ":")
 
+	src := s.source(opts)
+	if strings.Contains(opts.Query, "//") {
+		return s.searchSubpaths(ctx, opts, src, scorer, filters, excludeMap)
+	}
 	if isWorkspaceQuery {
-		return s.searchWorkspaces(ctx, opts, excludeMap)
+		return s.searchWorkspaces(ctx, opts, src, scorer, filters, excludeMap)
 	}
 
-	return s.searchProjects(ctx, opts, excludeMap)
+	return s.searchProjects(ctx, opts, src, scorer, filters, excludeMap)
 }
 
-func (s *QueryService) searchProjects(ctx context.Context, opts SearchOptions, excludeMap map[string]bool) ([]*SearchResult, error) {
+// resolveQueryAlias replaces an alias in the project part of query, e.g.
+// "k8s" or "k8s:branch", with the "user/project" it stands for, so that the
+// aliased project matches exactly.
+func resolveQueryAlias(aliases map[string]string, query string) string {
+	projectPart, branchPart, isWorkspace := strings.Cut(query, ":")
+	target, ok := aliases[strings.TrimSpace(projectPart)]
+	if !ok {
+		return query
+	}
+	if isWorkspace {
+		return target + ":" + branchPart
+	}
+	return target
+}
+
+func (s *QueryService) searchProjects(ctx context.Context, opts SearchOptions, src projectSource, scorer Scorer, filters queryFilters, excludeMap map[string]bool) ([]*SearchResult, error) {
 	var results []*SearchResult
 
-	qLower := strings.ToLower(opts.Query)
-	qOrg, qName, qHasOrg := strings.Cut(qLower, "/")
+	query := match.Normalize(opts.Query)
 
-	err := s.projectService.Walk(func(d fs.DirEntry, p *Project) error {
+	var descriptions *index.Index
+	var orgs func(string) bool
+	if opts.Descriptions && query != "" {
+		descriptions = s.loadIndex()
+	}
+	if descriptions == nil {
+		// Descriptions match projects of any organisation
+		orgs = queryOrgs(scorer, query)
+	}
+
+	err := src.WalkOrgs(orgs, func(d fs.DirEntry, p *Project) error {
 		// Check if project should be excluded
-		if excludeMap[p.Path] {
+		if excludeMap[match.Path(p.Path)] {
 			s.logger.Debug("excluding project", "path", p.Path)
 			return filepath.SkipDir
 		}
 
-		if opts.Query == "" {
-			results = append(results, &SearchResult{
-				Project:   p,
-				Workspace: "",
-				Distance:  1,
-			})
+		if filters.excludes(p) {
+			s.logger.Debug("filtering out project", "project", p.String())
 			return nil
 		}
 
-		// Calculate match distance
-		projectName := p.String()
-		distance := fuzzy.RankMatchFold(opts.Query, projectName)
-		if distance < 0 {
-			return nil
+		// Subprojects of monorepos are matched like projects
+		for _, candidate := range append([]*Project{p}, s.projectService.Subprojects(p)...) {
+			if candidate != p && excludeMap[match.Path(candidate.Path)] {
+				continue
+			}
+			if result := s.matchProject(query, candidate, scorer, descriptions); result != nil {
+				results = append(results, result)
+			}
 		}
 
-		projectLower := strings.ToLower(projectName)
+		return nil
+	})
+
+	if err != nil && !IsPartialWalk(err) {
+		return nil, fmt.Errorf("failed to walk projects: %w", err)
+	}
+
+	return s.sortAndLimitResults(results, opts), err
+}
 
-		// Split project name into parts (org/name)
-		pOrg, pName, _ := strings.Cut(projectLower, "/")
+// searchSubpaths searches the directories of projects for queries of the
+// "project//subpath" form. Without project part, only the current project is
+// searched.
+func (s *QueryService) searchSubpaths(ctx context.Context, opts SearchOptions, src projectSource, scorer Scorer, filters queryFilters, excludeMap map[string]bool) ([]*SearchResult, error) {
+	projectPart, subpath, _ := strings.Cut(opts.Query, "//")
+	projectPart = match.Normalize(resolveQueryAlias(s.projectService.config.Aliases, strings.TrimSpace(projectPart)))
 
-		if qHasOrg {
-			if qOrg != pOrg {
-				return nil
-			}
+	subpath, ok := cleanSubpath(strings.TrimSpace(subpath))
+	if !ok {
+		return nil, fmt.Errorf("invalid subpath in '%s': must be inside the project", opts.Query)
+	}
 
-			if qName == pName {
-				distance = 0
-			} else {
-				distance = fuzzy.RankMatchFold(qName, pName)
-			}
-		} else {
-			switch {
-			case qLower == pName:
-				distance = distanceExactName
-			case qLower == pOrg:
-				distance = distanceExactOrg
-			case strings.Contains(pName, qLower):
-				distance = distanceNameContains + fuzzy.RankMatchFold(qLower, pName)
-			case strings.Contains(pOrg, qLower):
-				distance = distanceOrgContains + fuzzy.RankMatchFold(qLower, pOrg)
-			default:
-				distance = distanceFuzzyFallback + fuzzy.RankMatchFold(qLower, projectLower)
+	s.logger.Debug("searching subpaths", "projectPart", projectPart, "subpath", subpath)
+
+	var matched []*SearchResult
+	err := src.WalkOrgs(queryOrgs(scorer, projectPart), func(d fs.DirEntry, p *Project) error {
+		if excludeMap[match.Path(p.Path)] {
+			s.logger.Debug("excluding project", "path", p.Path)
+			return filepath.SkipDir
+		}
+
+		if filters.excludes(p) {
+			s.logger.Debug("filtering out project", "project", p.String())
+			return nil
+		}
+
+		for _, candidate := range append([]*Project{p}, s.projectService.Subprojects(p)...) {
+			distance := 0
+			if projectPart != "" {
+				if distance = scorer.ScoreProject(projectPart, candidate); distance < 0 {
+					continue
+				}
+			} else if opts.CurrentProject == nil || !match.PathsEqual(candidate.Path, opts.CurrentProject.Path) {
+				continue
 			}
+			matched = append(matched, &SearchResult{Project: candidate, Distance: distance})
+		}
+		return nil
+	})
+	if err != nil && !IsPartialWalk(err) {
+		return nil, fmt.Errorf("failed to walk projects: %w", err)
+	}
+	walkErr := err
+
+	projs := make([]*Project, len(matched))
+	for i, m := range matched {
+		projs[i] = m.Project
+	}
+	topLevel := s.subdirService.TopLevel(projs)
+
+	var results []*SearchResult
+	for i, m := range matched {
+		for dir, distance := range matchSubpaths(m.Project.Path, subpath, topLevel[i]) {
+			results = append(results, &SearchResult{
+				Project:  m.Project,
+				Subpath:  dir,
+				Distance: m.Distance + distance,
+			})
 		}
+	}
+	return s.sortAndLimitResults(results, opts), walkErr
+}
 
-		results = append(results, &SearchResult{
+// matchProject returns the result of p for query, or nil if p doesn't
+// match. All projects match an empty query.
+func (s *QueryService) matchProject(query string, p *Project, scorer Scorer, descriptions *index.Index) *SearchResult {
+	if query == "" {
+		return &SearchResult{
 			Project:   p,
 			Workspace: "",
-			Distance:  distance,
-		})
-
-		s.logger.Debug("found matching project",
-			"name", projectName,
-			"distance", distance,
-		)
+			Distance:  1,
+		}
+	}
 
+	// Calculate match distance
+	distance := scorer.ScoreProject(query, p)
+	if descriptions != nil {
+		if d := scoreDescription(query, descriptions, p); d >= 0 && (distance < 0 || d < distance) {
+			distance = d
+		}
+	}
+	if distance < 0 {
 		return nil
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to walk projects: %w", err)
 	}
 
-	return s.sortAndLimitResults(results, opts), nil
+	s.logger.Debug("found matching project",
+		"name", p.String(),
+		"distance", distance,
+	)
+
+	return &SearchResult{
+		Project:   p,
+		Workspace: "",
+		Distance:  distance,
+	}
 }
 
-func (s *QueryService) searchWorkspaces(ctx context.Context, opts SearchOptions, excludeMap map[string]bool) ([]*SearchResult, error) {
+func (s *QueryService) searchWorkspaces(ctx context.Context, opts SearchOptions, src projectSource, scorer Scorer, filters queryFilters, excludeMap map[string]bool) ([]*SearchResult, error) {
 	var results []*SearchResult
 
 	// Parse workspace query: project_part:branch_part
 	projectPart, branchPart, _ := strings.Cut(opts.Query, ":")
-	projectPart = strings.TrimSpace(projectPart)
-	branchPart = strings.TrimSpace(branchPart)
+	projectPart = match.Normalize(strings.TrimSpace(projectPart))
+	branchPart = match.Normalize(strings.TrimSpace(branchPart))
 
 	s.logger.Debug("searching workspaces", "projectPart", projectPart, "branchPart", branchPart)
 
-	err := s.projectService.Walk(func(d fs.DirEntry, p *Project) error {
+	err := src.WalkOrgs(workspaceQueryOrgs(scorer, projectPart), func(d fs.DirEntry, p *Project) error {
 		// Check if project should be excluded
-		if excludeMap[p.Path] {
+		if excludeMap[match.Path(p.Path)] {
 			s.logger.Debug("excluding project", "path", p.Path)
 			return filepath.SkipDir
 		}
 
+		if filters.excludes(p) {
+			s.logger.Debug("filtering out project", "project", p.String())
+			return nil
+		}
+
 		// If project part is specified, check if this project matches
+		projectDistance := 0
 		if projectPart != "" {
-			projectName := strings.ToLower(p.String())
-			if !s.matchesProject(projectPart, projectName) {
+			projectDistance = scoreWorkspaceProject(scorer, projectPart, p)
+			if projectDistance < 0 {
 				return nil
 			}
 		} else if opts.CurrentProject != nil {
-			if !pathsEqual(p.Path, opts.CurrentProject.Path) {
+			if !match.PathsEqual(p.Path, opts.CurrentProject.Path) {
 				return nil
 			}
 		}
 
 		// Get workspaces for this project
-		workspaces, err := s.workspaceService.List(ctx, *p)
+		workspaces, err := src.Workspaces(ctx, p)
 		if err != nil {
 			s.logger.Debug("failed to list workspaces for project", "project", p.String(), "error", err)
 			return nil // Continue with other projects
@@ -201,117 +387,186 @@ func (s *QueryService) searchWorkspaces(ctx context.Context, opts SearchOptions,
 
 		// Match workspaces against branch part
 		for _, ws := range workspaces {
-			if branchPart == "" || s.matchesBranch(branchPart, ws.Branch) {
-				distance := s.calculateWorkspaceDistance(projectPart, branchPart, p.String(), ws.Branch)
-				results = append(results, &SearchResult{
-					Project:   p,
-					Workspace: ws.Branch,
-					Distance:  distance,
-				})
-
-				s.logger.Debug("found matching workspace",
-					"project", p.String(),
-					"branch", ws.Branch,
-					"distance", distance,
-				)
+			distance := projectDistance
+			if branchPart != "" {
+				branchDistance := scorer.ScoreBranch(branchPart, match.Normalize(ws.Branch))
+				if branchDistance < 0 {
+					continue
+				}
+				distance += branchDistance
+			}
+
+			result := &SearchResult{
+				Project:   p,
+				Workspace: ws.Branch,
+				Distance:  distance,
 			}
+
+			if opts.Annotate {
+				dirty, err := s.workspaceService.IsDirty(ctx, ws.Path)
+				if err != nil {
+					s.logger.Debug("failed to get workspace status", "path", ws.Path, "error", err)
+				}
+				result.Dirty = dirty
+			}
+
+			results = append(results, result)
+
+			s.logger.Debug("found matching workspace",
+				"project", p.String(),
+				"branch", ws.Branch,
+				"distance", distance,
+			)
 		}
 
 		return nil
 	})
 
-	if err != nil {
+	if err != nil && !IsPartialWalk(err) {
 		return nil, fmt.Errorf("failed to walk projects: %w", err)
 	}
 
-	return s.sortAndLimitResults(results, opts), nil
+	return s.sortAndLimitResults(results, opts), err
 }
 
-func (s *QueryService) matchesProject(query, projectName string) bool {
-	queryLower := strings.ToLower(query)
+// loadIndex loads the description index, returning nil when it is missing
+// or unreadable so searches degrade to name matching.
+func (s *QueryService) loadIndex() *index.Index {
+	if s.indexPath == "" {
+		return nil
+	}
+
+	idx, err := index.Load(s.indexPath)
+	if err != nil {
+		s.logger.Warn("failed to load description index", "path", s.indexPath, "error", err)
+		return nil
+	}
 
-	// Exact match
-	if projectName == queryLower {
-		return true
+	if len(idx.Entries) == 0 {
+		s.logger.Warn("description index is empty, run 'proj index' to build it")
 	}
 
-	// Fuzzy match
-	return fuzzy.MatchFold(queryLower, projectName)
+	return idx
 }
 
-func (s *QueryService) matchesBranch(query, branchName string) bool {
-	queryLower := strings.ToLower(query)
-	branchLower := strings.ToLower(branchName)
+// scoreDescription returns the distance between query and the indexed
+// description of the project, or -1 if any query word is missing from it.
+func scoreDescription(query string, idx *index.Index, p *Project) int {
+	entry, ok := idx.Get(p.Path)
+	if !ok || entry.Description == "" {
+		return -1
+	}
 
-	// Exact match
-	if branchLower == queryLower {
-		return true
+	desc := match.Normalize(entry.Description)
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return -1
 	}
 
-	// Substring match
-	if strings.Contains(branchLower, queryLower) {
-		return true
+	for _, word := range words {
+		if !strings.Contains(desc, word) {
+			return -1
+		}
 	}
 
-	// Fuzzy match
-	return fuzzy.MatchFold(queryLower, branchName)
+	return distanceDescription + strings.Index(desc, words[0])
 }
 
-func (s *QueryService) calculateWorkspaceDistance(projectQuery, branchQuery, projectName, branchName string) int {
-	distance := 0
+// annotateResult returns a short human-readable annotation of a result,
+// used by shell completion to annotate candidates.
+func annotateResult(result *SearchResult) string {
+	if result.Subpath != "" {
+		return "directory of " + result.Project.String()
+	}
+	if result.Workspace == "" {
+		return "project"
+	}
 
-	// Project matching distance
-	if projectQuery != "" {
-		projectLower := strings.ToLower(projectName)
-		queryLower := strings.ToLower(projectQuery)
+	state := "clean"
+	if result.Dirty {
+		state = "dirty"
+	}
+	return fmt.Sprintf("workspace of %s (%s)", result.Project.String(), state)
+}
 
-		switch {
-		case projectLower == queryLower:
-			// Exact match: no distance added
-		case strings.Contains(projectLower, queryLower):
-			distance += distanceNameContains
-		default:
-			distance += distanceFuzzyFallback + fuzzy.RankMatchFold(projectQuery, projectName)
-		}
+func (s *QueryService) sortAndLimitResults(results []*SearchResult, opts SearchOptions) []*SearchResult {
+	if opts.Sort == SortRecent {
+		sortByActivity(results)
+	} else {
+		sortByDistance(results)
+	}
+	if s.pinService != nil {
+		sortPinned(results, s.pinService.PinnedSet())
 	}
 
-	// Branch matching distance
-	if branchQuery != "" {
-		branchLower := strings.ToLower(branchName)
-		queryLower := strings.ToLower(branchQuery)
+	// Apply limit
+	if opts.Limit > 0 && opts.Limit < len(results) {
+		results = results[:opts.Limit]
+	}
 
-		switch {
-		case branchLower == queryLower:
-			// Exact match: no distance added
-		case strings.Contains(branchLower, queryLower):
-			distance += distanceBranchSubstr
-		default:
-			distance += distanceBranchFuzzy + fuzzy.RankMatchFold(branchQuery, branchName)
+	return results
+}
+
+// sortByActivity orders results by most recent Git activity first, falling
+// back to distance for projects with the same activity time.
+func sortByActivity(results []*SearchResult) {
+	activity := make(map[string]time.Time, len(results))
+	for _, result := range results {
+		if _, ok := activity[result.Project.Path]; !ok {
+			activity[result.Project.Path] = result.Project.LastActivity()
 		}
 	}
 
-	return distance
+	sortByDistance(results)
+	sort.SliceStable(results, func(i, j int) bool {
+		return activity[results[i].Project.Path].After(activity[results[j].Project.Path])
+	})
 }
 
-func (s *QueryService) sortAndLimitResults(results []*SearchResult, opts SearchOptions) []*SearchResult {
-	// Sort by distance (lower is better), then by project name, then by workspace
+func sortByDistance(results []*SearchResult) {
+	// Sort by distance (lower is better), then by project name, then by workspace and subpath
 	sort.Slice(results, func(i, j int) bool {
 		if results[i].Distance == results[j].Distance {
 			projectCompare := results[i].Project.String()
 			if projectCompare == results[j].Project.String() {
+				if results[i].Workspace == results[j].Workspace {
+					return results[i].Subpath < results[j].Subpath
+				}
 				return results[i].Workspace < results[j].Workspace
 			}
 			return projectCompare < results[j].Project.String()
 		}
 		return results[i].Distance < results[j].Distance
 	})
+}
 
-	// Apply limit
-	if opts.Limit > 0 && opts.Limit < len(results) {
-		results = results[:opts.Limit]
+// BestMatches returns the results that share the best distance, in their
+// order, whatever the sort order of results. More than one best match means
+// the query is ambiguous.
+func BestMatches(results []*SearchResult) []*SearchResult {
+	if len(results) == 0 {
+		return nil
 	}
 
-	return results
+	best := results[0].Distance
+	for _, r := range results[1:] {
+		best = min(best, r.Distance)
+	}
+
+	var matches []*SearchResult
+	for _, r := range results {
+		if r.Distance == best {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
+
+// formatWriter is the output of the formatting of results, written piece
+// by piece rather than building a string per result.
+type formatWriter interface {
+	io.StringWriter
+	io.ByteWriter
 }
 
 // Format formats the search results according to the options.
@@ -320,45 +575,102 @@ func (s *QueryService) Format(results []*SearchResult, opts SearchOptions) strin
 		return ""
 	}
 
+	// Paths are usually longer than names: sizing after the path of the
+	// first result builds the output without growing it
+	var b strings.Builder
+	b.Grow(len(results) * (len(results[0].Project.Path) + len(opts.Separator) + 1))
+	s.formatResults(&b, results, opts)
+	return b.String()
+}
+
+// FormatTo writes the search results formatted like Format to w, without
+// building the whole output in memory, such as the thousands of candidates
+// of shell completions.
+func (s *QueryService) FormatTo(w io.Writer, results []*SearchResult, opts SearchOptions) error {
+	bw := bufio.NewWriter(w)
+	s.formatResults(bw, results, opts)
+	return bw.Flush()
+}
+
+// formatResults writes the results to w, separated by opts.Separator or
+// NUL terminated with opts.Print0. The errors of w are left to the caller,
+// bufio.Writer keeping the first one.
+func (s *QueryService) formatResults(w formatWriter, results []*SearchResult, opts SearchOptions) {
 	// Check if this is a bare workspace query (starts with ':' and has a current project)
 	isBareWorkspaceQuery := opts.CurrentProject != nil && strings.HasPrefix(opts.Query, ":")
 
-	getPath := func(result *SearchResult) string {
-		var path string
-		if opts.AbsPath {
-			if result.Workspace != "" {
-				// For workspace results, return the workspace path
-				workspacePath := s.workspaceService.WorkspacePath(*result.Project, result.Workspace)
-				path = workspacePath
-			} else {
-				path = result.Project.Path
-			}
-		} else {
-			if result.Workspace != "" {
-				// For bare workspace queries from current project, return :branch format
-				// This allows shell completion to work when user types "p :"
-				if isBareWorkspaceQuery && pathsEqual(result.Project.Path, opts.CurrentProject.Path) {
-					path = ":" + result.Workspace
-				} else {
-					// For workspace results, return project:branch format
-					path = result.Project.String() + ":" + result.Workspace
-				}
-			} else {
-				path = result.Project.String()
-			}
+	for i, result := range results {
+		if i > 0 && !opts.Print0 {
+			w.WriteString(opts.Separator)
 		}
 
+		s.formatPath(w, result, opts, isBareWorkspaceQuery)
+
 		if opts.ShowDistance {
-			path += fmt.Sprintf(" - %d", result.Distance)
+			w.WriteString(" - ")
+			w.WriteString(strconv.Itoa(result.Distance))
 		}
 
-		return path
+		if opts.Annotate {
+			w.WriteByte('\t')
+			w.WriteString(annotateResult(result))
+		}
+
+		if opts.Print0 {
+			w.WriteByte(0)
+		}
 	}
+}
 
-	var parts []string
-	for _, result := range results {
-		parts = append(parts, getPath(result))
+// formatPath writes the path of result: its absolute path with
+// opts.AbsPath, its name otherwise.
+func (s *QueryService) formatPath(w formatWriter, result *SearchResult, opts SearchOptions, isBareWorkspaceQuery bool) {
+	switch {
+	case result.Subpath != "":
+		switch {
+		case opts.AbsPath:
+			w.WriteString(filepath.Join(result.Project.Path, filepath.FromSlash(result.Subpath)))
+		case opts.CurrentProject != nil && strings.HasPrefix(opts.Query, "//"):
+			// Like bare workspace queries, keep the //subpath format
+			w.WriteString("//")
+			w.WriteString(result.Subpath)
+		default:
+			writeProjectName(w, result.Project)
+			w.WriteString("//")
+			w.WriteString(result.Subpath)
+		}
+	case opts.AbsPath:
+		if result.Workspace != "" {
+			// For workspace results, return the workspace path
+			w.WriteString(s.workspaceService.WorkspacePath(*result.Project, result.Workspace))
+		} else {
+			w.WriteString(result.Project.Path)
+		}
+	case result.Workspace != "":
+		// For bare workspace queries from current project, return :branch format
+		// This allows shell completion to work when user types "p :"
+		if !isBareWorkspaceQuery || !match.PathsEqual(result.Project.Path, opts.CurrentProject.Path) {
+			// For workspace results, return project:branch format
+			writeProjectName(w, result.Project)
+		}
+		w.WriteByte(':')
+		w.WriteString(result.Workspace)
+	default:
+		writeProjectName(w, result.Project)
 	}
+}
 
-	return strings.Join(parts, opts.Separator)
+// writeProjectName writes the name of p, like p.String().
+func writeProjectName(w formatWriter, p *Project) {
+	if p.Host != "" {
+		w.WriteString(p.Host)
+		w.WriteByte('/')
+	}
+	w.WriteString(p.Organisation)
+	w.WriteByte('/')
+	w.WriteString(p.Name)
+	if p.Subproject != "" {
+		w.WriteByte('/')
+		w.WriteString(p.Subproject)
+	}
 }
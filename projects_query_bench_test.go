@@ -0,0 +1,49 @@
+package projects
+
+import (
+	"fmt"
+	"io"
+	"testing"
+)
+
+// benchmarkResults returns n project and workspace results, like the
+// candidates of a shell completion on a big root directory.
+func benchmarkResults(n int) []*SearchResult {
+	results := make([]*SearchResult, n)
+	for i := range results {
+		p := &Project{
+			Organisation: fmt.Sprintf("org%d", i%50),
+			Name:         fmt.Sprintf("project%d", i),
+			Path:         fmt.Sprintf("/home/user/code/org%d/project%d", i%50, i),
+		}
+		results[i] = &SearchResult{Project: p, Distance: i % 100}
+		if i%4 == 0 {
+			results[i].Workspace = "feature"
+		}
+	}
+	return results
+}
+
+func BenchmarkFormat(b *testing.B) {
+	svc := &QueryService{}
+	results := benchmarkResults(10000)
+	opts := SearchOptions{Separator: "\n"}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = svc.Format(results, opts)
+	}
+}
+
+func BenchmarkFormatTo(b *testing.B) {
+	svc := &QueryService{}
+	results := benchmarkResults(10000)
+	opts := SearchOptions{Separator: "\n"}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := svc.FormatTo(io.Discard, results, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gfanton/projects/internal/index"
+)
+
+func TestScoreDescription(t *testing.T) {
+	idx := index.New()
+	idx.Set("/code/org/op", index.Entry{Description: "Kubernetes operator for Élan databases"})
+
+	described := &Project{Organisation: "org", Name: "op", Path: "/code/org/op"}
+	undescribed := &Project{Organisation: "org", Name: "other", Path: "/code/org/other"}
+
+	tests := []struct {
+		name    string
+		query   string
+		project *Project
+		want    int
+	}{
+		{"single word", "kubernetes", described, distanceDescription},
+		{"later word", "operator", described, distanceDescription + len("kubernetes ")},
+		{"all words required", "kubernetes mysql", described, -1},
+		{"unicode normalized", "élan", described, distanceDescription + len("kubernetes operator for ")},
+		{"no entry", "kubernetes", undescribed, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scoreDescription(tt.query, idx, tt.project); got != tt.want {
+				t.Errorf("scoreDescription(%q) = %d, want %d", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAnnotate(t *testing.T) {
+	svc := &QueryService{}
+	proj := &Project{Organisation: "org", Name: "app", Path: "/code/org/app"}
+
+	results := []*SearchResult{
+		{Project: proj},
+		{Project: proj, Workspace: "feature", Dirty: true},
+		{Project: proj, Workspace: "main"},
+	}
+
+	got := svc.Format(results, SearchOptions{Separator: "\n", Annotate: true})
+	expected := "org/app\tproject\n" +
+		"org/app:feature\tworkspace of org/app (dirty)\n" +
+		"org/app:main\tworkspace of org/app (clean)"
+
+	if got != expected {
+		t.Errorf("Format() =\n%q\nwant:\n%q", got, expected)
+	}
+}
+
+func TestFormatPrint0(t *testing.T) {
+	svc := &QueryService{}
+	results := []*SearchResult{
+		{Project: &Project{Organisation: "org", Name: "app", Path: "/my code/org/app"}},
+		{Project: &Project{Organisation: "org", Name: "api", Path: "/my code/org/api"}},
+	}
+
+	got := svc.Format(results, SearchOptions{Separator: "\n", AbsPath: true, Print0: true})
+	if expected := "/my code/org/app\x00/my code/org/api\x00"; got != expected {
+		t.Errorf("Format() = %q, want %q", got, expected)
+	}
+}
+
+func TestFormatTo(t *testing.T) {
+	svc := &QueryService{}
+	current := &Project{Organisation: "org", Name: "app", Path: "/code/org/app"}
+	results := []*SearchResult{
+		{Project: current, Workspace: "feature", Distance: 3},
+		{Project: &Project{Organisation: "org", Name: "mono", Path: "/code/org/mono", Subproject: "auth"}},
+		{Project: &Project{Host: "git.corp.com", Organisation: "team", Name: "api", Path: "/code/git.corp.com/team/api"}, Subpath: "cmd/api"},
+	}
+
+	for _, opts := range []SearchOptions{
+		{Separator: "\n"},
+		{Separator: " ", ShowDistance: true, Annotate: true},
+		{Separator: "\n", Print0: true},
+		{Separator: "\n", Query: ":", CurrentProject: current},
+	} {
+		var b strings.Builder
+		if err := svc.FormatTo(&b, results, opts); err != nil {
+			t.Fatalf("FormatTo() failed: %v", err)
+		}
+		if want := svc.Format(results, opts); b.String() != want {
+			t.Errorf("FormatTo(%+v) = %q, want %q", opts, b.String(), want)
+		}
+	}
+
+	want := "org/app:feature\norg/mono/auth\ngit.corp.com/team/api//cmd/api"
+	if got := svc.Format(results, SearchOptions{Separator: "\n"}); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchSortRecent(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"user1/old":    72 * time.Hour,
+		"user1/newest": time.Hour,
+		"user2/middle": 24 * time.Hour,
+	}
+	for name, age := range ages {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+		if err := os.Chtimes(dir, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+	}
+
+	svc := NewQueryService(&Config{RootDir: root}, &testLogger{})
+
+	results, err := svc.Search(context.Background(), SearchOptions{Sort: SortRecent})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Project.String())
+	}
+	want := []string{"user1/newest", "user2/middle", "user1/old"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search(sort=recent) = %v, want %v", got, want)
+	}
+
+	if _, err := svc.Search(context.Background(), SearchOptions{Sort: "bogus"}); err == nil {
+		t.Error("Search() with unknown sort should fail")
+	}
+}
+
+func TestBestMatches(t *testing.T) {
+	a := &Project{Organisation: "a", Name: "api"}
+	b := &Project{Organisation: "b", Name: "api"}
+	c := &Project{Organisation: "c", Name: "apis"}
+
+	tests := []struct {
+		name    string
+		results []*SearchResult
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"single", []*SearchResult{{Project: a, Distance: 1}}, 1},
+		{"clear winner", []*SearchResult{{Project: a, Distance: 1}, {Project: c, Distance: 10}}, 1},
+		{"tie", []*SearchResult{{Project: a, Distance: 1}, {Project: b, Distance: 1}, {Project: c, Distance: 10}}, 2},
+		{"sorted by activity", []*SearchResult{{Project: c, Distance: 24}, {Project: a, Distance: 12}, {Project: b, Distance: 12}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BestMatches(tt.results); len(got) != tt.want {
+				t.Errorf("BestMatches() returned %d results, want %d", len(got), tt.want)
+			}
+			for _, r := range BestMatches(tt.results) {
+				if r.Project == c {
+					t.Errorf("BestMatches() returned %s, distance %d", r.Project.String(), r.Distance)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchAlias(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"kubernetes/kubernetes", "kubernetes/kubectl", "user/k8s-notes"} {
+		if err := os.MkdirAll(filepath.Join(root, name), 0755); err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+	}
+
+	cfg := &Config{RootDir: root, Aliases: map[string]string{"k8s": "kubernetes/kubernetes"}}
+	results, err := NewQueryService(cfg, &testLogger{}).Search(context.Background(), SearchOptions{Query: "k8s"})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) == 0 || results[0].Project.String() != "kubernetes/kubernetes" {
+		t.Errorf("Search(k8s) = %v, want kubernetes/kubernetes first", results)
+	}
+
+	if got := resolveQueryAlias(cfg.Aliases, "k8s:main"); got != "kubernetes/kubernetes:main" {
+		t.Errorf("resolveQueryAlias(k8s:main) = %q", got)
+	}
+	if got := resolveQueryAlias(cfg.Aliases, "k8s-notes"); got != "k8s-notes" {
+		t.Errorf("resolveQueryAlias(k8s-notes) = %q, want unchanged", got)
+	}
+
+	p, err := NewProjectService(cfg, &testLogger{}).ParseProject("k8s")
+	if err != nil {
+		t.Fatalf("ParseProject(k8s) failed: %v", err)
+	}
+	if p.String() != "kubernetes/kubernetes" || p.Path != filepath.Join(root, "kubernetes", "kubernetes") {
+		t.Errorf("ParseProject(k8s) = %+v", p)
+	}
+}
+
+func TestSearchHostProjects(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"gfanton/projects", "git.corp.com/team/app", "git.corp.com/infra/app"} {
+		if err := os.MkdirAll(filepath.Join(root, name, ".git"), 0755); err != nil {
+			t.Fatalf("failed to create project: %v", err)
+		}
+	}
+
+	cfg := &Config{RootDir: root, RootUser: "gfanton", Hosts: map[string]Host{"git.corp.com": {DefaultOrgPrefix: "team/"}}}
+	results, err := NewQueryService(cfg, &testLogger{}).Search(context.Background(), SearchOptions{Query: "git.corp.com/infra/app"})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) == 0 || results[0].Project.String() != "git.corp.com/infra/app" {
+		t.Fatalf("Search(git.corp.com/infra/app) = %v, want git.corp.com/infra/app first", results)
+	}
+	if p := results[0].Project; p.Host != "git.corp.com" || p.GitSSHURL() != "git@git.corp.com:infra/app.git" {
+		t.Errorf("Search() project = %+v, SSH URL %s", p, p.GitSSHURL())
+	}
+
+	svc := NewProjectService(cfg, &testLogger{})
+	tests := []struct {
+		name string
+		want string
+		path string
+	}{
+		{"github.com/gfanton/projects", "gfanton/projects", "gfanton/projects"},
+		{"git.corp.com/infra/app", "git.corp.com/infra/app", "git.corp.com/infra/app"},
+		{"git.corp.com/app", "git.corp.com/team/app", "git.corp.com/team/app"},
+		{"gitlab.com/org/name", "gitlab.com/org/name", "gitlab.com/org/name"},
+	}
+	for _, tt := range tests {
+		p, err := svc.ParseProject(tt.name)
+		if err != nil {
+			t.Errorf("ParseProject(%s) failed: %v", tt.name, err)
+			continue
+		}
+		if p.String() != tt.want || p.Path != filepath.Join(root, tt.path) {
+			t.Errorf("ParseProject(%s) = %s at %s, want %s at %s", tt.name, p.String(), p.Path, tt.want, tt.path)
+		}
+	}
+
+	p, err := svc.FindFromPath(filepath.Join(root, "git.corp.com", "team", "app"))
+	if err != nil || p.String() != "git.corp.com/team/app" {
+		t.Errorf("FindFromPath() = %v, %v, want git.corp.com/team/app", p, err)
+	}
+	if dir := NewWorkspaceService(cfg, &testLogger{}).WorkspacePath(*p, "main"); dir != filepath.Join(root, ".workspace", "git.corp.com", "team", "app", "main") {
+		t.Errorf("WorkspacePath() = %s", dir)
+	}
+}
+
+func TestSearchWorkspaceFuzzyProject(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	cfg := &Config{RootDir: root, SkipSubmodules: true}
+	p := Project{Path: filepath.Join(root, "alice", "api"), Organisation: "alice", Name: "api"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = p.Path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	if err := NewWorkspaceService(cfg, &testLogger{}).Add(ctx, p, "feat/x"); err != nil {
+		t.Fatalf("Add(feat/x) failed: %v", err)
+	}
+
+	// The project part of workspace queries is fuzzy, organisation included
+	results, err := NewQueryService(cfg, &testLogger{}).Search(ctx, SearchOptions{Query: "ali/api:feat"})
+	if err != nil {
+		t.Fatalf("Search(ali/api:feat) failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Project.String() != "alice/api" || results[0].Workspace != "feat/x" {
+		t.Errorf("Search(ali/api:feat) = %v, want alice/api:feat/x", results)
+	}
+}
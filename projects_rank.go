@@ -0,0 +1,317 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects/internal/match"
+	"github.com/gfanton/projects/internal/project"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// Ranking algorithm names accepted by NewScorer.
+const (
+	RankFuzzy     = "fuzzy"
+	RankSubstring = "substring"
+	RankExact     = "exact"
+	RankFrecency  = "frecency"
+
+	// DefaultRank is the ranking algorithm used when none is configured.
+	DefaultRank = RankFuzzy
+)
+
+// RankNames lists the supported ranking algorithms.
+var RankNames = []string{RankFuzzy, RankSubstring, RankExact, RankFrecency}
+
+// Scorer computes match distances for queries. Lower distances rank first.
+// Queries and names passed to a Scorer are already normalized with
+// match.Normalize.
+type Scorer interface {
+	// ScoreProject returns the distance between query and the project,
+	// or -1 if the project does not match.
+	ScoreProject(query string, p *Project) int
+	// ScoreBranch returns the distance between query and a workspace
+	// branch name, or -1 if the branch does not match.
+	ScoreBranch(query, branch string) int
+}
+
+// NewScorer returns the scorer for the given ranking algorithm name.
+// An empty name selects DefaultRank.
+func NewScorer(name string) (Scorer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", RankFuzzy:
+		return fuzzyScorer{}, nil
+	case RankSubstring:
+		return substringScorer{}, nil
+	case RankExact:
+		return exactScorer{}, nil
+	case RankFrecency:
+		return frecencyScorer{now: time.Now()}, nil
+	default:
+		return nil, fmt.Errorf("unknown rank algorithm '%s' (expected one of: %s)", name, strings.Join(RankNames, ", "))
+	}
+}
+
+// splitProjectName returns the normalized org and name of a project.
+func splitProjectName(p *Project) (full, org, name string) {
+	full = match.Normalize(p.String())
+	org, name, _ = strings.Cut(full, "/")
+	return full, org, name
+}
+
+// queryOrgs returns whether the projects of a top-level directory of the
+// root, an organisation or a Git server host, can match the query with an
+// organisation part, "org/name", so that searches don't walk the others. It
+// returns nil when the projects of any directory can match.
+func queryOrgs(scorer Scorer, query string) func(name string) bool {
+	qOrg, _, ok := strings.Cut(query, "/")
+	if !ok || qOrg == "" {
+		return nil
+	}
+
+	switch scorer.(type) {
+	case fuzzyScorer, frecencyScorer:
+		return func(name string) bool {
+			return match.Normalize(name) == qOrg
+		}
+	case exactScorer:
+		// The query can also be the "org/name" of a project of a host
+		return func(name string) bool {
+			return match.Normalize(name) == qOrg || project.IsHostName(name)
+		}
+	case substringScorer:
+		// The query can also end an organisation, or be part of the
+		// "org/name" of a project of a host
+		return func(name string) bool {
+			return strings.HasSuffix(match.Normalize(name), qOrg) || project.IsHostName(name)
+		}
+	default:
+		return nil
+	}
+}
+
+// scoreWorkspaceProject returns the distance between the project part of a
+// workspace query and the project, or -1 if the project does not match.
+// Unlike project queries, the organisation of an "org/name" project part is
+// fuzzy with the fuzzy scorers: "ali/api:feat" matches alice/api:feat/x.
+func scoreWorkspaceProject(scorer Scorer, query string, p *Project) int {
+	if distance := scorer.ScoreProject(query, p); distance >= 0 {
+		return distance
+	}
+
+	switch scorer := scorer.(type) {
+	case fuzzyScorer:
+		full, _, _ := splitProjectName(p)
+		if rank := fuzzy.RankMatch(query, full); rank >= 0 {
+			return distanceFuzzyFallback + rank
+		}
+	case frecencyScorer:
+		if distance := scoreWorkspaceProject(fuzzyScorer{}, query, p); distance >= 0 {
+			return distance + scorer.agePenalty(p)
+		}
+	}
+	return -1
+}
+
+// workspaceQueryOrgs is queryOrgs for the project part of workspace
+// queries, whose organisation is fuzzy with the fuzzy scorers.
+func workspaceQueryOrgs(scorer Scorer, query string) func(name string) bool {
+	switch scorer.(type) {
+	case fuzzyScorer, frecencyScorer:
+		return nil
+	default:
+		return queryOrgs(scorer, query)
+	}
+}
+
+// ---- Fuzzy
+
+// fuzzyScorer is the default scorer: exact and substring matches on the
+// project name or organisation rank first, followed by fuzzy matches.
+type fuzzyScorer struct{}
+
+func (fuzzyScorer) ScoreProject(query string, p *Project) int {
+	full, pOrg, pName := splitProjectName(p)
+
+	distance := fuzzy.RankMatch(query, full)
+	if distance < 0 {
+		return -1
+	}
+
+	if qOrg, qName, qHasOrg := strings.Cut(query, "/"); qHasOrg {
+		if qOrg != pOrg {
+			return -1
+		}
+		if qName == pName {
+			return 0
+		}
+		return fuzzy.RankMatch(qName, pName)
+	}
+
+	switch {
+	case query == pName:
+		return distanceExactName
+	case query == pOrg:
+		return distanceExactOrg
+	case strings.Contains(pName, query):
+		return distanceNameContains + fuzzy.RankMatch(query, pName)
+	case strings.Contains(pOrg, query):
+		return distanceOrgContains + fuzzy.RankMatch(query, pOrg)
+	default:
+		return distanceFuzzyFallback + fuzzy.RankMatch(query, full)
+	}
+}
+
+func (fuzzyScorer) ScoreBranch(query, branch string) int {
+	switch {
+	case branch == query:
+		return 0
+	case strings.Contains(branch, query):
+		return distanceBranchSubstr
+	}
+
+	rank := fuzzy.RankMatch(query, branch)
+	if rank < 0 {
+		return -1
+	}
+	return distanceBranchFuzzy + rank
+}
+
+// ---- Substring
+
+// substringScorer only matches contiguous substrings, preferring prefixes.
+// Results are deterministic and never include fuzzy surprises.
+type substringScorer struct{}
+
+func (substringScorer) ScoreProject(query string, p *Project) int {
+	full, pOrg, pName := splitProjectName(p)
+
+	switch {
+	case query == full:
+		return 0
+	case query == pName:
+		return distanceExactName
+	case query == pOrg:
+		return distanceExactOrg
+	case strings.HasPrefix(full, query):
+		return distanceNameContains + len(full) - len(query)
+	case strings.HasPrefix(pName, query):
+		return distanceNameContains + len(pName) - len(query)
+	case strings.Contains(pName, query):
+		return distanceOrgContains + strings.Index(pName, query)
+	case strings.Contains(full, query):
+		return distanceFuzzyFallback + strings.Index(full, query)
+	default:
+		return -1
+	}
+}
+
+func (substringScorer) ScoreBranch(query, branch string) int {
+	switch {
+	case branch == query:
+		return 0
+	case strings.HasPrefix(branch, query):
+		return distanceBranchSubstr
+	case strings.Contains(branch, query):
+		return distanceBranchFuzzy + strings.Index(branch, query)
+	default:
+		return -1
+	}
+}
+
+// ---- Exact
+
+// exactScorer only matches the full project name, the project name or the
+// organisation verbatim (after normalization).
+type exactScorer struct{}
+
+func (exactScorer) ScoreProject(query string, p *Project) int {
+	full, pOrg, pName := splitProjectName(p)
+
+	switch query {
+	case full:
+		return 0
+	case pName:
+		return distanceExactName
+	case pOrg:
+		return distanceExactOrg
+	default:
+		return -1
+	}
+}
+
+func (exactScorer) ScoreBranch(query, branch string) int {
+	if branch == query {
+		return 0
+	}
+	return -1
+}
+
+// ---- Frecency
+
+// frecencyMaxAgeDays caps the recency penalty applied by frecencyScorer.
+const frecencyMaxAgeDays = 365
+
+// frecencyScorer filters with fuzzy matching and then favours projects with
+// recent Git activity, so the repositories you work in daily float to the top.
+type frecencyScorer struct {
+	now time.Time
+}
+
+func (s frecencyScorer) ScoreProject(query string, p *Project) int {
+	distance := fuzzyScorer{}.ScoreProject(query, p)
+	if distance < 0 {
+		return -1
+	}
+	return distance + s.agePenalty(p)
+}
+
+func (s frecencyScorer) ScoreBranch(query, branch string) int {
+	return fuzzyScorer{}.ScoreBranch(query, branch)
+}
+
+// agePenalty returns the number of days since the project was last touched,
+// capped at frecencyMaxAgeDays.
+func (s frecencyScorer) agePenalty(p *Project) int {
+	lastUsed := lastActivity(p)
+	if lastUsed.IsZero() {
+		return frecencyMaxAgeDays
+	}
+
+	days := int(s.now.Sub(lastUsed).Hours() / 24)
+	switch {
+	case days < 0:
+		return 0
+	case days > frecencyMaxAgeDays:
+		return frecencyMaxAgeDays
+	default:
+		return days
+	}
+}
+
+// lastActivity returns the most recent modification time among the files
+// Git updates on common operations, falling back to the project directory.
+func lastActivity(p *Project) time.Time {
+	var latest time.Time
+	candidates := []string{
+		filepath.Join(p.GitDir(), "index"),
+		filepath.Join(p.GitDir(), "HEAD"),
+		filepath.Join(p.GitDir(), "FETCH_HEAD"),
+		p.Path,
+	}
+
+	for _, path := range candidates {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest
+}
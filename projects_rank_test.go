@@ -0,0 +1,187 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewScorer(t *testing.T) {
+	tests := []struct {
+		name    string
+		rank    string
+		wantErr bool
+	}{
+		{name: "empty defaults to fuzzy", rank: ""},
+		{name: "fuzzy", rank: RankFuzzy},
+		{name: "substring", rank: RankSubstring},
+		{name: "exact", rank: RankExact},
+		{name: "frecency", rank: RankFrecency},
+		{name: "case insensitive", rank: "Exact"},
+		{name: "unknown", rank: "magic", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := NewScorer(tt.rank)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewScorer(%q) expected error", tt.rank)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewScorer(%q) unexpected error: %v", tt.rank, err)
+			}
+			if scorer == nil {
+				t.Fatalf("NewScorer(%q) returned nil scorer", tt.rank)
+			}
+		})
+	}
+}
+
+func TestScoreProject(t *testing.T) {
+	webapp := &Project{Organisation: "user1", Name: "webapp"}
+	api := &Project{Organisation: "acme", Name: "my-api"}
+
+	tests := []struct {
+		name    string
+		scorer  Scorer
+		query   string
+		project *Project
+		want    int
+	}{
+		{"fuzzy exact full name", fuzzyScorer{}, "user1/webapp", webapp, 0},
+		{"fuzzy exact name", fuzzyScorer{}, "webapp", webapp, distanceExactName},
+		{"fuzzy exact org", fuzzyScorer{}, "user1", webapp, distanceExactOrg},
+		{"fuzzy wrong org", fuzzyScorer{}, "user2/webapp", webapp, -1},
+		{"fuzzy no match", fuzzyScorer{}, "zzz", webapp, -1},
+
+		{"substring exact full", substringScorer{}, "acme/my-api", api, 0},
+		{"substring exact name", substringScorer{}, "my-api", api, distanceExactName},
+		{"substring name prefix", substringScorer{}, "my", api, distanceNameContains + 4},
+		{"substring inner", substringScorer{}, "api", api, distanceOrgContains + 3},
+		{"substring rejects fuzzy", substringScorer{}, "mapi", api, -1},
+
+		{"exact full", exactScorer{}, "acme/my-api", api, 0},
+		{"exact name", exactScorer{}, "my-api", api, distanceExactName},
+		{"exact org", exactScorer{}, "acme", api, distanceExactOrg},
+		{"exact rejects prefix", exactScorer{}, "my", api, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scorer.ScoreProject(tt.query, tt.project); got != tt.want {
+				t.Errorf("ScoreProject(%q, %s) = %d, want %d", tt.query, tt.project, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreBranch(t *testing.T) {
+	tests := []struct {
+		name   string
+		scorer Scorer
+		query  string
+		branch string
+		want   int
+	}{
+		{"fuzzy exact", fuzzyScorer{}, "main", "main", 0},
+		{"fuzzy substring", fuzzyScorer{}, "auth", "feature-auth", distanceBranchSubstr},
+		{"fuzzy no match", fuzzyScorer{}, "xyz", "main", -1},
+
+		{"substring prefix", substringScorer{}, "feat", "feature-auth", distanceBranchSubstr},
+		{"substring inner", substringScorer{}, "auth", "feature-auth", distanceBranchFuzzy + 8},
+		{"substring rejects fuzzy", substringScorer{}, "fa", "feature-auth", -1},
+
+		{"exact match", exactScorer{}, "main", "main", 0},
+		{"exact rejects substring", exactScorer{}, "mai", "main", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scorer.ScoreBranch(tt.query, tt.branch); got != tt.want {
+				t.Errorf("ScoreBranch(%q, %q) = %d, want %d", tt.query, tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryOrgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		scorer Scorer
+		query  string
+		dir    string
+		want   bool
+	}{
+		{"fuzzy same org", fuzzyScorer{}, "acme/api", "acme", true},
+		{"fuzzy case folded", fuzzyScorer{}, "acme/api", "ACME", true},
+		{"fuzzy other org", fuzzyScorer{}, "acme/api", "acme-labs", false},
+		{"fuzzy host", fuzzyScorer{}, "gitlab.com/acme/api", "gitlab.com", true},
+		{"frecency other org", frecencyScorer{}, "acme/api", "user1", false},
+
+		{"substring org suffix", substringScorer{}, "me/api", "acme", true},
+		{"substring other org", substringScorer{}, "acme/api", "user1", false},
+		{"substring host", substringScorer{}, "acme/api", "gitlab.com", true},
+
+		{"exact same org", exactScorer{}, "acme/api", "acme", true},
+		{"exact other org", exactScorer{}, "acme/api", "user1", false},
+		{"exact host", exactScorer{}, "acme/api", "gitlab.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orgs := queryOrgs(tt.scorer, tt.query)
+			if orgs == nil {
+				t.Fatalf("queryOrgs(%q) = nil, want a filter", tt.query)
+			}
+			if got := orgs(tt.dir); got != tt.want {
+				t.Errorf("queryOrgs(%q)(%q) = %v, want %v", tt.query, tt.dir, got, tt.want)
+			}
+		})
+	}
+
+	// Queries without organisation part match projects of any directory
+	for _, query := range []string{"", "api", "/api"} {
+		if queryOrgs(fuzzyScorer{}, query) != nil {
+			t.Errorf("queryOrgs(%q) returned a filter, want nil", query)
+		}
+	}
+}
+
+func TestFrecencyScorer(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+
+	recent := &Project{Organisation: "org", Name: "app-recent", Path: filepath.Join(root, "org", "app-recent")}
+	stale := &Project{Organisation: "org", Name: "app-stale", Path: filepath.Join(root, "org", "app-stale")}
+
+	for _, p := range []*Project{recent, stale} {
+		if err := os.MkdirAll(p.Path, 0755); err != nil {
+			t.Fatalf("failed to create project dir: %v", err)
+		}
+	}
+
+	old := now.Add(-30 * 24 * time.Hour)
+	if err := os.Chtimes(stale.Path, old, old); err != nil {
+		t.Fatalf("failed to set project mtime: %v", err)
+	}
+
+	scorer := frecencyScorer{now: now}
+	recentScore := scorer.ScoreProject("app", recent)
+	staleScore := scorer.ScoreProject("app", stale)
+
+	if recentScore < 0 || staleScore < 0 {
+		t.Fatalf("expected both projects to match, got recent=%d stale=%d", recentScore, staleScore)
+	}
+
+	if recentScore >= staleScore {
+		t.Errorf("recent project should rank before stale one: recent=%d stale=%d", recentScore, staleScore)
+	}
+
+	if got := scorer.ScoreProject("zzz", recent); got != -1 {
+		t.Errorf("frecency scorer should reject non-matching query, got %d", got)
+	}
+}
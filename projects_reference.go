@@ -0,0 +1,53 @@
+package projects
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/gfanton/projects/internal/index"
+)
+
+// ReferenceCandidates returns the local clones whose objects a clone of proj
+// can borrow, best first: the projects whose origin remote, as recorded in
+// idx, is the repository of proj, then the projects of the same repository
+// name, such as forks. idx may be nil, leaving only name matches.
+func (s *ProjectService) ReferenceCandidates(proj *Project, idx *index.Index) ([]*Project, error) {
+	var same, forks []*Project
+	err := s.Walk(func(d fs.DirEntry, p *Project) error {
+		if p.Path == proj.Path || !p.IsGitRepository() {
+			return nil
+		}
+
+		var remoteOrg, remoteName string
+		if idx != nil {
+			if entry, ok := idx.Get(p.Path); ok {
+				remoteOrg, remoteName = remoteRepository(entry.Remote)
+			}
+		}
+
+		switch {
+		case strings.EqualFold(remoteOrg, proj.Organisation) && strings.EqualFold(remoteName, proj.Name):
+			same = append(same, p)
+		case strings.EqualFold(p.Name, proj.Name), strings.EqualFold(remoteName, proj.Name):
+			forks = append(forks, p)
+		}
+		return nil
+	})
+	if err != nil && !IsPartialWalk(err) {
+		return nil, err
+	}
+
+	return slices.Concat(same, forks), nil
+}
+
+// remoteRepository returns the organisation and repository name of a Git
+// remote URL, in the https, ssh:// or scp-like (git@host:org/name) forms.
+func remoteRepository(url string) (org, name string) {
+	url = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(url), "/"), ".git")
+	parts := strings.FieldsFunc(url, func(r rune) bool { return r == '/' || r == ':' })
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
@@ -0,0 +1,88 @@
+package projects
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gfanton/projects/internal/index"
+)
+
+func TestRemoteRepository(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantOrg  string
+		wantName string
+	}{
+		{"https://github.com/user/repo.git", "user", "repo"},
+		{"https://github.com/user/repo/", "user", "repo"},
+		{"git@github.com:user/repo.git", "user", "repo"},
+		{"ssh://git@github.com/user/repo", "user", "repo"},
+		{"repo", "", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			org, name := remoteRepository(tt.url)
+			if org != tt.wantOrg || name != tt.wantName {
+				t.Errorf("remoteRepository(%q) = %q, %q, want %q, %q", tt.url, org, name, tt.wantOrg, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestReferenceCandidates(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"fork/repo", "mirror/copy", "user/other", "plain/repo"} {
+		path := filepath.Join(root, dir)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if dir == "plain/repo" {
+			continue // not a Git repository
+		}
+		if err := os.Mkdir(filepath.Join(path, ".git"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx := index.New()
+	idx.Set(filepath.Join(root, "mirror", "copy"), index.Entry{Remote: "git@github.com:upstream/repo.git"})
+	idx.Set(filepath.Join(root, "user", "other"), index.Entry{Remote: "https://github.com/someone/other.git"})
+
+	svc := NewProjectService(&Config{RootDir: root}, &testLogger{})
+	proj := &Project{Path: filepath.Join(root, "upstream", "repo"), Organisation: "upstream", Name: "repo"}
+
+	tests := []struct {
+		name string
+		idx  *index.Index
+		want []string
+	}{
+		{"with index", idx, []string{"mirror/copy", "fork/repo"}},
+		{"without index", nil, []string{"fork/repo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := svc.ReferenceCandidates(proj, tt.idx)
+			if err != nil {
+				t.Fatalf("ReferenceCandidates() failed: %v", err)
+			}
+
+			var got []string
+			for _, c := range candidates {
+				got = append(got, c.String())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ReferenceCandidates() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ReferenceCandidates() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SessionName returns the terminal multiplexer session name of a project:
+// prefix followed by "<org>_<name>", or "<org>_<name>/<subproject>" for a
+// subproject. Dots are replaced with dashes since multiplexers such as tmux
+// reserve them in target names.
+// The projects of other Git servers than GitHub are prefixed with
+// "<host>+", the dots of the host replaced with underscores, which host
+// names can't contain.
+func SessionName(prefix string, p *Project) string {
+	org := strings.ReplaceAll(p.Organisation, ".", "-")
+	name := strings.ReplaceAll(p.Name, ".", "-")
+	if p.Subproject != "" {
+		name += "/" + strings.ReplaceAll(p.Subproject, ".", "-")
+	}
+	if p.Host != "" {
+		prefix += strings.ReplaceAll(p.Host, ".", "_") + sessionHostSeparator
+	}
+	return fmt.Sprintf("%s%s_%s", prefix, org, name)
+}
+
+// sessionHostSeparator separates the host from the project in the session
+// names of the projects of other Git servers than GitHub.
+const sessionHostSeparator = "+"
+
+// workspaceSessionSeparator separates the project from the workspace in
+// workspace session names. Git forbids it in branch names.
+const workspaceSessionSeparator = "~"
+
+// WorkspaceSessionName returns the terminal multiplexer session name of the
+// workspace of a project, for a session per workspace: the SessionName of
+// the project followed by "~<workspace>", dots replaced with dashes.
+func WorkspaceSessionName(prefix string, p *Project, workspace string) string {
+	return SessionName(prefix, p) + workspaceSessionSeparator + strings.ReplaceAll(workspace, ".", "-")
+}
+
+// WorkspaceFromSessionName returns the workspace of a session named by
+// WorkspaceSessionName, with dots replaced, or an empty string for other
+// sessions.
+func WorkspaceFromSessionName(prefix, session string) string {
+	if !strings.HasPrefix(session, prefix) {
+		return ""
+	}
+	_, workspace, _ := strings.Cut(session, workspaceSessionSeparator)
+	return workspace
+}
+
+// SetSessionName returns the terminal multiplexer session name of the
+// project set name: prefix followed by "@<name>".
+func SetSessionName(prefix, name string) string {
+	return prefix + SetPrefix + name
+}
+
+// ProjectFromSessionName returns the "org/name" (or "org/name/subproject")
+// project of a session named by SessionName or WorkspaceSessionName,
+// prefixed with "<host>/" for the projects of other Git servers than
+// GitHub, or an empty string if session doesn't start with prefix or is the
+// session of a project set.
+// Legacy "<prefix><org>-<name>" names are also recognized, assuming the
+// project name has no dash.
+func ProjectFromSessionName(prefix, session string) string {
+	remainder, ok := strings.CutPrefix(session, prefix)
+	if !ok || strings.HasPrefix(remainder, SetPrefix) {
+		return ""
+	}
+	remainder, _, _ = strings.Cut(remainder, workspaceSessionSeparator)
+
+	if host, rest, ok := strings.Cut(remainder, sessionHostSeparator); ok {
+		org, name, ok := strings.Cut(rest, "_")
+		if host == "" || !ok {
+			return ""
+		}
+		return strings.ReplaceAll(host, "_", ".") + "/" + org + "/" + name
+	}
+
+	// Current format: underscore is an unambiguous separator
+	if org, name, ok := strings.Cut(remainder, "_"); ok {
+		return org + "/" + name
+	}
+
+	// Legacy format: assume the last dash separates org from name
+	i := strings.LastIndex(remainder, "-")
+	if i <= 0 || i == len(remainder)-1 {
+		return ""
+	}
+	return remainder[:i] + "/" + remainder[i+1:]
+}
+
+// Resolve returns the named project, or the project containing the current
+// working directory when name is empty.
+func (s *ProjectService) Resolve(name string) (*Project, error) {
+	if name != "" {
+		return s.ParseProject(name)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	p, err := s.FindFromPath(wd)
+	if err != nil {
+		return nil, fmt.Errorf("not inside a project directory and no project specified: %w", err)
+	}
+
+	return p, nil
+}
@@ -0,0 +1,59 @@
+package projects
+
+import "testing"
+
+func TestSessionName(t *testing.T) {
+	p := &Project{Organisation: "gfanton", Name: "my.app"}
+	if got := SessionName("proj-", p); got != "proj-gfanton_my-app" {
+		t.Errorf("SessionName() = %q, want %q", got, "proj-gfanton_my-app")
+	}
+
+	p = &Project{Host: "git.corp.com", Organisation: "team", Name: "app"}
+	session := SessionName("proj-", p)
+	if session != "proj-git_corp_com+team_app" {
+		t.Errorf("SessionName() = %q, want %q", session, "proj-git_corp_com+team_app")
+	}
+	if got := ProjectFromSessionName("proj-", session); got != p.String() {
+		t.Errorf("ProjectFromSessionName(%q) = %q, want %q", session, got, p.String())
+	}
+}
+
+func TestWorkspaceSessionName(t *testing.T) {
+	p := &Project{Organisation: "gfanton", Name: "my.app"}
+	session := WorkspaceSessionName("proj-", p, "release/v1.2")
+	if session != "proj-gfanton_my-app~release/v1-2" {
+		t.Errorf("WorkspaceSessionName() = %q, want %q", session, "proj-gfanton_my-app~release/v1-2")
+	}
+	if got := WorkspaceFromSessionName("proj-", session); got != "release/v1-2" {
+		t.Errorf("WorkspaceFromSessionName(%q) = %q, want %q", session, got, "release/v1-2")
+	}
+	if got := WorkspaceFromSessionName("proj-", SessionName("proj-", p)); got != "" {
+		t.Errorf("WorkspaceFromSessionName() of a project session = %q, want empty", got)
+	}
+}
+
+func TestProjectFromSessionName(t *testing.T) {
+	tests := []struct {
+		session string
+		want    string
+	}{
+		{"proj-gfanton_projects", "gfanton/projects"},
+		{"proj-my-org_my-app", "my-org/my-app"},
+		{"proj-gfanton-projects", "gfanton/projects"},
+		{"proj-my-org-app", "my-org/app"},
+		{"proj-gfanton_projects~feature/login", "gfanton/projects"},
+		{"proj-git_corp_com+team_app", "git.corp.com/team/app"},
+		{"proj-gitlab_com+team_app~feature/login", "gitlab.com/team/app"},
+		{"proj-git_corp_com+single", ""},
+		{"proj-single", ""},
+		{"proj-@payments", ""},
+		{"proj-@pay_ments", ""},
+		{"other-gfanton_projects", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ProjectFromSessionName("proj-", tt.session); got != tt.want {
+			t.Errorf("ProjectFromSessionName(%q) = %q, want %q", tt.session, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package projects
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetPrefix starts the names of project sets in arguments, such as
+// "@payments" for the set payments.
+const SetPrefix = "@"
+
+// ParseSetName returns the name of the project set arg names, "@<name>",
+// or false when arg doesn't name a set.
+func ParseSetName(arg string) (string, bool) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(arg), SetPrefix)
+	return name, ok && name != ""
+}
+
+// SetProjectNames returns the names of the projects of the set name, as
+// written in the configuration.
+func (s *ProjectService) SetProjectNames(name string) ([]string, error) {
+	names, ok := s.config.Sets[name]
+	if !ok {
+		known := make([]string, 0, len(s.config.Sets))
+		for set := range s.config.Sets {
+			known = append(known, SetPrefix+set)
+		}
+		sort.Strings(known)
+		if len(known) == 0 {
+			return nil, fmt.Errorf("unknown project set '%s': no sets in the [sets] table of the config file", name)
+		}
+		return nil, fmt.Errorf("unknown project set '%s' (sets: %s)", name, strings.Join(known, ", "))
+	}
+	return names, nil
+}
+
+// ParseSet returns the projects of the set name, cloned or not, in the
+// order of the configuration.
+func (s *ProjectService) ParseSet(name string) ([]*Project, error) {
+	names, err := s.SetProjectNames(name)
+	if err != nil {
+		return nil, err
+	}
+
+	projs := make([]*Project, 0, len(names))
+	for _, projectName := range names {
+		p, err := s.ParseProject(projectName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project '%s' of set %s: %w", projectName, name, err)
+		}
+		projs = append(projs, p)
+	}
+	return projs, nil
+}
+
+// ExpandSets returns args with the project sets they name, such as
+// "@payments", replaced by the names of their projects.
+func (s *ProjectService) ExpandSets(args []string) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+	for _, arg := range args {
+		name, ok := ParseSetName(arg)
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+		names, err := s.SetProjectNames(name)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, names...)
+	}
+	return expanded, nil
+}
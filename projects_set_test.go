@@ -0,0 +1,61 @@
+package projects
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProjectSets(t *testing.T) {
+	root := t.TempDir()
+	cfg := &Config{
+		RootDir:  root,
+		RootUser: "gfanton",
+		Aliases:  map[string]string{"ui": "acme/web-ui"},
+		Sets:     map[string][]string{"payments": {"acme/api", "acme/worker", "ui"}},
+	}
+	svc := NewProjectService(cfg, &testLogger{})
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{"no set", []string{"acme/api", "docs"}, []string{"acme/api", "docs"}, false},
+		{"set", []string{"@payments", "docs"}, []string{"acme/api", "acme/worker", "ui", "docs"}, false},
+		{"bare prefix", []string{"@"}, []string{"@"}, false},
+		{"unknown set", []string{"@billing"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ExpandSets(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandSets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandSets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	projs, err := svc.ParseSet("payments")
+	if err != nil {
+		t.Fatalf("ParseSet() error = %v", err)
+	}
+	var names []string
+	for _, p := range projs {
+		names = append(names, p.String())
+	}
+	if want := []string{"acme/api", "acme/worker", "acme/web-ui"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ParseSet() = %v, want %v", names, want)
+	}
+	if want := filepath.Join(root, "acme", "api"); projs[0].Path != want {
+		t.Errorf("ParseSet() path = %q, want %q", projs[0].Path, want)
+	}
+
+	if got := SetSessionName("proj-", "payments"); got != "proj-@payments" {
+		t.Errorf("SetSessionName() = %q, want proj-@payments", got)
+	}
+}
@@ -0,0 +1,160 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterbourgon/ff/v4/fftoml"
+)
+
+// SettingsFile is the per-project settings file, at the project root.
+const SettingsFile = ".proj.toml"
+
+// Settings holds the per-project settings of SettingsFile:
+//
+//	[tasks]
+//	dev = "npm run dev"
+//	test = "go test ./..."
+//
+//	[session]
+//	tasks = "dev"    # tasks started in panes of new sessions, comma separated
+//	layout = "web"   # proj-tmux layout of new sessions
+//	window-name = "#{name}:#{branch}#{dirty}"  # proj-tmux workspace window names
+//
+//	[setup]
+//	go = "go mod download"   # run in new workspaces, keyed by detected stack
+//	node = "pnpm i --frozen-lockfile"
+//	on-failure = "warn"      # warn, fail or remove
+//
+//	[subprojects]
+//	api = "services/api"     # monorepo subdirectories listed as projects
+type Settings struct {
+	Tasks         map[string]string
+	SessionTasks  []string
+	SessionLayout string // name of a proj-tmux layout file
+	WindowName    string // proj-tmux format of workspace window names
+
+	Setup          map[string]string // setup commands by language
+	SetupOnFailure string
+
+	Subprojects map[string]string // subdirectories by subproject name
+}
+
+// Failure policies of the setup commands of new workspaces.
+const (
+	SetupWarn   = "warn"   // log the failure and keep the workspace
+	SetupFail   = "fail"   // fail, keeping the workspace
+	SetupRemove = "remove" // fail and remove the workspace
+)
+
+// setupStackAliases maps the [setup] keys that are not a detected language
+// to the language they stand for.
+var setupStackAliases = map[string]string{
+	"node":       "javascript",
+	"typescript": "javascript",
+}
+
+// LoadSettings reads the SettingsFile of dir. A missing file yields empty
+// settings.
+func LoadSettings(dir string) (*Settings, error) {
+	settings := &Settings{
+		Tasks:          make(map[string]string),
+		Setup:          make(map[string]string),
+		SetupOnFailure: SetupWarn,
+		Subprojects:    make(map[string]string),
+	}
+
+	path := filepath.Join(dir, SettingsFile)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	err = fftoml.Parse(f, func(name, value string) error {
+		if task, ok := strings.CutPrefix(name, "tasks."); ok {
+			settings.Tasks[task] = value
+			return nil
+		}
+
+		if name == "setup.on-failure" {
+			settings.SetupOnFailure = value
+			return nil
+		}
+
+		if stack, ok := strings.CutPrefix(name, "setup."); ok {
+			if language, ok := setupStackAliases[stack]; ok {
+				stack = language
+			}
+			settings.Setup[stack] = value
+			return nil
+		}
+
+		if sub, ok := strings.CutPrefix(name, "subprojects."); ok {
+			settings.Subprojects[sub] = value
+			return nil
+		}
+
+		if name == "session.layout" {
+			settings.SessionLayout = value
+			return nil
+		}
+
+		if name == "session.window-name" {
+			settings.WindowName = value
+			return nil
+		}
+
+		if name == "session.tasks" {
+			for _, task := range strings.Split(value, ",") {
+				if task = strings.TrimSpace(task); task != "" {
+					settings.SessionTasks = append(settings.SessionTasks, task)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, task := range settings.SessionTasks {
+		if _, ok := settings.Tasks[task]; !ok {
+			return nil, fmt.Errorf("invalid %s: session task '%s' is not defined in [tasks]", path, task)
+		}
+	}
+
+	switch settings.SetupOnFailure {
+	case SetupWarn, SetupFail, SetupRemove:
+	default:
+		return nil, fmt.Errorf("invalid %s: setup on-failure '%s' (expected warn, fail or remove)", path, settings.SetupOnFailure)
+	}
+
+	for sub, dir := range settings.Subprojects {
+		if strings.ContainsAny(sub, "/: ") {
+			return nil, fmt.Errorf("invalid %s: subproject name '%s' must not contain '/', ':' or spaces", path, sub)
+		}
+		if dir = filepath.Clean(dir); filepath.IsAbs(dir) || dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("invalid %s: subproject %s directory '%s' must be inside the project", path, sub, settings.Subprojects[sub])
+		}
+	}
+
+	return settings, nil
+}
+
+// TaskNames returns the sorted names of the tasks.
+func (s *Settings) TaskNames() []string {
+	names := make([]string, 0, len(s.Tasks))
+	for name := range s.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
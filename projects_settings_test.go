@@ -0,0 +1,116 @@
+package projects
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+
+	settings, err := LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() without file error = %v", err)
+	}
+	if len(settings.Tasks) != 0 || len(settings.SessionTasks) != 0 {
+		t.Errorf("LoadSettings() without file = %+v, want empty", settings)
+	}
+
+	content := `[tasks]
+dev = "npm run dev"
+test = "go test ./..."
+
+[session]
+tasks = "dev"
+layout = "web"
+window-name = "#{name}:#{branch}"
+`
+	if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err = LoadSettings(dir)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if settings.Tasks["dev"] != "npm run dev" || settings.Tasks["test"] != "go test ./..." {
+		t.Errorf("LoadSettings() tasks = %v", settings.Tasks)
+	}
+	if len(settings.SessionTasks) != 1 || settings.SessionTasks[0] != "dev" {
+		t.Errorf("LoadSettings() session tasks = %v, want [dev]", settings.SessionTasks)
+	}
+	if settings.SessionLayout != "web" {
+		t.Errorf("LoadSettings() session layout = %q, want web", settings.SessionLayout)
+	}
+	if settings.WindowName != "#{name}:#{branch}" {
+		t.Errorf("LoadSettings() window name = %q, want #{name}:#{branch}", settings.WindowName)
+	}
+	if names := settings.TaskNames(); len(names) != 2 || names[0] != "dev" || names[1] != "test" {
+		t.Errorf("TaskNames() = %v, want [dev test]", names)
+	}
+}
+
+func TestLoadSettingsUndefinedSessionTask(t *testing.T) {
+	dir := t.TempDir()
+	content := "[tasks]\ndev = \"npm run dev\"\n\n[session]\ntasks = \"dev, watch\"\n"
+	if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadSettings(dir); err == nil {
+		t.Error("LoadSettings() expected error for undefined session task")
+	}
+}
+
+func TestLoadSettingsSetup(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantSetup map[string]string
+		wantOn    string
+		wantErr   bool
+	}{
+		{
+			name:      "default policy",
+			content:   "[setup]\ngo = \"go mod download\"\n",
+			wantSetup: map[string]string{"go": "go mod download"},
+			wantOn:    SetupWarn,
+		},
+		{
+			name:      "node alias",
+			content:   "[setup]\nnode = \"pnpm i --frozen-lockfile\"\non-failure = \"remove\"\n",
+			wantSetup: map[string]string{"javascript": "pnpm i --frozen-lockfile"},
+			wantOn:    SetupRemove,
+		},
+		{
+			name:    "invalid policy",
+			content: "[setup]\non-failure = \"ignore\"\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			settings, err := LoadSettings(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !maps.Equal(settings.Setup, tt.wantSetup) {
+				t.Errorf("LoadSettings() setup = %v, want %v", settings.Setup, tt.wantSetup)
+			}
+			if settings.SetupOnFailure != tt.wantOn {
+				t.Errorf("LoadSettings() on-failure = %q, want %q", settings.SetupOnFailure, tt.wantOn)
+			}
+		})
+	}
+}
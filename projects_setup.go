@@ -0,0 +1,75 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gfanton/projects/internal/project"
+)
+
+// setupWorkspace runs the [setup] commands of the SettingsFile of the new
+// workspace at path matching its detected languages, streaming their output.
+// On failure, the SetupOnFailure policy applies: the workspace, and the
+// branch created for it when branch is set, are removed with SetupRemove.
+func (s *WorkspaceService) setupWorkspace(ctx context.Context, proj Project, path, branch string) error {
+	settings, err := LoadSettings(path)
+	if err != nil {
+		return fmt.Errorf("workspace created at %s, but %w", path, err)
+	}
+	if len(settings.Setup) == 0 {
+		return nil
+	}
+
+	for _, language := range project.DetectLanguages(path) {
+		command, ok := settings.Setup[language]
+		if !ok {
+			continue
+		}
+
+		s.logger.Info("running workspace setup", "path", path, "language", language, "command", command)
+		if err := s.runSetup(ctx, path, command); err != nil {
+			err = fmt.Errorf("workspace setup '%s' failed: %w", command, err)
+			return s.setupFailed(ctx, proj, path, branch, settings.SetupOnFailure, err)
+		}
+	}
+	return nil
+}
+
+// runSetup runs a setup command with sh -c in dir.
+func (s *WorkspaceService) runSetup(ctx context.Context, dir, command string) error {
+	out := s.setupOutput
+	if out == nil {
+		out = os.Stderr
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return cmd.Run()
+}
+
+// setupFailed applies the failure policy to the setup error cause.
+func (s *WorkspaceService) setupFailed(ctx context.Context, proj Project, path, branch, policy string, cause error) error {
+	switch policy {
+	case SetupFail:
+		return fmt.Errorf("workspace created at %s, but %w", path, cause)
+	case SetupRemove:
+		if _, err := runGitCombined(ctx, proj.Path, "worktree", "remove", "--force", path); err != nil {
+			return errors.Join(cause, fmt.Errorf("failed to remove workspace: %w", err))
+		}
+		if branch != "" {
+			if _, err := runGitCombined(ctx, proj.Path, "branch", "-D", branch); err != nil {
+				return errors.Join(cause, fmt.Errorf("failed to delete branch: %w", err))
+			}
+		}
+		s.logger.Info("workspace removed after setup failure", "path", path)
+		return cause
+	default:
+		s.logger.Warn("workspace created, but its setup failed", "path", path, "error", cause)
+		return nil
+	}
+}
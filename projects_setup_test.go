@@ -0,0 +1,94 @@
+package projects
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceSetup(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+	commitSettings := func(settings string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(p.Path, SettingsFile), []byte(settings), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", SettingsFile)
+		git("commit", "-m", "settings")
+	}
+
+	git("init", "-b", "main")
+	if err := os.WriteFile(filepath.Join(p.Path, "go.mod"), []byte("module example.com/repo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "go.mod")
+	git("commit", "-m", "init")
+
+	var out bytes.Buffer
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	svc.setupOutput = &out
+
+	// Only the commands of the detected languages run, in the workspace
+	commitSettings("[setup]\ngo = \"pwd > setup.txt && echo go setup\"\nnode = \"touch node.txt\"\n")
+	if err := svc.Add(ctx, p, "setup"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	path := svc.WorkspacePath(p, "setup")
+	if _, err := os.Stat(filepath.Join(path, "setup.txt")); err != nil {
+		t.Errorf("go setup should run in the workspace: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "node.txt")); !os.IsNotExist(err) {
+		t.Errorf("node setup should not run without package.json, stat error = %v", err)
+	}
+	if !strings.Contains(out.String(), "go setup") {
+		t.Errorf("setup output = %q, want streamed command output", out.String())
+	}
+
+	tests := []struct {
+		policy     string
+		wantErr    bool
+		wantExists bool
+	}{
+		{policy: SetupWarn, wantErr: false, wantExists: true},
+		{policy: SetupFail, wantErr: true, wantExists: true},
+		{policy: SetupRemove, wantErr: true, wantExists: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			commitSettings("[setup]\ngo = \"exit 3\"\non-failure = \"" + tt.policy + "\"\n")
+
+			branch := "fail-" + tt.policy
+			err := svc.Add(ctx, p, branch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			_, statErr := os.Stat(svc.WorkspacePath(p, branch))
+			if exists := statErr == nil; exists != tt.wantExists {
+				t.Errorf("workspace exists = %v, want %v", exists, tt.wantExists)
+			}
+			if got := git("branch", "--list", branch); (got != "") != tt.wantExists {
+				t.Errorf("branch list = %q, want exists %v", got, tt.wantExists)
+			}
+		})
+	}
+}
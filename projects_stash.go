@@ -0,0 +1,101 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// AddTakingChanges creates a workspace like AddFrom and moves the
+// uncommitted changes of the project, including untracked files, into it.
+//
+// The changes are stashed in the project, the workspace is created and the
+// stash is applied there, then dropped. On failure, the new workspace and
+// branch are removed and the changes are restored in the project.
+func (s *WorkspaceService) AddTakingChanges(ctx context.Context, proj Project, branch, base string) error {
+	if err := s.config.CheckWritable("add workspace " + proj.String() + ":" + branch); err != nil {
+		return err
+	}
+
+	checkout := proj.CheckoutPath()
+	dirty, err := s.IsDirty(ctx, checkout)
+	if err != nil {
+		return err
+	}
+	if !dirty {
+		s.logger.Info("no uncommitted changes to take", "project", proj.String())
+		return s.AddFrom(ctx, proj, branch, base)
+	}
+
+	localBranch := branch
+	if prNum, isPR := s.isPullRequest(branch); isPR {
+		localBranch = fmt.Sprintf("pr-%d", prNum)
+	}
+	_, err = runGitCombined(ctx, proj.Path, "rev-parse", "--verify", "--quiet", "refs/heads/"+localBranch)
+	branchExisted := err == nil
+
+	message := fmt.Sprintf("proj: take changes to workspace %s", branch)
+	if _, err := runGitCombined(ctx, checkout, "stash", "push", "--include-untracked", "--message", message); err != nil {
+		return fmt.Errorf("failed to stash changes: %w", err)
+	}
+
+	stash, err := runGitCombined(ctx, checkout, "rev-parse", "refs/stash")
+	if err != nil {
+		return fmt.Errorf("failed to resolve stash: %w", err)
+	}
+	s.logger.Debug("changes stashed", "project", proj.String(), "stash", stash)
+
+	// restore puts the stashed changes back in the project
+	restore := func(cause error) error {
+		if _, err := runGitCombined(ctx, checkout, "stash", "pop", "--index"); err != nil {
+			return errors.Join(cause, fmt.Errorf("failed to restore changes, they are kept in stash %s: %w", stash, err))
+		}
+		s.logger.Info("changes restored in project", "project", proj.String())
+		return cause
+	}
+
+	if err := s.AddFrom(ctx, proj, branch, base); err != nil {
+		return restore(err)
+	}
+
+	workspacePath := s.WorkspacePath(proj, branch)
+	if _, err := runGitCombined(ctx, workspacePath, "stash", "apply", "--index", stash); err != nil {
+		err = fmt.Errorf("failed to apply changes in workspace: %w", err)
+
+		if _, rmErr := runGitCombined(ctx, proj.Path, "worktree", "remove", "--force", workspacePath); rmErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to remove workspace: %w", rmErr))
+		} else if !branchExisted {
+			if _, brErr := runGitCombined(ctx, proj.Path, "branch", "-D", localBranch); brErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to delete branch: %w", brErr))
+			}
+		}
+		return restore(err)
+	}
+
+	// Only drop the stash entry if nothing was stashed meanwhile
+	if top, err := runGitCombined(ctx, checkout, "rev-parse", "refs/stash"); err != nil || top != stash {
+		s.logger.Warn("changes taken, but the stash entry was kept", "stash", stash)
+	} else if _, err := runGitCombined(ctx, checkout, "stash", "drop"); err != nil {
+		s.logger.Warn("changes taken, but failed to drop the stash entry", "stash", stash, "error", err)
+	}
+
+	s.logger.Info("uncommitted changes moved to workspace", "path", workspacePath, "branch", branch)
+	return nil
+}
+
+// runGitCombined runs git with args in dir and returns its trimmed output,
+// which is included in the error on failure.
+func runGitCombined(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w\nOutput: %s", strings.Join(args, " "), gitpkg.ContextError(ctx, err), string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,81 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddTakingChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+	write := func(dir, name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-b", "main")
+	write(p.Path, "x", "x\n")
+	git("add", "x")
+	git("commit", "-m", "x")
+	git("tag", "without-a")
+	write(p.Path, "a", "a\n")
+	git("add", "a")
+	git("commit", "-m", "a")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+
+	// A failed apply removes the workspace and branch and restores the changes
+	write(p.Path, "a", "changed\n")
+	if err := svc.AddTakingChanges(ctx, p, "conflict", "without-a"); err == nil {
+		t.Fatal("AddTakingChanges() onto a base without the changed file should fail")
+	}
+	if _, err := os.Stat(svc.WorkspacePath(p, "conflict")); !os.IsNotExist(err) {
+		t.Errorf("workspace should be removed after a failed apply, stat error = %v", err)
+	}
+	if got := git("branch", "--list", "conflict"); got != "" {
+		t.Errorf("branch should be deleted after a failed apply, got %q", got)
+	}
+	if got := git("status", "--porcelain"); got != "M a" {
+		t.Errorf("project status after rollback = %q, want changes restored", got)
+	}
+
+	// Changes, including untracked files, move to the new workspace
+	write(p.Path, "untracked", "u\n")
+	if err := svc.AddTakingChanges(ctx, p, "feature", ""); err != nil {
+		t.Fatalf("AddTakingChanges() failed: %v", err)
+	}
+	if got := git("status", "--porcelain"); got != "" {
+		t.Errorf("project status = %q, want clean", got)
+	}
+	if got := git("stash", "list"); got != "" {
+		t.Errorf("stash list = %q, want empty", got)
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = svc.WorkspacePath(p, "feature")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git status in workspace: %v", err)
+	}
+	if got := string(output); got != " M a\n?? untracked\n" {
+		t.Errorf("workspace status = %q, want the taken changes", got)
+	}
+}
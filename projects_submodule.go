@@ -0,0 +1,109 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// SubmoduleState is the state of a submodule in a worktree, as reported by
+// 'git submodule status'.
+type SubmoduleState string
+
+const (
+	// SubmoduleInSync indicates the recorded commit is checked out.
+	SubmoduleInSync SubmoduleState = "in sync"
+	// SubmoduleDrifted indicates another commit than the recorded one is checked out.
+	SubmoduleDrifted SubmoduleState = "drifted"
+	// SubmoduleUninitialized indicates the submodule is not initialized.
+	SubmoduleUninitialized SubmoduleState = "not initialized"
+	// SubmoduleConflict indicates the submodule has merge conflicts.
+	SubmoduleConflict SubmoduleState = "conflict"
+)
+
+// Submodule is a submodule of a worktree.
+type Submodule struct {
+	Path   string
+	Commit string
+	State  SubmoduleState
+}
+
+// hasSubmodules reports whether the worktree at path declares submodules.
+func hasSubmodules(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".gitmodules"))
+	return err == nil
+}
+
+// initSubmodules initializes and checks out the submodules of the new
+// workspace at path, unless disabled by the configuration.
+func (s *WorkspaceService) initSubmodules(ctx context.Context, path string) error {
+	if s.config.SkipSubmodules || !hasSubmodules(path) {
+		return nil
+	}
+
+	s.logger.Debug("initializing submodules", "path", path)
+
+	ctx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "submodule", "update", "--init", "--recursive")
+	cmd.Dir = path
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("workspace created at %s, but failed to initialize submodules: %w\nOutput: %s", path, gitpkg.ContextError(ctx, err), string(output))
+	}
+
+	s.logger.Info("submodules initialized", "path", path)
+	return nil
+}
+
+// Submodules returns the submodules of the worktree at path, recursively,
+// or none if it has no submodules.
+func (s *WorkspaceService) Submodules(ctx context.Context, path string) ([]Submodule, error) {
+	if !hasSubmodules(path) {
+		return nil, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "submodule", "status", "--recursive")
+	cmd.Dir = path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submodule status of %s: %w", path, err)
+	}
+
+	return parseSubmoduleStatus(string(output)), nil
+}
+
+// parseSubmoduleStatus parses 'git submodule status' output, made of
+// "<state><commit> <path>[ (<describe>)]" lines.
+func parseSubmoduleStatus(output string) []Submodule {
+	var submodules []Submodule
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+
+		state := SubmoduleInSync
+		switch line[0] {
+		case '+':
+			state = SubmoduleDrifted
+		case '-':
+			state = SubmoduleUninitialized
+		case 'U':
+			state = SubmoduleConflict
+		}
+
+		fields := strings.Fields(line[1:])
+		if len(fields) < 2 {
+			continue
+		}
+		submodules = append(submodules, Submodule{Path: fields[1], Commit: fields[0], State: state})
+	}
+	return submodules
+}
@@ -0,0 +1,27 @@
+package projects
+
+import "testing"
+
+func TestParseSubmoduleStatus(t *testing.T) {
+	output := " 1234567890abcdef vendor/lib (v1.2.0)\n" +
+		"+abcdef1234567890 vendor/drifted (v1.3.0-2-gabcdef1)\n" +
+		"-fedcba0987654321 vendor/missing\n" +
+		"U0000000000000000 vendor/conflict\n"
+
+	got := parseSubmoduleStatus(output)
+	want := []Submodule{
+		{Path: "vendor/lib", Commit: "1234567890abcdef", State: SubmoduleInSync},
+		{Path: "vendor/drifted", Commit: "abcdef1234567890", State: SubmoduleDrifted},
+		{Path: "vendor/missing", Commit: "fedcba0987654321", State: SubmoduleUninitialized},
+		{Path: "vendor/conflict", Commit: "0000000000000000", State: SubmoduleConflict},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("parseSubmoduleStatus() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseSubmoduleStatus()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package projects
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gfanton/projects/internal/match"
+)
+
+// SubdirCacheVersion is the current on-disk format version of the
+// top-level directories cache.
+const SubdirCacheVersion = 1
+
+// subdirEntry is the cached list of top-level directories of a project,
+// with the modification time of the project directory it was read at.
+type subdirEntry struct {
+	Stamp time.Time `json:"stamp"`
+	Dirs  []string  `json:"dirs"`
+}
+
+// subdirCache is the content of the top-level directories cache, keyed by
+// project path.
+type subdirCache struct {
+	Version  int                    `json:"version"`
+	Projects map[string]subdirEntry `json:"projects"`
+}
+
+// SubdirService lists the top-level directories of projects for the
+// "project//subpath" queries, caching them until the project directory
+// changes.
+type SubdirService struct {
+	logger Logger
+	path   string
+}
+
+// NewSubdirService creates a new top-level directories service. The cache
+// file lives in the user cache directory and is keyed by the projects root
+// directory.
+func NewSubdirService(config *Config, logger Logger) *SubdirService {
+	return &SubdirService{
+		logger: logger,
+		path:   cacheFilePath(config.RootDir, "subdirs"),
+	}
+}
+
+// TopLevel returns the sorted top-level directories of projs, in the same
+// order, hidden ones excluded. Directories are read again when the
+// modification time of the project directory changed, which happens when a
+// top-level entry is added, removed or renamed.
+func (s *SubdirService) TopLevel(projs []*Project) [][]string {
+	cache := s.load()
+
+	dirs := make([][]string, len(projs))
+	updated := false
+	for i, p := range projs {
+		info, err := os.Stat(p.Path)
+		if err != nil {
+			continue
+		}
+		if entry, ok := cache.Projects[p.Path]; ok && entry.Stamp.Equal(info.ModTime()) {
+			dirs[i] = entry.Dirs
+			continue
+		}
+
+		if dirs[i], err = readSubdirs(p.Path); err != nil {
+			s.logger.Debug("failed to read project directories", "project", p.String(), "error", err)
+			continue
+		}
+		cache.Projects[p.Path] = subdirEntry{Stamp: info.ModTime().UTC(), Dirs: dirs[i]}
+		updated = true
+	}
+
+	if updated {
+		if err := s.save(cache); err != nil {
+			s.logger.Debug("failed to save directories cache", "path", s.path, "error", err)
+		}
+	}
+	return dirs
+}
+
+// readSubdirs returns the sorted directories of dir, hidden ones excluded.
+func readSubdirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// load reads the directories cache, returning an empty one if it is missing
+// or invalid.
+func (s *SubdirService) load() *subdirCache {
+	cache := &subdirCache{Version: SubdirCacheVersion, Projects: make(map[string]subdirEntry)}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return cache
+	}
+
+	var loaded subdirCache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Version != SubdirCacheVersion || loaded.Projects == nil {
+		s.logger.Debug("ignoring invalid directories cache", "path", s.path, "error", err)
+		return cache
+	}
+	return &loaded
+}
+
+func (s *SubdirService) save(cache *subdirCache) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to encode directories cache: %w", err)
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", s.path, os.Getpid())
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write directories cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace directories cache: %w", err)
+	}
+	return nil
+}
+
+// cleanSubpath returns subpath cleaned, with a trailing slash kept, or false
+// if it leaves the project.
+func cleanSubpath(subpath string) (string, bool) {
+	if subpath == "" {
+		return "", true
+	}
+	if path.IsAbs(subpath) {
+		return "", false
+	}
+
+	cleaned := path.Clean(subpath)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	if cleaned == "." {
+		return "", true
+	}
+	if strings.HasSuffix(subpath, "/") {
+		cleaned += "/"
+	}
+	return cleaned, true
+}
+
+// matchSubpaths returns the directories of the project at projectPath
+// matching subpath, with their distance: subpath itself when it is a
+// directory, or else the directories of its parent starting with its last
+// element, topLevel being the directories of the project.
+func matchSubpaths(projectPath, subpath string, topLevel []string) map[string]int {
+	matches := make(map[string]int)
+
+	if subpath != "" && !strings.HasSuffix(subpath, "/") {
+		if info, err := os.Stat(filepath.Join(projectPath, filepath.FromSlash(subpath))); err == nil && info.IsDir() {
+			matches[subpath] = 0
+			return matches
+		}
+	}
+
+	parent, prefix := path.Split(subpath)
+	names := topLevel
+	if parent != "" {
+		var err error
+		if names, err = readSubdirs(filepath.Join(projectPath, filepath.FromSlash(parent))); err != nil {
+			return matches
+		}
+	}
+
+	prefix = match.Normalize(prefix)
+	for _, name := range names {
+		if normalized := match.Normalize(name); strings.HasPrefix(normalized, prefix) {
+			matches[parent+name] = distanceBranchSubstr + len(normalized) - len(prefix)
+		}
+	}
+	return matches
+}
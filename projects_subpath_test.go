@@ -0,0 +1,105 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSearchSubpath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	mono := filepath.Join(root, "acme", "mono")
+	for _, dir := range []string{"services/auth", "services/api", "scripts", ".github", "../tools"} {
+		if err := os.MkdirAll(filepath.Join(mono, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	svc := NewQueryService(&Config{RootDir: root}, &testLogger{})
+	current := &Project{Path: mono, Organisation: "acme", Name: "mono"}
+
+	tests := []struct {
+		query   string
+		current *Project
+		want    []string
+		wantErr bool
+	}{
+		{query: "mono//services/auth", want: []string{"acme/mono//services/auth"}},
+		{query: "mono//ser", want: []string{"acme/mono//services"}},
+		{query: "mono//s", want: []string{"acme/mono//scripts", "acme/mono//services"}},
+		{query: "mono//services/", want: []string{"acme/mono//services/api", "acme/mono//services/auth"}},
+		{query: "mono//", want: []string{"acme/mono//scripts", "acme/mono//services"}},
+		{query: "//scr", current: current, want: []string{"//scripts"}},
+		{query: "//scr", want: nil},
+		{query: "mono//nope", want: nil},
+		{query: "mono//../acme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			opts := SearchOptions{Query: tt.query, CurrentProject: tt.current}
+			results, err := svc.Search(context.Background(), opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got []string
+			for _, r := range results {
+				got = append(got, svc.Format([]*SearchResult{r}, opts))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	results, err := svc.Search(context.Background(), SearchOptions{Query: "mono//services/auth"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %v, %v", results, err)
+	}
+	if got := svc.Format(results, SearchOptions{AbsPath: true}); got != filepath.Join(mono, "services", "auth") {
+		t.Errorf("Format(abspath) = %q", got)
+	}
+}
+
+func TestSubdirServiceTopLevel(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, sub := range []string{"src", "docs", ".git"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewSubdirService(&Config{RootDir: filepath.Dir(dir)}, &testLogger{})
+	p := &Project{Path: dir}
+	if got := svc.TopLevel([]*Project{p})[0]; !slices.Equal(got, []string{"docs", "src"}) {
+		t.Fatalf("TopLevel() = %v, want [docs src]", got)
+	}
+	if entry, ok := svc.load().Projects[dir]; !ok || !slices.Equal(entry.Dirs, []string{"docs", "src"}) {
+		t.Errorf("cached directories = %+v, %v", entry, ok)
+	}
+
+	// A new directory changes the modification time of the project
+	if err := os.Mkdir(filepath.Join(dir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := svc.load()
+	cache.Projects[dir] = subdirEntry{Stamp: info.ModTime().Add(-1), Dirs: []string{"stale"}}
+	if err := svc.save(cache); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.TopLevel([]*Project{p})[0]; !slices.Equal(got, []string{"api", "docs", "src"}) {
+		t.Errorf("TopLevel() after change = %v, want [api docs src]", got)
+	}
+}
@@ -0,0 +1,60 @@
+package projects
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Subprojects returns the subprojects of the monorepo p, listed in the
+// [subprojects] table of its settings file, sorted by name. Subprojects
+// whose directory doesn't exist are skipped.
+func (s *ProjectService) Subprojects(p *Project) []*Project {
+	settings, err := LoadSettings(p.Path)
+	if err != nil {
+		s.logger.Debug("failed to load subprojects", "project", p.String(), "error", err)
+		return nil
+	}
+
+	names := make([]string, 0, len(settings.Subprojects))
+	for name := range settings.Subprojects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var subprojects []*Project
+	for _, name := range names {
+		sub := newSubproject(p, name, settings.Subprojects[name])
+		if info, err := os.Stat(sub.Path); err != nil || !info.IsDir() {
+			s.logger.Debug("skipping missing subproject", "subproject", sub.String(), "path", sub.Path)
+			continue
+		}
+		subprojects = append(subprojects, sub)
+	}
+	return subprojects
+}
+
+// Subproject returns the subproject name of the monorepo p.
+func (s *ProjectService) Subproject(p *Project, name string) (*Project, error) {
+	settings, err := LoadSettings(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, ok := settings.Subprojects[name]
+	if !ok {
+		return nil, fmt.Errorf("no subproject '%s' in the %s of %s", name, SettingsFile, p.String())
+	}
+	return newSubproject(p, name, dir), nil
+}
+
+func newSubproject(p *Project, name, dir string) *Project {
+	return &Project{
+		Path:         filepath.Join(p.Path, dir),
+		Name:         p.Name,
+		Organisation: p.Organisation,
+		Host:         p.Host,
+		Subproject:   name,
+	}
+}
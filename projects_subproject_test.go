@@ -0,0 +1,87 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubprojects(t *testing.T) {
+	root := t.TempDir()
+	mono := filepath.Join(root, "acme", "mono")
+	for _, dir := range []string{"services/api", "apps/web"} {
+		if err := os.MkdirAll(filepath.Join(mono, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "acme", "api-gateway"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "[subprojects]\napi = \"services/api\"\nweb = \"apps/web\"\ngone = \"apps/gone\"\n"
+	if err := os.WriteFile(filepath.Join(mono, SettingsFile), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{RootDir: root}
+	svc := NewProjectService(cfg, &testLogger{})
+
+	subs := svc.Subprojects(&Project{Path: mono, Organisation: "acme", Name: "mono"})
+	if len(subs) != 2 || subs[0].String() != "acme/mono/api" || subs[0].Path != filepath.Join(mono, "services", "api") || subs[1].String() != "acme/mono/web" {
+		t.Fatalf("Subprojects() = %v, want acme/mono/api and acme/mono/web", subs)
+	}
+
+	p, err := svc.ParseProject("acme/mono/web")
+	if err != nil {
+		t.Fatalf("ParseProject(acme/mono/web) failed: %v", err)
+	}
+	if p.Subproject != "web" || p.Path != filepath.Join(mono, "apps", "web") {
+		t.Errorf("ParseProject(acme/mono/web) = %+v", p)
+	}
+	if _, err := svc.ParseProject("acme/mono/nope"); err == nil {
+		t.Error("ParseProject() of an undeclared subproject should fail")
+	}
+	if got := SessionName("proj-", p); got != "proj-acme_mono/web" || ProjectFromSessionName("proj-", got) != "acme/mono/web" {
+		t.Errorf("SessionName() = %q, want proj-acme_mono/web", got)
+	}
+
+	results, err := NewQueryService(cfg, &testLogger{}).Search(context.Background(), SearchOptions{Query: "api", Exclude: []string{filepath.Join(root, "acme", "api-gateway")}})
+	if err != nil {
+		t.Fatalf("Search() failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Project.String() != "acme/mono/api" {
+		var got []string
+		for _, r := range results {
+			got = append(got, r.Project.String())
+		}
+		t.Errorf("Search(api) = %v, want [acme/mono/api]", got)
+	}
+}
+
+func TestLoadSettingsSubprojects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: "[subprojects]\napi = \"services/api\"\n"},
+		{name: "outside project", content: "[subprojects]\napi = \"../api\"\n", wantErr: true},
+		{name: "absolute", content: "[subprojects]\napi = \"/srv/api\"\n", wantErr: true},
+		{name: "project root", content: "[subprojects]\napi = \".\"\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, SettingsFile), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadSettings(dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ticketTimeout bounds the request fetching the title of a ticket.
+	ticketTimeout = 10 * time.Second
+	// maxSlugLength is the length past which ticket title slugs are cut.
+	maxSlugLength = 50
+)
+
+// ticketPattern matches issue tracker ticket IDs, such as JIRA-1234.
+var ticketPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*-[0-9]+$`)
+
+// IsTicket reports whether name is an issue tracker ticket ID, such as
+// JIRA-1234.
+func IsTicket(name string) bool {
+	return ticketPattern.MatchString(name)
+}
+
+// TicketBranch returns the branch of a ticket, expanding the branch template
+// of the configuration: {user} is the configured user (or the system user),
+// {ticket} the ticket ID and {slug} the slug of the ticket title, fetched
+// from the ticket URL template, where {ticket} is replaced too. The slug is
+// empty without ticket URL template.
+func (s *WorkspaceService) TicketBranch(ctx context.Context, ticket string) (string, error) {
+	if s.config.BranchTemplate == "" {
+		return "", errors.New("no branch template configured (workspace.branch-template)")
+	}
+
+	var slug string
+	if s.config.TicketURL != "" && strings.Contains(s.config.BranchTemplate, "{slug}") {
+		title, err := fetchTicketTitle(ctx, strings.ReplaceAll(s.config.TicketURL, "{ticket}", url.PathEscape(ticket)))
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch the title of %s: %w", ticket, err)
+		}
+		slug = slugify(title)
+		s.logger.Debug("fetched ticket title", "ticket", ticket, "title", title, "slug", slug)
+	}
+
+	username, err := s.templateUser()
+	if err != nil {
+		return "", err
+	}
+
+	return expandBranchTemplate(s.config.BranchTemplate, username, ticket, slug), nil
+}
+
+// IssueBranch returns the branch of the provider issue number titled title,
+// expanding the issue branch template of the configuration: {user} is the
+// configured user (or the system user), {issue} the issue number and {slug}
+// the slug of title.
+func (s *WorkspaceService) IssueBranch(number int, title string) (string, error) {
+	if s.config.IssueBranchTemplate == "" {
+		return "", errors.New("no issue branch template configured (workspace.issue-branch-template)")
+	}
+
+	username, err := s.templateUser()
+	if err != nil {
+		return "", err
+	}
+
+	tmpl := strings.ReplaceAll(s.config.IssueBranchTemplate, "{issue}", "{ticket}")
+	return expandBranchTemplate(tmpl, username, strconv.Itoa(number), slugify(title)), nil
+}
+
+// LinkIssue records the issue number of branch in proj, titled title, in the
+// description of the branch, shown by 'git branch --edit-description' and
+// used by 'git request-pull' and 'git format-patch --cover-letter'.
+func (s *WorkspaceService) LinkIssue(ctx context.Context, proj Project, branch string, number int, title, issueURL string) error {
+	description := fmt.Sprintf("#%d %s", number, title)
+	if issueURL != "" {
+		description += "\n\n" + issueURL
+	}
+	if _, err := runGitCombined(ctx, proj.Path, "config", "branch."+branch+".description", description); err != nil {
+		return fmt.Errorf("failed to link issue #%d to %s: %w", number, branch, err)
+	}
+	s.logger.Debug("linked issue", "project", proj.String(), "branch", branch, "issue", number)
+	return nil
+}
+
+// templateUser returns the {user} of branch templates: the configured user,
+// or the system user.
+func (s *WorkspaceService) templateUser() (string, error) {
+	if s.config.RootUser != "" {
+		return s.config.RootUser, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return u.Username, nil
+}
+
+// expandBranchTemplate replaces the placeholders of tmpl, dropping the
+// separators left dangling by empty values.
+func expandBranchTemplate(tmpl, username, ticket, slug string) string {
+	branch := strings.NewReplacer("{user}", username, "{ticket}", ticket, "{slug}", slug).Replace(tmpl)
+
+	for _, sep := range []string{"-/", "_/", "//"} {
+		branch = strings.ReplaceAll(branch, sep, "/")
+	}
+	return strings.Trim(branch, "-_/.")
+}
+
+// slugify returns title lowercased, with runs of characters other than
+// ASCII letters and digits replaced by a dash, cut to maxSlugLength at a
+// word boundary when possible.
+func slugify(title string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	slug := sb.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
+
+// fetchTicketTitle returns the title of the ticket at rawURL: the "title",
+// "summary" or "fields.summary" (Jira) member of a JSON response, or else
+// the first line of the response. Credentials can be given in the URL.
+func fetchTicketTitle(ctx context.Context, rawURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ticketTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid ticket URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json, text/plain")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	title := ticketTitle(body)
+	if title == "" {
+		return "", errors.New("no title in response")
+	}
+	return title, nil
+}
+
+// ticketTitle extracts the title of a ticket from a response body.
+func ticketTitle(body []byte) string {
+	var ticket struct {
+		Title   string `json:"title"`
+		Summary string `json:"summary"`
+		Fields  struct {
+			Summary string `json:"summary"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(body, &ticket); err == nil {
+		for _, title := range []string{ticket.Title, ticket.Summary, ticket.Fields.Summary} {
+			if title = strings.TrimSpace(title); title != "" {
+				return title
+			}
+		}
+		return ""
+	}
+
+	line, _, _ := strings.Cut(string(body), "\n")
+	return strings.TrimSpace(line)
+}
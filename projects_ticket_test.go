@@ -0,0 +1,174 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+)
+
+func TestIsTicket(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"JIRA-1234", true},
+		{"AB2-1", true},
+		{"jira-1234", false},
+		{"JIRA-", false},
+		{"feature-x", false},
+		{"#123", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTicket(tt.name); got != tt.want {
+			t.Errorf("IsTicket(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Fix the login page", "fix-the-login-page"},
+		{"  [API] Don't crash on nil!  ", "api-don-t-crash-on-nil"},
+		{"Très café", "tr-s-caf"},
+		{"A very long title that goes on and on past the maximum slug length", "a-very-long-title-that-goes-on-and-on-past-the"},
+	}
+
+	for _, tt := range tests {
+		if got := slugify(tt.title); got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestExpandBranchTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		slug string
+		want string
+	}{
+		{"{user}/{ticket}-{slug}", "fix-login", "alice/JIRA-1-fix-login"},
+		{"{user}/{ticket}-{slug}", "", "alice/JIRA-1"},
+		{"{slug}/{ticket}", "", "JIRA-1"},
+		{"{ticket}", "fix-login", "JIRA-1"},
+	}
+
+	for _, tt := range tests {
+		if got := expandBranchTemplate(tt.tmpl, "alice", "JIRA-1", tt.slug); got != tt.want {
+			t.Errorf("expandBranchTemplate(%q, slug %q) = %q, want %q", tt.tmpl, tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestTicketBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/issue/JIRA-1":
+			fmt.Fprint(w, `{"key":"JIRA-1","fields":{"summary":"Fix the login page"}}`)
+		case "/issue/JIRA-2":
+			fmt.Fprint(w, "Add dark mode\nmore details")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		ticket  string
+		want    string
+		wantErr bool
+	}{
+		{ticket: "JIRA-1", want: "alice/JIRA-1-fix-the-login-page"},
+		{ticket: "JIRA-2", want: "alice/JIRA-2-add-dark-mode"},
+		{ticket: "JIRA-3", wantErr: true},
+	}
+
+	svc := NewWorkspaceService(&Config{
+		RootUser:       "alice",
+		BranchTemplate: "{user}/{ticket}-{slug}",
+		TicketURL:      server.URL + "/issue/{ticket}",
+	}, &testLogger{})
+
+	for _, tt := range tests {
+		t.Run(tt.ticket, func(t *testing.T) {
+			got, err := svc.TicketBranch(context.Background(), tt.ticket)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("TicketBranch() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TicketBranch() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TicketBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIssueBranch(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		title   string
+		want    string
+		wantErr bool
+	}{
+		{tmpl: "{issue}-{slug}", title: "Fix the login page", want: "42-fix-the-login-page"},
+		{tmpl: "{user}/issue-{issue}-{slug}", title: "[API] Crash!", want: "alice/issue-42-api-crash"},
+		{tmpl: "{user}/{issue}-{slug}", title: "", want: "alice/42"},
+		{tmpl: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			svc := NewWorkspaceService(&Config{RootUser: "alice", IssueBranchTemplate: tt.tmpl}, &testLogger{})
+			got, err := svc.IssueBranch(42, tt.title)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("IssueBranch() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IssueBranch() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IssueBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkIssue(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	p := Project{Path: t.TempDir(), Organisation: "user", Name: "repo"}
+	if output, err := exec.Command("git", "init", p.Path).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, output)
+	}
+
+	svc := NewWorkspaceService(&Config{}, &testLogger{})
+	if err := svc.LinkIssue(context.Background(), p, "42-fix-login", 42, "Fix login", "https://github.com/user/repo/issues/42"); err != nil {
+		t.Fatalf("LinkIssue() failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "config", "branch.42-fix-login.description")
+	cmd.Dir = p.Path
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git config: %v", err)
+	}
+	if want := "#42 Fix login\n\nhttps://github.com/user/repo/issues/42\n"; string(output) != want {
+		t.Errorf("branch description = %q, want %q", output, want)
+	}
+}
@@ -0,0 +1,23 @@
+package projects
+
+import (
+	"context"
+	"time"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// withTimeout returns ctx bounded by timeout, such as Config.NetworkTimeout,
+// or only cancelable when timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// retry returns the retry policy of the Git network operations of the
+// services.
+func (c *Config) retry() gitpkg.RetryPolicy {
+	return gitpkg.RetryPolicy{Attempts: c.RetryAttempts, Backoff: c.RetryBackoff}
+}
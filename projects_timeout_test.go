@@ -0,0 +1,37 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("withTimeout(0) should not set a deadline")
+	}
+
+	ctx, cancel = withTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("withTimeout(1m) deadline = %v, %v", deadline, ok)
+	}
+}
+
+func TestRunGitCombinedCanceled(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := runGitCombined(ctx, t.TempDir(), "status")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("runGitCombined() error = %v, want context.Canceled", err)
+	}
+}
@@ -1,6 +1,11 @@
 package projects
 
-import "log/slog"
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gfanton/projects/internal/project"
+)
 
 // Config holds the global configuration for the project tool.
 type Config struct {
@@ -8,6 +13,72 @@ type Config struct {
 	Debug      bool
 	RootDir    string
 	RootUser   string
+
+	// SkipSubmodules disables the initialization of submodules in new workspaces.
+	SkipSubmodules bool
+
+	// Aliases maps short names to the "user/project" they stand for.
+	Aliases map[string]string
+
+	// Sets maps the names of project sets to the names of their projects
+	// (see ProjectService.ParseSet).
+	Sets map[string][]string
+
+	// Hosts maps the names of Git servers other than GitHub to their
+	// settings, for the "host/user/project" names of their projects.
+	Hosts map[string]Host
+
+	// BranchTemplate is the branch of workspaces added for a ticket, such as
+	// "{user}/{ticket}-{slug}" (see WorkspaceService.TicketBranch).
+	BranchTemplate string
+	// TicketURL is the URL template the title of a ticket is fetched from.
+	TicketURL string
+	// IssueBranchTemplate is the branch of workspaces added for a provider
+	// issue, such as "{issue}-{slug}" (see WorkspaceService.IssueBranch).
+	IssueBranchTemplate string
+	// IdleAfter is how long a workspace is left unused before it is idle
+	// (see WorkspaceService.IsIdle). Zero never flags workspaces.
+	IdleAfter time.Duration
+
+	// ReadOnly makes the operations modifying the root directory fail with
+	// ErrReadOnly, such as for a shared team checkout directory.
+	ReadOnly bool
+	// Strict makes the callers walking the root directory fail with the
+	// *WalkErrors of unreadable directories instead of using the partial
+	// results (see IsPartialWalk).
+	Strict bool
+
+	// AllowHidden lists the hidden directories, such as ".dotfiles", walked
+	// for projects like others. Organisations and projects named after them
+	// are found, the other hidden directories are skipped.
+	AllowHidden []string
+
+	// NetworkTimeout bounds each Git network operation of the services:
+	// fetches, ls-remote and submodule updates. Zero means no timeout.
+	NetworkTimeout time.Duration
+	// RetryAttempts is the number of attempts of the Git network operations
+	// failing with transient errors, waiting RetryBackoff before the first
+	// retry and doubling it before each next one. 1 or less never retries.
+	RetryAttempts int
+	RetryBackoff  time.Duration
+}
+
+// hostNames returns the names of the configured Git server hosts.
+func (c *Config) hostNames() []string {
+	names := make([]string, 0, len(c.Hosts))
+	for name := range c.Hosts {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Host holds the settings of a Git server other than GitHub.
+type Host struct {
+	// SSHUser is the user of SSH clone URLs, "git" when empty.
+	SSHUser string
+	// DefaultOrgPrefix is the organisation, e.g. "team/", of the project
+	// names of the host without one.
+	DefaultOrgPrefix string
 }
 
 // Project represents a project with its organization and name.
@@ -15,21 +86,48 @@ type Project struct {
 	Path         string
 	Name         string
 	Organisation string
+
+	// Host is the Git server of projects stored under a directory of its
+	// name in the root directory, such as "git.corp.com". It is empty for
+	// the projects of GitHub.
+	Host string
+
+	// Subproject is the name of a subdirectory of a monorepo listed in the
+	// [subprojects] table of its settings file, Path being the one of the
+	// subdirectory. It is empty for projects.
+	Subproject string
 }
 
-// String returns the string representation of the project (user/project).
+// String returns the string representation of the project (user/project),
+// followed by the subproject name for subprojects (user/project/subproject)
+// and prefixed by the host of projects not on GitHub (host/user/project).
 func (p *Project) String() string {
-	return p.Organisation + "/" + p.Name
+	name := p.Organisation + "/" + p.Name
+	if p.Host != "" {
+		name = p.Host + "/" + name
+	}
+	if p.Subproject != "" {
+		return name + "/" + p.Subproject
+	}
+	return name
+}
+
+// Provider returns the host name of the Git server of the project.
+func (p *Project) Provider() string {
+	if p.Host != "" {
+		return p.Host
+	}
+	return project.DefaultProvider
 }
 
 // GitHTTPURL returns the HTTP URL for cloning the project.
 func (p *Project) GitHTTPURL() string {
-	return "https://github.com/" + p.Organisation + "/" + p.Name + ".git"
+	return "https://" + p.Provider() + "/" + p.Organisation + "/" + p.Name + ".git"
 }
 
 // GitSSHURL returns the SSH URL for cloning the project.
 func (p *Project) GitSSHURL() string {
-	return "git@github.com:" + p.Organisation + "/" + p.Name + ".git"
+	return "git@" + p.Provider() + ":" + p.Organisation + "/" + p.Name + ".git"
 }
 
 // Workspace represents a workspace with its project and branch.
@@ -43,7 +141,9 @@ type Workspace struct {
 type SearchResult struct {
 	Project   *Project
 	Workspace string // Empty for project results, branch name for workspace results
+	Subpath   string // Slash-separated directory inside the project, for "project//subpath" queries
 	Distance  int
+	Dirty     bool // Workspace has uncommitted changes (only set when SearchOptions.Annotate is enabled)
 }
 
 // SearchOptions holds configuration for project queries.
@@ -52,9 +152,15 @@ type SearchOptions struct {
 	Exclude        []string
 	AbsPath        bool
 	Separator      string
+	Print0         bool // Terminate each result with a NUL byte, ignoring Separator (for xargs -0)
 	Limit          int
 	ShowDistance   bool
-	CurrentProject *Project // When set, workspace queries without project prefix are limited to this project
+	Rank           string         // Ranking algorithm (see RankNames); empty selects DefaultRank
+	Sort           string         // Result order (see SortNames); empty selects SortRelevance
+	Descriptions   bool           // Also match the query against indexed project descriptions
+	Annotate       bool           // Append a tab-separated annotation to each formatted result (for shell completion)
+	Snapshot       *CacheSnapshot // When set, search this cache snapshot instead of walking the filesystem
+	CurrentProject *Project       // When set, workspace queries without project prefix are limited to this project
 }
 
 // Logger interface for dependency injection
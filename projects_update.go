@@ -0,0 +1,121 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
+)
+
+// pullRequest returns the number of the pull request ws was added for with
+// "#123", recorded in its metadata or else told by its pr-123 branch, or
+// false.
+func (s *WorkspaceService) pullRequest(ws Workspace) (int, bool) {
+	if info, err := s.ReadPullRequest(ws.Path); err == nil && info != nil {
+		return info.Number, true
+	}
+
+	num, ok := strings.CutPrefix(ws.Branch, "pr-")
+	if !ok {
+		return 0, false
+	}
+	prNum, err := strconv.Atoi(num)
+	if err != nil || ws.Path != s.WorkspacePath(ws.Project, "#"+num) {
+		return 0, false
+	}
+	return prNum, true
+}
+
+// Update refreshes the checkout of ws and returns its head commit before and
+// after. Pull request workspaces are reset to the head of the pull request
+// fetched again, following force pushes; other workspaces fast-forward to
+// the upstream of their branch with git pull --ff-only. The workspace must
+// be clean.
+func (s *WorkspaceService) Update(ctx context.Context, ws Workspace) (from, to string, err error) {
+	s.logger.Debug("updating workspace", "project", ws.Project.String(), "branch", ws.Branch)
+
+	if err := s.config.CheckWritable("update workspace " + ws.Project.String() + ":" + ws.Branch); err != nil {
+		return "", "", err
+	}
+
+	dirty, err := s.IsDirty(ctx, ws.Path)
+	if err != nil {
+		return "", "", err
+	}
+	if dirty {
+		return "", "", fmt.Errorf("%w: %s (commit or stash them first)", ErrDirtyCheckout, ws.Path)
+	}
+
+	if from, err = runGitCombined(ctx, ws.Path, "rev-parse", "HEAD"); err != nil {
+		return "", "", fmt.Errorf("failed to get head of %s: %w", ws.Path, err)
+	}
+
+	if prNum, ok := s.pullRequest(ws); ok {
+		err = s.updatePullRequest(ctx, ws, prNum)
+	} else {
+		err = s.pull(ctx, ws)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if to, err = runGitCombined(ctx, ws.Path, "rev-parse", "HEAD"); err != nil {
+		return "", "", fmt.Errorf("failed to get head of %s: %w", ws.Path, err)
+	}
+
+	s.logger.Info("workspace updated", "path", ws.Path, "branch", ws.Branch, "from", from, "to", to)
+	return from, to, nil
+}
+
+// updatePullRequest fetches the head of the pull request prNum and resets
+// the workspace to it. The branch is checked out, so the ref is fetched into
+// FETCH_HEAD rather than into the branch.
+func (s *WorkspaceService) updatePullRequest(ctx context.Context, ws Workspace, prNum int) error {
+	remote, err := s.getDefaultRemote(ctx, ws.Project)
+	if err != nil {
+		return fmt.Errorf("failed to get remote: %w", err)
+	}
+
+	fetchCtx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
+
+	err = s.config.retry().Do(fetchCtx, s.logger.Warn, func() error {
+		cmd := exec.CommandContext(fetchCtx, "git", "fetch", remote, fmt.Sprintf("refs/pull/%d/head", prNum))
+		cmd.Dir = ws.Path
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w\nOutput: %s", gitpkg.ContextError(fetchCtx, err), string(output))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch PR #%d: %w", prNum, err)
+	}
+
+	if _, err := runGitCombined(ctx, ws.Path, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("failed to reset workspace to PR #%d: %w", prNum, err)
+	}
+	return nil
+}
+
+// pull fast-forwards the workspace to the upstream of its branch.
+func (s *WorkspaceService) pull(ctx context.Context, ws Workspace) error {
+	if _, err := runGitCombined(ctx, ws.Path, "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err != nil {
+		return fmt.Errorf("branch %s has no upstream to pull from", ws.Branch)
+	}
+
+	pullCtx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
+
+	err := s.config.retry().Do(pullCtx, s.logger.Warn, func() error {
+		_, err := runGitCombined(pullCtx, ws.Path, "pull", "--ff-only")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ws.Branch, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceUpdate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	origin := filepath.Join(t.TempDir(), "origin")
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+
+	commit := func(dir, name, content string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, dir, "add", name)
+		runGit(t, dir, "commit", "-m", name)
+		return runGit(t, dir, "rev-parse", "HEAD")
+	}
+
+	if err := os.MkdirAll(origin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, origin, "init", "-b", "main")
+	commit(origin, "a", "a\n")
+	runGit(t, origin, "branch", "feature")
+	runGit(t, origin, "update-ref", "refs/pull/1/head", commit(origin, "pr", "v1\n"))
+	runGit(t, origin, "reset", "--hard", "HEAD~1")
+	runGit(t, root, "clone", origin, p.Path)
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	for _, branch := range []string{"#1", "feature"} {
+		if err := svc.Add(ctx, p, branch); err != nil {
+			t.Fatalf("Add(%s) failed: %v", branch, err)
+		}
+	}
+	prWS := Workspace{Project: p, Branch: "pr-1", Path: svc.WorkspacePath(p, "#1")}
+	featureWS := Workspace{Project: p, Branch: "feature", Path: svc.WorkspacePath(p, "feature")}
+
+	if info, err := svc.ReadPullRequest(prWS.Path); err != nil || info == nil || info.Number != 1 {
+		t.Fatalf("ReadPullRequest(#1) = %+v, %v, want number 1", info, err)
+	}
+	if info, err := svc.ReadPullRequest(featureWS.Path); err != nil || info != nil {
+		t.Errorf("ReadPullRequest(feature) = %+v, %v, want nil", info, err)
+	}
+
+	// The pull request is force-pushed and the feature branch gets a commit
+	runGit(t, origin, "checkout", "-q", "--detach", "main")
+	prHead := commit(origin, "pr", "v2\n")
+	runGit(t, origin, "update-ref", "refs/pull/1/head", prHead)
+	runGit(t, origin, "checkout", "-q", "feature")
+	featureHead := commit(origin, "b", "b\n")
+	runGit(t, origin, "checkout", "-q", "main")
+
+	for _, tt := range []struct {
+		ws   Workspace
+		want string
+	}{
+		{prWS, prHead},
+		{featureWS, featureHead},
+	} {
+		from, to, err := svc.Update(ctx, tt.ws)
+		if err != nil {
+			t.Fatalf("Update(%s) failed: %v", tt.ws.Branch, err)
+		}
+		if to != tt.want || from == to {
+			t.Errorf("Update(%s) = %s..%s, want ..%s", tt.ws.Branch, from, to, tt.want)
+		}
+		if head := runGit(t, tt.ws.Path, "rev-parse", "HEAD"); head != tt.want {
+			t.Errorf("head of %s after Update = %s, want %s", tt.ws.Branch, head, tt.want)
+		}
+	}
+
+	if from, to, err := svc.Update(ctx, prWS); err != nil || from != to {
+		t.Errorf("Update(pr-1) again = %s..%s, %v, want up to date", from, to, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(featureWS.Path, "dirty"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.Update(ctx, featureWS); !errors.Is(err, ErrDirtyCheckout) {
+		t.Errorf("Update(dirty) error = %v, want ErrDirtyCheckout", err)
+	}
+}
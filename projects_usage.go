@@ -0,0 +1,140 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DiskUsage returns the total size in bytes of the regular files under path,
+// counting hard-linked files once. With exclusive, files hard-linked
+// elsewhere, such as package manager stores shared between checkouts, are
+// not counted at all.
+func DiskUsage(ctx context.Context, path string, exclusive bool) (int64, error) {
+	var total int64
+	seen := make(map[fileID]bool)
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries rather than failing the whole report
+			if errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		id, links, ok := fileLinks(info)
+		if ok && links > 1 {
+			if exclusive || seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute disk usage of %s: %w", path, err)
+	}
+
+	return total, nil
+}
+
+// Artifact is a build artifact directory of a project, such as node_modules.
+type Artifact struct {
+	Path string
+	Size int64
+}
+
+// ProjectUsage returns the disk usage of the project at path and its
+// artifact directories, whose base name matches one of patterns (see
+// filepath.Match). Artifact directories are not searched for nested ones.
+func ProjectUsage(ctx context.Context, path string, patterns []string) (int64, []Artifact, error) {
+	var total int64
+	var artifacts []Artifact
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p == path || !matchAny(patterns, d.Name()) {
+				return nil
+			}
+
+			size, err := DiskUsage(ctx, p, false)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, Artifact{Path: p, Size: size})
+			total += size
+			return filepath.SkipDir
+		}
+
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to compute disk usage of %s: %w", path, err)
+	}
+
+	return total, artifacts, nil
+}
+
+// matchAny reports whether name matches one of patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LastCommit returns the committer date of the HEAD commit of the worktree
+// at path.
+func (s *WorkspaceService) LastCommit(ctx context.Context, path string) (time.Time, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--format=%ct")
+	cmd.Dir = path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last commit of %s: %w", path, err)
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last commit date of %s: %w", path, err)
+	}
+
+	return time.Unix(seconds, 0), nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package projects
+
+import "io/fs"
+
+// fileID identifies a file across hard links.
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileLinks reports no hard link information on this platform: every file
+// is counted.
+func fileLinks(info fs.FileInfo) (fileID, uint64, bool) {
+	return fileID{}, 0, false
+}
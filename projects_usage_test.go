@@ -0,0 +1,81 @@
+package projects
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "node_modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shared := filepath.Join(dir, "node_modules", "dep.js")
+	if err := os.WriteFile(shared, make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	got, err := DiskUsage(ctx, dir, false)
+	if err != nil {
+		t.Fatalf("DiskUsage() error = %v", err)
+	}
+	if got != 1100 {
+		t.Errorf("DiskUsage() = %d, want 1100", got)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	// A hard link is counted once, and not at all when exclusive
+	if err := os.Link(shared, filepath.Join(dir, "node_modules", "link.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := DiskUsage(ctx, dir, false); got != 1100 {
+		t.Errorf("DiskUsage() with hard link = %d, want 1100", got)
+	}
+	if got, _ := DiskUsage(ctx, dir, true); got != 100 {
+		t.Errorf("DiskUsage(exclusive) = %d, want 100", got)
+	}
+}
+
+func TestProjectUsage(t *testing.T) {
+	dir := t.TempDir()
+	for path, size := range map[string]int{
+		"main.rs":                       100,
+		"target/debug/app":              1000,
+		"web/node_modules/dep/index.js": 500,
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total, artifacts, err := ProjectUsage(context.Background(), dir, []string{"target", "node_*"})
+	if err != nil {
+		t.Fatalf("ProjectUsage() error = %v", err)
+	}
+	if total != 1600 {
+		t.Errorf("ProjectUsage() total = %d, want 1600", total)
+	}
+
+	sizes := make(map[string]int64)
+	for _, artifact := range artifacts {
+		sizes[artifact.Path] = artifact.Size
+	}
+	if len(sizes) != 2 || sizes[filepath.Join(dir, "target")] != 1000 || sizes[filepath.Join(dir, "web", "node_modules")] != 500 {
+		t.Errorf("ProjectUsage() artifacts = %+v", artifacts)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package projects
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID identifies a file across hard links.
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileLinks returns the identity and hard link count of the file of info.
+func fileLinks(info fs.FileInfo) (fileID, uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, 0, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, uint64(st.Nlink), true
+}
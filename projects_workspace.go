@@ -2,24 +2,126 @@ package projects
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	gitpkg "github.com/gfanton/projects/internal/git"
 )
 
+// ErrWorkspaceNotFound is returned when a workspace does not exist.
+var ErrWorkspaceNotFound = errors.New("workspace does not exist")
+
+// ErrWorkspaceDirty is returned when removing a workspace with uncommitted
+// changes or untracked files without force.
+var ErrWorkspaceDirty = errors.New("workspace has uncommitted changes")
+
+// DirtyWorkspaceError reports the changes of a workspace that was not
+// removed. It matches ErrWorkspaceDirty with errors.Is.
+type DirtyWorkspaceError struct {
+	Path    string
+	Summary string // diffstat of the changes, followed by the untracked files count
+}
+
+func (e *DirtyWorkspaceError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrWorkspaceDirty, e.Path)
+}
+
+func (e *DirtyWorkspaceError) Unwrap() error {
+	return ErrWorkspaceDirty
+}
+
 // encodeBranch converts branch name to safe directory name.
-// Replaces "/" with "--" to avoid subdirectory creation.
+// Replaces "/" with "--" to avoid subdirectory creation, and escapes "%" as
+// "%25" and the dashes next to a dash or a slash as "%2D", so that no two
+// branches share a directory: "feat/x" is "feat--x" and "feat--x" is
+// "feat%2D%2Dx". Other branches keep their name.
 func encodeBranch(branch string) string {
+	if !strings.ContainsAny(branch, "/-%") {
+		return branch
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(branch); i++ {
+		switch c := branch[i]; {
+		case c == '/':
+			b.WriteString("--")
+		case c == '%':
+			b.WriteString("%25")
+		case c == '-' && (i > 0 && isDashOrSlash(branch[i-1]) || i+1 < len(branch) && isDashOrSlash(branch[i+1])):
+			b.WriteString("%2D")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isDashOrSlash(c byte) bool {
+	return c == '-' || c == '/'
+}
+
+// DecodeBranch returns the branch of a workspace directory name, reversing
+// the encoding of WorkspacePath.
+func DecodeBranch(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch rest := name[i:]; {
+		case strings.HasPrefix(rest, "--"):
+			b.WriteByte('/')
+			i++
+		case strings.HasPrefix(rest, "%25"):
+			b.WriteByte('%')
+			i += 2
+		case strings.HasPrefix(rest, "%2D"):
+			b.WriteByte('-')
+			i += 2
+		default:
+			b.WriteByte(name[i])
+		}
+	}
+	return b.String()
+}
+
+// legacyEncodeBranch is the encoding of branch names of the workspaces
+// created before encodeBranch escaped dashes, under which "feat/x" and
+// "feat--x" shared a directory.
+func legacyEncodeBranch(branch string) string {
 	return strings.ReplaceAll(branch, "/", "--")
 }
 
+// branchPath returns the directory of the worktree of branch in dir: its
+// encoded name, or its legacy name when the worktree of branch was created
+// there and is yet to be migrated.
+func branchPath(dir, branch string) string {
+	encoded := encodeBranch(branch)
+	path := filepath.Join(dir, encoded)
+
+	legacy := legacyEncodeBranch(branch)
+	if legacy == encoded {
+		return path
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return path
+	}
+	legacyPath := filepath.Join(dir, legacy)
+	if head, ok := WorktreeBranch(legacyPath); ok && head == branch {
+		return legacyPath
+	}
+	return path
+}
+
 // WorkspaceService provides workspace operations.
 type WorkspaceService struct {
 	logger Logger
 	config *Config
+
+	setupOutput io.Writer // output of setup commands, os.Stderr when nil
 }
 
 // NewWorkspaceService creates a new workspace service.
@@ -35,10 +137,21 @@ func (s *WorkspaceService) WorkspaceDir() string {
 	return filepath.Join(s.config.RootDir, ".workspace")
 }
 
-// WorkspacePath returns the path for a specific workspace.
+// ProjectWorkspaceDir returns the directory of the workspaces of proj: its
+// directory under WorkspaceDir, or the project directory itself with the
+// bare layout.
+func (s *WorkspaceService) ProjectWorkspaceDir(proj Project) string {
+	if proj.IsBare() {
+		return proj.Path
+	}
+	return filepath.Join(s.WorkspaceDir(), proj.Host, proj.Organisation, proj.Name)
+}
+
+// WorkspacePath returns the path for a specific workspace. Workspaces
+// created under the legacy encoding of branch names are found at their
+// legacy path until 'proj workspace migrate' moves them.
 func (s *WorkspaceService) WorkspacePath(proj Project, branch string) string {
-	encoded := encodeBranch(branch)
-	return filepath.Join(s.WorkspaceDir(), proj.Organisation, proj.Name, encoded)
+	return branchPath(s.ProjectWorkspaceDir(proj), branch)
 }
 
 // isPullRequest checks if the branch string is a PR number (#123 format)
@@ -92,12 +205,22 @@ func (s *WorkspaceService) validatePullRequest(ctx context.Context, proj Project
 	}
 
 	// Try to fetch the PR ref to validate it exists
-	cmd := exec.CommandContext(ctx, "git", "ls-remote", remote, fmt.Sprintf("refs/pull/%d/head", prNum))
-	cmd.Dir = proj.Path
+	ctx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
 
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	err = s.config.retry().Do(ctx, s.logger.Warn, func() error {
+		cmd := exec.CommandContext(ctx, "git", "ls-remote", remote, fmt.Sprintf("refs/pull/%d/head", prNum))
+		cmd.Dir = proj.Path
+
+		var err error
+		if output, err = cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w\nOutput: %s", gitpkg.ContextError(ctx, err), string(output))
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to validate PR #%d: %w\nOutput: %s", prNum, err, string(output))
+		return fmt.Errorf("failed to validate PR #%d: %w", prNum, err)
 	}
 
 	if strings.TrimSpace(string(output)) == "" {
@@ -139,15 +262,24 @@ func (s *WorkspaceService) addPullRequestWorkspace(ctx context.Context, proj Pro
 	s.logger.Debug("fetching pull request", "ref", prRef, "local_branch", localBranch)
 
 	// Fetch the PR ref
-	cmd := exec.CommandContext(ctx, "git", "fetch", remote, fmt.Sprintf("%s:%s", prRef, localBranch))
-	cmd.Dir = proj.Path
+	fetchCtx, cancel := withTimeout(ctx, s.config.NetworkTimeout)
+	defer cancel()
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to fetch PR #%d: %w\nOutput: %s", prNum, err, string(output))
+	err = s.config.retry().Do(fetchCtx, s.logger.Warn, func() error {
+		cmd := exec.CommandContext(fetchCtx, "git", "fetch", remote, fmt.Sprintf("%s:%s", prRef, localBranch))
+		cmd.Dir = proj.Path
+
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%w\nOutput: %s", gitpkg.ContextError(fetchCtx, err), string(output))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch PR #%d: %w", prNum, err)
 	}
 
 	// Create worktree with the fetched PR branch
-	cmd = exec.CommandContext(ctx, "git", "worktree", "add", workspacePath, localBranch)
+	cmd := exec.CommandContext(ctx, "git", "worktree", "add", workspacePath, localBranch)
 	cmd.Dir = proj.Path
 
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -155,12 +287,58 @@ func (s *WorkspaceService) addPullRequestWorkspace(ctx context.Context, proj Pro
 	}
 
 	s.logger.Info("workspace created for pull request", "path", workspacePath, "pr", prNum, "branch", localBranch)
-	return nil
+
+	// Record the pull request for listings and status lines
+	info := PullRequestInfo{Number: prNum}
+	if remoteURL, err := runGitCombined(ctx, proj.Path, "remote", "get-url", remote); err == nil {
+		info.URL = pullRequestURL(remoteURL, prNum)
+	}
+	if err := writePullRequest(workspacePath, info); err != nil {
+		s.logger.Warn("failed to record pull request of workspace", "path", workspacePath, "error", err)
+	}
+
+	if err := s.initSubmodules(ctx, workspacePath); err != nil {
+		return err
+	}
+	return s.setupWorkspace(ctx, proj, workspacePath, "")
 }
 
 // Add creates a new workspace for the given project and branch.
 func (s *WorkspaceService) Add(ctx context.Context, proj Project, branch string) error {
-	s.logger.Debug("adding workspace", "project", proj.Name, "org", proj.Organisation, "branch", branch)
+	return s.AddFrom(ctx, proj, branch, "")
+}
+
+// Ensure creates the workspace of branch for the given project unless it
+// already exists.
+func (s *WorkspaceService) Ensure(ctx context.Context, proj Project, branch string) error {
+	workspaces, err := s.List(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	for _, ws := range workspaces {
+		if ws.Branch == branch {
+			return nil
+		}
+	}
+
+	s.logger.Info("workspace not found, creating", "workspace", branch, "project", proj.String())
+	if err := s.Add(ctx, proj, branch); err != nil {
+		return fmt.Errorf("workspace '%s' not found and auto-create failed: %w", branch, err)
+	}
+
+	return nil
+}
+
+// AddFrom creates a new workspace for the given project and branch. When
+// the branch doesn't exist, it is created from base, or from HEAD when base
+// is empty.
+func (s *WorkspaceService) AddFrom(ctx context.Context, proj Project, branch, base string) error {
+	s.logger.Debug("adding workspace", "project", proj.Name, "org", proj.Organisation, "branch", branch, "base", base)
+
+	if err := s.config.CheckWritable("add workspace " + proj.String() + ":" + branch); err != nil {
+		return err
+	}
 
 	// Check if this is a pull request
 	if prNum, isPR := s.isPullRequest(branch); isPR {
@@ -178,6 +356,7 @@ func (s *WorkspaceService) Add(ctx context.Context, proj Project, branch string)
 	}
 
 	// Try to create worktree with existing branch first
+	var newBranch string
 	cmd := exec.CommandContext(ctx, "git", "worktree", "add", workspacePath, branch)
 	cmd.Dir = proj.Path
 
@@ -185,31 +364,72 @@ func (s *WorkspaceService) Add(ctx context.Context, proj Project, branch string)
 		// If branch doesn't exist, try creating it
 		s.logger.Debug("branch doesn't exist, creating new branch", "branch", branch, "error", err, "output", string(output))
 
-		cmd = exec.CommandContext(ctx, "git", "worktree", "add", "-b", branch, workspacePath)
+		args := []string{"worktree", "add", "-b", branch, workspacePath}
+		if base != "" {
+			args = append(args, base)
+		}
+		cmd = exec.CommandContext(ctx, "git", args...)
 		cmd.Dir = proj.Path
 
 		if output, err := cmd.CombinedOutput(); err != nil {
 			return fmt.Errorf("failed to create worktree with new branch: %w\nOutput: %s", err, string(output))
 		}
-		s.logger.Info("workspace created with new branch", "path", workspacePath, "branch", branch)
+		s.logger.Info("workspace created with new branch", "path", workspacePath, "branch", branch, "base", base)
+		newBranch = branch
 	} else {
 		s.logger.Info("workspace created with existing branch", "path", workspacePath, "branch", branch)
 	}
 
-	return nil
+	if err := s.initSubmodules(ctx, workspacePath); err != nil {
+		return err
+	}
+	return s.setupWorkspace(ctx, proj, workspacePath, newBranch)
 }
 
-// Remove removes a workspace for the given project and branch.
+// Remove removes a workspace for the given project and branch. Workspaces
+// with uncommitted changes or untracked files are kept, returning a
+// *DirtyWorkspaceError.
 func (s *WorkspaceService) Remove(ctx context.Context, proj Project, branch string, deleteBranch bool) error {
-	s.logger.Debug("removing workspace", "project", proj.Name, "org", proj.Organisation, "branch", branch, "deleteBranch", deleteBranch)
+	return s.remove(ctx, proj, branch, deleteBranch, false)
+}
+
+// ForceRemove removes a workspace for the given project and branch,
+// discarding its uncommitted changes and untracked files.
+func (s *WorkspaceService) ForceRemove(ctx context.Context, proj Project, branch string, deleteBranch bool) error {
+	return s.remove(ctx, proj, branch, deleteBranch, true)
+}
+
+func (s *WorkspaceService) remove(ctx context.Context, proj Project, branch string, deleteBranch, force bool) error {
+	s.logger.Debug("removing workspace", "project", proj.Name, "org", proj.Organisation, "branch", branch, "deleteBranch", deleteBranch, "force", force)
+
+	if err := s.config.CheckWritable("remove workspace " + proj.String() + ":" + branch); err != nil {
+		return err
+	}
 
 	workspacePath := s.WorkspacePath(proj, branch)
 
 	if _, err := os.Stat(workspacePath); os.IsNotExist(err) {
-		return fmt.Errorf("workspace does not exist: %s", workspacePath)
+		return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspacePath)
+	}
+
+	args := []string{"worktree", "remove", workspacePath}
+	if force {
+		args = append(args, "--force")
+	} else {
+		dirty, err := s.IsDirty(ctx, workspacePath)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			summary, err := s.changeSummary(ctx, workspacePath)
+			if err != nil {
+				s.logger.Debug("failed to summarize workspace changes", "path", workspacePath, "error", err)
+			}
+			return &DirtyWorkspaceError{Path: workspacePath, Summary: summary}
+		}
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "worktree", "remove", workspacePath)
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = proj.Path
 
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -233,6 +453,71 @@ func (s *WorkspaceService) Remove(ctx context.Context, proj Project, branch stri
 	return nil
 }
 
+// changeSummary returns the diffstat of the uncommitted changes of the
+// worktree at path, followed by the number of untracked files.
+func (s *WorkspaceService) changeSummary(ctx context.Context, path string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "HEAD", "--stat")
+	cmd.Dir = path
+
+	stat, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get diffstat of %s: %w", path, err)
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "ls-files", "-z", "--others", "--exclude-standard")
+	cmd.Dir = path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list untracked files of %s: %w", path, err)
+	}
+
+	summary := strings.TrimRight(string(stat), "\n")
+	if untracked := strings.Count(string(output), "\x00"); untracked > 0 {
+		noun := "files"
+		if untracked == 1 {
+			noun = "file"
+		}
+		if summary != "" {
+			summary += "\n"
+		}
+		summary += fmt.Sprintf(" %d untracked %s", untracked, noun)
+	}
+	return summary, nil
+}
+
+// IsMerged reports whether branch is merged into base in the project, i.e.
+// its head commit is an ancestor of base.
+func (s *WorkspaceService) IsMerged(ctx context.Context, proj Project, branch, base string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", "refs/heads/"+branch, base)
+	cmd.Dir = proj.Path
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check if %s is merged into %s: %w", branch, base, err)
+	}
+}
+
+// IsDirty reports whether the worktree at path has uncommitted changes,
+// including untracked files.
+func (s *WorkspaceService) IsDirty(ctx context.Context, path string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
+	cmd.Dir = path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to get status of %s: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
 // List returns all workspaces for the given project.
 func (s *WorkspaceService) List(ctx context.Context, proj Project) ([]Workspace, error) {
 	s.logger.Debug("listing workspaces", "project", proj.Name, "org", proj.Organisation)
@@ -291,10 +576,20 @@ func (s *WorkspaceService) parseWorktreeList(proj Project, output string) ([]Wor
 		workspaces = append(workspaces, *currentWorkspace)
 	}
 
-	// Filter to only include workspaces in our workspace directory
-	workspaceDir, err := filepath.EvalSymlinks(s.WorkspaceDir())
-	if err != nil {
-		workspaceDir = s.WorkspaceDir()
+	return s.filterWorkspaces(proj, workspaces), nil
+}
+
+// filterWorkspaces returns the worktrees of proj that are workspaces: those
+// checking out a branch in the workspace directory.
+func (s *WorkspaceService) filterWorkspaces(proj Project, workspaces []Workspace) []Workspace {
+	// Filter to only include workspaces in our workspace directory, or in
+	// the project directory with the bare layout
+	workspaceDir := s.WorkspaceDir()
+	if proj.IsBare() {
+		workspaceDir = proj.Path
+	}
+	if evalDir, err := filepath.EvalSymlinks(workspaceDir); err == nil {
+		workspaceDir = evalDir
 	}
 
 	var filteredWorkspaces []Workspace
@@ -309,5 +604,5 @@ func (s *WorkspaceService) parseWorktreeList(proj Project, output string) ([]Wor
 		}
 	}
 
-	return filteredWorkspaces, nil
+	return filteredWorkspaces
 }
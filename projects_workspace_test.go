@@ -0,0 +1,258 @@
+package projects
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemoveDirtyWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+
+	if err := os.WriteFile(filepath.Join(p.Path, "a"), []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-b", "main")
+	git("add", "a")
+	git("commit", "-m", "a")
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	if err := svc.Add(ctx, p, "feature"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	path := svc.WorkspacePath(p, "feature")
+	for name, content := range map[string]string{"a": "changed\n", "untracked": "u\n"} {
+		if err := os.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := svc.Remove(ctx, p, "feature", false)
+	if !errors.Is(err, ErrWorkspaceDirty) {
+		t.Fatalf("Remove() of dirty workspace error = %v, want ErrWorkspaceDirty", err)
+	}
+	var dirtyErr *DirtyWorkspaceError
+	if !errors.As(err, &dirtyErr) || !strings.Contains(dirtyErr.Summary, "1 file changed") || !strings.HasSuffix(dirtyErr.Summary, " 1 untracked file") {
+		t.Errorf("Remove() summary = %q, want the diffstat and untracked count", dirtyErr.Summary)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("dirty workspace should be kept: %v", err)
+	}
+
+	if err := svc.ForceRemove(ctx, p, "feature", false); err != nil {
+		t.Fatalf("ForceRemove() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("workspace should be removed, stat error = %v", err)
+	}
+}
+
+func TestReadList(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, p.Path, args...)
+	}
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	if got, err := svc.ReadList(p); err != nil || len(got) != 0 {
+		t.Fatalf("ReadList() of a non-Git directory = %v, %v, want none", got, err)
+	}
+
+	git("init", "-b", "main")
+	git("commit", "--allow-empty", "-m", "init")
+	for _, branch := range []string{"feature", "user/fix"} {
+		if err := svc.Add(ctx, p, branch); err != nil {
+			t.Fatalf("Add(%s) failed: %v", branch, err)
+		}
+	}
+	// Detached worktrees are not workspaces
+	git("worktree", "add", "--detach", filepath.Join(svc.ProjectWorkspaceDir(p), "detached"))
+
+	want, err := svc.List(ctx, p)
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+	got, err := svc.ReadList(p)
+	if err != nil {
+		t.Fatalf("ReadList() failed: %v", err)
+	}
+
+	branches := func(workspaces []Workspace) map[string]string {
+		m := make(map[string]string)
+		for _, ws := range workspaces {
+			path, _ := filepath.EvalSymlinks(ws.Path)
+			m[ws.Branch] = path
+		}
+		return m
+	}
+	if len(want) != 2 || !maps.Equal(branches(got), branches(want)) {
+		t.Errorf("ReadList() = %v, want %v", branches(got), branches(want))
+	}
+
+	scanned, err := svc.ScanWorkspaces()
+	if err != nil {
+		t.Fatalf("ScanWorkspaces() failed: %v", err)
+	}
+	got, ok := validScan(p, scanned[p.String()])
+	if !ok || !maps.Equal(branches(got), branches(want)) {
+		t.Errorf("ScanWorkspaces() = %v (valid %v), want %v", branches(got), ok, branches(want))
+	}
+
+	// Workspaces of another repository don't validate, to fall back on git
+	other := Project{Path: t.TempDir(), Organisation: "user", Name: "other"}
+	if err := os.Mkdir(filepath.Join(other.Path, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := validScan(other, scanned[p.String()]); ok {
+		t.Error("validScan() of workspaces of another repository should fail")
+	}
+
+	// Workspaces of the projects of other hosts are one level deeper
+	hosted := Project{Path: filepath.Join(root, "gitlab.com", "acme", "app"), Host: "gitlab.com", Organisation: "acme", Name: "app"}
+	if err := os.MkdirAll(hosted.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = hosted.Path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	if err := svc.Add(ctx, hosted, "feat"); err != nil {
+		t.Fatalf("Add(feat) of %s failed: %v", hosted.String(), err)
+	}
+
+	scanned, err = svc.ScanWorkspaces()
+	if err != nil {
+		t.Fatalf("ScanWorkspaces() failed: %v", err)
+	}
+	got, ok = validScan(hosted, scanned[hosted.String()])
+	if !ok || len(got) != 1 || got[0].Branch != "feat" {
+		t.Errorf("ScanWorkspaces() of %s = %v (valid %v), want feat", hosted.String(), branches(got), ok)
+	}
+	if sw := scanned[hosted.String()]; len(sw) == 1 && sw[0].Project.Host != "gitlab.com" {
+		t.Errorf("ScanWorkspaces() project host = %q, want gitlab.com", sw[0].Project.Host)
+	}
+	if len(scanned[p.String()]) != 2 {
+		t.Errorf("ScanWorkspaces() of %s = %d workspaces, want 2", p.String(), len(scanned[p.String()]))
+	}
+}
+
+func TestReadOnlyWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	ctx := context.Background()
+	root := t.TempDir()
+	p := Project{Path: filepath.Join(root, "user", "repo"), Organisation: "user", Name: "repo"}
+	if err := os.MkdirAll(p.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"commit", "-q", "--allow-empty", "-m", "init"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = p.Path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	readOnly := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true, ReadOnly: true}, &testLogger{})
+	if err := readOnly.Add(ctx, p, "feature"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("Add() in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if _, err := os.Stat(readOnly.WorkspaceDir()); !os.IsNotExist(err) {
+		t.Errorf("Add() in read-only mode created the workspace directory, stat error = %v", err)
+	}
+
+	svc := NewWorkspaceService(&Config{RootDir: root, SkipSubmodules: true}, &testLogger{})
+	if err := svc.Add(ctx, p, "feature"); err != nil {
+		t.Fatalf("Add() failed: %v", err)
+	}
+
+	// Reading still works, modifying doesn't
+	if workspaces, err := readOnly.List(ctx, p); err != nil || len(workspaces) != 1 {
+		t.Errorf("List() in read-only mode = %v, %v, want the workspace", workspaces, err)
+	}
+	if err := readOnly.Remove(ctx, p, "feature", true); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Remove() in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if err := readOnly.DeleteBranch(ctx, p, "feature"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteBranch() in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if err := readOnly.Ensure(ctx, p, "feature"); err != nil {
+		t.Errorf("Ensure() of an existing workspace in read-only mode failed: %v", err)
+	}
+	if err := readOnly.Ensure(ctx, p, "other"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Ensure() of a new workspace in read-only mode error = %v, want ErrReadOnly", err)
+	}
+	if _, err := os.Stat(svc.WorkspacePath(p, "feature")); err != nil {
+		t.Errorf("workspace should be kept: %v", err)
+	}
+}
+
+func TestEncodeBranch(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"main", "main"},
+		{"fix-bug", "fix-bug"},
+		{"#123", "#123"},
+		{"feat/x", "feat--x"},
+		{"feat/x-y/z", "feat--x-y--z"},
+		{"feat--x", "feat%2D%2Dx"},
+		{"feat-/x", "feat%2D--x"},
+		{"feat/-x", "feat--%2Dx"},
+		{"a---b", "a%2D%2D%2Db"},
+		{"100%", "100%25"},
+		{"a%2Db", "a%252Db"},
+	}
+
+	seen := make(map[string]string)
+	for _, tt := range tests {
+		got := encodeBranch(tt.branch)
+		if got != tt.want {
+			t.Errorf("encodeBranch(%q) = %q, want %q", tt.branch, got, tt.want)
+		}
+		if decoded := DecodeBranch(got); decoded != tt.branch {
+			t.Errorf("DecodeBranch(%q) = %q, want %q", got, decoded, tt.branch)
+		}
+		if other, ok := seen[got]; ok {
+			t.Errorf("encodeBranch(%q) = encodeBranch(%q) = %q", tt.branch, other, got)
+		}
+		seen[got] = tt.branch
+	}
+}
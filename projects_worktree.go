@@ -0,0 +1,228 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gfanton/projects/internal/project"
+)
+
+// ReadList returns the workspaces of proj like List, but read from the
+// worktree metadata of its Git directory (<gitdir>/worktrees/<id>) instead of
+// running 'git worktree list', which is much cheaper across many projects.
+func (s *WorkspaceService) ReadList(proj Project) ([]Workspace, error) {
+	gitDir, err := resolveGitDir(proj.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Workspace{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	worktreesDir := filepath.Join(gitDir, "worktrees")
+	entries, err := os.ReadDir(worktreesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Workspace{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read worktrees: %w", err)
+	}
+
+	var workspaces []Workspace
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		ws, err := readWorktree(proj, filepath.Join(worktreesDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	return s.filterWorkspaces(proj, workspaces), nil
+}
+
+// readWorktree reads the worktree of proj whose metadata is at dir: its
+// gitdir file holds the path of the .git file of the worktree and its HEAD
+// the checked out branch, left empty when detached.
+func readWorktree(proj Project, dir string) (Workspace, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "gitdir"))
+	if err != nil {
+		return Workspace{}, fmt.Errorf("failed to read worktree: %w", err)
+	}
+	dotGit := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(dotGit) {
+		dotGit = filepath.Join(dir, dotGit)
+	}
+
+	head, err := os.ReadFile(filepath.Join(dir, "HEAD"))
+	if err != nil {
+		return Workspace{}, fmt.Errorf("failed to read worktree HEAD: %w", err)
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		branch = ""
+	}
+
+	return Workspace{
+		Project: proj,
+		Branch:  branch,
+		Path:    filepath.Dir(filepath.Clean(dotGit)),
+	}, nil
+}
+
+// WorktreeBranch returns the branch checked out in the worktree at path,
+// reading the HEAD of its worktree metadata without running git, or false
+// when it is detached or not a worktree.
+func WorktreeBranch(path string) (string, bool) {
+	gitDir, err := resolveGitDir(path)
+	if err != nil {
+		return "", false
+	}
+	return headBranch(gitDir)
+}
+
+// headBranch returns the branch the HEAD of gitDir points to, or false when
+// it is detached or can't be read.
+func headBranch(gitDir string) (string, bool) {
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", false
+	}
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	return branch, ok && branch != ""
+}
+
+// resolveGitDir returns the Git directory of the repository at path, following
+// a .git file such as the one of the bare layout.
+func resolveGitDir(path string) (string, error) {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("invalid .git file: %s", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(path, gitDir)
+	}
+	return gitDir, nil
+}
+
+// ScanWorkspaces returns the workspaces found in the workspace directory,
+// keyed by the Project.String() of their project, without running git nor
+// reading the projects: each <org>/<name>/<branch> directory, or
+// <host>/<org>/<name>/<branch> for the projects of other hosts, holding a
+// .git file is a workspace, whose branch is read from the HEAD of its
+// worktree metadata. Detached worktrees are skipped. Projects with the bare
+// layout keep their workspaces in their own directory and are not part of
+// the result.
+func (s *WorkspaceService) ScanWorkspaces() (map[string][]ScannedWorkspace, error) {
+	workspaces := make(map[string][]ScannedWorkspace)
+	hosts := s.config.hostNames()
+
+	var paths []string
+	for _, pattern := range []string{"*/*/*/.git", "*/*/*/*/.git"} {
+		matches, err := filepath.Glob(filepath.Join(s.WorkspaceDir(), pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workspaces: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	for _, dotGit := range paths {
+		path := filepath.Dir(dotGit)
+		rel, err := filepath.Rel(s.WorkspaceDir(), filepath.Dir(path))
+		if err != nil {
+			continue
+		}
+
+		// <org>/<name> or <host>/<org>/<name>, the host level being told
+		// apart from an organisation the way the projects are walked
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		isHost := project.IsHostDir(s.config.RootDir, parts[0], parts[1], hosts)
+		var proj Project
+		switch {
+		case len(parts) == 2 && !isHost:
+			proj = Project{Organisation: parts[0], Name: parts[1]}
+		case len(parts) == 3 && isHost:
+			proj = Project{Host: parts[0], Organisation: parts[1], Name: parts[2]}
+		default:
+			continue
+		}
+		proj.Path = filepath.Join(s.config.RootDir, rel)
+
+		gitDir, err := resolveGitDir(path)
+		if err != nil {
+			s.logger.Debug("skipping invalid workspace", "path", path, "error", err)
+			continue
+		}
+
+		branch, ok := headBranch(gitDir)
+		if !ok {
+			continue
+		}
+
+		key := proj.String()
+		workspaces[key] = append(workspaces[key], ScannedWorkspace{Project: proj, Branch: branch, Path: path, GitDir: gitDir})
+	}
+
+	return workspaces, nil
+}
+
+// ScannedWorkspace is a workspace found by ScanWorkspaces.
+type ScannedWorkspace struct {
+	Project Project // project of the workspace, without its settings
+	Branch  string
+	Path    string
+	GitDir  string // worktree metadata directory, <gitdir>/worktrees/<id>
+}
+
+// validScan returns the scanned workspaces of proj, or false when one of
+// them is not a worktree of proj, for the caller to ask git instead.
+func validScan(proj Project, scanned []ScannedWorkspace) ([]Workspace, bool) {
+	workspaces := make([]Workspace, 0, len(scanned))
+	if len(scanned) == 0 {
+		return workspaces, true
+	}
+
+	gitDir, err := resolveGitDir(proj.Path)
+	if err != nil {
+		return nil, false
+	}
+	worktreesDir := filepath.Join(gitDir, "worktrees")
+
+	for _, sw := range scanned {
+		if !samePath(filepath.Dir(sw.GitDir), worktreesDir) {
+			return nil, false
+		}
+		workspaces = append(workspaces, Workspace{Project: proj, Branch: sw.Branch, Path: sw.Path})
+	}
+	return workspaces, true
+}
+
+// samePath reports whether a and b are the same path, resolving symlinks
+// when they differ.
+func samePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	evalA, errA := filepath.EvalSymlinks(a)
+	evalB, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && evalA == evalB
+}